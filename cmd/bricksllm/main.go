@@ -13,19 +13,38 @@ import (
 	auth "github.com/bricks-cloud/bricksllm/internal/authenticator"
 	"github.com/bricks-cloud/bricksllm/internal/cache"
 	"github.com/bricks-cloud/bricksllm/internal/config"
+	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/logger/zap"
 	"github.com/bricks-cloud/bricksllm/internal/manager"
 	"github.com/bricks-cloud/bricksllm/internal/message"
 	"github.com/bricks-cloud/bricksllm/internal/pii"
 	"github.com/bricks-cloud/bricksllm/internal/pii/amazon"
+	"github.com/bricks-cloud/bricksllm/internal/pii/local"
+	"github.com/bricks-cloud/bricksllm/internal/pii/presidio"
 	custompolicy "github.com/bricks-cloud/bricksllm/internal/policy/custom"
+	"github.com/bricks-cloud/bricksllm/internal/pricing"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/provider/deepinfra"
+	"github.com/bricks-cloud/bricksllm/internal/provider/deepseek"
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+	"github.com/bricks-cloud/bricksllm/internal/provider/groq"
+	"github.com/bricks-cloud/bricksllm/internal/provider/jina"
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
 	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openrouter"
+	"github.com/bricks-cloud/bricksllm/internal/provider/perplexity"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vertex"
 	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
+	"github.com/bricks-cloud/bricksllm/internal/provider/voyage"
+	"github.com/bricks-cloud/bricksllm/internal/provider/xai"
+	"github.com/bricks-cloud/bricksllm/internal/reconciliation"
 	"github.com/bricks-cloud/bricksllm/internal/recorder"
+	"github.com/bricks-cloud/bricksllm/internal/retention"
 	"github.com/bricks-cloud/bricksllm/internal/server/web/admin"
 	"github.com/bricks-cloud/bricksllm/internal/server/web/proxy"
 	"github.com/bricks-cloud/bricksllm/internal/storage/memdb"
@@ -49,7 +68,7 @@ func main() {
 
 	cfg, err := config.LoadConfig(log)
 	if err != nil {
-		log.Sugar().Fatalf("cannot parse environment variables: %v", err)
+		log.Sugar().Fatalf("cannot load config: %v", err)
 	}
 
 	err = telemetry.Init(cfg)
@@ -72,6 +91,21 @@ func main() {
 		log.Sugar().Fatalf("error creating custom providers table: %v", err)
 	}
 
+	err = store.AlterCustomProvidersTable()
+	if err != nil {
+		log.Sugar().Fatalf("error altering custom providers table: %v", err)
+	}
+
+	err = store.CreateModelRemappingsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating model remappings table: %v", err)
+	}
+
+	err = store.CreateModelPricingsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating model pricings table: %v", err)
+	}
+
 	err = store.CreateRoutesTable()
 	if err != nil {
 		log.Sugar().Fatalf("error creating routes table: %v", err)
@@ -112,6 +146,11 @@ func main() {
 		log.Sugar().Fatalf("error altering events table: %v", err)
 	}
 
+	err = store.CreateKeyIdAndCreatedAtIndexForEventsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating key id and created at index for events table: %v", err)
+	}
+
 	err = store.CreateProviderSettingsTable()
 	if err != nil {
 		log.Sugar().Fatalf("error creating provider settings table: %v", err)
@@ -127,6 +166,11 @@ func main() {
 		log.Sugar().Fatalf("error creating policies table: %v", err)
 	}
 
+	err = store.AlterPolicyTable()
+	if err != nil {
+		log.Sugar().Fatalf("error altering policies table: %v", err)
+	}
+
 	err = store.CreateEventsByDayTable()
 	if err != nil {
 		log.Sugar().Fatalf("error creating event aggregated by day table: %v", err)
@@ -152,6 +196,16 @@ func main() {
 		log.Sugar().Fatalf("error creating users table: %v", err)
 	}
 
+	err = store.CreateAuditLogsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating audit logs table: %v", err)
+	}
+
+	err = store.CreateKeyHistoryTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating key history table: %v", err)
+	}
+
 	err = store.CreateCreatedAtIndexForUsers()
 	if err != nil {
 		log.Sugar().Fatalf("error creating created at index for users table: %v", err)
@@ -168,12 +222,27 @@ func main() {
 	}
 	cpMemStore.Listen()
 
+	mrMemStore, err := memdb.NewModelRemappingsMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize model remappings memdb: %v", err)
+	}
+	mrMemStore.Listen()
+
+	mpMemStore, err := memdb.NewModelPricingsMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize model pricings memdb: %v", err)
+	}
+	mpMemStore.Listen()
+
 	rMemStore, err := memdb.NewRoutesMemDb(store, store, log, cfg.InMemoryDbUpdateInterval)
 	if err != nil {
 		log.Sugar().Fatalf("cannot initialize routes memdb: %v", err)
 	}
 	rMemStore.Listen()
 
+	retentionJob := retention.NewJob(store, store, log, cfg.RetentionJobInterval)
+	retentionJob.Start()
+
 	defaultRedisOption := func(cfg *config.Config, dbIndex int) *redis.Options {
 		return &redis.Options{
 			Addr:     fmt.Sprintf("%s:%s", cfg.RedisHosts, cfg.RedisPort),
@@ -272,6 +341,30 @@ func main() {
 		log.Sugar().Fatalf("error connecting to keys redis storage: %v", err)
 	}
 
+	sessionRedisStorage := redis.NewClient(defaultRedisOption(cfg, 11))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := sessionRedisStorage.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to session redis storage: %v", err)
+	}
+
+	threadRedisStorage := redis.NewClient(defaultRedisOption(cfg, 12))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := threadRedisStorage.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to thread redis storage: %v", err)
+	}
+
+	batchRedisStorage := redis.NewClient(defaultRedisOption(cfg, 13))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := batchRedisStorage.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to batch redis storage: %v", err)
+	}
+
 	rateLimitCache := redisStorage.NewCache(rateLimitRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	costLimitCache := redisStorage.NewCache(costLimitRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	costStorage := redisStorage.NewStore(costRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
@@ -283,18 +376,61 @@ func main() {
 	userCostStorage := redisStorage.NewStore(userCostRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	userAccessCache := redisStorage.NewAccessCache(userAccessRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 
+	reconciliationJob := reconciliation.NewJob(store, store, costStorage, costLimitCache, log, cfg.ReconciliationJobInterval)
+	reconciliationJob.Start()
+
 	psCache := redisStorage.NewProviderSettingsCache(providerSettingsRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	keysCache := redisStorage.NewKeysCache(keysRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	sessionStorage := redisStorage.NewSessionStorage(sessionRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout, cfg.SessionTtl)
+	threadStorage := redisStorage.NewThreadStorage(threadRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout, cfg.ThreadTtl)
+	batchStorage := redisStorage.NewBatchStorage(batchRedisStorage, cfg.RedisWriteTimeout, cfg.BatchTtl)
 
 	m := manager.NewManager(store, costLimitCache, rateLimitCache, accessCache, keysCache)
 	krm := manager.NewReportingManager(costStorage, store, store)
 	psm := manager.NewProviderSettingsManager(store, psCache)
 	cpm := manager.NewCustomProvidersManager(store, cpMemStore)
+	mrm := manager.NewModelRemappingsManager(store, mrMemStore, cfg.BuiltInModelRemappings)
+	mpm := manager.NewModelPricingsManager(store, mpMemStore)
 	rm := manager.NewRouteManager(store, store, rMemStore, psm)
 	pm := manager.NewPolicyManager(store, rMemStore)
 	um := manager.NewUserManager(store, store)
 
-	as, err := admin.NewAdminServer(log, *modePtr, m, krm, psm, cpm, rm, pm, um, cfg.AdminPass)
+	var anonymizer *event.Anonymizer
+	var es recorder.EventsStore = store
+	if cfg.AnonymizeEvents {
+		if len(cfg.AnonymizationSalt) == 0 {
+			log.Warn("anonymization salt is not set. hashed user ids can be reversed with a dictionary attack")
+		}
+
+		anonymizer = event.NewAnonymizer(cfg.AnonymizationSalt)
+		es = recorder.NewAnonymizedEventsStore(store, anonymizer)
+	}
+
+	if len(cfg.Region) != 0 {
+		es = recorder.NewRegionalEventsStore(es, cfg.Region)
+	}
+
+	c := cache.NewCache(apiCache)
+	em := manager.NewErasureManager(store, c, anonymizer)
+	cm := manager.NewComplianceManager(store, cfg.ComplianceExportSigningKey)
+
+	detector, err := amazon.NewClient(cfg.AmazonRequestTimeout, cfg.AmazonConnectionTimeout, log, cfg.AmazonRegion)
+	if err != nil {
+		log.Sugar().Infof("error when connecting to amazon: %v", err)
+	}
+
+	backends := map[string]pii.Detector{
+		pii.BackendLocal: local.NewDetector(),
+	}
+
+	if len(cfg.PresidioAnalyzerUrl) != 0 {
+		backends[pii.BackendPresidio] = presidio.NewClient(cfg.PresidioRequestTimeout, log, cfg.PresidioAnalyzerUrl)
+	}
+
+	scanner := pii.NewScanner(detector, backends)
+	cd := custompolicy.NewOpenAiDetector(cfg.CustomPolicyDetectionTimeout, cfg.OpenAiApiKey)
+
+	as, err := admin.NewAdminServer(log, *modePtr, m, krm, psm, cpm, mrm, mpm, rm, pm, um, em, cm, store, cfg, scanner, cd, cfg.AdminPass)
 	if err != nil {
 		log.Sugar().Fatalf("error creating admin http server: %v", err)
 	}
@@ -304,7 +440,10 @@ func main() {
 
 	as.Run()
 
-	ce := openai.NewCostEstimator(openai.OpenAiPerThousandTokenCost, tc)
+	ce := openai.NewCostEstimator(openai.OpenAiPerThousandTokenCost, tc, mpMemStore)
+
+	pricingJob := pricing.NewJob(cfg.PricingManifestUrl, ce, log, cfg.PricingSyncJobInterval)
+	pricingJob.Start()
 
 	atc, err := anthropic.NewTokenCounter()
 	if err != nil {
@@ -320,15 +459,55 @@ func main() {
 	aoe := azure.NewCostEstimator()
 	vllme := vllm.NewCostEstimator(vllmtc)
 	die := deepinfra.NewCostEstimator()
+	vxe := vertex.NewCostEstimator()
+
+	gtc, err := gemini.NewTokenCounter()
+	if err != nil {
+		log.Sugar().Fatalf("error creating gemini token counter: %v", err)
+	}
+
+	ge := gemini.NewCostEstimator(gtc)
+
+	ctc, err := cohere.NewTokenCounter()
+	if err != nil {
+		log.Sugar().Fatalf("error creating cohere token counter: %v", err)
+	}
+
+	coe := cohere.NewCostEstimator(ctc)
+
+	// openai compatible providers share a token counter.
+	comptc, err := compatible.NewTokenCounter()
+	if err != nil {
+		log.Sugar().Fatalf("error creating openai compatible token counter: %v", err)
+	}
+
+	me := mistral.NewCostEstimator(comptc)
+	gre := groq.NewCostEstimator(comptc)
+	pe := perplexity.NewCostEstimator(comptc)
+	dse := deepseek.NewCostEstimator(comptc)
+	xe := xai.NewCostEstimator(comptc)
+	ore := openrouter.NewCostEstimator(comptc)
 
-	v := validator.NewValidator(costLimitCache, rateLimitCache, costStorage)
+	vyp, err := voyage.NewPlugin()
+	if err != nil {
+		log.Sugar().Fatalf("error creating voyage plugin: %v", err)
+	}
+
+	provider.RegisterPlugin(vyp)
+
+	jnp, err := jina.NewPlugin()
+	if err != nil {
+		log.Sugar().Fatalf("error creating jina plugin: %v", err)
+	}
+
+	provider.RegisterPlugin(jnp)
+
+	v := validator.NewValidator(costLimitCache, rateLimitCache, costStorage, cfg.SpendLagTolerance)
 	uv := validator.NewUserValidator(userCostLimitCache, userRateLimitCache, userCostStorage)
 
-	rec := recorder.NewRecorder(costStorage, userCostStorage, costLimitCache, userCostLimitCache, ce, store)
+	rec := recorder.NewRecorder(costStorage, userCostStorage, costLimitCache, userCostLimitCache, ce, es, sessionStorage)
 	rlm := manager.NewRateLimitManager(rateLimitCache, userRateLimitCache)
-	a := auth.NewAuthenticator(psm, m, rm, store)
-
-	c := cache.NewCache(apiCache)
+	a := auth.NewAuthenticator(psm, m, rm, store, cfg.PreferredProviderSettingIds, cfg.QueueTimeout, cfg.UpstreamQuotaReserve, sessionStorage)
 
 	messageBus := message.NewMessageBus()
 	eventMessageChan := make(chan message.Message)
@@ -339,15 +518,7 @@ func main() {
 	eventConsumer := message.NewConsumer(eventMessageChan, log, 4, handler.HandleEventWithRequestAndResponse)
 	eventConsumer.StartEventMessageConsumers()
 
-	detector, err := amazon.NewClient(cfg.AmazonRequestTimeout, cfg.AmazonConnectionTimeout, log, cfg.AmazonRegion)
-	if err != nil {
-		log.Sugar().Infof("error when connecting to amazon: %v", err)
-	}
-
-	scanner := pii.NewScanner(detector)
-	cd := custompolicy.NewOpenAiDetector(cfg.CustomPolicyDetectionTimeout, cfg.OpenAiApiKey)
-
-	ps, err := proxy.NewProxyServer(log, *modePtr, *privacyPtr, c, m, rm, a, psm, cpm, store, ce, ace, aoe, v, rec, messageBus, rlm, cfg.ProxyTimeout, accessCache, userAccessCache, pm, scanner, cd, die, um, cfg.RemoveUserAgent)
+	ps, err := proxy.NewProxyServer(log, *modePtr, *privacyPtr, c, m, rm, a, psm, cpm, mrm, store, ce, ace, aoe, v, rec, messageBus, rlm, cfg.ProxyTimeout, accessCache, userAccessCache, pm, scanner, cd, die, vxe, ge, me, coe, gre, pe, dse, xe, ore, um, cfg.RemoveUserAgent, cfg.ClampMaxTokens, cfg.GetContextWindowSiblingModels(), sessionStorage, threadStorage, batchStorage)
 	if err != nil {
 		log.Sugar().Fatalf("error creating proxy http server: %v", err)
 	}
@@ -360,7 +531,12 @@ func main() {
 
 	eventConsumer.Stop()
 	cpMemStore.Stop()
+	mrMemStore.Stop()
+	mpMemStore.Stop()
 	rMemStore.Stop()
+	retentionJob.Stop()
+	reconciliationJob.Stop()
+	pricingJob.Stop()
 
 	log.Sugar().Infof("shutting down server...")
 
@@ -0,0 +1,257 @@
+// Command openapi generates the OpenAPI document served by the admin server
+// at /api/openapi.json. It merges the admin and proxy specifications in docs
+// and adds every route registered on the admin and proxy gin routers so that
+// the document never lags behind the handler code.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var routePattern = regexp.MustCompile(`router\.(GET|POST|PUT|PATCH|DELETE)\("([^"]+)"`)
+
+var paramPattern = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+type route struct {
+	method string
+	path   string
+	server string
+}
+
+func readSpec(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := map[string]any{}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("error when parsing %s: %v", path, err)
+	}
+
+	return normalize(spec).(map[string]any), nil
+}
+
+// normalize converts maps with non string keys, such as response status
+// codes, into maps that can be marshalled as JSON.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			v[key] = normalize(nested)
+		}
+
+		return v
+	case map[any]any:
+		converted := map[string]any{}
+		for key, nested := range v {
+			converted[fmt.Sprint(key)] = normalize(nested)
+		}
+
+		return converted
+	case []any:
+		for i, nested := range v {
+			v[i] = normalize(nested)
+		}
+
+		return v
+	}
+
+	return value
+}
+
+var templatePattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// templateKey identifies a path regardless of the names of its parameters.
+func templateKey(path string) string {
+	return templatePattern.ReplaceAllString(path, "{}")
+}
+
+func getMap(m map[string]any, key string) map[string]any {
+	if existing, ok := m[key].(map[string]any); ok {
+		return existing
+	}
+
+	created := map[string]any{}
+	m[key] = created
+	return created
+}
+
+// readRoutes returns the routes registered in a router definition file.
+func readRoutes(path, server string) ([]*route, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []*route{}
+	for _, match := range routePattern.FindAllStringSubmatch(string(data), -1) {
+		routes = append(routes, &route{
+			method: strings.ToLower(match[1]),
+			path:   paramPattern.ReplaceAllString(match[2], "{$1}"),
+			server: server,
+		})
+	}
+
+	return routes, nil
+}
+
+func getPathParams(path string) []any {
+	params := []any{}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, map[string]any{
+				"in":       "path",
+				"name":     strings.Trim(segment, "{}"),
+				"required": true,
+				"schema": map[string]any{
+					"type": "string",
+				},
+			})
+		}
+	}
+
+	return params
+}
+
+func merge(admin, proxy map[string]any, routes []*route) map[string]any {
+	spec := map[string]any{
+		"openapi": admin["openapi"],
+		"info": map[string]any{
+			"title":       "BricksLLM",
+			"description": "Admin and proxy APIs of BricksLLM. The admin API is served on port 8001 and the proxy API on port 8002.",
+			"version":     getMap(admin, "info")["version"],
+		},
+	}
+
+	paths := getMap(spec, "paths")
+	schemas := getMap(getMap(spec, "components"), "schemas")
+	tags := []any{}
+	seenTags := map[string]bool{}
+
+	for _, source := range []map[string]any{admin, proxy} {
+		for path, item := range getMap(source, "paths") {
+			paths[path] = item
+		}
+
+		components := getMap(source, "components")
+		for name, schema := range getMap(components, "schemas") {
+			schemas[name] = schema
+		}
+
+		for name, value := range components {
+			if name != "schemas" {
+				getMap(spec, "components")[name] = value
+			}
+		}
+
+		list, _ := source["tags"].([]any)
+		for _, tag := range list {
+			t, ok := tag.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			name, _ := t["name"].(string)
+			if !seenTags[name] {
+				seenTags[name] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	if len(tags) != 0 {
+		spec["tags"] = tags
+	}
+
+	documented := map[string]string{}
+	for path := range paths {
+		documented[templateKey(path)] = path
+	}
+
+	for _, r := range routes {
+		path := r.path
+		if existing, ok := documented[templateKey(path)]; ok {
+			path = existing
+		}
+
+		item := getMap(paths, path)
+		if _, ok := item[r.method]; ok {
+			continue
+		}
+
+		operation := map[string]any{
+			"summary": fmt.Sprintf("%s %s", strings.ToUpper(r.method), path),
+			"tags":    []any{r.server},
+			"responses": map[string]any{
+				"default": map[string]any{
+					"description": "Response of the " + r.server + " server.",
+				},
+			},
+			"x-generated": true,
+		}
+
+		if params := getPathParams(path); len(params) != 0 {
+			operation["parameters"] = params
+		}
+
+		item[r.method] = operation
+	}
+
+	return spec
+}
+
+func main() {
+	adminPath := flag.String("admin", "docs/admin.yaml", "path of the admin api specification")
+	proxyPath := flag.String("proxy", "docs/proxy.yaml", "path of the proxy api specification")
+	adminRoutes := flag.String("admin-routes", "internal/server/web/admin/admin.go", "path of the admin router definition")
+	proxyRoutes := flag.String("proxy-routes", "internal/server/web/proxy/proxy.go", "path of the proxy router definition")
+	out := flag.String("out", "internal/server/web/admin/openapi.json", "path of the generated document")
+	flag.Parse()
+
+	admin, err := readSpec(*adminPath)
+	if err != nil {
+		log.Fatalf("error reading admin specification: %v", err)
+	}
+
+	proxy, err := readSpec(*proxyPath)
+	if err != nil {
+		log.Fatalf("error reading proxy specification: %v", err)
+	}
+
+	routes := []*route{}
+	for server, path := range map[string]string{"admin": *adminRoutes, "proxy": *proxyRoutes} {
+		found, err := readRoutes(path, server)
+		if err != nil {
+			log.Fatalf("error reading %s routes: %v", server, err)
+		}
+
+		routes = append(routes, found...)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].path != routes[j].path {
+			return routes[i].path < routes[j].path
+		}
+
+		return routes[i].method < routes[j].method
+	})
+
+	data, err := json.MarshalIndent(merge(admin, proxy, routes), "", "  ")
+	if err != nil {
+		log.Fatalf("error marshalling openapi document: %v", err)
+	}
+
+	if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+		log.Fatalf("error writing openapi document: %v", err)
+	}
+}
@@ -1,11 +1,12 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"strings"
+	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/hasher"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 )
 
@@ -35,25 +37,179 @@ type keyStorage interface {
 }
 
 type Authenticator struct {
-	psm providerSettingsManager
-	kc  keysCache
-	rm  routesManager
-	ks  keyStorage
+	psm       providerSettingsManager
+	kc        keysCache
+	rm        routesManager
+	ks        keyStorage
+	preferred map[string]bool
+	balancer  *balancer
+	queue     *queue
+	qt        time.Duration
+	quotas    *quotas
+	pins      sessionPins
 }
 
-func NewAuthenticator(psm providerSettingsManager, kc keysCache, rm routesManager, ks keyStorage) *Authenticator {
+func NewAuthenticator(psm providerSettingsManager, kc keysCache, rm routesManager, ks keyStorage, preferredSettingIds []string, queueTimeout time.Duration, quotaReserve float64, sp sessionPins) *Authenticator {
+	preferred := map[string]bool{}
+	for _, id := range preferredSettingIds {
+		if len(id) != 0 {
+			preferred[id] = true
+		}
+	}
+
 	return &Authenticator{
-		psm: psm,
-		kc:  kc,
-		rm:  rm,
-		ks:  ks,
+		psm:       psm,
+		kc:        kc,
+		rm:        rm,
+		ks:        ks,
+		preferred: preferred,
+		balancer:  newBalancer(),
+		queue:     newQueue(),
+		qt:        queueTimeout,
+		quotas:    newQuotas(quotaReserve),
+		pins:      sp,
+	}
+}
+
+// Acquire counts a request in flight with a provider setting for keys
+// balancing the least loaded settings. The returned function releases it.
+func (a *Authenticator) Acquire(settingId string) func() {
+	return a.balancer.acquire(settingId)
+}
+
+// Queue waits for a slot of a provider setting capping its concurrent
+// requests. Requests of keys with higher priorities are dequeued first. An
+// error is returned if no slot frees up within the queue timeout, or right
+// away if the setting rejects requests over its limit.
+func (a *Authenticator) Queue(ctx context.Context, kc *key.ResponseKey, setting *provider.Setting) (func(), error) {
+	if setting == nil || setting.MaxConcurrentRequests <= 0 {
+		return func() {}, nil
+	}
+
+	tags := []string{
+		fmt.Sprintf("provider:%s", setting.Provider),
+		fmt.Sprintf("priority:%s", kc.Priority),
+	}
+
+	return a.acquire(ctx, kc, setting, setting.Id, setting.MaxConcurrentRequests, tags)
+}
+
+// QueueModel waits for a slot of a model of a provider setting capping the
+// concurrent requests of the model, so that a single heavy model cannot starve
+// the other models of the setting.
+func (a *Authenticator) QueueModel(ctx context.Context, kc *key.ResponseKey, setting *provider.Setting, model string) (func(), error) {
+	if setting == nil || setting.ModelConcurrencyLimit(model) <= 0 {
+		return func() {}, nil
+	}
+
+	tags := []string{
+		fmt.Sprintf("provider:%s", setting.Provider),
+		fmt.Sprintf("model:%s", model),
+		fmt.Sprintf("priority:%s", kc.Priority),
+	}
+
+	return a.acquire(ctx, kc, setting, setting.Id+"/"+model, setting.ModelConcurrencyLimit(model), tags)
+}
+
+func (a *Authenticator) acquire(ctx context.Context, kc *key.ResponseKey, setting *provider.Setting, slot string, limit int, tags []string) (func(), error) {
+	if setting.ShouldRejectOverflow() {
+		release, ok := a.queue.tryAcquire(slot, limit)
+		if !ok {
+			telemetry.Incr("bricksllm.authenticator.queue.rejected", tags, 1)
+			return nil, errors.New("concurrency limit is reached")
+		}
+
+		return release, nil
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, a.qt)
+	defer cancel()
+
+	release, waited, err := a.queue.acquire(ctxTimeout, slot, limit, kc.PriorityRank())
+	if err != nil {
+		telemetry.Incr("bricksllm.authenticator.queue.timeout", tags, 1)
+		return nil, err
+	}
+
+	if waited > 0 {
+		telemetry.Incr("bricksllm.authenticator.queue.queued", tags, 1)
+		telemetry.Timing("bricksllm.authenticator.queue.wait_latency", waited, tags, 1)
+	}
+
+	return release, nil
+}
+
+// RecordQuota records the remaining upstream quotas of a provider setting
+// reported by the rate limit headers of an upstream response.
+func (a *Authenticator) RecordQuota(setting *provider.Setting, header http.Header) {
+	if setting == nil {
+		return
+	}
+
+	a.quotas.record(setting, header)
+}
+
+// WaitForQuota throttles requests of a provider setting whose upstream quotas
+// are nearly exhausted until the quotas reset. An error is returned if they do
+// not reset within the queue timeout.
+func (a *Authenticator) WaitForQuota(ctx context.Context, setting *provider.Setting) error {
+	if setting == nil {
+		return nil
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, a.qt)
+	defer cancel()
+
+	tags := []string{
+		fmt.Sprintf("provider:%s", setting.Provider),
+	}
+
+	waited, err := a.quotas.wait(ctxTimeout, setting)
+	if err != nil {
+		telemetry.Incr("bricksllm.authenticator.upstream_quota.exhausted", tags, 1)
+		return err
+	}
+
+	if waited > 0 {
+		telemetry.Incr("bricksllm.authenticator.upstream_quota.throttled", tags, 1)
+		telemetry.Timing("bricksllm.authenticator.upstream_quota.throttle_latency", waited, tags, 1)
+	}
+
+	return nil
+}
+
+// preferRegional moves provider settings preferred by the gateway's region to
+// the front while keeping the relative order of the rest. If rotation is
+// enabled, only preferred settings are rotated through when any are present.
+func (a *Authenticator) preferRegional(settings []*provider.Setting) ([]*provider.Setting, int) {
+	if len(a.preferred) == 0 {
+		return settings, len(settings)
+	}
+
+	ordered := []*provider.Setting{}
+	rest := []*provider.Setting{}
+	for _, setting := range settings {
+		if a.preferred[setting.Id] {
+			ordered = append(ordered, setting)
+			continue
+		}
+
+		rest = append(rest, setting)
 	}
+
+	count := len(ordered)
+	if count == 0 {
+		count = len(settings)
+	}
+
+	return append(ordered, rest...), count
 }
 
 func getApiKey(req *http.Request) (string, error) {
 	list := []string{
 		req.Header.Get("x-api-key"),
 		req.Header.Get("api-key"),
+		req.Header.Get("x-goog-api-key"),
 	}
 
 	split := strings.Split(req.Header.Get("Authorization"), " ")
@@ -62,6 +218,11 @@ func getApiKey(req *http.Request) (string, error) {
 		list = append(list, split[1])
 	}
 
+	// gemini clients can send api keys as the key query param.
+	if strings.HasPrefix(req.URL.Path, "/api/providers/gemini") {
+		list = append(list, req.URL.Query().Get("key"))
+	}
+
 	for _, key := range list {
 		if len(key) != 0 {
 			return key, nil
@@ -71,14 +232,63 @@ func getApiKey(req *http.Request) (string, error) {
 	return "", internal_errors.NewAuthError("api key not found in header")
 }
 
+// setAnthropicBetaHeader merges the beta features configured on an Anthropic
+// provider setting, such as prompt caching, with the features requested by
+// the client in the anthropic-beta header.
+func setAnthropicBetaHeader(req *http.Request, betaFeatures string) {
+	if len(betaFeatures) == 0 {
+		return
+	}
+
+	features := []string{}
+	seen := map[string]bool{}
+	for _, feature := range strings.Split(req.Header.Get("anthropic-beta")+","+betaFeatures, ",") {
+		trimmed := strings.TrimSpace(feature)
+		if len(trimmed) == 0 || seen[trimmed] {
+			continue
+		}
+
+		seen[trimmed] = true
+		features = append(features, trimmed)
+	}
+
+	req.Header.Set("anthropic-beta", strings.Join(features, ","))
+}
+
+// getNativeAuthConfig returns the auth scheme of natively supported
+// providers that authenticate with a static api key.
+func getNativeAuthConfig(uri string) *signer.Config {
+	if strings.HasPrefix(uri, "/api/providers/anthropic") {
+		return &signer.Config{Scheme: signer.SchemeApiKeyHeader, HeaderName: "x-api-key"}
+	}
+
+	if strings.HasPrefix(uri, "/api/providers/azure") {
+		return &signer.Config{Scheme: signer.SchemeApiKeyHeader, HeaderName: "api-key"}
+	}
+
+	if strings.HasPrefix(uri, "/api/providers/gemini") {
+		return &signer.Config{Scheme: signer.SchemeApiKeyHeader, HeaderName: "x-goog-api-key"}
+	}
+
+	return &signer.Config{Scheme: signer.SchemeBearer}
+}
+
 func rewriteHttpAuthHeader(req *http.Request, setting *provider.Setting) error {
 	uri := req.URL.RequestURI()
 	if strings.HasPrefix(uri, "/api/routes") {
 		return nil
 	}
 
+	// vertex ai and custom providers are signed by their proxy handlers since
+	// their auth schemes can require fetching access tokens.
+	if strings.HasPrefix(uri, "/api/providers/vertexai") || strings.HasPrefix(uri, "/api/custom/providers") {
+		return nil
+	}
+
 	apiKey := setting.GetParam("apikey")
 
+	// vllm upstreams, including local servers such as Ollama, usually do not
+	// require api keys.
 	if strings.HasPrefix(uri, "/api/providers/vllm") {
 		if len(apiKey) != 0 {
 			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
@@ -94,17 +304,18 @@ func rewriteHttpAuthHeader(req *http.Request, setting *provider.Setting) error {
 		return errors.New("api key is empty in provider setting")
 	}
 
-	if strings.HasPrefix(uri, "/api/providers/anthropic") {
-		req.Header.Set("x-api-key", apiKey)
-		return nil
+	s, err := signer.NewStaticSigner(getNativeAuthConfig(uri), apiKey)
+	if err != nil {
+		return err
 	}
 
-	if strings.HasPrefix(uri, "/api/providers/azure") {
-		req.Header.Set("api-key", apiKey)
-		return nil
+	if err := s.Sign(req.Context(), req); err != nil {
+		return err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	if strings.HasPrefix(uri, "/api/providers/anthropic") {
+		setAnthropicBetaHeader(req, setting.GetParam("betaFeatures"))
+	}
 
 	return nil
 }
@@ -155,30 +366,85 @@ func (a *Authenticator) getProviderSettingsThatCanAccessCustomRoute(path string,
 	return selected
 }
 
-func canAccessPath(provider string, path string) bool {
-	if provider == "bedrock" && !strings.HasPrefix(path, "/api/providers/bedrock") {
+func canAccessPath(providerName string, path string) bool {
+	if providerName == "bedrock" && !strings.HasPrefix(path, "/api/providers/bedrock") {
+		return false
+	}
+
+	if providerName == "openai" && !strings.HasPrefix(path, "/api/providers/openai") {
+		return false
+	}
+
+	if providerName == "azure" && !strings.HasPrefix(path, "/api/providers/azure/openai") {
+		return false
+	}
+
+	if providerName == "anthropic" && !strings.HasPrefix(path, "/api/providers/anthropic") {
+		return false
+	}
+
+	if providerName == "vllm" && !strings.HasPrefix(path, "/api/providers/vllm") {
+		return false
+	}
+
+	if providerName == "vertexai" && !strings.HasPrefix(path, "/api/providers/vertexai") {
+		return false
+	}
+
+	if providerName == "gemini" && !strings.HasPrefix(path, "/api/providers/gemini") {
+		return false
+	}
+
+	if providerName == "mistral" && !strings.HasPrefix(path, "/api/providers/mistral") {
+		return false
+	}
+
+	if providerName == "cohere" && !strings.HasPrefix(path, "/api/providers/cohere") {
+		return false
+	}
+
+	if providerName == "groq" && !strings.HasPrefix(path, "/api/providers/groq") {
+		return false
+	}
+
+	if providerName == "perplexity" && !strings.HasPrefix(path, "/api/providers/perplexity") {
 		return false
 	}
 
-	if provider == "openai" && !strings.HasPrefix(path, "/api/providers/openai") {
+	if providerName == "deepseek" && !strings.HasPrefix(path, "/api/providers/deepseek") {
 		return false
 	}
 
-	if provider == "azure" && !strings.HasPrefix(path, "/api/providers/azure/openai") {
+	if providerName == "xai" && !strings.HasPrefix(path, "/api/providers/xai") {
 		return false
 	}
 
-	if provider == "anthropic" && !strings.HasPrefix(path, "/api/providers/anthropic") {
+	if providerName == "openrouter" && !strings.HasPrefix(path, "/api/providers/openrouter") {
 		return false
 	}
 
-	if provider == "vllm" && !strings.HasPrefix(path, "/api/providers/vllm") {
+	if p := provider.GetPlugin(providerName); p != nil && !strings.HasPrefix(path, p.RoutePrefix()) {
 		return false
 	}
 
 	return true
 }
 
+// isUnifiedPath returns true for endpoints serving requests with the provider
+// of the requested model. Their provider settings are selected and their
+// requests are signed by the proxy once the request body is parsed.
+func isRoutePath(path string) bool {
+	return strings.HasPrefix(path, "/api/routes")
+}
+
+func isUnifiedPath(path string) bool {
+	return path == "/api/v1/chat/completions"
+}
+
+func isGatewayPath(path string) bool {
+	return strings.HasPrefix(path, "/api/tokens") || strings.HasPrefix(path, "/api/costs") || path == "/v1/models"
+}
+
 type notFoundError interface {
 	Error() string
 	NotFound()
@@ -262,10 +528,59 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 		}
 	}
 
+	if isUnifiedPath(req.URL.Path) {
+		unified := []*provider.Setting{}
+		for _, setting := range allSettings {
+			if setting.Provider == "openai" || setting.Provider == "anthropic" {
+				unified = append(unified, setting)
+			}
+		}
+
+		if len(unified) == 0 {
+			return nil, nil, internal_errors.NewAuthError(fmt.Sprintf("provider setting not found for key %s", anonymize(raw)))
+		}
+
+		unified, _ = a.preferRegional(unified)
+		return key, unified, nil
+	}
+
+	if isGatewayPath(req.URL.Path) {
+		if len(allSettings) == 0 {
+			return nil, nil, internal_errors.NewAuthError(fmt.Sprintf("provider setting not found for key %s", anonymize(raw)))
+		}
+
+		allSettings, _ = a.preferRegional(allSettings)
+		return key, allSettings, nil
+	}
+
 	if len(selected) != 0 {
+		ordered, candidates := a.preferRegional(selected)
+		selected = ordered
+
 		used := selected[0]
-		if key.RotationEnabled {
-			used = selected[rand.Intn(len(selected))]
+		if key.RotationEnabled || len(key.RotationStrategy) != 0 {
+			// settings with nearly exhausted upstream quotas are only used
+			// once the quotas of the other candidates are exhausted too.
+			preferred, available := a.quotas.prefer(selected[:candidates])
+			copy(selected, preferred)
+			if available != 0 && available < candidates {
+				telemetry.Incr("bricksllm.authenticator.upstream_quota.rerouted", nil, 1)
+				candidates = available
+			}
+
+			// requests of a session stick to the setting that served its
+			// earlier requests.
+			idx, pinned := a.pinnedIndex(req, key, selected[:candidates])
+			if !pinned {
+				idx = a.balancer.pick(key, selected[:candidates])
+				a.pin(req, key, selected[idx])
+			}
+
+			used = selected[idx]
+
+			// the setting used for the request is moved to the front so that
+			// handlers signing requests themselves pick the same setting.
+			selected[0], selected[idx] = selected[idx], selected[0]
 		}
 
 		err := rewriteHttpAuthHeader(req, used)
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+// balancer selects the provider setting used by keys rotating through a pool
+// of provider settings. Round robin positions and in flight requests are
+// tracked per gateway instance.
+type balancer struct {
+	positions sync.Map
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+func newBalancer() *balancer {
+	return &balancer{
+		inFlight: map[string]int{},
+	}
+}
+
+// pick returns the index of the candidate setting used for a request of a
+// key.
+func (b *balancer) pick(k *key.ResponseKey, candidates []*provider.Setting) int {
+	if len(candidates) <= 1 {
+		return 0
+	}
+
+	switch k.RotationStrategy {
+	case key.RotationStrategyRoundRobin:
+		raw, _ := b.positions.LoadOrStore(k.KeyId, &atomic.Uint64{})
+		position := raw.(*atomic.Uint64).Add(1) - 1
+		return int(position % uint64(len(candidates)))
+	case key.RotationStrategyLeastLoaded:
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		// ties are broken randomly so that idle settings share traffic.
+		least := []int{}
+		min := -1
+		for idx, setting := range candidates {
+			load := b.inFlight[setting.Id]
+			if min == -1 || load < min {
+				min = load
+				least = []int{idx}
+				continue
+			}
+
+			if load == min {
+				least = append(least, idx)
+			}
+		}
+
+		return least[rand.Intn(len(least))]
+	}
+
+	return rand.Intn(len(candidates))
+}
+
+// acquire counts a request in flight with a provider setting until the
+// returned function is called.
+func (b *balancer) acquire(settingId string) func() {
+	b.mu.Lock()
+	b.inFlight[settingId]++
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			b.inFlight[settingId]--
+			if b.inFlight[settingId] <= 0 {
+				delete(b.inFlight, settingId)
+			}
+		})
+	}
+}
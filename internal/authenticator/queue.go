@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// waiter is a request queued for a provider setting at its concurrency limit.
+type waiter struct {
+	rank  int
+	seq   uint64
+	ready chan struct{}
+}
+
+// queue caps the requests in flight with provider settings. Requests over
+// the cap wait for a slot, which is handed to the queued request with the
+// highest priority rank and then to the earliest one. Slots are tracked per
+// gateway instance and keyed by provider setting ids, or by setting ids and
+// models for model concurrency limits.
+type queue struct {
+	mu       sync.Mutex
+	seq      uint64
+	inFlight map[string]int
+	waiting  map[string][]*waiter
+}
+
+func newQueue() *queue {
+	return &queue{
+		inFlight: map[string]int{},
+		waiting:  map[string][]*waiter{},
+	}
+}
+
+// acquire waits for a slot of a provider setting until the context is done.
+// It returns a function releasing the slot and how long the request waited.
+func (q *queue) acquire(ctx context.Context, settingId string, limit, rank int) (func(), time.Duration, error) {
+	start := time.Now()
+
+	q.mu.Lock()
+	if q.inFlight[settingId] < limit && len(q.waiting[settingId]) == 0 {
+		q.inFlight[settingId]++
+		q.mu.Unlock()
+
+		return q.releaser(settingId), 0, nil
+	}
+
+	q.seq++
+	w := &waiter{rank: rank, seq: q.seq, ready: make(chan struct{})}
+	q.enqueue(settingId, w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return q.releaser(settingId), time.Since(start), nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// the slot might have been handed over while the context was done.
+	select {
+	case <-w.ready:
+		return q.releaser(settingId), time.Since(start), nil
+	default:
+	}
+
+	q.remove(settingId, w)
+
+	return nil, time.Since(start), ctx.Err()
+}
+
+// tryAcquire takes a slot of a provider setting without waiting. False is
+// returned if none are free.
+func (q *queue) tryAcquire(settingId string, limit int) (func(), bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.inFlight[settingId] >= limit || len(q.waiting[settingId]) != 0 {
+		return nil, false
+	}
+
+	q.inFlight[settingId]++
+
+	return q.releaser(settingId), true
+}
+
+// enqueue inserts a waiter after the waiters with the same or a higher rank.
+func (q *queue) enqueue(settingId string, w *waiter) {
+	waiting := q.waiting[settingId]
+
+	idx := len(waiting)
+	for i, queued := range waiting {
+		if queued.rank < w.rank {
+			idx = i
+			break
+		}
+	}
+
+	waiting = append(waiting, nil)
+	copy(waiting[idx+1:], waiting[idx:])
+	waiting[idx] = w
+
+	q.waiting[settingId] = waiting
+}
+
+func (q *queue) remove(settingId string, w *waiter) {
+	waiting := q.waiting[settingId]
+	for i, queued := range waiting {
+		if queued == w {
+			q.waiting[settingId] = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+
+	if len(q.waiting[settingId]) == 0 {
+		delete(q.waiting, settingId)
+	}
+}
+
+// releaser returns a function handing the slot of a request to the next
+// queued request or freeing it.
+func (q *queue) releaser(settingId string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+
+			if waiting := q.waiting[settingId]; len(waiting) != 0 {
+				next := waiting[0]
+				q.remove(settingId, next)
+				close(next.ready)
+				return
+			}
+
+			q.inFlight[settingId]--
+			if q.inFlight[settingId] <= 0 {
+				delete(q.inFlight, settingId)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// rateLimitHeaders are the names of the rate limit headers of upstream
+// responses of a provider.
+type rateLimitHeaders struct {
+	limitRequests     string
+	remainingRequests string
+	resetRequests     string
+	limitTokens       string
+	remainingTokens   string
+	resetTokens       string
+}
+
+var upstreamRateLimitHeaders = map[string]*rateLimitHeaders{
+	"openai": {
+		limitRequests:     "x-ratelimit-limit-requests",
+		remainingRequests: "x-ratelimit-remaining-requests",
+		resetRequests:     "x-ratelimit-reset-requests",
+		limitTokens:       "x-ratelimit-limit-tokens",
+		remainingTokens:   "x-ratelimit-remaining-tokens",
+		resetTokens:       "x-ratelimit-reset-tokens",
+	},
+	"anthropic": {
+		limitRequests:     "anthropic-ratelimit-requests-limit",
+		remainingRequests: "anthropic-ratelimit-requests-remaining",
+		resetRequests:     "anthropic-ratelimit-requests-reset",
+		limitTokens:       "anthropic-ratelimit-tokens-limit",
+		remainingTokens:   "anthropic-ratelimit-tokens-remaining",
+		resetTokens:       "anthropic-ratelimit-tokens-reset",
+	},
+}
+
+// quota is the remaining upstream quota of a rate limit of a provider setting.
+type quota struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// saturated reports whether the remaining quota is within the reserved
+// fraction of the limit before the quota resets.
+func (q *quota) saturated(now time.Time, reserve float64) bool {
+	if q.limit <= 0 || !now.Before(q.reset) {
+		return false
+	}
+
+	return q.remaining <= 0 || float64(q.remaining) <= reserve*float64(q.limit)
+}
+
+// quotas tracks the remaining upstream request and token quotas of provider
+// settings reported by the rate limit headers of their responses. Quotas are
+// tracked per gateway instance.
+type quotas struct {
+	mu       sync.Mutex
+	reserve  float64
+	requests map[string]*quota
+	tokens   map[string]*quota
+}
+
+func newQuotas(reserve float64) *quotas {
+	return &quotas{
+		reserve:  reserve,
+		requests: map[string]*quota{},
+		tokens:   map[string]*quota{},
+	}
+}
+
+// record updates the quotas of a provider setting from the headers of an
+// upstream response and emits the remaining quotas.
+func (qs *quotas) record(setting *provider.Setting, header http.Header) {
+	names, ok := upstreamRateLimitHeaders[setting.Provider]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	requests, rok := parseQuota(now, header.Get(names.limitRequests), header.Get(names.remainingRequests), header.Get(names.resetRequests))
+	tokens, tok := parseQuota(now, header.Get(names.limitTokens), header.Get(names.remainingTokens), header.Get(names.resetTokens))
+	if !rok && !tok {
+		return
+	}
+
+	tags := []string{
+		fmt.Sprintf("provider:%s", setting.Provider),
+		fmt.Sprintf("setting_id:%s", setting.Id),
+	}
+
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	if rok {
+		qs.requests[setting.Id] = requests
+		telemetry.Histogram("bricksllm.authenticator.upstream_quota.remaining_requests", float64(requests.remaining), tags, 1)
+	}
+
+	if tok {
+		qs.tokens[setting.Id] = tokens
+		telemetry.Histogram("bricksllm.authenticator.upstream_quota.remaining_tokens", float64(tokens.remaining), tags, 1)
+	}
+}
+
+// resetsAt returns when the saturated quotas of a provider setting reset.
+// False is returned if none of its quotas are saturated.
+func (qs *quotas) resetsAt(settingId string) (time.Time, bool) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	now := time.Now()
+	var reset time.Time
+	saturated := false
+	for _, q := range []*quota{qs.requests[settingId], qs.tokens[settingId]} {
+		if q == nil || !q.saturated(now, qs.reserve) {
+			continue
+		}
+
+		saturated = true
+		if q.reset.After(reset) {
+			reset = q.reset
+		}
+	}
+
+	return reset, saturated
+}
+
+// prefer moves provider settings with unsaturated quotas to the front while
+// keeping the relative order of the rest. It returns the number of settings
+// with unsaturated quotas.
+func (qs *quotas) prefer(settings []*provider.Setting) ([]*provider.Setting, int) {
+	available := []*provider.Setting{}
+	rest := []*provider.Setting{}
+	for _, setting := range settings {
+		if _, saturated := qs.resetsAt(setting.Id); saturated {
+			rest = append(rest, setting)
+			continue
+		}
+
+		available = append(available, setting)
+	}
+
+	return append(available, rest...), len(available)
+}
+
+// wait blocks until the saturated quotas of a provider setting reset. An
+// error is returned if they do not reset before the context is done.
+func (qs *quotas) wait(ctx context.Context, setting *provider.Setting) (time.Duration, error) {
+	reset, saturated := qs.resetsAt(setting.Id)
+	if !saturated {
+		return 0, nil
+	}
+
+	dur := time.Until(reset)
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(reset) {
+		return dur, fmt.Errorf("upstream quota of provider setting %s resets in %s", setting.Id, dur.Round(time.Millisecond))
+	}
+
+	timer := time.NewTimer(dur)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return dur, nil
+	case <-ctx.Done():
+		return dur, ctx.Err()
+	}
+}
+
+// parseQuota parses the limit, remaining quota and reset of an upstream rate
+// limit. Resets are durations such as 6m0s or RFC 3339 timestamps.
+func parseQuota(now time.Time, limit, remaining, reset string) (*quota, bool) {
+	if len(limit) == 0 || len(remaining) == 0 || len(reset) == 0 {
+		return nil, false
+	}
+
+	l, err := strconv.Atoi(limit)
+	if err != nil {
+		return nil, false
+	}
+
+	r, err := strconv.Atoi(remaining)
+	if err != nil {
+		return nil, false
+	}
+
+	if dur, err := time.ParseDuration(reset); err == nil {
+		return &quota{limit: l, remaining: r, reset: now.Add(dur)}, true
+	}
+
+	if at, err := time.Parse(time.RFC3339, reset); err == nil {
+		return &quota{limit: l, remaining: r, reset: at}, true
+	}
+
+	return nil, false
+}
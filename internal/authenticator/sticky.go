@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+type sessionPins interface {
+	GetPinnedSetting(keyId, sessionId string) (string, error)
+	PinSetting(keyId, sessionId, settingId string) error
+}
+
+// pinnedIndex returns the index of the candidate provider setting that served
+// earlier requests of the session of a request. Requests of a session are
+// kept on the same setting so that provider side prompt caches are reused and
+// behavior stays consistent mid conversation. False is returned if the request
+// has no session or its setting is not a candidate anymore.
+//
+// Pins only apply to requests of provider paths. Route steps pick the setting
+// of their provider, so requests of custom routes are neither pinned nor
+// placed by pins. Pinned settings outside the preferred regional settings or
+// with exhausted upstream quotas are not candidates and are repinned.
+func (a *Authenticator) pinnedIndex(req *http.Request, k *key.ResponseKey, candidates []*provider.Setting) (int, bool) {
+	sessionId := req.Header.Get("X-SESSION-ID")
+	if a.pins == nil || len(sessionId) == 0 || len(candidates) <= 1 || isRoutePath(req.URL.Path) {
+		return 0, false
+	}
+
+	settingId, err := a.pins.GetPinnedSetting(k.KeyId, sessionId)
+	if err != nil {
+		telemetry.Incr("bricksllm.authenticator.sticky_session.get_pinned_setting_error", nil, 1)
+		return 0, false
+	}
+
+	if len(settingId) == 0 {
+		return 0, false
+	}
+
+	for idx, setting := range candidates {
+		if setting.Id == settingId {
+			telemetry.Incr("bricksllm.authenticator.sticky_session.hit", nil, 1)
+			return idx, true
+		}
+	}
+
+	telemetry.Incr("bricksllm.authenticator.sticky_session.repinned", nil, 1)
+	return 0, false
+}
+
+// pin records the provider setting serving a request for later requests of
+// its session.
+func (a *Authenticator) pin(req *http.Request, k *key.ResponseKey, setting *provider.Setting) {
+	sessionId := req.Header.Get("X-SESSION-ID")
+	if a.pins == nil || len(sessionId) == 0 || isRoutePath(req.URL.Path) {
+		return
+	}
+
+	if err := a.pins.PinSetting(k.KeyId, sessionId, setting.Id); err != nil {
+		telemetry.Incr("bricksllm.authenticator.sticky_session.pin_setting_error", nil, 1)
+	}
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKeysCache struct {
+	k *key.ResponseKey
+}
+
+func (kc *staticKeysCache) GetKeyViaCache(hash string) (*key.ResponseKey, error) {
+	return kc.k, nil
+}
+
+type staticSettingsManager struct {
+	providerSettingsManager
+	settings map[string]*provider.Setting
+}
+
+func (psm *staticSettingsManager) GetSettingViaCache(id string) (*provider.Setting, error) {
+	return psm.settings[id], nil
+}
+
+type staticRoutesManager struct {
+	r *route.Route
+}
+
+func (rm *staticRoutesManager) GetRouteFromMemDb(path string) *route.Route {
+	return rm.r
+}
+
+type memoryPins struct {
+	pins map[string]string
+}
+
+func (p *memoryPins) GetPinnedSetting(keyId, sessionId string) (string, error) {
+	return p.pins[sessionId], nil
+}
+
+func (p *memoryPins) PinSetting(keyId, sessionId, settingId string) error {
+	p.pins[sessionId] = settingId
+	return nil
+}
+
+func TestStickySessions(t *testing.T) {
+	psm := &staticSettingsManager{settings: map[string]*provider.Setting{
+		"a": {Id: "a", Provider: "openai", Setting: map[string]string{"apikey": "a"}},
+		"b": {Id: "b", Provider: "openai", Setting: map[string]string{"apikey": "b"}},
+		"c": {Id: "c", Provider: "anthropic", Setting: map[string]string{"apikey": "c"}},
+	}}
+
+	kc := &staticKeysCache{k: &key.ResponseKey{
+		KeyId:            "key",
+		SettingIds:       []string{"a", "b", "c"},
+		RotationStrategy: key.RotationStrategyRoundRobin,
+	}}
+
+	rm := &staticRoutesManager{r: &route.Route{
+		KeyIds: []string{"key"},
+		Steps:  []*route.Step{{Provider: "anthropic"}, {Provider: "openai"}},
+	}}
+
+	tests := []struct {
+		name      string
+		path      string
+		preferred []string
+		pinned    string
+		used      string
+		pins      map[string]string
+	}{
+		{
+			name:   "pinned",
+			path:   "/api/providers/openai/v1/chat/completions",
+			pinned: "b",
+			used:   "b",
+			pins:   map[string]string{"session": "b"},
+		},
+		{
+			name: "not pinned yet",
+			path: "/api/providers/openai/v1/chat/completions",
+			used: "a",
+			pins: map[string]string{"session": "a"},
+		},
+		{
+			name:      "pinned outside preferred settings",
+			path:      "/api/providers/openai/v1/chat/completions",
+			preferred: []string{"a"},
+			pinned:    "b",
+			used:      "a",
+			pins:      map[string]string{"session": "a"},
+		},
+		{
+			name: "route",
+			path: "/api/routes/chat",
+			pins: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pins := &memoryPins{pins: map[string]string{}}
+			if len(tt.pinned) != 0 {
+				pins.pins["session"] = tt.pinned
+			}
+
+			a := NewAuthenticator(psm, kc, rm, nil, tt.preferred, 0, 0, pins)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer raw")
+			req.Header.Set("X-SESSION-ID", "session")
+
+			_, settings, err := a.AuthenticateHttpRequest(req)
+			require.NoError(t, err)
+			require.NotEmpty(t, settings)
+
+			if len(tt.used) != 0 {
+				assert.Equal(t, tt.used, settings[0].Id)
+				assert.Equal(t, "Bearer "+tt.used, req.Header.Get("Authorization"))
+			}
+
+			assert.Equal(t, tt.pins, pins.pins)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 type store interface {
 	Set(key string, value interface{}, ttl time.Duration) error
 	GetBytes(key string) ([]byte, error)
+	Delete(key string) error
 }
 
 type Cache struct {
@@ -32,3 +33,7 @@ func (c *Cache) StoreBytes(key string, value []byte, ttl time.Duration) error {
 func (c *Cache) GetBytes(key string) ([]byte, error) {
 	return c.store.GetBytes(c.computeHashKey(key))
 }
+
+func (c *Cache) DeleteBytes(key string) error {
+	return c.store.Delete(c.computeHashKey(key))
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to the admin API of BricksLLM.
+type Client struct {
+	baseUrl   string
+	adminPass string
+	http      http.Client
+}
+
+func NewClient(baseUrl, adminPass string, timeout time.Duration) *Client {
+	return &Client{
+		baseUrl:   strings.TrimSuffix(baseUrl, "/"),
+		adminPass: adminPass,
+		http: http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+type errorResponse struct {
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+func (c *Client) do(method, path string, query url.Values, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	u := c.baseUrl + path
+	if len(query) != 0 {
+		u = u + "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.adminPass) != 0 {
+		req.Header.Set("X-API-KEY", c.adminPass)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		er := &errorResponse{}
+		if err := json.Unmarshal(data, er); err == nil && len(er.Title) != 0 {
+			return fmt.Errorf("%s %s failed with status %d: %s: %s", method, path, res.StatusCode, er.Title, er.Detail)
+		}
+
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, res.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/spf13/cobra"
+)
+
+func newEventsCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Inspect events recorded by the proxy",
+	}
+
+	cmd.AddCommand(newEventsTailCommand(o))
+
+	return cmd
+}
+
+func printEvent(w io.Writer, e *event.Event) {
+	action := e.Action
+	if len(action) == 0 {
+		action = "-"
+	}
+
+	fmt.Fprintf(w, "%s %s %d %s %s %s %s tokens=%d/%d cost=%.6f latency=%dms action=%s\n",
+		time.Unix(e.CreatedAt, 0).Format(time.RFC3339),
+		e.Id,
+		e.Status,
+		e.KeyId,
+		e.Method,
+		e.Path,
+		e.Model,
+		e.PromptTokenCount,
+		e.CompletionTokenCount,
+		e.CostInUsd,
+		e.LatencyInMs,
+		action,
+	)
+}
+
+func newEventsTailCommand(o *options) *cobra.Command {
+	keyIds := []string{}
+	tags := []string{}
+	since := time.Duration(0)
+	interval := time.Duration(0)
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Print new events as they are recorded",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := o.client()
+			start := time.Now().Add(-since).Unix()
+			seen := map[string]bool{}
+
+			for {
+				end := time.Now().Unix()
+				res := &event.EventResponse{}
+				err := client.do(http.MethodPost, "/api/v2/events", nil, &event.EventRequest{
+					KeyIds: keyIds,
+					Tags:   tags,
+					Start:  start,
+					End:    end,
+				}, res)
+				if err != nil {
+					return err
+				}
+
+				sort.SliceStable(res.Events, func(i, j int) bool {
+					return res.Events[i].CreatedAt < res.Events[j].CreatedAt
+				})
+
+				// events are polled from the last second seen so that events
+				// recorded late within that second are not missed.
+				next := map[string]bool{}
+				for _, e := range res.Events {
+					if e.CreatedAt >= start {
+						start = e.CreatedAt
+					}
+
+					if !seen[e.Id] {
+						printEvent(cmd.OutOrStdout(), e)
+					}
+				}
+
+				for _, e := range res.Events {
+					if e.CreatedAt == start {
+						next[e.Id] = true
+					}
+				}
+
+				seen = next
+
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&keyIds, "key-id", nil, "ids of keys events are filtered by, can be repeated")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "tags events are filtered by, can be repeated")
+	cmd.Flags().DurationVar(&since, "since", time.Minute, "how far back the first poll looks for events")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "interval between polls")
+
+	return cmd
+}
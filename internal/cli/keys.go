@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/spf13/cobra"
+)
+
+func newKeysCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Create, list and update keys",
+	}
+
+	cmd.AddCommand(
+		newKeysCreateCommand(o),
+		newKeysListCommand(o),
+		newKeysAttachPolicyCommand(o),
+	)
+
+	return cmd
+}
+
+func newKeysCreateCommand(o *options) *cobra.Command {
+	rk := &key.RequestKey{}
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(rk.Key) == 0 {
+				return errors.New("--key is required")
+			}
+
+			created := &key.ResponseKey{}
+			if err := o.client().do(http.MethodPut, "/api/key-management/keys", nil, rk, created); err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), created)
+		},
+	}
+
+	cmd.Flags().StringVar(&rk.Name, "name", "", "name of the key")
+	cmd.Flags().StringVar(&rk.Key, "key", "", "value of the key used to authenticate with the proxy")
+	cmd.Flags().StringSliceVar(&rk.Tags, "tag", nil, "tags of the key, can be repeated")
+	cmd.Flags().StringSliceVar(&rk.SettingIds, "setting-id", nil, "provider setting ids of the key, can be repeated")
+	cmd.Flags().Float64Var(&rk.CostLimitInUsd, "cost-limit-in-usd", 0, "total cost limit of the key in USD")
+	cmd.Flags().StringVar(&rk.PolicyId, "policy-id", "", "id of the policy attached to the key")
+	cmd.Flags().StringVar(&rk.Ttl, "ttl", "", "time to live of the key, such as 24h")
+
+	return cmd
+}
+
+func newKeysListCommand(o *options) *cobra.Command {
+	tags := []string{}
+	keyIds := []string{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List keys by tags or ids",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			for _, tag := range tags {
+				query.Add("tags", tag)
+			}
+
+			for _, id := range keyIds {
+				query.Add("keyIds", id)
+			}
+
+			keys := []*key.ResponseKey{}
+			if err := o.client().do(http.MethodGet, "/api/key-management/keys", query, nil, &keys); err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), keys)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "tags keys are filtered by, can be repeated")
+	cmd.Flags().StringSliceVar(&keyIds, "key-id", nil, "ids keys are filtered by, can be repeated")
+
+	return cmd
+}
+
+func newKeysAttachPolicyCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "attach-policy KEY_ID POLICY_ID",
+		Short: "Attach a policy to a key",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policyId := args[1]
+			updated := &key.ResponseKey{}
+			if err := o.client().do(http.MethodPatch, "/api/key-management/keys/"+url.PathEscape(args[0]), nil, &key.UpdateKey{PolicyId: &policyId}, updated); err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), updated)
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/loadtest"
+	"github.com/spf13/cobra"
+)
+
+func newLoadtestCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Benchmark the proxy against a synthetic provider",
+	}
+
+	cmd.AddCommand(
+		newLoadtestStubCommand(),
+		newLoadtestRunCommand(o),
+	)
+
+	return cmd
+}
+
+func newLoadtestStubCommand() *cobra.Command {
+	addr := ""
+	cfg := &loadtest.StubConfig{}
+
+	cmd := &cobra.Command{
+		Use:   "stub",
+		Short: "Serve an OpenAI compatible provider returning synthetic completions",
+		Long:  "Serve an OpenAI compatible provider returning synthetic completions. Point a vllm provider setting at its url to benchmark the proxy without spending provider credits.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server := &http.Server{
+				Addr:    addr,
+				Handler: loadtest.NewStub(cfg).Handler(),
+			}
+
+			go func() {
+				<-cmd.Context().Done()
+				server.Close()
+			}()
+
+			fmt.Fprintf(cmd.OutOrStdout(), "provider stub is listening on %s\n", addr)
+
+			err := server.ListenAndServe()
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", ":8090", "address the stub listens on")
+	cmd.Flags().Float64Var(&cfg.LatencyMs.Mean, "latency-ms", 200, "mean latency in milliseconds before the first token")
+	cmd.Flags().Float64Var(&cfg.LatencyMs.StdDev, "latency-stddev-ms", 50, "standard deviation of the latency in milliseconds")
+	cmd.Flags().Float64Var(&cfg.LatencyMs.Max, "latency-max-ms", 0, "maximum latency in milliseconds, 0 for no maximum")
+	cmd.Flags().Float64Var(&cfg.CompletionTokens.Mean, "completion-tokens", 100, "mean number of completion tokens")
+	cmd.Flags().Float64Var(&cfg.CompletionTokens.StdDev, "completion-tokens-stddev", 30, "standard deviation of the number of completion tokens")
+	cmd.Flags().Float64Var(&cfg.CompletionTokens.Max, "completion-tokens-max", 0, "maximum number of completion tokens, 0 for no maximum")
+	cmd.Flags().Float64Var(&cfg.TokensPerSecond, "tokens-per-second", 0, "pace of streamed tokens, 0 streams without delay")
+	cmd.Flags().Float64Var(&cfg.ErrorRate, "error-rate", 0, "fraction of requests answered with a 500")
+
+	cfg.CompletionTokens.Min = 1
+
+	return cmd
+}
+
+func newLoadtestRunCommand(o *options) *cobra.Command {
+	proxyUrl := ""
+	path := ""
+	model := ""
+	prompt := ""
+	bodyFile := ""
+	stream := false
+	keyId := ""
+	eventsWait := time.Duration(0)
+	cfg := &loadtest.RunConfig{}
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Send requests to the proxy and report throughput and latency",
+		Long:  "Send requests to the proxy and report throughput, latency and rate limited responses. When --key-id is set, the events recorded for the key during the run are counted through the admin API.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(cfg.ApiKey) == 0 {
+				return errors.New("--api-key is required")
+			}
+
+			if cfg.Requests == 0 && cfg.Duration <= 0 {
+				return errors.New("either --requests or --duration is required")
+			}
+
+			if len(bodyFile) != 0 {
+				data, err := os.ReadFile(bodyFile)
+				if err != nil {
+					return err
+				}
+
+				cfg.Body = data
+			} else {
+				data, err := json.Marshal(map[string]any{
+					"model":  model,
+					"stream": stream,
+					"messages": []map[string]string{
+						{"role": "user", "content": prompt},
+					},
+				})
+				if err != nil {
+					return err
+				}
+
+				cfg.Body = data
+			}
+
+			cfg.Url = strings.TrimSuffix(proxyUrl, "/") + path
+
+			report := loadtest.Run(cmd.Context(), cfg)
+			report.Print(cmd.OutOrStdout())
+
+			if len(keyId) == 0 {
+				return nil
+			}
+
+			// events are written asynchronously by the proxy.
+			select {
+			case <-cmd.Context().Done():
+				return nil
+			case <-time.After(eventsWait):
+			}
+
+			res := &event.EventResponse{}
+			err := o.client().do(http.MethodPost, "/api/v2/events", nil, &event.EventRequest{
+				KeyIds:      []string{keyId},
+				Start:       report.Start.Unix(),
+				End:         time.Now().Unix(),
+				Limit:       1,
+				ReturnCount: true,
+			}, res)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "events:       %d recorded for %d responses\n", res.Count, report.Requests-report.Errors)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&proxyUrl, "proxy-url", getEnv("BRICKSLLM_PROXY_URL", "http://localhost:8002"), "url of the proxy server (BRICKSLLM_PROXY_URL)")
+	cmd.Flags().StringVar(&path, "path", "/api/providers/vllm/v1/chat/completions", "proxy endpoint requests are sent to")
+	cmd.Flags().StringVar(&cfg.ApiKey, "api-key", os.Getenv("BRICKSLLM_API_KEY"), "BricksLLM api key requests are sent with (BRICKSLLM_API_KEY)")
+	cmd.Flags().StringVar(&model, "model", "gpt-3.5-turbo", "model of the generated chat completion requests")
+	cmd.Flags().StringVar(&prompt, "prompt", "Say hello.", "user message of the generated chat completion requests")
+	cmd.Flags().BoolVar(&stream, "stream", false, "send streaming chat completion requests")
+	cmd.Flags().StringVar(&bodyFile, "body-file", "", "file with the request body, overrides --model, --prompt and --stream")
+	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", 10, "number of concurrent workers")
+	cmd.Flags().DurationVar(&cfg.Duration, "duration", 30*time.Second, "duration of the run, ignored when --requests is set")
+	cmd.Flags().IntVar(&cfg.Requests, "requests", 0, "number of requests to send")
+	cmd.Flags().Float64Var(&cfg.Rate, "rate", 0, "maximum requests per second, 0 for no maximum")
+	cmd.Flags().DurationVar(&cfg.Timeout, "request-timeout", time.Minute, "timeout of each request")
+	cmd.Flags().StringVar(&keyId, "key-id", "", "id of the key the api key belongs to, used to count recorded events")
+	cmd.Flags().DurationVar(&eventsWait, "events-wait", 5*time.Second, "time to wait for events to be written before counting them")
+
+	return cmd
+}
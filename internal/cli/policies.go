@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+func newPoliciesCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policies",
+		Short: "List and test policies",
+	}
+
+	cmd.AddCommand(
+		newPoliciesListCommand(o),
+		newPoliciesTestCommand(o),
+	)
+
+	return cmd
+}
+
+func newPoliciesListCommand(o *options) *cobra.Command {
+	tags := []string{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List policies by tags",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(tags) == 0 {
+				return errors.New("--tag is required")
+			}
+
+			query := url.Values{}
+			for _, tag := range tags {
+				query.Add("tags", tag)
+			}
+
+			policies := []*policy.Policy{}
+			if err := o.client().do(http.MethodGet, "/api/policies", query, nil, &policies); err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), policies)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "tags policies are filtered by, can be repeated")
+
+	return cmd
+}
+
+func newPoliciesTestCommand(o *options) *cobra.Command {
+	text := ""
+
+	cmd := &cobra.Command{
+		Use:   "test POLICY_ID",
+		Short: "Test a policy against sample text",
+		Long:  "Test a policy against sample text sent as a user message. The text is read from stdin when --text is not set.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(text) == 0 {
+				data, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				text = strings.TrimSpace(string(data))
+			}
+
+			if len(text) == 0 {
+				return errors.New("sample text is empty")
+			}
+
+			result := &policy.TestResult{}
+			if err := o.client().do(http.MethodPost, "/api/policies/"+url.PathEscape(args[0])+"/test", nil, map[string]string{"text": text}, result); err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), result)
+		},
+	}
+
+	cmd.Flags().StringVar(&text, "text", "", "sample text the policy is tested against")
+
+	return cmd
+}
@@ -0,0 +1,63 @@
+// Package cli implements bricksllm-cli, a command line tool for managing
+// BricksLLM through its admin API.
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	adminUrl  string
+	adminPass string
+	timeout   time.Duration
+}
+
+func (o *options) client() *Client {
+	return NewClient(o.adminUrl, o.adminPass, o.timeout)
+}
+
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); len(value) != 0 {
+		return value
+	}
+
+	return fallback
+}
+
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// NewRootCommand returns the bricksllm-cli command with all of its
+// subcommands.
+func NewRootCommand() *cobra.Command {
+	o := &options{}
+
+	cmd := &cobra.Command{
+		Use:           "bricksllm-cli",
+		Short:         "Manage keys, policies, usage and events of BricksLLM and benchmark its proxy from the terminal",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.PersistentFlags().StringVar(&o.adminUrl, "admin-url", getEnv("BRICKSLLM_ADMIN_URL", "http://localhost:8001"), "url of the admin server (BRICKSLLM_ADMIN_URL)")
+	cmd.PersistentFlags().StringVar(&o.adminPass, "admin-pass", os.Getenv("BRICKSLLM_ADMIN_PASS"), "admin password sent as the X-API-KEY header (BRICKSLLM_ADMIN_PASS)")
+	cmd.PersistentFlags().DurationVar(&o.timeout, "timeout", 30*time.Second, "timeout of requests to the admin server")
+
+	cmd.AddCommand(
+		newKeysCommand(o),
+		newPoliciesCommand(o),
+		newUsageCommand(o),
+		newEventsCommand(o),
+		newLoadtestCommand(o),
+	)
+
+	return cmd
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/spf13/cobra"
+)
+
+type keyUsage struct {
+	Id        string  `json:"id"`
+	CostInUsd float64 `json:"costInUsd"`
+}
+
+func newUsageCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "usage KEY_ID",
+		Short: "Show the accumulated cost of a key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kr := &key.KeyReporting{}
+			if err := o.client().do(http.MethodGet, "/api/reporting/keys/"+url.PathEscape(args[0]), nil, nil, kr); err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), &keyUsage{
+				Id:        kr.Id,
+				CostInUsd: float64(kr.CostInMicroDollars) / 1000000,
+			})
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type AuditLog struct {
+	Id            string `json:"id"`
+	CreatedAt     int64  `json:"createdAt"`
+	Method        string `json:"method"`
+	Path          string `json:"path"`
+	StatusCode    int    `json:"statusCode"`
+	CorrelationId string `json:"correlationId"`
+	ClientIp      string `json:"clientIp"`
+}
+
+type KeyHistory struct {
+	KeyId     string          `json:"keyId"`
+	Action    string          `json:"action"`
+	CreatedAt int64           `json:"createdAt"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+}
+
+type PolicyDecision struct {
+	EventId               string          `json:"eventId"`
+	CreatedAt             int64           `json:"createdAt"`
+	KeyId                 string          `json:"keyId"`
+	PolicyId              string          `json:"policyId"`
+	Action                string          `json:"action"`
+	PiiFindings           json.RawMessage `json:"piiFindings,omitempty"`
+	Exemption             json.RawMessage `json:"exemption,omitempty"`
+	ContentFilterResults  json.RawMessage `json:"contentFilterResults,omitempty"`
+	GuardrailIntervention json.RawMessage `json:"guardrailIntervention,omitempty"`
+}
+
+type AccessRecord struct {
+	EventId       string `json:"eventId"`
+	CreatedAt     int64  `json:"createdAt"`
+	KeyId         string `json:"keyId"`
+	UserId        string `json:"userId"`
+	Path          string `json:"path"`
+	Method        string `json:"method"`
+	Status        int    `json:"status"`
+	CorrelationId string `json:"correlationId"`
+}
+
+type ExportRequest struct {
+	Start int64
+	End   int64
+}
+
+func (r *ExportRequest) Validate() error {
+	if r.Start <= 0 || r.End <= 0 {
+		return internal_errors.NewValidationError("start and end must be positive unix timestamps")
+	}
+
+	if r.Start >= r.End {
+		return internal_errors.NewValidationError(fmt.Sprintf("start %d cannot be larger than end %d", r.Start, r.End))
+	}
+
+	return nil
+}
+
+// Manifest lists the files of an export bundle together with their SHA-256
+// checksums. The manifest itself is signed with HMAC-SHA256.
+type Manifest struct {
+	GeneratedAt int64             `json:"generatedAt"`
+	Start       int64             `json:"start"`
+	End         int64             `json:"end"`
+	Files       map[string]string `json:"files"`
+}
@@ -3,28 +3,23 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/caarlos0/env"
-
 	"github.com/joho/godotenv"
-	"github.com/knadh/koanf/parsers/json"
-	"github.com/knadh/koanf/providers/file"
-	"github.com/knadh/koanf/v2"
-	"go.uber.org/zap"
 )
 
 type Config struct {
 	PostgresqlHosts               string        `koanf:"postgresql_hosts" env:"POSTGRESQL_HOSTS" envSeparator:":" envDefault:"localhost"`
 	PostgresqlDbName              string        `koanf:"postgresql_db_name" env:"POSTGRESQL_DB_NAME"`
 	PostgresqlUsername            string        `koanf:"postgresql_username" env:"POSTGRESQL_USERNAME"`
-	PostgresqlPassword            string        `koanf:"postgresql_password" env:"POSTGRESQL_PASSWORD"`
+	PostgresqlPassword            string        `koanf:"postgresql_password" env:"POSTGRESQL_PASSWORD" redact:"true"`
 	PostgresqlSslMode             string        `koanf:"postgresql_ssl_mode" env:"POSTGRESQL_SSL_MODE" envDefault:"disable"`
 	PostgresqlPort                string        `koanf:"postgresql_port" env:"POSTGRESQL_PORT" envDefault:"5432"`
 	RedisHosts                    string        `koanf:"redis_hosts" env:"REDIS_HOSTS" envSeparator:":" envDefault:"localhost"`
 	RedisPort                     string        `koanf:"redis_port" env:"REDIS_PORT" envDefault:"6379"`
 	RedisUsername                 string        `koanf:"redis_username" env:"REDIS_USERNAME"`
-	RedisPassword                 string        `koanf:"redis_password" env:"REDIS_PASSWORD"`
+	RedisPassword                 string        `koanf:"redis_password" env:"REDIS_PASSWORD" redact:"true"`
 	RedisInsecureSkipVerify       bool          `koanf:"redis_insecure_skip_verify" env:"REDIS_INSECURE_SKIP_VERIFY" envDefault:"false"`
 	RedisDBStartIndex             int           `koanf:"redis_db_start_index" env:"REDIS_DB_START_INDEX" envDefault:"0"`
 	RedisReadTimeout              time.Duration `koanf:"redis_read_time_out" env:"REDIS_READ_TIME_OUT" envDefault:"1s"`
@@ -37,15 +32,38 @@ type Config struct {
 	StatsAddress                  string        `koanf:"stats_address" env:"STATS_ADDRESS" envDefault:"127.0.0.1:8125"`
 	PrometheusEnabled             bool          `koanf:"prometheus_enabled" env:"PROMETHEUS_ENABLED" envDefault:"true"`
 	PrometheusPort                string        `koanf:"prometheus_port" env:"PROMETHEUS_PORT" envDefault:"2112"`
-	AdminPass                     string        `koanf:"admin_pass" env:"ADMIN_PASS"`
+	AdminPass                     string        `koanf:"admin_pass" env:"ADMIN_PASS" redact:"true"`
 	ProxyTimeout                  time.Duration `koanf:"proxy_timeout" env:"PROXY_TIMEOUT" envDefault:"600s"`
 	NumberOfEventMessageConsumers int           `koanf:"number_of_event_message_consumers" env:"NUMBER_OF_EVENT_MESSAGE_CONSUMERS" envDefault:"3"`
-	OpenAiApiKey                  string        `koanf:"openai_api_key" env:"OPENAI_API_KEY"`
+	OpenAiApiKey                  string        `koanf:"openai_api_key" env:"OPENAI_API_KEY" redact:"true"`
 	CustomPolicyDetectionTimeout  time.Duration `koanf:"custom_policy_detection_timeout" env:"CUSTOM_POLICY_DETECTION_TIMEOUT" envDefault:"10m"`
 	AmazonRegion                  string        `koanf:"amazon_region" env:"AMAZON_REGION" envDefault:"us-west-2"`
 	AmazonRequestTimeout          time.Duration `koanf:"amazon_request_timeout" env:"AMAZON_REQUEST_TIMEOUT" envDefault:"5s"`
 	AmazonConnectionTimeout       time.Duration `koanf:"amazon_connection_timeout" env:"AMAZON_CONNECTION_TIMEOUT" envDefault:"10s"`
+	PresidioAnalyzerUrl           string        `koanf:"presidio_analyzer_url" env:"PRESIDIO_ANALYZER_URL"`
+	PresidioRequestTimeout        time.Duration `koanf:"presidio_request_timeout" env:"PRESIDIO_REQUEST_TIMEOUT" envDefault:"5s"`
 	RemoveUserAgent               bool          `koanf:"remove_user_agent" env:"REMOVE_USER_AGENT" envDefault:"false"`
+	ClampMaxTokens                bool          `koanf:"clamp_max_tokens" env:"CLAMP_MAX_TOKENS" envDefault:"false"`
+	BuiltInModelRemappings        bool          `koanf:"built_in_model_remappings" env:"BUILT_IN_MODEL_REMAPPINGS" envDefault:"true"`
+	ContextWindowSiblingModels    []string      `koanf:"context_window_sibling_models" env:"CONTEXT_WINDOW_SIBLING_MODELS" envSeparator:","`
+	SessionTtl                    time.Duration `koanf:"session_ttl" env:"SESSION_TTL" envDefault:"24h"`
+	ThreadTtl                     time.Duration `koanf:"thread_ttl" env:"THREAD_TTL" envDefault:"720h"`
+	BatchTtl                      time.Duration `koanf:"batch_ttl" env:"BATCH_TTL" envDefault:"720h"`
+	RetentionJobInterval          time.Duration `koanf:"retention_job_interval" env:"RETENTION_JOB_INTERVAL" envDefault:"1h"`
+	ReconciliationJobInterval     time.Duration `koanf:"reconciliation_job_interval" env:"RECONCILIATION_JOB_INTERVAL" envDefault:"1h"`
+	PricingManifestUrl            string        `koanf:"pricing_manifest_url" env:"PRICING_MANIFEST_URL"`
+	PricingSyncJobInterval        time.Duration `koanf:"pricing_sync_job_interval" env:"PRICING_SYNC_JOB_INTERVAL" envDefault:"1h"`
+	AnonymizeEvents               bool          `koanf:"anonymize_events" env:"ANONYMIZE_EVENTS" envDefault:"false"`
+	AnonymizationSalt             string        `koanf:"anonymization_salt" env:"ANONYMIZATION_SALT" redact:"true"`
+	ComplianceExportSigningKey    string        `koanf:"compliance_export_signing_key" env:"COMPLIANCE_EXPORT_SIGNING_KEY" redact:"true"`
+	Region                        string        `koanf:"region" env:"REGION"`
+	PreferredProviderSettingIds   []string      `koanf:"preferred_provider_setting_ids" env:"PREFERRED_PROVIDER_SETTING_IDS" envSeparator:","`
+	QueueTimeout                  time.Duration `koanf:"queue_timeout" env:"QUEUE_TIMEOUT" envDefault:"30s"`
+	UpstreamQuotaReserve          float64       `koanf:"upstream_quota_reserve" env:"UPSTREAM_QUOTA_RESERVE" envDefault:"0.05"`
+	SpendLagTolerance             float64       `koanf:"spend_lag_tolerance" env:"SPEND_LAG_TOLERANCE" envDefault:"0"`
+
+	// sources records the layer each setting was loaded from.
+	sources map[string]string
 }
 
 func prepareDotEnv(envFilePath string) error {
@@ -71,32 +89,17 @@ func prepareDotEnv(envFilePath string) error {
 	return nil
 }
 
-var k = koanf.New(".")
-
-func LoadConfig(log *zap.Logger) (*Config, error) {
-	cfg := &Config{}
-
-	err := env.Parse(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	err = prepareDotEnv(".env")
-	if err != nil {
-		log.Sugar().Infof("error loading config from .env file: %v", err)
-	}
-
-	cfgPath := os.Getenv("CONFIG_FILE_NAME")
-	if cfgPath != "" {
-		f := file.Provider(cfgPath)
-		if err := k.Load(f, json.Parser()); err != nil {
-			log.Sugar().Infof("error loading config from json file: %v", err)
+// GetContextWindowSiblingModels parses sibling models configured in the format of model=sibling.
+func (c *Config) GetContextWindowSiblingModels() map[string]string {
+	siblings := map[string]string{}
+	for _, pair := range c.ContextWindowSiblingModels {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			continue
 		}
-	}
 
-	if len(cfgPath) != 0 {
-		k.Unmarshal("", cfg)
+		siblings[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
 	}
 
-	return cfg, nil
+	return siblings
 }
@@ -0,0 +1,371 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"go.uber.org/zap"
+)
+
+// Layers of the configuration in the order of precedence. Settings of a layer
+// override settings of the layers before it.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceRemote  = "remote"
+)
+
+const (
+	// configFileEnv is the path of a JSON, YAML or TOML config file.
+	configFileEnv = "CONFIG_FILE_NAME"
+	// configRemoteUrlEnv is the url remote overrides are fetched from.
+	configRemoteUrlEnv = "CONFIG_REMOTE_URL"
+	// configRemoteTokenEnv is sent as the bearer token when fetching remote
+	// overrides.
+	configRemoteTokenEnv = "CONFIG_REMOTE_TOKEN"
+)
+
+const redacted = "[REDACTED]"
+
+type field struct {
+	index     int
+	key       string
+	env       string
+	def       string
+	separator string
+	redact    bool
+	isSlice   bool
+}
+
+func getFields() []*field {
+	fields := []*field{}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("koanf")
+		if len(key) == 0 {
+			continue
+		}
+
+		separator := sf.Tag.Get("envSeparator")
+		if len(separator) == 0 {
+			separator = ","
+		}
+
+		fields = append(fields, &field{
+			index:     i,
+			key:       key,
+			env:       sf.Tag.Get("env"),
+			def:       sf.Tag.Get("envDefault"),
+			separator: separator,
+			redact:    sf.Tag.Get("redact") == "true",
+			isSlice:   sf.Type.Kind() == reflect.Slice,
+		})
+	}
+
+	return fields
+}
+
+func (f *field) parse(value string) any {
+	if !f.isSlice {
+		return value
+	}
+
+	values := []string{}
+	for _, v := range strings.Split(value, f.separator) {
+		if trimmed := strings.TrimSpace(v); len(trimmed) != 0 {
+			values = append(values, trimmed)
+		}
+	}
+
+	return values
+}
+
+func getParser(name string) koanf.Parser {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return yaml.Parser()
+	case ".toml":
+		return toml.Parser()
+	}
+
+	return json.Parser()
+}
+
+func getParserByContentType(contentType, url string) koanf.Parser {
+	switch {
+	case strings.Contains(contentType, "yaml"):
+		return yaml.Parser()
+	case strings.Contains(contentType, "toml"):
+		return toml.Parser()
+	case strings.Contains(contentType, "json"):
+		return json.Parser()
+	}
+
+	return getParser(strings.SplitN(url, "?", 2)[0])
+}
+
+func fetchRemoteOverrides(url, token string) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(token) != 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote config responded with status %d", res.StatusCode)
+	}
+
+	return getParserByContentType(res.Header.Get("Content-Type"), url).Unmarshal(data)
+}
+
+// loader merges the layers of the configuration and records the layer each
+// setting was loaded from.
+type loader struct {
+	k       *koanf.Koanf
+	known   map[string]bool
+	sources map[string]string
+	log     *zap.Logger
+}
+
+func (l *loader) merge(source string, values map[string]any) error {
+	layer := koanf.New(".")
+	if err := layer.Load(confmap.Provider(values, "."), nil); err != nil {
+		return err
+	}
+
+	known := map[string]any{}
+	for _, key := range layer.Keys() {
+		if !l.known[key] {
+			l.log.Sugar().Warnf("unknown config setting %s from %s is ignored", key, source)
+			continue
+		}
+
+		known[key] = layer.Get(key)
+		l.sources[key] = source
+	}
+
+	return l.k.Load(confmap.Provider(known, "."), nil)
+}
+
+// LoadConfig loads the configuration by merging, in order of precedence,
+// defaults, the config file set by CONFIG_FILE_NAME, environment variables and
+// remote overrides fetched from CONFIG_REMOTE_URL. A config file or remote
+// overrides that are configured but cannot be loaded fail the load.
+func LoadConfig(log *zap.Logger) (*Config, error) {
+	err := prepareDotEnv(".env")
+	if err != nil {
+		log.Sugar().Infof("error loading config from .env file: %v", err)
+	}
+
+	fields := getFields()
+	l := &loader{
+		k:       koanf.New("."),
+		known:   map[string]bool{},
+		sources: map[string]string{},
+		log:     log,
+	}
+
+	defaults := map[string]any{}
+	for _, f := range fields {
+		l.known[f.key] = true
+		if len(f.def) != 0 {
+			defaults[f.key] = f.parse(f.def)
+		}
+	}
+
+	if err := l.merge(SourceDefault, defaults); err != nil {
+		return nil, err
+	}
+
+	if cfgPath := os.Getenv(configFileEnv); len(cfgPath) != 0 {
+		fk := koanf.New(".")
+		if err := fk.Load(file.Provider(cfgPath), getParser(cfgPath)); err != nil {
+			return nil, fmt.Errorf("error loading config from file %s: %w", cfgPath, err)
+		}
+
+		if err := l.merge(SourceFile, fk.Raw()); err != nil {
+			return nil, err
+		}
+	}
+
+	envs := map[string]any{}
+	for _, f := range fields {
+		if len(f.env) == 0 {
+			continue
+		}
+
+		if value, ok := os.LookupEnv(f.env); ok {
+			envs[f.key] = f.parse(value)
+		}
+	}
+
+	if err := l.merge(SourceEnv, envs); err != nil {
+		return nil, err
+	}
+
+	if url := os.Getenv(configRemoteUrlEnv); len(url) != 0 {
+		overrides, err := fetchRemoteOverrides(url, os.Getenv(configRemoteTokenEnv))
+		if err != nil {
+			return nil, fmt.Errorf("error loading remote config overrides: %w", err)
+		}
+
+		if err := l.merge(SourceRemote, overrides); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := &Config{}
+	if err := l.k.Unmarshal("", cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg.sources = l.sources
+
+	return cfg, nil
+}
+
+// Validate checks that settings are within their allowed ranges.
+func (c *Config) Validate() error {
+	invalid := []string{}
+
+	durations := map[string]time.Duration{
+		"redis_read_time_out":             c.RedisReadTimeout,
+		"redis_write_time_out":            c.RedisWriteTimeout,
+		"postgresql_read_time_out":        c.PostgresqlReadTimeout,
+		"postgresql_write_time_out":       c.PostgresqlWriteTimeout,
+		"in_memory_db_update_interval":    c.InMemoryDbUpdateInterval,
+		"proxy_timeout":                   c.ProxyTimeout,
+		"custom_policy_detection_timeout": c.CustomPolicyDetectionTimeout,
+		"amazon_request_timeout":          c.AmazonRequestTimeout,
+		"amazon_connection_timeout":       c.AmazonConnectionTimeout,
+		"presidio_request_timeout":        c.PresidioRequestTimeout,
+		"session_ttl":                     c.SessionTtl,
+		"thread_ttl":                      c.ThreadTtl,
+		"batch_ttl":                       c.BatchTtl,
+		"retention_job_interval":          c.RetentionJobInterval,
+		"reconciliation_job_interval":     c.ReconciliationJobInterval,
+		"pricing_sync_job_interval":       c.PricingSyncJobInterval,
+	}
+
+	for key, d := range durations {
+		if d <= 0 {
+			invalid = append(invalid, key)
+		}
+	}
+
+	ports := map[string]string{
+		"postgresql_port": c.PostgresqlPort,
+		"redis_port":      c.RedisPort,
+		"prometheus_port": c.PrometheusPort,
+	}
+
+	for key, port := range ports {
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+			invalid = append(invalid, key)
+		}
+	}
+
+	if c.TelemetryProvider != "statsd" && c.TelemetryProvider != "prometheus" {
+		invalid = append(invalid, "telemetry_provider")
+	}
+
+	if c.RedisDBStartIndex < 0 {
+		invalid = append(invalid, "redis_db_start_index")
+	}
+
+	if c.NumberOfEventMessageConsumers <= 0 {
+		invalid = append(invalid, "number_of_event_message_consumers")
+	}
+
+	if c.SpendLagTolerance < 0 || c.SpendLagTolerance >= 1 {
+		invalid = append(invalid, "spend_lag_tolerance")
+	}
+
+	if len(invalid) != 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("config settings [%s] are invalid", strings.Join(invalid, ", "))
+	}
+
+	return nil
+}
+
+// EffectiveSetting is a setting of the loaded configuration along with the
+// layer it was loaded from.
+type EffectiveSetting struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// Dump returns the effective configuration. Secrets that are set are redacted.
+func (c *Config) Dump() []*EffectiveSetting {
+	settings := []*EffectiveSetting{}
+
+	v := reflect.ValueOf(c).Elem()
+	for _, f := range getFields() {
+		fv := v.Field(f.index)
+
+		var value any = fv.Interface()
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+
+		if f.redact && !fv.IsZero() {
+			value = redacted
+		}
+
+		source := c.sources[f.key]
+		if len(source) == 0 {
+			source = SourceDefault
+		}
+
+		settings = append(settings, &EffectiveSetting{
+			Key:    f.key,
+			Env:    f.env,
+			Value:  value,
+			Source: source,
+		})
+	}
+
+	return settings
+}
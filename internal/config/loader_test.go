@@ -0,0 +1,87 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoadConfig(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"proxy_timeout":"30s","unknown_setting":"a"}`))
+	}))
+	defer remote.Close()
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte("proxy_timeout: 45s\nunknown_setting: a\n"), 0o600))
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{`), 0o600))
+
+	tests := []struct {
+		name      string
+		file      string
+		url       string
+		timeout   time.Duration
+		source    string
+		shouldErr bool
+	}{
+		{name: "defaults", timeout: 600 * time.Second, source: SourceDefault},
+		{name: "file", file: valid, timeout: 45 * time.Second, source: SourceFile},
+		{name: "missing file", file: filepath.Join(dir, "missing.json"), shouldErr: true},
+		{name: "invalid file", file: invalid, shouldErr: true},
+		{name: "remote", url: remote.URL + "/config.json", timeout: 30 * time.Second, source: SourceRemote},
+		{name: "remote error", url: remote.URL + "/missing.json", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(configFileEnv, tt.file)
+			t.Setenv(configRemoteUrlEnv, tt.url)
+
+			cfg, err := LoadConfig(zap.NewNop())
+			if tt.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.timeout, cfg.ProxyTimeout)
+
+			for _, s := range cfg.Dump() {
+				if s.Key == "proxy_timeout" {
+					assert.Equal(t, tt.source, s.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestLoaderMergeIgnoresUnknownSettings(t *testing.T) {
+	l := &loader{
+		k:       koanf.New("."),
+		known:   map[string]bool{"proxy_timeout": true},
+		sources: map[string]string{},
+		log:     zap.NewNop(),
+	}
+
+	require.NoError(t, l.merge(SourceFile, map[string]any{"proxy_timeout": "45s", "unknown_setting": "a"}))
+
+	assert.Equal(t, []string{"proxy_timeout"}, l.k.Keys())
+	assert.Equal(t, map[string]string{"proxy_timeout": SourceFile}, l.sources)
+}
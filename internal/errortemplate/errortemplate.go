@@ -0,0 +1,83 @@
+// Package errortemplate renders gateway generated errors in the JSON shape of
+// the applications served by routes and custom providers.
+package errortemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	TypeBlocked     = "blocked"
+	TypeRateLimited = "rate_limited"
+	TypeOverBudget  = "over_budget"
+)
+
+var Types = []string{TypeBlocked, TypeRateLimited, TypeOverBudget}
+
+type Template struct {
+	StatusCode int    `json:"statusCode"`
+	Message    string `json:"message"`
+	Body       string `json:"body"`
+}
+
+func escapeJsonString(str string) string {
+	data, _ := json.Marshal(str)
+	return string(data[1 : len(data)-1])
+}
+
+// Render builds the response status code and JSON body of a gateway generated
+// error. The {{message}}, {{status}} and {{type}} placeholders in the body are
+// replaced with their values. Placeholders are expected to be used inside JSON
+// strings, except for {{status}} which can also be used as a number.
+func (et *Template) Render(errType string, code int, message string) (int, []byte) {
+	if et.StatusCode != 0 {
+		code = et.StatusCode
+	}
+
+	if len(et.Message) != 0 {
+		message = et.Message
+	}
+
+	replacer := strings.NewReplacer(
+		"{{message}}", escapeJsonString(message),
+		"{{status}}", strconv.Itoa(code),
+		"{{type}}", escapeJsonString(errType),
+	)
+
+	return code, []byte(replacer.Replace(et.Body))
+}
+
+// Validate returns the names of invalid fields of an error template.
+func (et *Template) Validate(prefix string) []string {
+	fields := []string{}
+	if et.StatusCode != 0 && (et.StatusCode < 400 || et.StatusCode > 599) {
+		fields = append(fields, prefix+".statusCode")
+	}
+
+	_, body := et.Render(TypeBlocked, 403, "")
+	if len(et.Body) == 0 || !json.Valid(body) {
+		fields = append(fields, prefix+".body")
+	}
+
+	return fields
+}
+
+// ValidateTemplates returns the names of invalid fields of error templates
+// keyed by error type.
+func ValidateTemplates(prefix string, templates map[string]*Template) []string {
+	fields := []string{}
+	for errType, et := range templates {
+		if !slices.Contains(Types, errType) || et == nil {
+			fields = append(fields, fmt.Sprintf("%s.%s", prefix, errType))
+			continue
+		}
+
+		fields = append(fields, et.Validate(fmt.Sprintf("%s.%s", prefix, errType))...)
+	}
+
+	return fields
+}
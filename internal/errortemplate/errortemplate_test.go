@@ -0,0 +1,27 @@
+package errortemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates map[string]*Template
+		fields    []string
+	}{
+		{name: "valid", templates: map[string]*Template{TypeOverBudget: {StatusCode: 402, Body: `{"error":"{{message}}","status":{{status}}}`}}, fields: []string{}},
+		{name: "unknown type", templates: map[string]*Template{"unknown": {Body: `{}`}}, fields: []string{"error_templates.unknown"}},
+		{name: "nil template", templates: map[string]*Template{TypeBlocked: nil}, fields: []string{"error_templates.blocked"}},
+		{name: "invalid status code", templates: map[string]*Template{TypeRateLimited: {StatusCode: 200, Body: `{}`}}, fields: []string{"error_templates.rate_limited.statusCode"}},
+		{name: "invalid body", templates: map[string]*Template{TypeRateLimited: {Body: `{"error":`}}, fields: []string{"error_templates.rate_limited.body"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.fields, ValidateTemplates("error_templates", tt.templates))
+		})
+	}
+}
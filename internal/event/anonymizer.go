@@ -0,0 +1,57 @@
+package event
+
+import "github.com/bricks-cloud/bricksllm/internal/hasher"
+
+// Anonymizer replaces end user, custom and session identifiers with salted
+// hashes and strips payloads, metadata and policy details from events. Hashes
+// are stable so that per user aggregates stay accurate. A nil Anonymizer
+// leaves events untouched.
+type Anonymizer struct {
+	salt string
+}
+
+func NewAnonymizer(salt string) *Anonymizer {
+	return &Anonymizer{
+		salt: salt,
+	}
+}
+
+func (a *Anonymizer) AnonymizeId(id string) string {
+	if a == nil || len(id) == 0 {
+		return id
+	}
+
+	return hasher.Hash(a.salt + id)
+}
+
+func (a *Anonymizer) AnonymizeIds(ids []string) []string {
+	if a == nil {
+		return ids
+	}
+
+	anonymized := []string{}
+	for _, id := range ids {
+		anonymized = append(anonymized, a.AnonymizeId(id))
+	}
+
+	return anonymized
+}
+
+func (a *Anonymizer) Anonymize(e *Event) {
+	if a == nil || e == nil {
+		return
+	}
+
+	e.UserId = a.AnonymizeId(e.UserId)
+	e.CustomId = a.AnonymizeId(e.CustomId)
+	e.SessionId = a.AnonymizeId(e.SessionId)
+
+	e.Request = nil
+	e.Response = nil
+	e.Metadata = nil
+	e.Citations = nil
+	e.ContentFilterResults = nil
+	e.GuardrailIntervention = nil
+	e.PolicyRules = nil
+	e.PiiFindings = nil
+}
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/hasher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymize(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     *Anonymizer
+		event *Event
+		want  *Event
+	}{
+		{
+			name: "anonymized",
+			a:    NewAnonymizer("salt"),
+			event: &Event{
+				KeyId:                 "key",
+				UserId:                "user",
+				CustomId:              "custom",
+				SessionId:             "session",
+				Request:               []byte(`{}`),
+				Response:              []byte(`{}`),
+				Metadata:              []byte(`{"email":"jane@example.com"}`),
+				Citations:             []byte(`[]`),
+				ContentFilterResults:  []byte(`{}`),
+				GuardrailIntervention: []byte(`{}`),
+				PolicyRules:           []byte(`[]`),
+				PiiFindings:           []byte(`[]`),
+			},
+			want: &Event{
+				KeyId:     "key",
+				UserId:    hasher.Hash("saltuser"),
+				CustomId:  hasher.Hash("saltcustom"),
+				SessionId: hasher.Hash("saltsession"),
+			},
+		},
+		{
+			name:  "empty ids",
+			a:     NewAnonymizer("salt"),
+			event: &Event{KeyId: "key"},
+			want:  &Event{KeyId: "key"},
+		},
+		{
+			name:  "nil anonymizer",
+			event: &Event{UserId: "user", Metadata: []byte(`{}`)},
+			want:  &Event{UserId: "user", Metadata: []byte(`{}`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.a.Anonymize(tt.event)
+			assert.Equal(t, tt.want, tt.event)
+		})
+	}
+}
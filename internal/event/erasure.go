@@ -0,0 +1,60 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type ErasureRequest struct {
+	UserIds        []string `json:"userIds"`
+	CustomIds      []string `json:"customIds"`
+	CorrelationIds []string `json:"correlationIds"`
+}
+
+func (r *ErasureRequest) Validate() error {
+	if len(r.UserIds) == 0 && len(r.CustomIds) == 0 && len(r.CorrelationIds) == 0 {
+		return internal_errors.NewValidationError("one of userIds, customIds and correlationIds must be specified")
+	}
+
+	invalid := []string{}
+	for _, uid := range r.UserIds {
+		if len(uid) == 0 {
+			invalid = append(invalid, "userIds")
+			break
+		}
+	}
+
+	for _, cid := range r.CustomIds {
+		if len(cid) == 0 {
+			invalid = append(invalid, "customIds")
+			break
+		}
+	}
+
+	for _, cid := range r.CorrelationIds {
+		if len(cid) == 0 {
+			invalid = append(invalid, "correlationIds")
+			break
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	return nil
+}
+
+type ErasureReport struct {
+	RequestedAt        int64    `json:"requestedAt"`
+	CompletedAt        int64    `json:"completedAt"`
+	UserIds            []string `json:"userIds"`
+	CustomIds          []string `json:"customIds"`
+	CorrelationIds     []string `json:"correlationIds"`
+	EventIds           []string `json:"eventIds"`
+	EventsDeleted      int64    `json:"eventsDeleted"`
+	CacheEntriesPurged int      `json:"cacheEntriesPurged"`
+	CacheEntriesFailed int      `json:"cacheEntriesFailed"`
+}
@@ -8,28 +8,41 @@ import (
 )
 
 type Event struct {
-	Id                   string   `json:"id"`
-	CreatedAt            int64    `json:"created_at"`
-	Tags                 []string `json:"tags"`
-	KeyId                string   `json:"key_id"`
-	CostInUsd            float64  `json:"cost_in_usd"`
-	Provider             string   `json:"provider"`
-	Model                string   `json:"model"`
-	Status               int      `json:"status"`
-	PromptTokenCount     int      `json:"prompt_token_count"`
-	CompletionTokenCount int      `json:"completion_token_count"`
-	LatencyInMs          int      `json:"latency_in_ms"`
-	Path                 string   `json:"path"`
-	Method               string   `json:"method"`
-	CustomId             string   `json:"custom_id"`
-	Request              []byte   `json:"request"`
-	Response             []byte   `json:"response"`
-	UserId               string   `json:"userId"`
-	Action               string   `json:"action"`
-	PolicyId             string   `json:"policyId"`
-	RouteId              string   `json:"routeId"`
-	CorrelationId        string   `json:"correlationId"`
-	Metadata             []byte   `json:"metadata"`
+	Id                    string   `json:"id"`
+	CreatedAt             int64    `json:"created_at"`
+	Tags                  []string `json:"tags"`
+	KeyId                 string   `json:"key_id"`
+	CostInUsd             float64  `json:"cost_in_usd"`
+	Provider              string   `json:"provider"`
+	Model                 string   `json:"model"`
+	Status                int      `json:"status"`
+	PromptTokenCount      int      `json:"prompt_token_count"`
+	CompletionTokenCount  int      `json:"completion_token_count"`
+	LatencyInMs           int      `json:"latency_in_ms"`
+	Path                  string   `json:"path"`
+	Method                string   `json:"method"`
+	CustomId              string   `json:"custom_id"`
+	Request               []byte   `json:"request"`
+	Response              []byte   `json:"response"`
+	UserId                string   `json:"userId"`
+	Action                string   `json:"action"`
+	PolicyId              string   `json:"policyId"`
+	RouteId               string   `json:"routeId"`
+	CorrelationId         string   `json:"correlationId"`
+	Metadata              []byte   `json:"metadata"`
+	SessionId             string   `json:"sessionId"`
+	PiiFindings           []byte   `json:"piiFindings"`
+	PolicyExemption       []byte   `json:"policyExemption"`
+	Region                string   `json:"region"`
+	RequestTags           []string `json:"requestTags"`
+	ContentFilterResults  []byte   `json:"contentFilterResults"`
+	GuardrailIntervention []byte   `json:"guardrailIntervention"`
+	Citations             []byte   `json:"citations"`
+	ReasoningTokenCount   int      `json:"reasoning_token_count"`
+	RetryCount            int      `json:"retry_count"`
+	PolicyRules           []byte   `json:"policyRules"`
+	PricingVersion        string   `json:"pricingVersion"`
+	CustomerPriceInUsd    float64  `json:"customerPriceInUsd"`
 }
 
 type EventResponse struct {
@@ -54,6 +67,9 @@ type EventRequest struct {
 	DateOrder       string   `json:"dateOrder"`
 	ReturnCount     bool     `json:"returnCount"`
 	Status          int      `json:"status"`
+	SessionIds      []string `json:"sessionIds"`
+	Regions         []string `json:"regions"`
+	RequestTags     []string `json:"requestTags"`
 }
 
 func (r *EventRequest) Validate() error {
@@ -87,6 +103,27 @@ func (r *EventRequest) Validate() error {
 		}
 	}
 
+	for _, sid := range r.SessionIds {
+		if len(sid) == 0 {
+			invalid = append(invalid, "sessionIds")
+			break
+		}
+	}
+
+	for _, region := range r.Regions {
+		if len(region) == 0 {
+			invalid = append(invalid, "regions")
+			break
+		}
+	}
+
+	for _, tag := range r.RequestTags {
+		if len(tag) == 0 {
+			invalid = append(invalid, "requestTags")
+			break
+		}
+	}
+
 	for _, pid := range r.PolicyIds {
 		if len(pid) == 0 {
 			invalid = append(invalid, "policyIds")
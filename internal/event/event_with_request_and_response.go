@@ -4,6 +4,8 @@ import (
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+
+	goopenai "github.com/sashabaranov/go-openai"
 )
 
 type EventWithRequestAndContent struct {
@@ -15,4 +17,5 @@ type EventWithRequestAndContent struct {
 	Response            interface{}
 	Key                 *key.ResponseKey
 	CostMap             *provider.CostMap
+	StreamUsage         *goopenai.Usage
 }
@@ -0,0 +1,91 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+)
+
+type PiiFindingsRequest struct {
+	KeyIds    []string `json:"keyIds"`
+	Tags      []string `json:"tags"`
+	PolicyIds []string `json:"policyIds"`
+	Types     []string `json:"types"`
+	Start     int64    `json:"start"`
+	End       int64    `json:"end"`
+	Limit     int      `json:"limit"`
+	Offset    int      `json:"offset"`
+}
+
+func (r *PiiFindingsRequest) Validate() error {
+	invalid := []string{}
+	if r.Start == 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if r.End == 0 {
+		invalid = append(invalid, "end")
+	}
+
+	for _, kid := range r.KeyIds {
+		if len(kid) == 0 {
+			invalid = append(invalid, "keyIds")
+			break
+		}
+	}
+
+	for _, tag := range r.Tags {
+		if len(tag) == 0 {
+			invalid = append(invalid, "tags")
+			break
+		}
+	}
+
+	for _, pid := range r.PolicyIds {
+		if len(pid) == 0 {
+			invalid = append(invalid, "policyIds")
+			break
+		}
+	}
+
+	for _, t := range r.Types {
+		if len(t) == 0 {
+			invalid = append(invalid, "types")
+			break
+		}
+	}
+
+	if r.Limit < 0 {
+		invalid = append(invalid, "limit")
+	}
+
+	if r.Offset < 0 {
+		invalid = append(invalid, "offset")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	if r.Start >= r.End {
+		return internal_errors.NewValidationError(fmt.Sprintf("start %d cannot be larger than end %d", r.Start, r.End))
+	}
+
+	return nil
+}
+
+type PiiFindingsRecord struct {
+	EventId   string         `json:"eventId"`
+	CreatedAt int64          `json:"createdAt"`
+	KeyId     string         `json:"keyId"`
+	PolicyId  string         `json:"policyId"`
+	Action    string         `json:"action"`
+	Findings  []*pii.Finding `json:"findings"`
+}
+
+type PiiFindingsResponse struct {
+	Events  []*PiiFindingsRecord `json:"events"`
+	Summary map[string]int       `json:"summary"`
+}
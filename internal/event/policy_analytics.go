@@ -0,0 +1,95 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+type PolicyAnalyticsRequest struct {
+	PolicyIds []string `json:"policyIds"`
+	KeyIds    []string `json:"keyIds"`
+	Start     int64    `json:"start"`
+	End       int64    `json:"end"`
+	Increment int64    `json:"increment"`
+	Limit     int      `json:"limit"`
+}
+
+func (r *PolicyAnalyticsRequest) Validate() error {
+	invalid := []string{}
+	if r.Start == 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if r.End == 0 {
+		invalid = append(invalid, "end")
+	}
+
+	if r.Increment <= 0 {
+		invalid = append(invalid, "increment")
+	}
+
+	for _, pid := range r.PolicyIds {
+		if len(pid) == 0 {
+			invalid = append(invalid, "policyIds")
+			break
+		}
+	}
+
+	for _, kid := range r.KeyIds {
+		if len(kid) == 0 {
+			invalid = append(invalid, "keyIds")
+			break
+		}
+	}
+
+	if r.Limit < 0 {
+		invalid = append(invalid, "limit")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	if r.Start >= r.End {
+		return internal_errors.NewValidationError(fmt.Sprintf("start %d cannot be larger than end %d", r.Start, r.End))
+	}
+
+	return nil
+}
+
+// PolicyDataPoint counts the requests filtered by policies in a time bucket
+// by the action taken on them.
+type PolicyDataPoint struct {
+	TimeStamp        int64   `json:"timeStamp"`
+	NumberOfRequests int64   `json:"numberOfRequests"`
+	BlockedCount     int64   `json:"blockedCount"`
+	WarnedCount      int64   `json:"warnedCount"`
+	RedactedCount    int64   `json:"redactedCount"`
+	BlockRate        float64 `json:"blockRate"`
+	WarnRate         float64 `json:"warnRate"`
+	RedactRate       float64 `json:"redactRate"`
+}
+
+type PolicyRuleCount struct {
+	Rule   string `json:"rule"`
+	Action string `json:"action"`
+	Count  int64  `json:"count"`
+}
+
+// PolicyKeyCount counts the requests of a key that policies blocked, warned
+// or redacted.
+type PolicyKeyCount struct {
+	KeyId         string `json:"keyId"`
+	BlockedCount  int64  `json:"blockedCount"`
+	WarnedCount   int64  `json:"warnedCount"`
+	RedactedCount int64  `json:"redactedCount"`
+	Count         int64  `json:"count"`
+}
+
+type PolicyAnalyticsResponse struct {
+	DataPoints []*PolicyDataPoint `json:"dataPoints"`
+	TopRules   []*PolicyRuleCount `json:"topRules"`
+	TopKeys    []*PolicyKeyCount  `json:"topKeys"`
+}
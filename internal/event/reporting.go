@@ -12,6 +12,7 @@ type DataPoint struct {
 	KeyId                string  `json:"keyId"`
 	CustomId             string  `json:"customId"`
 	UserId               string  `json:"userId"`
+	RequestTag           string  `json:"requestTag"`
 }
 
 type DataPointV2 struct {
@@ -41,12 +42,13 @@ type ReportingResponseV2 struct {
 }
 
 type ReportingRequest struct {
-	KeyIds    []string `json:"keyIds"`
-	Tags      []string `json:"tags"`
-	CustomIds []string `json:"customIds"`
-	UserIds   []string `json:"userIds"`
-	Start     int64    `json:"start"`
-	End       int64    `json:"end"`
-	Increment int64    `json:"increment"`
-	Filters   []string `json:"filters"`
+	KeyIds      []string `json:"keyIds"`
+	Tags        []string `json:"tags"`
+	CustomIds   []string `json:"customIds"`
+	UserIds     []string `json:"userIds"`
+	Start       int64    `json:"start"`
+	End         int64    `json:"end"`
+	Increment   int64    `json:"increment"`
+	Filters     []string `json:"filters"`
+	RequestTags []string `json:"requestTags"`
 }
@@ -0,0 +1,12 @@
+package event
+
+type SessionReporting struct {
+	SessionId            string  `json:"sessionId"`
+	KeyId                string  `json:"keyId"`
+	NumberOfRequests     int     `json:"numberOfRequests"`
+	CostInUsd            float64 `json:"costInUsd"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	FirstRequestAt       int64   `json:"firstRequestAt"`
+	LastRequestAt        int64   `json:"lastRequestAt"`
+}
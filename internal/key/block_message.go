@@ -0,0 +1,56 @@
+package key
+
+import "strings"
+
+// DefaultBlockMessages are the client facing messages of blocked requests per
+// locale. They never contain the internal reason a request was blocked for.
+var DefaultBlockMessages = map[string]string{
+	"en": "Your request was blocked because it contains content that is not allowed. Reference: {{requestId}}",
+	"es": "Su solicitud fue bloqueada porque contiene contenido no permitido. Referencia: {{requestId}}",
+	"fr": "Votre requête a été bloquée car elle contient du contenu non autorisé. Référence : {{requestId}}",
+	"de": "Ihre Anfrage wurde blockiert, da sie unzulässige Inhalte enthält. Referenz: {{requestId}}",
+	"pt": "Sua solicitação foi bloqueada porque contém conteúdo não permitido. Referência: {{requestId}}",
+	"ja": "許可されていない内容が含まれているため、リクエストはブロックされました。参照: {{requestId}}",
+	"zh": "您的请求包含不允许的内容，已被拦截。参考编号：{{requestId}}",
+}
+
+const maxBlockMessageTemplateLength = 1000
+
+type BlockMessage struct {
+	Locale   string `json:"locale"`
+	Template string `json:"template"`
+}
+
+// Validate returns the names of invalid fields of a block message.
+func (bm *BlockMessage) Validate() []string {
+	invalid := []string{}
+	if len(bm.Locale) != 0 {
+		if _, ok := DefaultBlockMessages[bm.Locale]; !ok {
+			invalid = append(invalid, "blockMessage.locale")
+		}
+	}
+
+	if len(bm.Template) > maxBlockMessageTemplateLength {
+		invalid = append(invalid, "blockMessage.template")
+	}
+
+	return invalid
+}
+
+// Render returns the message sent to clients when a request is blocked. The
+// {{requestId}} placeholder is replaced with the correlation id of the request
+// so that end users can reference it without seeing policy internals.
+func (bm *BlockMessage) Render(requestId string) string {
+	template := DefaultBlockMessages["en"]
+	if bm != nil {
+		if localized, ok := DefaultBlockMessages[bm.Locale]; ok {
+			template = localized
+		}
+
+		if len(bm.Template) != 0 {
+			template = bm.Template
+		}
+	}
+
+	return strings.ReplaceAll(template, "{{requestId}}", requestId)
+}
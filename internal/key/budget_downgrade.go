@@ -0,0 +1,64 @@
+package key
+
+import "fmt"
+
+// BudgetDowngrade lets a key keep serving requests once its spend reaches a
+// percentage of its cost limits by rewriting requested models to cheaper
+// fallback models. Only requests that are downgraded are served once the cost
+// limits are reached.
+type BudgetDowngrade struct {
+	ThresholdPercentage float64           `json:"thresholdPercentage"`
+	FallbackModels      map[string]string `json:"fallbackModels"`
+}
+
+// Validate returns the names of invalid fields of a budget downgrade.
+func (bd *BudgetDowngrade) Validate() []string {
+	invalid := []string{}
+	if bd.ThresholdPercentage < 0 || bd.ThresholdPercentage > 100 {
+		invalid = append(invalid, "budgetDowngrade.thresholdPercentage")
+	}
+
+	if len(bd.FallbackModels) == 0 {
+		invalid = append(invalid, "budgetDowngrade.fallbackModels")
+	}
+
+	for model, fallback := range bd.FallbackModels {
+		if len(model) == 0 || len(fallback) == 0 || model == fallback {
+			invalid = append(invalid, fmt.Sprintf("budgetDowngrade.fallbackModels.%s", model))
+		}
+	}
+
+	return invalid
+}
+
+// Threshold returns the fraction of the cost limits at which models are
+// downgraded. It defaults to the cost limits themselves.
+func (bd *BudgetDowngrade) Threshold() float64 {
+	if bd == nil || bd.ThresholdPercentage == 0 {
+		return 1
+	}
+
+	return bd.ThresholdPercentage / 100
+}
+
+// Fallback returns the cheaper model a requested model is downgraded to.
+func (bd *BudgetDowngrade) Fallback(model string) (string, bool) {
+	if bd == nil {
+		return "", false
+	}
+
+	fallback, ok := bd.FallbackModels[model]
+	return fallback, ok
+}
+
+// HasCostLimits reports whether spend of a key is limited.
+func (rk *ResponseKey) HasCostLimits() bool {
+	return rk.CostLimitInUsd > 0 || rk.CostLimitInUsdOverTime > 0
+}
+
+// BudgetAccessCacheKey returns the access cache key that marks a key as having
+// reached its cost limits. It is kept apart from the key id that marks rate
+// limited keys so that both denials can be told apart.
+func BudgetAccessCacheKey(keyId string) string {
+	return keyId + ":budget"
+}
@@ -0,0 +1,60 @@
+package key
+
+import "slices"
+
+// FilePurposes are the purposes accepted by the OpenAI files API.
+var FilePurposes = []string{"assistants", "batch", "fine-tune", "vision", "user_data", "evals"}
+
+const (
+	minFileExpiresAfterInSeconds = 3600
+	maxFileExpiresAfterInSeconds = 2592000
+)
+
+// FilePolicy restricts the files uploaded with a key through the files API.
+// Files are deleted by OpenAI once they expire when ExpiresAfterInSeconds is
+// set and the upload does not specify its own expiration.
+type FilePolicy struct {
+	MaxFileSizeInBytes    int64    `json:"maxFileSizeInBytes"`
+	AllowedPurposes       []string `json:"allowedPurposes"`
+	ExpiresAfterInSeconds int      `json:"expiresAfterInSeconds"`
+}
+
+// Validate returns the names of invalid fields of a file policy.
+func (fp *FilePolicy) Validate() []string {
+	invalid := []string{}
+	if fp.MaxFileSizeInBytes < 0 {
+		invalid = append(invalid, "filePolicy.maxFileSizeInBytes")
+	}
+
+	for _, purpose := range fp.AllowedPurposes {
+		if !slices.Contains(FilePurposes, purpose) {
+			invalid = append(invalid, "filePolicy.allowedPurposes")
+			break
+		}
+	}
+
+	if fp.ExpiresAfterInSeconds != 0 && (fp.ExpiresAfterInSeconds < minFileExpiresAfterInSeconds || fp.ExpiresAfterInSeconds > maxFileExpiresAfterInSeconds) {
+		invalid = append(invalid, "filePolicy.expiresAfterInSeconds")
+	}
+
+	return invalid
+}
+
+// IsPurposeAllowed reports whether files with the purpose can be uploaded.
+// Every purpose is allowed when no purposes are listed.
+func (fp *FilePolicy) IsPurposeAllowed(purpose string) bool {
+	if fp == nil || len(fp.AllowedPurposes) == 0 {
+		return true
+	}
+
+	return slices.Contains(fp.AllowedPurposes, purpose)
+}
+
+// ExceedsMaxFileSize reports whether a file of the size cannot be uploaded.
+func (fp *FilePolicy) ExceedsMaxFileSize(size int64) bool {
+	if fp == nil || fp.MaxFileSizeInBytes == 0 {
+		return false
+	}
+
+	return size > fp.MaxFileSizeInBytes
+}
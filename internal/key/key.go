@@ -12,25 +12,38 @@ import (
 const RevokedReasonExpired string = "expired"
 
 type UpdateKey struct {
-	Name                   string        `json:"name"`
-	UpdatedAt              int64         `json:"updatedAt"`
-	Tags                   []string      `json:"tags"`
-	Revoked                *bool         `json:"revoked"`
-	RevokedReason          string        `json:"revokedReason"`
-	Key                    string        `json:"key"`
-	SettingId              string        `json:"settingId"`
-	SettingIds             []string      `json:"settingIds"`
-	CostLimitInUsd         *float64      `json:"costLimitInUsd"`
-	CostLimitInUsdOverTime *float64      `json:"costLimitInUsdOverTime"`
-	CostLimitInUsdUnit     *TimeUnit     `json:"costLimitInUsdUnit"`
-	RateLimitOverTime      *int          `json:"rateLimitOverTime"`
-	RateLimitUnit          *TimeUnit     `json:"rateLimitUnit"`
-	AllowedPaths           *[]PathConfig `json:"allowedPaths,omitempty"`
-	ShouldLogRequest       *bool         `json:"shouldLogRequest"`
-	ShouldLogResponse      *bool         `json:"shouldLogResponse"`
-	RotationEnabled        *bool         `json:"rotationEnabled"`
-	PolicyId               *string       `json:"policyId"`
-	IsKeyNotHashed         *bool         `json:"isKeyNotHashed"`
+	Name                   string            `json:"name"`
+	UpdatedAt              int64             `json:"updatedAt"`
+	Tags                   []string          `json:"tags"`
+	Revoked                *bool             `json:"revoked"`
+	RevokedReason          string            `json:"revokedReason"`
+	Key                    string            `json:"key"`
+	SettingId              string            `json:"settingId"`
+	SettingIds             []string          `json:"settingIds"`
+	CostLimitInUsd         *float64          `json:"costLimitInUsd"`
+	CostLimitInUsdOverTime *float64          `json:"costLimitInUsdOverTime"`
+	CostLimitInUsdUnit     *TimeUnit         `json:"costLimitInUsdUnit"`
+	RateLimitOverTime      *int              `json:"rateLimitOverTime"`
+	RateLimitUnit          *TimeUnit         `json:"rateLimitUnit"`
+	AllowedPaths           *[]PathConfig     `json:"allowedPaths,omitempty"`
+	ShouldLogRequest       *bool             `json:"shouldLogRequest"`
+	ShouldLogResponse      *bool             `json:"shouldLogResponse"`
+	RotationEnabled        *bool             `json:"rotationEnabled"`
+	RotationStrategy       *string           `json:"rotationStrategy"`
+	PolicyId               *string           `json:"policyId"`
+	IsKeyNotHashed         *bool             `json:"isKeyNotHashed"`
+	SessionCostLimitInUsd  *float64          `json:"sessionCostLimitInUsd"`
+	SessionTokenLimit      *int              `json:"sessionTokenLimit"`
+	RetentionInDays        *int              `json:"retentionInDays"`
+	PayloadRetentionInDays *int              `json:"payloadRetentionInDays"`
+	MetadataOnly           *bool             `json:"metadataOnly"`
+	PolicyExempt           *bool             `json:"policyExempt"`
+	BlockMessage           *BlockMessage     `json:"blockMessage"`
+	FilePolicy             *FilePolicy       `json:"filePolicy"`
+	ModelAliases           map[string]string `json:"modelAliases"`
+	BudgetDowngrade        *BudgetDowngrade  `json:"budgetDowngrade"`
+	Markup                 *Markup           `json:"markup"`
+	Priority               *string           `json:"priority"`
 }
 
 func (uk *UpdateKey) Validate() error {
@@ -51,6 +64,48 @@ func (uk *UpdateKey) Validate() error {
 		invalid = append(invalid, "costLimitInUsd")
 	}
 
+	if uk.SessionCostLimitInUsd != nil && *uk.SessionCostLimitInUsd < 0 {
+		invalid = append(invalid, "sessionCostLimitInUsd")
+	}
+
+	if uk.SessionTokenLimit != nil && *uk.SessionTokenLimit < 0 {
+		invalid = append(invalid, "sessionTokenLimit")
+	}
+
+	if uk.RetentionInDays != nil && *uk.RetentionInDays < 0 {
+		invalid = append(invalid, "retentionInDays")
+	}
+
+	if uk.PayloadRetentionInDays != nil && *uk.PayloadRetentionInDays < 0 {
+		invalid = append(invalid, "payloadRetentionInDays")
+	}
+
+	if uk.BlockMessage != nil {
+		invalid = append(invalid, uk.BlockMessage.Validate()...)
+	}
+
+	if uk.FilePolicy != nil {
+		invalid = append(invalid, uk.FilePolicy.Validate()...)
+	}
+
+	if uk.RotationStrategy != nil && !IsValidRotationStrategy(*uk.RotationStrategy) {
+		invalid = append(invalid, "rotationStrategy")
+	}
+
+	invalid = append(invalid, ValidateModelAliases(uk.ModelAliases)...)
+
+	if uk.BudgetDowngrade != nil && len(uk.BudgetDowngrade.FallbackModels) != 0 {
+		invalid = append(invalid, uk.BudgetDowngrade.Validate()...)
+	}
+
+	if uk.Markup != nil && !uk.Markup.IsEmpty() {
+		invalid = append(invalid, uk.Markup.Validate()...)
+	}
+
+	if uk.Priority != nil && !IsValidPriority(*uk.Priority) {
+		invalid = append(invalid, "priority")
+	}
+
 	if uk.UpdatedAt <= 0 {
 		invalid = append(invalid, "updatedAt")
 	}
@@ -152,26 +207,39 @@ type PathConfig struct {
 }
 
 type RequestKey struct {
-	Name                   string       `json:"name"`
-	CreatedAt              int64        `json:"createdAt"`
-	UpdatedAt              int64        `json:"updatedAt"`
-	Tags                   []string     `json:"tags"`
-	KeyId                  string       `json:"keyId"`
-	Key                    string       `json:"key"`
-	CostLimitInUsd         float64      `json:"costLimitInUsd"`
-	CostLimitInUsdOverTime float64      `json:"costLimitInUsdOverTime"`
-	CostLimitInUsdUnit     TimeUnit     `json:"costLimitInUsdUnit"`
-	RateLimitOverTime      int          `json:"rateLimitOverTime"`
-	RateLimitUnit          TimeUnit     `json:"rateLimitUnit"`
-	Ttl                    string       `json:"ttl"`
-	SettingId              string       `json:"settingId"`
-	AllowedPaths           []PathConfig `json:"allowedPaths"`
-	SettingIds             []string     `json:"settingIds"`
-	ShouldLogRequest       bool         `json:"shouldLogRequest"`
-	ShouldLogResponse      bool         `json:"shouldLogResponse"`
-	RotationEnabled        bool         `json:"rotationEnabled"`
-	PolicyId               string       `json:"policyId"`
-	IsKeyNotHashed         bool         `json:"isKeyNotHashed"`
+	Name                   string            `json:"name"`
+	CreatedAt              int64             `json:"createdAt"`
+	UpdatedAt              int64             `json:"updatedAt"`
+	Tags                   []string          `json:"tags"`
+	KeyId                  string            `json:"keyId"`
+	Key                    string            `json:"key"`
+	CostLimitInUsd         float64           `json:"costLimitInUsd"`
+	CostLimitInUsdOverTime float64           `json:"costLimitInUsdOverTime"`
+	CostLimitInUsdUnit     TimeUnit          `json:"costLimitInUsdUnit"`
+	RateLimitOverTime      int               `json:"rateLimitOverTime"`
+	RateLimitUnit          TimeUnit          `json:"rateLimitUnit"`
+	Ttl                    string            `json:"ttl"`
+	SettingId              string            `json:"settingId"`
+	AllowedPaths           []PathConfig      `json:"allowedPaths"`
+	SettingIds             []string          `json:"settingIds"`
+	ShouldLogRequest       bool              `json:"shouldLogRequest"`
+	ShouldLogResponse      bool              `json:"shouldLogResponse"`
+	RotationEnabled        bool              `json:"rotationEnabled"`
+	RotationStrategy       string            `json:"rotationStrategy"`
+	PolicyId               string            `json:"policyId"`
+	IsKeyNotHashed         bool              `json:"isKeyNotHashed"`
+	SessionCostLimitInUsd  float64           `json:"sessionCostLimitInUsd"`
+	SessionTokenLimit      int               `json:"sessionTokenLimit"`
+	RetentionInDays        int               `json:"retentionInDays"`
+	PayloadRetentionInDays int               `json:"payloadRetentionInDays"`
+	MetadataOnly           bool              `json:"metadataOnly"`
+	PolicyExempt           bool              `json:"policyExempt"`
+	BlockMessage           *BlockMessage     `json:"blockMessage"`
+	FilePolicy             *FilePolicy       `json:"filePolicy"`
+	ModelAliases           map[string]string `json:"modelAliases"`
+	BudgetDowngrade        *BudgetDowngrade  `json:"budgetDowngrade"`
+	Markup                 *Markup           `json:"markup"`
+	Priority               string            `json:"priority"`
 }
 
 func (rk *RequestKey) Validate() error {
@@ -232,6 +300,48 @@ func (rk *RequestKey) Validate() error {
 		invalid = append(invalid, "rateLimitOverTime")
 	}
 
+	if rk.SessionCostLimitInUsd < 0 {
+		invalid = append(invalid, "sessionCostLimitInUsd")
+	}
+
+	if rk.SessionTokenLimit < 0 {
+		invalid = append(invalid, "sessionTokenLimit")
+	}
+
+	if rk.RetentionInDays < 0 {
+		invalid = append(invalid, "retentionInDays")
+	}
+
+	if rk.PayloadRetentionInDays < 0 {
+		invalid = append(invalid, "payloadRetentionInDays")
+	}
+
+	if rk.BlockMessage != nil {
+		invalid = append(invalid, rk.BlockMessage.Validate()...)
+	}
+
+	if rk.FilePolicy != nil {
+		invalid = append(invalid, rk.FilePolicy.Validate()...)
+	}
+
+	if !IsValidRotationStrategy(rk.RotationStrategy) {
+		invalid = append(invalid, "rotationStrategy")
+	}
+
+	invalid = append(invalid, ValidateModelAliases(rk.ModelAliases)...)
+
+	if rk.BudgetDowngrade != nil {
+		invalid = append(invalid, rk.BudgetDowngrade.Validate()...)
+	}
+
+	if rk.Markup != nil {
+		invalid = append(invalid, rk.Markup.Validate()...)
+	}
+
+	if !IsValidPriority(rk.Priority) {
+		invalid = append(invalid, "priority")
+	}
+
 	if len(rk.Ttl) != 0 {
 		_, err := time.ParseDuration(rk.Ttl)
 		if err != nil {
@@ -299,28 +409,42 @@ const (
 )
 
 type ResponseKey struct {
-	Name                   string       `json:"name"`
-	CreatedAt              int64        `json:"createdAt"`
-	UpdatedAt              int64        `json:"updatedAt"`
-	Tags                   []string     `json:"tags"`
-	KeyId                  string       `json:"keyId"`
-	Revoked                bool         `json:"revoked"`
-	Key                    string       `json:"key"`
-	RevokedReason          string       `json:"revokedReason"`
-	CostLimitInUsd         float64      `json:"costLimitInUsd"`
-	CostLimitInUsdOverTime float64      `json:"costLimitInUsdOverTime"`
-	CostLimitInUsdUnit     TimeUnit     `json:"costLimitInUsdUnit"`
-	RateLimitOverTime      int          `json:"rateLimitOverTime"`
-	RateLimitUnit          TimeUnit     `json:"rateLimitUnit"`
-	Ttl                    string       `json:"ttl"`
-	SettingId              string       `json:"settingId"`
-	AllowedPaths           []PathConfig `json:"allowedPaths"`
-	SettingIds             []string     `json:"settingIds"`
-	ShouldLogRequest       bool         `json:"shouldLogRequest"`
-	ShouldLogResponse      bool         `json:"shouldLogResponse"`
-	RotationEnabled        bool         `json:"rotationEnabled"`
-	PolicyId               string       `json:"policyId"`
-	IsKeyNotHashed         bool         `json:"isKeyNotHashed"`
+	Name                   string            `json:"name"`
+	CreatedAt              int64             `json:"createdAt"`
+	UpdatedAt              int64             `json:"updatedAt"`
+	Tags                   []string          `json:"tags"`
+	KeyId                  string            `json:"keyId"`
+	Revoked                bool              `json:"revoked"`
+	Key                    string            `json:"key"`
+	RevokedReason          string            `json:"revokedReason"`
+	CostLimitInUsd         float64           `json:"costLimitInUsd"`
+	CostLimitInUsdOverTime float64           `json:"costLimitInUsdOverTime"`
+	CostLimitInUsdUnit     TimeUnit          `json:"costLimitInUsdUnit"`
+	RateLimitOverTime      int               `json:"rateLimitOverTime"`
+	RateLimitUnit          TimeUnit          `json:"rateLimitUnit"`
+	Ttl                    string            `json:"ttl"`
+	SettingId              string            `json:"settingId"`
+	AllowedPaths           []PathConfig      `json:"allowedPaths"`
+	SettingIds             []string          `json:"settingIds"`
+	ShouldLogRequest       bool              `json:"shouldLogRequest"`
+	ShouldLogResponse      bool              `json:"shouldLogResponse"`
+	RotationEnabled        bool              `json:"rotationEnabled"`
+	RotationStrategy       string            `json:"rotationStrategy"`
+	PolicyId               string            `json:"policyId"`
+	IsKeyNotHashed         bool              `json:"isKeyNotHashed"`
+	SessionCostLimitInUsd  float64           `json:"sessionCostLimitInUsd"`
+	SessionTokenLimit      int               `json:"sessionTokenLimit"`
+	RetentionInDays        int               `json:"retentionInDays"`
+	PayloadRetentionInDays int               `json:"payloadRetentionInDays"`
+	MetadataOnly           bool              `json:"metadataOnly"`
+	PolicyExempt           bool              `json:"policyExempt"`
+	LimitOverride          *LimitOverride    `json:"limitOverride"`
+	BlockMessage           *BlockMessage     `json:"blockMessage"`
+	FilePolicy             *FilePolicy       `json:"filePolicy"`
+	ModelAliases           map[string]string `json:"modelAliases"`
+	BudgetDowngrade        *BudgetDowngrade  `json:"budgetDowngrade"`
+	Markup                 *Markup           `json:"markup"`
+	Priority               string            `json:"priority"`
 }
 
 func (rk *ResponseKey) GetSettingIds() []string {
@@ -337,3 +461,55 @@ func (rk *ResponseKey) GetSettingIds() []string {
 
 	return settingIds
 }
+
+// ThreadOwner is the key and custom id that created an OpenAI assistants
+// thread. Usage of runs on the thread is attributed to them.
+type ThreadOwner struct {
+	KeyId    string `json:"keyId"`
+	CustomId string `json:"customId"`
+}
+
+type SessionUsage struct {
+	CostInMicros int64 `json:"costInMicros"`
+	TokenCount   int64 `json:"tokenCount"`
+}
+
+// ExceedsSessionLimits reports whether the usage of a session reached the
+// session limits of the key. Limits that are zero are ignored.
+func (rk *ResponseKey) ExceedsSessionLimits(u *SessionUsage) bool {
+	if u == nil {
+		return false
+	}
+
+	if rk.SessionCostLimitInUsd != 0 && float64(u.CostInMicros) >= rk.SessionCostLimitInUsd*1000000 {
+		return true
+	}
+
+	if rk.SessionTokenLimit != 0 && u.TokenCount >= int64(rk.SessionTokenLimit) {
+		return true
+	}
+
+	return false
+}
+
+func (rk *ResponseKey) HasSessionLimits() bool {
+	return rk.SessionCostLimitInUsd != 0 || rk.SessionTokenLimit != 0
+}
+
+// ShouldStoreRequest reports whether request payloads are stored on the
+// events of the key. Payloads of metadata only keys are never written.
+func (rk *ResponseKey) ShouldStoreRequest() bool {
+	return rk.ShouldLogRequest && !rk.MetadataOnly
+}
+
+// ShouldStoreResponse reports whether response payloads are stored on the
+// events of the key. Payloads of metadata only keys are never written.
+func (rk *ResponseKey) ShouldStoreResponse() bool {
+	return rk.ShouldLogResponse && !rk.MetadataOnly
+}
+
+// HasRetentionPolicy returns true if events or logged payloads of the key
+// should be removed by the retention job.
+func (rk *ResponseKey) HasRetentionPolicy() bool {
+	return rk.RetentionInDays != 0 || rk.PayloadRetentionInDays != 0 || rk.MetadataOnly
+}
@@ -0,0 +1,27 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldStorePayloads(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          *ResponseKey
+		wantRequest  bool
+		wantResponse bool
+	}{
+		{name: "logged", key: &ResponseKey{ShouldLogRequest: true, ShouldLogResponse: true}, wantRequest: true, wantResponse: true},
+		{name: "not logged", key: &ResponseKey{}},
+		{name: "metadata only", key: &ResponseKey{ShouldLogRequest: true, ShouldLogResponse: true, MetadataOnly: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantRequest, tt.key.ShouldStoreRequest())
+			assert.Equal(t, tt.wantResponse, tt.key.ShouldStoreResponse())
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package key
+
+import "fmt"
+
+// Markup prices requests of a key for platform teams reselling LLM access.
+// Customer facing prices are recorded on events alongside raw provider costs.
+// Requests for models with a price override are priced by their token counts
+// while other requests are priced at their cost marked up by the percentage.
+// Markups are scoped to keys since the gateway has no notion of
+// organizations. Keys of an organization share a markup by being given the
+// same one.
+type Markup struct {
+	Percentage  float64                `json:"percentage"`
+	ModelPrices map[string]*ModelPrice `json:"modelPrices"`
+}
+
+// ModelPrice is the customer facing price of a model in USD per million
+// tokens.
+type ModelPrice struct {
+	PromptCostPerMillionTokens     float64 `json:"promptCostPerMillionTokens"`
+	CompletionCostPerMillionTokens float64 `json:"completionCostPerMillionTokens"`
+}
+
+// IsEmpty reports whether the markup neither marks up costs nor overrides
+// prices. Empty markups remove the markup of a key when it is updated.
+func (m *Markup) IsEmpty() bool {
+	return m.Percentage == 0 && len(m.ModelPrices) == 0
+}
+
+// Validate returns the names of invalid fields of a markup.
+func (m *Markup) Validate() []string {
+	invalid := []string{}
+	if m.Percentage < 0 {
+		invalid = append(invalid, "markup.percentage")
+	}
+
+	for model, price := range m.ModelPrices {
+		if len(model) == 0 || price == nil || price.PromptCostPerMillionTokens < 0 || price.CompletionCostPerMillionTokens < 0 {
+			invalid = append(invalid, fmt.Sprintf("markup.modelPrices.%s", model))
+		}
+	}
+
+	return invalid
+}
+
+// Price returns the customer facing price of a request with a raw provider
+// cost. Price overrides only apply to requests with token counts.
+func (m *Markup) Price(model string, costInUsd float64, promptTks, completionTks int) float64 {
+	if m == nil {
+		return costInUsd
+	}
+
+	if price, ok := m.ModelPrices[model]; ok && price != nil && (promptTks != 0 || completionTks != 0) {
+		return (float64(promptTks)*price.PromptCostPerMillionTokens + float64(completionTks)*price.CompletionCostPerMillionTokens) / 1000000
+	}
+
+	return costInUsd * (1 + m.Percentage/100)
+}
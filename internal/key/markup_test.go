@@ -0,0 +1,37 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkupPrice(t *testing.T) {
+	m := &Markup{
+		Percentage: 50,
+		ModelPrices: map[string]*ModelPrice{
+			"gpt-4o": {PromptCostPerMillionTokens: 10, CompletionCostPerMillionTokens: 20},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		markup        *Markup
+		model         string
+		cost          float64
+		promptTks     int
+		completionTks int
+		want          float64
+	}{
+		{name: "no markup", model: "gpt-4o", cost: 2, promptTks: 100, completionTks: 100, want: 2},
+		{name: "marked up", markup: m, model: "gpt-3.5-turbo", cost: 2, promptTks: 100, completionTks: 100, want: 3},
+		{name: "price override", markup: m, model: "gpt-4o", cost: 2, promptTks: 1000000, completionTks: 500000, want: 20},
+		{name: "price override without tokens", markup: m, model: "gpt-4o", cost: 2, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.markup.Price(tt.model, tt.cost, tt.promptTks, tt.completionTks), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package key
+
+import (
+	"fmt"
+	"sort"
+)
+
+const maxModelNameLength = 255
+
+// ValidateModelAliases returns the names of invalid model aliases. Aliases
+// must point to a model rather than another alias.
+func ValidateModelAliases(aliases map[string]string) []string {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+
+	sort.Strings(names)
+
+	invalid := []string{}
+	for _, alias := range names {
+		model := aliases[alias]
+		if len(alias) == 0 || len(alias) > maxModelNameLength || len(model) == 0 || len(model) > maxModelNameLength || alias == model {
+			invalid = append(invalid, fmt.Sprintf("modelAliases.%s", alias))
+			continue
+		}
+
+		if _, ok := aliases[model]; ok {
+			invalid = append(invalid, fmt.Sprintf("modelAliases.%s", alias))
+		}
+	}
+
+	return invalid
+}
+
+// ResolveModel returns the model an alias of a key points to. False is
+// returned if the model is not an alias.
+func (rk *ResponseKey) ResolveModel(model string) (string, bool) {
+	if rk == nil || len(model) == 0 {
+		return model, false
+	}
+
+	resolved, ok := rk.ModelAliases[model]
+	if !ok {
+		return model, false
+	}
+
+	return resolved, true
+}
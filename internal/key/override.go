@@ -0,0 +1,104 @@
+package key
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// LimitOverride temporarily replaces the limits of a key until it expires.
+// Limits that are zero keep the values configured on the key.
+type LimitOverride struct {
+	RateLimitOverTime      int     `json:"rateLimitOverTime"`
+	CostLimitInUsdOverTime float64 `json:"costLimitInUsdOverTime"`
+	CostLimitInUsd         float64 `json:"costLimitInUsd"`
+	Reason                 string  `json:"reason"`
+	CreatedAt              int64   `json:"createdAt"`
+	ExpiresAt              int64   `json:"expiresAt"`
+}
+
+type LimitOverrideRequest struct {
+	RateLimitOverTime      int     `json:"rateLimitOverTime"`
+	CostLimitInUsdOverTime float64 `json:"costLimitInUsdOverTime"`
+	CostLimitInUsd         float64 `json:"costLimitInUsd"`
+	Reason                 string  `json:"reason"`
+	Ttl                    string  `json:"ttl"`
+}
+
+func (r *LimitOverrideRequest) Validate() error {
+	invalid := []string{}
+
+	if r.RateLimitOverTime < 0 {
+		invalid = append(invalid, "rateLimitOverTime")
+	}
+
+	if r.CostLimitInUsdOverTime < 0 {
+		invalid = append(invalid, "costLimitInUsdOverTime")
+	}
+
+	if r.CostLimitInUsd < 0 {
+		invalid = append(invalid, "costLimitInUsd")
+	}
+
+	if len(r.Reason) == 0 {
+		invalid = append(invalid, "reason")
+	}
+
+	ttl, err := time.ParseDuration(r.Ttl)
+	if err != nil || ttl <= 0 {
+		invalid = append(invalid, "ttl")
+	}
+
+	if len(invalid) != 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+	}
+
+	if r.RateLimitOverTime == 0 && r.CostLimitInUsdOverTime == 0 && r.CostLimitInUsd == 0 {
+		return internal_errors.NewValidationError("at least one of rateLimitOverTime, costLimitInUsdOverTime and costLimitInUsd is required")
+	}
+
+	return nil
+}
+
+// ToLimitOverride converts the request into an override starting at now.
+func (r *LimitOverrideRequest) ToLimitOverride(now time.Time) *LimitOverride {
+	ttl, _ := time.ParseDuration(r.Ttl)
+
+	return &LimitOverride{
+		RateLimitOverTime:      r.RateLimitOverTime,
+		CostLimitInUsdOverTime: r.CostLimitInUsdOverTime,
+		CostLimitInUsd:         r.CostLimitInUsd,
+		Reason:                 r.Reason,
+		CreatedAt:              now.Unix(),
+		ExpiresAt:              now.Add(ttl).Unix(),
+	}
+}
+
+func (lo *LimitOverride) IsActive(now time.Time) bool {
+	return lo != nil && now.Unix() < lo.ExpiresAt
+}
+
+// WithActiveLimitOverride returns a copy of the key with limits replaced by
+// the limit override if it has not expired. The key is returned as is otherwise.
+func (rk *ResponseKey) WithActiveLimitOverride(now time.Time) *ResponseKey {
+	if rk == nil || !rk.LimitOverride.IsActive(now) {
+		return rk
+	}
+
+	copied := *rk
+	if rk.LimitOverride.RateLimitOverTime != 0 {
+		copied.RateLimitOverTime = rk.LimitOverride.RateLimitOverTime
+	}
+
+	if rk.LimitOverride.CostLimitInUsdOverTime != 0 {
+		copied.CostLimitInUsdOverTime = rk.LimitOverride.CostLimitInUsdOverTime
+	}
+
+	if rk.LimitOverride.CostLimitInUsd != 0 {
+		copied.CostLimitInUsd = rk.LimitOverride.CostLimitInUsd
+	}
+
+	return &copied
+}
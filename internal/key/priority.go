@@ -0,0 +1,32 @@
+package key
+
+// Priority classes order queued requests of keys once the concurrency limits
+// of their provider settings are reached. Requests of keys with a higher
+// priority are sent first. Standard is used if no priority is set.
+const (
+	PriorityInteractive = "interactive"
+	PriorityStandard    = "standard"
+	PriorityBatch       = "batch"
+)
+
+func IsValidPriority(priority string) bool {
+	switch priority {
+	case "", PriorityInteractive, PriorityStandard, PriorityBatch:
+		return true
+	}
+
+	return false
+}
+
+// PriorityRank returns the rank of the priority class of a key. Requests with
+// higher ranks are dequeued first.
+func (rk *ResponseKey) PriorityRank() int {
+	switch rk.Priority {
+	case PriorityInteractive:
+		return 2
+	case PriorityBatch:
+		return 0
+	}
+
+	return 1
+}
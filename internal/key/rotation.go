@@ -0,0 +1,19 @@
+package key
+
+// Rotation strategies select the provider setting of a request among the
+// provider settings of a key that can serve it. Random is used if no strategy
+// is set and rotation is enabled.
+const (
+	RotationStrategyRandom      = "random"
+	RotationStrategyRoundRobin  = "round_robin"
+	RotationStrategyLeastLoaded = "least_loaded"
+)
+
+func IsValidRotationStrategy(strategy string) bool {
+	switch strategy {
+	case "", RotationStrategyRandom, RotationStrategyRoundRobin, RotationStrategyLeastLoaded:
+		return true
+	}
+
+	return false
+}
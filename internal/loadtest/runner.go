@@ -0,0 +1,228 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type RunConfig struct {
+	// Url is the proxy endpoint requests are sent to.
+	Url    string
+	ApiKey string
+	// Body is sent as the body of every request.
+	Body []byte
+	// Concurrency is the number of workers sending requests.
+	Concurrency int
+	// Duration bounds the run. Requests bounds it by number of requests
+	// instead if it is set.
+	Duration time.Duration
+	Requests int
+	// Rate caps the requests sent per second across workers. Zero sends as
+	// fast as the workers can.
+	Rate    float64
+	Timeout time.Duration
+}
+
+// Report summarizes the responses received during a run.
+type Report struct {
+	Start       time.Time
+	Elapsed     time.Duration
+	Requests    int
+	Errors      int
+	StatusCodes map[int]int
+	latencies   []time.Duration
+}
+
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// Succeeded returns the number of responses with a 2xx status.
+func (r *Report) Succeeded() int {
+	succeeded := 0
+	for status, count := range r.StatusCodes {
+		if status >= 200 && status < 300 {
+			succeeded += count
+		}
+	}
+
+	return succeeded
+}
+
+// RateLimited returns the number of responses with a 429 status.
+func (r *Report) RateLimited() int {
+	return r.StatusCodes[http.StatusTooManyRequests]
+}
+
+// Percentile returns the latency below which a fraction p of the responses
+// were received.
+func (r *Report) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+
+	index := int(p * float64(len(r.latencies)-1))
+	return r.latencies[index]
+}
+
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "requests:     %d in %s (%.1f req/s)\n", r.Requests, r.Elapsed.Round(time.Millisecond), r.Throughput())
+	fmt.Fprintf(w, "succeeded:    %d\n", r.Succeeded())
+	fmt.Fprintf(w, "rate limited: %d\n", r.RateLimited())
+	fmt.Fprintf(w, "errors:       %d\n", r.Errors)
+
+	statuses := []int{}
+	for status := range r.StatusCodes {
+		statuses = append(statuses, status)
+	}
+
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "status %d:   %d\n", status, r.StatusCodes[status])
+	}
+
+	fmt.Fprintf(w, "latency:      p50=%s p90=%s p99=%s max=%s\n",
+		r.Percentile(0.5).Round(time.Millisecond),
+		r.Percentile(0.9).Round(time.Millisecond),
+		r.Percentile(0.99).Round(time.Millisecond),
+		r.Percentile(1).Round(time.Millisecond),
+	)
+}
+
+type result struct {
+	status  int
+	latency time.Duration
+	err     error
+}
+
+func send(ctx context.Context, client *http.Client, cfg *RunConfig) *result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Url, bytes.NewReader(cfg.Body))
+	if err != nil {
+		return &result{err: err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+cfg.ApiKey)
+
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return &result{err: err}
+	}
+
+	defer res.Body.Close()
+
+	// latency includes reading streamed responses to the end.
+	_, err = io.Copy(io.Discard, res.Body)
+
+	return &result{
+		status:  res.StatusCode,
+		latency: time.Since(start),
+		err:     err,
+	}
+}
+
+// Run sends requests to the proxy until the duration elapses, the number of
+// requests is sent or the context is cancelled.
+func Run(ctx context.Context, cfg *RunConfig) *Report {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
+	if cfg.Requests == 0 && cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: cfg.Concurrency,
+		},
+	}
+
+	jobs := make(chan struct{})
+	results := make(chan *result, cfg.Concurrency)
+
+	go func() {
+		defer close(jobs)
+
+		var ticker *time.Ticker
+		if cfg.Rate > 0 {
+			ticker = time.NewTicker(time.Duration(float64(time.Second) / cfg.Rate))
+			defer ticker.Stop()
+		}
+
+		for sent := 0; cfg.Requests == 0 || sent < cfg.Requests; sent++ {
+			if ticker != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- struct{}{}:
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range jobs {
+				results <- send(ctx, client, cfg)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &Report{
+		Start:       time.Now(),
+		StatusCodes: map[int]int{},
+	}
+
+	for r := range results {
+		// requests cut off by the end of the run are not counted.
+		if r.err != nil && ctx.Err() != nil {
+			continue
+		}
+
+		report.Requests++
+		if r.err != nil {
+			report.Errors++
+			continue
+		}
+
+		report.StatusCodes[r.status]++
+		report.latencies = append(report.latencies, r.latency)
+	}
+
+	report.Elapsed = time.Since(report.Start)
+	sort.Slice(report.latencies, func(i, j int) bool {
+		return report.latencies[i] < report.latencies[j]
+	})
+
+	return report
+}
@@ -0,0 +1,322 @@
+// Package loadtest implements a synthetic provider stub and a load generator
+// for benchmarking the throughput, rate limiting and event writes of the
+// proxy without sending requests to real providers.
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// Distribution is a normal distribution clamped to [Min, Max]. A zero
+// standard deviation always yields the mean.
+type Distribution struct {
+	Mean   float64
+	StdDev float64
+	Min    float64
+	Max    float64
+}
+
+func (d Distribution) sample(r *rand.Rand) float64 {
+	v := d.Mean
+	if d.StdDev > 0 {
+		v = r.NormFloat64()*d.StdDev + d.Mean
+	}
+
+	if v < d.Min {
+		v = d.Min
+	}
+
+	if d.Max > 0 && v > d.Max {
+		v = d.Max
+	}
+
+	return v
+}
+
+type StubConfig struct {
+	// LatencyMs is the time to the first token or the full response.
+	LatencyMs Distribution
+	// CompletionTokens is the number of tokens of each completion.
+	CompletionTokens Distribution
+	// TokensPerSecond paces streamed tokens. Zero streams without delay.
+	TokensPerSecond float64
+	// ErrorRate is the fraction of requests answered with a 500.
+	ErrorRate float64
+}
+
+// Stub is an OpenAI compatible provider returning synthetic completions. It
+// serves chat completions, completions and embeddings under /v1 and can be
+// used as the url of a vllm provider setting.
+type Stub struct {
+	cfg  *StubConfig
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+func NewStub(cfg *StubConfig) *Stub {
+	return &Stub{
+		cfg:  cfg,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (s *Stub) sample(d Distribution) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return d.sample(s.rand)
+}
+
+func (s *Stub) shouldFail() bool {
+	if s.cfg.ErrorRate <= 0 {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.rand.Float64() < s.cfg.ErrorRate
+}
+
+func (s *Stub) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	mux.HandleFunc("/v1/completions", s.handleCompletions)
+	mux.HandleFunc("/v1/embeddings", s.handleEmbeddings)
+
+	return mux
+}
+
+type stubRequest struct {
+	Model    string          `json:"model"`
+	Stream   bool            `json:"stream"`
+	Messages json.RawMessage `json:"messages"`
+	Prompt   json.RawMessage `json:"prompt"`
+	Input    json.RawMessage `json:"input"`
+}
+
+// promptTokens approximates the number of tokens of a prompt as one token per
+// four bytes.
+func promptTokens(raw json.RawMessage) int {
+	return int(math.Ceil(float64(len(raw)) / 4))
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(goopenai.ErrorResponse{
+		Error: &goopenai.APIError{
+			Type:           "stub_error",
+			Message:        msg,
+			HTTPStatusCode: status,
+		},
+	})
+}
+
+// start decodes a request, waits for the sampled latency and returns the
+// number of completion tokens to generate.
+func (s *Stub) start(w http.ResponseWriter, r *http.Request) (*stubRequest, int, bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method is not allowed")
+		return nil, 0, false
+	}
+
+	req := &stubRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "request body is not valid json")
+		return nil, 0, false
+	}
+
+	select {
+	case <-r.Context().Done():
+		return nil, 0, false
+	case <-time.After(time.Duration(s.sample(s.cfg.LatencyMs) * float64(time.Millisecond))):
+	}
+
+	if s.shouldFail() {
+		writeError(w, http.StatusInternalServerError, "synthetic failure")
+		return nil, 0, false
+	}
+
+	return req, int(s.sample(s.cfg.CompletionTokens)), true
+}
+
+func (s *Stub) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	req, completionTks, ok := s.start(w, r)
+	if !ok {
+		return
+	}
+
+	promptTks := promptTokens(req.Messages)
+	id := fmt.Sprintf("chatcmpl-stub-%d", time.Now().UnixNano())
+
+	if !req.Stream {
+		writeJSON(w, goopenai.ChatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []goopenai.ChatCompletionChoice{{
+				Message: goopenai.ChatCompletionMessage{
+					Role:    goopenai.ChatMessageRoleAssistant,
+					Content: completion(completionTks),
+				},
+				FinishReason: goopenai.FinishReasonStop,
+			}},
+			Usage: goopenai.Usage{
+				PromptTokens:     promptTks,
+				CompletionTokens: completionTks,
+				TotalTokens:      promptTks + completionTks,
+			},
+		})
+		return
+	}
+
+	s.stream(w, r, completionTks, func(token string, last bool) any {
+		chunk := goopenai.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []goopenai.ChatCompletionStreamChoice{{
+				Delta: goopenai.ChatCompletionStreamChoiceDelta{Content: token},
+			}},
+		}
+
+		if last {
+			chunk.Choices[0].FinishReason = goopenai.FinishReasonStop
+		}
+
+		return chunk
+	})
+}
+
+func (s *Stub) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	req, completionTks, ok := s.start(w, r)
+	if !ok {
+		return
+	}
+
+	promptTks := promptTokens(req.Prompt)
+	id := fmt.Sprintf("cmpl-stub-%d", time.Now().UnixNano())
+
+	if !req.Stream {
+		writeJSON(w, goopenai.CompletionResponse{
+			ID:      id,
+			Object:  "text_completion",
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []goopenai.CompletionChoice{{
+				Text:         completion(completionTks),
+				FinishReason: string(goopenai.FinishReasonStop),
+			}},
+			Usage: goopenai.Usage{
+				PromptTokens:     promptTks,
+				CompletionTokens: completionTks,
+				TotalTokens:      promptTks + completionTks,
+			},
+		})
+		return
+	}
+
+	s.stream(w, r, completionTks, func(token string, last bool) any {
+		choice := goopenai.CompletionChoice{Text: token}
+		if last {
+			choice.FinishReason = string(goopenai.FinishReasonStop)
+		}
+
+		return goopenai.CompletionResponse{
+			ID:      id,
+			Object:  "text_completion",
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []goopenai.CompletionChoice{choice},
+		}
+	})
+}
+
+func (s *Stub) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	req, _, ok := s.start(w, r)
+	if !ok {
+		return
+	}
+
+	promptTks := promptTokens(req.Input)
+	embedding := make([]float32, 8)
+	for i := range embedding {
+		embedding[i] = float32(i) / 8
+	}
+
+	writeJSON(w, goopenai.EmbeddingResponse{
+		Object: "list",
+		Model:  goopenai.EmbeddingModel(req.Model),
+		Data: []goopenai.Embedding{{
+			Object:    "embedding",
+			Embedding: embedding,
+		}},
+		Usage: goopenai.Usage{
+			PromptTokens: promptTks,
+			TotalTokens:  promptTks,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func completion(tks int) string {
+	return strings.Repeat(" lorem", tks)
+}
+
+// stream writes one server sent event per token, paced by TokensPerSecond.
+func (s *Stub) stream(w http.ResponseWriter, r *http.Request, tks int, chunk func(token string, last bool) any) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, _ := w.(http.Flusher)
+
+	var interval time.Duration
+	if s.cfg.TokensPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / s.cfg.TokensPerSecond)
+	}
+
+	for i := 0; i < tks; i++ {
+		data, err := json.Marshal(chunk(" lorem", i == tks-1))
+		if err != nil {
+			return
+		}
+
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if interval > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
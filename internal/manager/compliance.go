@@ -0,0 +1,159 @@
+package manager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/compliance"
+)
+
+type complianceStorage interface {
+	GetAuditLogs(start, end int64) ([]*compliance.AuditLog, error)
+	GetKeyHistory(start, end int64) ([]*compliance.KeyHistory, error)
+	GetPolicyDecisions(start, end int64) ([]*compliance.PolicyDecision, error)
+	GetAccessRecords(start, end int64) ([]*compliance.AccessRecord, error)
+}
+
+type ComplianceManager struct {
+	s          complianceStorage
+	signingKey string
+}
+
+func NewComplianceManager(s complianceStorage, signingKey string) *ComplianceManager {
+	return &ComplianceManager{
+		s:          s,
+		signingKey: signingKey,
+	}
+}
+
+func (m *ComplianceManager) collect(req *compliance.ExportRequest) (map[string]any, error) {
+	logs, err := m.s.GetAuditLogs(req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := m.s.GetKeyHistory(req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, err := m.s.GetPolicyDecisions(req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := m.s.GetAccessRecords(req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"audit_logs.json":       logs,
+		"key_history.json":      history,
+		"policy_decisions.json": decisions,
+		"access_records.json":   records,
+	}, nil
+}
+
+func addTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(data)
+	return err
+}
+
+// Export assembles audit logs, key configuration history, policy decisions and
+// access records within the requested time range into a gzipped tar archive.
+// The archive contains a manifest with checksums of every file and a HMAC-SHA256
+// signature of the manifest which is also returned.
+func (m *ComplianceManager) Export(req *compliance.ExportRequest) ([]byte, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if len(m.signingKey) == 0 {
+		return nil, "", errors.New("compliance export signing key is not configured")
+	}
+
+	contents, err := m.collect(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	manifest := &compliance.Manifest{
+		GeneratedAt: now.Unix(),
+		Start:       req.Start,
+		End:         req.End,
+		Files:       map[string]string{},
+	}
+
+	names := []string{}
+	files := map[string][]byte{}
+	for name, content := range contents {
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return nil, "", err
+		}
+
+		sum := sha256.Sum256(data)
+		manifest.Files[name] = hex.EncodeToString(sum[:])
+		files[name] = data
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	mdata, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+
+	mac := hmac.New(sha256.New, []byte(m.signingKey))
+	mac.Write(mdata)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	buf := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+
+	for _, name := range names {
+		if err := addTarFile(tw, name, files[name], now); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := addTarFile(tw, "manifest.json", mdata, now); err != nil {
+		return nil, "", err
+	}
+
+	if err := addTarFile(tw, "manifest.sig", []byte(signature), now); err != nil {
+		return nil, "", err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	if err := gw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), signature, nil
+}
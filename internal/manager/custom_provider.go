@@ -8,6 +8,7 @@ import (
 	"unicode"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 )
@@ -73,6 +74,15 @@ func gatherEmptyFieldsFromRouteConfig(index int, rc *custom.RouteConfig) []strin
 
 }
 
+func validateRouteConfigTemplates(index int, rc *custom.RouteConfig) error {
+	invalid := rc.ValidateTemplates()
+	if len(invalid) != 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("route_configs.[%d].%s cannot be parsed", index, invalid[0]))
+	}
+
+	return nil
+}
+
 func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.UpdateProvider) error {
 	invalidFields := []string{}
 	pathToRouteMap := map[string]*custom.RouteConfig{}
@@ -84,6 +94,10 @@ func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.Upd
 	for index, rc := range updated.RouteConfigs {
 		_, ok := pathToRouteMap[rc.Path]
 
+		if err := validateRouteConfigTemplates(index, rc); err != nil {
+			return err
+		}
+
 		if len(rc.StreamLocation) != 0 {
 			if len(rc.StreamEndWord) == 0 {
 				invalidFields = append(invalidFields, fmt.Sprintf("route_configs.[%d].stream_end_word", index))
@@ -94,6 +108,8 @@ func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.Upd
 			}
 		}
 
+		invalidFields = append(invalidFields, errortemplate.ValidateTemplates(fmt.Sprintf("route_configs.[%d].error_templates", index), rc.ErrorTemplates)...)
+
 		if !ok {
 			duplicates := map[string]struct{}{}
 			_, ok := duplicates[rc.Path]
@@ -109,6 +125,10 @@ func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.Upd
 		}
 	}
 
+	if updated.Auth != nil {
+		invalidFields = append(invalidFields, updated.Auth.Validate()...)
+	}
+
 	if len(invalidFields) != 0 {
 		return internal_errors.NewValidationError(fmt.Sprintf("empty fields in provider: %s", strings.Join(invalidFields, ",")))
 	}
@@ -119,7 +139,7 @@ func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.Upd
 func validateCustomProviderCreation(provider *custom.Provider) error {
 	invalidFields := []string{}
 
-	if provider.Provider == "openai" || provider.Provider == "anthropic" || provider.Provider == "azure" || provider.Provider == "deepinfra" || provider.Provider == "vllm" {
+	if provider.Provider == "openai" || provider.Provider == "anthropic" || provider.Provider == "azure" || provider.Provider == "deepinfra" || provider.Provider == "vllm" || provider.Provider == "vertexai" || provider.Provider == "gemini" || provider.Provider == "mistral" || provider.Provider == "cohere" || provider.Provider == "groq" || provider.Provider == "perplexity" || provider.Provider == "deepseek" || provider.Provider == "xai" || provider.Provider == "openrouter" || isProviderPlugin(provider.Provider) {
 		return internal_errors.NewValidationError("provider cannot be named openai or anthropic")
 	}
 
@@ -147,6 +167,10 @@ func validateCustomProviderCreation(provider *custom.Provider) error {
 				return internal_errors.NewValidationError(`route configs path must start with "/"`)
 			}
 
+			if err := validateRouteConfigTemplates(index, rc); err != nil {
+				return err
+			}
+
 			_, ok := duplicates[rc.Path]
 			if ok {
 				return internal_errors.NewValidationError("route configs cannot contain duplicated paths")
@@ -167,6 +191,7 @@ func validateCustomProviderCreation(provider *custom.Provider) error {
 			}
 
 			invalidFields = append(invalidFields, gatherEmptyFieldsFromRouteConfig(index, rc)...)
+			invalidFields = append(invalidFields, errortemplate.ValidateTemplates(fmt.Sprintf("route_configs.[%d].error_templates", index), rc.ErrorTemplates)...)
 		}
 	}
 
@@ -174,6 +199,10 @@ func validateCustomProviderCreation(provider *custom.Provider) error {
 		return internal_errors.NewValidationError("authentication_param cannot contain white space")
 	}
 
+	if provider.Auth != nil {
+		invalidFields = append(invalidFields, provider.Auth.Validate()...)
+	}
+
 	if len(invalidFields) != 0 {
 		return internal_errors.NewValidationError(fmt.Sprintf("empty fields in provider: %s", strings.Join(invalidFields, ",")))
 	}
@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type erasureStorage interface {
+	GetEventsForErasure(userIds, customIds, correlationIds []string) ([]*event.Event, error)
+	DeleteEventsByIds(ids []string) (int64, error)
+}
+
+type responseCache interface {
+	DeleteBytes(key string) error
+}
+
+type ErasureManager struct {
+	es erasureStorage
+	rc responseCache
+	a  *event.Anonymizer
+}
+
+func NewErasureManager(es erasureStorage, rc responseCache, a *event.Anonymizer) *ErasureManager {
+	return &ErasureManager{
+		es: es,
+		rc: rc,
+		a:  a,
+	}
+}
+
+// computeCacheKeys recomputes route cache keys of a logged request so that
+// cached responses derived from it can be purged.
+func computeCacheKeys(e *event.Event) []string {
+	if len(e.RouteId) == 0 || len(e.Request) == 0 || !strings.HasPrefix(e.Path, "/api/routes") {
+		return nil
+	}
+
+	path := strings.TrimPrefix(e.Path, "/api/routes")
+	keys := []string{}
+
+	ccr := &goopenai.ChatCompletionRequest{}
+	if err := json.Unmarshal(e.Request, ccr); err == nil && len(ccr.Messages) != 0 {
+		keys = append(keys, route.ComputeCacheKeyForChatCompletionRequest(path, ccr))
+	}
+
+	er := &goopenai.EmbeddingRequest{}
+	if err := json.Unmarshal(e.Request, er); err == nil && er.Input != nil {
+		keys = append(keys, route.ComputeCacheKeyForEmbeddingsRequest(path, er))
+	}
+
+	return keys
+}
+
+func (m *ErasureManager) Erase(req *event.ErasureRequest) (*event.ErasureReport, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &event.ErasureReport{
+		RequestedAt:    time.Now().Unix(),
+		UserIds:        req.UserIds,
+		CustomIds:      req.CustomIds,
+		CorrelationIds: req.CorrelationIds,
+		EventIds:       []string{},
+	}
+
+	events, err := m.es.GetEventsForErasure(m.a.AnonymizeIds(req.UserIds), m.a.AnonymizeIds(req.CustomIds), req.CorrelationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range events {
+		report.EventIds = append(report.EventIds, e.Id)
+
+		for _, ck := range computeCacheKeys(e) {
+			err := m.rc.DeleteBytes(ck)
+			if err != nil {
+				telemetry.Incr("bricksllm.manager.erase.delete_cache_error", nil, 1)
+				report.CacheEntriesFailed++
+				continue
+			}
+
+			report.CacheEntriesPurged++
+		}
+	}
+
+	if len(report.EventIds) != 0 {
+		deleted, err := m.es.DeleteEventsByIds(report.EventIds)
+		if err != nil {
+			return nil, err
+		}
+
+		report.EventsDeleted = deleted
+	}
+
+	report.CompletedAt = time.Now().Unix()
+
+	return report, nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/compliance"
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/hasher"
 	"github.com/bricks-cloud/bricksllm/internal/key"
@@ -26,6 +27,8 @@ type Storage interface {
 	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
 	GetKey(keyId string) (*key.ResponseKey, error)
 	GetKeyByHash(hash string) (*key.ResponseKey, error)
+	InsertKeyHistory(h *compliance.KeyHistory) error
+	UpdateKeyLimitOverride(id string, lo *key.LimitOverride, updatedAt int64) (*key.ResponseKey, error)
 }
 
 type costLimitCache interface {
@@ -77,9 +80,13 @@ func (m *Manager) GetKeys(tags, keyIds []string, provider string) ([]*key.Respon
 }
 
 func (m *Manager) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
+	return m.createKey(util.NewUuid(), rk)
+}
+
+func (m *Manager) createKey(id string, rk *key.RequestKey) (*key.ResponseKey, error) {
 	rk.CreatedAt = time.Now().Unix()
 	rk.UpdatedAt = time.Now().Unix()
-	rk.KeyId = util.NewUuid()
+	rk.KeyId = id
 
 	if err := rk.Validate(); err != nil {
 		return nil, err
@@ -113,7 +120,140 @@ func (m *Manager) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		}
 	}
 
-	return m.s.CreateKey(rk)
+	created, err := m.s.CreateKey(rk)
+	if err != nil {
+		return nil, err
+	}
+
+	m.recordKeyHistory(created.KeyId, "created", created)
+
+	return created, nil
+}
+
+// GetKeyByName returns the key declared with a name.
+func (m *Manager) GetKeyByName(name string) (*key.ResponseKey, error) {
+	return m.s.GetKey(util.NewUuidFromName("keys", name))
+}
+
+// UpsertKey creates or updates the key declared with a name. The id of the
+// key is derived from the name so that concurrent upserts converge on the same
+// key. The key secret is only set when the key is created.
+func (m *Manager) UpsertKey(name string, rk *key.RequestKey) (*key.ResponseKey, error) {
+	id := util.NewUuidFromName("keys", name)
+	rk.Name = name
+
+	existing, err := m.s.GetKey(id)
+	if err != nil {
+		if _, ok := err.(notFoundError); !ok {
+			return nil, err
+		}
+
+		created, err := m.createKey(id, rk)
+		if err == nil {
+			return created, nil
+		}
+
+		// the key might have been created by a concurrent upsert.
+		concurrent, gerr := m.s.GetKey(id)
+		if gerr != nil {
+			return nil, err
+		}
+
+		existing = concurrent
+	}
+
+	return m.UpdateKey(id, newDeclaredKeyUpdate(rk, existing))
+}
+
+// newDeclaredKeyUpdate converts a declared key into an update of an existing
+// key. Limits over time are only updated when they changed since updating them
+// resets the spend and rate counters of the key.
+func newDeclaredKeyUpdate(rk *key.RequestKey, existing *key.ResponseKey) *key.UpdateKey {
+	allowedPaths := rk.AllowedPaths
+	if allowedPaths == nil {
+		allowedPaths = []key.PathConfig{}
+	}
+
+	uk := &key.UpdateKey{
+		Name:                   rk.Name,
+		Tags:                   rk.Tags,
+		SettingId:              rk.SettingId,
+		SettingIds:             rk.SettingIds,
+		CostLimitInUsd:         &rk.CostLimitInUsd,
+		AllowedPaths:           &allowedPaths,
+		ShouldLogRequest:       &rk.ShouldLogRequest,
+		ShouldLogResponse:      &rk.ShouldLogResponse,
+		RotationEnabled:        &rk.RotationEnabled,
+		RotationStrategy:       &rk.RotationStrategy,
+		SessionCostLimitInUsd:  &rk.SessionCostLimitInUsd,
+		SessionTokenLimit:      &rk.SessionTokenLimit,
+		RetentionInDays:        &rk.RetentionInDays,
+		PayloadRetentionInDays: &rk.PayloadRetentionInDays,
+		MetadataOnly:           &rk.MetadataOnly,
+		PolicyExempt:           &rk.PolicyExempt,
+		BlockMessage:           rk.BlockMessage,
+		FilePolicy:             rk.FilePolicy,
+		ModelAliases:           rk.ModelAliases,
+		BudgetDowngrade:        rk.BudgetDowngrade,
+		Markup:                 rk.Markup,
+		Priority:               &rk.Priority,
+	}
+
+	if uk.ModelAliases == nil {
+		uk.ModelAliases = map[string]string{}
+	}
+
+	if uk.BudgetDowngrade == nil {
+		uk.BudgetDowngrade = &key.BudgetDowngrade{}
+	}
+
+	if uk.Markup == nil {
+		uk.Markup = &key.Markup{}
+	}
+
+	if len(rk.PolicyId) != 0 {
+		uk.PolicyId = &rk.PolicyId
+	}
+
+	if rk.CostLimitInUsdOverTime != existing.CostLimitInUsdOverTime || rk.CostLimitInUsdUnit != existing.CostLimitInUsdUnit {
+		uk.CostLimitInUsdOverTime = &rk.CostLimitInUsdOverTime
+		uk.CostLimitInUsdUnit = &rk.CostLimitInUsdUnit
+	}
+
+	if rk.RateLimitOverTime != existing.RateLimitOverTime || rk.RateLimitUnit != existing.RateLimitUnit {
+		uk.RateLimitOverTime = &rk.RateLimitOverTime
+		uk.RateLimitUnit = &rk.RateLimitUnit
+	}
+
+	return uk
+}
+
+// recordKeyHistory stores a snapshot of a key configuration for compliance
+// exports. The key secret is never part of the snapshot.
+func (m *Manager) recordKeyHistory(keyId, action string, k *key.ResponseKey) {
+	snapshot := []byte(`{}`)
+	if k != nil {
+		copied := *k
+		copied.Key = ""
+
+		data, err := json.Marshal(copied)
+		if err != nil {
+			telemetry.Incr("bricksllm.manager.record_key_history.json_marshal_error", nil, 1)
+			return
+		}
+
+		snapshot = data
+	}
+
+	err := m.s.InsertKeyHistory(&compliance.KeyHistory{
+		KeyId:     keyId,
+		Action:    action,
+		CreatedAt: time.Now().Unix(),
+		Snapshot:  snapshot,
+	})
+	if err != nil {
+		telemetry.Incr("bricksllm.manager.record_key_history.insert_error", nil, 1)
+	}
 }
 
 func (m *Manager) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error) {
@@ -170,7 +310,7 @@ func (m *Manager) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, err
 	}
 
 	if uk.CostLimitInUsdUnit != nil || uk.RateLimitUnit != nil {
-		err := m.ac.Delete(id)
+		err := m.deleteAccessStatus(id)
 		if err != nil {
 			return nil, err
 		}
@@ -195,6 +335,8 @@ func (m *Manager) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, err
 		telemetry.Incr("bricksllm.manager.update_key.delete_cache_error", nil, 1)
 	}
 
+	m.recordKeyHistory(id, "updated", updated)
+
 	return updated, nil
 }
 
@@ -230,5 +372,90 @@ func (m *Manager) GetKeyViaCache(raw string) (*key.ResponseKey, error) {
 }
 
 func (m *Manager) DeleteKey(id string) error {
-	return m.s.DeleteKey(id)
+	err := m.s.DeleteKey(id)
+	if err != nil {
+		return err
+	}
+
+	m.recordKeyHistory(id, "deleted", nil)
+
+	return nil
+}
+
+// SetLimitOverride grants a key temporarily boosted limits that expire
+// automatically after the requested ttl.
+func (m *Manager) SetLimitOverride(id string, r *key.LimitOverrideRequest) (*key.ResponseKey, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.s.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.RateLimitOverTime != 0 && len(existing.RateLimitUnit) == 0 {
+		return nil, internal_errors.NewValidationError("rateLimitOverTime cannot be overridden for a key without rateLimitUnit")
+	}
+
+	if r.CostLimitInUsdOverTime != 0 && len(existing.CostLimitInUsdUnit) == 0 {
+		return nil, internal_errors.NewValidationError("costLimitInUsdOverTime cannot be overridden for a key without costLimitInUsdUnit")
+	}
+
+	now := time.Now()
+	updated, err := m.s.UpdateKeyLimitOverride(id, r.ToLimitOverride(now), now.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.deleteAccessStatus(id)
+	if err != nil {
+		telemetry.Incr("bricksllm.manager.set_limit_override.delete_access_cache_error", nil, 1)
+	}
+
+	err = m.kc.Delete(existing.Key)
+	if err != nil {
+		telemetry.Incr("bricksllm.manager.set_limit_override.delete_cache_error", nil, 1)
+	}
+
+	m.recordKeyHistory(id, "limit_override_granted", updated)
+
+	return updated, nil
+}
+
+// deleteAccessStatus lets a key through the access cache again after it was
+// rate limited or reached its cost limits.
+func (m *Manager) deleteAccessStatus(id string) error {
+	if err := m.ac.Delete(id); err != nil {
+		return err
+	}
+
+	return m.ac.Delete(key.BudgetAccessCacheKey(id))
+}
+
+// DeleteLimitOverride removes the limit override of a key before it expires.
+func (m *Manager) DeleteLimitOverride(id string) (*key.ResponseKey, error) {
+	existing, err := m.s.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := m.s.UpdateKeyLimitOverride(id, nil, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.deleteAccessStatus(id)
+	if err != nil {
+		telemetry.Incr("bricksllm.manager.delete_limit_override.delete_access_cache_error", nil, 1)
+	}
+
+	err = m.kc.Delete(existing.Key)
+	if err != nil {
+		telemetry.Incr("bricksllm.manager.delete_limit_override.delete_cache_error", nil, 1)
+	}
+
+	m.recordKeyHistory(id, "limit_override_removed", updated)
+
+	return updated, nil
 }
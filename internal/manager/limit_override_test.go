@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/compliance"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overrideStorage stores a single key. Methods the limit override endpoints
+// do not use are left unimplemented.
+type overrideStorage struct {
+	Storage
+	k *key.ResponseKey
+}
+
+func (s *overrideStorage) GetKey(keyId string) (*key.ResponseKey, error) {
+	return s.k, nil
+}
+
+func (s *overrideStorage) UpdateKeyLimitOverride(keyId string, lo *key.LimitOverride, updatedAt int64) (*key.ResponseKey, error) {
+	s.k.LimitOverride = lo
+	s.k.UpdatedAt = updatedAt
+	return s.k, nil
+}
+
+func (s *overrideStorage) InsertKeyHistory(kh *compliance.KeyHistory) error {
+	return nil
+}
+
+// deletedKeys records the keys deleted from a cache.
+type deletedKeys struct {
+	keys []string
+}
+
+func (d *deletedKeys) Delete(keyId string) error {
+	d.keys = append(d.keys, keyId)
+	return nil
+}
+
+func (d *deletedKeys) Set(keyId string, value interface{}, ttl time.Duration) error {
+	return nil
+}
+
+func (d *deletedKeys) Get(keyId string) (*key.ResponseKey, error) {
+	return nil, nil
+}
+
+func TestLimitOverrideInvalidatesCaches(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(m *Manager) error
+	}{
+		{
+			name: "set",
+			update: func(m *Manager) error {
+				_, err := m.SetLimitOverride("id", &key.LimitOverrideRequest{CostLimitInUsd: 10, Reason: "launch", Ttl: "1h"})
+				return err
+			},
+		},
+		{
+			name: "delete",
+			update: func(m *Manager) error {
+				_, err := m.DeleteLimitOverride("id")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ac := &deletedKeys{}
+			kc := &deletedKeys{}
+			m := NewManager(&overrideStorage{k: &key.ResponseKey{KeyId: "id", Key: "hashed"}}, nil, nil, ac, kc)
+
+			require.NoError(t, tt.update(m))
+			assert.Equal(t, []string{"id", key.BudgetAccessCacheKey("id")}, ac.keys)
+			assert.Equal(t, []string{"hashed"}, kc.keys)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+)
+
+type ModelPricingsStorage interface {
+	UpsertModelPricing(mp *openai.ModelPricing) (*openai.ModelPricing, error)
+	DeleteModelPricing(model string, updatedAt int64) error
+	GetModelPricings() ([]*openai.ModelPricing, error)
+}
+
+type ModelPricingsMemStorage interface {
+	SetPricing(mp *openai.ModelPricing)
+}
+
+type ModelPricingsManager struct {
+	Storage ModelPricingsStorage
+	Mem     ModelPricingsMemStorage
+}
+
+func NewModelPricingsManager(s ModelPricingsStorage, mem ModelPricingsMemStorage) *ModelPricingsManager {
+	return &ModelPricingsManager{
+		Storage: s,
+		Mem:     mem,
+	}
+}
+
+// UpsertPricing prices an OpenAI model. Kinds of costs left out of the
+// pricing fall back to the built in costs of the model.
+func (m *ModelPricingsManager) UpsertPricing(mp *openai.ModelPricing) (*openai.ModelPricing, error) {
+	if invalid := mp.Validate(); len(invalid) != 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("invalid fields in model pricing: %s", strings.Join(invalid, ",")))
+	}
+
+	mp.CreatedAt = time.Now().Unix()
+	mp.UpdatedAt = time.Now().Unix()
+
+	upserted, err := m.Storage.UpsertModelPricing(mp)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Mem.SetPricing(upserted)
+
+	return upserted, nil
+}
+
+// DeletePricing deletes a pricing managed via the admin server. Built in
+// costs of the deleted model apply again.
+func (m *ModelPricingsManager) DeletePricing(model string) error {
+	if err := m.Storage.DeleteModelPricing(model, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	m.Mem.SetPricing(&openai.ModelPricing{Model: model, Deleted: true})
+
+	return nil
+}
+
+// GetPricings returns the pricings managed via the admin server along with
+// the built in pricings they do not override.
+func (m *ModelPricingsManager) GetPricings() ([]*openai.ModelPricing, error) {
+	stored, err := m.Storage.GetModelPricings()
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := map[string]bool{}
+	for _, mp := range stored {
+		overridden[mp.Model] = true
+	}
+
+	pricings := stored
+	for model, costs := range openai.DefaultModelPricings() {
+		if !overridden[model] {
+			pricings = append(pricings, &openai.ModelPricing{
+				Model:   model,
+				Costs:   costs,
+				BuiltIn: true,
+			})
+		}
+	}
+
+	sort.Slice(pricings, func(i, j int) bool {
+		return pricings[i].Model < pricings[j].Model
+	})
+
+	return pricings, nil
+}
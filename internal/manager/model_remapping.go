@@ -0,0 +1,149 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+type ModelRemappingsStorage interface {
+	UpsertModelRemapping(mr *provider.ModelRemapping) (*provider.ModelRemapping, error)
+	DeleteModelRemapping(model string, updatedAt int64) error
+	GetModelRemappings() ([]*provider.ModelRemapping, error)
+}
+
+type ModelRemappingsMemStorage interface {
+	GetRemapping(model string) (*provider.ModelRemapping, bool)
+	SetRemapping(mr *provider.ModelRemapping)
+}
+
+type ModelRemappingsManager struct {
+	Storage ModelRemappingsStorage
+	Mem     ModelRemappingsMemStorage
+	// BuiltIn enables the default remappings of retired models.
+	BuiltIn bool
+}
+
+func NewModelRemappingsManager(s ModelRemappingsStorage, mem ModelRemappingsMemStorage, builtIn bool) *ModelRemappingsManager {
+	return &ModelRemappingsManager{
+		Storage: s,
+		Mem:     mem,
+		BuiltIn: builtIn,
+	}
+}
+
+// remapping returns the remapping of a model. Remappings managed via the
+// admin server take precedence over the default ones.
+func (m *ModelRemappingsManager) remapping(model string) (*provider.ModelRemapping, bool) {
+	if mr, ok := m.Mem.GetRemapping(model); ok {
+		return mr, true
+	}
+
+	if !m.BuiltIn {
+		return nil, false
+	}
+
+	return provider.DefaultModelRemapping(model)
+}
+
+// UpsertRemapping remaps requests for a deprecated model to a successor.
+func (m *ModelRemappingsManager) UpsertRemapping(mr *provider.ModelRemapping) (*provider.ModelRemapping, error) {
+	if invalid := mr.Validate(); len(invalid) != 0 {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("invalid fields in model remapping: %s", strings.Join(invalid, ",")))
+	}
+
+	// remappings leading back to the deprecated model would never resolve.
+	if _, ok := provider.ResolveSuccessor(mr.Model, func(model string) (string, bool) {
+		if model == mr.Model {
+			return mr.Successor, true
+		}
+
+		existing, ok := m.remapping(model)
+		if !ok {
+			return "", false
+		}
+
+		return existing.Successor, true
+	}); !ok {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("remapping %s to %s forms a cycle", mr.Model, mr.Successor))
+	}
+
+	mr.CreatedAt = time.Now().Unix()
+	mr.UpdatedAt = time.Now().Unix()
+
+	upserted, err := m.Storage.UpsertModelRemapping(mr)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Mem.SetRemapping(upserted)
+
+	return upserted, nil
+}
+
+// DeleteRemapping deletes a remapping managed via the admin server. Default
+// remappings of the deleted model apply again.
+func (m *ModelRemappingsManager) DeleteRemapping(model string) error {
+	if err := m.Storage.DeleteModelRemapping(model, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	m.Mem.SetRemapping(&provider.ModelRemapping{Model: model, Deleted: true})
+
+	return nil
+}
+
+// GetRemappings returns the remappings managed via the admin server along
+// with the default remappings they do not override if those are enabled.
+func (m *ModelRemappingsManager) GetRemappings() ([]*provider.ModelRemapping, error) {
+	stored, err := m.Storage.GetModelRemappings()
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := map[string]bool{}
+	for _, mr := range stored {
+		overridden[mr.Model] = true
+	}
+
+	remappings := stored
+	for _, mr := range provider.DefaultModelRemappings {
+		if m.BuiltIn && !overridden[mr.Model] {
+			remappings = append(remappings, &provider.ModelRemapping{
+				Model:     mr.Model,
+				Successor: mr.Successor,
+				Provider:  mr.Provider,
+				Paths:     mr.Paths,
+				BuiltIn:   true,
+			})
+		}
+	}
+
+	sort.Slice(remappings, func(i, j int) bool {
+		return remappings[i].Model < remappings[j].Model
+	})
+
+	return remappings, nil
+}
+
+// ResolveModel returns the model requests of a provider to a proxy path for
+// a deprecated model are upgraded to. False is returned if the model is not
+// deprecated or none of its remappings apply to the request.
+func (m *ModelRemappingsManager) ResolveModel(providerName, path, model string) (string, bool) {
+	if len(model) == 0 {
+		return "", false
+	}
+
+	return provider.ResolveSuccessor(model, func(model string) (string, bool) {
+		mr, ok := m.remapping(model)
+		if !ok || !mr.Applies(providerName, path) {
+			return "", false
+		}
+
+		return mr.Successor, true
+	})
+}
@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticRemappings map[string]*provider.ModelRemapping
+
+func (s staticRemappings) GetRemapping(model string) (*provider.ModelRemapping, bool) {
+	mr, ok := s[model]
+	return mr, ok
+}
+
+func (s staticRemappings) SetRemapping(mr *provider.ModelRemapping) {
+	s[mr.Model] = mr
+}
+
+func TestResolveModel(t *testing.T) {
+	stored := staticRemappings{
+		"old":  {Model: "old", Successor: "new"},
+		"gpt":  {Model: "gpt", Successor: "gpt-new", Provider: "openai", Paths: []string{"/api/providers/openai/v1/chat/completions"}},
+		"ada":  {Model: "ada", Successor: "ada-custom"},
+		"loop": {Model: "loop", Successor: "text-davinci-003"},
+	}
+
+	tests := []struct {
+		name      string
+		builtIn   bool
+		provider  string
+		path      string
+		model     string
+		successor string
+		remapped  bool
+	}{
+		{name: "unscoped", provider: "vllm", path: "/api/providers/vllm/v1/chat/completions", model: "old", successor: "new", remapped: true},
+		{name: "scoped", provider: "openai", path: "/api/providers/openai/v1/chat/completions", model: "gpt", successor: "gpt-new", remapped: true},
+		{name: "other provider", provider: "azure", path: "/api/providers/openai/v1/chat/completions", model: "gpt"},
+		{name: "other path", provider: "openai", path: "/api/providers/openai/v1/completions", model: "gpt"},
+		{name: "built in", builtIn: true, provider: "openai", path: "/api/providers/openai/v1/completions", model: "text-davinci-003", successor: "gpt-3.5-turbo-instruct", remapped: true},
+		{name: "built in disabled", provider: "openai", path: "/api/providers/openai/v1/completions", model: "text-davinci-003"},
+		{name: "built in chained", builtIn: true, provider: "openai", path: "/api/providers/openai/v1/completions", model: "loop", successor: "gpt-3.5-turbo-instruct", remapped: true},
+		{name: "built in on other provider", builtIn: true, provider: "vllm", path: "/api/providers/vllm/v1/completions", model: "text-davinci-003"},
+		{name: "claude instant messages", builtIn: true, provider: "anthropic", path: "/api/providers/anthropic/v1/messages", model: "claude-instant-1.2", successor: "claude-3-haiku-20240307", remapped: true},
+		{name: "claude instant complete", builtIn: true, provider: "anthropic", path: "/api/providers/anthropic/v1/complete", model: "claude-instant-1.2"},
+		{name: "stored overrides built in", builtIn: true, provider: "openai", path: "/api/providers/openai/v1/completions", model: "ada", successor: "ada-custom", remapped: true},
+		{name: "not deprecated", builtIn: true, provider: "openai", path: "/api/providers/openai/v1/chat/completions", model: "gpt-4o"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModelRemappingsManager(nil, stored, tt.builtIn)
+
+			successor, remapped := m.ResolveModel(tt.provider, tt.path, tt.model)
+			assert.Equal(t, tt.remapped, remapped)
+			if tt.remapped {
+				assert.Equal(t, tt.successor, successor)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ type PoliciesStorage interface {
 
 type PoliciesMemStorage interface {
 	GetPolicy(id string) *policy.Policy
+	GetPolicyByKeyTags(tags []string) *policy.Policy
 }
 
 type PolicyManager struct {
@@ -31,6 +32,10 @@ func NewPolicyManager(s PoliciesStorage, memdb PoliciesMemStorage) *PolicyManage
 }
 
 func (m *PolicyManager) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
+	return m.createPolicy(util.NewUuid(), p)
+}
+
+func (m *PolicyManager) createPolicy(id string, p *policy.Policy) (*policy.Policy, error) {
 	err := p.Validate()
 	if err != nil {
 		return nil, err
@@ -38,7 +43,7 @@ func (m *PolicyManager) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 
 	p.CreatedAt = time.Now().Unix()
 	p.UpdatedAt = time.Now().Unix()
-	p.Id = util.NewUuid()
+	p.Id = id
 
 	if p.Config == nil {
 		p.Config = &policy.Config{}
@@ -73,3 +78,137 @@ func (m *PolicyManager) GetPoliciesByTags(tags []string) ([]*policy.Policy, erro
 func (m *PolicyManager) GetPolicyByIdFromMemdb(id string) *policy.Policy {
 	return m.Memdb.GetPolicy(id)
 }
+
+// GetPolicyByKeyTagsFromMemdb returns the policy attached to any of the tags
+// of a key.
+func (m *PolicyManager) GetPolicyByKeyTagsFromMemdb(tags []string) *policy.Policy {
+	return m.Memdb.GetPolicyByKeyTags(tags)
+}
+
+// GetPolicyByName returns the policy declared with a name.
+func (m *PolicyManager) GetPolicyByName(name string) (*policy.Policy, error) {
+	return m.Storage.GetPolicyById(util.NewUuidFromName("policies", name))
+}
+
+// UpsertPolicy creates or updates the policy declared with a name. The id of
+// the policy is derived from the name so that concurrent upserts converge on
+// the same policy.
+func (m *PolicyManager) UpsertPolicy(name string, p *policy.Policy) (*policy.Policy, error) {
+	id := util.NewUuidFromName("policies", name)
+	p.Name = name
+
+	_, err := m.Storage.GetPolicyById(id)
+	if err != nil {
+		if _, ok := err.(notFoundError); !ok {
+			return nil, err
+		}
+
+		created, err := m.createPolicy(id, p)
+		if err == nil {
+			return created, nil
+		}
+
+		// the policy might have been created by a concurrent upsert.
+		if _, gerr := m.Storage.GetPolicyById(id); gerr != nil {
+			return nil, err
+		}
+	}
+
+	up := &policy.UpdatePolicy{
+		Name:                     p.Name,
+		Tags:                     p.Tags,
+		Config:                   p.Config,
+		RegexConfig:              p.RegexConfig,
+		CustomConfig:             p.CustomConfig,
+		AzureContentFilterConfig: p.AzureContentFilterConfig,
+		ModerationConfig:         p.ModerationConfig,
+		StructuredOutputConfig:   p.StructuredOutputConfig,
+		PromptInjectionConfig:    p.PromptInjectionConfig,
+		ExternalInspectionConfig: p.ExternalInspectionConfig,
+		ImageConfig:              p.ImageConfig,
+		WarningConfig:            p.WarningConfig,
+		TopicConfig:              p.TopicConfig,
+		SizeConfig:               p.SizeConfig,
+		LanguageConfig:           p.LanguageConfig,
+		BrandConfig:              p.BrandConfig,
+		ProfanityConfig:          p.ProfanityConfig,
+		KeyTags:                  p.KeyTags,
+	}
+
+	// configs missing from the declaration are reset.
+	if up.Config == nil {
+		up.Config = &policy.Config{}
+	}
+
+	if up.RegexConfig == nil {
+		up.RegexConfig = &policy.RegexConfig{}
+	}
+
+	if up.CustomConfig == nil {
+		up.CustomConfig = &policy.CustomConfig{}
+	}
+
+	if up.AzureContentFilterConfig == nil {
+		up.AzureContentFilterConfig = &policy.AzureContentFilterConfig{
+			Action: policy.Allow,
+		}
+	}
+
+	if up.ModerationConfig == nil {
+		up.ModerationConfig = &policy.ModerationConfig{
+			Action: policy.Allow,
+		}
+	}
+
+	if up.StructuredOutputConfig == nil {
+		up.StructuredOutputConfig = &policy.StructuredOutputConfig{
+			Action: policy.Allow,
+		}
+	}
+
+	if up.PromptInjectionConfig == nil {
+		up.PromptInjectionConfig = &policy.PromptInjectionConfig{
+			Action: policy.Allow,
+		}
+	}
+
+	if up.ExternalInspectionConfig == nil {
+		up.ExternalInspectionConfig = &policy.ExternalInspectionConfig{}
+	}
+
+	if up.ImageConfig == nil {
+		up.ImageConfig = &policy.ImageConfig{
+			Action: policy.Allow,
+		}
+	}
+
+	if up.WarningConfig == nil {
+		up.WarningConfig = &policy.WarningConfig{}
+	}
+
+	if up.TopicConfig == nil {
+		up.TopicConfig = &policy.TopicConfig{}
+	}
+
+	if up.SizeConfig == nil {
+		up.SizeConfig = &policy.SizeConfig{}
+	}
+
+	if up.LanguageConfig == nil {
+		up.LanguageConfig = &policy.LanguageConfig{}
+	}
+
+	if up.BrandConfig == nil {
+		up.BrandConfig = &policy.BrandConfig{}
+	}
+
+	if up.ProfanityConfig == nil {
+		up.ProfanityConfig = &policy.ProfanityConfig{}
+	}
+
+	if up.KeyTags == nil {
+		up.KeyTags = []string{}
+	}
+
+	return m.UpdatePolicy(id, up)
+}
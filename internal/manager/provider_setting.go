@@ -9,6 +9,8 @@ import (
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
+	"github.com/bricks-cloud/bricksllm/internal/provider/xai"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 )
@@ -40,13 +42,19 @@ func NewProviderSettingsManager(s ProviderSettingsStorage, cache ProviderSetting
 }
 
 func isProviderNativelySupported(provider string) bool {
-	return provider == "openai" || provider == "anthropic" || provider == "azure" || provider == "vllm" || provider == "deepinfra" || provider == "bedrock"
+	return provider == "openai" || provider == "anthropic" || provider == "azure" || provider == "vllm" || provider == "deepinfra" || provider == "bedrock" || provider == "vertexai" || provider == "gemini" || provider == "mistral" || provider == "cohere" || provider == "groq" || provider == "perplexity" || provider == "deepseek" || provider == "xai" || provider == "openrouter" || isProviderPlugin(provider)
+}
+
+// isProviderPlugin reports whether a provider is served by a registered
+// provider plugin. Plugins authenticate with the api key of the setting.
+func isProviderPlugin(name string) bool {
+	return provider.GetPlugin(name) != nil
 }
 
 func findMissingAuthParams(providerName string, params map[string]string) string {
 	missingFields := []string{}
 
-	if providerName == "openai" || providerName == "anthropic" || providerName == "deepinfra" {
+	if providerName == "openai" || providerName == "anthropic" || providerName == "deepinfra" || providerName == "gemini" || providerName == "mistral" || providerName == "cohere" || providerName == "groq" || providerName == "perplexity" || providerName == "deepseek" || providerName == "xai" || providerName == "openrouter" || isProviderPlugin(providerName) {
 		val := params["apikey"]
 		if len(val) == 0 {
 			missingFields = append(missingFields, "apikey")
@@ -91,6 +99,18 @@ func findMissingAuthParams(providerName string, params map[string]string) string
 		}
 	}
 
+	if providerName == "vertexai" {
+		val := params["projectId"]
+		if len(val) == 0 {
+			missingFields = append(missingFields, "projectId")
+		}
+
+		val = params["region"]
+		if len(val) == 0 {
+			missingFields = append(missingFields, "region")
+		}
+	}
+
 	return strings.Join(missingFields, ",")
 }
 
@@ -108,6 +128,18 @@ func (m *ProviderSettingsManager) validateSettings(providerName string, setting
 				return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing value for field %s", providerName, provider.AuthenticationParam))
 			}
 		}
+
+		if provider.Auth != nil {
+			for _, param := range provider.Auth.RequiredParams() {
+				if len(setting[param]) == 0 {
+					return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing value for field %s", providerName, param))
+				}
+			}
+
+			if provider.Auth.Scheme == signer.SchemeAwsSigV4 && (len(setting["awsAccessKeyId"]) == 0) != (len(setting["awsSecretAccessKey"]) == 0) {
+				return internal_errors.NewValidationError(fmt.Sprintf("provider %s must have values for both or neither of fields awsAccessKeyId and awsSecretAccessKey", providerName))
+			}
+		}
 	}
 
 	missing := findMissingAuthParams(providerName, setting)
@@ -115,10 +147,48 @@ func (m *ProviderSettingsManager) validateSettings(providerName string, setting
 		return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing fields %s", providerName, missing))
 	}
 
+	if providerName == "vertexai" && len(setting["serviceAccountJson"]) != 0 {
+		if _, err := signer.ParseServiceAccount(setting["serviceAccountJson"]); err != nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("provider %s has invalid serviceAccountJson: %v", providerName, err))
+		}
+	}
+
+	if providerName == "xai" {
+		if err := xai.ValidateApiKey(setting["apikey"]); err != nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("provider %s has invalid apikey: %v", providerName, err))
+		}
+	}
+
+	return nil
+}
+
+func validateDeployments(providerName string, deployments provider.Deployments) error {
+	if len(deployments) == 0 {
+		return nil
+	}
+
+	if providerName != "azure" {
+		return internal_errors.NewValidationError(fmt.Sprintf("provider %s does not support deployments", providerName))
+	}
+
+	for name, deployment := range deployments {
+		if len(name) == 0 {
+			return internal_errors.NewValidationError("deployment model name cannot be empty")
+		}
+
+		if deployment == nil || len(deployment.Deployment) == 0 {
+			return internal_errors.NewValidationError(fmt.Sprintf("deployment for model %s is missing field deployment", name))
+		}
+	}
+
 	return nil
 }
 
 func (m *ProviderSettingsManager) CreateSetting(setting *provider.Setting) (*provider.Setting, error) {
+	return m.createSetting(util.NewUuid(), setting)
+}
+
+func (m *ProviderSettingsManager) createSetting(id string, setting *provider.Setting) (*provider.Setting, error) {
 	if len(setting.Provider) == 0 {
 		return nil, internal_errors.NewValidationError("provider field cannot be empty")
 	}
@@ -127,13 +197,109 @@ func (m *ProviderSettingsManager) CreateSetting(setting *provider.Setting) (*pro
 		return nil, err
 	}
 
-	setting.Id = util.NewUuid()
+	if err := validateDeployments(setting.Provider, setting.Deployments); err != nil {
+		return nil, err
+	}
+
+	if setting.MaxConcurrentRequests < 0 {
+		return nil, internal_errors.NewValidationError("maxConcurrentRequests cannot be negative")
+	}
+
+	if err := validateConcurrency(setting.ModelConcurrencyLimits, setting.ConcurrencyOverflow); err != nil {
+		return nil, err
+	}
+
+	setting.Id = id
 	setting.CreatedAt = time.Now().Unix()
 	setting.UpdatedAt = time.Now().Unix()
 
 	return m.Storage.CreateProviderSetting(setting)
 }
 
+// GetSettingByName returns the provider setting declared with a name. Secrets
+// are omitted.
+func (m *ProviderSettingsManager) GetSettingByName(name string) (*provider.Setting, error) {
+	return m.Storage.GetProviderSetting(util.NewUuidFromName("provider-settings", name), false)
+}
+
+// UpsertSetting creates or updates the provider setting declared with a name.
+// The id of the setting is derived from the name so that concurrent upserts
+// converge on the same setting. The provider of an existing setting cannot be
+// changed.
+func (m *ProviderSettingsManager) UpsertSetting(name string, setting *provider.Setting) (*provider.Setting, error) {
+	id := util.NewUuidFromName("provider-settings", name)
+	setting.Name = name
+
+	existing, err := m.Storage.GetProviderSetting(id, false)
+	if err != nil {
+		if _, ok := err.(notFoundError); !ok {
+			return nil, err
+		}
+
+		created, err := m.createSetting(id, setting)
+		if err == nil {
+			return created, nil
+		}
+
+		// the setting might have been created by a concurrent upsert.
+		concurrent, gerr := m.Storage.GetProviderSetting(id, false)
+		if gerr != nil {
+			return nil, err
+		}
+
+		existing = concurrent
+	}
+
+	if existing.Provider != setting.Provider {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("provider of setting %s cannot be changed from %s", name, existing.Provider))
+	}
+
+	allowedModels := setting.AllowedModels
+	if allowedModels == nil {
+		allowedModels = []string{}
+	}
+
+	costMap := setting.CostMap
+	if costMap == nil {
+		costMap = &provider.CostMap{}
+	}
+
+	deployments := setting.Deployments
+	if deployments == nil {
+		deployments = provider.Deployments{}
+	}
+
+	modelConcurrencyLimits := setting.ModelConcurrencyLimits
+	if modelConcurrencyLimits == nil {
+		modelConcurrencyLimits = map[string]int{}
+	}
+
+	return m.UpdateSetting(id, &provider.UpdateSetting{
+		Setting:                setting.Setting,
+		Name:                   &setting.Name,
+		AllowedModels:          &allowedModels,
+		CostMap:                costMap,
+		Deployments:            &deployments,
+		MaxConcurrentRequests:  &setting.MaxConcurrentRequests,
+		ModelConcurrencyLimits: modelConcurrencyLimits,
+		ConcurrencyOverflow:    &setting.ConcurrencyOverflow,
+	})
+}
+
+func validateConcurrency(limits map[string]int, overflow string) error {
+	for model, limit := range limits {
+		if limit < 0 {
+			return internal_errors.NewValidationError(fmt.Sprintf("modelConcurrencyLimits.%s cannot be negative", model))
+		}
+	}
+
+	if len(overflow) != 0 && overflow != provider.ConcurrencyOverflowQueue && overflow != provider.ConcurrencyOverflowReject {
+		return internal_errors.NewValidationError("concurrencyOverflow must be one of queue or reject")
+	}
+
+	return nil
+}
+
 func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.UpdateSetting) (*provider.Setting, error) {
 	if len(id) == 0 {
 		return nil, internal_errors.NewValidationError("id cannot be empty")
@@ -157,6 +323,25 @@ func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.Upd
 		setting.Setting = merged
 	}
 
+	if setting.Deployments != nil {
+		if err := validateDeployments(existing.Provider, *setting.Deployments); err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.MaxConcurrentRequests != nil && *setting.MaxConcurrentRequests < 0 {
+		return nil, internal_errors.NewValidationError("maxConcurrentRequests cannot be negative")
+	}
+
+	overflow := ""
+	if setting.ConcurrencyOverflow != nil {
+		overflow = *setting.ConcurrencyOverflow
+	}
+
+	if err := validateConcurrency(setting.ModelConcurrencyLimits, overflow); err != nil {
+		return nil, err
+	}
+
 	setting.UpdatedAt = time.Now().Unix()
 
 	err := m.Cache.Delete(id)
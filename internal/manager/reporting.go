@@ -19,12 +19,17 @@ type keyStorage interface {
 type eventStorage interface {
 	GetEvents(userId, customId string, keyIds []string, start, end int64) ([]*event.Event, error)
 	GetEventsV2(req *event.EventRequest) (*event.EventResponse, error)
-	GetEventDataPoints(start, end, increment int64, tags, keyIds, customIds, userIds []string, filters []string) ([]*event.DataPoint, error)
+	GetEventDataPoints(start, end, increment int64, tags, keyIds, customIds, userIds, requestTags []string, filters []string) ([]*event.DataPoint, error)
 	GetLatencyPercentiles(start, end int64, tags, keyIds []string) ([]float64, error)
 	GetAggregatedEventByDayDataPoints(start, end int64, keyIds []string) ([]*event.DataPointV2, error)
 	GetUserIds(keyId string) ([]string, error)
+	GetSessions(keyId string, start, end int64) ([]*event.SessionReporting, error)
+	GetPiiFindings(req *event.PiiFindingsRequest) ([]*event.PiiFindingsRecord, error)
 	GetCustomIds(keyId string) ([]string, error)
 	GetTopKeyDataPoints(start, end int64, tags, keyIds []string, order string, limit, offset int, name string, revoked *bool) ([]*event.KeyDataPoint, error)
+	GetPolicyDataPoints(req *event.PolicyAnalyticsRequest) ([]*event.PolicyDataPoint, error)
+	GetTopPolicyRules(req *event.PolicyAnalyticsRequest, limit int) ([]*event.PolicyRuleCount, error)
+	GetTopPolicyKeys(req *event.PolicyAnalyticsRequest, limit int) ([]*event.PolicyKeyCount, error)
 }
 
 type ReportingManager struct {
@@ -42,7 +47,7 @@ func NewReportingManager(cs costStorage, ks keyStorage, es eventStorage) *Report
 }
 
 func (rm *ReportingManager) GetEventReporting(e *event.ReportingRequest) (*event.ReportingResponse, error) {
-	dataPoints, err := rm.es.GetEventDataPoints(e.Start, e.End, e.Increment, e.Tags, e.KeyIds, e.CustomIds, e.UserIds, e.Filters)
+	dataPoints, err := rm.es.GetEventDataPoints(e.Start, e.End, e.Increment, e.Tags, e.KeyIds, e.CustomIds, e.UserIds, e.RequestTags, e.Filters)
 	if err != nil {
 		return nil, err
 	}
@@ -113,6 +118,10 @@ func (rm *ReportingManager) GetUserIds(keyId string) ([]string, error) {
 	return rm.es.GetUserIds(keyId)
 }
 
+func (rm *ReportingManager) GetSessions(keyId string, start, end int64) ([]*event.SessionReporting, error) {
+	return rm.es.GetSessions(keyId, start, end)
+}
+
 func (rm *ReportingManager) GetKeyReporting(keyId string) (*key.KeyReporting, error) {
 	k, err := rm.ks.GetKey(keyId)
 	if err != nil {
@@ -155,3 +164,76 @@ func (rm *ReportingManager) GetEventsV2(req *event.EventRequest) (*event.EventRe
 
 	return resp, nil
 }
+
+// defaultPolicyAnalyticsLimit is the number of top rules and keys returned
+// by policy analytics requests without a limit.
+const defaultPolicyAnalyticsLimit = 10
+
+// GetPolicyAnalytics returns the rates at which policies blocked, warned and
+// redacted requests over time along with the rules triggering actions and the
+// keys whose requests were acted on the most.
+func (rm *ReportingManager) GetPolicyAnalytics(req *event.PolicyAnalyticsRequest) (*event.PolicyAnalyticsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultPolicyAnalyticsLimit
+	}
+
+	dataPoints, err := rm.es.GetPolicyDataPoints(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dp := range dataPoints {
+		if dp.NumberOfRequests == 0 {
+			continue
+		}
+
+		total := float64(dp.NumberOfRequests)
+		dp.BlockRate = float64(dp.BlockedCount) / total
+		dp.WarnRate = float64(dp.WarnedCount) / total
+		dp.RedactRate = float64(dp.RedactedCount) / total
+	}
+
+	rules, err := rm.es.GetTopPolicyRules(req, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := rm.es.GetTopPolicyKeys(req, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event.PolicyAnalyticsResponse{
+		DataPoints: dataPoints,
+		TopRules:   rules,
+		TopKeys:    keys,
+	}, nil
+}
+
+func (rm *ReportingManager) GetPiiFindings(req *event.PiiFindingsRequest) (*event.PiiFindingsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	records, err := rm.es.GetPiiFindings(req)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := map[string]int{}
+	for _, r := range records {
+		for _, f := range r.Findings {
+			summary[f.Type] += f.Count
+		}
+	}
+
+	return &event.PiiFindingsResponse{
+		Events:  records,
+		Summary: summary,
+	}, nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -14,6 +15,7 @@ import (
 
 type RoutesStorage interface {
 	CreateRoute(r *route.Route) (*route.Route, error)
+	UpdateRoute(r *route.Route) (*route.Route, error)
 	GetRoute(id string) (*route.Route, error)
 	GetRoutes() ([]*route.Route, error)
 	GetRouteByPath(path string) (*route.Route, error)
@@ -74,6 +76,46 @@ func (m *RouteManager) CreateRoute(r *route.Route) (*route.Route, error) {
 	return m.s.CreateRoute(r)
 }
 
+// GetRouteByName returns the route declared with a name.
+func (m *RouteManager) GetRouteByName(name string) (*route.Route, error) {
+	return m.s.GetRoute(util.NewUuidFromName("routes", name))
+}
+
+// UpsertRoute creates or replaces the route declared with a name. The id of
+// the route is derived from the name so that concurrent upserts converge on
+// the same route. Replaced routes stop being served on their previous paths.
+func (m *RouteManager) UpsertRoute(name string, r *route.Route) (*route.Route, error) {
+	r.Id = util.NewUuidFromName("routes", name)
+	r.Name = name
+	r.UpdatedAt = time.Now().Unix()
+
+	if err := m.validateRoute(r); err != nil {
+		return nil, err
+	}
+
+	addDefaultValues(r)
+
+	_, err := m.s.GetRoute(r.Id)
+	if err != nil {
+		if _, ok := err.(notFoundError); !ok {
+			return nil, err
+		}
+
+		r.CreatedAt = r.UpdatedAt
+		created, err := m.s.CreateRoute(r)
+		if err == nil {
+			return created, nil
+		}
+
+		// the route might have been created by a concurrent upsert.
+		if _, gerr := m.s.GetRoute(r.Id); gerr != nil {
+			return nil, err
+		}
+	}
+
+	return m.s.UpdateRoute(r)
+}
+
 func addDefaultValues(r *route.Route) {
 	if r.CacheConfig != nil && r.CacheConfig.Enabled && len(r.CacheConfig.Ttl) == 0 {
 		r.CacheConfig.Ttl = "168h"
@@ -96,6 +138,15 @@ func checkModelValidity(provider, model string) bool {
 		return contains(model, openaiSupportedModels)
 	}
 
+	if provider == "anthropic" {
+		return strings.HasPrefix(model, "claude")
+	}
+
+	// bedrock model ids may be prefixed with the region of an inference profile.
+	if provider == "bedrock" {
+		return strings.Contains(model, "anthropic.claude")
+	}
+
 	return false
 }
 
@@ -247,7 +298,12 @@ var (
 	supportedProviders = []string{
 		"openai",
 		"azure",
+		"anthropic",
 	}
+
+	// stepProviders are the providers of route steps. Bedrock is not supported
+	// by truncation summaries and shadow traffic.
+	stepProviders = append([]string{"bedrock"}, supportedProviders...)
 )
 
 func contains(target string, source []string) bool {
@@ -283,6 +339,14 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		fields = append(fields, "retryStrategy")
 	}
 
+	if !route.IsValidRoutingStrategy(r.RoutingStrategy) {
+		fields = append(fields, "routingStrategy")
+	}
+
+	if len(r.CapabilityTier) != 0 && !provider.IsValidTier(r.CapabilityTier) {
+		fields = append(fields, "capabilityTier")
+	}
+
 	containAda := false
 
 	for index, step := range r.Steps {
@@ -301,8 +365,8 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 			}
 		}
 
-		if !contains(step.Provider, supportedProviders) {
-			return fmt.Errorf("steps.[%d].provider is not supported. Only azure and openai are supported", index)
+		if !contains(step.Provider, stepProviders) {
+			return fmt.Errorf("steps.[%d].provider is not supported. Only azure, openai, anthropic and bedrock are supported", index)
 		}
 
 		if step.Provider == "azure" {
@@ -321,6 +385,10 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 			fields = append(fields, fmt.Sprintf("steps.[%d].model", index))
 		}
 
+		if step.Weight < 0 || step.Weight > 100 {
+			fields = append(fields, fmt.Sprintf("steps.[%d].weight", index))
+		}
+
 		if val, ok := step.RequestParams["frequency_penalty"]; ok {
 			if _, ok := val.(float64); !ok {
 				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.frequency_penalty", index))
@@ -328,7 +396,12 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		}
 
 		if val, ok := step.RequestParams["max_tokens"]; ok {
-			if _, ok := val.(float64); !ok {
+			parsed, ok := val.(float64)
+			if !ok {
+				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.max_tokens", index))
+			}
+
+			if c := provider.GetCapability(step.Model); ok && c != nil && c.MaxOutputTokens != 0 && int(parsed) > c.MaxOutputTokens {
 				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.max_tokens", index))
 			}
 		}
@@ -403,7 +476,7 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 			}
 		}
 
-		if !contains(step.Model, supportedModels) {
+		if step.Provider != "anthropic" && step.Provider != "bedrock" && !contains(step.Model, supportedModels) {
 			return fmt.Errorf("steps.[%d].model is not supported. Only chat completion and embeddings model are supported", index)
 		}
 
@@ -421,11 +494,85 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 			return errors.New("steps must have congruent models. Chat completion and embedding models cannot be in the same route config")
 		}
 
-		if !containAda && !contains(step.Model, chatCompletionModels) {
+		// anthropic and bedrock steps only serve chat completion models.
+		if !containAda && step.Provider != "anthropic" && step.Provider != "bedrock" && !contains(step.Model, chatCompletionModels) {
 			return errors.New("steps must have congruent models. Chat completion and embedding models cannot be in the same route config")
 		}
 	}
 
+	if r.RoutingStrategy == route.RoutingStrategyWeighted && r.TotalWeight() != 100 {
+		return errors.New("weights of the steps of a weighted route must add up to 100")
+	}
+
+	if r.RoutingStrategy == route.RoutingStrategyCost && provider.IsValidTier(r.CapabilityTier) {
+		satisfied := false
+		for _, step := range r.Steps {
+			if provider.GetCapability(step.Model).SatisfiesTier(r.CapabilityTier) {
+				satisfied = true
+				break
+			}
+		}
+
+		if !satisfied {
+			return fmt.Errorf("no steps satisfy the %s capability tier", r.CapabilityTier)
+		}
+	}
+
+	if r.TruncationConfig != nil && r.TruncationConfig.Enabled {
+		fields = append(fields, r.TruncationConfig.Validate()...)
+
+		if len(r.TruncationConfig.SummaryProvider) != 0 && !contains(r.TruncationConfig.SummaryProvider, supportedProviders) {
+			return errors.New("truncationConfig.summaryProvider is not supported. Only azure, openai and anthropic are supported")
+		}
+	}
+
+	if r.RetryConfig != nil {
+		fields = append(fields, r.RetryConfig.Validate()...)
+	}
+
+	if r.ShadowConfig != nil {
+		fields = append(fields, r.ShadowConfig.Validate()...)
+	}
+
+	if r.ShadowConfig.IsEnabled() {
+		if !contains(r.ShadowConfig.Provider, supportedProviders) {
+			return errors.New("shadowConfig.provider is not supported. Only azure, openai and anthropic are supported")
+		}
+
+		if len(r.ShadowConfig.Model) != 0 && !checkModelValidity(r.ShadowConfig.Provider, r.ShadowConfig.Model) {
+			return fmt.Errorf("model: %s is not supported for provider: %s", r.ShadowConfig.Model, r.ShadowConfig.Provider)
+		}
+	}
+
+	if r.GuardrailConfig != nil {
+		if len(r.GuardrailConfig.Identifier) == 0 {
+			fields = append(fields, "guardrailConfig.identifier")
+		}
+
+		hasBedrockStep := false
+		for _, step := range r.Steps {
+			if step.Provider == "bedrock" {
+				hasBedrockStep = true
+				break
+			}
+		}
+
+		if !hasBedrockStep {
+			return errors.New("guardrailConfig requires at least one bedrock step")
+		}
+	}
+
+	for idx, rule := range r.Rules {
+		if rule == nil {
+			fields = append(fields, fmt.Sprintf("rules.[%d]", idx))
+			continue
+		}
+
+		fields = append(fields, rule.Validate(idx, len(r.Steps))...)
+	}
+
+	fields = append(fields, errortemplate.ValidateTemplates("errorTemplates", r.ErrorTemplates)...)
+
 	if r.CacheConfig == nil {
 		fields = append(fields, "cacheConfig")
 	}
@@ -443,6 +590,12 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		}
 	}
 
+	if len(r.PolicyId) != 0 {
+		if _, err := m.ks.GetPolicyById(r.PolicyId); err != nil {
+			return err
+		}
+	}
+
 	found, err := m.ks.GetKeys(nil, r.KeyIds, "")
 	if err != nil {
 		return err
@@ -460,12 +613,13 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		}
 	}
 
-	_, err = m.s.GetRouteByPath(r.Path)
-	if err == nil {
+	// routes replaced by upserts keep their paths.
+	existing, err := m.s.GetRouteByPath(r.Path)
+	if err == nil && existing.Id != r.Id {
 		return internal_errors.NewValidationError("path is not unique")
 	}
 
-	if _, ok := err.(notFoundError); !ok {
+	if _, ok := err.(notFoundError); err != nil && !ok {
 		return err
 	}
 
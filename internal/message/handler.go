@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
@@ -39,6 +40,7 @@ type estimator interface {
 	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
 	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
 	EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error)
+	PricingVersionOf(estimate func()) string
 }
 
 type azureEstimator interface {
@@ -208,6 +210,13 @@ func (h *Handler) handleValidationResult(kc *key.ResponseKey, cost float64) erro
 				err := h.v.Validate(tks[0], cost)
 				if err != nil {
 					if xe, ok := err.(expirationError); ok {
+						// keys with a budget downgrade keep serving fallback models
+						// past their total cost limit.
+						if xe.Reason() == internal_errors.CostLimitExpiration && tks[0].BudgetDowngrade != nil {
+							telemetry.Incr("bricksllm.message.handler.handle_validation_result.budget_downgraded", nil, 1)
+							return nil
+						}
+
 						h.log.Debug("expiration error",
 							zap.String("expired_reason", xe.Reason()),
 							zap.String("key_id", kc.KeyId),
@@ -245,7 +254,14 @@ func (h *Handler) handleValidationResult(kc *key.ResponseKey, cost float64) erro
 		if _, ok := err.(costLimitError); ok {
 			telemetry.Incr("bricksllm.message.handler.handle_validation_result.cost_limit_error", nil, 1)
 
-			err = h.ac.Set(kc.KeyId, kc.CostLimitInUsdUnit)
+			// keys are blocked apart from rate limits so that cost limit denials
+			// are reported as over budget and downgraded requests of keys with
+			// a budget downgrade keep being served.
+			if kc.BudgetDowngrade != nil {
+				telemetry.Incr("bricksllm.message.handler.handle_validation_result.budget_downgraded", nil, 1)
+			}
+
+			err = h.ac.Set(key.BudgetAccessCacheKey(kc.KeyId), kc.CostLimitInUsdUnit)
 			if err != nil {
 				telemetry.Incr("bricksllm.message.handler.handle_validation_result.set_cost_limit_error", nil, 1)
 				return err
@@ -313,6 +329,24 @@ func (h *Handler) handleUserValidationResult(u *user.User, cost float64) error {
 	return nil
 }
 
+// getAttributedKey returns the key an event is attributed to when it differs
+// from the key making the request. The event is attributed back to the key
+// making the request if the attributed key cannot be found.
+func (h *Handler) getAttributedKey(e *event.EventWithRequestAndContent) *key.ResponseKey {
+	tks, err := h.km.GetKeys(nil, []string{e.Event.KeyId}, "")
+	if err != nil {
+		telemetry.Incr("bricksllm.message.handler.get_attributed_key.get_keys_error", nil, 1)
+		h.log.Debug("error when getting attributed key", zap.Error(err))
+	}
+
+	if len(tks) != 1 {
+		e.Event.KeyId = e.Key.KeyId
+		return e.Key
+	}
+
+	return tks[0]
+}
+
 func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 	e, ok := m.Data.(*event.EventWithRequestAndContent)
 	if !ok {
@@ -321,6 +355,13 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 		return errors.New("message data cannot be parsed as event with request and response")
 	}
 
+	// requests are rate limited by the key making them while their spend is
+	// attributed to the key of the event, such as the owner of a thread.
+	requester := e.Key
+	if e.Key != nil && e.Event != nil && e.Event.KeyId != e.Key.KeyId {
+		e.Key = h.getAttributedKey(e)
+	}
+
 	if e.Key != nil && !e.Key.Revoked && e.Event != nil {
 		err := h.decorateEvent(m)
 		if err != nil {
@@ -357,8 +398,18 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 			}
 		}
 
-		if len(e.Key.RateLimitUnit) != 0 {
-			if err := h.rlm.Increment(e.Key.KeyId, e.Key.RateLimitUnit); err != nil {
+		if len(e.Event.SessionId) != 0 {
+			micros := int64(e.Event.CostInUsd * 1000000)
+			tks := e.Event.PromptTokenCount + e.Event.CompletionTokenCount
+			err = h.recorder.RecordSessionUsage(e.Event.KeyId, e.Event.SessionId, micros, tks)
+			if err != nil {
+				telemetry.Incr("bricksllm.message.handler.handle_event_with_request_and_response.record_session_usage_error", nil, 1)
+				h.log.Debug("error when recording session usage", zap.Error(err))
+			}
+		}
+
+		if len(requester.RateLimitUnit) != 0 {
+			if err := h.rlm.Increment(requester.KeyId, requester.RateLimitUnit); err != nil {
 				telemetry.Incr("bricksllm.message.handler.handle_event_with_request_and_response.rate_limit_increment_error", nil, 1)
 
 				h.log.Debug("error when incrementing rate limit", zap.Error(err))
@@ -387,6 +438,10 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 			h.log.Debug("error when handling validation result", zap.Error(err))
 		}
 
+		// customer facing prices are recorded once costs of streamed
+		// responses are decorated. Requests of keys without a markup are
+		// priced at their cost.
+		e.Event.CustomerPriceInUsd = e.Key.Markup.Price(e.Event.Model, e.Event.CostInUsd, e.Event.PromptTokenCount, e.Event.CompletionTokenCount)
 	}
 
 	start := time.Now()
@@ -403,6 +458,53 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 	return nil
 }
 
+// estimateChatCompletionStream returns the prompt tokens, completion tokens and
+// cost of a streamed OpenAI chat completion. Usage reported in the final chunk
+// of streams requested with stream_options.include_usage is authoritative.
+// Otherwise the request and the streamed content are tokenized.
+func (h *Handler) estimateChatCompletionStream(ccr *goopenai.ChatCompletionRequest, e *event.EventWithRequestAndContent) (int, int, float64, error) {
+	if e.StreamUsage != nil {
+		telemetry.Incr("bricksllm.message.handler.decorate_event.stream_usage", nil, 1)
+
+		cost, err := h.e.EstimateTotalCost(e.Event.Model, e.StreamUsage.PromptTokens, e.StreamUsage.CompletionTokens)
+		if err != nil {
+			telemetry.Incr("bricksllm.message.handler.decorate_event.estimate_total_cost_error", nil, 1)
+			return 0, 0, 0, err
+		}
+
+		return e.StreamUsage.PromptTokens, e.StreamUsage.CompletionTokens, cost, nil
+	}
+
+	tks, cost, err := h.e.EstimateChatCompletionPromptCostWithTokenCounts(ccr)
+	if err != nil {
+		telemetry.Incr("bricksllm.message.handler.decorate_event.estimate_chat_completion_prompt_cost_with_token_counts", nil, 1)
+		return 0, 0, 0, err
+	}
+
+	completiontks, completionCost, err := h.e.EstimateChatCompletionStreamCostWithTokenCounts(e.Event.Model, e.Content)
+	if err != nil {
+		telemetry.Incr("bricksllm.message.handler.decorate_event.estimate_chat_completion_stream_cost_with_token_counts", nil, 1)
+		return 0, 0, 0, err
+	}
+
+	return tks, completiontks, cost + completionCost, nil
+}
+
+// countGeneratedCompletionTokens accounts for candidates generated with best_of
+// that are not streamed back to the client. Streamed content already contains
+// all n returned choices.
+func countGeneratedCompletionTokens(tks, n, bestOf int) int {
+	if n < 1 {
+		n = 1
+	}
+
+	if bestOf <= n {
+		return tks
+	}
+
+	return tks * bestOf / n
+}
+
 func (h *Handler) decorateEvent(m Message) error {
 	telemetry.Incr("bricksllm.message.handler.decorate_event.request", nil, 1)
 
@@ -422,7 +524,12 @@ func (h *Handler) decorateEvent(m Message) error {
 		}
 
 		if e.Event.Status == http.StatusOK {
-			cost, err := h.e.EstimateSpeechCost(csr.Input, string(csr.Model))
+			var cost float64
+			var err error
+			e.Event.PricingVersion = h.e.PricingVersionOf(func() {
+				cost, err = h.e.EstimateSpeechCost(csr.Input, string(csr.Model))
+			})
+
 			if err != nil {
 				telemetry.Incr("bricksllm.message.handler.decorate_event.estimate_prompt_cost", nil, 1)
 				h.log.Debug("event contains request that cannot be converted to anthropic completion request", zap.Error(err))
@@ -607,7 +714,8 @@ func (h *Handler) decorateEvent(m Message) error {
 		}
 	}
 
-	if e.Event.Path == "/api/providers/openai/v1/chat/completions" {
+	// unified chat completions served by openai are estimated as openai ones.
+	if e.Event.Path == "/api/providers/openai/v1/chat/completions" || (e.Event.Path == "/api/v1/chat/completions" && e.Event.Provider == "openai") {
 		ccr, ok := e.Request.(*goopenai.ChatCompletionRequest)
 		if !ok {
 			telemetry.Incr("bricksllm.message.handler.decorate_event.event_request_parsing_error", nil, 1)
@@ -616,15 +724,14 @@ func (h *Handler) decorateEvent(m Message) error {
 		}
 
 		if ccr.Stream {
-			tks, cost, err := h.e.EstimateChatCompletionPromptCostWithTokenCounts(ccr)
-			if err != nil {
-				telemetry.Incr("bricksllm.message.handler.decorate_event.estimate_chat_completion_prompt_cost_with_token_counts", nil, 1)
-				return err
-			}
+			var tks, completiontks int
+			var cost float64
+			var err error
+			e.Event.PricingVersion = h.e.PricingVersionOf(func() {
+				tks, completiontks, cost, err = h.estimateChatCompletionStream(ccr, e)
+			})
 
-			completiontks, completionCost, err := h.e.EstimateChatCompletionStreamCostWithTokenCounts(e.Event.Model, e.Content)
 			if err != nil {
-				telemetry.Incr("bricksllm.message.handler.decorate_event.estimate_chat_completion_stream_cost_with_token_counts", nil, 1)
 				return err
 			}
 
@@ -632,7 +739,7 @@ func (h *Handler) decorateEvent(m Message) error {
 			e.Event.CompletionTokenCount = completiontks
 
 			if e.Event.Status == http.StatusOK {
-				e.Event.CostInUsd = cost + completionCost
+				e.Event.CostInUsd = cost
 
 				if e.CostMap != nil {
 					newCost, err := provider.EstimateTotalCostWithCostMaps(e.Event.Model, tks, completiontks, 1000, e.CostMap.PromptCostPerModel, e.CostMap.CompletionCostPerModel)
@@ -659,19 +766,9 @@ func (h *Handler) decorateEvent(m Message) error {
 
 		if ccr.Stream {
 			e.Event.PromptTokenCount = h.vllme.EstimateChatCompletionPromptToken(ccr)
-			e.Event.CompletionTokenCount = h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content)
-			if e.Event.Status == http.StatusOK {
-				if e.CostMap != nil {
-					newCost, err := provider.EstimateTotalCostWithCostMaps(e.Event.Model, e.Event.PromptTokenCount, e.Event.CompletionTokenCount, 1000, e.CostMap.PromptCostPerModel, e.CostMap.CompletionCostPerModel)
-					if err != nil {
-						h.log.Debug("error when estimating total cost with cost maps", zap.Error(err))
-						telemetry.Incr("bricksllm.proxy.decorate_event.estimate_total_cost_with_cost_maps_error", nil, 1)
-					}
-
-					if newCost != 0 {
-						e.Event.CostInUsd = newCost
-					}
-				}
+			e.Event.CompletionTokenCount = countGeneratedCompletionTokens(h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content), ccr.N, ccr.BestOf)
+			if e.Event.Status == http.StatusOK && e.CostMap != nil {
+				e.Event.CostInUsd = provider.EstimateTotalCostWithOptionalCostMaps(e.Event.Model, e.Event.PromptTokenCount, e.Event.CompletionTokenCount, 1000, e.CostMap.PromptCostPerModel, e.CostMap.CompletionCostPerModel)
 			}
 		}
 	}
@@ -686,20 +783,10 @@ func (h *Handler) decorateEvent(m Message) error {
 
 		if cr.Stream {
 			e.Event.PromptTokenCount = h.vllme.EstimateCompletionPromptToken(cr)
-			e.Event.CompletionTokenCount = h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content)
+			e.Event.CompletionTokenCount = countGeneratedCompletionTokens(h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content), cr.N, cr.BestOf)
 
-			if e.Event.Status == http.StatusOK {
-				if e.CostMap != nil {
-					newCost, err := provider.EstimateTotalCostWithCostMaps(e.Event.Model, e.Event.PromptTokenCount, e.Event.CompletionTokenCount, 1000, e.CostMap.PromptCostPerModel, e.CostMap.CompletionCostPerModel)
-					if err != nil {
-						h.log.Debug("error when estimating total cost with cost maps", zap.Error(err))
-						telemetry.Incr("bricksllm.proxy.decorate_event.estimate_total_cost_with_cost_maps_error", nil, 1)
-					}
-
-					if newCost != 0 {
-						e.Event.CostInUsd = newCost
-					}
-				}
+			if e.Event.Status == http.StatusOK && e.CostMap != nil {
+				e.Event.CostInUsd = provider.EstimateTotalCostWithOptionalCostMaps(e.Event.Model, e.Event.PromptTokenCount, e.Event.CompletionTokenCount, 1000, e.CostMap.PromptCostPerModel, e.CostMap.CompletionCostPerModel)
 			}
 		}
 	}
@@ -714,7 +801,7 @@ func (h *Handler) decorateEvent(m Message) error {
 
 		if ccr.Stream {
 			e.Event.PromptTokenCount = h.vllme.EstimateChatCompletionPromptToken(ccr)
-			e.Event.CompletionTokenCount = h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content)
+			e.Event.CompletionTokenCount = countGeneratedCompletionTokens(h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content), ccr.N, ccr.BestOf)
 			if e.Event.Status == http.StatusOK {
 				if e.CostMap != nil {
 					newCost, err := provider.EstimateTotalCostWithCostMaps(e.Event.Model, e.Event.PromptTokenCount, e.Event.CompletionTokenCount, 1000, e.CostMap.PromptCostPerModel, e.CostMap.CompletionCostPerModel)
@@ -741,7 +828,7 @@ func (h *Handler) decorateEvent(m Message) error {
 
 		if cr.Stream {
 			e.Event.PromptTokenCount = h.vllme.EstimateCompletionPromptToken(cr)
-			e.Event.CompletionTokenCount = h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content)
+			e.Event.CompletionTokenCount = countGeneratedCompletionTokens(h.vllme.EstimateContentTokenCounts(e.Event.Model, e.Content), cr.N, cr.BestOf)
 			if e.Event.Status == http.StatusOK {
 				if e.CostMap != nil {
 					newCost, err := provider.EstimateTotalCostWithCostMaps(e.Event.Model, e.Event.PromptTokenCount, e.Event.CompletionTokenCount, 1000, e.CostMap.PromptCostPerModel, e.CostMap.CompletionCostPerModel)
@@ -0,0 +1,204 @@
+package local
+
+import (
+	"math/big"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+)
+
+type pattern struct {
+	entityType string
+	regex      *regexp.Regexp
+	valid      func(match string) bool
+	// group is the capture group holding the entity. The whole match is the
+	// entity if it is zero.
+	group int
+}
+
+// patterns are matched in order. Matches overlapping an earlier match are
+// discarded so that, for example, credit card numbers are not also reported
+// as phone numbers.
+var patterns = append(append([]*pattern{}, secretPatterns...), []*pattern{
+	{
+		entityType: "EMAIL",
+		regex:      regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	{
+		entityType: "INTERNATIONAL_BANK_ACCOUNT_NUMBER",
+		regex:      regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`),
+		valid:      validIban,
+	},
+	{
+		entityType: "CREDIT_DEBIT_NUMBER",
+		regex:      regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		valid:      validCardNumber,
+	},
+	{
+		entityType: "SSN",
+		regex:      regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		valid:      validSsn,
+	},
+	{
+		entityType: "IP_ADDRESS",
+		regex:      regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+		valid: func(match string) bool {
+			return net.ParseIP(match) != nil
+		},
+	},
+	{
+		entityType: "PHONE",
+		regex:      regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{3}\)|\b\d{3})[ .\-]?\d{3}[ .\-]\d{4}\b`),
+	},
+}...)
+
+// Detector detects common PII entities with regular expressions and checksums
+// without calling an external service. Entity types are named after the ones
+// of Amazon Comprehend.
+type Detector struct{}
+
+func NewDetector() *Detector {
+	return &Detector{}
+}
+
+func (d *Detector) Detect(input []string) (*pii.Result, error) {
+	result := &pii.Result{
+		Detections: make([]*pii.Detection, 0, len(input)),
+	}
+
+	for _, text := range input {
+		result.Detections = append(result.Detections, &pii.Detection{
+			Input:    text,
+			Entities: detect(text),
+		})
+	}
+
+	return result, nil
+}
+
+func detect(text string) []*pii.Entity {
+	return detectWith(patterns, text)
+}
+
+func detectWith(patterns []*pattern, text string) []*pii.Entity {
+	entities := []*pii.Entity{}
+
+	for _, p := range patterns {
+		for _, loc := range p.regex.FindAllStringSubmatchIndex(text, -1) {
+			begin, end := loc[2*p.group], loc[2*p.group+1]
+			if begin < 0 {
+				continue
+			}
+
+			if p.valid != nil && !p.valid(text[begin:end]) {
+				continue
+			}
+
+			if overlaps(entities, begin, end) {
+				continue
+			}
+
+			entities = append(entities, &pii.Entity{
+				BeginOffset: begin,
+				EndOffset:   end,
+				Type:        p.entityType,
+			})
+		}
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].BeginOffset < entities[j].BeginOffset
+	})
+
+	return entities
+}
+
+func overlaps(entities []*pii.Entity, begin, end int) bool {
+	for _, e := range entities {
+		if begin < e.EndOffset && e.BeginOffset < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, s)
+}
+
+// validCardNumber checks the length and Luhn checksum of a card number.
+func validCardNumber(match string) bool {
+	number := digits(match)
+	if len(number) < 13 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		n := int(number[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+
+		sum += n
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// validIban checks the mod 97 checksum of an IBAN.
+func validIban(match string) bool {
+	iban := strings.ReplaceAll(match, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	rearranged := iban[4:] + iban[:4]
+
+	var sb strings.Builder
+	for _, r := range rearranged {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteString(big.NewInt(int64(r-'A') + 10).String())
+			continue
+		}
+
+		sb.WriteRune(r)
+	}
+
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	if !ok {
+		return false
+	}
+
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validSsn excludes area, group and serial numbers that are never issued.
+func validSsn(match string) bool {
+	parts := strings.Split(match, "-")
+	if len(parts) != 3 {
+		return false
+	}
+
+	area := parts[0]
+	if area == "000" || area == "666" || area[0] == '9' {
+		return false
+	}
+
+	return parts[1] != "00" && parts[2] != "0000"
+}
@@ -0,0 +1,75 @@
+package local
+
+import (
+	"regexp"
+
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+)
+
+// secretPatterns detect leaked credentials. Provider specific formats are
+// matched before generic API keys so that tokens are reported with their most
+// specific type.
+var secretPatterns = []*pattern{
+	{
+		// keys are redacted through their END marker, or to the end of the
+		// input if it is missing, so that bodies of truncated keys are not
+		// leaked.
+		entityType: "PRIVATE_KEY",
+		regex:      regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----(?:[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----|[\s\S]*)`),
+	},
+	{
+		entityType: "AWS_ACCESS_KEY",
+		regex:      regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		entityType: "JWT",
+		regex:      regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`),
+	},
+	{
+		entityType: "GITHUB_TOKEN",
+		regex:      regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36}\b|\bgithub_pat_[A-Za-z0-9_]{82}\b`),
+	},
+	{
+		entityType: "SLACK_TOKEN",
+		regex:      regexp.MustCompile(`\bxox[abeprs]-[A-Za-z0-9\-]{10,}|https://hooks\.slack\.com/services/[A-Za-z0-9/]{20,}`),
+	},
+	{
+		entityType: "STRIPE_KEY",
+		regex:      regexp.MustCompile(`\b(?:sk|rk|pk)_(?:live|test)_[0-9A-Za-z]{24,}\b|\bwhsec_[0-9A-Za-z]{32,}\b`),
+	},
+	{
+		entityType: "API_KEY",
+		regex:      regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_\-]{20,}|\bAIza[0-9A-Za-z_\-]{35}\b`),
+	},
+	{
+		// values assigned to API key, secret and token like names in code,
+		// configuration files and environment variables.
+		entityType: "API_KEY",
+		regex:      regexp.MustCompile(`(?i)(?:api[_\-]?key|secret(?:[_\-]?key)?|access[_\-]?token|auth[_\-]?token|client[_\-]?secret)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-+/=.]{16,})`),
+		group:      1,
+	},
+}
+
+// SecretsDetector detects leaked credentials such as private keys, JWTs and
+// API keys of well known providers. It lets policies detect secrets
+// regardless of the backend detecting their other entities.
+type SecretsDetector struct{}
+
+func NewSecretsDetector() *SecretsDetector {
+	return &SecretsDetector{}
+}
+
+func (d *SecretsDetector) Detect(input []string) (*pii.Result, error) {
+	result := &pii.Result{
+		Detections: make([]*pii.Detection, 0, len(input)),
+	}
+
+	for _, text := range input {
+		result.Detections = append(result.Detections, &pii.Detection{
+			Input:    text,
+			Entities: detectWith(secretPatterns, text),
+		})
+	}
+
+	return result, nil
+}
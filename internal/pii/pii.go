@@ -1,11 +1,14 @@
 package pii
 
+import "fmt"
+
 type Detector interface {
 	Detect(input []string) (*Result, error)
 }
 
 type Scanner struct {
 	detector Detector
+	backends map[string]Detector
 }
 
 type Detection struct {
@@ -23,9 +26,44 @@ type Result struct {
 	Detections []*Detection
 }
 
-func NewScanner(d Detector) *Scanner {
+type Offset struct {
+	Input       int `json:"input"`
+	BeginOffset int `json:"beginOffset"`
+	EndOffset   int `json:"endOffset"`
+}
+
+// Finding describes an entity type detected in a request. Raw values are
+// never included, only where they were found.
+type Finding struct {
+	Type    string    `json:"type"`
+	Count   int       `json:"count"`
+	Offsets []*Offset `json:"offsets"`
+}
+
+const (
+	// BackendAmazonComprehend detects entities with Amazon Comprehend. It is
+	// the default backend.
+	BackendAmazonComprehend = "amazon_comprehend"
+	// BackendLocal detects common entities with regular expressions and
+	// checksums without calling an external service.
+	BackendLocal = "local"
+	// BackendPresidio detects entities with the analyzer of a Microsoft
+	// Presidio deployment.
+	BackendPresidio = "presidio"
+)
+
+// IsBackend reports whether a backend can be selected by policies. Empty
+// backends select the default one.
+func IsBackend(backend string) bool {
+	return len(backend) == 0 || backend == BackendAmazonComprehend || backend == BackendLocal || backend == BackendPresidio
+}
+
+// NewScanner returns a scanner detecting entities with d by default and with
+// the detectors of backends selected by policies.
+func NewScanner(d Detector, backends map[string]Detector) *Scanner {
 	return &Scanner{
 		detector: d,
+		backends: backends,
 	}
 }
 
@@ -34,3 +72,18 @@ func (s *Scanner) Scan(input []string) (*Result, error) {
 		input,
 	)
 }
+
+// ScanWith scans the input with the detector of a backend. An error is
+// returned if the backend is not configured.
+func (s *Scanner) ScanWith(backend string, input []string) (*Result, error) {
+	if len(backend) == 0 || backend == BackendAmazonComprehend {
+		return s.Scan(input)
+	}
+
+	d, ok := s.backends[backend]
+	if !ok || d == nil {
+		return nil, fmt.Errorf("%s scanner is not configured", backend)
+	}
+
+	return d.Detect(input)
+}
@@ -0,0 +1,191 @@
+package presidio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+// entityTypes maps Presidio entity types to the entity types of Amazon
+// Comprehend that policy rules are matched against. Presidio entity types
+// without an equivalent rule are ignored.
+var entityTypes = map[string]string{
+	"CREDIT_CARD":       "CREDIT_DEBIT_NUMBER",
+	"DATE_TIME":         "DATE_TIME",
+	"EMAIL_ADDRESS":     "EMAIL",
+	"IBAN_CODE":         "INTERNATIONAL_BANK_ACCOUNT_NUMBER",
+	"IP_ADDRESS":        "IP_ADDRESS",
+	"LOCATION":          "ADDRESS",
+	"PERSON":            "NAME",
+	"PHONE_NUMBER":      "PHONE",
+	"URL":               "URL",
+	"US_BANK_NUMBER":    "BANK_ACCOUNT_NUMBER",
+	"US_DRIVER_LICENSE": "DRIVER_ID",
+	"US_ITIN":           "US_INDIVIDUAL_TAX_IDENTIFICATION_NUMBER",
+	"US_PASSPORT":       "PASSPORT_NUMBER",
+	"US_SSN":            "SSN",
+	"UK_NHS":            "UK_NATIONAL_HEALTH_SERVICE_NUMBER",
+	"UK_NINO":           "UK_NATIONAL_INSURANCE_NUMBER",
+	"IN_PAN":            "IN_PERMANENT_ACCOUNT_NUMBER",
+	"IN_AADHAAR":        "IN_AADHAAR",
+	"IN_VOTER":          "IN_VOTER_NUMBER",
+}
+
+type analyzeRequest struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+}
+
+type analyzeResult struct {
+	EntityType string  `json:"entity_type"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Score      float64 `json:"score"`
+}
+
+// Client detects entities with the analyzer of a Microsoft Presidio
+// deployment. Detected entities are redacted by the gateway, so the
+// anonymizer is not required.
+type Client struct {
+	client http.Client
+	url    string
+	rt     time.Duration
+	log    *zap.Logger
+}
+
+func NewClient(rt time.Duration, log *zap.Logger, analyzerUrl string) *Client {
+	return &Client{
+		client: http.Client{},
+		url:    strings.TrimSuffix(analyzerUrl, "/") + "/analyze",
+		rt:     rt,
+		log:    log,
+	}
+}
+
+func (c *Client) analyze(text string) ([]*analyzeResult, error) {
+	data, err := json.Marshal(&analyzeRequest{
+		Text:     text,
+		Language: "en",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.rt)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("presidio analyze request failed with status code %d", res.StatusCode)
+	}
+
+	results := []*analyzeResult{}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// byteOffsets converts the character offsets reported by Presidio into byte
+// offsets of the text.
+func byteOffsets(text string) []int {
+	offsets := []int{}
+	for idx := range text {
+		offsets = append(offsets, idx)
+	}
+
+	return append(offsets, len(text))
+}
+
+// Detect analyzes every input concurrently. An error is returned if any input
+// could not be analyzed so that the failure mode of the policy applies instead
+// of the input being treated as free of entities.
+func (c *Client) Detect(input []string) (*pii.Result, error) {
+	var wg sync.WaitGroup
+	errs := make([]error, len(input))
+
+	result := &pii.Result{
+		Detections: make([]*pii.Detection, len(input)),
+	}
+
+	for index, text := range input {
+		wg.Add(1)
+		go func(t string, i int) {
+			defer wg.Done()
+			detection := &pii.Detection{
+				Input:    t,
+				Entities: []*pii.Entity{},
+			}
+
+			start := time.Now()
+
+			r, err := c.analyze(t)
+			if err != nil {
+				c.log.Debug("error when detecting pii entities with presidio", zap.Error(err))
+				telemetry.Incr("bricksllm.presidio.detect.error", nil, 1)
+				errs[i] = err
+				return
+			}
+
+			telemetry.Timing("bricksllm.presidio.detect.latency_in_ms", time.Since(start), nil, 1)
+
+			offsets := byteOffsets(t)
+			for _, detected := range r {
+				converted, ok := entityTypes[detected.EntityType]
+				if !ok {
+					continue
+				}
+
+				if detected.Start < 0 || detected.End < detected.Start || detected.End >= len(offsets) {
+					continue
+				}
+
+				detection.Entities = append(detection.Entities, &pii.Entity{
+					BeginOffset: offsets[detected.Start],
+					EndOffset:   offsets[detected.End],
+					Type:        converted,
+				})
+			}
+
+			result.Detections[i] = detection
+		}(text, index)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package presidio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDetect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &analyzeRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		if req.Text == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode([]*analyzeResult{
+			{EntityType: "EMAIL_ADDRESS", Start: 5, End: 16, Score: 1},
+			{EntityType: "UNMAPPED", Start: 0, End: 4, Score: 1},
+		})
+	}))
+	defer server.Close()
+
+	c := NewClient(time.Second, zap.NewNop(), server.URL)
+
+	tests := []struct {
+		name    string
+		input   []string
+		wantErr bool
+	}{
+		{name: "detected", input: []string{"mail a@b.example"}},
+		{name: "analyze error", input: []string{"mail a@b.example", "fail"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := c.Detect(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, r)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, r.Detections, 1)
+			require.Len(t, r.Detections[0].Entities, 1)
+			assert.Equal(t, "EMAIL", r.Detections[0].Entities[0].Type)
+			assert.Equal(t, 5, r.Detections[0].Entities[0].BeginOffset)
+			assert.Equal(t, 16, r.Detections[0].Entities[0].EndOffset)
+		})
+	}
+}
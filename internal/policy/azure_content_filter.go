@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
+)
+
+// AzureContentFilterConfig lets a policy act on content filter results
+// returned by Azure OpenAI. Findings at or above the severity threshold, or
+// findings that Azure filtered or detected, trigger the configured action.
+type AzureContentFilterConfig struct {
+	Action            Action   `json:"action"`
+	SeverityThreshold string   `json:"severityThreshold"`
+	Categories        []string `json:"categories"`
+}
+
+func (c *AzureContentFilterConfig) Validate() []string {
+	msgs := []string{}
+	if c.Action != Block && c.Action != AllowButWarn && c.Action != Allow {
+		msgs = append(msgs, "azure content filter action must be one of block, allow_but_warn or allow")
+	}
+
+	if len(c.SeverityThreshold) != 0 && azure.SeverityRank(c.SeverityThreshold) <= 0 {
+		msgs = append(msgs, "azure content filter severity threshold must be one of low, medium or high")
+	}
+
+	return msgs
+}
+
+func (c *AzureContentFilterConfig) matches(f *azure.ContentFilterFinding) bool {
+	if len(c.Categories) != 0 {
+		found := false
+		for _, category := range c.Categories {
+			if category == f.Category {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.Filtered || f.Detected {
+		return true
+	}
+
+	threshold := "medium"
+	if len(c.SeverityThreshold) != 0 {
+		threshold = c.SeverityThreshold
+	}
+
+	return azure.SeverityRank(f.Severity) >= azure.SeverityRank(threshold)
+}
+
+// EvaluateAzureContentFilter returns the action the policy takes on Azure
+// content filter findings. Allow is returned if the policy does not act on
+// content filter results or if no finding meets the configured threshold.
+func (p *Policy) EvaluateAzureContentFilter(findings []*azure.ContentFilterFinding) Action {
+	if p == nil || p.AzureContentFilterConfig == nil {
+		return Allow
+	}
+
+	for _, f := range findings {
+		if f != nil && p.AzureContentFilterConfig.matches(f) {
+			return p.AzureContentFilterConfig.Action
+		}
+	}
+
+	return Allow
+}
@@ -0,0 +1,180 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// brandPrefix is prepended to the names of matched brand rules in the errors
+// of blocked responses.
+const brandPrefix = "brand:"
+
+// AppendDisclaimer appends the disclaimer of a brand rule to generated texts
+// mentioning its names.
+const AppendDisclaimer Action = "append_disclaimer"
+
+// BrandRule guards responses against mentions of brand or competitor names.
+// Names are matched as whole words regardless of case.
+type BrandRule struct {
+	Name        string   `json:"name"`
+	Names       []string `json:"names"`
+	Action      Action   `json:"action"`
+	Replacement string   `json:"replacement"`
+	Disclaimer  string   `json:"disclaimer"`
+
+	regex *regexp.Regexp
+}
+
+// BrandConfig lets a policy block, redact or append disclaimers to responses
+// mentioning brands or competitors, such as those of customer facing chatbots.
+type BrandConfig struct {
+	Rules []*BrandRule `json:"rules"`
+}
+
+func (c *BrandConfig) Validate() []string {
+	msgs := []string{}
+	for idx, rule := range c.Rules {
+		if rule == nil {
+			msgs = append(msgs, fmt.Sprintf("brand rule at index [%d] cannot be nil", idx))
+			continue
+		}
+
+		if len(rule.Name) == 0 {
+			msgs = append(msgs, fmt.Sprintf("brand rule at index [%d] must have a name", idx))
+		}
+
+		if len(rule.Names) == 0 {
+			msgs = append(msgs, fmt.Sprintf("brand rule at index [%d] must have names", idx))
+		}
+
+		for _, name := range rule.Names {
+			if len(strings.TrimSpace(name)) == 0 {
+				msgs = append(msgs, fmt.Sprintf("brand rule at index [%d] cannot have empty names", idx))
+				break
+			}
+		}
+
+		if rule.Action != Block && rule.Action != AllowButRedact && rule.Action != AppendDisclaimer && rule.Action != Allow {
+			msgs = append(msgs, fmt.Sprintf("brand rule at index [%d] must have an action of block, allow_but_redact, append_disclaimer or allow", idx))
+		}
+
+		if rule.Action == AppendDisclaimer && len(strings.TrimSpace(rule.Disclaimer)) == 0 {
+			msgs = append(msgs, fmt.Sprintf("brand rule at index [%d] must have a disclaimer", idx))
+		}
+	}
+
+	return msgs
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (r *BrandRule) compile() (*regexp.Regexp, error) {
+	names := []string{}
+	for _, name := range r.Names {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		pattern := regexp.QuoteMeta(name)
+
+		// word boundaries only apply next to word characters, which keeps
+		// names such as "C++" matchable.
+		if first, _ := utf8.DecodeRuneInString(name); isWordRune(first) {
+			pattern = `\b` + pattern
+		}
+
+		if last, _ := utf8.DecodeLastRuneInString(name); isWordRune(last) {
+			pattern = pattern + `\b`
+		}
+
+		names = append(names, pattern)
+	}
+
+	// longer names are tried first so that they win over their prefixes.
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+
+	return regexp.Compile(`(?i)(?:` + strings.Join(names, "|") + `)`)
+}
+
+// ShouldGuardBrands reports whether responses of requests filtered by the
+// policy are checked for brand mentions.
+func (p *Policy) ShouldGuardBrands() bool {
+	if p == nil || p.BrandConfig == nil {
+		return false
+	}
+
+	for _, rule := range p.BrandConfig.Rules {
+		if rule != nil && rule.Action != Allow && len(rule.Action) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// guardBrands applies brand rules to the generated texts of a scan result.
+// Disclaimers are only appended to texts for which disclaimable returns true
+// so that tool call arguments stay valid JSON. Responses with redacted names
+// or appended disclaimers are reported as redacted since their bodies change.
+func (p *Policy) guardBrands(sr *ScanResult, disclaimable func(idx int) bool) {
+	for _, rule := range p.BrandConfig.Rules {
+		if rule == nil || (rule.Action != Block && rule.Action != AllowButRedact && rule.Action != AppendDisclaimer) {
+			continue
+		}
+
+		regex, err := rule.compiled()
+		if err != nil {
+			telemetry.Incr("bricksllm.policy.guard_brands.regex_compile_error", nil, 1)
+			continue
+		}
+
+		matched := false
+		updated := []string{}
+		for idx, text := range sr.Updated {
+			if regex.MatchString(text) {
+				matched = true
+
+				if rule.Action == AllowButRedact {
+					text = regex.ReplaceAllStringFunc(text, func(match string) string {
+						return redact(match, "brand", rule.Replacement)
+					})
+				}
+
+				if rule.Action == AppendDisclaimer && disclaimable(idx) {
+					text = text + "\n\n" + rule.Disclaimer
+				}
+			}
+
+			updated = append(updated, text)
+		}
+
+		if !matched {
+			continue
+		}
+
+		sr.Updated = updated
+
+		if rule.Action == Block {
+			sr.Action = Block
+			sr.BlockedCustomDefinitions = append(sr.BlockedCustomDefinitions, brandPrefix+rule.Name)
+			continue
+		}
+
+		if sr.Action == Allow || len(sr.Action) == 0 {
+			sr.Action = AllowButRedact
+		}
+
+		sr.redact(brandPrefix + rule.Name)
+	}
+}
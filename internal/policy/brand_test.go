@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"testing"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFilterResponseBrands(t *testing.T) {
+	warnRule := &RegexConfig{
+		ScanResponses:          true,
+		RegularExpressionRules: []*RegularExpressionRule{{Definition: `refund`, Action: AllowButWarn}},
+	}
+
+	tests := []struct {
+		name  string
+		rule  *BrandRule
+		regex *RegexConfig
+		body  string
+		want  string
+		err   error
+	}{
+		{
+			name: "redacted",
+			rule: &BrandRule{Name: "competitors", Names: []string{"Acme"}, Action: AllowButRedact, Replacement: "[BRAND]"},
+			body: `{"choices":[{"message":{"content":"try Acme instead"}}]}`,
+			want: `{"choices":[{"message":{"content":"try [BRAND] instead"}}]}`,
+			err:  &internal_errors.RedactError{},
+		},
+		{
+			name:  "redacted with warning",
+			rule:  &BrandRule{Name: "competitors", Names: []string{"Acme"}, Action: AllowButRedact, Replacement: "[BRAND]"},
+			regex: warnRule,
+			body:  `{"choices":[{"message":{"content":"ask Acme for a refund"}}]}`,
+			want:  `{"choices":[{"message":{"content":"ask [BRAND] for a refund"}}]}`,
+			err:   &internal_errors.WarningError{},
+		},
+		{
+			name:  "disclaimer with warning",
+			rule:  &BrandRule{Name: "competitors", Names: []string{"Acme"}, Action: AppendDisclaimer, Disclaimer: "not endorsed"},
+			regex: warnRule,
+			body:  `{"choices":[{"message":{"content":"ask Acme for a refund"}}]}`,
+			want:  `{"choices":[{"message":{"content":"ask Acme for a refund\n\nnot endorsed"}}]}`,
+			err:   &internal_errors.WarningError{},
+		},
+		{
+			name:  "warning without brands",
+			rule:  &BrandRule{Name: "competitors", Names: []string{"Acme"}, Action: AllowButRedact, Replacement: "[BRAND]"},
+			regex: warnRule,
+			body:  `{"choices":[{"message":{"content":"ask for a refund"}}]}`,
+			want:  `{"choices":[{"message":{"content":"ask for a refund"}}]}`,
+			err:   &internal_errors.WarningError{},
+		},
+		{
+			name: "no match",
+			rule: &BrandRule{Name: "competitors", Names: []string{"Acme"}, Action: AllowButRedact, Replacement: "[BRAND]"},
+			body: `{"choices":[{"message":{"content":"hello there"}}]}`,
+			want: `{"choices":[{"message":{"content":"hello there"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{
+				RegexConfig: tt.regex,
+				BrandConfig: &BrandConfig{Rules: []*BrandRule{tt.rule}},
+			}
+
+			filtered, err := p.FilterResponse([]byte(tt.body), nil, nil, zap.NewNop())
+			assert.JSONEq(t, tt.want, string(filtered))
+
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.IsType(t, tt.err, err)
+		})
+	}
+}
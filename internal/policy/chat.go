@@ -0,0 +1,62 @@
+package policy
+
+import (
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// chatMessageTexts returns the texts of chat messages inspected by policies.
+// Besides contents, including text parts of multi-part contents and contents
+// of tool and function results, PII often
+// flows through the arguments of tool and function calls. Texts are returned
+// in the order setChatMessageTexts expects.
+func chatMessageTexts(messages []goopenai.ChatCompletionMessage) []string {
+	texts := []string{}
+	for _, message := range messages {
+		texts = append(texts, message.Content)
+
+		for _, part := range message.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeText {
+				texts = append(texts, part.Text)
+			}
+		}
+
+		if message.FunctionCall != nil {
+			texts = append(texts, message.FunctionCall.Arguments)
+		}
+
+		for _, tc := range message.ToolCalls {
+			texts = append(texts, tc.Function.Arguments)
+		}
+	}
+
+	return texts
+}
+
+// setChatMessageTexts updates chat messages with texts returned by
+// chatMessageTexts.
+func setChatMessageTexts(messages []goopenai.ChatCompletionMessage, texts []string) {
+	idx := 0
+	next := func() string {
+		text := texts[idx]
+		idx++
+		return text
+	}
+
+	for i := range messages {
+		messages[i].Content = next()
+
+		for j := range messages[i].MultiContent {
+			if messages[i].MultiContent[j].Type == goopenai.ChatMessagePartTypeText {
+				messages[i].MultiContent[j].Text = next()
+			}
+		}
+
+		if messages[i].FunctionCall != nil {
+			messages[i].FunctionCall.Arguments = next()
+		}
+
+		for j := range messages[i].ToolCalls {
+			messages[i].ToolCalls[j].Function.Arguments = next()
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Compile compiles the regular expressions of the rules of a policy so that
+// they are not compiled again for every request. It is called before policies
+// are cached in memory and must not be called once a policy is shared between
+// goroutines.
+func (p *Policy) Compile() error {
+	if p == nil {
+		return nil
+	}
+
+	invalid := []string{}
+
+	if p.RegexConfig != nil {
+		for idx, rule := range p.RegexConfig.RegularExpressionRules {
+			if rule == nil {
+				continue
+			}
+
+			regex, err := regexp.Compile(rule.Definition)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("regex rule at index [%d] cannot be compiled", idx))
+				continue
+			}
+
+			rule.regex = regex
+		}
+	}
+
+	if p.TopicConfig != nil {
+		for idx, rule := range p.TopicConfig.Rules {
+			if rule == nil {
+				continue
+			}
+
+			regex, err := rule.compile()
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("topic rule at index [%d] cannot be compiled", idx))
+				continue
+			}
+
+			rule.regex = regex
+		}
+	}
+
+	if p.BrandConfig != nil {
+		for idx, rule := range p.BrandConfig.Rules {
+			if rule == nil {
+				continue
+			}
+
+			regex, err := rule.compile()
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("brand rule at index [%d] cannot be compiled", idx))
+				continue
+			}
+
+			rule.regex = regex
+		}
+	}
+
+	if p.ProfanityConfig != nil {
+		regex, err := p.ProfanityConfig.compile()
+		if err != nil {
+			invalid = append(invalid, "profanity config cannot be compiled")
+		} else {
+			p.ProfanityConfig.regex = regex
+		}
+	}
+
+	if len(invalid) != 0 {
+		return fmt.Errorf("policy %s has invalid rules: %s", p.Id, strings.Join(invalid, ","))
+	}
+
+	return nil
+}
+
+// compiled returns the regular expression of a rule. Rules of policies that
+// are not compiled are compiled on the fly.
+func (r *RegularExpressionRule) compiled() (*regexp.Regexp, error) {
+	if r.regex != nil {
+		return r.regex, nil
+	}
+
+	return regexp.Compile(r.Definition)
+}
+
+func (r *TopicRule) compiled() (*regexp.Regexp, error) {
+	if r.regex != nil {
+		return r.regex, nil
+	}
+
+	return r.compile()
+}
+
+func (r *BrandRule) compiled() (*regexp.Regexp, error) {
+	if r.regex != nil {
+		return r.regex, nil
+	}
+
+	return r.compile()
+}
+
+func (c *ProfanityConfig) compiled() (*regexp.Regexp, error) {
+	if c.regex != nil {
+		return c.regex, nil
+	}
+
+	return c.compile()
+}
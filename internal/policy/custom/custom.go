@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -12,18 +13,36 @@ import (
 	goopenai "github.com/sashabaranov/go-openai"
 )
 
+// openAiHost is the host of the OpenAI API. The API key of the detector is
+// only sent to it.
+const openAiHost = "api.openai.com"
+
 type OpenAiDetector struct {
 	client *goopenai.Client
 	rt     time.Duration
+	key    string
 }
 
 func NewOpenAiDetector(rt time.Duration, key string) *OpenAiDetector {
 	return &OpenAiDetector{
 		client: goopenai.NewClient(key),
 		rt:     rt,
+		key:    key,
 	}
 }
 
+// AuthenticateEmbeddingRequest adds the API key of the detector to requests
+// sent to the OpenAI embeddings endpoint by custom entity types. Requests to
+// other endpoints are left as is so that the key is never sent to third
+// parties.
+func (c *OpenAiDetector) AuthenticateEmbeddingRequest(req *http.Request) {
+	if len(c.key) == 0 || req.URL.Scheme != "https" || req.URL.Host != openAiHost {
+		return
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.key)
+}
+
 type result struct {
 	RelevantTextsFound bool `json:"relevant_texts_found"`
 }
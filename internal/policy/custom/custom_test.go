@@ -0,0 +1,33 @@
+package custompolicy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticateEmbeddingRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		auth string
+	}{
+		{name: "openai", url: "https://api.openai.com/v1/embeddings", auth: "Bearer secret"},
+		{name: "third party", url: "https://embeddings.example.com/v1/embeddings"},
+		{name: "plain http", url: "http://api.openai.com/v1/embeddings"},
+	}
+
+	d := NewOpenAiDetector(time.Second, "secret")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tt.url, nil)
+			require.NoError(t, err)
+
+			d.AuthenticateEmbeddingRequest(req)
+			assert.Equal(t, tt.auth, req.Header.Get("Authorization"))
+		})
+	}
+}
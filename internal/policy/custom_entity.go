@@ -0,0 +1,410 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+)
+
+const (
+	// customEntityPrefix is prepended to the names of custom entity types in
+	// scan results to tell them apart from the entity types of scanners.
+	customEntityPrefix = "CUSTOM:"
+
+	defaultCustomEntityTimeout   = 2 * time.Second
+	defaultCustomEntityThreshold = 0.5
+)
+
+var customEntityNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// CustomEntityType is an entity type registered by a policy and detected by
+// a classifier endpoint or by the embedding similarity of texts to examples.
+// Custom entity types are used as rules of the PII config with the standard
+// action set.
+//
+// Classifier endpoints receive a CustomEntityRequest and respond with a
+// CustomEntityResponse. Embedding endpoints follow the OpenAI embeddings API
+// and are authenticated by the custom policy detector if it implements
+// EmbeddingAuthenticator. Texts at least as similar to any of the examples as
+// the threshold are detected as a whole. Embeddings of the examples are only
+// requested once per entity type.
+type CustomEntityType struct {
+	Name           string   `json:"name"`
+	ClassifierUrl  string   `json:"classifierUrl"`
+	EmbeddingUrl   string   `json:"embeddingUrl"`
+	EmbeddingModel string   `json:"embeddingModel"`
+	Examples       []string `json:"examples"`
+	Threshold      float64  `json:"threshold"`
+	Timeout        string   `json:"timeout"`
+
+	exampleLock       sync.Mutex
+	exampleEmbeddings [][]float64
+}
+
+// EmbeddingAuthenticator is implemented by custom policy detectors holding
+// provider credentials. Requests to embedding endpoints of custom entity
+// types are authenticated with them. Credentials must only be added to
+// requests sent to their provider.
+type EmbeddingAuthenticator interface {
+	AuthenticateEmbeddingRequest(req *http.Request)
+}
+
+type CustomEntityRequest struct {
+	EntityType string   `json:"entityType"`
+	Texts      []string `json:"texts"`
+}
+
+type CustomEntity struct {
+	Input       int     `json:"input"`
+	BeginOffset int     `json:"beginOffset"`
+	EndOffset   int     `json:"endOffset"`
+	Score       float64 `json:"score"`
+}
+
+type CustomEntityResponse struct {
+	Entities []*CustomEntity `json:"entities"`
+}
+
+func (c *CustomConfig) Validate() []string {
+	msgs := []string{}
+	names := map[string]bool{}
+	builtIn := map[string]bool{}
+	for _, rule := range entityMap {
+		builtIn[rule] = true
+	}
+
+	for idx, et := range c.EntityTypes {
+		if et == nil {
+			msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] cannot be nil", idx))
+			continue
+		}
+
+		if !customEntityNameRegex.MatchString(et.Name) {
+			msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] must have a lower snake case name", idx))
+		} else if builtIn[et.Name] || names[et.Name] {
+			msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] has a name that is already used", idx))
+		}
+
+		names[et.Name] = true
+
+		if (len(et.ClassifierUrl) == 0) == (len(et.EmbeddingUrl) == 0) {
+			msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] must have either a classifier url or an embedding url", idx))
+		}
+
+		for _, raw := range []string{et.ClassifierUrl, et.EmbeddingUrl} {
+			if len(raw) == 0 {
+				continue
+			}
+
+			if u, err := url.ParseRequestURI(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] must have http or https urls", idx))
+			}
+		}
+
+		if len(et.EmbeddingUrl) != 0 && len(et.Examples) == 0 {
+			msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] must have examples to compare embeddings with", idx))
+		}
+
+		if et.Threshold < 0 || et.Threshold > 1 {
+			msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] must have a threshold between 0 and 1", idx))
+		}
+
+		if len(et.Timeout) != 0 {
+			if parsed, err := time.ParseDuration(et.Timeout); err != nil || parsed <= 0 {
+				msgs = append(msgs, fmt.Sprintf("custom entity type at index [%d] must have a positive timeout", idx))
+			}
+		}
+	}
+
+	return msgs
+}
+
+func (et *CustomEntityType) timeout() time.Duration {
+	if parsed, err := time.ParseDuration(et.Timeout); err == nil && parsed > 0 {
+		return parsed
+	}
+
+	return defaultCustomEntityTimeout
+}
+
+func (et *CustomEntityType) threshold() float64 {
+	if et.Threshold > 0 {
+		return et.Threshold
+	}
+
+	return defaultCustomEntityThreshold
+}
+
+// convertEntity returns the rule of a detected entity type.
+func convertEntity(entityType string) (string, bool) {
+	if strings.HasPrefix(entityType, customEntityPrefix) {
+		return strings.TrimPrefix(entityType, customEntityPrefix), true
+	}
+
+	converted, ok := entityMap[entityType]
+	return converted, ok
+}
+
+// customEntityTypes returns the custom entity types used by the rules of the
+// PII config of the policy.
+func (p *Policy) customEntityTypes() []*CustomEntityType {
+	if p.CustomConfig == nil || p.Config == nil {
+		return nil
+	}
+
+	ets := []*CustomEntityType{}
+	for _, et := range p.CustomConfig.EntityTypes {
+		if et == nil {
+			continue
+		}
+
+		if action, ok := p.Config.Rules[Rule(et.Name)]; ok && action != Allow {
+			ets = append(ets, et)
+		}
+	}
+
+	return ets
+}
+
+// detectCustomEntities adds the custom entity types detected in the texts of
+// a scan result to it.
+func (p *Policy) detectCustomEntities(client http.Client, cd CustomPolicyDetector, r *pii.Result) error {
+	ets := p.customEntityTypes()
+	if len(ets) == 0 {
+		return nil
+	}
+
+	texts := make([]string, 0, len(r.Detections))
+	for _, detection := range r.Detections {
+		texts = append(texts, detection.Input)
+	}
+
+	detected := make([][]*CustomEntity, len(ets))
+	errs := make([]error, len(ets))
+
+	var wg sync.WaitGroup
+	for idx, et := range ets {
+		wg.Add(1)
+		go func(idx int, et *CustomEntityType) {
+			defer wg.Done()
+
+			if len(et.ClassifierUrl) != 0 {
+				detected[idx], errs[idx] = et.classify(client, texts)
+				return
+			}
+
+			detected[idx], errs[idx] = et.compare(client, cd, texts)
+		}(idx, et)
+	}
+
+	wg.Wait()
+
+	for idx, et := range ets {
+		if errs[idx] != nil {
+			return fmt.Errorf("detecting custom entity type %s failed: %w", et.Name, errs[idx])
+		}
+
+		for _, entity := range detected[idx] {
+			if entity.Input < 0 || entity.Input >= len(r.Detections) {
+				continue
+			}
+
+			detection := r.Detections[entity.Input]
+			if entity.BeginOffset < 0 || entity.EndOffset > len(detection.Input) || entity.BeginOffset >= entity.EndOffset {
+				continue
+			}
+
+			detection.Entities = append(detection.Entities, &pii.Entity{
+				BeginOffset: entity.BeginOffset,
+				EndOffset:   entity.EndOffset,
+				Type:        customEntityPrefix + et.Name,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (et *CustomEntityType) classify(client http.Client, texts []string) ([]*CustomEntity, error) {
+	res := &CustomEntityResponse{}
+	if err := postJSON(client, et.ClassifierUrl, et.timeout(), &CustomEntityRequest{
+		EntityType: et.Name,
+		Texts:      texts,
+	}, res, nil); err != nil {
+		return nil, err
+	}
+
+	entities := []*CustomEntity{}
+	for _, entity := range res.Entities {
+		if entity != nil && (et.Threshold == 0 || entity.Score >= et.Threshold) {
+			entities = append(entities, entity)
+		}
+	}
+
+	return entities, nil
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// embed returns the embeddings of the input.
+func (et *CustomEntityType) embed(client http.Client, cd CustomPolicyDetector, input []string) ([][]float64, error) {
+	var authenticate func(req *http.Request)
+	if ea, ok := cd.(EmbeddingAuthenticator); ok {
+		authenticate = ea.AuthenticateEmbeddingRequest
+	}
+
+	res := &embeddingResponse{}
+	if err := postJSON(client, et.EmbeddingUrl, et.timeout(), &embeddingRequest{
+		Model: et.EmbeddingModel,
+		Input: input,
+	}, res, authenticate); err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float64, len(input))
+	for _, d := range res.Data {
+		if d.Index >= 0 && d.Index < len(embeddings) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+
+	for idx, embedding := range embeddings {
+		if len(embedding) == 0 {
+			return nil, fmt.Errorf("embedding endpoint returned no embedding for input %d", idx)
+		}
+	}
+
+	return embeddings, nil
+}
+
+// examples returns the embeddings of the examples of the entity type. They
+// are requested once and reused by later requests.
+func (et *CustomEntityType) examples(client http.Client, cd CustomPolicyDetector) ([][]float64, error) {
+	et.exampleLock.Lock()
+	defer et.exampleLock.Unlock()
+
+	if et.exampleEmbeddings != nil {
+		return et.exampleEmbeddings, nil
+	}
+
+	embeddings, err := et.embed(client, cd, et.Examples)
+	if err != nil {
+		return nil, err
+	}
+
+	et.exampleEmbeddings = embeddings
+	return embeddings, nil
+}
+
+// compare detects texts similar to the examples of the entity type.
+func (et *CustomEntityType) compare(client http.Client, cd CustomPolicyDetector, texts []string) ([]*CustomEntity, error) {
+	examples, err := et.examples(client, cd)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings, err := et.embed(client, cd, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []*CustomEntity{}
+	for idx, text := range texts {
+		if len(strings.TrimSpace(text)) == 0 {
+			continue
+		}
+
+		for _, example := range examples {
+			if score := cosineSimilarity(embeddings[idx], example); score >= et.threshold() {
+				entities = append(entities, &CustomEntity{
+					Input:       idx,
+					BeginOffset: 0,
+					EndOffset:   len(text),
+					Score:       score,
+				})
+				break
+			}
+		}
+	}
+
+	return entities, nil
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+
+	if na == 0 || nb == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// postJSON posts in to u and decodes the response into out. Requests are
+// passed to authenticate before they are sent if it is not nil.
+func postJSON(client http.Client, u string, timeout time.Duration, in, out any, authenticate func(req *http.Request)) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if authenticate != nil {
+		authenticate(req)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status code %d", u, res.StatusCode)
+	}
+
+	return json.Unmarshal(body, out)
+}
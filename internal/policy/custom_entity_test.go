@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyAuthenticator struct{}
+
+func (a *keyAuthenticator) Detect(input []string, requirements []string) (bool, error) {
+	return false, nil
+}
+
+func (a *keyAuthenticator) AuthenticateEmbeddingRequest(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer secret")
+}
+
+func TestCustomEntityTypeCompare(t *testing.T) {
+	vectors := map[string][]float64{
+		"refund my order":  {1, 0},
+		"give me a refund": {0.9, 0.1},
+		"what time is it":  {0, 1},
+	}
+
+	tests := []struct {
+		name     string
+		cd       CustomPolicyDetector
+		auth     string
+		detected []int
+	}{
+		{name: "authenticated", cd: &keyAuthenticator{}, auth: "Bearer secret", detected: []int{0}},
+		{name: "unauthenticated", detected: []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				assert.Equal(t, tt.auth, r.Header.Get("Authorization"))
+
+				req := &embeddingRequest{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+				res := map[string]any{}
+				data := []map[string]any{}
+				for idx, input := range req.Input {
+					data = append(data, map[string]any{"index": idx, "embedding": vectors[input]})
+				}
+				res["data"] = data
+
+				json.NewEncoder(w).Encode(res)
+			}))
+			defer server.Close()
+
+			et := &CustomEntityType{
+				Name:         "refund",
+				EmbeddingUrl: server.URL,
+				Examples:     []string{"refund my order"},
+				Threshold:    0.9,
+			}
+
+			for i := 0; i < 2; i++ {
+				entities, err := et.compare(http.Client{}, tt.cd, []string{"give me a refund", "what time is it"})
+				require.NoError(t, err)
+
+				detected := []int{}
+				for _, entity := range entities {
+					detected = append(detected, entity.Input)
+				}
+
+				assert.Equal(t, tt.detected, detected)
+			}
+
+			// examples are embedded once and texts once per comparison.
+			assert.Equal(t, int32(3), requests.Load())
+		})
+	}
+}
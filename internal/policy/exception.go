@@ -0,0 +1,110 @@
+package policy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+)
+
+// excepted reports whether a matched value is on an allow-list of known safe
+// values. Exceptions are either CIDR ranges matching IP addresses, domains
+// prefixed with @ matching email addresses of the domain and its subdomains,
+// or values compared regardless of case, spaces and dashes so that, for
+// example, test card numbers match however they are formatted.
+func excepted(exceptions []string, value string) bool {
+	if len(exceptions) == 0 {
+		return false
+	}
+
+	normalized := normalizeException(value)
+	for _, exception := range exceptions {
+		if strings.Contains(exception, "/") {
+			_, network, err := net.ParseCIDR(exception)
+			if err != nil {
+				continue
+			}
+
+			if ip := net.ParseIP(strings.TrimSpace(value)); ip != nil && network.Contains(ip) {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(exception, "@") {
+			domain := strings.ToLower(exception[1:])
+			at := strings.LastIndex(value, "@")
+			if at == -1 {
+				continue
+			}
+
+			host := strings.ToLower(strings.TrimSpace(value[at+1:]))
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+
+			continue
+		}
+
+		if normalizeException(exception) == normalized {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeException(value string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(value)))
+}
+
+func validateExceptions(name string, exceptions []string) []string {
+	msgs := []string{}
+	for idx, exception := range exceptions {
+		if len(strings.TrimSpace(exception)) == 0 {
+			msgs = append(msgs, fmt.Sprintf("%s exception at index [%d] cannot be empty", name, idx))
+			continue
+		}
+
+		if strings.Contains(exception, "/") {
+			if _, _, err := net.ParseCIDR(exception); err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s exception at index [%d] is not a valid CIDR range", name, idx))
+			}
+		}
+	}
+
+	return msgs
+}
+
+// removeExceptions drops the detected entities whose values are exceptions of
+// their rules so that they neither trigger actions nor count as findings.
+func (c *Config) removeExceptions(r *pii.Result) {
+	if c == nil || len(c.Exceptions) == 0 {
+		return
+	}
+
+	for _, detection := range r.Detections {
+		entities := make([]*pii.Entity, 0, len(detection.Entities))
+		for _, entity := range detection.Entities {
+			converted, ok := convertEntity(entity.Type)
+			if ok && excepted(c.Exceptions[c.ruleFor(converted)], detection.Input[entity.BeginOffset:entity.EndOffset]) {
+				continue
+			}
+
+			entities = append(entities, entity)
+		}
+
+		detection.Entities = entities
+	}
+}
+
+func (c *Config) validateExceptions() []string {
+	msgs := []string{}
+	for rule, exceptions := range c.Exceptions {
+		msgs = append(msgs, validateExceptions(string(rule), exceptions)...)
+	}
+
+	return msgs
+}
@@ -0,0 +1,47 @@
+package policy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		action Action
+		text   string
+		want   string
+		rules  []string
+	}{
+		{name: "blocked by regex rule", action: Block, text: "order 12345", want: "blocked", rules: []string{`\d{5}`}},
+		{name: "redacted by regex rule", action: AllowButRedact, text: "order 12345", want: "redacted", rules: []string{`\d{5}`}},
+		{name: "no match", action: Block, text: "hello", want: "allowed", rules: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{
+				Id: "policy",
+				RegexConfig: &RegexConfig{
+					RegularExpressionRules: []*RegularExpressionRule{{Definition: `\d{5}`, Action: tt.action}},
+				},
+			}
+			require.NoError(t, p.Compile())
+
+			req := &goopenai.ChatCompletionRequest{
+				Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: tt.text}},
+			}
+
+			ex, err := p.Evaluate(http.Client{}, req, &resultScanner{}, nil, zap.NewNop())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ex.Action)
+			assert.Equal(t, tt.rules, ex.Rules)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+const (
+	FailureModeOpen   = "open"
+	FailureModeClosed = "closed"
+
+	defaultExternalInspectionTimeout = 2 * time.Second
+
+	// externalInspectionFailedReason is the reason requests are blocked for
+	// when the external inspection endpoint of a fail closed policy fails.
+	externalInspectionFailedReason = "external inspection failed"
+)
+
+// ExternalInspectionConfig lets a policy send the texts of requests to an
+// external inspection endpoint so that proprietary classifiers can block,
+// warn on or redact requests. The endpoint receives a Request and responds
+// with a Response. Requests are let through if the endpoint fails unless
+// FailureMode is closed.
+type ExternalInspectionConfig struct {
+	Url         string `json:"url"`
+	Timeout     string `json:"timeout"`
+	FailureMode string `json:"failureMode"`
+}
+
+func (c *ExternalInspectionConfig) Validate() []string {
+	msgs := []string{}
+	if len(c.Url) != 0 {
+		if u, err := url.ParseRequestURI(c.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			msgs = append(msgs, "external inspection url must be an http or https url")
+		}
+	}
+
+	if len(c.Timeout) != 0 {
+		if parsed, err := time.ParseDuration(c.Timeout); err != nil || parsed <= 0 {
+			msgs = append(msgs, "external inspection timeout must be a positive duration")
+		}
+	}
+
+	if len(c.FailureMode) != 0 && c.FailureMode != FailureModeOpen && c.FailureMode != FailureModeClosed {
+		msgs = append(msgs, "external inspection failure mode must be one of open or closed")
+	}
+
+	return msgs
+}
+
+func (c *ExternalInspectionConfig) timeout() time.Duration {
+	if parsed, err := time.ParseDuration(c.Timeout); err == nil && parsed > 0 {
+		return parsed
+	}
+
+	return defaultExternalInspectionTimeout
+}
+
+// ShouldInspectExternally reports whether texts of requests filtered by the
+// policy are sent to an external inspection endpoint.
+func (p *Policy) ShouldInspectExternally() bool {
+	return p != nil && p.ExternalInspectionConfig != nil && len(p.ExternalInspectionConfig.Url) != 0
+}
+
+func (p *Policy) inspect(client http.Client, contents []string) (*Response, error) {
+	data, err := json.Marshal(&Request{
+		Contents: contents,
+		Policy:   p,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.ExternalInspectionConfig.timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.ExternalInspectionConfig.Url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("external inspection request failed with status code %d", res.StatusCode)
+	}
+
+	inspected := &Response{}
+	if err := json.Unmarshal(body, inspected); err != nil {
+		return nil, err
+	}
+
+	if inspected.Action == AllowButRedact && len(inspected.Contents) != len(contents) {
+		return nil, fmt.Errorf("external inspection returned %d contents for %d inputs", len(inspected.Contents), len(contents))
+	}
+
+	return inspected, nil
+}
+
+func reasons(m map[string]bool) []string {
+	rs := []string{}
+	for r, ok := range m {
+		if ok {
+			rs = append(rs, r)
+		}
+	}
+
+	sort.Strings(rs)
+	return rs
+}
+
+// inspectExternally merges the verdict of the external inspection endpoint
+// into a scan result. Reasons given by the endpoint are reported in the
+// errors of blocked and warned requests like matched rule definitions.
+func (p *Policy) inspectExternally(client http.Client, sr *ScanResult, log *zap.Logger) {
+	start := time.Now()
+	inspected, err := p.inspect(client, sr.Updated)
+	telemetry.Timing("bricksllm.policy.inspect_externally.latency", time.Since(start), nil, 1)
+
+	if err != nil {
+		telemetry.Incr("bricksllm.policy.inspect_externally.inspect_error", []string{
+			"failure_mode:" + p.ExternalInspectionConfig.FailureMode,
+		}, 1)
+		log.Debug("error when inspecting externally", zap.Error(err))
+
+		if p.ExternalInspectionConfig.FailureMode == FailureModeClosed {
+			sr.Action = Block
+			sr.BlockedCustomDefinitions = append(sr.BlockedCustomDefinitions, externalInspectionFailedReason)
+		}
+
+		return
+	}
+
+	switch inspected.Action {
+	case Block:
+		sr.Action = Block
+		sr.BlockedCustomDefinitions = append(sr.BlockedCustomDefinitions, reasons(inspected.BlockedReasons)...)
+	case AllowButWarn:
+		if sr.Action != Block {
+			sr.Action = AllowButWarn
+		}
+
+		sr.WarnedRegexDefinitions = append(sr.WarnedRegexDefinitions, reasons(inspected.Warnings)...)
+	case AllowButRedact:
+		sr.Updated = inspected.Contents
+		if sr.Action == Allow {
+			sr.Action = AllowButRedact
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package policy
+
+import (
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// ImageConfig lets a policy act on image parts of multi-part chat messages,
+// which cannot be scanned for entities. The allow_but_redact action strips
+// image parts from requests.
+type ImageConfig struct {
+	Action Action `json:"action"`
+}
+
+func (c *ImageConfig) Validate() []string {
+	msgs := []string{}
+	if c.Action != Block && c.Action != AllowButWarn && c.Action != AllowButRedact && c.Action != Allow {
+		msgs = append(msgs, "image action must be one of block, allow_but_warn, allow_but_redact or allow")
+	}
+
+	return msgs
+}
+
+// ShouldInspectImages reports whether image parts of requests filtered by the
+// policy are acted on.
+func (p *Policy) ShouldInspectImages() bool {
+	return p != nil && p.ImageConfig != nil && p.ImageConfig.Action != Allow && len(p.ImageConfig.Action) != 0
+}
+
+// filterImages applies the image config of the policy to chat messages and
+// returns the action taken. Image parts are removed from the messages for the
+// allow_but_redact action. Allow is returned if the messages have no image
+// parts.
+func (p *Policy) filterImages(messages []goopenai.ChatCompletionMessage) Action {
+	if !p.ShouldInspectImages() {
+		return Allow
+	}
+
+	found := false
+	for _, message := range messages {
+		for _, part := range message.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeImageURL {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return Allow
+	}
+
+	if p.ImageConfig.Action == AllowButRedact {
+		for i := range messages {
+			parts := []goopenai.ChatMessagePart{}
+			for _, part := range messages[i].MultiContent {
+				if part.Type != goopenai.ChatMessagePartTypeImageURL {
+					parts = append(parts, part)
+				}
+			}
+
+			if messages[i].MultiContent != nil {
+				messages[i].MultiContent = parts
+			}
+		}
+	}
+
+	return p.ImageConfig.Action
+}
@@ -0,0 +1,295 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxSchemaViolations caps the violations reported for a single response.
+const maxSchemaViolations = 10
+
+// maxSchemaSteps caps the schemas visited while validating a single response
+// so that references fanning out through anyOf and allOf cannot make
+// validation exponential.
+const maxSchemaSteps = 10000
+
+// schemaValidator validates decoded JSON values against the subset of JSON
+// Schema supported by OpenAI structured outputs: types, enum, const,
+// properties, required, additionalProperties, items, anyOf, allOf, local $ref,
+// string lengths and patterns, numeric bounds and array lengths.
+type schemaValidator struct {
+	root       map[string]any
+	violations []string
+	steps      *int
+}
+
+func validateJsonSchema(schema map[string]any, instance any) []string {
+	steps := maxSchemaSteps
+	v := &schemaValidator{root: schema, steps: &steps}
+	v.validate("$", schema, instance, 0)
+
+	if steps < 0 {
+		v.report("$", "schema cannot be validated within %d steps", maxSchemaSteps)
+	}
+
+	return v.violations
+}
+
+func (v *schemaValidator) report(path, format string, args ...any) {
+	if len(v.violations) < maxSchemaViolations {
+		v.violations = append(v.violations, path+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+// resolve returns the schema a local reference such as #/$defs/step points
+// to. Nil is returned for remote or unknown references.
+func (v *schemaValidator) resolve(ref string) map[string]any {
+	if ref == "#" {
+		return v.root
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+
+	var current any = v.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		current = m[token]
+	}
+
+	resolved, _ := current.(map[string]any)
+	return resolved
+}
+
+func jsonType(instance any) string {
+	switch converted := instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if converted == math.Trunc(converted) {
+			return "integer"
+		}
+
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return "unknown"
+}
+
+func matchesType(expected, actual string) bool {
+	return expected == actual || (expected == "number" && actual == "integer")
+}
+
+func (v *schemaValidator) validate(path string, schema map[string]any, instance any, depth int) {
+	// guards against recursive references that never consume the instance.
+	if schema == nil || depth > 64 {
+		return
+	}
+
+	// the budget is shared with nested validators of anyOf schemas.
+	*v.steps--
+	if *v.steps < 0 {
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved := v.resolve(ref)
+		if resolved == nil {
+			v.report(path, "reference %s cannot be resolved", ref)
+			return
+		}
+
+		v.validate(path, resolved, instance, depth+1)
+	}
+
+	actual := jsonType(instance)
+	switch expected := schema["type"].(type) {
+	case string:
+		if !matchesType(expected, actual) {
+			v.report(path, "expected %s but got %s", expected, actual)
+			return
+		}
+	case []any:
+		found := false
+		names := []string{}
+		for _, t := range expected {
+			if name, ok := t.(string); ok {
+				names = append(names, name)
+				found = found || matchesType(name, actual)
+			}
+		}
+
+		if !found {
+			v.report(path, "expected %s but got %s", strings.Join(names, " or "), actual)
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, instance) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			v.report(path, "value is not one of the enum values")
+		}
+	}
+
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, instance) {
+		v.report(path, "value does not match the const value")
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			subSchema, _ := sub.(map[string]any)
+			nested := &schemaValidator{root: v.root, steps: v.steps}
+			nested.validate(path, subSchema, instance, depth+1)
+			if len(nested.violations) == 0 {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			v.report(path, "value does not match any of the anyOf schemas")
+		}
+	}
+
+	if allOf, ok := schema["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			subSchema, _ := sub.(map[string]any)
+			v.validate(path, subSchema, instance, depth+1)
+		}
+	}
+
+	switch converted := instance.(type) {
+	case map[string]any:
+		v.validateObject(path, schema, converted, depth)
+	case []any:
+		v.validateArray(path, schema, converted, depth)
+	case string:
+		v.validateString(path, schema, converted)
+	case float64:
+		v.validateNumber(path, schema, converted)
+	}
+}
+
+func (v *schemaValidator) validateObject(path string, schema map[string]any, instance map[string]any, depth int) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := instance[name]; !ok {
+				v.report(path, "missing required property %s", name)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(instance))
+	for key := range instance {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if sub, ok := properties[key].(map[string]any); ok {
+			v.validate(path+"."+key, sub, instance[key], depth+1)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.report(path, "property %s is not allowed", key)
+			}
+		case map[string]any:
+			v.validate(path+"."+key, additional, instance[key], depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(path string, schema map[string]any, instance []any, depth int) {
+	if min, ok := schema["minItems"].(float64); ok && float64(len(instance)) < min {
+		v.report(path, "expected at least %v items but got %d", min, len(instance))
+	}
+
+	if max, ok := schema["maxItems"].(float64); ok && float64(len(instance)) > max {
+		v.report(path, "expected at most %v items but got %d", max, len(instance))
+	}
+
+	if items, ok := schema["items"].(map[string]any); ok {
+		for idx, item := range instance {
+			v.validate(fmt.Sprintf("%s[%d]", path, idx), items, item, depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(path string, schema map[string]any, instance string) {
+	length := len([]rune(instance))
+	if min, ok := schema["minLength"].(float64); ok && float64(length) < min {
+		v.report(path, "expected at least %v characters but got %d", min, length)
+	}
+
+	if max, ok := schema["maxLength"].(float64); ok && float64(length) > max {
+		v.report(path, "expected at most %v characters but got %d", max, length)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil && !re.MatchString(instance) {
+			v.report(path, "value does not match pattern %s", pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(path string, schema map[string]any, instance float64) {
+	if min, ok := schema["minimum"].(float64); ok && instance < min {
+		v.report(path, "value is less than the minimum %v", min)
+	}
+
+	if max, ok := schema["maximum"].(float64); ok && instance > max {
+		v.report(path, "value is greater than the maximum %v", max)
+	}
+
+	if min, ok := schema["exclusiveMinimum"].(float64); ok && instance <= min {
+		v.report(path, "value is not greater than the exclusive minimum %v", min)
+	}
+
+	if max, ok := schema["exclusiveMaximum"].(float64); ok && instance >= max {
+		v.report(path, "value is not less than the exclusive maximum %v", max)
+	}
+}
+
+// parseJsonSchema decodes a JSON schema. Boolean schemas are not supported.
+func parseJsonSchema(data []byte) (map[string]any, error) {
+	schema := map[string]any{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
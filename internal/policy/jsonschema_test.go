@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJsonSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 2},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"step": {"$ref": "#/$defs/step"}
+		},
+		"required": ["name"],
+		"additionalProperties": false,
+		"$defs": {
+			"step": {"anyOf": [{"type": "string"}, {"type": "null"}]}
+		}
+	}`
+
+	tests := []struct {
+		name       string
+		instance   string
+		violations []string
+	}{
+		{name: "valid", instance: `{"name":"ann","age":3,"tags":["a"],"step":null}`, violations: []string{}},
+		{name: "missing required", instance: `{"age":3}`, violations: []string{"$: missing required property name"}},
+		{name: "wrong type", instance: `{"name":"ann","age":1.5}`, violations: []string{"$.age: expected integer but got number"}},
+		{name: "additional property", instance: `{"name":"ann","extra":true}`, violations: []string{"$: property extra is not allowed"}},
+		{name: "too many items", instance: `{"name":"ann","tags":["a","b","c"]}`, violations: []string{"$.tags: expected at most 2 items but got 3"}},
+		{name: "reference mismatch", instance: `{"name":"ann","step":1}`, violations: []string{"$.step: value does not match any of the anyOf schemas"}},
+	}
+
+	parsed, err := parseJsonSchema([]byte(schema))
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var instance any
+			require.NoError(t, json.Unmarshal([]byte(tt.instance), &instance))
+
+			violations := validateJsonSchema(parsed, instance)
+			if len(tt.violations) == 0 {
+				assert.Empty(t, violations)
+				return
+			}
+
+			assert.Equal(t, tt.violations, violations)
+		})
+	}
+}
+
+func TestValidateJsonSchemaStepBudget(t *testing.T) {
+	// every definition branches into two references to the next one so that
+	// an exhaustive validation would visit 2^25 schemas.
+	defs := map[string]any{"d25": map[string]any{"type": "integer"}}
+	for i := 0; i < 25; i++ {
+		next := map[string]any{"$ref": fmt.Sprintf("#/$defs/d%d", i+1)}
+		defs[fmt.Sprintf("d%d", i)] = map[string]any{"anyOf": []any{next, next}}
+	}
+
+	schema := map[string]any{"$ref": "#/$defs/d0", "$defs": defs}
+
+	violations := validateJsonSchema(schema, "not an integer")
+	assert.Contains(t, violations, fmt.Sprintf("$: schema cannot be validated within %d steps", maxSchemaSteps))
+}
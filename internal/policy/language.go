@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// languagePrefix is prepended to the detected languages reported in the
+// errors of blocked and warned requests.
+const languagePrefix = "language:"
+
+// minLanguageWords is the number of words texts in latin scripts need for
+// their language to be detected. Shorter texts are too ambiguous.
+const minLanguageWords = 3
+
+// scriptLanguages are languages detected by their script alone.
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Cyrillic, "ru"},
+}
+
+// minLanguageHits is the number of stopwords texts in latin scripts need for
+// their language to be detected.
+const minLanguageHits = 2
+
+// stopwords are frequent words of languages written in latin scripts. Words of
+// one or two letters, such as a or de, are left out since they are shared by
+// too many languages.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "are", "that", "you", "for", "with", "this", "what", "how", "can", "please", "your", "have", "from", "why", "which", "would"},
+	"es": {"los", "las", "que", "por", "para", "con", "una", "como", "qué", "cómo", "está", "pero", "del", "muy", "esto", "tengo", "puedo", "también"},
+	"fr": {"les", "des", "est", "que", "une", "pour", "dans", "avec", "vous", "comment", "pas", "mon", "sur", "mais", "qui", "cette", "nous", "très"},
+	"de": {"der", "die", "das", "und", "ist", "ich", "nicht", "ein", "eine", "mit", "sie", "wie", "was", "für", "auf", "mein", "bitte", "auch"},
+	"it": {"che", "per", "non", "con", "sono", "come", "cosa", "mio", "gli", "della", "questo", "del", "una", "anche", "perché", "molto", "mia", "hai"},
+	"pt": {"que", "não", "como", "meu", "você", "isso", "para", "com", "uma", "mais", "por", "está", "também", "minha", "muito", "tem", "são", "dos"},
+	"nl": {"het", "een", "van", "niet", "dat", "die", "met", "voor", "zijn", "wat", "hoe", "mijn", "ook", "maar", "kan", "wil", "naar", "deze"},
+	"pl": {"nie", "się", "jest", "jak", "mój", "czy", "proszę", "jestem", "ten", "dla", "tak", "jaki", "jaka", "mam", "być", "może", "ale", "tym"},
+	"tr": {"bir", "için", "nasıl", "ben", "değil", "ile", "çok", "benim", "lütfen", "var", "gibi", "olarak", "daha", "ama", "sen", "nedir", "neden", "şey"},
+	"id": {"dan", "yang", "ini", "itu", "dengan", "untuk", "tidak", "saya", "apa", "bagaimana", "ada", "dari", "akan", "bisa", "anda", "tolong", "juga", "sudah"},
+}
+
+var stopwordSets = func() map[string]map[string]bool {
+	sets := map[string]map[string]bool{}
+	for language, list := range stopwords {
+		sets[language] = map[string]bool{}
+		for _, w := range list {
+			sets[language][w] = true
+		}
+	}
+
+	return sets
+}()
+
+// LanguageConfig restricts requests to a set of languages. Languages are
+// ISO 639-1 codes. Texts whose language cannot be detected, such as short
+// texts or code, are let through.
+type LanguageConfig struct {
+	Allowed []string `json:"allowed"`
+	Action  Action   `json:"action"`
+}
+
+func (c *LanguageConfig) Validate() []string {
+	msgs := []string{}
+	for idx, language := range c.Allowed {
+		if len(language) != 2 || strings.ToLower(language) != language {
+			msgs = append(msgs, fmt.Sprintf("allowed language at index [%d] must be a lower case ISO 639-1 code", idx))
+		}
+	}
+
+	if len(c.Action) != 0 && c.Action != Block && c.Action != AllowButWarn && c.Action != Allow {
+		msgs = append(msgs, "language action must be one of block, allow_but_warn or allow")
+	}
+
+	return msgs
+}
+
+// ShouldRestrictLanguages reports whether the languages of requests filtered
+// by the policy are restricted.
+func (p *Policy) ShouldRestrictLanguages() bool {
+	return p != nil && p.LanguageConfig != nil && len(p.LanguageConfig.Allowed) != 0 && (p.LanguageConfig.Action == Block || p.LanguageConfig.Action == AllowButWarn)
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language of a text and
+// false if it cannot be detected. Languages with their own script are told
+// apart by the script of most letters and languages written in latin scripts
+// by their most frequent words. Latin texts are only detected if the most
+// frequent words of a language are found at least minLanguageHits times and
+// twice as often as those of any other language.
+func DetectLanguage(text string) (string, bool) {
+	letters := 0
+	scripts := map[string]int{}
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		letters++
+		for _, sl := range scriptLanguages {
+			if unicode.Is(sl.table, r) {
+				scripts[sl.language]++
+				break
+			}
+		}
+	}
+
+	if letters == 0 {
+		return "", false
+	}
+
+	if scripted, count := mostFrequent(scripts); count*2 > letters {
+		// kanji are used in japanese texts along with kana.
+		if scripted == "zh" && scripts["ja"] != 0 {
+			return "ja", true
+		}
+
+		if scripted == "ru" && strings.ContainsAny(text, "іїєґІЇЄҐ") {
+			return "uk", true
+		}
+
+		if scripted == "ar" && strings.ContainsAny(text, "پچژگ") {
+			return "fa", true
+		}
+
+		return scripted, true
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	if len(words) < minLanguageWords {
+		return "", false
+	}
+
+	scores := map[string]int{}
+	for language, set := range stopwordSets {
+		for _, w := range words {
+			if set[w] {
+				scores[language]++
+			}
+		}
+	}
+
+	language, score := mostFrequent(scores)
+	if score < minLanguageHits {
+		return "", false
+	}
+
+	// languages sharing stopwords, such as spanish and portuguese, are only
+	// told apart by a clear margin.
+	for other, count := range scores {
+		if other != language && count*2 > score {
+			return "", false
+		}
+	}
+
+	return language, true
+}
+
+// mostFrequent returns the key with the highest count. Ties are broken in
+// alphabetical order so that results are deterministic.
+func mostFrequent(counts map[string]int) (string, int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	best, count := "", 0
+	for _, k := range keys {
+		if counts[k] > count {
+			best, count = k, counts[k]
+		}
+	}
+
+	return best, count
+}
+
+// restrictLanguages merges the languages of the texts of a scan result that
+// are not allowed into it.
+func (p *Policy) restrictLanguages(sr *ScanResult) {
+	allowed := map[string]bool{}
+	for _, language := range p.LanguageConfig.Allowed {
+		allowed[language] = true
+	}
+
+	disallowed := []string{}
+	found := map[string]bool{}
+	for _, text := range sr.Updated {
+		language, ok := DetectLanguage(text)
+		if !ok || allowed[language] || found[language] {
+			continue
+		}
+
+		found[language] = true
+		disallowed = append(disallowed, languagePrefix+language)
+	}
+
+	if len(disallowed) == 0 {
+		return
+	}
+
+	if p.LanguageConfig.Action == Block {
+		sr.Action = Block
+		sr.BlockedCustomDefinitions = append(sr.BlockedCustomDefinitions, disallowed...)
+		return
+	}
+
+	if sr.Action != Block {
+		sr.Action = AllowButWarn
+	}
+
+	sr.WarnedRegexDefinitions = append(sr.WarnedRegexDefinitions, disallowed...)
+}
@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		language string
+		detected bool
+	}{
+		{name: "english", text: "What is the best way to learn how to code with Go?", language: "en", detected: true},
+		{name: "spanish", text: "¿Cómo puedo aprender a programar? Tengo muchas preguntas para ti.", language: "es", detected: true},
+		{name: "french", text: "Comment est-ce que je peux apprendre avec vous dans cette classe?", language: "fr", detected: true},
+		{name: "german", text: "Ich habe eine Frage und bitte um Hilfe mit der Aufgabe.", language: "de", detected: true},
+		{name: "portuguese", text: "Você pode me ajudar com isso? Não sei como fazer.", language: "pt", detected: true},
+		{name: "japanese", text: "東京は日本の首都です。", language: "ja", detected: true},
+		{name: "korean", text: "안녕하세요 만나서 반갑습니다", language: "ko", detected: true},
+		{name: "russian", text: "Привет, как у тебя дела?", language: "ru", detected: true},
+		{name: "ukrainian", text: "Привіт, як справи? Її немає.", language: "uk", detected: true},
+		{name: "english haiku", text: "Write a haiku about a cat"},
+		{name: "english todo app", text: "Give me a todo app with a login page"},
+		{name: "short", text: "hola amigo"},
+		{name: "no letters", text: "1 + 2 = 3"},
+		{name: "ambiguous", text: "que para con que para com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language, detected := DetectLanguage(tt.text)
+			assert.Equal(t, tt.detected, detected)
+			assert.Equal(t, tt.language, language)
+		})
+	}
+}
+
+func TestRestrictLanguages(t *testing.T) {
+	tests := []struct {
+		name   string
+		action Action
+		texts  []string
+		want   Action
+	}{
+		{name: "allowed language", action: Block, texts: []string{"What is the best way to learn how to code?"}, want: Allow},
+		{name: "undetected language", action: Block, texts: []string{"Write a haiku about a cat"}, want: Allow},
+		{name: "blocked language", action: Block, texts: []string{"Você pode me ajudar com isso? Não sei como fazer."}, want: Block},
+		{name: "warned language", action: AllowButWarn, texts: []string{"Você pode me ajudar com isso? Não sei como fazer."}, want: AllowButWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{LanguageConfig: &LanguageConfig{Allowed: []string{"en"}, Action: tt.action}}
+			sr := &ScanResult{Updated: tt.texts, Action: Allow}
+
+			p.restrictLanguages(sr)
+			assert.Equal(t, tt.want, sr.Action)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// ModerationConfig lets a policy act on the results of the OpenAI
+// moderations endpoint for requests made with an OpenAI provider setting.
+// Categories scoring at or above the threshold, or flagged categories if no
+// threshold is set, trigger the configured action. Categories with a category
+// rule are evaluated with the threshold and action of their rule instead.
+// Requests are sent to Url if set, which must serve an OpenAI compatible
+// moderations endpoint. Requests are let through if the moderations endpoint
+// fails unless FailureMode is closed.
+type ModerationConfig struct {
+	Action         Action                    `json:"action"`
+	ScoreThreshold float64                   `json:"scoreThreshold"`
+	Categories     []string                  `json:"categories"`
+	CategoryRules  []*ModerationCategoryRule `json:"categoryRules"`
+	Model          string                    `json:"model"`
+	Url            string                    `json:"url"`
+	FailureMode    string                    `json:"failureMode"`
+}
+
+// ModerationCategoryRule is the threshold and action of a single moderation
+// category.
+type ModerationCategoryRule struct {
+	Category  string  `json:"category"`
+	Threshold float64 `json:"threshold"`
+	Action    Action  `json:"action"`
+}
+
+func (r *ModerationCategoryRule) matches(f *openai.ModerationFinding) bool {
+	if r.Threshold == 0 {
+		return f.Flagged
+	}
+
+	return f.Score >= r.Threshold
+}
+
+func (c *ModerationConfig) Validate() []string {
+	msgs := []string{}
+	if c.Action != Block && c.Action != AllowButWarn && c.Action != Allow {
+		msgs = append(msgs, "moderation action must be one of block, allow_but_warn or allow")
+	}
+
+	if c.ScoreThreshold < 0 || c.ScoreThreshold > 1 {
+		msgs = append(msgs, "moderation score threshold must be between 0 and 1")
+	}
+
+	seen := map[string]bool{}
+	for idx, rule := range c.CategoryRules {
+		if rule == nil {
+			msgs = append(msgs, fmt.Sprintf("moderation category rule at index [%d] cannot be nil", idx))
+			continue
+		}
+
+		if len(rule.Category) == 0 {
+			msgs = append(msgs, fmt.Sprintf("moderation category rule at index [%d] must have a category", idx))
+		} else if seen[rule.Category] {
+			msgs = append(msgs, fmt.Sprintf("moderation category rule at index [%d] duplicates category %s", idx, rule.Category))
+		}
+
+		seen[rule.Category] = true
+
+		if rule.Threshold < 0 || rule.Threshold > 1 {
+			msgs = append(msgs, fmt.Sprintf("moderation category rule at index [%d] must have a threshold between 0 and 1", idx))
+		}
+
+		if rule.Action != Block && rule.Action != AllowButWarn {
+			msgs = append(msgs, fmt.Sprintf("moderation category rule at index [%d] must have an action of block or allow_but_warn", idx))
+		}
+	}
+
+	if len(c.Url) != 0 {
+		if u, err := url.ParseRequestURI(c.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			msgs = append(msgs, "moderation url must be an http or https url")
+		}
+	}
+
+	if len(c.FailureMode) != 0 && c.FailureMode != FailureModeOpen && c.FailureMode != FailureModeClosed {
+		msgs = append(msgs, "moderation failure mode must be one of open or closed")
+	}
+
+	return msgs
+}
+
+func (c *ModerationConfig) categoryRule(category string) *ModerationCategoryRule {
+	for _, rule := range c.CategoryRules {
+		if rule != nil && rule.Category == category {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+func (c *ModerationConfig) matches(f *openai.ModerationFinding) bool {
+	if len(c.Categories) != 0 && !slices.Contains(c.Categories, f.Category) {
+		return false
+	}
+
+	if c.ScoreThreshold == 0 {
+		return f.Flagged
+	}
+
+	return f.Score >= c.ScoreThreshold
+}
+
+// ShouldModerate reports whether requests filtered by the policy are sent to
+// the moderations endpoint.
+func (p *Policy) ShouldModerate() bool {
+	if p == nil || p.ModerationConfig == nil {
+		return false
+	}
+
+	return (p.ModerationConfig.Action != Allow && len(p.ModerationConfig.Action) != 0) || len(p.ModerationConfig.CategoryRules) != 0
+}
+
+// ModerationModel returns the model used to moderate requests.
+func (p *Policy) ModerationModel() string {
+	if p == nil || p.ModerationConfig == nil || len(p.ModerationConfig.Model) == 0 {
+		return openai.DefaultModerationModel
+	}
+
+	return p.ModerationConfig.Model
+}
+
+// ModerationFailureMode returns what happens to requests if the moderations
+// endpoint fails. Requests are let through by default.
+func (p *Policy) ModerationFailureMode() string {
+	if p == nil || p.ModerationConfig == nil || p.ModerationConfig.FailureMode != FailureModeClosed {
+		return FailureModeOpen
+	}
+
+	return FailureModeClosed
+}
+
+// ModerationUrl returns the moderations endpoint requests are sent to.
+func (p *Policy) ModerationUrl() string {
+	if p == nil || p.ModerationConfig == nil || len(p.ModerationConfig.Url) == 0 {
+		return openai.ModerationsUrl
+	}
+
+	return p.ModerationConfig.Url
+}
+
+// EvaluateModeration returns the most severe action the policy takes on
+// moderation findings. Allow is returned if the policy does not act on
+// moderation results or if no finding matches the config.
+func (p *Policy) EvaluateModeration(findings []*openai.ModerationFinding) Action {
+	if !p.ShouldModerate() {
+		return Allow
+	}
+
+	action := Allow
+	for _, f := range findings {
+		if f == nil {
+			continue
+		}
+
+		if rule := p.ModerationConfig.categoryRule(f.Category); rule != nil {
+			if rule.matches(f) {
+				action = moreSevere(action, rule.Action)
+			}
+
+			continue
+		}
+
+		if p.ModerationConfig.matches(f) && len(p.ModerationConfig.Action) != 0 {
+			action = moreSevere(action, p.ModerationConfig.Action)
+		}
+	}
+
+	return action
+}
+
+func moreSevere(a, b Action) Action {
+	if a == Block || b == Block {
+		return Block
+	}
+
+	if a == AllowButWarn || b == AllowButWarn {
+		return AllowButWarn
+	}
+
+	return Allow
+}
+
+// ModerationInput returns the texts of a request that are moderated. Requests
+// without texts that can be moderated return an empty slice.
+func ModerationInput(input any) []string {
+	texts := []string{}
+
+	switch converted := input.(type) {
+	case *goopenai.ChatCompletionRequest:
+		for _, message := range converted.Messages {
+			if len(message.Content) != 0 {
+				texts = append(texts, message.Content)
+			}
+
+			for _, part := range message.MultiContent {
+				if part.Type == goopenai.ChatMessagePartTypeText && len(part.Text) != 0 {
+					texts = append(texts, part.Text)
+				}
+			}
+		}
+	case *goopenai.EmbeddingRequest:
+		if inputs, ok := converted.Input.([]interface{}); ok {
+			for _, input := range inputs {
+				if stringified, ok := input.(string); ok && len(stringified) != 0 {
+					texts = append(texts, stringified)
+				}
+			}
+		}
+
+		if stringified, ok := converted.Input.(string); ok && len(stringified) != 0 {
+			texts = append(texts, stringified)
+		}
+	}
+
+	return texts
+}
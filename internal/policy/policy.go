@@ -1,18 +1,18 @@
 package policy
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/pii"
-	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
-	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
-	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"go.uber.org/zap"
 
@@ -26,6 +26,7 @@ const (
 	AllowButWarn   Action = "allow_but_warn"
 	AllowButRedact Action = "allow_but_redact"
 	Allow          Action = "allow"
+	Retry          Action = "retry"
 )
 
 type Rule string
@@ -34,6 +35,7 @@ const (
 	Address                             Rule = "address"
 	Age                                 Rule = "age"
 	All                                 Rule = "all"
+	ApiKey                              Rule = "api_key"
 	AwsAccessKey                        Rule = "aws_access_key"
 	AwsSecretKey                        Rule = "aws_secret_key"
 	BankAccountNumber                   Rule = "bank_account_number"
@@ -46,12 +48,14 @@ const (
 	DateTime                            Rule = "date_time"
 	DriverId                            Rule = "driver_id"
 	Email                               Rule = "email"
+	GithubToken                         Rule = "github_token"
 	InAadhaar                           Rule = "in_aadhaar"
 	InNrega                             Rule = "in_nrega"
 	InPermanentAccountNumber            Rule = "in_permanent_account_number"
 	InVoterNumber                       Rule = "in_voter_number"
 	InternationalBankAccountNumber      Rule = "international_bank_account_number"
 	IpAddress                           Rule = "ip_address"
+	Jwt                                 Rule = "jwt"
 	LicensePlate                        Rule = "license_plate"
 	MacAddress                          Rule = "mac_address"
 	Name                                Rule = "name"
@@ -59,7 +63,10 @@ const (
 	Password                            Rule = "password"
 	Phone                               Rule = "phone"
 	Pin                                 Rule = "pin"
+	PrivateKey                          Rule = "private_key"
+	SlackToken                          Rule = "slack_token"
 	Ssn                                 Rule = "ssn"
+	StripeKey                           Rule = "stripe_key"
 	SwiftCode                           Rule = "swift_code"
 	UkNationalHealthServiceNumber       Rule = "uk_national_health_service_number"
 	UkNationalInsuranceNumber           Rule = "uk_national_insurance_number"
@@ -76,70 +83,104 @@ type CustomRule struct {
 }
 
 type RegularExpressionRule struct {
-	Definition string `json:"definition"`
-	Action     Action `json:"action"`
+	Definition  string   `json:"definition"`
+	Action      Action   `json:"action"`
+	Replacement string   `json:"replacement"`
+	Exceptions  []string `json:"exceptions"`
+
+	regex *regexp.Regexp
 }
 
 type Config struct {
-	Rules map[Rule]Action `json:"rules"`
+	Rules         map[Rule]Action   `json:"rules"`
+	Replacements  map[Rule]string   `json:"replacements"`
+	StoreFindings bool              `json:"storeFindings"`
+	ScanResponses bool              `json:"scanResponses"`
+	Scanner       string            `json:"scanner"`
+	Reversible    bool              `json:"reversible"`
+	Exceptions    map[Rule][]string `json:"exceptions"`
+	FailureMode   string            `json:"failureMode"`
 }
 
 type RegexConfig struct {
 	RegularExpressionRules []*RegularExpressionRule `json:"rules"`
+	ScanResponses          bool                     `json:"scanResponses"`
+	Reversible             bool                     `json:"reversible"`
 }
 
 type CustomConfig struct {
-	CustomRules []*CustomRule `json:"rules"`
+	CustomRules []*CustomRule       `json:"rules"`
+	EntityTypes []*CustomEntityType `json:"entityTypes"`
 }
 
 type Policy struct {
-	Id           string        `json:"id"`
-	Name         string        `json:"name"`
-	CreatedAt    int64         `json:"createdAt"`
-	UpdatedAt    int64         `json:"updatedAt"`
-	Tags         []string      `json:"tags"`
-	Config       *Config       `json:"config"`
-	RegexConfig  *RegexConfig  `json:"regexConfig"`
-	CustomConfig *CustomConfig `json:"customConfig"`
+	Id                       string                    `json:"id"`
+	Name                     string                    `json:"name"`
+	CreatedAt                int64                     `json:"createdAt"`
+	UpdatedAt                int64                     `json:"updatedAt"`
+	Tags                     []string                  `json:"tags"`
+	Config                   *Config                   `json:"config"`
+	RegexConfig              *RegexConfig              `json:"regexConfig"`
+	CustomConfig             *CustomConfig             `json:"customConfig"`
+	AzureContentFilterConfig *AzureContentFilterConfig `json:"azureContentFilterConfig"`
+	ModerationConfig         *ModerationConfig         `json:"moderationConfig"`
+	StructuredOutputConfig   *StructuredOutputConfig   `json:"structuredOutputConfig"`
+	PromptInjectionConfig    *PromptInjectionConfig    `json:"promptInjectionConfig"`
+	ExternalInspectionConfig *ExternalInspectionConfig `json:"externalInspectionConfig"`
+	ImageConfig              *ImageConfig              `json:"imageConfig"`
+	WarningConfig            *WarningConfig            `json:"warningConfig"`
+	TopicConfig              *TopicConfig              `json:"topicConfig"`
+	SizeConfig               *SizeConfig               `json:"sizeConfig"`
+	LanguageConfig           *LanguageConfig           `json:"languageConfig"`
+	BrandConfig              *BrandConfig              `json:"brandConfig"`
+	ProfanityConfig          *ProfanityConfig          `json:"profanityConfig"`
+	KeyTags                  []string                  `json:"keyTags"`
 }
 
 type UpdatePolicy struct {
-	Name         string        `json:"name"`
-	UpdatedAt    int64         `json:"updatedAt"`
-	Tags         []string      `json:"tags"`
-	Config       *Config       `json:"config"`
-	RegexConfig  *RegexConfig  `json:"regexConfig"`
-	CustomConfig *CustomConfig `json:"customConfig"`
+	Name                     string                    `json:"name"`
+	UpdatedAt                int64                     `json:"updatedAt"`
+	Tags                     []string                  `json:"tags"`
+	Config                   *Config                   `json:"config"`
+	RegexConfig              *RegexConfig              `json:"regexConfig"`
+	CustomConfig             *CustomConfig             `json:"customConfig"`
+	AzureContentFilterConfig *AzureContentFilterConfig `json:"azureContentFilterConfig"`
+	ModerationConfig         *ModerationConfig         `json:"moderationConfig"`
+	StructuredOutputConfig   *StructuredOutputConfig   `json:"structuredOutputConfig"`
+	PromptInjectionConfig    *PromptInjectionConfig    `json:"promptInjectionConfig"`
+	ExternalInspectionConfig *ExternalInspectionConfig `json:"externalInspectionConfig"`
+	ImageConfig              *ImageConfig              `json:"imageConfig"`
+	WarningConfig            *WarningConfig            `json:"warningConfig"`
+	TopicConfig              *TopicConfig              `json:"topicConfig"`
+	SizeConfig               *SizeConfig               `json:"sizeConfig"`
+	LanguageConfig           *LanguageConfig           `json:"languageConfig"`
+	BrandConfig              *BrandConfig              `json:"brandConfig"`
+	ProfanityConfig          *ProfanityConfig          `json:"profanityConfig"`
+	KeyTags                  []string                  `json:"keyTags"`
 }
 
-func extractTextContents(input any) []string {
-	contents := []string{}
+func (p *UpdatePolicy) Validate() error {
+	if p == nil {
+		return internal_errors.NewValidationError("regex rule at index [%d] cannot be nil")
+	}
 
-	if parts, ok := input.([]interface{}); ok {
-		for _, part := range parts {
-			if textPart, ok := part.(map[string]interface{}); ok {
-				tv, ok := textPart["text"].(string)
+	msgs := []string{}
 
-				if ok {
-					contents = append(contents, tv)
-				}
-			}
-		}
+	if p.Config != nil && !pii.IsBackend(p.Config.Scanner) {
+		msgs = append(msgs, "config scanner must be one of amazon_comprehend, local or presidio")
 	}
 
-	if content, ok := input.(string); ok {
-		contents = append(contents, content)
+	if p.Config != nil {
+		msgs = append(msgs, p.Config.validateExceptions()...)
 	}
 
-	return contents
-}
-
-func (p *UpdatePolicy) Validate() error {
-	if p == nil {
-		return internal_errors.NewValidationError("regex rule at index [%d] cannot be nil")
+	if p.CustomConfig != nil {
+		msgs = append(msgs, p.CustomConfig.Validate()...)
 	}
 
-	msgs := []string{}
+	if p.Config != nil && len(p.Config.FailureMode) != 0 && p.Config.FailureMode != FailureModeOpen && p.Config.FailureMode != FailureModeClosed {
+		msgs = append(msgs, "config failure mode must be one of open or closed")
+	}
 
 	if p.RegexConfig != nil {
 		for idx, rule := range p.RegexConfig.RegularExpressionRules {
@@ -152,6 +193,62 @@ func (p *UpdatePolicy) Validate() error {
 			if err != nil {
 				msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] cannot be compiled", idx))
 			}
+
+			msgs = append(msgs, validateExceptions(fmt.Sprintf("regex rule at index [%d]", idx), rule.Exceptions)...)
+		}
+	}
+
+	if p.AzureContentFilterConfig != nil {
+		msgs = append(msgs, p.AzureContentFilterConfig.Validate()...)
+	}
+
+	if p.ModerationConfig != nil {
+		msgs = append(msgs, p.ModerationConfig.Validate()...)
+	}
+
+	if p.StructuredOutputConfig != nil {
+		msgs = append(msgs, p.StructuredOutputConfig.Validate()...)
+	}
+
+	if p.PromptInjectionConfig != nil {
+		msgs = append(msgs, p.PromptInjectionConfig.Validate()...)
+	}
+
+	if p.ExternalInspectionConfig != nil {
+		msgs = append(msgs, p.ExternalInspectionConfig.Validate()...)
+	}
+
+	if p.ImageConfig != nil {
+		msgs = append(msgs, p.ImageConfig.Validate()...)
+	}
+
+	if p.WarningConfig != nil {
+		msgs = append(msgs, p.WarningConfig.Validate()...)
+	}
+
+	if p.TopicConfig != nil {
+		msgs = append(msgs, p.TopicConfig.Validate()...)
+	}
+
+	if p.SizeConfig != nil {
+		msgs = append(msgs, p.SizeConfig.Validate()...)
+	}
+
+	if p.LanguageConfig != nil {
+		msgs = append(msgs, p.LanguageConfig.Validate()...)
+	}
+
+	if p.BrandConfig != nil {
+		msgs = append(msgs, p.BrandConfig.Validate()...)
+	}
+
+	if p.ProfanityConfig != nil {
+		msgs = append(msgs, p.ProfanityConfig.Validate()...)
+	}
+
+	for idx, tag := range p.KeyTags {
+		if len(tag) == 0 {
+			msgs = append(msgs, fmt.Sprintf("key tag at index [%d] cannot be empty", idx))
 		}
 	}
 
@@ -181,6 +278,22 @@ func (p *Policy) Validate() error {
 
 	msgs := []string{}
 
+	if p.Config != nil && !pii.IsBackend(p.Config.Scanner) {
+		msgs = append(msgs, "config scanner must be one of amazon_comprehend, local or presidio")
+	}
+
+	if p.Config != nil {
+		msgs = append(msgs, p.Config.validateExceptions()...)
+	}
+
+	if p.CustomConfig != nil {
+		msgs = append(msgs, p.CustomConfig.Validate()...)
+	}
+
+	if p.Config != nil && len(p.Config.FailureMode) != 0 && p.Config.FailureMode != FailureModeOpen && p.Config.FailureMode != FailureModeClosed {
+		msgs = append(msgs, "config failure mode must be one of open or closed")
+	}
+
 	if p.RegexConfig != nil {
 		for idx, rule := range p.RegexConfig.RegularExpressionRules {
 			if rule == nil {
@@ -192,627 +305,380 @@ func (p *Policy) Validate() error {
 			if err != nil {
 				msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] cannot be compiled", idx))
 			}
+
+			msgs = append(msgs, validateExceptions(fmt.Sprintf("regex rule at index [%d]", idx), rule.Exceptions)...)
 		}
 	}
 
-	if len(msgs) != 0 {
-		return internal_errors.NewValidationError("policy is not valid: " + strings.Join(msgs, " ,"))
+	if p.AzureContentFilterConfig != nil {
+		msgs = append(msgs, p.AzureContentFilterConfig.Validate()...)
 	}
 
-	return nil
-}
-
-func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) error {
-	if p == nil || scanner == nil || input == nil {
-		return nil
+	if p.ModerationConfig != nil {
+		msgs = append(msgs, p.ModerationConfig.Validate()...)
 	}
 
-	shouldInspect := false
-	if p.Config != nil {
-		for _, action := range p.Config.Rules {
-			if action != Allow {
-				shouldInspect = true
-			}
-		}
+	if p.StructuredOutputConfig != nil {
+		msgs = append(msgs, p.StructuredOutputConfig.Validate()...)
 	}
 
-	if p.RegexConfig != nil {
-		for _, regexr := range p.RegexConfig.RegularExpressionRules {
-			if regexr.Action != Allow {
-				shouldInspect = true
-			}
-		}
+	if p.PromptInjectionConfig != nil {
+		msgs = append(msgs, p.PromptInjectionConfig.Validate()...)
 	}
 
-	if p.CustomConfig != nil {
-		for _, cr := range p.CustomConfig.CustomRules {
-			if cr.Action != Allow {
-				shouldInspect = true
-			}
-		}
+	if p.ExternalInspectionConfig != nil {
+		msgs = append(msgs, p.ExternalInspectionConfig.Validate()...)
 	}
 
-	if !shouldInspect {
-		return nil
+	if p.ImageConfig != nil {
+		msgs = append(msgs, p.ImageConfig.Validate()...)
 	}
 
-	switch input.(type) {
-	case *goopenai.EmbeddingRequest:
-		converted := input.(*goopenai.EmbeddingRequest)
-		if inputs, ok := converted.Input.([]interface{}); ok {
-			inputsToInspect := []string{}
-
-			for _, input := range inputs {
-				stringified, ok := input.(string)
-				if !ok {
-					return errors.New("input is not string")
-				}
-
-				inputsToInspect = append(inputsToInspect, stringified)
-			}
-
-			result, err := p.scan(inputsToInspect, scanner, cd, log)
-			if err != nil {
-				return err
-			}
-
-			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-			}
-
-			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-			}
-
-			if len(result.Updated) == 1 {
-				converted.Input = result.Updated[0]
-			}
-
-			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
-			}
-		} else if input, ok := converted.Input.(string); ok {
-			result, err := p.scan([]string{input}, scanner, cd, log)
-			if err != nil {
-				return err
-			}
-
-			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-			}
-
-			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-			}
-
-			if len(result.Updated) == 1 {
-				converted.Input = result.Updated[0]
-			}
-
-			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
-			}
-		}
-
-		return nil
-	case *goopenai.ChatCompletionRequest:
-		converted := input.(*goopenai.ChatCompletionRequest)
-		newMessages := []goopenai.ChatCompletionMessage{}
-
-		contents := []string{}
-		for _, message := range converted.Messages {
-			contents = append(contents, message.Content)
-		}
-
-		result, err := p.scan(contents, scanner, cd, log)
-		if err != nil {
-			return err
-		}
-
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-		}
-
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-		}
-
-		if len(result.Updated) != len(converted.Messages) {
-			return errors.New("updated contents length not consistent with existing content length")
-		}
-
-		for index, c := range result.Updated {
-			newMessages = append(newMessages, goopenai.ChatCompletionMessage{
-				Content:      c,
-				Role:         converted.Messages[index].Role,
-				ToolCalls:    converted.Messages[index].ToolCalls,
-				ToolCallID:   converted.Messages[index].ToolCallID,
-				Name:         converted.Messages[index].Name,
-				FunctionCall: converted.Messages[index].FunctionCall,
-			})
-		}
-
-		converted.Messages = newMessages
-
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
-		}
-
-		return nil
-	case *vllm.CompletionRequest:
-		converted := input.(*vllm.CompletionRequest)
-		if inputs, ok := converted.Prompt.([]string); ok {
-			result, err := p.scan(inputs, scanner, cd, log)
-			if err != nil {
-				return err
-			}
-
-			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-			}
-
-			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-			}
-
-			if len(result.Updated) == 1 {
-				converted.Prompt = result.Updated[0]
-			}
-
-			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
-			}
-
-		} else if input, ok := converted.Prompt.(string); ok {
-			result, err := p.scan([]string{input}, scanner, cd, log)
-			if err != nil {
-				return err
-			}
-
-			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-			}
-
-			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-			}
-
-			if len(result.Updated) == 1 {
-				converted.Prompt = result.Updated[0]
-			}
+	if p.WarningConfig != nil {
+		msgs = append(msgs, p.WarningConfig.Validate()...)
+	}
 
-			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
-			}
-		}
+	if p.TopicConfig != nil {
+		msgs = append(msgs, p.TopicConfig.Validate()...)
+	}
 
-		return nil
-	case *vllm.ChatRequest:
-		converted := input.(*vllm.ChatRequest)
-		newMessages := []goopenai.ChatCompletionMessage{}
+	if p.SizeConfig != nil {
+		msgs = append(msgs, p.SizeConfig.Validate()...)
+	}
 
-		contents := []string{}
-		for _, message := range converted.Messages {
-			contents = append(contents, message.Content)
-		}
+	if p.LanguageConfig != nil {
+		msgs = append(msgs, p.LanguageConfig.Validate()...)
+	}
 
-		result, err := p.scan(contents, scanner, cd, log)
-		if err != nil {
-			return err
-		}
+	if p.BrandConfig != nil {
+		msgs = append(msgs, p.BrandConfig.Validate()...)
+	}
 
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-		}
+	if p.ProfanityConfig != nil {
+		msgs = append(msgs, p.ProfanityConfig.Validate()...)
+	}
 
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+	for idx, tag := range p.KeyTags {
+		if len(tag) == 0 {
+			msgs = append(msgs, fmt.Sprintf("key tag at index [%d] cannot be empty", idx))
 		}
+	}
 
-		if len(result.Updated) != len(converted.Messages) {
-			return errors.New("updated contents length not consistent with existing content length")
-		}
+	if len(msgs) != 0 {
+		return internal_errors.NewValidationError("policy is not valid: " + strings.Join(msgs, " ,"))
+	}
 
-		for index, c := range result.Updated {
-			newMessages = append(newMessages, goopenai.ChatCompletionMessage{
-				Content:      c,
-				Role:         converted.Messages[index].Role,
-				ToolCalls:    converted.Messages[index].ToolCalls,
-				ToolCallID:   converted.Messages[index].ToolCallID,
-				Name:         converted.Messages[index].Name,
-				FunctionCall: converted.Messages[index].FunctionCall,
-			})
-		}
+	return nil
+}
 
-		converted.Messages = newMessages
+func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) error {
+	return p.filter(client, input, scanner, cd, log, nil)
+}
 
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
-		}
+// ShouldStoreFindings returns true if detected entities should be persisted
+// alongside events.
+func (p *Policy) ShouldStoreFindings() bool {
+	return p != nil && p.Config != nil && p.Config.StoreFindings && len(p.Config.Rules) != 0
+}
 
-		return nil
+// FilterWithFindings behaves like Filter and additionally returns the entity
+// types detected in the input regardless of the action taken.
+func (p *Policy) FilterWithFindings(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) ([]*pii.Finding, error) {
+	findings, _, err := p.FilterWithTokens(client, input, scanner, cd, log)
+	return findings, err
+}
 
-	case *anthropic.MessagesRequest:
-		converted := input.(*anthropic.MessagesRequest)
-		newMessages := []anthropic.Message{}
+// FilterWithTokens behaves like FilterWithFindings and additionally returns
+// the tokens replacing values redacted by reversible rules, which are used to
+// restore the values in responses.
+func (p *Policy) FilterWithTokens(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) ([]*pii.Finding, *Tokens, error) {
+	fr, err := p.FilterWithResult(client, input, scanner, cd, log)
 
-		contents := []string{}
-		for _, message := range converted.Messages {
-			contents = append(contents, message.Content)
-		}
+	return fr.Findings, fr.Tokens, err
+}
 
-		result, err := p.scan(contents, scanner, cd, log)
-		if err != nil {
-			return err
-		}
+// FilterResult describes what happened while a request was filtered.
+type FilterResult struct {
+	Findings []*pii.Finding
+	Tokens   *Tokens
+	// ScannerFailure is the failure mode applied if the PII scanner failed
+	// and is empty otherwise.
+	ScannerFailure string
+	// RegexRules are the definitions of the regex rules matching the input.
+	RegexRules []string
+	// Truncated is true if messages were dropped to fit size limits.
+	Truncated bool
+	// Rules are the rules that made the policy block, warn on or redact the
+	// request.
+	Rules []*TriggeredRule
+}
 
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-		}
+// FilterWithResult behaves like FilterWithTokens and additionally reports
+// whether the PII scanner failed.
+func (p *Policy) FilterWithResult(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) (*FilterResult, error) {
+	fc := newFindingsCollector()
+	err := p.filter(client, input, scanner, cd, log, fc)
+
+	return &FilterResult{
+		Findings:       fc.findings(),
+		Tokens:         fc.tokens,
+		ScannerFailure: fc.scannerFailure,
+		RegexRules:     fc.regexRules,
+		Truncated:      fc.truncated,
+		Rules:          fc.rules,
+	}, err
+}
 
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-		}
+// Exemption describes the outcome a policy would have enforced on a request
+// made with a policy exempt key.
+type Exemption struct {
+	PolicyId string   `json:"policyId"`
+	Action   string   `json:"action"`
+	Reason   string   `json:"reason,omitempty"`
+	Rules    []string `json:"rules"`
+}
 
-		if len(result.Updated) != len(converted.Messages) {
-			return errors.New("updated contents length not consistent with existing content length")
-		}
+func copyInput(input any) (any, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
 
-		for index, c := range result.Updated {
-			newMessages = append(newMessages, anthropic.Message{
-				Content: c,
-				Role:    converted.Messages[index].Role,
-			})
-		}
+	copied := reflect.New(reflect.TypeOf(input).Elem()).Interface()
+	err = json.Unmarshal(data, copied)
+	if err != nil {
+		return nil, err
+	}
 
-		converted.Messages = newMessages
+	return copied, nil
+}
 
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
-		}
+// Evaluate runs the policy against a copy of the input without enforcing it
+// and returns the action and rules that would have fired.
+func (p *Policy) Evaluate(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) (*Exemption, error) {
+	copied, err := copyInput(input)
+	if err != nil {
+		return nil, err
+	}
 
-		return nil
+	ex, _, err := p.evaluate(client, copied, scanner, cd, log)
+	return ex, err
+}
 
-	case *anthropic.CompletionRequest:
-		converted := input.(*anthropic.CompletionRequest)
-		result, err := p.scan([]string{converted.Prompt}, scanner, cd, log)
-		if err != nil {
-			return err
-		}
+func (p *Policy) evaluate(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) (*Exemption, *FilterResult, error) {
+	ex := &Exemption{
+		PolicyId: p.Id,
+		Action:   "allowed",
+		Rules:    []string{},
+	}
 
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+	fr, err := p.FilterWithResult(client, input, scanner, cd, log)
+	if err != nil {
+		switch err.(type) {
+		case *internal_errors.BlockedError:
+			ex.Action = "blocked"
+		case *internal_errors.WarningError:
+			ex.Action = "warned"
+		case *internal_errors.RedactError:
+			ex.Action = "redacted"
+		default:
+			return nil, nil, err
 		}
 
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-		}
+		ex.Reason = err.Error()
+	}
 
-		if len(result.Updated) == 1 {
-			converted.Prompt = result.Updated[0]
+	seen := map[string]bool{}
+	addRule := func(rule string) {
+		if !seen[rule] {
+			seen[rule] = true
+			ex.Rules = append(ex.Rules, rule)
 		}
+	}
 
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+	if p.Config != nil {
+		for _, f := range fr.Findings {
+			action, ok := p.Config.Rules[p.Config.ruleFor(f.Type)]
+			if ok && action != Allow {
+				addRule(f.Type)
+			}
 		}
+	}
 
-		return nil
-	case *goopenai.AssistantRequest:
-		converted := input.(*goopenai.AssistantRequest)
+	// regex, custom and other rules are reported as they triggered.
+	for _, triggered := range fr.Rules {
+		addRule(triggered.Rule)
+	}
 
-		if converted.Instructions != nil {
-			result, err := p.scan([]string{*converted.Instructions}, scanner, cd, log)
-			if err != nil {
-				return err
-			}
+	return ex, fr, nil
+}
 
-			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-			}
+// TestResult is the outcome of running a policy against sample text.
+type TestResult struct {
+	*Exemption
+	Output     string         `json:"output"`
+	Entities   []*pii.Finding `json:"entities"`
+	RegexRules []string       `json:"regexRules"`
+}
 
-			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-			}
+// Test runs the policy against sample text sent as a user message and returns
+// the action that would have been enforced along with the text after
+// redaction.
+func (p *Policy) Test(client http.Client, text string, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) (*TestResult, error) {
+	input := &goopenai.ChatCompletionRequest{
+		Messages: []goopenai.ChatCompletionMessage{
+			{
+				Role:    goopenai.ChatMessageRoleUser,
+				Content: text,
+			},
+		},
+	}
 
-			if len(result.Updated) == 1 {
-				converted.Instructions = &result.Updated[0]
-			}
+	ex, fr, err := p.evaluate(client, input, scanner, cd, log)
+	if err != nil {
+		return nil, err
+	}
 
-			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
-			}
-		}
+	return &TestResult{
+		Exemption:  ex,
+		Output:     input.Messages[0].Content,
+		Entities:   fr.Findings,
+		RegexRules: fr.RegexRules,
+	}, nil
+}
 
+func (p *Policy) filter(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger, fc *findingsCollector) error {
+	if p == nil || scanner == nil || input == nil {
 		return nil
-	case *openai.ThreadRequest:
-		converted := input.(*openai.ThreadRequest)
-
-		newMessages := []openai.ThreadMessage{}
-		contents := []string{}
+	}
 
-		for _, message := range converted.Messages {
-			contents = append(contents, extractTextContents(message.Content)...)
+	if p.ShouldLimitSize() {
+		truncated, err := p.limitSize(input)
+		if truncated && fc != nil {
+			fc.lock.Lock()
+			fc.truncated = true
+			fc.lock.Unlock()
 		}
 
-		result, err := p.scan(contents, scanner, cd, log)
 		if err != nil {
 			return err
 		}
+	}
 
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-		}
-
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-		}
-
-		i := 0
-
-		for _, message := range converted.Messages {
-			if parts, ok := message.Content.([]any); ok {
-				contentParts := []any{}
-
-				for _, part := range parts {
-					if textPart, ok := part.(map[string]interface{}); ok {
-						updated := ""
-
-						_, tok := textPart["text"].(string)
-						typev, typeok := textPart["type"].(string)
-
-						if tok && typeok {
-							if i < len(result.Updated) {
-								updated = result.Updated[i]
-							}
-
-							i++
-
-							contentParts = append(contentParts, openai.TextContentPart{
-								Type: typev,
-								Text: updated,
-							})
-
-							continue
-						}
-
-						contentParts = append(contentParts, part)
-					}
-				}
-
-				newMessages = append(newMessages, openai.ThreadMessage{
-					Role:     message.Role,
-					Content:  contentParts,
-					FileIDs:  message.FileIDs,
-					Metadata: message.Metadata,
-				})
-				continue
-			}
-
-			if _, ok := message.Content.(string); ok {
-				if i < len(result.Updated) {
-					newMessages = append(newMessages, openai.ThreadMessage{
-						Role:     message.Role,
-						Content:  result.Updated[i],
-						FileIDs:  message.FileIDs,
-						Metadata: message.Metadata,
-					})
-				}
-
-				i++
+	shouldInspect := fc != nil && p.ShouldStoreFindings()
+	if p.Config != nil {
+		for _, action := range p.Config.Rules {
+			if action != Allow {
+				shouldInspect = true
 			}
 		}
+	}
 
-		converted.Messages = newMessages
-
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
-		}
-
-		return nil
-	case *openai.MessageRequest:
-		converted := input.(*openai.MessageRequest)
-		contents := extractTextContents(converted.Content)
-
-		result, err := p.scan(contents, scanner, cd, log)
-		if err != nil {
-			return err
-		}
-
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-		}
-
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-		}
-
-		i := 0
-		if parts, ok := converted.Content.([]any); ok {
-			contentParts := []any{}
-
-			for _, part := range parts {
-				if textPart, ok := part.(map[string]interface{}); ok {
-					updated := ""
-
-					_, tok := textPart["text"].(string)
-					typev, typeok := textPart["type"].(string)
-
-					if tok && typeok {
-						if i < len(result.Updated) {
-							updated = result.Updated[i]
-						}
-
-						i++
-
-						contentParts = append(contentParts, openai.TextContentPart{
-							Type: typev,
-							Text: updated,
-						})
-
-						continue
-					}
-
-					contentParts = append(contentParts, part)
-				}
+	if p.RegexConfig != nil {
+		for _, regexr := range p.RegexConfig.RegularExpressionRules {
+			if regexr.Action != Allow {
+				shouldInspect = true
 			}
-
-			converted.Content = contentParts
 		}
+	}
 
-		if _, ok := converted.Content.(string); ok {
-			if i < len(result.Updated) {
-				converted.Content = result.Updated[i]
+	if p.CustomConfig != nil {
+		for _, cr := range p.CustomConfig.CustomRules {
+			if cr.Action != Allow {
+				shouldInspect = true
 			}
-
-			i++
-		}
-
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
-		}
-
-		return nil
-	case *goopenai.RunRequest:
-		converted := input.(*goopenai.RunRequest)
-
-		contents := []string{}
-		hasInstructions := false
-		if len(converted.Instructions) != 0 {
-			hasInstructions = true
-			contents = append(contents, converted.Instructions)
-		}
-
-		hasAdditionalInstructions := false
-		if len(converted.AdditionalInstructions) != 0 {
-			hasAdditionalInstructions = true
-			contents = append(contents, converted.AdditionalInstructions)
-		}
-
-		result, err := p.scan(contents, scanner, cd, log)
-		if err != nil {
-			return err
-		}
-
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
 		}
+	}
 
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-		}
+	if p.ShouldInspectExternally() {
+		shouldInspect = true
+	}
 
-		if len(result.Updated) == 2 {
-			converted.Instructions = result.Updated[0]
-			converted.AdditionalInstructions = result.Updated[1]
-		}
+	if p.ShouldInspectImages() {
+		shouldInspect = true
+	}
 
-		if hasInstructions && len(result.Updated) == 1 {
-			converted.Instructions = result.Updated[0]
-		}
+	if p.ShouldMatchTopics() {
+		shouldInspect = true
+	}
 
-		if hasAdditionalInstructions && len(result.Updated) == 1 {
-			converted.AdditionalInstructions = result.Updated[0]
-		}
+	if p.ShouldRestrictLanguages() {
+		shouldInspect = true
+	}
 
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
-		}
+	if p.ShouldFilterProfanity() {
+		shouldInspect = true
+	}
 
+	if !shouldInspect {
 		return nil
-	case *openai.CreateThreadAndRunRequest:
-		converted := input.(*openai.CreateThreadAndRunRequest)
-
-		newMessages := []openai.ThreadMessage{}
-		contents := []string{}
-
-		for _, message := range converted.Thread.Messages {
-			contents = append(contents, extractTextContents(message.Content)...)
-		}
-
-		if len(converted.Instructions) != 0 {
-			contents = append(contents, converted.Instructions)
-		}
-
-		result, err := p.scan(contents, scanner, cd, log)
-		if err != nil {
-			return err
-		}
-
-		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
-		}
-
-		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
-		}
+	}
 
-		i := 0
+	converted, ok := textRequestOf(input)
+	if !ok {
+		return nil
+	}
 
-		for _, message := range converted.Thread.Messages {
-			if parts, ok := message.Content.([]any); ok {
-				contentParts := []any{}
+	if chat, ok := converted.(*chatTexts); ok {
+		return p.filterChat(client, chat, scanner, cd, log, fc)
+	}
 
-				for _, part := range parts {
-					if textPart, ok := part.(map[string]interface{}); ok {
-						updated := ""
+	return p.filterTexts(client, converted, scanner, cd, log, fc)
+}
 
-						_, tok := textPart["text"].(string)
-						typev, typeok := textPart["type"].(string)
+// filterChat filters the texts and images of chat messages. Image outcomes
+// apply after the outcomes of the texts.
+func (p *Policy) filterChat(client http.Client, chat *chatTexts, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger, fc *findingsCollector) error {
+	imageAction := p.filterImages(chat.messages)
+	if imageAction == Block {
+		return internal_errors.NewBlockedError("request blocked due to image content")
+	}
 
-						if tok && typeok {
-							if i < len(result.Updated) {
-								updated = result.Updated[i]
-							}
+	err := p.filterTexts(client, chat, scanner, cd, log, fc)
+	if _, redacted := err.(*internal_errors.RedactError); err != nil && !redacted {
+		return err
+	}
 
-							i++
+	if imageAction == AllowButWarn {
+		return internal_errors.NewWarningError("request warned due to image content")
+	}
 
-							contentParts = append(contentParts, openai.TextContentPart{
-								Type: typev,
-								Text: updated,
-							})
+	if err != nil {
+		return err
+	}
 
-							continue
-						}
+	if imageAction == AllowButRedact {
+		return internal_errors.NewRedactError("request redacted due to image content")
+	}
 
-						contentParts = append(contentParts, part)
-					}
-				}
+	return nil
+}
 
-				newMessages = append(newMessages, openai.ThreadMessage{
-					Role:     message.Role,
-					Content:  contentParts,
-					FileIDs:  message.FileIDs,
-					Metadata: message.Metadata,
-				})
-				continue
-			}
+// filterTexts filters requests exposing their texts through TextRequest.
+func (p *Policy) filterTexts(client http.Client, converted TextRequest, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger, fc *findingsCollector) error {
+	contents := converted.Texts()
 
-			if _, ok := message.Content.(string); ok {
-				if i < len(result.Updated) {
-					newMessages = append(newMessages, openai.ThreadMessage{
-						Role:     message.Role,
-						Content:  result.Updated[i],
-						FileIDs:  message.FileIDs,
-						Metadata: message.Metadata,
-					})
-				}
+	result, err := p.scan(client, contents, scanner, cd, log, fc)
+	if err != nil {
+		return err
+	}
 
-				i++
-			}
-		}
+	if result.Action == Block {
+		return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+	}
 
-		converted.Thread.Messages = newMessages
-		if i < len(result.Updated) {
-			converted.Instructions = result.Updated[i]
-		}
+	if result.Action == AllowButWarn {
+		return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+	}
 
-		log.Info("", zap.Any("", converted))
+	if len(result.Updated) != len(contents) {
+		return errors.New("updated contents length not consistent with existing content length")
+	}
 
-		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
-		}
+	converted.SetTexts(result.Updated)
 
-		return nil
+	if result.Action == AllowButRedact {
+		return internal_errors.NewRedactError("request redacted due to detected entities")
 	}
 
 	return nil
@@ -839,6 +705,20 @@ type Scanner interface {
 	Scan(input []string) (*pii.Result, error)
 }
 
+// backendScanner is implemented by scanners that can detect entities with
+// the backend selected by a policy.
+type backendScanner interface {
+	ScanWith(backend string, input []string) (*pii.Result, error)
+}
+
+func scanWith(scanner Scanner, backend string, input []string) (*pii.Result, error) {
+	if bs, ok := scanner.(backendScanner); ok {
+		return bs.ScanWith(backend, input)
+	}
+
+	return scanner.Scan(input)
+}
+
 type CustomPolicyDetector interface {
 	Detect(input []string, requirements []string) (bool, error)
 }
@@ -881,6 +761,12 @@ var entityMap map[string]string = map[string]string{
 	"CA_HEALTH_NUMBER":                        "ca_health_number",
 	"IN_AADHAAR":                              "in_aadhaar",
 	"IN_VOTER_NUMBER":                         "in_voter_number",
+	"API_KEY":                                 "api_key",
+	"JWT":                                     "jwt",
+	"PRIVATE_KEY":                             "private_key",
+	"GITHUB_TOKEN":                            "github_token",
+	"SLACK_TOKEN":                             "slack_token",
+	"STRIPE_KEY":                              "stripe_key",
 }
 
 type ScanResult struct {
@@ -891,10 +777,116 @@ type ScanResult struct {
 	BlockedRegexDefinitions  []string
 	WarnedRegexDefinitions   []string
 	BlockedCustomDefinitions []string
-	Updated                  []string
+	// RedactedRules are the rules whose matches were redacted.
+	RedactedRules []string
+	Updated       []string
+}
+
+// findingsCollector collects the entities detected in a request and the
+// values its reversible rules redacted.
+type findingsCollector struct {
+	lock           sync.Mutex
+	found          map[string]*pii.Finding
+	tokens         *Tokens
+	scannerFailure string
+	regexRules     []string
+	truncated      bool
+	rules          []*TriggeredRule
+}
+
+func newFindingsCollector() *findingsCollector {
+	return &findingsCollector{
+		found:      map[string]*pii.Finding{},
+		tokens:     newTokens(),
+		regexRules: []string{},
+		rules:      []*TriggeredRule{},
+	}
+}
+
+// replace returns the replacement of a redacted value. Values redacted by
+// reversible rules are replaced by tokens if the values can be restored.
+func (fc *findingsCollector) replace(value, ruleType, replacement string, reversible bool) string {
+	if reversible && fc != nil {
+		return fc.tokens.tokenize(value, ruleType)
+	}
+
+	return redact(value, ruleType, replacement)
+}
+
+func (fc *findingsCollector) add(r *pii.Result) {
+	if fc == nil || r == nil {
+		return
+	}
+
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	for idx, detection := range r.Detections {
+		if detection == nil {
+			continue
+		}
+
+		for _, entity := range detection.Entities {
+			converted, ok := convertEntity(entity.Type)
+			if !ok {
+				continue
+			}
+
+			f, ok := fc.found[converted]
+			if !ok {
+				f = &pii.Finding{
+					Type:    converted,
+					Offsets: []*pii.Offset{},
+				}
+
+				fc.found[converted] = f
+			}
+
+			f.Count++
+			f.Offsets = append(f.Offsets, &pii.Offset{
+				Input:       idx,
+				BeginOffset: entity.BeginOffset,
+				EndOffset:   entity.EndOffset,
+			})
+		}
+	}
+}
+
+// matchRegex records the definition of a regex rule matching the input.
+func (fc *findingsCollector) matchRegex(definition string) {
+	if fc == nil {
+		return
+	}
+
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	for _, matched := range fc.regexRules {
+		if matched == definition {
+			return
+		}
+	}
+
+	fc.regexRules = append(fc.regexRules, definition)
+}
+
+func (fc *findingsCollector) findings() []*pii.Finding {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	findings := []*pii.Finding{}
+	for _, f := range fc.found {
+		findings = append(findings, f)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Type < findings[j].Type
+	})
+
+	return findings
 }
 
-func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) (*ScanResult, error) {
+func (p *Policy) scan(client http.Client, input []string, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger, fc *findingsCollector) (*ScanResult, error) {
 	sr := &ScanResult{
 		Action:  Allow,
 		Updated: input,
@@ -907,24 +899,40 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 		go func(result *ScanResult) {
 			defer wg.Done()
 
-			r, err := scanner.Scan(result.Updated)
+			r, err := scanWith(scanner, p.Config.Scanner, result.Updated)
+			if err == nil {
+				err = checkDetections(r, len(result.Updated))
+			}
+
 			if err != nil {
-				telemetry.Incr("bricksllm.policy.scanner.scan.scan_error", nil, 1)
+				p.Config.failScan(result, fc, log, err)
 				return
 			}
 
+			if p.Config.detectsSecrets() {
+				mergeSecrets(r)
+			}
+
+			if err := p.detectCustomEntities(client, cd, r); err != nil {
+				p.Config.failScan(result, fc, log, err)
+			}
+
+			p.Config.removeExceptions(r)
+
+			fc.add(r)
+
 			result.ActionLock.Lock()
 			defer result.ActionLock.Unlock()
 
 			found := map[string]bool{}
 			for _, detection := range r.Detections {
 				for _, entity := range detection.Entities {
-					converted, ok := entityMap[entity.Type]
+					converted, ok := convertEntity(entity.Type)
 					if !ok {
 						continue
 					}
 
-					found[converted] = true
+					found[string(p.Config.ruleFor(converted))] = true
 				}
 			}
 
@@ -963,19 +971,22 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 				replaced := detection.Input
 
 				for _, entity := range detection.Entities {
-					converted, ok := entityMap[entity.Type]
+					converted, ok := convertEntity(entity.Type)
 					if !ok {
 						continue
 					}
 
-					_, ok = redactedEntities[Rule(converted)]
+					rule := p.Config.ruleFor(converted)
+					_, ok = redactedEntities[rule]
 					if ok {
 						if result.Action != Block && result.Action != AllowButWarn {
 							result.Action = AllowButRedact
 						}
 
+						result.redact(string(rule))
+
 						old := detection.Input[entity.BeginOffset:entity.EndOffset]
-						replaced = strings.ReplaceAll(replaced, old, "***")
+						replaced = strings.ReplaceAll(replaced, old, fc.replace(old, converted, p.Config.replacement(rule), p.Config.Reversible))
 					}
 				}
 
@@ -1031,15 +1042,17 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 		found := map[string]bool{}
 		for _, text := range sr.Updated {
 			for _, rule := range p.RegexConfig.RegularExpressionRules {
-				regex, err := regexp.Compile(rule.Definition)
+				regex, err := rule.compiled()
 				if err != nil {
 					telemetry.Incr("bricksllm.policy.scanner.scan.regex_compile_error", nil, 1)
 					continue
 				}
 
-				match := regex.FindString(text)
-				if len(match) != 0 {
-					found[rule.Definition] = true
+				for _, match := range regex.FindAllString(text, -1) {
+					if len(match) != 0 && !excepted(rule.Exceptions, match) {
+						found[rule.Definition] = true
+						break
+					}
 				}
 			}
 		}
@@ -1049,6 +1062,10 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 
 		for _, rule := range p.RegexConfig.RegularExpressionRules {
 			_, ok := found[rule.Definition]
+			if ok {
+				fc.matchRegex(rule.Definition)
+			}
+
 			if ok && rule.Action == Block {
 				blockedRegexDefinitions = append(blockedRegexDefinitions, rule.Definition)
 			}
@@ -1077,18 +1094,30 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 
 			for _, rule := range p.RegexConfig.RegularExpressionRules {
 				if rule.Action == AllowButRedact {
-					regex, err := regexp.Compile(rule.Definition)
+					regex, err := rule.compiled()
 					if err != nil {
 						telemetry.Incr("bricksllm.policy.scanner.scan.regex_compile_error", nil, 1)
 						continue
 					}
 
-					if regex.MatchString(text) {
-						replaced = regex.ReplaceAllString(text, "***")
+					if regex.MatchString(replaced) {
+						redacted := false
+						replaced = regex.ReplaceAllStringFunc(replaced, func(match string) string {
+							if excepted(rule.Exceptions, match) {
+								return match
+							}
 
-						if sr.Action != Block && sr.Action != AllowButWarn {
+							redacted = true
+							return fc.replace(match, "regex", rule.Replacement, p.RegexConfig.Reversible)
+						})
+
+						if redacted && sr.Action != Block && sr.Action != AllowButWarn {
 							sr.Action = AllowButRedact
 						}
+
+						if redacted {
+							sr.redact(rule.Definition)
+						}
 					}
 				}
 			}
@@ -1099,5 +1128,23 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 		sr.Updated = updated
 	}
 
+	if p.ShouldMatchTopics() {
+		p.matchTopics(sr)
+	}
+
+	if p.ShouldRestrictLanguages() {
+		p.restrictLanguages(sr)
+	}
+
+	if p.ShouldFilterProfanity() {
+		p.filterProfanity(sr)
+	}
+
+	if p.ShouldInspectExternally() && sr.Action != Block {
+		p.inspectExternally(client, sr, log)
+	}
+
+	fc.trigger(sr)
+
 	return sr, nil
 }
@@ -0,0 +1,208 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// profanityDefinition is reported in the errors of blocked and warned
+// requests and responses containing profanity.
+const profanityDefinition = "profanity"
+
+type Severity string
+
+const (
+	Mild     Severity = "mild"
+	Moderate Severity = "moderate"
+	Severe   Severity = "severe"
+)
+
+var severityLevels = map[Severity]int{
+	Mild:     0,
+	Moderate: 1,
+	Severe:   2,
+}
+
+// ProfanityWords are the built-in profanity words by severity. Words are
+// matched along with their plural and inflected forms.
+var ProfanityWords = map[Severity][]string{
+	Mild: {
+		"crap", "damn", "dammit", "hell", "bloody", "bugger", "screw you", "sucks", "piss", "arse",
+	},
+	Moderate: {
+		"ass", "asshole", "bastard", "bitch", "bullshit", "dick", "douche", "douchebag", "jackass", "prick", "shit", "slut", "whore", "wanker",
+	},
+	Severe: {
+		"fuck", "fucking", "motherfucker", "cunt", "cocksucker", "twat",
+	},
+}
+
+// ProfanityConfig filters profanity out of prompts and completions. Built-in
+// words at or above the configured severity are matched along with the words
+// supplied by the tenant, regardless of case.
+type ProfanityConfig struct {
+	Severity     Severity `json:"severity"`
+	Words        []string `json:"words"`
+	AllowedWords []string `json:"allowedWords"`
+	Action       Action   `json:"action"`
+	Replacement  string   `json:"replacement"`
+
+	regex *regexp.Regexp
+}
+
+func (c *ProfanityConfig) Validate() []string {
+	msgs := []string{}
+	if len(c.Severity) != 0 {
+		if _, ok := severityLevels[c.Severity]; !ok {
+			msgs = append(msgs, "profanity config must have a severity of mild, moderate or severe")
+		}
+	}
+
+	for _, word := range c.Words {
+		if len(strings.TrimSpace(word)) == 0 {
+			msgs = append(msgs, "profanity config cannot have empty words")
+			break
+		}
+	}
+
+	if c.Action != Block && c.Action != AllowButWarn && c.Action != AllowButRedact && c.Action != Allow && len(c.Action) != 0 {
+		msgs = append(msgs, fmt.Sprintf("profanity config action %s must be one of block, allow_but_warn, allow_but_redact or allow", c.Action))
+	}
+
+	return msgs
+}
+
+// words returns the words matched by the config. Built-in words default to
+// the mild severity, which includes all of them.
+func (c *ProfanityConfig) words() []string {
+	allowed := map[string]bool{}
+	for _, word := range c.AllowedWords {
+		allowed[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+
+	level := severityLevels[c.Severity]
+
+	words := []string{}
+	for severity, list := range ProfanityWords {
+		if severityLevels[severity] < level {
+			continue
+		}
+
+		for _, word := range list {
+			if !allowed[word] {
+				words = append(words, word)
+			}
+		}
+	}
+
+	for _, word := range c.Words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len(word) != 0 && !allowed[word] {
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
+
+func (c *ProfanityConfig) compile() (*regexp.Regexp, error) {
+	words := c.words()
+	if len(words) == 0 {
+		// matches nothing.
+		return regexp.Compile(`[^\s\S]`)
+	}
+
+	// longer words are tried first so that they win over their prefixes.
+	sort.Slice(words, func(i, j int) bool {
+		return len(words[i]) > len(words[j])
+	})
+
+	patterns := []string{}
+	for _, word := range words {
+		patterns = append(patterns, profanityPattern(word))
+	}
+
+	return regexp.Compile(`(?i)(?:` + strings.Join(patterns, "|") + `)`)
+}
+
+// profanityPattern matches a word along with its inflected forms. Word
+// boundaries are only required next to letters and digits so that custom
+// words starting or ending with symbols, such as $hit, are matched.
+func profanityPattern(word string) string {
+	pattern := regexp.QuoteMeta(word)
+	if isWordByte(word[0]) {
+		pattern = `\b` + pattern
+	}
+
+	if isWordByte(word[len(word)-1]) {
+		return pattern + `(?:s|es|ed|er|ers|ing)?\b`
+	}
+
+	return pattern
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// ShouldFilterProfanity reports whether prompts and completions of requests
+// filtered by the policy are checked for profanity.
+func (p *Policy) ShouldFilterProfanity() bool {
+	return p != nil && p.ProfanityConfig != nil && p.ProfanityConfig.Action != Allow && len(p.ProfanityConfig.Action) != 0
+}
+
+// filterProfanity merges the profanity found in the texts of a scan result
+// into it and redacts it if the action of the config is allow_but_redact.
+func (p *Policy) filterProfanity(sr *ScanResult) {
+	c := p.ProfanityConfig
+
+	regex, err := c.compiled()
+	if err != nil {
+		telemetry.Incr("bricksllm.policy.filter_profanity.regex_compile_error", nil, 1)
+		return
+	}
+
+	matched := false
+	updated := []string{}
+	for _, text := range sr.Updated {
+		if regex.MatchString(text) {
+			matched = true
+
+			if c.Action == AllowButRedact {
+				text = regex.ReplaceAllStringFunc(text, func(match string) string {
+					return redact(match, profanityDefinition, c.Replacement)
+				})
+			}
+		}
+
+		updated = append(updated, text)
+	}
+
+	if !matched {
+		return
+	}
+
+	sr.Updated = updated
+
+	switch c.Action {
+	case Block:
+		sr.Action = Block
+		sr.BlockedCustomDefinitions = append(sr.BlockedCustomDefinitions, profanityDefinition)
+	case AllowButWarn:
+		if sr.Action != Block {
+			sr.Action = AllowButWarn
+		}
+
+		sr.WarnedRegexDefinitions = append(sr.WarnedRegexDefinitions, profanityDefinition)
+	case AllowButRedact:
+		if sr.Action != Block && sr.Action != AllowButWarn {
+			sr.Action = AllowButRedact
+		}
+
+		sr.redact(profanityDefinition)
+	}
+}
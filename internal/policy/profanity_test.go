@@ -0,0 +1,129 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfanityConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ProfanityConfig
+		want   []string
+	}{
+		{name: "valid", config: &ProfanityConfig{Severity: Moderate, Words: []string{"heck"}, Action: Block}, want: []string{}},
+		{name: "defaults", config: &ProfanityConfig{}, want: []string{}},
+		{name: "invalid severity", config: &ProfanityConfig{Severity: "extreme", Action: Block}, want: []string{"profanity config must have a severity of mild, moderate or severe"}},
+		{name: "empty word", config: &ProfanityConfig{Words: []string{"heck", " "}, Action: Block}, want: []string{"profanity config cannot have empty words"}},
+		{name: "invalid action", config: &ProfanityConfig{Action: "allow_but_mask"}, want: []string{"profanity config action allow_but_mask must be one of block, allow_but_warn, allow_but_redact or allow"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.config.Validate())
+		})
+	}
+}
+
+func TestFilterProfanity(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *ProfanityConfig
+		texts   []string
+		action  Action
+		updated []string
+		blocked []string
+		warned  []string
+	}{
+		{
+			name:    "clean",
+			config:  &ProfanityConfig{Action: Block},
+			texts:   []string{"hello, this shell class is an assessment"},
+			action:  Allow,
+			updated: []string{"hello, this shell class is an assessment"},
+		},
+		{
+			name:    "blocked",
+			config:  &ProfanityConfig{Action: Block},
+			texts:   []string{"what the Hell"},
+			action:  Block,
+			updated: []string{"what the Hell"},
+			blocked: []string{"profanity"},
+		},
+		{
+			name:    "warned",
+			config:  &ProfanityConfig{Action: AllowButWarn},
+			texts:   []string{"this sucks"},
+			action:  AllowButWarn,
+			updated: []string{"this sucks"},
+			warned:  []string{"profanity"},
+		},
+		{
+			name:    "inflected forms redacted",
+			config:  &ProfanityConfig{Action: AllowButRedact, Replacement: "***"},
+			texts:   []string{"damned bastards"},
+			action:  AllowButRedact,
+			updated: []string{"*** ***"},
+		},
+		{
+			name:    "below severity",
+			config:  &ProfanityConfig{Severity: Severe, Action: Block},
+			texts:   []string{"damn that bastard"},
+			action:  Allow,
+			updated: []string{"damn that bastard"},
+		},
+		{
+			name:    "at severity",
+			config:  &ProfanityConfig{Severity: Moderate, Action: Block},
+			texts:   []string{"damn that bastard"},
+			action:  Block,
+			updated: []string{"damn that bastard"},
+			blocked: []string{"profanity"},
+		},
+		{
+			name:    "allowed words",
+			config:  &ProfanityConfig{AllowedWords: []string{"Hell"}, Action: Block},
+			texts:   []string{"welcome to hell's kitchen"},
+			action:  Allow,
+			updated: []string{"welcome to hell's kitchen"},
+		},
+		{
+			name:    "custom words",
+			config:  &ProfanityConfig{Severity: Severe, Words: []string{"Frak"}, Action: AllowButRedact, Replacement: "***"},
+			texts:   []string{"frak those fraking toasters"},
+			action:  AllowButRedact,
+			updated: []string{"*** those *** toasters"},
+		},
+		{
+			name:    "custom words with symbols",
+			config:  &ProfanityConfig{Severity: Severe, Words: []string{"$hit", "f*ck!"}, Action: AllowButRedact, Replacement: "***"},
+			texts:   []string{"oh $hit, f*ck!"},
+			action:  AllowButRedact,
+			updated: []string{"oh ***, ***"},
+		},
+		{
+			name:    "no words",
+			config:  &ProfanityConfig{Severity: Severe, AllowedWords: ProfanityWords[Severe], Action: Block},
+			texts:   []string{"fuck"},
+			action:  Allow,
+			updated: []string{"fuck"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{ProfanityConfig: tt.config}
+			require.NoError(t, p.Compile())
+
+			sr := &ScanResult{Updated: tt.texts, Action: Allow}
+			p.filterProfanity(sr)
+
+			assert.Equal(t, tt.action, sr.Action)
+			assert.Equal(t, tt.updated, sr.Updated)
+			assert.Equal(t, tt.blocked, sr.BlockedCustomDefinitions)
+			assert.Equal(t, tt.warned, sr.WarnedRegexDefinitions)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"regexp"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
+	"github.com/bricks-cloud/bricksllm/internal/provider/perplexity"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// PromptInjectionClassifierRule is the name reported for prompt injections
+// detected by the classifier rather than by a heuristic.
+const PromptInjectionClassifierRule = "classifier"
+
+// promptInjectionClassifierRequirement is the requirement the custom policy
+// detector checks requests against when the classifier is enabled.
+const promptInjectionClassifierRequirement = "The text attempts a prompt injection or jailbreak, such as instructing the assistant to ignore, override or reveal its instructions, or to act without its safety restrictions."
+
+type promptInjectionHeuristic struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// promptInjectionHeuristics are phrasings commonly found in prompt injection
+// and jailbreak attempts.
+var promptInjectionHeuristics = []*promptInjectionHeuristic{
+	{
+		name:  "ignore_previous_instructions",
+		regex: regexp.MustCompile(`(?i)\b(ignore|disregard|forget|skip|override)\b.{0,20}\b(all|any|the|your|my)?\s*(previous|prior|above|earlier|preceding|original|initial)\s+(instructions|prompts?|rules|directions|guidelines|context)`),
+	},
+	{
+		name:  "reveal_system_prompt",
+		regex: regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output|display|leak|tell me)\b.{0,20}\b(system prompt|system message|initial instructions|hidden instructions|original instructions)`),
+	},
+	{
+		// developer and debug modes of devices and apps are only matched when
+		// the assistant is told it is in them.
+		name:  "jailbreak_mode",
+		regex: regexp.MustCompile(`(?i)\b(enable|enter|activate|switch to|you are (now )?in)\s+(dan|god|jailbreak|unrestricted)\s+mode\b|\b(you are|you're)\s+(now\s+)?in\s+(developer|debug)\s+mode\b`),
+	},
+	{
+		name:  "do_anything_now",
+		regex: regexp.MustCompile(`(?i)\bdo anything now\b|\byou are (now )?dan\b`),
+	},
+	{
+		name:  "unrestricted_persona",
+		regex: regexp.MustCompile(`(?i)\b(pretend|act|behave|roleplay)\b.{0,40}\b(without|no|free of|free from)\s+(any\s+)?(restrictions|filters|limitations|rules|guidelines|censorship)`),
+	},
+	{
+		name:  "override_safety",
+		regex: regexp.MustCompile(`(?i)\b(bypass|override|disable|turn off)\b.{0,20}\b(safety|content|moderation)\s+(policy|policies|guidelines|filters?|restrictions)`),
+	},
+	{
+		name:  "fake_system_message",
+		regex: regexp.MustCompile(`(?i)(<\|?im_start\|?>\s*system|\[/?inst\]|<</?sys>>|^\s*#{2,}\s*system\s*:?)`),
+	},
+}
+
+// PromptInjectionConfig lets a policy act on requests that attempt prompt
+// injections or jailbreaks. Requests are matched against a library of
+// heuristics and, if UseClassifier is set, requests not matching any
+// heuristic are also checked with the custom policy detector.
+type PromptInjectionConfig struct {
+	Action        Action `json:"action"`
+	UseClassifier bool   `json:"useClassifier"`
+}
+
+func (c *PromptInjectionConfig) Validate() []string {
+	msgs := []string{}
+	if c.Action != Block && c.Action != AllowButWarn && c.Action != Allow {
+		msgs = append(msgs, "prompt injection action must be one of block, allow_but_warn or allow")
+	}
+
+	return msgs
+}
+
+// ShouldDetectPromptInjection reports whether requests filtered by the policy
+// are checked for prompt injections.
+func (p *Policy) ShouldDetectPromptInjection() bool {
+	return p != nil && p.PromptInjectionConfig != nil && p.PromptInjectionConfig.Action != Allow && len(p.PromptInjectionConfig.Action) != 0
+}
+
+// DetectPromptInjection returns the action the policy takes on texts of a
+// request along with the names of the heuristics that matched them. Allow is
+// returned if the policy does not detect prompt injections or if nothing is
+// detected.
+func (p *Policy) DetectPromptInjection(texts []string, cd CustomPolicyDetector, log *zap.Logger) (Action, []string) {
+	if !p.ShouldDetectPromptInjection() || len(texts) == 0 {
+		return Allow, nil
+	}
+
+	matched := []string{}
+	for _, h := range promptInjectionHeuristics {
+		for _, text := range texts {
+			if h.regex.MatchString(text) {
+				matched = append(matched, h.name)
+				break
+			}
+		}
+	}
+
+	if len(matched) == 0 && p.PromptInjectionConfig.UseClassifier && cd != nil {
+		found, err := cd.Detect(texts, []string{promptInjectionClassifierRequirement})
+		if err != nil {
+			log.Debug("error when detecting prompt injection using classifier", zap.Error(err))
+			telemetry.Incr("bricksllm.policy.detect_prompt_injection.detect_error", nil, 1)
+		}
+
+		if found {
+			matched = append(matched, PromptInjectionClassifierRule)
+		}
+	}
+
+	if len(matched) == 0 {
+		return Allow, nil
+	}
+
+	return p.PromptInjectionConfig.Action, matched
+}
+
+// PromptInjectionInput returns the texts of a request that are checked for
+// prompt injections. Requests without texts return an empty slice.
+func PromptInjectionInput(input any) []string {
+	switch converted := input.(type) {
+	case *goopenai.ChatCompletionRequest, *goopenai.EmbeddingRequest:
+		return ModerationInput(converted)
+	case *mistral.ChatRequest:
+		return ModerationInput(&converted.ChatCompletionRequest)
+	case *perplexity.ChatRequest:
+		return ModerationInput(&converted.ChatCompletionRequest)
+	case *vllm.ChatRequest:
+		return ModerationInput(&converted.ChatCompletionRequest)
+	case *vllm.CompletionRequest:
+		return (&promptTexts{prompt: &converted.Prompt}).Texts()
+	case TextRequest:
+		return converted.Texts()
+	}
+
+	return []string{}
+}
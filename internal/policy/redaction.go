@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// defaultReplacement replaces redacted values of rules without a replacement.
+const defaultReplacement = "***"
+
+const (
+	typePlaceholder = "{{type}}"
+	hashPlaceholder = "{{hash}}"
+	maskPlaceholder = "{{mask}}"
+)
+
+// unmaskedSuffixLength is the number of trailing letters and digits left
+// visible by the {{mask}} placeholder.
+const unmaskedSuffixLength = 4
+
+// redact returns the replacement of a redacted value. Replacements can use
+// the {{type}} placeholder for the upper cased name of the rule, {{hash}} for
+// a short SHA-256 hash of the value, which is stable across requests, and
+// {{mask}} for the value with all but its last 4 letters and digits masked.
+func redact(value, ruleType, replacement string) string {
+	if len(replacement) == 0 {
+		return defaultReplacement
+	}
+
+	if !strings.Contains(replacement, "{{") {
+		return replacement
+	}
+
+	replacer := strings.NewReplacer(
+		typePlaceholder, strings.ToUpper(ruleType),
+		hashPlaceholder, hash(value),
+		maskPlaceholder, mask(value),
+	)
+
+	return replacer.Replace(replacement)
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func mask(value string) string {
+	runes := []rune(value)
+
+	visible := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			continue
+		}
+
+		if visible < unmaskedSuffixLength {
+			visible++
+			continue
+		}
+
+		runes[i] = '*'
+	}
+
+	return string(runes)
+}
+
+func (c *Config) replacement(rule Rule) string {
+	if c == nil || c.Replacements == nil {
+		return ""
+	}
+
+	return c.Replacements[rule]
+}
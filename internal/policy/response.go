@@ -0,0 +1,217 @@
+package policy
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"go.uber.org/zap"
+)
+
+// ShouldScanResponses reports whether PII, regex, profanity or brand rules of
+// the policy are applied to responses of filtered requests.
+func (p *Policy) ShouldScanResponses() bool {
+	return p.responsePolicy() != nil || p.ShouldGuardBrands()
+}
+
+// responsePolicy returns a policy made of the rules that opted into response
+// scanning and the profanity config. Nil is returned if there are none.
+func (p *Policy) responsePolicy() *Policy {
+	if p == nil {
+		return nil
+	}
+
+	rp := &Policy{}
+	if p.Config != nil && p.Config.ScanResponses && len(p.Config.Rules) != 0 {
+		rp.Config = p.Config
+	}
+
+	if p.RegexConfig != nil && p.RegexConfig.ScanResponses && len(p.RegexConfig.RegularExpressionRules) != 0 {
+		rp.RegexConfig = p.RegexConfig
+	}
+
+	// profanity is filtered out of both prompts and completions.
+	if p.ShouldFilterProfanity() {
+		rp.ProfanityConfig = p.ProfanityConfig
+	}
+
+	if rp.Config == nil && rp.RegexConfig == nil && rp.ProfanityConfig == nil {
+		return nil
+	}
+
+	return rp
+}
+
+// responseText is a text field of a decoded response body.
+type responseText struct {
+	parent map[string]any
+	key    string
+	// arguments is true for the JSON arguments of tool calls.
+	arguments bool
+}
+
+func addResponseText(texts []*responseText, parent map[string]any, key string) []*responseText {
+	if _, ok := parent[key].(string); ok {
+		return append(texts, &responseText{parent: parent, key: key})
+	}
+
+	return texts
+}
+
+func addResponseArguments(texts []*responseText, parent map[string]any) []*responseText {
+	if _, ok := parent["arguments"].(string); ok {
+		return append(texts, &responseText{parent: parent, key: "arguments", arguments: true})
+	}
+
+	return texts
+}
+
+func objects(val any) []map[string]any {
+	objs := []map[string]any{}
+
+	arr, ok := val.([]any)
+	if !ok {
+		return objs
+	}
+
+	for _, item := range arr {
+		if obj, ok := item.(map[string]any); ok {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs
+}
+
+// extractResponseTexts returns the generated texts, including tool call
+// arguments, of OpenAI compatible chat and text completion responses,
+// Anthropic messages and completions responses and Gemini responses.
+func extractResponseTexts(body map[string]any) []*responseText {
+	texts := []*responseText{}
+
+	for _, choice := range objects(body["choices"]) {
+		if message, ok := choice["message"].(map[string]any); ok {
+			texts = addResponseText(texts, message, "content")
+
+			if fc, ok := message["function_call"].(map[string]any); ok {
+				texts = addResponseArguments(texts, fc)
+			}
+
+			for _, tc := range objects(message["tool_calls"]) {
+				if function, ok := tc["function"].(map[string]any); ok {
+					texts = addResponseArguments(texts, function)
+				}
+			}
+		}
+
+		texts = addResponseText(texts, choice, "text")
+	}
+
+	for _, block := range objects(body["content"]) {
+		texts = addResponseText(texts, block, "text")
+	}
+
+	texts = addResponseText(texts, body, "completion")
+
+	for _, candidate := range objects(body["candidates"]) {
+		if content, ok := candidate["content"].(map[string]any); ok {
+			for _, part := range objects(content["parts"]) {
+				texts = addResponseText(texts, part, "text")
+			}
+		}
+	}
+
+	return texts
+}
+
+// FilterResponse applies the rules that opted into response scanning and the
+// brand rules to the generated texts of a response body. Like Filter, a
+// blocked, warning or redact error is returned depending on the action taken.
+// The returned body has detected entities redacted and is the original body
+// otherwise. Responses without generated texts, such as embeddings, are
+// returned as is.
+func (p *Policy) FilterResponse(body []byte, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) ([]byte, error) {
+	filtered, _, err := p.filterResponse(body, scanner, cd, log)
+	return filtered, err
+}
+
+// FilterResponseWithRules behaves like FilterResponse and additionally
+// returns the rules that made the policy block, warn on or redact the
+// response.
+func (p *Policy) FilterResponseWithRules(body []byte, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) ([]byte, []*TriggeredRule, error) {
+	filtered, result, err := p.filterResponse(body, scanner, cd, log)
+	if result == nil {
+		return filtered, []*TriggeredRule{}, err
+	}
+
+	return filtered, result.triggeredRules(), err
+}
+
+func (p *Policy) filterResponse(body []byte, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) ([]byte, *ScanResult, error) {
+	rp := p.responsePolicy()
+	if rp == nil && !p.ShouldGuardBrands() {
+		return body, nil, nil
+	}
+
+	decoded := map[string]any{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return body, nil, nil
+	}
+
+	texts := extractResponseTexts(decoded)
+	if len(texts) == 0 {
+		return body, nil, nil
+	}
+
+	input := []string{}
+	for _, t := range texts {
+		input = append(input, t.parent[t.key].(string))
+	}
+
+	result := &ScanResult{Action: Allow, Updated: input}
+	if rp != nil {
+		scanned, err := rp.scan(http.Client{}, input, scanner, cd, log, nil)
+		if err != nil {
+			return body, nil, p.Config.failResponse(log, err)
+		}
+
+		result = scanned
+	}
+
+	if p.ShouldGuardBrands() && result.Action != Block {
+		p.guardBrands(result, func(idx int) bool {
+			return idx < len(texts) && !texts[idx].arguments
+		})
+	}
+
+	if result.Action == Block {
+		return body, result, internal_errors.NewBlockedError("response blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+	}
+
+	// redactions and brand edits are applied before warnings are reported so
+	// that they are kept when a warn rule also matches.
+	filtered := body
+	if len(result.Updated) == len(texts) && !slices.Equal(input, result.Updated) {
+		for idx, t := range texts {
+			t.parent[t.key] = result.Updated[idx]
+		}
+
+		redacted, err := json.Marshal(decoded)
+		if err != nil {
+			return body, result, p.Config.failResponse(log, err)
+		}
+
+		filtered = redacted
+	}
+
+	if result.Action == AllowButWarn {
+		return filtered, result, internal_errors.NewWarningError("response warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+	}
+
+	if result.Action == AllowButRedact {
+		return filtered, result, internal_errors.NewRedactError("response redacted due to detected entities")
+	}
+
+	return body, result, nil
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+// scannerFailedReason is the reason requests are blocked for when the PII
+// scanner of a fail closed policy fails.
+const scannerFailedReason = "pii scanner failed"
+
+// failureMode returns what happens to requests if the PII scanner times out
+// or fails. Requests are let through by default.
+func (c *Config) failureMode() string {
+	if c == nil || c.FailureMode != FailureModeClosed {
+		return FailureModeOpen
+	}
+
+	return FailureModeClosed
+}
+
+// checkDetections returns an error if the PII scanner did not return a
+// detection for every input, such as when some of its requests timed out.
+// Incomplete results are treated as scanner failures so that the failure mode
+// of the config applies to them.
+func checkDetections(r *pii.Result, n int) error {
+	if r == nil {
+		return fmt.Errorf("pii scanner returned no result")
+	}
+
+	if len(r.Detections) != n {
+		return fmt.Errorf("pii scanner returned %d detections for %d inputs", len(r.Detections), n)
+	}
+
+	for idx, detection := range r.Detections {
+		if detection == nil {
+			return fmt.Errorf("pii scanner returned no detection for input %d", idx)
+		}
+	}
+
+	return nil
+}
+
+// failScan applies the failure mode of the config to a scan result after the
+// PII scanner failed.
+func (c *Config) failScan(sr *ScanResult, fc *findingsCollector, log *zap.Logger, err error) {
+	mode := c.failureMode()
+
+	telemetry.Incr("bricksllm.policy.scanner.scan.scan_error", []string{
+		"failure_mode:" + mode,
+	}, 1)
+	log.Debug("error when scanning for pii", zap.Error(err), zap.String("failureMode", mode))
+
+	if fc != nil {
+		fc.lock.Lock()
+		fc.scannerFailure = mode
+		fc.lock.Unlock()
+	}
+
+	if mode == FailureModeClosed {
+		sr.ActionLock.Lock()
+		defer sr.ActionLock.Unlock()
+
+		sr.Action = Block
+		sr.BlockedCustomDefinitions = append(sr.BlockedCustomDefinitions, scannerFailedReason)
+	}
+}
+
+// failResponse applies the failure mode of the config to a response that
+// could not be scanned or redacted. A blocked error is returned if the config
+// fails closed and the original error otherwise.
+func (c *Config) failResponse(log *zap.Logger, err error) error {
+	mode := c.failureMode()
+
+	telemetry.Incr("bricksllm.policy.scanner.fail_response", []string{
+		"failure_mode:" + mode,
+	}, 1)
+	log.Debug("error when filtering response", zap.Error(err), zap.String("failureMode", mode))
+
+	if mode == FailureModeClosed {
+		return internal_errors.NewBlockedError("response blocked: " + scannerFailedReason)
+	}
+
+	return err
+}
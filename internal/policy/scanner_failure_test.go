@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type resultScanner struct {
+	result *pii.Result
+}
+
+func (s *resultScanner) Scan(input []string) (*pii.Result, error) {
+	return s.result, nil
+}
+
+type failingScanner struct{}
+
+func (s *failingScanner) Scan(input []string) (*pii.Result, error) {
+	return nil, errors.New("scanner unavailable")
+}
+
+func TestScanWithIncompleteDetections(t *testing.T) {
+	input := []string{"email me at jane@example.com", "call me"}
+
+	tests := []struct {
+		name        string
+		result      *pii.Result
+		failureMode string
+		action      Action
+	}{
+		{
+			name:        "timed out detection fails open",
+			result:      &pii.Result{Detections: []*pii.Detection{nil, {Input: input[1]}}},
+			failureMode: FailureModeOpen,
+			action:      Allow,
+		},
+		{
+			name:        "timed out detection fails closed",
+			result:      &pii.Result{Detections: []*pii.Detection{nil, {Input: input[1]}}},
+			failureMode: FailureModeClosed,
+			action:      Block,
+		},
+		{
+			name:        "missing detections fail closed",
+			result:      &pii.Result{Detections: []*pii.Detection{{Input: input[0]}}},
+			failureMode: FailureModeClosed,
+			action:      Block,
+		},
+		{
+			name:        "nil result fails closed",
+			result:      nil,
+			failureMode: FailureModeClosed,
+			action:      Block,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{
+				Config: &Config{
+					Rules:       map[Rule]Action{Email: Block},
+					FailureMode: tt.failureMode,
+				},
+			}
+
+			fc := newFindingsCollector()
+			sr, err := p.scan(http.Client{}, input, &resultScanner{result: tt.result}, nil, zap.NewNop(), fc)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.action, sr.Action)
+			assert.Equal(t, tt.failureMode, fc.scannerFailure)
+		})
+	}
+}
+
+func TestFindingsCollectorAddSkipsNilDetections(t *testing.T) {
+	fc := newFindingsCollector()
+
+	assert.NotPanics(t, func() {
+		fc.add(&pii.Result{Detections: []*pii.Detection{nil}})
+	})
+	assert.Empty(t, fc.findings())
+}
+
+func TestFilterResponseScannerFailure(t *testing.T) {
+	body := `{"choices":[{"message":{"content":"email me at jane@example.com"}}]}`
+
+	tests := []struct {
+		name        string
+		failureMode string
+		blocked     bool
+	}{
+		{name: "fails open", failureMode: FailureModeOpen},
+		{name: "fails open by default"},
+		{name: "fails closed", failureMode: FailureModeClosed, blocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{
+				Config: &Config{
+					Rules:         map[Rule]Action{Email: AllowButRedact},
+					ScanResponses: true,
+					FailureMode:   tt.failureMode,
+				},
+			}
+
+			filtered, err := p.FilterResponse([]byte(body), &failingScanner{}, nil, zap.NewNop())
+			assert.JSONEq(t, body, string(filtered))
+
+			if tt.blocked {
+				_, ok := err.(*internal_errors.BlockedError)
+				assert.True(t, ok)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+	"github.com/bricks-cloud/bricksllm/internal/pii/local"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// secretRules are the rules of leaked credentials. They are detected by a
+// built-in ruleset regardless of the scanner of a policy.
+var secretRules = map[Rule]bool{
+	ApiKey:       true,
+	AwsAccessKey: true,
+	GithubToken:  true,
+	Jwt:          true,
+	PrivateKey:   true,
+	SlackToken:   true,
+	StripeKey:    true,
+}
+
+// tokenRules are provider specific token formats that api_key rules also
+// apply to unless the policy has a rule for the format itself.
+var tokenRules = map[Rule]bool{
+	GithubToken: true,
+	SlackToken:  true,
+	StripeKey:   true,
+}
+
+var secretsDetector = local.NewSecretsDetector()
+
+// detectsSecrets reports whether the built-in secrets ruleset has to be run
+// in addition to the scanner of the config. The local scanner already
+// includes it.
+func (c *Config) detectsSecrets() bool {
+	if c == nil || c.Scanner == pii.BackendLocal {
+		return false
+	}
+
+	for rule, action := range c.Rules {
+		if secretRules[rule] && action != Allow {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ruleFor returns the rule of the config applying to a detected entity.
+func (c *Config) ruleFor(detected string) Rule {
+	rule := Rule(detected)
+	if c == nil || !tokenRules[rule] {
+		return rule
+	}
+
+	if _, ok := c.Rules[rule]; ok {
+		return rule
+	}
+
+	if _, ok := c.Rules[ApiKey]; ok {
+		return ApiKey
+	}
+
+	return rule
+}
+
+// mergeSecrets adds the secrets detected by the built-in ruleset to a scan
+// result. Secrets overlapping entities detected by the scanner are dropped.
+func mergeSecrets(r *pii.Result) {
+	input := make([]string, 0, len(r.Detections))
+	for _, detection := range r.Detections {
+		input = append(input, detection.Input)
+	}
+
+	secrets, err := secretsDetector.Detect(input)
+	if err != nil {
+		telemetry.Incr("bricksllm.policy.merge_secrets.detect_error", nil, 1)
+		return
+	}
+
+	for idx, detection := range secrets.Detections {
+		for _, secret := range detection.Entities {
+			overlapping := false
+			for _, entity := range r.Detections[idx].Entities {
+				if secret.BeginOffset < entity.EndOffset && entity.BeginOffset < secret.EndOffset {
+					overlapping = true
+					break
+				}
+			}
+
+			if !overlapping {
+				r.Detections[idx].Entities = append(r.Detections[idx].Entities, secret)
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/tidwall/gjson"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// Truncate drops the oldest messages of requests exceeding size limits.
+const Truncate Action = "truncate"
+
+// SizeConfig limits the size of requests before they incur cost. Tokens are
+// estimated at four characters per token. Oversized requests are blocked
+// unless Action is truncate, in which case the oldest messages of chat
+// requests other than system messages are dropped until the requests fit.
+// Requests that cannot be truncated enough are blocked.
+type SizeConfig struct {
+	MaxCharacters int    `json:"maxCharacters"`
+	MaxTokens     int    `json:"maxTokens"`
+	MaxMessages   int    `json:"maxMessages"`
+	Action        Action `json:"action"`
+}
+
+func (c *SizeConfig) Validate() []string {
+	msgs := []string{}
+	if c.MaxCharacters < 0 || c.MaxTokens < 0 || c.MaxMessages < 0 {
+		msgs = append(msgs, "size limits cannot be negative")
+	}
+
+	if len(c.Action) != 0 && c.Action != Block && c.Action != Truncate {
+		msgs = append(msgs, "size action must be one of block or truncate")
+	}
+
+	return msgs
+}
+
+// ShouldLimitSize reports whether the size of requests filtered by the
+// policy is limited.
+func (p *Policy) ShouldLimitSize() bool {
+	return p != nil && p.SizeConfig != nil && (p.SizeConfig.MaxCharacters > 0 || p.SizeConfig.MaxTokens > 0 || p.SizeConfig.MaxMessages > 0)
+}
+
+func estimateTokens(characters int) int {
+	return (characters + 3) / 4
+}
+
+// exceeded returns the limit exceeded by texts making up a number of
+// messages and an empty string if none is.
+func (c *SizeConfig) exceeded(texts []string, messages int) string {
+	if c.MaxMessages > 0 && messages > c.MaxMessages {
+		return "maxMessages"
+	}
+
+	characters := 0
+	for _, text := range texts {
+		characters += utf8.RuneCountInString(text)
+	}
+
+	if c.MaxCharacters > 0 && characters > c.MaxCharacters {
+		return "maxCharacters"
+	}
+
+	if c.MaxTokens > 0 && estimateTokens(characters) > c.MaxTokens {
+		return "maxTokens"
+	}
+
+	return ""
+}
+
+// fit truncates a request until it is within the limits by dropping its
+// oldest messages. False is returned if the request does not fit.
+func (c *SizeConfig) fit(texts func() []string, messages func() int, drop func() bool) (bool, string) {
+	truncated := false
+	for {
+		limit := c.exceeded(texts(), messages())
+		if len(limit) == 0 {
+			return truncated, ""
+		}
+
+		if c.Action != Truncate || !drop() {
+			return truncated, limit
+		}
+
+		truncated = true
+	}
+}
+
+// limitSize enforces the size limits of the policy on a request. It reports
+// whether the request was truncated.
+func (p *Policy) limitSize(input any) (bool, error) {
+	c := p.SizeConfig
+
+	var truncated bool
+	var limit string
+	switch converted := input.(type) {
+	case *goopenai.ChatCompletionRequest:
+		truncated, limit = c.fit(func() []string {
+			return chatMessageTexts(converted.Messages)
+		}, func() int {
+			return len(converted.Messages)
+		}, func() bool {
+			return dropChatMessage(&converted.Messages)
+		})
+	case *vllm.ChatRequest:
+		truncated, limit = c.fit(func() []string {
+			return chatMessageTexts(converted.Messages)
+		}, func() int {
+			return len(converted.Messages)
+		}, func() bool {
+			return dropChatMessage(&converted.Messages)
+		})
+	case *anthropic.MessagesRequest:
+		truncated, limit = c.fit(converted.Texts, func() int {
+			return len(converted.Messages)
+		}, func() bool {
+			return dropAnthropicMessage(converted)
+		})
+	case TextRequest:
+		limit = c.exceeded(converted.Texts(), 0)
+	default:
+		// requests without messages are measured by the strings they are
+		// made of and cannot be truncated.
+		limit = c.exceeded(requestStrings(input), 0)
+	}
+
+	if truncated {
+		telemetry.Incr("bricksllm.policy.limit_size.truncated", nil, 1)
+	}
+
+	if len(limit) != 0 {
+		telemetry.Incr("bricksllm.policy.limit_size.blocked", []string{
+			"limit:" + limit,
+		}, 1)
+
+		return truncated, internal_errors.NewBlockedError(fmt.Sprintf("request blocked due to exceeded size limit: %s", limit))
+	}
+
+	return truncated, nil
+}
+
+// dropChatMessage drops the oldest message other than system and developer
+// messages along with the tool results answering it. The last message is
+// never dropped.
+func dropChatMessage(messages *[]goopenai.ChatCompletionMessage) bool {
+	ms := *messages
+	for idx := 0; idx < len(ms)-1; idx++ {
+		if ms[idx].Role == goopenai.ChatMessageRoleSystem || ms[idx].Role == "developer" {
+			continue
+		}
+
+		end := idx + 1
+		for end < len(ms)-1 && ms[end].Role == goopenai.ChatMessageRoleTool {
+			end++
+		}
+
+		*messages = append(ms[:idx:idx], ms[end:]...)
+		return true
+	}
+
+	return false
+}
+
+// dropAnthropicMessage drops the oldest message and the following messages
+// until the conversation starts with a user message that does not answer a
+// dropped tool use. The last message is never dropped.
+func dropAnthropicMessage(mr *anthropic.MessagesRequest) bool {
+	if len(mr.Messages) <= 1 {
+		return false
+	}
+
+	end := 1
+	for end < len(mr.Messages)-1 && (mr.Messages[end].Role != "user" || hasToolResult(mr.Messages[end].Content)) {
+		end++
+	}
+
+	mr.Messages = mr.Messages[end:]
+	return true
+}
+
+func hasToolResult(mc anthropic.MessageContent) bool {
+	for _, block := range mc.Blocks {
+		if block != nil && block.Type == "tool_result" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func requestStrings(input any) []string {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil
+	}
+
+	strs := []string{}
+	var walk func(value gjson.Result)
+	walk = func(value gjson.Result) {
+		switch {
+		case value.IsObject() || value.IsArray():
+			value.ForEach(func(_, v gjson.Result) bool {
+				walk(v)
+				return true
+			})
+		case value.Type == gjson.String && !strings.HasPrefix(value.Str, "data:"):
+			strs = append(strs, value.Str)
+		}
+	}
+
+	walk(gjson.ParseBytes(data))
+
+	return strs
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"encoding/json"
+)
+
+const maxStructuredOutputRetries = 3
+
+// StructuredOutputConfig lets a policy act on responses of JSON mode and
+// structured output requests that are not valid JSON or do not conform to the
+// JSON schema declared by the request. The retry action sends the request
+// again up to MaxRetries times and tags the last response if none conforms.
+type StructuredOutputConfig struct {
+	Action     Action `json:"action"`
+	MaxRetries int    `json:"maxRetries"`
+}
+
+func (c *StructuredOutputConfig) Validate() []string {
+	msgs := []string{}
+	if c.Action != Block && c.Action != AllowButWarn && c.Action != Retry && c.Action != Allow {
+		msgs = append(msgs, "structured output action must be one of block, allow_but_warn, retry or allow")
+	}
+
+	if c.MaxRetries < 0 || c.MaxRetries > maxStructuredOutputRetries {
+		msgs = append(msgs, "structured output max retries must be between 0 and 3")
+	}
+
+	return msgs
+}
+
+// ShouldValidateStructuredOutput reports whether responses of structured
+// output requests filtered by the policy are validated.
+func (p *Policy) ShouldValidateStructuredOutput() bool {
+	return p != nil && p.StructuredOutputConfig != nil && p.StructuredOutputConfig.Action != Allow && len(p.StructuredOutputConfig.Action) != 0
+}
+
+// StructuredOutputRetries returns the number of times a request is sent again
+// when its response does not conform. It defaults to 1 for the retry action.
+func (p *Policy) StructuredOutputRetries() int {
+	if !p.ShouldValidateStructuredOutput() || p.StructuredOutputConfig.Action != Retry {
+		return 0
+	}
+
+	if p.StructuredOutputConfig.MaxRetries == 0 {
+		return 1
+	}
+
+	return p.StructuredOutputConfig.MaxRetries
+}
+
+// ResponseFormat is the output format declared by a chat completion request.
+type ResponseFormat struct {
+	Type   string
+	Schema map[string]any
+}
+
+// ParseResponseFormat returns the response format of the body of an OpenAI
+// compatible chat completion request. Nil is returned if the request does not
+// use JSON mode or structured outputs.
+func ParseResponseFormat(body []byte) *ResponseFormat {
+	req := &struct {
+		ResponseFormat *struct {
+			Type       string `json:"type"`
+			JsonSchema *struct {
+				Schema json.RawMessage `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}{}
+
+	if err := json.Unmarshal(body, req); err != nil || req.ResponseFormat == nil {
+		return nil
+	}
+
+	switch req.ResponseFormat.Type {
+	case "json_object":
+		return &ResponseFormat{Type: "json_object"}
+	case "json_schema":
+		rf := &ResponseFormat{Type: "json_schema"}
+		if req.ResponseFormat.JsonSchema != nil && len(req.ResponseFormat.JsonSchema.Schema) != 0 {
+			schema, err := parseJsonSchema(req.ResponseFormat.JsonSchema.Schema)
+			if err == nil {
+				rf.Schema = schema
+			}
+		}
+
+		return rf
+	}
+
+	return nil
+}
+
+// Violations returns why the content of a response does not conform to the
+// response format. An empty slice is returned for conforming content.
+func (rf *ResponseFormat) Violations(content string) []string {
+	var instance any
+	if err := json.Unmarshal([]byte(content), &instance); err != nil {
+		return []string{"$: content is not valid json"}
+	}
+
+	if rf.Type != "json_schema" || rf.Schema == nil {
+		return []string{}
+	}
+
+	return validateJsonSchema(rf.Schema, instance)
+}
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResponseFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		typ    string
+		schema bool
+	}{
+		{name: "no response format", body: `{"model":"gpt-4o"}`},
+		{name: "text", body: `{"response_format":{"type":"text"}}`},
+		{name: "invalid body", body: `{`},
+		{name: "json mode", body: `{"response_format":{"type":"json_object"}}`, typ: "json_object"},
+		{name: "json schema", body: `{"response_format":{"type":"json_schema","json_schema":{"name":"a","schema":{"type":"object"}}}}`, typ: "json_schema", schema: true},
+		{name: "json schema without schema", body: `{"response_format":{"type":"json_schema","json_schema":{"name":"a"}}}`, typ: "json_schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rf := ParseResponseFormat([]byte(tt.body))
+			if len(tt.typ) == 0 {
+				assert.Nil(t, rf)
+				return
+			}
+
+			require.NotNil(t, rf)
+			assert.Equal(t, tt.typ, rf.Type)
+			assert.Equal(t, tt.schema, rf.Schema != nil)
+		})
+	}
+}
+
+func TestResponseFormatViolations(t *testing.T) {
+	schema := `{"response_format":{"type":"json_schema","json_schema":{"schema":{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}}}}`
+
+	tests := []struct {
+		name       string
+		body       string
+		content    string
+		violations bool
+	}{
+		{name: "json mode", body: `{"response_format":{"type":"json_object"}}`, content: `{"a":1}`},
+		{name: "json mode invalid json", body: `{"response_format":{"type":"json_object"}}`, content: `not json`, violations: true},
+		{name: "conforming", body: schema, content: `{"name":"a"}`},
+		{name: "missing property", body: schema, content: `{"age":1}`, violations: true},
+		{name: "wrong type", body: schema, content: `{"name":1}`, violations: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rf := ParseResponseFormat([]byte(tt.body))
+			require.NotNil(t, rf)
+
+			assert.Equal(t, tt.violations, len(rf.Violations(tt.content)) != 0)
+		})
+	}
+}
+
+func TestStructuredOutputRetries(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *StructuredOutputConfig
+		want   int
+	}{
+		{name: "not configured"},
+		{name: "block", config: &StructuredOutputConfig{Action: Block, MaxRetries: 2}},
+		{name: "retry default", config: &StructuredOutputConfig{Action: Retry}, want: 1},
+		{name: "retry", config: &StructuredOutputConfig{Action: Retry, MaxRetries: 3}, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{StructuredOutputConfig: tt.config}
+			assert.Equal(t, tt.want, p.StructuredOutputRetries())
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package policy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/provider/perplexity"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// TextRequest is implemented by requests whose texts are inspected and
+// redacted by policies, such as Anthropic messages, Gemini contents and
+// requests of provider plugins. Texts returns the texts of a request in a
+// stable order and SetTexts replaces them in the same order, so request
+// shapes implementing it are filtered without changes to policies.
+type TextRequest interface {
+	Texts() []string
+	SetTexts(texts []string)
+}
+
+var (
+	_ TextRequest = (*anthropic.MessagesRequest)(nil)
+	_ TextRequest = (*anthropic.CompletionRequest)(nil)
+	_ TextRequest = (*gemini.GenerateContentRequest)(nil)
+	_ TextRequest = (*cohere.ChatRequest)(nil)
+	_ TextRequest = (*cohere.RerankRequest)(nil)
+	_ TextRequest = (*openai.ThreadRequest)(nil)
+	_ TextRequest = (*openai.MessageRequest)(nil)
+	_ TextRequest = (*openai.CreateThreadAndRunRequest)(nil)
+)
+
+// textRequestOf returns the TextRequest of a request. Requests of the OpenAI
+// API and the requests embedding them cannot implement it, so they are
+// adapted. False is returned for requests without texts to inspect.
+func textRequestOf(input any) (TextRequest, bool) {
+	switch converted := input.(type) {
+	case TextRequest:
+		return converted, true
+	case *goopenai.ChatCompletionRequest:
+		return &chatTexts{messages: converted.Messages}, true
+	case *vllm.ChatRequest:
+		return &chatTexts{messages: converted.Messages}, true
+	case *mistral.ChatRequest:
+		return &chatTexts{messages: converted.Messages}, true
+	case *perplexity.ChatRequest:
+		return &chatTexts{messages: converted.Messages}, true
+	case *goopenai.EmbeddingRequest:
+		return &promptTexts{prompt: &converted.Input}, true
+	case *vllm.CompletionRequest:
+		return &promptTexts{prompt: &converted.Prompt}, true
+	case *cohere.EmbedRequest:
+		return &sliceTexts{texts: converted.Texts}, true
+	case *goopenai.AssistantRequest:
+		return &instructionTexts{instructions: []*string{converted.Instructions}}, true
+	case *goopenai.RunRequest:
+		return &instructionTexts{instructions: []*string{&converted.Instructions, &converted.AdditionalInstructions}}, true
+	}
+
+	return nil, false
+}
+
+// chatTexts adapts chat messages. Their images are filtered separately.
+type chatTexts struct {
+	messages []goopenai.ChatCompletionMessage
+}
+
+func (ct *chatTexts) Texts() []string {
+	return chatMessageTexts(ct.messages)
+}
+
+func (ct *chatTexts) SetTexts(texts []string) {
+	setChatMessageTexts(ct.messages, texts)
+}
+
+// promptTexts adapts prompts and inputs that are either a string or a list
+// of strings. Token inputs are not inspected.
+type promptTexts struct {
+	prompt *any
+}
+
+func (pt *promptTexts) Texts() []string {
+	switch converted := (*pt.prompt).(type) {
+	case string:
+		return []string{converted}
+	case []string:
+		return append([]string{}, converted...)
+	case []any:
+		texts := []string{}
+		for _, input := range converted {
+			if text, ok := input.(string); ok {
+				texts = append(texts, text)
+			}
+		}
+
+		return texts
+	}
+
+	return []string{}
+}
+
+func (pt *promptTexts) SetTexts(texts []string) {
+	switch converted := (*pt.prompt).(type) {
+	case string:
+		if len(texts) != 0 {
+			*pt.prompt = texts[0]
+		}
+	case []string:
+		copy(converted, texts)
+	case []any:
+		idx := 0
+		for i, input := range converted {
+			if _, ok := input.(string); ok && idx < len(texts) {
+				converted[i] = texts[idx]
+				idx++
+			}
+		}
+	}
+}
+
+// sliceTexts adapts lists of texts.
+type sliceTexts struct {
+	texts []string
+}
+
+func (st *sliceTexts) Texts() []string {
+	return append([]string{}, st.texts...)
+}
+
+func (st *sliceTexts) SetTexts(texts []string) {
+	copy(st.texts, texts)
+}
+
+// instructionTexts adapts the instructions of assistants and runs. Missing
+// and empty instructions are skipped.
+type instructionTexts struct {
+	instructions []*string
+}
+
+func (it *instructionTexts) Texts() []string {
+	texts := []string{}
+	for _, instruction := range it.instructions {
+		if instruction != nil && len(*instruction) != 0 {
+			texts = append(texts, *instruction)
+		}
+	}
+
+	return texts
+}
+
+func (it *instructionTexts) SetTexts(texts []string) {
+	for _, instruction := range it.instructions {
+		if instruction != nil && len(*instruction) != 0 && len(texts) != 0 {
+			*instruction = texts[0]
+			texts = texts[1:]
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+func TestTextRequestOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		request any
+		body    string
+		texts   []string
+		// field returns the updated texts of the request.
+		field func(request any) any
+		want  any
+	}{
+		{
+			name:    "openai chat completion",
+			request: &goopenai.ChatCompletionRequest{},
+			body:    `{"model":"gpt-4o","messages":[{"role":"system","content":"be nice"},{"role":"user","content":"hi"}]}`,
+			texts:   []string{"be nice", "hi"},
+			field: func(request any) any {
+				return chatMessageTexts(request.(*goopenai.ChatCompletionRequest).Messages)
+			},
+			want: []string{"BE NICE", "HI"},
+		},
+		{
+			name:    "openai embedding list",
+			request: &goopenai.EmbeddingRequest{},
+			body:    `{"model":"text-embedding-3-small","input":["first","second"]}`,
+			texts:   []string{"first", "second"},
+			field: func(request any) any {
+				return request.(*goopenai.EmbeddingRequest).Input
+			},
+			want: []any{"FIRST", "SECOND"},
+		},
+		{
+			name:    "openai embedding tokens",
+			request: &goopenai.EmbeddingRequest{},
+			body:    `{"model":"text-embedding-3-small","input":[1,2,3]}`,
+			texts:   []string{},
+			field: func(request any) any {
+				return request.(*goopenai.EmbeddingRequest).Input
+			},
+			want: []any{float64(1), float64(2), float64(3)},
+		},
+		{
+			name:    "vllm completion",
+			request: &vllm.CompletionRequest{},
+			body:    `{"model":"llama3","prompt":"complete this"}`,
+			texts:   []string{"complete this"},
+			field: func(request any) any {
+				return request.(*vllm.CompletionRequest).Prompt
+			},
+			want: "COMPLETE THIS",
+		},
+		{
+			name:    "vllm chat",
+			request: &vllm.ChatRequest{},
+			body:    `{"model":"llama3","messages":[{"role":"user","content":"hello"}]}`,
+			texts:   []string{"hello"},
+			field: func(request any) any {
+				return chatMessageTexts(request.(*vllm.ChatRequest).Messages)
+			},
+			want: []string{"HELLO"},
+		},
+		{
+			name:    "cohere embed",
+			request: &cohere.EmbedRequest{},
+			body:    `{"model":"embed-english-v3.0","texts":["one","two"]}`,
+			texts:   []string{"one", "two"},
+			field: func(request any) any {
+				return request.(*cohere.EmbedRequest).Texts
+			},
+			want: []string{"ONE", "TWO"},
+		},
+		{
+			name:    "anthropic completion",
+			request: &anthropic.CompletionRequest{},
+			body:    `{"model":"claude-2","prompt":"Human: hi"}`,
+			texts:   []string{"Human: hi"},
+			field: func(request any) any {
+				return request.(*anthropic.CompletionRequest).Prompt
+			},
+			want: "HUMAN: HI",
+		},
+		{
+			name:    "assistant",
+			request: &goopenai.AssistantRequest{},
+			body:    `{"model":"gpt-4o","instructions":"answer briefly"}`,
+			texts:   []string{"answer briefly"},
+			field: func(request any) any {
+				return *request.(*goopenai.AssistantRequest).Instructions
+			},
+			want: "ANSWER BRIEFLY",
+		},
+		{
+			name:    "assistant without instructions",
+			request: &goopenai.AssistantRequest{},
+			body:    `{"model":"gpt-4o"}`,
+			texts:   []string{},
+			field: func(request any) any {
+				return request.(*goopenai.AssistantRequest).Instructions
+			},
+			want: (*string)(nil),
+		},
+		{
+			name:    "run",
+			request: &goopenai.RunRequest{},
+			body:    `{"assistant_id":"asst_1","additional_instructions":"be brief"}`,
+			texts:   []string{"be brief"},
+			field: func(request any) any {
+				r := request.(*goopenai.RunRequest)
+				return []string{r.Instructions, r.AdditionalInstructions}
+			},
+			want: []string{"", "BE BRIEF"},
+		},
+		{
+			name:    "message parts",
+			request: &openai.MessageRequest{},
+			body:    `{"role":"user","content":[{"type":"text","text":"hello"},{"type":"image_file","image_file":{"file_id":"file_1"}}]}`,
+			texts:   []string{"hello"},
+			field: func(request any) any {
+				return request.(*openai.MessageRequest).Content
+			},
+			want: []any{
+				map[string]any{"type": "text", "text": "HELLO"},
+				map[string]any{"type": "image_file", "image_file": map[string]any{"file_id": "file_1"}},
+			},
+		},
+		{
+			name:    "thread",
+			request: &openai.ThreadRequest{},
+			body:    `{"messages":[{"role":"user","content":"first"},{"role":"user","content":[{"type":"text","text":"second"}]}]}`,
+			texts:   []string{"first", "second"},
+			field: func(request any) any {
+				return request.(*openai.ThreadRequest).Texts()
+			},
+			want: []string{"FIRST", "SECOND"},
+		},
+		{
+			name:    "thread and run",
+			request: &openai.CreateThreadAndRunRequest{},
+			body:    `{"assistant_id":"asst_1","instructions":"be brief","thread":{"messages":[{"role":"user","content":"hello"}]}}`,
+			texts:   []string{"hello", "be brief"},
+			field: func(request any) any {
+				r := request.(*openai.CreateThreadAndRunRequest)
+				return []string{r.Thread.Texts()[0], r.Instructions}
+			},
+			want: []string{"HELLO", "BE BRIEF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, json.Unmarshal([]byte(tt.body), tt.request))
+
+			tr, ok := textRequestOf(tt.request)
+			require.True(t, ok)
+
+			texts := tr.Texts()
+			assert.Equal(t, tt.texts, texts)
+
+			updated := []string{}
+			for _, text := range texts {
+				updated = append(updated, strings.ToUpper(text))
+			}
+
+			tr.SetTexts(updated)
+			assert.Equal(t, tt.want, tt.field(tt.request))
+		})
+	}
+
+	_, ok := textRequestOf(&goopenai.ImageRequest{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,97 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Tokens maps the tokens replacing values redacted reversibly in a request
+// to the values. Tokens are stable within a request, so repeated values are
+// replaced by the same token, and are only held for the lifetime of the
+// request.
+type Tokens struct {
+	lock     sync.Mutex
+	values   map[string]string
+	tokens   map[string]string
+	counters map[string]int
+}
+
+func newTokens() *Tokens {
+	return &Tokens{
+		values:   map[string]string{},
+		tokens:   map[string]string{},
+		counters: map[string]int{},
+	}
+}
+
+func (t *Tokens) tokenize(value, ruleType string) string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if token, ok := t.tokens[value]; ok {
+		return token
+	}
+
+	upper := strings.ToUpper(ruleType)
+	t.counters[upper]++
+
+	token := fmt.Sprintf("[%s_%d]", upper, t.counters[upper])
+	t.tokens[value] = token
+	t.values[token] = value
+
+	return token
+}
+
+// Len returns the number of values redacted reversibly.
+func (t *Tokens) Len() int {
+	if t == nil {
+		return 0
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return len(t.values)
+}
+
+func (t *Tokens) replacer(escape func(string) string) *strings.Replacer {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	pairs := []string{}
+	for token, value := range t.values {
+		pairs = append(pairs, token, escape(value))
+	}
+
+	return strings.NewReplacer(pairs...)
+}
+
+// Detokenize restores the values of the tokens found in a text.
+func (t *Tokens) Detokenize(text string) string {
+	if t.Len() == 0 {
+		return text
+	}
+
+	return t.replacer(func(value string) string {
+		return value
+	}).Replace(text)
+}
+
+// DetokenizeJson restores the values of the tokens found in the strings of a
+// JSON document. Values are escaped so that the document stays valid.
+func (t *Tokens) DetokenizeJson(data []byte) []byte {
+	if t.Len() == 0 {
+		return data
+	}
+
+	return []byte(t.replacer(func(value string) string {
+		escaped, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+
+		return string(escaped[1 : len(escaped)-1])
+	}).Replace(string(data)))
+}
@@ -0,0 +1,165 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// topicPrefix is prepended to the names of matched topic rules in the errors
+// of blocked and warned requests.
+const topicPrefix = "topic:"
+
+// TopicCategories are phrase lists of topics commonly denied by policies.
+// Topic rules can use them in addition to their own phrases.
+var TopicCategories = map[string][]string{
+	"self_harm": {
+		"kill myself", "killing myself", "suicide", "suicidal", "self harm", "self-harm", "hurt myself", "cut myself", "end my life", "want to die",
+	},
+	"legal_advice": {
+		"legal advice", "sue", "lawsuit", "my lawyer", "my attorney", "am i liable", "legally required", "custody battle", "file for divorce",
+	},
+	"medical_advice": {
+		"medical advice", "diagnose", "diagnosis", "prescription", "dosage", "overdose", "what medication", "symptoms of",
+	},
+	"financial_advice": {
+		"financial advice", "investment advice", "should i invest", "which stocks", "stock tips", "buy crypto", "retirement portfolio",
+	},
+	"weapons": {
+		"build a bomb", "make a bomb", "explosives", "make a gun", "3d printed gun", "untraceable gun", "ammunition",
+	},
+}
+
+// TopicRule denies requests mentioning any of its phrases or the phrases of
+// its categories. Phrases are matched as whole words regardless of case.
+type TopicRule struct {
+	Name       string   `json:"name"`
+	Phrases    []string `json:"phrases"`
+	Categories []string `json:"categories"`
+	Action     Action   `json:"action"`
+
+	regex *regexp.Regexp
+}
+
+// TopicConfig lets a policy block or warn on requests about denied topics
+// such as self harm, legal advice or competitor names.
+type TopicConfig struct {
+	Rules []*TopicRule `json:"rules"`
+}
+
+func (c *TopicConfig) Validate() []string {
+	msgs := []string{}
+	for idx, rule := range c.Rules {
+		if rule == nil {
+			msgs = append(msgs, fmt.Sprintf("topic rule at index [%d] cannot be nil", idx))
+			continue
+		}
+
+		if len(rule.Name) == 0 {
+			msgs = append(msgs, fmt.Sprintf("topic rule at index [%d] must have a name", idx))
+		}
+
+		if len(rule.Phrases) == 0 && len(rule.Categories) == 0 {
+			msgs = append(msgs, fmt.Sprintf("topic rule at index [%d] must have phrases or categories", idx))
+		}
+
+		for _, phrase := range rule.Phrases {
+			if len(strings.TrimSpace(phrase)) == 0 {
+				msgs = append(msgs, fmt.Sprintf("topic rule at index [%d] cannot have empty phrases", idx))
+				break
+			}
+		}
+
+		for _, category := range rule.Categories {
+			if _, ok := TopicCategories[category]; !ok {
+				msgs = append(msgs, fmt.Sprintf("topic rule at index [%d] has unknown category %s", idx, category))
+			}
+		}
+
+		if rule.Action != Block && rule.Action != AllowButWarn && rule.Action != Allow {
+			msgs = append(msgs, fmt.Sprintf("topic rule at index [%d] must have an action of block, allow_but_warn or allow", idx))
+		}
+	}
+
+	return msgs
+}
+
+func (r *TopicRule) compile() (*regexp.Regexp, error) {
+	phrases := []string{}
+	for _, phrase := range r.Phrases {
+		phrases = append(phrases, regexp.QuoteMeta(strings.TrimSpace(phrase)))
+	}
+
+	for _, category := range r.Categories {
+		for _, phrase := range TopicCategories[category] {
+			phrases = append(phrases, regexp.QuoteMeta(phrase))
+		}
+	}
+
+	// longer phrases are tried first so that they win over their prefixes.
+	sort.Slice(phrases, func(i, j int) bool {
+		return len(phrases[i]) > len(phrases[j])
+	})
+
+	return regexp.Compile(`(?i)(?:^|\W)(?:` + strings.Join(phrases, "|") + `)(?:\W|$)`)
+}
+
+// ShouldMatchTopics reports whether requests filtered by the policy are
+// matched against topic rules.
+func (p *Policy) ShouldMatchTopics() bool {
+	if p == nil || p.TopicConfig == nil {
+		return false
+	}
+
+	for _, rule := range p.TopicConfig.Rules {
+		if rule != nil && rule.Action != Allow && len(rule.Action) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchTopics merges the topic rules matching the texts of a scan result into
+// it. Matched rules are reported by name in the errors of blocked and warned
+// requests.
+func (p *Policy) matchTopics(sr *ScanResult) {
+	for _, rule := range p.TopicConfig.Rules {
+		if rule == nil || (rule.Action != Block && rule.Action != AllowButWarn) {
+			continue
+		}
+
+		regex, err := rule.compiled()
+		if err != nil {
+			telemetry.Incr("bricksllm.policy.match_topics.regex_compile_error", nil, 1)
+			continue
+		}
+
+		matched := false
+		for _, text := range sr.Updated {
+			if regex.MatchString(text) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			continue
+		}
+
+		if rule.Action == Block {
+			sr.Action = Block
+			sr.BlockedCustomDefinitions = append(sr.BlockedCustomDefinitions, topicPrefix+rule.Name)
+			continue
+		}
+
+		if sr.Action != Block {
+			sr.Action = AllowButWarn
+		}
+
+		sr.WarnedRegexDefinitions = append(sr.WarnedRegexDefinitions, topicPrefix+rule.Name)
+	}
+}
@@ -0,0 +1,134 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *TopicRule
+		want []string
+	}{
+		{
+			name: "valid",
+			rule: &TopicRule{Name: "competitors", Phrases: []string{"Acme"}, Categories: []string{"legal_advice"}, Action: Block},
+			want: []string{},
+		},
+		{
+			name: "nil",
+			want: []string{"topic rule at index [0] cannot be nil"},
+		},
+		{
+			name: "missing name",
+			rule: &TopicRule{Phrases: []string{"Acme"}, Action: Block},
+			want: []string{"topic rule at index [0] must have a name"},
+		},
+		{
+			name: "missing phrases and categories",
+			rule: &TopicRule{Name: "empty", Action: Block},
+			want: []string{"topic rule at index [0] must have phrases or categories"},
+		},
+		{
+			name: "empty phrase",
+			rule: &TopicRule{Name: "competitors", Phrases: []string{"Acme", " "}, Action: Block},
+			want: []string{"topic rule at index [0] cannot have empty phrases"},
+		},
+		{
+			name: "unknown category",
+			rule: &TopicRule{Name: "astrology", Categories: []string{"astrology"}, Action: Block},
+			want: []string{"topic rule at index [0] has unknown category astrology"},
+		},
+		{
+			name: "invalid action",
+			rule: &TopicRule{Name: "competitors", Phrases: []string{"Acme"}, Action: AllowButRedact},
+			want: []string{"topic rule at index [0] must have an action of block, allow_but_warn or allow"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &TopicConfig{Rules: []*TopicRule{tt.rule}}
+			assert.Equal(t, tt.want, c.Validate())
+		})
+	}
+}
+
+func TestMatchTopics(t *testing.T) {
+	competitors := &TopicRule{Name: "competitors", Phrases: []string{"Acme", "Acme Cloud", "C++"}, Action: AllowButWarn}
+	selfHarm := &TopicRule{Name: "self_harm", Categories: []string{"self_harm"}, Action: Block}
+
+	tests := []struct {
+		name    string
+		rules   []*TopicRule
+		texts   []string
+		action  Action
+		blocked []string
+		warned  []string
+	}{
+		{
+			name:   "no match",
+			rules:  []*TopicRule{competitors, selfHarm},
+			texts:  []string{"compare our plans"},
+			action: Allow,
+		},
+		{
+			name:   "phrase regardless of case",
+			rules:  []*TopicRule{competitors},
+			texts:  []string{"is ACME cloud cheaper?"},
+			action: AllowButWarn,
+			warned: []string{"topic:competitors"},
+		},
+		{
+			name:   "phrase inside a word",
+			rules:  []*TopicRule{competitors},
+			texts:  []string{"we reached the acmes of sales"},
+			action: Allow,
+		},
+		{
+			name:   "phrase with symbols",
+			rules:  []*TopicRule{competitors},
+			texts:  []string{"rewrite it in C++."},
+			action: AllowButWarn,
+			warned: []string{"topic:competitors"},
+		},
+		{
+			name:    "category",
+			rules:   []*TopicRule{competitors, selfHarm},
+			texts:   []string{"hello", "I want to end my life"},
+			action:  Block,
+			blocked: []string{"topic:self_harm"},
+		},
+		{
+			name:    "block wins over warn",
+			rules:   []*TopicRule{selfHarm, competitors},
+			texts:   []string{"Acme made me feel suicidal"},
+			action:  Block,
+			blocked: []string{"topic:self_harm"},
+			warned:  []string{"topic:competitors"},
+		},
+		{
+			name:   "allowed rules are skipped",
+			rules:  []*TopicRule{{Name: "allowed", Phrases: []string{"Acme"}, Action: Allow}},
+			texts:  []string{"Acme"},
+			action: Allow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{TopicConfig: &TopicConfig{Rules: tt.rules}}
+			require.NoError(t, p.Compile())
+
+			sr := &ScanResult{Updated: tt.texts, Action: Allow}
+			p.matchTopics(sr)
+
+			assert.Equal(t, tt.action, sr.Action)
+			assert.Equal(t, tt.blocked, sr.BlockedCustomDefinitions)
+			assert.Equal(t, tt.warned, sr.WarnedRegexDefinitions)
+		})
+	}
+}
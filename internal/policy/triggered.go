@@ -0,0 +1,59 @@
+package policy
+
+// TriggeredRule is a rule that made a policy block, warn on or redact a
+// request or a response. PII rules are reported by entity type, regex rules by
+// definition and other rules by their prefixed names.
+type TriggeredRule struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"action"`
+}
+
+// redact records a rule whose matches were redacted.
+func (sr *ScanResult) redact(rule string) {
+	for _, redacted := range sr.RedactedRules {
+		if redacted == rule {
+			return
+		}
+	}
+
+	sr.RedactedRules = append(sr.RedactedRules, rule)
+}
+
+// triggeredRules returns the rules that triggered the actions of a scan
+// result.
+func (sr *ScanResult) triggeredRules() []*TriggeredRule {
+	rules := []*TriggeredRule{}
+	add := func(action Action, names ...string) {
+		for _, name := range names {
+			rules = append(rules, &TriggeredRule{Rule: name, Action: action})
+		}
+	}
+
+	for _, entity := range sr.BlockedEntities {
+		add(Block, string(entity))
+	}
+
+	add(Block, sr.BlockedRegexDefinitions...)
+	add(Block, sr.BlockedCustomDefinitions...)
+
+	for _, entity := range sr.WarnedEntities {
+		add(AllowButWarn, string(entity))
+	}
+
+	add(AllowButWarn, sr.WarnedRegexDefinitions...)
+	add(AllowButRedact, sr.RedactedRules...)
+
+	return rules
+}
+
+// trigger records the rules that triggered the actions of a scan result.
+func (fc *findingsCollector) trigger(sr *ScanResult) {
+	if fc == nil {
+		return
+	}
+
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	fc.rules = append(fc.rules, sr.triggeredRules()...)
+}
@@ -0,0 +1,27 @@
+package policy
+
+const (
+	WarningModeForward = "forward"
+	WarningModeBlock   = "block"
+)
+
+// WarningConfig sets what happens to requests warned by the rules of a
+// policy. Warned requests are forwarded by default.
+type WarningConfig struct {
+	Mode string `json:"mode"`
+}
+
+func (c *WarningConfig) Validate() []string {
+	msgs := []string{}
+	if len(c.Mode) != 0 && c.Mode != WarningModeForward && c.Mode != WarningModeBlock {
+		msgs = append(msgs, "warning mode must be one of forward or block")
+	}
+
+	return msgs
+}
+
+// ShouldBlockWarnings reports whether requests warned by the policy are
+// blocked instead of forwarded.
+func (p *Policy) ShouldBlockWarnings() bool {
+	return p != nil && p.WarningConfig != nil && p.WarningConfig.Mode == WarningModeBlock
+}
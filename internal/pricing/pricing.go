@@ -0,0 +1,255 @@
+package pricing
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+//go:embed manifest.json
+var embeddedManifest []byte
+
+const manifestRequestTimeout = 30 * time.Second
+
+// Manifest prices OpenAI models by kind of cost in the units of
+// openai.OpenAiPerThousandTokenCost. Models missing from a manifest keep their
+// built in costs.
+type Manifest struct {
+	Version string                        `json:"version"`
+	OpenAi  map[string]map[string]float64 `json:"openai"`
+}
+
+// Validate returns the names of invalid fields of a manifest.
+func (m *Manifest) Validate() []string {
+	invalid := []string{}
+	if len(m.OpenAi) == 0 {
+		invalid = append(invalid, "openai")
+	}
+
+	kinds := map[string]bool{}
+	for _, kind := range openai.PricingKinds {
+		kinds[kind] = true
+	}
+
+	for kind, costs := range m.OpenAi {
+		if !kinds[kind] {
+			invalid = append(invalid, "openai."+kind)
+			continue
+		}
+
+		for model, cost := range costs {
+			if len(model) == 0 || cost < 0 {
+				invalid = append(invalid, "openai."+kind+"."+model)
+			}
+		}
+	}
+
+	sort.Strings(invalid)
+
+	return invalid
+}
+
+// table returns the built in cost map of OpenAI models with the costs of the
+// manifest applied.
+func (m *Manifest) table() map[string]map[string]float64 {
+	table := map[string]map[string]float64{}
+	for kind, costs := range openai.OpenAiPerThousandTokenCost {
+		table[kind] = map[string]float64{}
+		for model, cost := range costs {
+			table[kind][model] = cost
+		}
+	}
+
+	for kind, costs := range m.OpenAi {
+		if _, ok := table[kind]; !ok {
+			table[kind] = map[string]float64{}
+		}
+
+		for model, cost := range costs {
+			table[kind][model] = cost
+		}
+	}
+
+	return table
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+
+	if invalid := m.Validate(); len(invalid) != 0 {
+		return nil, fmt.Errorf("invalid fields in pricing manifest: %s", strings.Join(invalid, ","))
+	}
+
+	return m, nil
+}
+
+type pricingTableSetter interface {
+	SetPricingTable(m map[string]map[string]float64, version string)
+}
+
+// Job periodically fetches a pricing manifest and hot reloads the cost map of
+// the OpenAI cost estimator with it. The manifest embedded in the binary is
+// applied instead if no manifest url is configured.
+type Job struct {
+	url      string
+	client   http.Client
+	setter   pricingTableSetter
+	log      *zap.Logger
+	interval time.Duration
+	etag     string
+	applied  bool
+	done     chan bool
+}
+
+func NewJob(url string, setter pricingTableSetter, log *zap.Logger, interval time.Duration) *Job {
+	return &Job{
+		url: url,
+		client: http.Client{
+			Timeout: manifestRequestTimeout,
+		},
+		setter:   setter,
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+}
+
+// fetch returns the manifest served at the url of the job along with its etag.
+// A nil manifest is returned if the manifest did not change since it was last
+// fetched.
+func (j *Job) fetch() (*Manifest, string, error) {
+	req, err := http.NewRequest(http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(j.etag) != 0 {
+		req.Header.Set("If-None-Match", j.etag)
+	}
+
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, "", nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("pricing manifest request failed with status code %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m, err := parseManifest(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return m, res.Header.Get("ETag"), nil
+}
+
+// apply hot reloads the cost map with a manifest. Manifests without a
+// version are versioned by their etag.
+func (j *Job) apply(m *Manifest, etag string) error {
+	version := m.Version
+	if len(version) == 0 {
+		version = etag
+	}
+
+	if len(version) == 0 {
+		return errors.New("pricing manifest does not have a version or an etag")
+	}
+
+	j.setter.SetPricingTable(m.table(), version)
+	j.log.Sugar().Infof("pricing job applied pricing manifest %s", version)
+
+	return nil
+}
+
+func (j *Job) run() {
+	if len(j.url) == 0 {
+		if j.applied {
+			return
+		}
+
+		m, err := parseManifest(embeddedManifest)
+		if err != nil {
+			telemetry.Incr("bricksllm.pricing.job.run.parse_embedded_manifest_error", nil, 1)
+			j.log.Sugar().Debugf("pricing job failed to parse embedded manifest: %v", err)
+			return
+		}
+
+		if err := j.apply(m, ""); err != nil {
+			telemetry.Incr("bricksllm.pricing.job.run.apply_manifest_error", nil, 1)
+			j.log.Sugar().Debugf("pricing job failed to apply embedded manifest: %v", err)
+			return
+		}
+
+		j.applied = true
+		return
+	}
+
+	m, etag, err := j.fetch()
+	if err != nil {
+		telemetry.Incr("bricksllm.pricing.job.run.fetch_manifest_error", nil, 1)
+		j.log.Sugar().Debugf("pricing job failed to fetch pricing manifest: %v", err)
+		return
+	}
+
+	if m == nil {
+		return
+	}
+
+	if err := j.apply(m, etag); err != nil {
+		telemetry.Incr("bricksllm.pricing.job.run.apply_manifest_error", nil, 1)
+		j.log.Sugar().Debugf("pricing job failed to apply pricing manifest: %v", err)
+		return
+	}
+
+	j.etag = etag
+}
+
+func (j *Job) Start() {
+	ticker := time.NewTicker(j.interval)
+	j.log.Info("pricing job started")
+
+	go func() {
+		j.run()
+
+		for {
+			select {
+			case <-j.done:
+				ticker.Stop()
+				j.log.Info("pricing job stopped")
+				return
+			case <-ticker.C:
+				j.run()
+			}
+		}
+	}()
+}
+
+func (j *Job) Stop() {
+	j.log.Info("shutting down pricing job...")
+
+	j.done <- true
+}
@@ -1,5 +1,7 @@
 package anthropic
 
+import "encoding/json"
+
 type Metadata struct {
 	UserId string `json:"user_id"`
 }
@@ -10,27 +12,115 @@ type CompletionRequest struct {
 	MaxTokensToSample int       `json:"max_tokens_to_sample"`
 	StopSequences     []string  `json:"stop_sequences,omitempty"`
 	Temperature       float32   `json:"temperature,omitempty"`
-	TopP              int       `json:"top_p,omitempty"`
+	TopP              float32   `json:"top_p,omitempty"`
 	TopK              int       `json:"top_k,omitempty"`
 	Metadata          *Metadata `json:"metadata,omitempty"`
 	Stream            bool      `json:"stream,omitempty"`
 }
 
+func (cr *CompletionRequest) Texts() []string {
+	return []string{cr.Prompt}
+}
+
+func (cr *CompletionRequest) SetTexts(texts []string) {
+	if len(texts) != 0 {
+		cr.Prompt = texts[0]
+	}
+}
+
 type Message struct {
-	Content string `json:"content"`
-	Role    string `json:"role"`
+	Content MessageContent `json:"content"`
+	Role    string         `json:"role"`
 }
 
+// MessagesRequest is a request of the messages API. Fields that are not
+// declared, such as tools, are kept so that a request can be forwarded after
+// being modified.
 type MessagesRequest struct {
-	Model         string    `json:"model"`
-	Messages      []Message `json:"messages"`
-	MaxTokens     int       `json:"max_tokens"`
-	StopSequences []string  `json:"stop_sequences,omitempty"`
-	Temperature   float32   `json:"temperature,omitempty"`
-	TopP          int       `json:"top_p,omitempty"`
-	TopK          int       `json:"top_k,omitempty"`
-	Metadata      *Metadata `json:"metadata,omitempty"`
-	Stream        bool      `json:"stream,omitempty"`
+	Model         string          `json:"model,omitempty"`
+	System        *MessageContent `json:"system,omitempty"`
+	Messages      []Message       `json:"messages"`
+	MaxTokens     int             `json:"max_tokens"`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
+	Temperature   float32         `json:"temperature,omitempty"`
+	TopP          float32         `json:"top_p,omitempty"`
+	TopK          int             `json:"top_k,omitempty"`
+	Metadata      *Metadata       `json:"metadata,omitempty"`
+	Stream        bool            `json:"stream,omitempty"`
+
+	raw map[string]json.RawMessage
+}
+
+type messagesRequest MessagesRequest
+
+func (mr *MessagesRequest) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	converted := (*messagesRequest)(mr)
+	if err := json.Unmarshal(data, converted); err != nil {
+		return err
+	}
+
+	mr.raw = raw
+	return nil
+}
+
+func (mr MessagesRequest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(messagesRequest(mr))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mr.raw) == 0 {
+		return data, nil
+	}
+
+	// declared fields override the fields of the original request while
+	// omitted ones, such as a zero temperature, are kept as they were sent.
+	fields := map[string]json.RawMessage{}
+	for k, v := range mr.raw {
+		fields[k] = v
+	}
+
+	declared := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &declared); err != nil {
+		return nil, err
+	}
+
+	for k, v := range declared {
+		fields[k] = v
+	}
+
+	return json.Marshal(fields)
+}
+
+// Texts returns the texts of the system prompt and of the messages in order.
+func (mr *MessagesRequest) Texts() []string {
+	texts := []string{}
+	if mr.System != nil {
+		texts = append(texts, mr.System.Texts()...)
+	}
+
+	for index := range mr.Messages {
+		texts = append(texts, mr.Messages[index].Content.Texts()...)
+	}
+
+	return texts
+}
+
+// SetTexts replaces the texts of the system prompt and of the messages in the
+// order returned by Texts.
+func (mr *MessagesRequest) SetTexts(texts []string) {
+	if mr.System != nil {
+		texts = mr.System.SetTexts(texts)
+	}
+
+	for index := range mr.Messages {
+		texts = mr.Messages[index].Content.SetTexts(texts)
+	}
 }
 
 type CompletionResponse struct {
@@ -39,9 +129,16 @@ type CompletionResponse struct {
 	Model      string `json:"model"`
 }
 
+// MessageResponseContent is a content block of a response or the delta of a
+// content block of a stream. Tool use blocks carry the id, name and input of
+// the tool call while their deltas carry partial JSON inputs.
 type MessageResponseContent struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type        string          `json:"type"`
+	Text        string          `json:"text,omitempty"`
+	Id          string          `json:"id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Input       json.RawMessage `json:"input,omitempty"`
+	PartialJson string          `json:"partial_json,omitempty"`
 }
 
 type MessagesResponse struct {
@@ -52,10 +149,33 @@ type MessagesResponse struct {
 	Model        string                   `json:"model"`
 	StopReason   string                   `json:"stop_reason"`
 	StopSequence string                   `json:"stop_sequence,omitempty"`
-	Usage        struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
+	Usage        MessagesUsage            `json:"usage"`
+}
+
+// Text returns the texts of the text content blocks of a response.
+func (mr *MessagesResponse) Text() string {
+	text := ""
+	for _, c := range mr.Content {
+		if c.Type == "text" {
+			text += c.Text
+		}
 	}
+
+	return text
+}
+
+// MessagesUsage reports token usage of a messages request. Input tokens
+// written to or read from the prompt cache are not included in InputTokens.
+type MessagesUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// TotalInputTokens returns the input tokens including cached prompt tokens.
+func (u *MessagesUsage) TotalInputTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
 }
 
 type MessagesStreamMessageStart struct {
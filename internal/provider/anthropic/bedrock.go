@@ -1,5 +1,7 @@
 package anthropic
 
+import "encoding/json"
+
 type BedrockCompletionRequest struct {
 	Prompt            string   `json:"prompt"`
 	MaxTokensToSample int      `json:"max_tokens_to_sample"`
@@ -17,14 +19,15 @@ type BedrockCompletionResponse struct {
 }
 
 type BedrockMessageRequest struct {
-	AnthropicVersion string    `json:"anthropic_version"`
-	Messages         []Message `json:"messages"`
-	MaxTokens        int       `json:"max_tokens"`
-	StopSequences    []string  `json:"stop_sequences,omitempty"`
-	Temperature      float32   `json:"temperature,omitempty"`
-	TopP             int       `json:"top_p,omitempty"`
-	TopK             int       `json:"top_k,omitempty"`
-	Metadata         *Metadata `json:"metadata,omitempty"`
+	AnthropicVersion string          `json:"anthropic_version"`
+	System           *MessageContent `json:"system,omitempty"`
+	Messages         []Message       `json:"messages"`
+	MaxTokens        int             `json:"max_tokens"`
+	StopSequences    []string        `json:"stop_sequences,omitempty"`
+	Temperature      float32         `json:"temperature,omitempty"`
+	TopP             float32         `json:"top_p,omitempty"`
+	TopK             int             `json:"top_k,omitempty"`
+	Metadata         *Metadata       `json:"metadata,omitempty"`
 }
 
 type BedrockMessagesStopResponse struct {
@@ -33,12 +36,34 @@ type BedrockMessagesStopResponse struct {
 }
 
 type BedrockMetrics struct {
-	InputTokenCount   int `json:"inputTokenCount"`
-	OutputTokenCount  int `json:"outputTokenCount"`
-	InvocationLatency int `json:"invocationLatency"`
-	FirstByteLatency  int `json:"firstByteLatency"`
+	InputTokenCount           int `json:"inputTokenCount"`
+	OutputTokenCount          int `json:"outputTokenCount"`
+	CacheReadInputTokenCount  int `json:"cacheReadInputTokenCount"`
+	CacheWriteInputTokenCount int `json:"cacheWriteInputTokenCount"`
+	InvocationLatency         int `json:"invocationLatency"`
+	FirstByteLatency          int `json:"firstByteLatency"`
 }
 
 type BedrockMessageType struct {
 	Type string `json:"type"`
 }
+
+// BedrockGuardrailIntervened is the action of a Bedrock guardrail that
+// intervened on a request.
+const BedrockGuardrailIntervened = "INTERVENED"
+
+// BedrockGuardrailOutput is the result of a Bedrock guardrail in a response
+// body or stream chunk. The trace is only present if it was requested.
+type BedrockGuardrailOutput struct {
+	Action string          `json:"amazon-bedrock-guardrailAction"`
+	Trace  json.RawMessage `json:"amazon-bedrock-trace,omitempty"`
+}
+
+// GuardrailIntervention is the result of a Bedrock guardrail recorded on the
+// event of a request.
+type GuardrailIntervention struct {
+	GuardrailIdentifier string          `json:"guardrailIdentifier"`
+	GuardrailVersion    string          `json:"guardrailVersion"`
+	Action              string          `json:"action"`
+	Trace               json.RawMessage `json:"trace,omitempty"`
+}
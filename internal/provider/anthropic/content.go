@@ -0,0 +1,158 @@
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// ContentBlock is a block of the content of a message or of the system prompt.
+// Fields other than the type, the text and the content of tool results are
+// kept as is so that images, tool use blocks and cache control survive being
+// forwarded.
+type ContentBlock struct {
+	Type    string
+	Text    string
+	Content *MessageContent
+	extra   map[string]json.RawMessage
+}
+
+func (b *ContentBlock) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if raw, ok := fields["type"]; ok {
+		if err := json.Unmarshal(raw, &b.Type); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := fields["text"]; ok {
+		if err := json.Unmarshal(raw, &b.Text); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := fields["content"]; ok {
+		b.Content = &MessageContent{}
+		if err := json.Unmarshal(raw, b.Content); err != nil {
+			return err
+		}
+	}
+
+	delete(fields, "type")
+	delete(fields, "text")
+	delete(fields, "content")
+	b.extra = fields
+
+	return nil
+}
+
+func (b ContentBlock) MarshalJSON() ([]byte, error) {
+	fields := map[string]any{}
+	for k, v := range b.extra {
+		fields[k] = v
+	}
+
+	fields["type"] = b.Type
+	if b.Type == "text" || len(b.Text) != 0 {
+		fields["text"] = b.Text
+	}
+
+	if b.Content != nil {
+		fields["content"] = b.Content
+	}
+
+	return json.Marshal(fields)
+}
+
+// MessageContent is the content of a message or of the system prompt. It is
+// either a string or a list of content blocks.
+type MessageContent struct {
+	Text   string
+	Blocks []*ContentBlock
+	// IsBlocks is true if the content is a list of content blocks.
+	IsBlocks bool
+}
+
+// NewTextContent returns content made of a string.
+func NewTextContent(text string) MessageContent {
+	return MessageContent{Text: text}
+}
+
+func (mc *MessageContent) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) != 0 && trimmed[0] == '[' {
+		mc.IsBlocks = true
+		return json.Unmarshal(trimmed, &mc.Blocks)
+	}
+
+	mc.IsBlocks = false
+	return json.Unmarshal(trimmed, &mc.Text)
+}
+
+func (mc MessageContent) MarshalJSON() ([]byte, error) {
+	if mc.IsBlocks {
+		if mc.Blocks == nil {
+			return []byte("[]"), nil
+		}
+
+		return json.Marshal(mc.Blocks)
+	}
+
+	return json.Marshal(mc.Text)
+}
+
+// Texts returns the texts of the content, including the texts of tool
+// results, in order.
+func (mc *MessageContent) Texts() []string {
+	if !mc.IsBlocks {
+		return []string{mc.Text}
+	}
+
+	texts := []string{}
+	for _, b := range mc.Blocks {
+		if b.Type == "text" {
+			texts = append(texts, b.Text)
+		}
+
+		if b.Content != nil {
+			texts = append(texts, b.Content.Texts()...)
+		}
+	}
+
+	return texts
+}
+
+// SetTexts replaces the texts of the content in the order returned by Texts
+// and returns the texts that were not used.
+func (mc *MessageContent) SetTexts(texts []string) []string {
+	if !mc.IsBlocks {
+		if len(texts) != 0 {
+			mc.Text = texts[0]
+			return texts[1:]
+		}
+
+		return texts
+	}
+
+	for _, b := range mc.Blocks {
+		if b.Type == "text" && len(texts) != 0 {
+			b.Text = texts[0]
+			texts = texts[1:]
+		}
+
+		if b.Content != nil {
+			texts = b.Content.SetTexts(texts)
+		}
+	}
+
+	return texts
+}
+
+// String returns the texts of the content joined by new lines.
+func (mc *MessageContent) String() string {
+	return strings.Join(mc.Texts(), "\n")
+}
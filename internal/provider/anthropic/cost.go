@@ -77,7 +77,7 @@ func selectModel(model string) string {
 		return "claude-3-opus"
 	} else if strings.HasPrefix(model, "claude-3-sonnet") {
 		return "claude-3-sonnet"
-	} else if strings.HasPrefix(model, "claude-3.5-sonnet") {
+	} else if strings.HasPrefix(model, "claude-3.5-sonnet") || strings.HasPrefix(model, "claude-3-5-sonnet") {
 		return "claude-3.5-sonnet"
 	} else if strings.HasPrefix(model, "claude-3-haiku") {
 		return "claude-3-haiku"
@@ -90,6 +90,34 @@ func selectModel(model string) string {
 	return ""
 }
 
+// CacheWriteCostMultiplier and CacheReadCostMultiplier price prompt caching
+// tokens relative to the base input token price of Claude models.
+const (
+	CacheWriteCostMultiplier = 1.25
+	CacheReadCostMultiplier  = 0.1
+)
+
+// EstimateCacheCost estimates the cost of prompt caching. Tokens written to the
+// cache are priced at 125% and tokens read from the cache at 10% of the base
+// input token price of the model.
+func (ce *CostEstimator) EstimateCacheCost(model string, cacheWriteTks, cacheReadTks int) (float64, error) {
+	if cacheWriteTks == 0 && cacheReadTks == 0 {
+		return 0, nil
+	}
+
+	writeCost, err := ce.EstimatePromptCost(model, cacheWriteTks)
+	if err != nil {
+		return 0, err
+	}
+
+	readCost, err := ce.EstimatePromptCost(model, cacheReadTks)
+	if err != nil {
+		return 0, err
+	}
+
+	return writeCost*CacheWriteCostMultiplier + readCost*CacheReadCostMultiplier, nil
+}
+
 func (ce *CostEstimator) EstimateCompletionCost(model string, tks int) (float64, error) {
 	costMap, ok := ce.tokenCostMap["completion"]
 	if !ok {
@@ -118,7 +146,7 @@ func (ce *CostEstimator) CountMessagesTokens(messages []Message) int {
 	count := 0
 
 	for _, message := range messages {
-		count += ce.tc.Count(message.Content) + anthropicMessageOverhead
+		count += ce.tc.Count(message.Content.String()) + anthropicMessageOverhead
 	}
 
 	return count + anthropicMessageOverhead
@@ -0,0 +1,620 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultMaxTokens is used for chat completions requests that do not limit
+// the number of generated tokens since the messages API requires a limit.
+const DefaultMaxTokens = 4096
+
+var finishReasons = map[string]goopenai.FinishReason{
+	"end_turn":      goopenai.FinishReasonStop,
+	"stop_sequence": goopenai.FinishReasonStop,
+	"max_tokens":    goopenai.FinishReasonLength,
+	"tool_use":      goopenai.FinishReasonToolCalls,
+}
+
+// ToFinishReason maps the stop reason of a messages response to the finish
+// reason of a chat completion.
+func ToFinishReason(stopReason string) goopenai.FinishReason {
+	if len(stopReason) == 0 {
+		return goopenai.FinishReasonNull
+	}
+
+	if reason, ok := finishReasons[stopReason]; ok {
+		return reason
+	}
+
+	return goopenai.FinishReasonStop
+}
+
+func newContentBlock(blockType string, fields map[string]any) (*ContentBlock, error) {
+	extra := map[string]json.RawMessage{}
+	for k, v := range fields {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		extra[k] = data
+	}
+
+	return &ContentBlock{
+		Type:  blockType,
+		extra: extra,
+	}, nil
+}
+
+// newImageBlock converts the url of an image part into an image block. Data
+// urls are sent as base64 sources.
+func newImageBlock(url string) (*ContentBlock, error) {
+	if strings.HasPrefix(url, "data:") {
+		meta, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+		if !ok || !strings.HasSuffix(meta, ";base64") {
+			return nil, errors.New("image data urls must be base64 encoded")
+		}
+
+		return newContentBlock("image", map[string]any{
+			"source": map[string]string{
+				"type":       "base64",
+				"media_type": strings.TrimSuffix(meta, ";base64"),
+				"data":       data,
+			},
+		})
+	}
+
+	return newContentBlock("image", map[string]any{
+		"source": map[string]string{
+			"type": "url",
+			"url":  url,
+		},
+	})
+}
+
+func messageText(m goopenai.ChatCompletionMessage) string {
+	if len(m.MultiContent) == 0 {
+		return m.Content
+	}
+
+	texts := []string{}
+	for _, part := range m.MultiContent {
+		if part.Type == goopenai.ChatMessagePartTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+func newMessageContent(m goopenai.ChatCompletionMessage) (MessageContent, error) {
+	if len(m.MultiContent) == 0 {
+		return NewTextContent(m.Content), nil
+	}
+
+	blocks := []*ContentBlock{}
+	for _, part := range m.MultiContent {
+		switch part.Type {
+		case goopenai.ChatMessagePartTypeText:
+			blocks = append(blocks, &ContentBlock{Type: "text", Text: part.Text})
+		case goopenai.ChatMessagePartTypeImageURL:
+			if part.ImageURL == nil {
+				continue
+			}
+
+			block, err := newImageBlock(part.ImageURL.URL)
+			if err != nil {
+				return MessageContent{}, err
+			}
+
+			blocks = append(blocks, block)
+		default:
+			return MessageContent{}, fmt.Errorf("content part type %s is not supported", part.Type)
+		}
+	}
+
+	return MessageContent{Blocks: blocks, IsBlocks: true}, nil
+}
+
+func newAssistantContent(m goopenai.ChatCompletionMessage) (MessageContent, error) {
+	if len(m.ToolCalls) == 0 {
+		return newMessageContent(m)
+	}
+
+	blocks := []*ContentBlock{}
+	if text := messageText(m); len(text) != 0 {
+		blocks = append(blocks, &ContentBlock{Type: "text", Text: text})
+	}
+
+	for _, tc := range m.ToolCalls {
+		input := json.RawMessage("{}")
+		if len(strings.TrimSpace(tc.Function.Arguments)) != 0 {
+			if !json.Valid([]byte(tc.Function.Arguments)) {
+				return MessageContent{}, fmt.Errorf("arguments of tool call %s are not valid json", tc.ID)
+			}
+
+			input = json.RawMessage(tc.Function.Arguments)
+		}
+
+		block, err := newContentBlock("tool_use", map[string]any{
+			"id":    tc.ID,
+			"name":  tc.Function.Name,
+			"input": input,
+		})
+		if err != nil {
+			return MessageContent{}, err
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return MessageContent{Blocks: blocks, IsBlocks: true}, nil
+}
+
+func newToolResultBlock(m goopenai.ChatCompletionMessage) (*ContentBlock, error) {
+	block, err := newContentBlock("tool_result", map[string]any{
+		"tool_use_id": m.ToolCallID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content := NewTextContent(messageText(m))
+	block.Content = &content
+
+	return block, nil
+}
+
+func newTools(tools []goopenai.Tool) []map[string]any {
+	converted := []map[string]any{}
+	for _, t := range tools {
+		if t.Function == nil {
+			continue
+		}
+
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			}
+		}
+
+		tool := map[string]any{
+			"name":         t.Function.Name,
+			"input_schema": schema,
+		}
+
+		if len(t.Function.Description) != 0 {
+			tool["description"] = t.Function.Description
+		}
+
+		converted = append(converted, tool)
+	}
+
+	return converted
+}
+
+// newToolChoice converts the tool choice of a chat completions request. A
+// nil choice is returned when the default behavior applies.
+func newToolChoice(choice any, parallel any) map[string]any {
+	converted := map[string]any{}
+
+	switch c := choice.(type) {
+	case string:
+		switch c {
+		case "none":
+			converted["type"] = "none"
+		case "required":
+			converted["type"] = "any"
+		default:
+			converted["type"] = "auto"
+		}
+	case map[string]any:
+		converted["type"] = "auto"
+		if function, ok := c["function"].(map[string]any); ok {
+			if name, ok := function["name"].(string); ok && len(name) != 0 {
+				converted["type"] = "tool"
+				converted["name"] = name
+			}
+		}
+	case goopenai.ToolChoice:
+		converted["type"] = "tool"
+		converted["name"] = c.Function.Name
+	case *goopenai.ToolChoice:
+		converted["type"] = "tool"
+		converted["name"] = c.Function.Name
+	}
+
+	if p, ok := parallel.(bool); ok && !p {
+		if len(converted) == 0 {
+			converted["type"] = "auto"
+		}
+
+		if converted["type"] != "none" {
+			converted["disable_parallel_tool_use"] = true
+		}
+	}
+
+	if len(converted) == 0 {
+		return nil
+	}
+
+	return converted
+}
+
+// NewMessagesRequestFromOpenAi translates a chat completions request into a
+// messages request. System and developer messages become the system prompt,
+// tool calls and tool messages become tool use and tool result blocks.
+func NewMessagesRequestFromOpenAi(r *goopenai.ChatCompletionRequest) (*MessagesRequest, error) {
+	if r == nil {
+		return nil, errors.New("chat completions request is empty")
+	}
+
+	mr := &MessagesRequest{
+		Model:         r.Model,
+		MaxTokens:     r.MaxCompletionTokens,
+		StopSequences: r.Stop,
+		Temperature:   r.Temperature,
+		TopP:          r.TopP,
+		Stream:        r.Stream,
+		Messages:      []Message{},
+		raw:           map[string]json.RawMessage{},
+	}
+
+	if mr.MaxTokens == 0 {
+		mr.MaxTokens = r.MaxTokens
+	}
+
+	if mr.MaxTokens == 0 {
+		mr.MaxTokens = DefaultMaxTokens
+	}
+
+	// the temperature of the messages API ranges from 0 to 1.
+	if mr.Temperature > 1 {
+		mr.Temperature = 1
+	}
+
+	if len(r.User) != 0 {
+		mr.Metadata = &Metadata{
+			UserId: r.User,
+		}
+	}
+
+	system := []string{}
+	lastIsToolResult := false
+	for _, m := range r.Messages {
+		switch m.Role {
+		case goopenai.ChatMessageRoleSystem, "developer":
+			system = append(system, messageText(m))
+			continue
+		case goopenai.ChatMessageRoleTool:
+			block, err := newToolResultBlock(m)
+			if err != nil {
+				return nil, err
+			}
+
+			// consecutive tool results are sent in a single user message.
+			if lastIsToolResult {
+				last := &mr.Messages[len(mr.Messages)-1]
+				last.Content.Blocks = append(last.Content.Blocks, block)
+				continue
+			}
+
+			mr.Messages = append(mr.Messages, Message{
+				Role:    "user",
+				Content: MessageContent{Blocks: []*ContentBlock{block}, IsBlocks: true},
+			})
+			lastIsToolResult = true
+			continue
+		case goopenai.ChatMessageRoleAssistant:
+			if len(m.ToolCalls) == 0 && len(messageText(m)) == 0 {
+				continue
+			}
+
+			content, err := newAssistantContent(m)
+			if err != nil {
+				return nil, err
+			}
+
+			mr.Messages = append(mr.Messages, Message{Role: "assistant", Content: content})
+		case goopenai.ChatMessageRoleUser:
+			content, err := newMessageContent(m)
+			if err != nil {
+				return nil, err
+			}
+
+			mr.Messages = append(mr.Messages, Message{Role: "user", Content: content})
+		default:
+			return nil, fmt.Errorf("message role %s is not supported", m.Role)
+		}
+
+		lastIsToolResult = false
+	}
+
+	if len(system) != 0 {
+		content := NewTextContent(strings.Join(system, "\n"))
+		mr.System = &content
+	}
+
+	if tools := newTools(r.Tools); len(tools) != 0 {
+		data, err := json.Marshal(tools)
+		if err != nil {
+			return nil, err
+		}
+
+		mr.raw["tools"] = data
+	}
+
+	if choice := newToolChoice(r.ToolChoice, r.ParallelToolCalls); choice != nil && len(r.Tools) != 0 {
+		data, err := json.Marshal(choice)
+		if err != nil {
+			return nil, err
+		}
+
+		mr.raw["tool_choice"] = data
+	}
+
+	return mr, nil
+}
+
+// ToOpenAi translates a messages response into a chat completions response.
+func (mr *MessagesResponse) ToOpenAi() *goopenai.ChatCompletionResponse {
+	message := goopenai.ChatCompletionMessage{
+		Role: goopenai.ChatMessageRoleAssistant,
+	}
+
+	for _, c := range mr.Content {
+		if c.Type == "text" {
+			message.Content += c.Text
+		}
+
+		if c.Type == "tool_use" {
+			arguments := "{}"
+			if len(c.Input) != 0 {
+				arguments = string(c.Input)
+			}
+
+			message.ToolCalls = append(message.ToolCalls, goopenai.ToolCall{
+				ID:   c.Id,
+				Type: goopenai.ToolTypeFunction,
+				Function: goopenai.FunctionCall{
+					Name:      c.Name,
+					Arguments: arguments,
+				},
+			})
+		}
+	}
+
+	return &goopenai.ChatCompletionResponse{
+		ID:      mr.Id,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   mr.Model,
+		Choices: []goopenai.ChatCompletionChoice{
+			{
+				Index:        0,
+				Message:      message,
+				FinishReason: ToFinishReason(mr.StopReason),
+			},
+		},
+		Usage: goopenai.Usage{
+			PromptTokens:     mr.Usage.TotalInputTokens(),
+			CompletionTokens: mr.Usage.OutputTokens,
+			TotalTokens:      mr.Usage.TotalInputTokens() + mr.Usage.OutputTokens,
+		},
+	}
+}
+
+// ToOpenAi translates an error response of the messages API into the error
+// format of the chat completions API.
+func (er *ErrorResponse) ToOpenAi() *goopenai.ErrorResponse {
+	apiErr := &goopenai.APIError{}
+	if er.Error != nil {
+		apiErr.Type = er.Error.Type
+		apiErr.Message = er.Error.Message
+	}
+
+	return &goopenai.ErrorResponse{
+		Error: apiErr,
+	}
+}
+
+// ChatCompletionChunk is a chunk of a chat completions stream. Unlike the
+// chunks of the go-openai client, fields absent from a delta are omitted so
+// that clients accumulating tool calls across chunks are not reset.
+type ChatCompletionChunk struct {
+	Id      string          `json:"id"`
+	Object  string          `json:"object"`
+	Created int64           `json:"created"`
+	Model   string          `json:"model"`
+	Choices []*ChunkChoice  `json:"choices"`
+	Usage   *goopenai.Usage `json:"usage,omitempty"`
+}
+
+type ChunkChoice struct {
+	Index        int                   `json:"index"`
+	Delta        ChunkDelta            `json:"delta"`
+	FinishReason goopenai.FinishReason `json:"finish_reason"`
+}
+
+type ChunkDelta struct {
+	Role      string           `json:"role,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	ToolCalls []*ChunkToolCall `json:"tool_calls,omitempty"`
+}
+
+type ChunkToolCall struct {
+	Index    int               `json:"index"`
+	Id       string            `json:"id,omitempty"`
+	Type     string            `json:"type,omitempty"`
+	Function ChunkFunctionCall `json:"function"`
+}
+
+type ChunkFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// StreamTranslator translates the events of a messages stream into chat
+// completion chunks. Tool use blocks are numbered in the order they start.
+type StreamTranslator struct {
+	id           string
+	model        string
+	created      int64
+	includeUsage bool
+	toolIndexes  map[int]int
+	usage        MessagesUsage
+}
+
+func NewStreamTranslator(includeUsage bool) *StreamTranslator {
+	return &StreamTranslator{
+		created:      time.Now().Unix(),
+		includeUsage: includeUsage,
+		toolIndexes:  map[int]int{},
+	}
+}
+
+// Usage returns the usage reported by the events translated so far.
+func (st *StreamTranslator) Usage() MessagesUsage {
+	return st.usage
+}
+
+func (st *StreamTranslator) chunk(delta ChunkDelta, reason goopenai.FinishReason) *ChatCompletionChunk {
+	return &ChatCompletionChunk{
+		Id:      st.id,
+		Object:  "chat.completion.chunk",
+		Created: st.created,
+		Model:   st.model,
+		Choices: []*ChunkChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: reason,
+			},
+		},
+	}
+}
+
+// Translate returns the chunks of an event of a messages stream. Events
+// without an equivalent, such as pings, do not produce chunks.
+func (st *StreamTranslator) Translate(event string, data []byte) ([]*ChatCompletionChunk, error) {
+	switch event {
+	case "message_start":
+		start := &MessagesStreamMessageStart{}
+		if err := json.Unmarshal(data, start); err != nil {
+			return nil, err
+		}
+
+		st.id = start.Message.Id
+		st.model = start.Message.Model
+		st.usage.InputTokens = start.Message.Usage.InputTokens
+		st.usage.CacheCreationInputTokens = start.Message.Usage.CacheCreationInputTokens
+		st.usage.CacheReadInputTokens = start.Message.Usage.CacheReadInputTokens
+
+		return []*ChatCompletionChunk{
+			st.chunk(ChunkDelta{Role: goopenai.ChatMessageRoleAssistant}, goopenai.FinishReasonNull),
+		}, nil
+
+	case "content_block_start":
+		start := &MessagesStreamBlockStart{}
+		if err := json.Unmarshal(data, start); err != nil {
+			return nil, err
+		}
+
+		if start.ContentBlock.Type == "tool_use" {
+			index := len(st.toolIndexes)
+			st.toolIndexes[start.Index] = index
+
+			return []*ChatCompletionChunk{
+				st.chunk(ChunkDelta{
+					ToolCalls: []*ChunkToolCall{
+						{
+							Index: index,
+							Id:    start.ContentBlock.Id,
+							Type:  string(goopenai.ToolTypeFunction),
+							Function: ChunkFunctionCall{
+								Name: start.ContentBlock.Name,
+							},
+						},
+					},
+				}, goopenai.FinishReasonNull),
+			}, nil
+		}
+
+		if len(start.ContentBlock.Text) != 0 {
+			return []*ChatCompletionChunk{
+				st.chunk(ChunkDelta{Content: start.ContentBlock.Text}, goopenai.FinishReasonNull),
+			}, nil
+		}
+
+	case "content_block_delta":
+		delta := &MessagesStreamBlockDelta{}
+		if err := json.Unmarshal(data, delta); err != nil {
+			return nil, err
+		}
+
+		if delta.Delta.Type == "text_delta" {
+			return []*ChatCompletionChunk{
+				st.chunk(ChunkDelta{Content: delta.Delta.Text}, goopenai.FinishReasonNull),
+			}, nil
+		}
+
+		if delta.Delta.Type == "input_json_delta" {
+			index, ok := st.toolIndexes[delta.Index]
+			if !ok {
+				return nil, nil
+			}
+
+			return []*ChatCompletionChunk{
+				st.chunk(ChunkDelta{
+					ToolCalls: []*ChunkToolCall{
+						{
+							Index: index,
+							Function: ChunkFunctionCall{
+								Arguments: delta.Delta.PartialJson,
+							},
+						},
+					},
+				}, goopenai.FinishReasonNull),
+			}, nil
+		}
+
+	case "message_delta":
+		delta := &MessagesStreamMessageDelta{}
+		if err := json.Unmarshal(data, delta); err != nil {
+			return nil, err
+		}
+
+		st.usage.OutputTokens = delta.Usage.OutputTokens
+
+		chunks := []*ChatCompletionChunk{
+			st.chunk(ChunkDelta{}, ToFinishReason(delta.Delta.StopReason)),
+		}
+
+		if st.includeUsage {
+			chunks = append(chunks, &ChatCompletionChunk{
+				Id:      st.id,
+				Object:  "chat.completion.chunk",
+				Created: st.created,
+				Model:   st.model,
+				Choices: []*ChunkChoice{},
+				Usage: &goopenai.Usage{
+					PromptTokens:     st.usage.TotalInputTokens(),
+					CompletionTokens: st.usage.OutputTokens,
+					TotalTokens:      st.usage.TotalInputTokens() + st.usage.OutputTokens,
+				},
+			})
+		}
+
+		return chunks, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,136 @@
+package azure
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+const (
+	ContentFilterSourcePrompt     = "prompt"
+	ContentFilterSourceCompletion = "completion"
+)
+
+var severityRanks = map[string]int{
+	"safe":   0,
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+}
+
+// SeverityRank returns the rank of an Azure content filter severity level.
+// Unknown levels are ranked as -1.
+func SeverityRank(severity string) int {
+	rank, ok := severityRanks[severity]
+	if !ok {
+		return -1
+	}
+
+	return rank
+}
+
+type ContentFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"`
+	Detected bool   `json:"detected,omitempty"`
+}
+
+// ContentFilterFinding is a category flagged by the Azure OpenAI content filter
+// on either the prompt or the completion.
+type ContentFilterFinding struct {
+	Source   string `json:"source"`
+	Category string `json:"category"`
+	Severity string `json:"severity,omitempty"`
+	Filtered bool   `json:"filtered"`
+	Detected bool   `json:"detected,omitempty"`
+}
+
+type promptFilterResult struct {
+	PromptIndex          int                        `json:"prompt_index"`
+	ContentFilterResults map[string]json.RawMessage `json:"content_filter_results"`
+}
+
+type contentFilterChoice struct {
+	ContentFilterResults map[string]json.RawMessage `json:"content_filter_results"`
+}
+
+type contentFilterResponse struct {
+	PromptFilterResults []*promptFilterResult  `json:"prompt_filter_results"`
+	Choices             []*contentFilterChoice `json:"choices"`
+	Error               *struct {
+		Code       string `json:"code"`
+		InnerError *struct {
+			ContentFilterResult map[string]json.RawMessage `json:"content_filter_result"`
+		} `json:"innererror"`
+	} `json:"error"`
+}
+
+func appendFindings(findings []*ContentFilterFinding, source string, results map[string]json.RawMessage) []*ContentFilterFinding {
+	categories := []string{}
+	for category := range results {
+		categories = append(categories, category)
+	}
+
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		r := &ContentFilterResult{}
+		if err := json.Unmarshal(results[category], r); err != nil {
+			continue
+		}
+
+		if !r.Filtered && !r.Detected && SeverityRank(r.Severity) <= 0 {
+			continue
+		}
+
+		findings = append(findings, &ContentFilterFinding{
+			Source:   source,
+			Category: category,
+			Severity: r.Severity,
+			Filtered: r.Filtered,
+			Detected: r.Detected,
+		})
+	}
+
+	return findings
+}
+
+// ParseContentFilterFindings extracts flagged categories from the
+// prompt_filter_results and content_filter_results of an Azure OpenAI response
+// or from the content_filter_result of a prompt filter error. Categories that
+// are safe and neither filtered nor detected are omitted.
+func ParseContentFilterFindings(body []byte) []*ContentFilterFinding {
+	res := &contentFilterResponse{}
+	if err := json.Unmarshal(body, res); err != nil {
+		return nil
+	}
+
+	findings := []*ContentFilterFinding{}
+	for _, pfr := range res.PromptFilterResults {
+		if pfr != nil {
+			findings = appendFindings(findings, ContentFilterSourcePrompt, pfr.ContentFilterResults)
+		}
+	}
+
+	for _, choice := range res.Choices {
+		if choice != nil {
+			findings = appendFindings(findings, ContentFilterSourceCompletion, choice.ContentFilterResults)
+		}
+	}
+
+	if res.Error != nil && res.Error.InnerError != nil {
+		findings = appendFindings(findings, ContentFilterSourcePrompt, res.Error.InnerError.ContentFilterResult)
+	}
+
+	return findings
+}
+
+// IsContentFilterError returns true if an Azure OpenAI error response was
+// caused by the prompt being filtered.
+func IsContentFilterError(body []byte) bool {
+	res := &contentFilterResponse{}
+	if err := json.Unmarshal(body, res); err != nil {
+		return false
+	}
+
+	return res.Error != nil && res.Error.Code == "content_filter"
+}
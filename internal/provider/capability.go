@@ -0,0 +1,157 @@
+package provider
+
+import "regexp"
+
+type Capability struct {
+	MaxContextTokens int    `json:"maxContextTokens"`
+	MaxOutputTokens  int    `json:"maxOutputTokens"`
+	SupportsTools    bool   `json:"supportsTools"`
+	SupportsVision   bool   `json:"supportsVision"`
+	SupportsJsonMode bool   `json:"supportsJsonMode"`
+	Tier             string `json:"tier,omitempty"`
+}
+
+type Requirements struct {
+	Tools    bool
+	Vision   bool
+	JsonMode bool
+}
+
+func (c *Capability) Satisfies(r *Requirements) bool {
+	if c == nil || r == nil {
+		return true
+	}
+
+	if r.Tools && !c.SupportsTools {
+		return false
+	}
+
+	if r.Vision && !c.SupportsVision {
+		return false
+	}
+
+	if r.JsonMode && !c.SupportsJsonMode {
+		return false
+	}
+
+	return true
+}
+
+// Capability tiers group chat completion models of comparable quality, from
+// the cheapest small models to the most capable frontier models.
+const (
+	TierSmall    = "small"
+	TierStandard = "standard"
+	TierFrontier = "frontier"
+)
+
+var tierRanks = map[string]int{
+	TierSmall:    1,
+	TierStandard: 2,
+	TierFrontier: 3,
+}
+
+func IsValidTier(tier string) bool {
+	_, ok := tierRanks[tier]
+	return ok
+}
+
+// SatisfiesTier reports whether a model belongs to a tier or a more capable
+// one. Models without a tier satisfy none.
+func (c *Capability) SatisfiesTier(tier string) bool {
+	if c == nil {
+		return false
+	}
+
+	rank, ok := tierRanks[c.Tier]
+	return ok && rank >= tierRanks[tier]
+}
+
+var CapabilityPerModel = map[string]*Capability{
+	"o1-preview":                {MaxContextTokens: 128000, MaxOutputTokens: 32768, Tier: TierFrontier},
+	"o1-preview-2024-09-12":     {MaxContextTokens: 128000, MaxOutputTokens: 32768, Tier: TierFrontier},
+	"o1-mini":                   {MaxContextTokens: 128000, MaxOutputTokens: 65536, Tier: TierStandard},
+	"o1-mini-2024-09-12":        {MaxContextTokens: 128000, MaxOutputTokens: 65536, Tier: TierStandard},
+	"gpt-4o":                    {MaxContextTokens: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, SupportsJsonMode: true, Tier: TierFrontier},
+	"gpt-4o-2024-05-13":         {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsJsonMode: true, Tier: TierFrontier},
+	"gpt-4o-2024-08-06":         {MaxContextTokens: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, SupportsJsonMode: true, Tier: TierFrontier},
+	"gpt-4o-mini":               {MaxContextTokens: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, SupportsJsonMode: true, Tier: TierSmall},
+	"gpt-4o-mini-2024-07-18":    {MaxContextTokens: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, SupportsJsonMode: true, Tier: TierSmall},
+	"gpt-4-turbo":               {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsJsonMode: true, Tier: TierStandard},
+	"gpt-4-turbo-2024-04-09":    {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsJsonMode: true, Tier: TierStandard},
+	"gpt-4-turbo-preview":       {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsTools: true, SupportsJsonMode: true, Tier: TierStandard},
+	"gpt-4-1106-preview":        {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsTools: true, SupportsJsonMode: true, Tier: TierStandard},
+	"gpt-4-0125-preview":        {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsTools: true, SupportsJsonMode: true, Tier: TierStandard},
+	"gpt-4-vision-preview":      {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsVision: true, Tier: TierStandard},
+	"gpt-4-1106-vision-preview": {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsVision: true, Tier: TierStandard},
+	"gpt-4-vision":              {MaxContextTokens: 128000, MaxOutputTokens: 4096, SupportsVision: true, Tier: TierStandard},
+	"gpt-4":                     {MaxContextTokens: 8192, MaxOutputTokens: 8192, SupportsTools: true, Tier: TierStandard},
+	"gpt-4-0314":                {MaxContextTokens: 8192, MaxOutputTokens: 8192, Tier: TierStandard},
+	"gpt-4-0613":                {MaxContextTokens: 8192, MaxOutputTokens: 8192, SupportsTools: true, Tier: TierStandard},
+	"gpt-4-32k":                 {MaxContextTokens: 32768, MaxOutputTokens: 32768, SupportsTools: true, Tier: TierStandard},
+	"gpt-4-32k-0314":            {MaxContextTokens: 32768, MaxOutputTokens: 32768, Tier: TierStandard},
+	"gpt-4-32k-0613":            {MaxContextTokens: 32768, MaxOutputTokens: 32768, SupportsTools: true, Tier: TierStandard},
+	"gpt-3.5-turbo":             {MaxContextTokens: 16385, MaxOutputTokens: 4096, SupportsTools: true, SupportsJsonMode: true, Tier: TierSmall},
+	"gpt-3.5-turbo-0125":        {MaxContextTokens: 16385, MaxOutputTokens: 4096, SupportsTools: true, SupportsJsonMode: true, Tier: TierSmall},
+	"gpt-3.5-turbo-1106":        {MaxContextTokens: 16385, MaxOutputTokens: 4096, SupportsTools: true, SupportsJsonMode: true, Tier: TierSmall},
+	"gpt-3.5-turbo-0301":        {MaxContextTokens: 4096, MaxOutputTokens: 4096, Tier: TierSmall},
+	"gpt-3.5-turbo-0613":        {MaxContextTokens: 4096, MaxOutputTokens: 4096, SupportsTools: true, Tier: TierSmall},
+	"gpt-3.5-turbo-instruct":    {MaxContextTokens: 4096, MaxOutputTokens: 4096, Tier: TierSmall},
+	"gpt-3.5-turbo-16k":         {MaxContextTokens: 16385, MaxOutputTokens: 4096, SupportsTools: true, Tier: TierSmall},
+	"gpt-3.5-turbo-16k-0613":    {MaxContextTokens: 16385, MaxOutputTokens: 4096, SupportsTools: true, Tier: TierSmall},
+	"gpt-35-turbo":              {MaxContextTokens: 16385, MaxOutputTokens: 4096, SupportsTools: true, SupportsJsonMode: true, Tier: TierSmall},
+	"gpt-35-turbo-16k":          {MaxContextTokens: 16385, MaxOutputTokens: 4096, SupportsTools: true, Tier: TierSmall},
+	"gpt-35-turbo-instruct":     {MaxContextTokens: 4096, MaxOutputTokens: 4096, Tier: TierSmall},
+	"text-embedding-ada-002":    {MaxContextTokens: 8191},
+	"text-embedding-3-small":    {MaxContextTokens: 8191},
+	"text-embedding-3-large":    {MaxContextTokens: 8191},
+	"claude-instant":            {MaxContextTokens: 100000, MaxOutputTokens: 4096, Tier: TierSmall},
+	"claude-instant-1":          {MaxContextTokens: 100000, MaxOutputTokens: 4096, Tier: TierSmall},
+	"claude-instant-1.2":        {MaxContextTokens: 100000, MaxOutputTokens: 4096, Tier: TierSmall},
+	"claude":                    {MaxContextTokens: 100000, MaxOutputTokens: 4096, Tier: TierStandard},
+	"claude-2":                  {MaxContextTokens: 100000, MaxOutputTokens: 4096, Tier: TierStandard},
+	"claude-2.0":                {MaxContextTokens: 100000, MaxOutputTokens: 4096, Tier: TierStandard},
+	"claude-2.1":                {MaxContextTokens: 200000, MaxOutputTokens: 4096, Tier: TierStandard},
+	"claude-3-opus":             {MaxContextTokens: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, Tier: TierFrontier},
+	"claude-3-sonnet":           {MaxContextTokens: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, Tier: TierStandard},
+	"claude-3.5-sonnet":         {MaxContextTokens: 200000, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, Tier: TierFrontier},
+	"claude-3-5-sonnet":         {MaxContextTokens: 200000, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, Tier: TierFrontier},
+	"claude-3-haiku":            {MaxContextTokens: 200000, MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, Tier: TierSmall},
+}
+
+// datedSuffix matches the version suffixes of dated models, such as
+// -2024-08-06, -20240229, -0613 or the @20240229 of Vertex AI model names.
+var datedSuffix = regexp.MustCompile(`^[-@](\d{8}|\d{4}-\d{2}-\d{2}|\d{4})$`)
+
+// GetCapability returns the capability metadata of a model. Dated model versions
+// fall back to the metadata of their base model. Nil is returned for any other
+// unknown model, including variants such as fine tuned models whose capability
+// may differ from their base model.
+func GetCapability(model string) *Capability {
+	if c, ok := CapabilityPerModel[model]; ok {
+		return c
+	}
+
+	selected := ""
+	for name := range CapabilityPerModel {
+		if len(model) > len(name) && model[:len(name)] == name && datedSuffix.MatchString(model[len(name):]) && len(name) > len(selected) {
+			selected = name
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	return CapabilityPerModel[selected]
+}
+
+// GetContextWindow returns the context window of a model. Zero is returned if unknown.
+func GetContextWindow(model string) int {
+	c := GetCapability(model)
+	if c == nil {
+		return 0
+	}
+
+	return c.MaxContextTokens
+}
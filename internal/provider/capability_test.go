@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCapability(t *testing.T) {
+	tests := []struct {
+		model string
+		want  *Capability
+	}{
+		{model: "gpt-4o", want: CapabilityPerModel["gpt-4o"]},
+		{model: "gpt-4o-2024-11-20", want: CapabilityPerModel["gpt-4o"]},
+		{model: "gpt-4o-mini-2024-07-18", want: CapabilityPerModel["gpt-4o-mini-2024-07-18"]},
+		{model: "gpt-4-1106", want: CapabilityPerModel["gpt-4"]},
+		{model: "claude-3-opus-20240229", want: CapabilityPerModel["claude-3-opus"]},
+		{model: "claude-3-5-sonnet@20240620", want: CapabilityPerModel["claude-3-5-sonnet"]},
+		{model: "gpt-4o-audio-preview"},
+		{model: "gpt-4-turbo-custom"},
+		{model: "ft:gpt-4o-mini-2024-07-18:org::abc"},
+		{model: "claude-3-opus-latest"},
+		{model: "gpt-4o-realtime-preview-2024-10-01"},
+		{model: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			assert.Same(t, tt.want, GetCapability(tt.model))
+		})
+	}
+}
@@ -0,0 +1,436 @@
+package cohere
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	ChatUrl   = "https://api.cohere.com/v2/chat"
+	EmbedUrl  = "https://api.cohere.com/v2/embed"
+	RerankUrl = "https://api.cohere.com/v2/rerank"
+)
+
+// rawFields keeps the fields of a request that are not declared on its type
+// so that a request can be forwarded after being modified.
+type rawFields map[string]json.RawMessage
+
+func (rf rawFields) merge(declared []byte) ([]byte, error) {
+	if len(rf) == 0 {
+		return declared, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	for k, v := range rf {
+		fields[k] = v
+	}
+
+	overrides := map[string]json.RawMessage{}
+	if err := json.Unmarshal(declared, &overrides); err != nil {
+		return nil, err
+	}
+
+	for k, v := range overrides {
+		fields[k] = v
+	}
+
+	return json.Marshal(fields)
+}
+
+// ContentItem is an item of the content of a message. Fields other than the
+// text, such as documents, are kept as is.
+type ContentItem struct {
+	Type  string
+	Text  string
+	extra map[string]json.RawMessage
+}
+
+func (ci *ContentItem) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if raw, ok := fields["type"]; ok {
+		if err := json.Unmarshal(raw, &ci.Type); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := fields["text"]; ok {
+		if err := json.Unmarshal(raw, &ci.Text); err != nil {
+			return err
+		}
+	}
+
+	delete(fields, "type")
+	delete(fields, "text")
+	ci.extra = fields
+
+	return nil
+}
+
+func (ci ContentItem) MarshalJSON() ([]byte, error) {
+	fields := map[string]any{}
+	for k, v := range ci.extra {
+		fields[k] = v
+	}
+
+	if len(ci.Type) != 0 {
+		fields["type"] = ci.Type
+	}
+
+	if ci.Type == "text" || len(ci.Text) != 0 {
+		fields["text"] = ci.Text
+	}
+
+	return json.Marshal(fields)
+}
+
+// Content is the content of a message which is either a string or a list of
+// content items.
+type Content struct {
+	Text    string
+	Items   []*ContentItem
+	IsItems bool
+}
+
+func (c *Content) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.Text); err == nil {
+		return nil
+	}
+
+	c.IsItems = true
+	return json.Unmarshal(data, &c.Items)
+}
+
+func (c Content) MarshalJSON() ([]byte, error) {
+	if c.IsItems {
+		return json.Marshal(c.Items)
+	}
+
+	return json.Marshal(c.Text)
+}
+
+// Message is a message of a chat request. Fields other than the role and the
+// content, such as tool calls, are kept as is.
+type Message struct {
+	Role    string
+	Content *Content
+	extra   map[string]json.RawMessage
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if raw, ok := fields["role"]; ok {
+		if err := json.Unmarshal(raw, &m.Role); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := fields["content"]; ok && string(raw) != "null" {
+		m.Content = &Content{}
+		if err := json.Unmarshal(raw, m.Content); err != nil {
+			return err
+		}
+	}
+
+	delete(fields, "role")
+	delete(fields, "content")
+	m.extra = fields
+
+	return nil
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	fields := map[string]any{}
+	for k, v := range m.extra {
+		fields[k] = v
+	}
+
+	fields["role"] = m.Role
+	if m.Content != nil {
+		fields["content"] = m.Content
+	}
+
+	return json.Marshal(fields)
+}
+
+func (m *Message) texts() []*string {
+	if m == nil || m.Content == nil {
+		return nil
+	}
+
+	if !m.Content.IsItems {
+		return []*string{&m.Content.Text}
+	}
+
+	texts := []*string{}
+	for _, item := range m.Content.Items {
+		if item != nil && item.Type == "text" {
+			texts = append(texts, &item.Text)
+		}
+	}
+
+	return texts
+}
+
+// ChatRequest is a request of the v2 chat endpoint.
+type ChatRequest struct {
+	Model    string     `json:"model"`
+	Messages []*Message `json:"messages"`
+	Stream   bool       `json:"stream,omitempty"`
+
+	raw rawFields
+}
+
+type chatRequest ChatRequest
+
+func (r *ChatRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.raw); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, (*chatRequest)(r))
+}
+
+func (r ChatRequest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(chatRequest(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.raw.merge(data)
+}
+
+func (r *ChatRequest) texts() []*string {
+	texts := []*string{}
+	for _, m := range r.Messages {
+		texts = append(texts, m.texts()...)
+	}
+
+	return texts
+}
+
+// Texts returns the texts of the messages in order.
+func (r *ChatRequest) Texts() []string {
+	texts := []string{}
+	for _, text := range r.texts() {
+		texts = append(texts, *text)
+	}
+
+	return texts
+}
+
+// SetTexts replaces the texts of the messages in the order returned by Texts.
+func (r *ChatRequest) SetTexts(texts []string) {
+	for i, text := range r.texts() {
+		if i < len(texts) {
+			*text = texts[i]
+		}
+	}
+}
+
+// EmbedRequest is a request of the v2 embed endpoint.
+type EmbedRequest struct {
+	Model     string   `json:"model"`
+	Texts     []string `json:"texts,omitempty"`
+	InputType string   `json:"input_type,omitempty"`
+
+	raw rawFields
+}
+
+type embedRequest EmbedRequest
+
+func (r *EmbedRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.raw); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, (*embedRequest)(r))
+}
+
+func (r EmbedRequest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(embedRequest(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.raw.merge(data)
+}
+
+// RerankRequest is a request of the v2 rerank endpoint.
+type RerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+
+	raw rawFields
+}
+
+type rerankRequest RerankRequest
+
+func (r *RerankRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.raw); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, (*rerankRequest)(r))
+}
+
+func (r RerankRequest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(rerankRequest(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return r.raw.merge(data)
+}
+
+// Texts returns the query followed by the documents.
+func (r *RerankRequest) Texts() []string {
+	return append([]string{r.Query}, r.Documents...)
+}
+
+// SetTexts replaces the query and the documents in the order returned by
+// Texts.
+func (r *RerankRequest) SetTexts(texts []string) {
+	if len(texts) == 0 {
+		return
+	}
+
+	r.Query = texts[0]
+	for i := range r.Documents {
+		if i+1 < len(texts) {
+			r.Documents[i] = texts[i+1]
+		}
+	}
+}
+
+type BilledUnits struct {
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+	SearchUnits  int `json:"search_units,omitempty"`
+}
+
+type Usage struct {
+	BilledUnits *BilledUnits `json:"billed_units,omitempty"`
+	Tokens      *BilledUnits `json:"tokens,omitempty"`
+}
+
+// InputTokens returns the billed input tokens and falls back to the tokens
+// processed by the model.
+func (u *Usage) InputTokens() int {
+	if u == nil {
+		return 0
+	}
+
+	if u.BilledUnits != nil && u.BilledUnits.InputTokens != 0 {
+		return u.BilledUnits.InputTokens
+	}
+
+	if u.Tokens != nil {
+		return u.Tokens.InputTokens
+	}
+
+	return 0
+}
+
+// OutputTokens returns the billed output tokens and falls back to the tokens
+// generated by the model.
+func (u *Usage) OutputTokens() int {
+	if u == nil {
+		return 0
+	}
+
+	if u.BilledUnits != nil && u.BilledUnits.OutputTokens != 0 {
+		return u.BilledUnits.OutputTokens
+	}
+
+	if u.Tokens != nil {
+		return u.Tokens.OutputTokens
+	}
+
+	return 0
+}
+
+type ResponseMessage struct {
+	Role    string         `json:"role"`
+	Content []*ContentItem `json:"content"`
+}
+
+type ChatResponse struct {
+	Id           string           `json:"id"`
+	Message      *ResponseMessage `json:"message"`
+	FinishReason string           `json:"finish_reason"`
+	Usage        *Usage           `json:"usage"`
+}
+
+func (r *ChatResponse) Text() string {
+	if r.Message == nil {
+		return ""
+	}
+
+	texts := []string{}
+	for _, item := range r.Message.Content {
+		if item != nil {
+			texts = append(texts, item.Text)
+		}
+	}
+
+	return strings.Join(texts, "")
+}
+
+type streamDeltaContent struct {
+	Text string `json:"text"`
+}
+
+type streamDeltaMessage struct {
+	Content *streamDeltaContent `json:"content"`
+}
+
+type StreamDelta struct {
+	Message      *streamDeltaMessage `json:"message"`
+	FinishReason string              `json:"finish_reason,omitempty"`
+	Usage        *Usage              `json:"usage,omitempty"`
+}
+
+// StreamEvent is an event of a streamed chat response. Texts are sent in
+// content-delta events and the usage is sent in the message-end event.
+type StreamEvent struct {
+	Type  string       `json:"type"`
+	Delta *StreamDelta `json:"delta"`
+}
+
+func (e *StreamEvent) Text() string {
+	if e.Type != "content-delta" || e.Delta == nil || e.Delta.Message == nil || e.Delta.Message.Content == nil {
+		return ""
+	}
+
+	return e.Delta.Message.Content.Text
+}
+
+type Meta struct {
+	BilledUnits *BilledUnits `json:"billed_units,omitempty"`
+}
+
+type EmbedResponse struct {
+	Id   string `json:"id"`
+	Meta *Meta  `json:"meta"`
+}
+
+type RerankResult struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
+type RerankResponse struct {
+	Id      string          `json:"id"`
+	Results []*RerankResult `json:"results"`
+	Meta    *Meta           `json:"meta"`
+}
@@ -0,0 +1,144 @@
+package cohere
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CoherePerMillionTokenCost maps chat and embed models to their cost per
+// million tokens.
+// updated according to this link:
+// https://cohere.com/pricing
+var CoherePerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"command-r-plus-08-2024": 2.5,
+		"command-r-plus":         3,
+		"command-r-08-2024":      0.15,
+		"command-r":              0.5,
+		"command-r7b-12-2024":    0.0375,
+		"command":                1,
+		"command-light":          0.3,
+	},
+	"completion": {
+		"command-r-plus-08-2024": 10,
+		"command-r-plus":         15,
+		"command-r-08-2024":      0.6,
+		"command-r":              1.5,
+		"command-r7b-12-2024":    0.15,
+		"command":                2,
+		"command-light":          0.6,
+	},
+	"embeddings": {
+		"embed-english-v3.0":            0.1,
+		"embed-multilingual-v3.0":       0.1,
+		"embed-english-light-v3.0":      0.1,
+		"embed-multilingual-light-v3.0": 0.1,
+	},
+}
+
+// CoherePerThousandSearchCost maps rerank models to their cost per thousand
+// search units. A search unit is a query with up to 100 documents.
+var CoherePerThousandSearchCost = map[string]float64{
+	"rerank-v3.5":              2,
+	"rerank-english-v3.0":      2,
+	"rerank-multilingual-v3.0": 2,
+}
+
+// documentsPerSearchUnit is the number of documents billed as one search
+// unit.
+const documentsPerSearchUnit = 100
+
+type tokenCounter interface {
+	Count(input string) int
+}
+
+type CostEstimator struct {
+	tokenCostMap  map[string]map[string]float64
+	searchCostMap map[string]float64
+	tc            tokenCounter
+}
+
+func NewCostEstimator(tc tokenCounter) *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap:  CoherePerMillionTokenCost,
+		searchCostMap: CoherePerThousandSearchCost,
+		tc:            tc,
+	}
+}
+
+func (ce *CostEstimator) getCost(kind, model string) (float64, error) {
+	costMap, ok := ce.tokenCostMap[kind]
+	if !ok {
+		return 0, errors.New(kind + " token cost is not provided")
+	}
+
+	cost, ok := costMap[strings.ToLower(model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return cost, nil
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	promptCost, err := ce.getCost("prompt", model)
+	if err != nil {
+		return 0, err
+	}
+
+	completionCost, err := ce.getCost("completion", model)
+	if err != nil {
+		return 0, err
+	}
+
+	return (float64(promptTks)*promptCost + float64(completionTks)*completionCost) / 1000000, nil
+}
+
+func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (float64, error) {
+	cost, err := ce.getCost("embeddings", model)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(tks) * cost / 1000000, nil
+}
+
+func (ce *CostEstimator) EstimateRerankCost(model string, searchUnits int) (float64, error) {
+	cost, ok := ce.searchCostMap[strings.ToLower(model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(searchUnits) * cost / 1000, nil
+}
+
+// EstimateSearchUnits approximates the search units of a rerank request for
+// responses that do not report them.
+func (ce *CostEstimator) EstimateSearchUnits(r *RerankRequest) int {
+	if r == nil {
+		return 0
+	}
+
+	units := (len(r.Documents) + documentsPerSearchUnit - 1) / documentsPerSearchUnit
+	if units == 0 {
+		return 1
+	}
+
+	return units
+}
+
+// CountTexts approximates the tokens of texts such as the messages of a chat
+// request or the query and documents of a rerank request.
+func (ce *CostEstimator) CountTexts(texts []string) int {
+	tks := 0
+	for _, text := range texts {
+		tks += ce.tc.Count(text)
+	}
+
+	return tks
+}
+
+func (ce *CostEstimator) Count(input string) int {
+	return ce.tc.Count(input)
+}
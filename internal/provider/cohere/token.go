@@ -0,0 +1,27 @@
+package cohere
+
+import (
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// TokenCounter approximates Cohere token counts for responses that do not
+// report usage. Texts are encoded with cl100k_base since Cohere tokenizers
+// are not available to the gateway.
+type TokenCounter struct {
+	encoder *tiktoken.Tiktoken
+}
+
+func NewTokenCounter() (*TokenCounter, error) {
+	encoder, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenCounter{
+		encoder: encoder,
+	}, nil
+}
+
+func (tc *TokenCounter) Count(input string) int {
+	return len(tc.encoder.Encode(input, nil, nil))
+}
@@ -0,0 +1,19 @@
+package compatible
+
+import (
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// ChatCompletionResponse is a chat completion of a provider reporting usage
+// in its own format U.
+type ChatCompletionResponse[U any] struct {
+	goopenai.ChatCompletionResponse
+	Usage *U `json:"usage,omitempty"`
+}
+
+// ChatCompletionStreamResponse is a chunk of a streamed chat completion of a
+// provider reporting usage in its own format U in the last chunk.
+type ChatCompletionStreamResponse[U any] struct {
+	goopenai.ChatCompletionStreamResponse
+	Usage *U `json:"usage,omitempty"`
+}
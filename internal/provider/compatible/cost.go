@@ -0,0 +1,87 @@
+package compatible
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Counter counts the tokens of a text.
+type Counter interface {
+	Count(input string) int
+}
+
+// CostEstimator prices requests to OpenAI compatible providers with a cost
+// map of USD per million tokens keyed by kind, such as prompt or completion,
+// and model.
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+	selectModel  func(model string) string
+	tc           Counter
+}
+
+// NewCostEstimator creates an estimator pricing models with the cost map.
+// Models are looked up by their lower case names unless selectModel is set.
+func NewCostEstimator(m map[string]map[string]float64, selectModel func(model string) string, tc Counter) *CostEstimator {
+	if selectModel == nil {
+		selectModel = strings.ToLower
+	}
+
+	return &CostEstimator{
+		tokenCostMap: m,
+		selectModel:  selectModel,
+		tc:           tc,
+	}
+}
+
+// GetCost returns the cost per million tokens of a kind of a model.
+func (ce *CostEstimator) GetCost(kind, model string) (float64, error) {
+	costMap, ok := ce.tokenCostMap[kind]
+	if !ok {
+		return 0, errors.New(kind + " token cost is not provided")
+	}
+
+	cost, ok := costMap[ce.selectModel(model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return cost, nil
+}
+
+// GetCostOr returns the cost per million tokens of a kind of a model, or the
+// cost of the fallback kind if the model is not priced for the kind.
+func (ce *CostEstimator) GetCostOr(kind, fallback, model string) (float64, error) {
+	if cost, err := ce.GetCost(kind, model); err == nil {
+		return cost, nil
+	}
+
+	return ce.GetCost(fallback, model)
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	promptCost, err := ce.GetCost("prompt", model)
+	if err != nil {
+		return 0, err
+	}
+
+	completionCost, err := ce.GetCost("completion", model)
+	if err != nil {
+		return 0, err
+	}
+
+	return (float64(promptTks)*promptCost + float64(completionTks)*completionCost) / 1000000, nil
+}
+
+func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (float64, error) {
+	cost, err := ce.GetCost("embeddings", model)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(tks) * cost / 1000000, nil
+}
+
+func (ce *CostEstimator) Count(input string) int {
+	return ce.tc.Count(input)
+}
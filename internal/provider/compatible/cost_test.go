@@ -0,0 +1,102 @@
+package compatible
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostEstimator(t *testing.T) {
+	costMap := map[string]map[string]float64{
+		"prompt":        {"model-a": 1, "model-b": 2},
+		"completion":    {"model-a": 4, "model-b": 8},
+		"cached_prompt": {"model-a": 0.5},
+		"embeddings":    {"embed": 0.1},
+	}
+
+	withoutSuffix := func(model string) string {
+		return strings.TrimSuffix(strings.ToLower(model), "-latest")
+	}
+
+	tests := []struct {
+		name        string
+		selectModel func(model string) string
+		estimate    func(ce *CostEstimator) (float64, error)
+		want        float64
+		shouldErr   bool
+	}{
+		{
+			name: "total cost",
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.EstimateTotalCost("model-a", 1000000, 500000)
+			},
+			want: 3,
+		},
+		{
+			name: "model names are case insensitive",
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.EstimateTotalCost("Model-B", 1000000, 1000000)
+			},
+			want: 10,
+		},
+		{
+			name:        "model selector",
+			selectModel: withoutSuffix,
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.EstimateTotalCost("model-a-latest", 1000000, 0)
+			},
+			want: 1,
+		},
+		{
+			name: "unknown model",
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.EstimateTotalCost("model-c", 1, 1)
+			},
+			shouldErr: true,
+		},
+		{
+			name: "embeddings",
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.EstimateEmbeddingsInputCost("embed", 1000000)
+			},
+			want: 0.1,
+		},
+		{
+			name: "missing kind",
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.GetCost("reasoning", "model-a")
+			},
+			shouldErr: true,
+		},
+		{
+			name: "priced kind",
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.GetCostOr("cached_prompt", "prompt", "model-a")
+			},
+			want: 0.5,
+		},
+		{
+			name: "fallback kind",
+			estimate: func(ce *CostEstimator) (float64, error) {
+				return ce.GetCostOr("cached_prompt", "prompt", "model-b")
+			},
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := NewCostEstimator(costMap, tt.selectModel, nil)
+
+			cost, err := tt.estimate(ce)
+			if tt.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want, cost, 1e-9)
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package custom
 
+import (
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
+)
+
 type Provider struct {
 	Id                  string         `json:"id"`
 	CreatedAt           int64          `json:"created_at"`
@@ -7,6 +12,7 @@ type Provider struct {
 	Provider            string         `json:"provider"`
 	RouteConfigs        []*RouteConfig `json:"route_configs"`
 	AuthenticationParam string         `json:"authentication_param"`
+	Auth                *signer.Config `json:"auth,omitempty"`
 }
 
 type RouteConfig struct {
@@ -19,10 +25,30 @@ type RouteConfig struct {
 	StreamEndWord                    string `json:"stream_end_word"`
 	StreamResponseCompletionLocation string `json:"stream_response_completion_location"`
 	StreamMaxEmptyMessages           int    `json:"stream_max_empty_messages"`
+	// RequestTemplate and ResponseTemplate are Go templates executed over
+	// the decoded JSON bodies of requests and non streaming responses. They
+	// adapt the API of the gateway to upstreams with other schemas, such as
+	// SageMaker inference endpoints.
+	RequestTemplate  string `json:"request_template,omitempty"`
+	ResponseTemplate string `json:"response_template,omitempty"`
+	// ErrorTemplates customizes gateway generated errors of the route keyed
+	// by error type.
+	ErrorTemplates map[string]*errortemplate.Template `json:"error_templates,omitempty"`
+}
+
+// GetErrorTemplate returns the error template configured for an error type.
+// Nil is returned if the route config does not customize that error.
+func (rc *RouteConfig) GetErrorTemplate(errType string) *errortemplate.Template {
+	if rc == nil || rc.ErrorTemplates == nil {
+		return nil
+	}
+
+	return rc.ErrorTemplates[errType]
 }
 
 type UpdateProvider struct {
 	UpdatedAt           int64          `json:"updated_at"`
 	RouteConfigs        []*RouteConfig `json:"route_configs"`
 	AuthenticationParam *string        `json:"authentication_param"`
+	Auth                *signer.Config `json:"auth"`
 }
@@ -0,0 +1,84 @@
+package custom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		return string(data), nil
+	},
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// ValidateTemplates returns the names of the templates of a route config that
+// cannot be parsed.
+func (rc *RouteConfig) ValidateTemplates() []string {
+	invalid := []string{}
+	if len(rc.RequestTemplate) != 0 {
+		if _, err := parseTemplate("request_template", rc.RequestTemplate); err != nil {
+			invalid = append(invalid, "request_template")
+		}
+	}
+
+	if len(rc.ResponseTemplate) != 0 {
+		if _, err := parseTemplate("response_template", rc.ResponseTemplate); err != nil {
+			invalid = append(invalid, "response_template")
+		}
+	}
+
+	return invalid
+}
+
+// RenderRequest transforms the body of a request with the request template.
+// The body is returned unchanged if the route config has no request template.
+func (rc *RouteConfig) RenderRequest(body []byte) ([]byte, error) {
+	return render("request_template", rc.RequestTemplate, body)
+}
+
+// RenderResponse transforms the body of a response with the response template.
+// The body is returned unchanged if the route config has no response template.
+func (rc *RouteConfig) RenderResponse(body []byte) ([]byte, error) {
+	return render("response_template", rc.ResponseTemplate, body)
+}
+
+// render executes a template over the decoded JSON body. The output must be
+// valid JSON.
+func render(name, text string, body []byte) ([]byte, error) {
+	if len(text) == 0 {
+		return body, nil
+	}
+
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return nil, err
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+
+	rendered := buf.Bytes()
+	if !json.Valid(rendered) {
+		return nil, fmt.Errorf("%s did not render valid json", name)
+	}
+
+	return rendered, nil
+}
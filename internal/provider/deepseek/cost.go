@@ -0,0 +1,86 @@
+package deepseek
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+)
+
+// DeepseekPerMillionTokenCost maps models to their cost per million tokens.
+// Cached prompt tokens are tokens read from the context cache and reasoning
+// tokens are the chain of thought tokens of reasoner models.
+// updated according to this link:
+// https://api-docs.deepseek.com/quick_start/pricing
+var DeepseekPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"deepseek-chat":     0.27,
+		"deepseek-reasoner": 0.55,
+	},
+	"cached_prompt": {
+		"deepseek-chat":     0.07,
+		"deepseek-reasoner": 0.14,
+	},
+	"completion": {
+		"deepseek-chat":     1.1,
+		"deepseek-reasoner": 2.19,
+	},
+	"reasoning": {
+		"deepseek-reasoner": 2.19,
+	},
+}
+
+// CostEstimator prices DeepSeek models with the cached prompt and reasoning
+// prices on top of the prices of OpenAI compatible providers.
+type CostEstimator struct {
+	*compatible.CostEstimator
+}
+
+func NewCostEstimator(tc compatible.Counter) *CostEstimator {
+	return &CostEstimator{
+		CostEstimator: compatible.NewCostEstimator(DeepseekPerMillionTokenCost, nil, tc),
+	}
+}
+
+// EstimateUsageCost estimates the cost of a request from its usage. Prompt
+// tokens read from the context cache and reasoning tokens are priced
+// separately from the rest of the prompt and completion tokens. Models
+// without a cached prompt or reasoning price are charged the prompt or
+// completion price.
+func (ce *CostEstimator) EstimateUsageCost(model string, usage *Usage) (float64, error) {
+	if usage == nil {
+		return 0, nil
+	}
+
+	promptCost, err := ce.GetCost("prompt", model)
+	if err != nil {
+		return 0, err
+	}
+
+	completionCost, err := ce.GetCost("completion", model)
+	if err != nil {
+		return 0, err
+	}
+
+	cachedPromptCost, err := ce.GetCostOr("cached_prompt", "prompt", model)
+	if err != nil {
+		return 0, err
+	}
+
+	reasoningCost, err := ce.GetCostOr("reasoning", "completion", model)
+	if err != nil {
+		return 0, err
+	}
+
+	cached := usage.PromptCacheHitTokens
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+
+	reasoning := usage.ReasoningTokens()
+	if reasoning > usage.CompletionTokens {
+		reasoning = usage.CompletionTokens
+	}
+
+	cost := float64(usage.PromptTokens-cached)*promptCost + float64(cached)*cachedPromptCost
+	cost += float64(usage.CompletionTokens-reasoning)*completionCost + float64(reasoning)*reasoningCost
+
+	return cost / 1000000, nil
+}
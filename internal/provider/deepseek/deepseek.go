@@ -0,0 +1,39 @@
+package deepseek
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+)
+
+const ChatCompletionsUrl = "https://api.deepseek.com/chat/completions"
+
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// Usage reports token usage of a chat completions request. Completion tokens
+// include the reasoning tokens of reasoner models and prompt tokens include
+// the tokens read from the context cache.
+type Usage struct {
+	PromptTokens            int                      `json:"prompt_tokens"`
+	CompletionTokens        int                      `json:"completion_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	PromptCacheHitTokens    int                      `json:"prompt_cache_hit_tokens"`
+	PromptCacheMissTokens   int                      `json:"prompt_cache_miss_tokens"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// ReasoningTokens returns the reasoning tokens included in the completion
+// tokens.
+func (u *Usage) ReasoningTokens() int {
+	if u == nil || u.CompletionTokensDetails == nil {
+		return 0
+	}
+
+	return u.CompletionTokensDetails.ReasoningTokens
+}
+
+type ChatCompletionResponse = compatible.ChatCompletionResponse[Usage]
+
+// ChatCompletionStreamResponse is a chunk of a streamed chat completion.
+// DeepSeek reports the usage of the request in the last chunk.
+type ChatCompletionStreamResponse = compatible.ChatCompletionStreamResponse[Usage]
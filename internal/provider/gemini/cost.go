@@ -0,0 +1,167 @@
+package gemini
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// longContextThreshold is the number of prompt tokens above which Gemini 1.5
+// models are billed at their long context rates.
+const longContextThreshold = 128000
+
+// GeminiPerMillionTokenCost maps models to their cost per million tokens.
+// Long context costs apply to the whole request once the prompt exceeds
+// 128k tokens. Cached content tokens are billed at the cached rates.
+var GeminiPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"gemini-1.5-pro":      1.25,
+		"gemini-1.5-flash":    0.075,
+		"gemini-1.5-flash-8b": 0.0375,
+		"gemini-1.0-pro":      0.5,
+	},
+	"prompt-long": {
+		"gemini-1.5-pro":      2.5,
+		"gemini-1.5-flash":    0.15,
+		"gemini-1.5-flash-8b": 0.075,
+	},
+	"completion": {
+		"gemini-1.5-pro":      5,
+		"gemini-1.5-flash":    0.3,
+		"gemini-1.5-flash-8b": 0.15,
+		"gemini-1.0-pro":      1.5,
+	},
+	"completion-long": {
+		"gemini-1.5-pro":      10,
+		"gemini-1.5-flash":    0.6,
+		"gemini-1.5-flash-8b": 0.3,
+	},
+	"cached": {
+		"gemini-1.5-pro":      0.3125,
+		"gemini-1.5-flash":    0.01875,
+		"gemini-1.5-flash-8b": 0.01,
+	},
+	"cached-long": {
+		"gemini-1.5-pro":      0.625,
+		"gemini-1.5-flash":    0.0375,
+		"gemini-1.5-flash-8b": 0.02,
+	},
+}
+
+type tokenCounter interface {
+	Count(input string) int
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+	tc           tokenCounter
+}
+
+func NewCostEstimator(tc tokenCounter) *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: GeminiPerMillionTokenCost,
+		tc:           tc,
+	}
+}
+
+// selectModel maps versioned model names such as gemini-1.5-pro-002 or
+// models/gemini-1.5-flash-latest to the model family used for pricing.
+func selectModel(model string) string {
+	lowerCased := strings.TrimPrefix(strings.ToLower(model), "models/")
+
+	families := []string{"gemini-1.5-flash-8b", "gemini-1.5-pro", "gemini-1.5-flash", "gemini-1.0-pro"}
+	for _, family := range families {
+		if strings.HasPrefix(lowerCased, family) {
+			return family
+		}
+	}
+
+	if lowerCased == "gemini-pro" {
+		return "gemini-1.0-pro"
+	}
+
+	return lowerCased
+}
+
+func (ce *CostEstimator) getCost(kind, model string, promptTks int) (float64, error) {
+	selected := selectModel(model)
+
+	if promptTks > longContextThreshold {
+		if cost, ok := ce.tokenCostMap[kind+"-long"][selected]; ok {
+			return cost, nil
+		}
+	}
+
+	costMap, ok := ce.tokenCostMap[kind]
+	if !ok {
+		return 0, errors.New(kind + " token cost is not provided")
+	}
+
+	cost, ok := costMap[selected]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return cost, nil
+}
+
+// EstimateTotalCost returns the cost of a request. promptTks includes
+// cachedTks as reported in the usage metadata of Gemini responses.
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks, cachedTks int) (float64, error) {
+	promptCost, err := ce.getCost("prompt", model, promptTks)
+	if err != nil {
+		return 0, err
+	}
+
+	completionCost, err := ce.getCost("completion", model, promptTks)
+	if err != nil {
+		return 0, err
+	}
+
+	if cachedTks > promptTks {
+		cachedTks = promptTks
+	}
+
+	cachedCost := promptCost
+	if cachedTks != 0 {
+		// models without context caching are billed at the prompt rate.
+		if cost, err := ce.getCost("cached", model, promptTks); err == nil {
+			cachedCost = cost
+		}
+	}
+
+	total := float64(promptTks-cachedTks)*promptCost + float64(cachedTks)*cachedCost + float64(completionTks)*completionCost
+	return total / 1000000, nil
+}
+
+// EstimatePromptCost returns the cost of prompt tokens without caching.
+func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, error) {
+	cost, err := ce.getCost("prompt", model, tks)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(tks) / 1000000 * cost, nil
+}
+
+func (ce *CostEstimator) Count(input string) int {
+	return ce.tc.Count(input)
+}
+
+var geminiContentOverhead = 3
+
+// CountRequestTokens estimates the prompt tokens of a generate content
+// request.
+func (ce *CostEstimator) CountRequestTokens(r *GenerateContentRequest) int {
+	count := 0
+	for _, c := range r.contents() {
+		count += geminiContentOverhead
+		for _, part := range c.Parts {
+			if part != nil {
+				count += ce.tc.Count(part.Text)
+			}
+		}
+	}
+
+	return count
+}
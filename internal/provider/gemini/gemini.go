@@ -0,0 +1,255 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	ActionGenerateContent       = "generateContent"
+	ActionStreamGenerateContent = "streamGenerateContent"
+	ActionCountTokens           = "countTokens"
+)
+
+const endpoint = "https://generativelanguage.googleapis.com"
+
+var supportedActions = map[string]bool{
+	ActionGenerateContent:       true,
+	ActionStreamGenerateContent: true,
+	ActionCountTokens:           true,
+}
+
+// SplitModelAction splits the last path segment of a model request, such as
+// gemini-1.5-pro:generateContent, into the model and the method invoked on
+// it. False is returned if the segment does not name a method.
+func SplitModelAction(segment string) (string, string, bool) {
+	idx := strings.LastIndex(segment, ":")
+	if idx <= 0 || idx == len(segment)-1 {
+		return "", "", false
+	}
+
+	return segment[:idx], segment[idx+1:], true
+}
+
+// ParseModelAction splits the last path segment of a Gemini model request
+// into the model and a supported method invoked on it.
+func ParseModelAction(segment string) (string, string, error) {
+	model, action, ok := SplitModelAction(segment)
+	if !ok {
+		return "", "", fmt.Errorf("%s is not a valid gemini model method", segment)
+	}
+
+	if !supportedActions[action] {
+		return "", "", fmt.Errorf("gemini method %s is not supported", action)
+	}
+
+	return model, action, nil
+}
+
+func IsStreamingAction(action string) bool {
+	return action == ActionStreamGenerateContent
+}
+
+// GetModelUrl builds the url of a Gemini model method. A custom endpoint such
+// as a regional proxy takes precedence over the public endpoint.
+func GetModelUrl(customEndpoint, version, model, action string) string {
+	base := endpoint
+	if len(customEndpoint) != 0 {
+		base = strings.TrimSuffix(customEndpoint, "/")
+	}
+
+	return fmt.Sprintf("%s/%s/models/%s:%s", base, version, model, action)
+}
+
+// Part is a part of the content of a turn. Fields other than the text, such
+// as inline data and function calls, are kept as is so that a request can be
+// forwarded after being modified.
+type Part struct {
+	Text  string
+	extra map[string]json.RawMessage
+}
+
+func (p *Part) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if raw, ok := fields["text"]; ok {
+		if err := json.Unmarshal(raw, &p.Text); err != nil {
+			return err
+		}
+	}
+
+	delete(fields, "text")
+	p.extra = fields
+
+	return nil
+}
+
+func (p Part) MarshalJSON() ([]byte, error) {
+	fields := map[string]any{}
+	for k, v := range p.extra {
+		fields[k] = v
+	}
+
+	if len(p.Text) != 0 || len(fields) == 0 {
+		fields["text"] = p.Text
+	}
+
+	return json.Marshal(fields)
+}
+
+type Content struct {
+	Role  string  `json:"role,omitempty"`
+	Parts []*Part `json:"parts"`
+}
+
+// GenerateContentRequest is a request of the generateContent and
+// streamGenerateContent methods. Fields that are not declared, such as the
+// generation config and tools, are kept so that a request can be forwarded
+// after being modified.
+type GenerateContentRequest struct {
+	Contents          []*Content `json:"contents"`
+	SystemInstruction *Content   `json:"systemInstruction,omitempty"`
+
+	raw map[string]json.RawMessage
+}
+
+type generateContentRequest GenerateContentRequest
+
+func (r *GenerateContentRequest) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	// the REST api also accepts snake cased field names.
+	if instruction, ok := raw["system_instruction"]; ok {
+		raw["systemInstruction"] = instruction
+		delete(raw, "system_instruction")
+
+		normalized, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+
+		data = normalized
+	}
+
+	if err := json.Unmarshal(data, (*generateContentRequest)(r)); err != nil {
+		return err
+	}
+
+	r.raw = raw
+	return nil
+}
+
+func (r GenerateContentRequest) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(generateContentRequest(r))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.raw) == 0 {
+		return data, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	for k, v := range r.raw {
+		fields[k] = v
+	}
+
+	declared := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &declared); err != nil {
+		return nil, err
+	}
+
+	for k, v := range declared {
+		fields[k] = v
+	}
+
+	return json.Marshal(fields)
+}
+
+func (r *GenerateContentRequest) contents() []*Content {
+	contents := []*Content{}
+	if r.SystemInstruction != nil {
+		contents = append(contents, r.SystemInstruction)
+	}
+
+	for _, c := range r.Contents {
+		if c != nil {
+			contents = append(contents, c)
+		}
+	}
+
+	return contents
+}
+
+// Texts returns the texts of the system instruction and of the contents in
+// order.
+func (r *GenerateContentRequest) Texts() []string {
+	texts := []string{}
+	for _, c := range r.contents() {
+		for _, part := range c.Parts {
+			if part != nil && len(part.Text) != 0 {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+
+	return texts
+}
+
+// SetTexts replaces the texts of the system instruction and of the contents in
+// the order returned by Texts.
+func (r *GenerateContentRequest) SetTexts(texts []string) {
+	for _, c := range r.contents() {
+		for _, part := range c.Parts {
+			if part != nil && len(part.Text) != 0 && len(texts) != 0 {
+				part.Text = texts[0]
+				texts = texts[1:]
+			}
+		}
+	}
+}
+
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+type Candidate struct {
+	Index        int      `json:"index"`
+	Content      *Content `json:"content"`
+	FinishReason string   `json:"finishReason,omitempty"`
+}
+
+// GenerateContentResponse is a response of the generateContent method or a
+// chunk of the streamGenerateContent method.
+type GenerateContentResponse struct {
+	Candidates    []*Candidate   `json:"candidates"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata"`
+	ModelVersion  string         `json:"modelVersion,omitempty"`
+}
+
+func (r *GenerateContentResponse) Text() string {
+	texts := []string{}
+	for _, candidate := range r.Candidates {
+		if candidate == nil || candidate.Content == nil {
+			continue
+		}
+
+		for _, part := range candidate.Content.Parts {
+			if part != nil {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+
+	return strings.Join(texts, "")
+}
@@ -0,0 +1,28 @@
+package gemini
+
+import (
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// TokenCounter approximates Gemini token counts. Gemini tokenizers are not
+// published, so texts are encoded with cl100k_base whose counts are close to
+// Gemini's for English text. Counts reported in the usage metadata of
+// responses are always preferred.
+type TokenCounter struct {
+	encoder *tiktoken.Tiktoken
+}
+
+func NewTokenCounter() (*TokenCounter, error) {
+	encoder, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenCounter{
+		encoder: encoder,
+	}, nil
+}
+
+func (tc *TokenCounter) Count(input string) int {
+	return len(tc.encoder.Encode(input, nil, nil))
+}
@@ -0,0 +1,38 @@
+package groq
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+)
+
+// GroqPerMillionTokenCost maps models to their cost per million tokens.
+// updated according to this link:
+// https://groq.com/pricing
+var GroqPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"llama-3.3-70b-versatile": 0.59,
+		"llama-3.3-70b-specdec":   0.59,
+		"llama-3.1-70b-versatile": 0.59,
+		"llama-3.1-8b-instant":    0.05,
+		"llama3-70b-8192":         0.59,
+		"llama3-8b-8192":          0.05,
+		"llama-guard-3-8b":        0.2,
+		"mixtral-8x7b-32768":      0.24,
+		"gemma2-9b-it":            0.2,
+	},
+	"completion": {
+		"llama-3.3-70b-versatile": 0.79,
+		"llama-3.3-70b-specdec":   0.99,
+		"llama-3.1-70b-versatile": 0.79,
+		"llama-3.1-8b-instant":    0.08,
+		"llama3-70b-8192":         0.79,
+		"llama3-8b-8192":          0.08,
+		"llama-guard-3-8b":        0.2,
+		"mixtral-8x7b-32768":      0.24,
+		"gemma2-9b-it":            0.2,
+	},
+}
+
+// NewCostEstimator creates an estimator pricing Groq models.
+func NewCostEstimator(tc compatible.Counter) *compatible.CostEstimator {
+	return compatible.NewCostEstimator(GroqPerMillionTokenCost, nil, tc)
+}
@@ -0,0 +1,29 @@
+package groq
+
+import (
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const ChatCompletionsUrl = "https://api.groq.com/openai/v1/chat/completions"
+
+// ChatCompletionStreamResponse is a chunk of a streamed chat completion.
+// Groq reports the usage of the request in the x_groq field of the last
+// chunk.
+type ChatCompletionStreamResponse struct {
+	goopenai.ChatCompletionStreamResponse
+	XGroq *XGroq `json:"x_groq,omitempty"`
+}
+
+type XGroq struct {
+	Id    string          `json:"id"`
+	Usage *goopenai.Usage `json:"usage,omitempty"`
+}
+
+// GetUsage returns the usage of the chunk if it is present.
+func (r *ChatCompletionStreamResponse) GetUsage() *goopenai.Usage {
+	if r.XGroq != nil && r.XGroq.Usage != nil {
+		return r.XGroq.Usage
+	}
+
+	return r.Usage
+}
@@ -0,0 +1,69 @@
+package jina
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// JinaPerMillionTokenCost maps embedding models to their cost per million
+// tokens.
+// updated according to this link:
+// https://jina.ai/embeddings/#pricing
+var JinaPerMillionTokenCost = map[string]map[string]float64{
+	"embeddings": {
+		"jina-embeddings-v4":           0.05,
+		"jina-embeddings-v3":           0.05,
+		"jina-clip-v2":                 0.05,
+		"jina-clip-v1":                 0.05,
+		"jina-colbert-v2":              0.05,
+		"jina-embeddings-v2-base-en":   0.02,
+		"jina-embeddings-v2-base-de":   0.02,
+		"jina-embeddings-v2-base-es":   0.02,
+		"jina-embeddings-v2-base-zh":   0.02,
+		"jina-embeddings-v2-base-code": 0.02,
+	},
+}
+
+type tokenCounter interface {
+	Count(input string) int
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+	tc           tokenCounter
+}
+
+func NewCostEstimator(tc tokenCounter) *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: JinaPerMillionTokenCost,
+		tc:           tc,
+	}
+}
+
+func (ce *CostEstimator) getCost(kind, model string) (float64, error) {
+	costMap, ok := ce.tokenCostMap[kind]
+	if !ok {
+		return 0, errors.New(kind + " token cost is not provided")
+	}
+
+	cost, ok := costMap[strings.ToLower(model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return cost, nil
+}
+
+func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (float64, error) {
+	cost, err := ce.getCost("embeddings", model)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(tks) * cost / 1000000, nil
+}
+
+func (ce *CostEstimator) Count(input string) int {
+	return ce.tc.Count(input)
+}
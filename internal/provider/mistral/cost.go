@@ -0,0 +1,59 @@
+package mistral
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+)
+
+// MistralPerMillionTokenCost maps models to their cost per million tokens.
+// updated according to this link:
+// https://mistral.ai/technology/#pricing
+var MistralPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"mistral-large":        2,
+		"mistral-small":        0.2,
+		"codestral":            0.2,
+		"pixtral-large":        2,
+		"pixtral-12b":          0.15,
+		"ministral-8b":         0.1,
+		"ministral-3b":         0.04,
+		"open-mistral-nemo":    0.15,
+		"open-mistral-7b":      0.25,
+		"open-mixtral-8x7b":    0.7,
+		"open-mixtral-8x22b":   2,
+		"open-codestral-mamba": 0.25,
+	},
+	"completion": {
+		"mistral-large":        6,
+		"mistral-small":        0.6,
+		"codestral":            0.6,
+		"pixtral-large":        6,
+		"pixtral-12b":          0.15,
+		"ministral-8b":         0.1,
+		"ministral-3b":         0.04,
+		"open-mistral-nemo":    0.15,
+		"open-mistral-7b":      0.25,
+		"open-mixtral-8x7b":    0.7,
+		"open-mixtral-8x22b":   6,
+		"open-codestral-mamba": 0.25,
+	},
+	"embeddings": {
+		"mistral-embed": 0.1,
+	},
+}
+
+// versionSuffix matches the -latest and dated suffixes of model names such as
+// mistral-large-2407.
+var versionSuffix = regexp.MustCompile(`-(latest|\d{4})$`)
+
+// NewCostEstimator creates an estimator pricing Mistral models by their names
+// without version suffixes.
+func NewCostEstimator(tc compatible.Counter) *compatible.CostEstimator {
+	return compatible.NewCostEstimator(MistralPerMillionTokenCost, selectModel, tc)
+}
+
+func selectModel(model string) string {
+	return versionSuffix.ReplaceAllString(strings.ToLower(model), "")
+}
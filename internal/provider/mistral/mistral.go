@@ -0,0 +1,18 @@
+package mistral
+
+import (
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	ChatCompletionsUrl = "https://api.mistral.ai/v1/chat/completions"
+	EmbeddingsUrl      = "https://api.mistral.ai/v1/embeddings"
+)
+
+// ChatRequest is an OpenAI compatible chat completion request with the
+// parameters that are specific to Mistral.
+type ChatRequest struct {
+	goopenai.ChatCompletionRequest
+	SafePrompt bool `json:"safe_prompt,omitempty"`
+	RandomSeed *int `json:"random_seed,omitempty"`
+}
@@ -0,0 +1,76 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// BatchOutputLine is a line of the output file of a batch.
+type BatchOutputLine struct {
+	Id       string               `json:"id"`
+	CustomId string               `json:"custom_id"`
+	Response *BatchOutputResponse `json:"response"`
+}
+
+type BatchOutputResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestId  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// BatchOutputBody holds the fields of the responses of chat completions,
+// completions and embeddings requests needed to price them.
+type BatchOutputBody struct {
+	Model string         `json:"model"`
+	Usage goopenai.Usage `json:"usage"`
+}
+
+// BatchUsage is the token usage of the successful requests of a batch by
+// model.
+type BatchUsage struct {
+	Requests int
+	Models   map[string]*goopenai.Usage
+}
+
+// ParseBatchOutput sums the token usage reported in the output file of a
+// batch. Lines that cannot be parsed or did not succeed are skipped.
+func ParseBatchOutput(data []byte) *BatchUsage {
+	usage := &BatchUsage{
+		Models: map[string]*goopenai.Usage{},
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	for scanner.Scan() {
+		line := &BatchOutputLine{}
+		if err := json.Unmarshal(scanner.Bytes(), line); err != nil {
+			continue
+		}
+
+		if line.Response == nil || line.Response.StatusCode != 200 {
+			continue
+		}
+
+		body := &BatchOutputBody{}
+		if err := json.Unmarshal(line.Response.Body, body); err != nil || len(body.Model) == 0 {
+			continue
+		}
+
+		u, ok := usage.Models[body.Model]
+		if !ok {
+			u = &goopenai.Usage{}
+			usage.Models[body.Model] = u
+		}
+
+		u.PromptTokens += body.Usage.PromptTokens
+		u.CompletionTokens += body.Usage.CompletionTokens
+		u.TotalTokens += body.Usage.TotalTokens
+		usage.Requests++
+	}
+
+	return usage
+}
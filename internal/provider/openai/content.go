@@ -0,0 +1,106 @@
+package openai
+
+// contentTexts returns the texts of the content of a message, which is either
+// a string or a list of content parts.
+func contentTexts(content any) []string {
+	if text, ok := content.(string); ok {
+		return []string{text}
+	}
+
+	texts := []string{}
+	if parts, ok := content.([]any); ok {
+		for _, part := range parts {
+			if textPart, ok := part.(map[string]any); ok {
+				if text, ok := textPart["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+	}
+
+	return texts
+}
+
+// setContentTexts replaces the texts of a content in the order returned by
+// contentTexts. It returns the updated content and the number of texts used.
+func setContentTexts(content any, texts []string) (any, int) {
+	if _, ok := content.(string); ok {
+		if len(texts) == 0 {
+			return content, 0
+		}
+
+		return texts[0], 1
+	}
+
+	used := 0
+	if parts, ok := content.([]any); ok {
+		for _, part := range parts {
+			textPart, ok := part.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if _, ok := textPart["text"].(string); ok && used < len(texts) {
+				textPart["text"] = texts[used]
+				used++
+			}
+		}
+	}
+
+	return content, used
+}
+
+// Texts returns the texts of the content of the message.
+func (r *MessageRequest) Texts() []string {
+	return contentTexts(r.Content)
+}
+
+// SetTexts replaces the texts of the content in the order returned by Texts.
+func (r *MessageRequest) SetTexts(texts []string) {
+	r.Content, _ = setContentTexts(r.Content, texts)
+}
+
+// Texts returns the texts of the messages of the thread in order.
+func (r *ThreadRequest) Texts() []string {
+	texts := []string{}
+	for _, message := range r.Messages {
+		texts = append(texts, contentTexts(message.Content)...)
+	}
+
+	return texts
+}
+
+// SetTexts replaces the texts of the messages in the order returned by Texts.
+func (r *ThreadRequest) SetTexts(texts []string) {
+	for i := range r.Messages {
+		var used int
+		r.Messages[i].Content, used = setContentTexts(r.Messages[i].Content, texts)
+		texts = texts[used:]
+	}
+}
+
+// Texts returns the texts of the messages of the thread followed by the
+// instructions of the run.
+func (r *CreateThreadAndRunRequest) Texts() []string {
+	texts := r.Thread.Texts()
+	if r.RunRequest != nil && len(r.Instructions) != 0 {
+		texts = append(texts, r.Instructions)
+	}
+
+	return texts
+}
+
+// SetTexts replaces the texts of the messages and the instructions in the
+// order returned by Texts.
+func (r *CreateThreadAndRunRequest) SetTexts(texts []string) {
+	n := len(r.Thread.Texts())
+	if n > len(texts) {
+		n = len(texts)
+	}
+
+	r.Thread.SetTexts(texts[:n])
+
+	if r.RunRequest != nil && len(r.Instructions) != 0 && n < len(texts) {
+		r.Instructions = texts[n]
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	goopenai "github.com/sashabaranov/go-openai"
@@ -34,6 +35,14 @@ func parseFinetuneModel(model string) string {
 
 var OpenAiPerThousandTokenCost = map[string]map[string]float64{
 	"prompt": {
+		"o3":                          0.002,
+		"o3-2025-04-16":               0.002,
+		"o3-mini":                     0.0011,
+		"o3-mini-2025-01-31":          0.0011,
+		"o1":                          0.015,
+		"o1-2024-12-17":               0.015,
+		"o1-mini":                     0.0011,
+		"o1-mini-2024-09-12":          0.0011,
 		"o1-preview":                  0.015,
 		"o1-preview-2024-09-12":       0.015,
 		"gpt-4o":                      0.0025,
@@ -41,6 +50,7 @@ var OpenAiPerThousandTokenCost = map[string]map[string]float64{
 		"gpt-4o-mini-2024-07-18":      0.00015,
 		"gpt-4o-2024-05-13":           0.005,
 		"gpt-4o-2024-08-06":           0.0025,
+		"gpt-4o-2024-11-20":           0.0025,
 		"gpt-4-1106-preview":          0.01,
 		"gpt-4-turbo-preview":         0.01,
 		"gpt-4-turbo":                 0.01,
@@ -98,6 +108,14 @@ var OpenAiPerThousandTokenCost = map[string]map[string]float64{
 		"tts-1-hd":  0.03,
 	},
 	"completion": {
+		"o3":                          0.008,
+		"o3-2025-04-16":               0.008,
+		"o3-mini":                     0.0044,
+		"o3-mini-2025-01-31":          0.0044,
+		"o1":                          0.06,
+		"o1-2024-12-17":               0.06,
+		"o1-mini":                     0.0044,
+		"o1-mini-2024-09-12":          0.0044,
 		"o1-preview":                  0.06,
 		"o1-preview-2024-09-12":       0.06,
 		"gpt-3.5-turbo-1106":          0.002,
@@ -106,6 +124,7 @@ var OpenAiPerThousandTokenCost = map[string]map[string]float64{
 		"gpt-4o-mini-2024-07-18":      0.0006,
 		"gpt-4o-2024-05-13":           0.015,
 		"gpt-4o-2024-08-06":           0.01,
+		"gpt-4o-2024-11-20":           0.01,
 		"gpt-4-turbo-preview":         0.03,
 		"gpt-4-turbo":                 0.03,
 		"gpt-4-turbo-2024-04-09":      0.03,
@@ -135,22 +154,179 @@ var OpenAiPerThousandTokenCost = map[string]map[string]float64{
 	},
 }
 
+// OpenAiPerImageCost maps image models to their cost per image by quality
+// and size. updated according to this link:
+// https://openai.com/api/pricing
+var OpenAiPerImageCost = map[string]map[string]map[string]float64{
+	"dall-e-2": {
+		"standard": {
+			"256x256":   0.016,
+			"512x512":   0.018,
+			"1024x1024": 0.02,
+		},
+	},
+	"dall-e-3": {
+		"standard": {
+			"1024x1024": 0.04,
+			"1024x1792": 0.08,
+			"1792x1024": 0.08,
+		},
+		"hd": {
+			"1024x1024": 0.08,
+			"1024x1792": 0.12,
+			"1792x1024": 0.12,
+		},
+	},
+	"gpt-image-1": {
+		"low": {
+			"1024x1024": 0.011,
+			"1024x1536": 0.016,
+			"1536x1024": 0.016,
+		},
+		"medium": {
+			"1024x1024": 0.042,
+			"1024x1536": 0.063,
+			"1536x1024": 0.063,
+		},
+		"high": {
+			"1024x1024": 0.167,
+			"1024x1536": 0.25,
+			"1536x1024": 0.25,
+		},
+	},
+}
+
+// BatchDiscount is the share of the regular price charged for requests
+// processed through the Batch API.
+const BatchDiscount = 0.5
+
 type tokenCounter interface {
 	Count(model string, input string) (int, error)
 }
 
+type pricingStorage interface {
+	GetCosts(model string) (map[string]float64, bool)
+	GetAllCosts() map[string]map[string]float64
+}
+
 type CostEstimator struct {
 	tokenCostMap map[string]map[string]float64
+	imageCostMap map[string]map[string]map[string]float64
 	tc           tokenCounter
+	ps           pricingStorage
+	version      string
+	generation   uint64
+	lock         sync.RWMutex
 }
 
-func NewCostEstimator(m map[string]map[string]float64, tc tokenCounter) *CostEstimator {
+// NewCostEstimator creates an estimator pricing models with the cost map.
+// Pricings managed via the admin server take precedence over the cost map if
+// ps is not nil.
+func NewCostEstimator(m map[string]map[string]float64, tc tokenCounter, ps pricingStorage) *CostEstimator {
 	return &CostEstimator{
 		tokenCostMap: m,
+		imageCostMap: OpenAiPerImageCost,
 		tc:           tc,
+		ps:           ps,
+	}
+}
+
+// SetPricingTable hot reloads the cost map of the estimator. The version of
+// the cost map is recorded on events priced with it.
+func (ce *CostEstimator) SetPricingTable(m map[string]map[string]float64, version string) {
+	ce.lock.Lock()
+	defer ce.lock.Unlock()
+
+	ce.tokenCostMap = m
+	ce.version = version
+	ce.generation++
+}
+
+// PricingVersion returns the version of the cost map of the estimator. It is
+// empty until a pricing manifest is applied.
+func (ce *CostEstimator) PricingVersion() string {
+	ce.lock.RLock()
+	defer ce.lock.RUnlock()
+
+	return ce.version
+}
+
+func (ce *CostEstimator) pricingGeneration() (uint64, string) {
+	ce.lock.RLock()
+	defer ce.lock.RUnlock()
+
+	return ce.generation, ce.version
+}
+
+// PricingVersionOf runs estimate and returns the version of the cost map it
+// priced with. estimate is run again if the cost map is reloaded while it runs
+// so that costs are never labelled with the version of another cost map.
+func (ce *CostEstimator) PricingVersionOf(estimate func()) string {
+	for {
+		generation, version := ce.pricingGeneration()
+		estimate()
+
+		if current, _ := ce.pricingGeneration(); current == generation {
+			return version
+		}
 	}
 }
 
+func (ce *CostEstimator) costMapOf(kind string) (map[string]float64, bool) {
+	ce.lock.RLock()
+	defer ce.lock.RUnlock()
+
+	costMap, ok := ce.tokenCostMap[kind]
+	return costMap, ok
+}
+
+// costOf returns the cost of a model of a kind, preferring pricings managed
+// via the admin server over the cost map of the kind.
+func (ce *CostEstimator) costOf(costMap map[string]float64, kind, model string) (float64, bool) {
+	if ce.ps != nil {
+		if costs, ok := ce.ps.GetCosts(model); ok {
+			if cost, ok := costs[kind]; ok {
+				return cost, true
+			}
+		}
+	}
+
+	cost, ok := costMap[model]
+	return cost, ok
+}
+
+// PricingTable returns the cost map of the estimator with the pricings
+// managed via the admin server applied.
+func (ce *CostEstimator) PricingTable() map[string]map[string]float64 {
+	ce.lock.RLock()
+	tokenCostMap := ce.tokenCostMap
+	ce.lock.RUnlock()
+
+	table := map[string]map[string]float64{}
+	for kind, costs := range tokenCostMap {
+		table[kind] = map[string]float64{}
+		for model, cost := range costs {
+			table[kind][model] = cost
+		}
+	}
+
+	if ce.ps == nil {
+		return table
+	}
+
+	for model, costs := range ce.ps.GetAllCosts() {
+		for kind, cost := range costs {
+			if _, ok := table[kind]; !ok {
+				table[kind] = map[string]float64{}
+			}
+
+			table[kind][model] = cost
+		}
+	}
+
+	return table
+}
+
 func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
 	promptCost, err := ce.EstimatePromptCost(model, promptTks)
 	if err != nil {
@@ -166,13 +342,13 @@ func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTk
 }
 
 func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, error) {
-	costMap, ok := ce.tokenCostMap["prompt"]
+	costMap, ok := ce.costMapOf("prompt")
 	if !ok {
 		return 0, errors.New("prompt token cost is not provided")
 
 	}
 
-	cost, ok := costMap[useFinetuneModel(model)]
+	cost, ok := ce.costOf(costMap, "prompt", useFinetuneModel(model))
 	if !ok {
 		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
 	}
@@ -182,13 +358,13 @@ func (ce *CostEstimator) EstimatePromptCost(model string, tks int) (float64, err
 }
 
 func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (float64, error) {
-	costMap, ok := ce.tokenCostMap["embeddings"]
+	costMap, ok := ce.costMapOf("embeddings")
 	if !ok {
 		return 0, errors.New("embeddings token cost is not provided")
 
 	}
 
-	cost, ok := costMap[model]
+	cost, ok := ce.costOf(costMap, "embeddings", model)
 	if !ok {
 		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
 	}
@@ -198,12 +374,12 @@ func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (flo
 }
 
 func (ce *CostEstimator) EstimateCompletionCost(model string, tks int) (float64, error) {
-	costMap, ok := ce.tokenCostMap["completion"]
+	costMap, ok := ce.costMapOf("completion")
 	if !ok {
 		return 0, errors.New("prompt token cost is not provided")
 	}
 
-	cost, ok := costMap[useFinetuneModel(model)]
+	cost, ok := ce.costOf(costMap, "completion", useFinetuneModel(model))
 	if !ok {
 		return 0, errors.New("model is not present in the cost map provided")
 	}
@@ -299,12 +475,12 @@ func (ce *CostEstimator) EstimateCompletionsStreamCostWithTokenCounts(model stri
 }
 
 func (ce *CostEstimator) EstimateTranscriptionCost(secs float64, model string) (float64, error) {
-	costMap, ok := ce.tokenCostMap["audio"]
+	costMap, ok := ce.costMapOf("audio")
 	if !ok {
 		return 0, errors.New("audio cost map is not provided")
 	}
 
-	cost, ok := costMap[model]
+	cost, ok := ce.costOf(costMap, "audio", model)
 	if !ok {
 		return 0, errors.New("model is not present in the audio cost map")
 	}
@@ -313,12 +489,12 @@ func (ce *CostEstimator) EstimateTranscriptionCost(secs float64, model string) (
 }
 
 func (ce *CostEstimator) EstimateSpeechCost(input string, model string) (float64, error) {
-	costMap, ok := ce.tokenCostMap["audio"]
+	costMap, ok := ce.costMapOf("audio")
 	if !ok {
 		return 0, errors.New("audio cost map is not provided")
 	}
 
-	cost, ok := costMap[model]
+	cost, ok := ce.costOf(costMap, "audio", model)
 	if !ok {
 		return 0, errors.New("model is not present in the audio cost map")
 	}
@@ -326,13 +502,72 @@ func (ce *CostEstimator) EstimateSpeechCost(input string, model string) (float64
 	return float64(len(input)) / 1000 * cost, nil
 }
 
+// EstimateImageCost estimates the cost of generating num images. Defaults of
+// the images API are used for fields left empty. Images of gpt-image-1 with
+// auto quality are priced at high quality since the quality picked by the
+// model is not known before the image is generated.
+func (ce *CostEstimator) EstimateImageCost(model, quality, size string, num int) (float64, error) {
+	if len(model) == 0 {
+		model = "dall-e-2"
+	}
+
+	qualities, ok := ce.imageCostMap[model]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the image cost map", model)
+	}
+
+	if model == "gpt-image-1" && (len(quality) == 0 || quality == "auto") {
+		quality = "high"
+	}
+
+	if model == "dall-e-2" || len(quality) == 0 {
+		quality = "standard"
+	}
+
+	sizes, ok := qualities[quality]
+	if !ok {
+		return 0, fmt.Errorf("quality %s of %s is not present in the image cost map", quality, model)
+	}
+
+	if len(size) == 0 || size == "auto" {
+		size = "1024x1024"
+	}
+
+	cost, ok := sizes[size]
+	if !ok {
+		return 0, fmt.Errorf("size %s of %s is not present in the image cost map", size, model)
+	}
+
+	if num <= 0 {
+		num = 1
+	}
+
+	return cost * float64(num), nil
+}
+
+// EstimateBatchCost estimates the cost of a request processed through the
+// Batch API. Requests to embedding models are priced by their input tokens.
+func (ce *CostEstimator) EstimateBatchCost(model string, promptTks, completionTks int) (float64, error) {
+	cost, err := ce.EstimateTotalCost(model, promptTks, completionTks)
+	if err != nil {
+		embeddingsCost, embeddingsErr := ce.EstimateEmbeddingsInputCost(model, promptTks)
+		if embeddingsErr != nil {
+			return 0, err
+		}
+
+		cost = embeddingsCost
+	}
+
+	return cost * BatchDiscount, nil
+}
+
 func (ce *CostEstimator) EstimateFinetuningCost(num int, model string) (float64, error) {
-	costMap, ok := ce.tokenCostMap["finetune"]
+	costMap, ok := ce.costMapOf("finetune")
 	if !ok {
 		return 0, errors.New("audio cost map is not provided")
 	}
 
-	cost, ok := costMap[model]
+	cost, ok := ce.costOf(costMap, "finetune", model)
 	if !ok {
 		return 0, errors.New("model is not present in the audio cost map")
 	}
@@ -443,6 +678,20 @@ func countMessageTokens(model string, r *goopenai.ChatCompletionRequest, tc toke
 		result += roleTks
 		result += nameTks
 
+		for _, part := range msg.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeImageURL {
+				result += countImageTokens(model, part.ImageURL)
+				continue
+			}
+
+			partTks, err := tc.Count(model, part.Text)
+			if err != nil {
+				return 0, err
+			}
+
+			result += partTks
+		}
+
 		result += 3
 		if len(msg.Name) != 0 {
 			result += 1
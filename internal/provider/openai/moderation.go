@@ -0,0 +1,95 @@
+package openai
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// DefaultModerationModel is the model used by the moderations endpoint when
+// requests do not specify one. Moderation requests are free of charge.
+const DefaultModerationModel = "omni-moderation-latest"
+
+// ModerationsUrl is the url of the OpenAI moderations endpoint.
+const ModerationsUrl = "https://api.openai.com/v1/moderations"
+
+// ModerationFinding is a category scored by the OpenAI moderations endpoint.
+// Scores of a category are the highest across all moderated inputs.
+type ModerationFinding struct {
+	Category string  `json:"category"`
+	Score    float64 `json:"score"`
+	Flagged  bool    `json:"flagged"`
+}
+
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+type ModerationResponse struct {
+	Id      string              `json:"id"`
+	Model   string              `json:"model"`
+	Results []*ModerationResult `json:"results"`
+}
+
+// ParseModerationFindings extracts the scored categories from the body of a
+// moderations response. Nil is returned if the body cannot be parsed.
+func ParseModerationFindings(body []byte) []*ModerationFinding {
+	res := &ModerationResponse{}
+	if err := json.Unmarshal(body, res); err != nil {
+		return nil
+	}
+
+	merged := map[string]*ModerationFinding{}
+	for _, r := range res.Results {
+		if r == nil {
+			continue
+		}
+
+		for category, score := range r.CategoryScores {
+			f, ok := merged[category]
+			if !ok {
+				f = &ModerationFinding{Category: category}
+				merged[category] = f
+			}
+
+			if score > f.Score {
+				f.Score = score
+			}
+		}
+
+		for category, flagged := range r.Categories {
+			f, ok := merged[category]
+			if !ok {
+				f = &ModerationFinding{Category: category}
+				merged[category] = f
+			}
+
+			f.Flagged = f.Flagged || flagged
+		}
+	}
+
+	findings := []*ModerationFinding{}
+	for _, f := range merged {
+		findings = append(findings, f)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Category < findings[j].Category
+	})
+
+	return findings
+}
+
+// FlaggedModerationFindings returns the findings flagged by the moderations
+// endpoint.
+func FlaggedModerationFindings(findings []*ModerationFinding) []*ModerationFinding {
+	flagged := []*ModerationFinding{}
+	for _, f := range findings {
+		if f != nil && f.Flagged {
+			flagged = append(flagged, f)
+		}
+	}
+
+	return flagged
+}
@@ -0,0 +1,65 @@
+package openai
+
+import "sort"
+
+// PricingKinds are the kinds of costs of OpenAI models. Costs are in USD per
+// thousand tokens except for audio costs, which are per minute for
+// transcriptions and per thousand characters for speech, and finetune costs,
+// which are per thousand trained tokens.
+var PricingKinds = []string{"prompt", "completion", "embeddings", "audio", "finetune"}
+
+// ModelPricing overrides the costs of an OpenAI model or prices a model that
+// is missing from OpenAiPerThousandTokenCost. Pricings managed via the admin
+// server take precedence over the built in costs so that new models do not
+// require a redeploy.
+type ModelPricing struct {
+	Model     string             `json:"model"`
+	Costs     map[string]float64 `json:"costs"`
+	CreatedAt int64              `json:"createdAt"`
+	UpdatedAt int64              `json:"updatedAt"`
+	BuiltIn   bool               `json:"builtIn"`
+	Deleted   bool               `json:"-"`
+}
+
+// Validate returns the names of invalid fields of a pricing.
+func (mp *ModelPricing) Validate() []string {
+	invalid := []string{}
+	if len(mp.Model) == 0 {
+		invalid = append(invalid, "model")
+	}
+
+	if len(mp.Costs) == 0 {
+		invalid = append(invalid, "costs")
+	}
+
+	kinds := map[string]bool{}
+	for _, kind := range PricingKinds {
+		kinds[kind] = true
+	}
+
+	for kind, cost := range mp.Costs {
+		if !kinds[kind] || cost < 0 {
+			invalid = append(invalid, "costs."+kind)
+		}
+	}
+
+	sort.Strings(invalid)
+
+	return invalid
+}
+
+// DefaultModelPricings returns the built in costs of OpenAI models by model.
+func DefaultModelPricings() map[string]map[string]float64 {
+	pricings := map[string]map[string]float64{}
+	for kind, costs := range OpenAiPerThousandTokenCost {
+		for model, cost := range costs {
+			if _, ok := pricings[model]; !ok {
+				pricings[model] = map[string]float64{}
+			}
+
+			pricings[model][kind] = cost
+		}
+	}
+
+	return pricings
+}
@@ -0,0 +1,94 @@
+package openai
+
+import (
+	"encoding/base64"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strings"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	maxImageDimension     = 2048
+	imageShortSide        = 768
+	imageTileSize         = 512
+	defaultImageBaseTks   = 85
+	defaultImageTileTks   = 170
+	gpt4oMiniImageBaseTks = 2833
+	gpt4oMiniImageTileTks = 5667
+)
+
+// imageTokenRates returns the base and per tile tokens of images sent to a
+// model. gpt-4o-mini bills images at a higher token rate to keep their cost in
+// line with gpt-4o.
+func imageTokenRates(model string) (int, int) {
+	if strings.HasPrefix(model, "gpt-4o-mini") {
+		return gpt4oMiniImageBaseTks, gpt4oMiniImageTileTks
+	}
+
+	return defaultImageBaseTks, defaultImageTileTks
+}
+
+// imageDimensions returns the size of images encoded as data urls. False is
+// returned for remote images and formats that cannot be decoded.
+func imageDimensions(url string) (int, int, bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return 0, 0, false
+	}
+
+	idx := strings.Index(url, ";base64,")
+	if idx == -1 {
+		return 0, 0, false
+	}
+
+	reader := base64.NewDecoder(base64.StdEncoding, strings.NewReader(url[idx+len(";base64,"):]))
+	cfg, _, err := image.DecodeConfig(reader)
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return 0, 0, false
+	}
+
+	return cfg.Width, cfg.Height, true
+}
+
+// countImageTiles returns the number of 512px tiles of an image after it is
+// scaled to fit within 2048x2048 and its shortest side is scaled down to
+// 768px.
+func countImageTiles(width, height int) int {
+	w, h := float64(width), float64(height)
+	if w > maxImageDimension || h > maxImageDimension {
+		scale := maxImageDimension / math.Max(w, h)
+		w, h = w*scale, h*scale
+	}
+
+	if math.Min(w, h) > imageShortSide {
+		scale := imageShortSide / math.Min(w, h)
+		w, h = w*scale, h*scale
+	}
+
+	return int(math.Ceil(w/imageTileSize) * math.Ceil(h/imageTileSize))
+}
+
+// countImageTokens estimates the tokens of an image_url part. Low detail
+// images cost the base tokens. Images of unknown size are counted as the
+// largest tiling an image can be scaled to, 2048x768.
+func countImageTokens(model string, img *goopenai.ChatMessageImageURL) int {
+	base, tile := imageTokenRates(model)
+	if img == nil {
+		return base
+	}
+
+	if img.Detail == goopenai.ImageURLDetailLow {
+		return base
+	}
+
+	width, height, ok := imageDimensions(img.URL)
+	if !ok {
+		width, height = maxImageDimension, imageShortSide
+	}
+
+	return base + tile*countImageTiles(width, height)
+}
@@ -0,0 +1,29 @@
+package openrouter
+
+import (
+	"errors"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+)
+
+// CostEstimator reads the cost billed by OpenRouter from the usage of a
+// response. OpenRouter prices requests according to the upstream provider
+// it routes them to, so the gateway does not keep a pricing table for it.
+type CostEstimator struct {
+	*compatible.CostEstimator
+}
+
+func NewCostEstimator(tc compatible.Counter) *CostEstimator {
+	return &CostEstimator{
+		CostEstimator: compatible.NewCostEstimator(nil, nil, tc),
+	}
+}
+
+// EstimateUsageCost returns the cost in USD reported in the usage.
+func (ce *CostEstimator) EstimateUsageCost(usage *Usage) (float64, error) {
+	if usage == nil || usage.Cost == nil {
+		return 0, errors.New("cost is not reported in the openrouter usage")
+	}
+
+	return *usage.Cost, nil
+}
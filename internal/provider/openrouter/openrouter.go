@@ -0,0 +1,35 @@
+package openrouter
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const ChatCompletionsUrl = "https://openrouter.ai/api/v1/chat/completions"
+
+// Usage extends the OpenAI usage with the cost in credits billed by
+// OpenRouter for the request.
+type Usage struct {
+	PromptTokens            int                               `json:"prompt_tokens"`
+	CompletionTokens        int                               `json:"completion_tokens"`
+	TotalTokens             int                               `json:"total_tokens"`
+	Cost                    *float64                          `json:"cost,omitempty"`
+	PromptTokensDetails     *goopenai.PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *goopenai.CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// ReasoningTokens returns the reasoning tokens reported in the usage.
+func (u *Usage) ReasoningTokens() int {
+	if u == nil || u.CompletionTokensDetails == nil {
+		return 0
+	}
+
+	return u.CompletionTokensDetails.ReasoningTokens
+}
+
+type ChatCompletionResponse = compatible.ChatCompletionResponse[Usage]
+
+// ChatCompletionStreamResponse is a chunk of a streamed chat completion.
+// OpenRouter reports the usage and the cost of the request in the last
+// chunk of a stream.
+type ChatCompletionStreamResponse = compatible.ChatCompletionStreamResponse[Usage]
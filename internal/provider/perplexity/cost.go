@@ -0,0 +1,82 @@
+package perplexity
+
+import (
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+)
+
+// PerplexityPerMillionTokenCost maps models to their cost per million tokens.
+// updated according to this link:
+// https://docs.perplexity.ai/guides/pricing
+var PerplexityPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"sonar":               1,
+		"sonar-pro":           3,
+		"sonar-reasoning":     1,
+		"sonar-reasoning-pro": 2,
+		"sonar-deep-research": 2,
+		"r1-1776":             2,
+	},
+	"completion": {
+		"sonar":               1,
+		"sonar-pro":           15,
+		"sonar-reasoning":     5,
+		"sonar-reasoning-pro": 8,
+		"sonar-deep-research": 8,
+		"r1-1776":             8,
+	},
+	"citation": {
+		"sonar-deep-research": 2,
+	},
+	"reasoning": {
+		"sonar-deep-research": 3,
+	},
+}
+
+// PerplexityPerThousandRequestCost maps models to their cost per thousand
+// requests and per thousand search queries.
+var PerplexityPerThousandRequestCost = map[string]map[string]float64{
+	"request": {
+		"sonar":               5,
+		"sonar-pro":           6,
+		"sonar-reasoning":     5,
+		"sonar-reasoning-pro": 6,
+	},
+	"search": {
+		"sonar-deep-research": 5,
+	},
+}
+
+// CostEstimator prices the searches of Perplexity models on top of the prices
+// of OpenAI compatible providers.
+type CostEstimator struct {
+	*compatible.CostEstimator
+	requestCostMap map[string]map[string]float64
+}
+
+func NewCostEstimator(tc compatible.Counter) *CostEstimator {
+	return &CostEstimator{
+		CostEstimator:  compatible.NewCostEstimator(PerplexityPerMillionTokenCost, nil, tc),
+		requestCostMap: PerplexityPerThousandRequestCost,
+	}
+}
+
+// EstimateSearchCost returns the cost of the citation and reasoning tokens,
+// the search queries and the request fee reported in the usage. Models that
+// are not billed for a component are not charged for it.
+func (ce *CostEstimator) EstimateSearchCost(model string, usage *Usage) float64 {
+	if usage == nil {
+		return 0
+	}
+
+	citationCost, _ := ce.GetCost("citation", model)
+	reasoningCost, _ := ce.GetCost("reasoning", model)
+
+	model = strings.ToLower(model)
+	cost := (float64(usage.CitationTokens)*citationCost + float64(usage.ReasoningTokens)*reasoningCost) / 1000000
+	cost += float64(usage.NumSearchQueries) * ce.requestCostMap["search"][model] / 1000
+	cost += ce.requestCostMap["request"][model] / 1000
+
+	return cost
+}
@@ -0,0 +1,99 @@
+package perplexity
+
+import (
+	"encoding/json"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const ChatCompletionsUrl = "https://api.perplexity.ai/chat/completions"
+
+// ChatRequest is an OpenAI compatible chat completions request with the
+// search options supported by the Perplexity API.
+type ChatRequest struct {
+	goopenai.ChatCompletionRequest
+	SearchDomainFilter     []string        `json:"search_domain_filter,omitempty"`
+	SearchRecencyFilter    string          `json:"search_recency_filter,omitempty"`
+	SearchMode             string          `json:"search_mode,omitempty"`
+	ReturnImages           bool            `json:"return_images,omitempty"`
+	ReturnRelatedQuestions bool            `json:"return_related_questions,omitempty"`
+	WebSearchOptions       json.RawMessage `json:"web_search_options,omitempty"`
+}
+
+// Usage extends the OpenAI usage with the search related token counts
+// reported by the Perplexity API.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	CitationTokens   int `json:"citation_tokens,omitempty"`
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"`
+	NumSearchQueries int `json:"num_search_queries,omitempty"`
+}
+
+type SearchResult struct {
+	Title string `json:"title"`
+	Url   string `json:"url"`
+	Date  string `json:"date,omitempty"`
+}
+
+type ChatCompletionResponse struct {
+	goopenai.ChatCompletionResponse
+	Usage         *Usage          `json:"usage,omitempty"`
+	Citations     []string        `json:"citations,omitempty"`
+	SearchResults []*SearchResult `json:"search_results,omitempty"`
+}
+
+// ChatCompletionStreamResponse is a chunk of a streamed chat completion.
+// Perplexity includes the citations and the usage of the request in the
+// chunks of a stream.
+type ChatCompletionStreamResponse struct {
+	goopenai.ChatCompletionStreamResponse
+	Usage         *Usage          `json:"usage,omitempty"`
+	Citations     []string        `json:"citations,omitempty"`
+	SearchResults []*SearchResult `json:"search_results,omitempty"`
+}
+
+// Citation is a source returned by the Perplexity API recorded on events.
+type Citation struct {
+	Url   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	Date  string `json:"date,omitempty"`
+}
+
+// NewCitations merges the citation urls of a response with the titles and
+// dates of its search results.
+func NewCitations(urls []string, results []*SearchResult) []*Citation {
+	citations := []*Citation{}
+	seen := map[string]int{}
+
+	for _, url := range urls {
+		if _, ok := seen[url]; ok {
+			continue
+		}
+
+		seen[url] = len(citations)
+		citations = append(citations, &Citation{Url: url})
+	}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+
+		if i, ok := seen[result.Url]; ok {
+			citations[i].Title = result.Title
+			citations[i].Date = result.Date
+			continue
+		}
+
+		seen[result.Url] = len(citations)
+		citations = append(citations, &Citation{
+			Url:   result.Url,
+			Title: result.Title,
+			Date:  result.Date,
+		})
+	}
+
+	return citations
+}
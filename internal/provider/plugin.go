@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type EndpointKind string
+
+const (
+	ChatEndpoint       EndpointKind = "chat"
+	EmbeddingsEndpoint EndpointKind = "embeddings"
+)
+
+// Endpoint is an endpoint proxied by a plugin. Requests to the route prefix of
+// the plugin followed by the path are forwarded to the url.
+type Endpoint struct {
+	Path string
+	Url  string
+	// Kind selects the fields of cost maps of provider settings used to
+	// estimate the cost of requests.
+	Kind EndpointKind
+}
+
+// PluginRequest is a request parsed by a plugin. The body is the input of
+// policies and is forwarded as JSON after policies are applied. Bodies
+// implementing Texts and SetTexts are inspected and redacted by policies.
+type PluginRequest struct {
+	Model  string
+	Stream bool
+	Body   any
+}
+
+// Usage is the usage of a response. A cost reported by the provider takes
+// precedence over the cost estimated from the token counts.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	CostInUsd        float64
+}
+
+// StreamDecoder accumulates the content and the usage of a streamed response.
+type StreamDecoder interface {
+	// Decode is called with the data of each server sent event.
+	Decode(data []byte) error
+	Content() string
+	// Usage returns nil if the stream did not report usage.
+	Usage() *Usage
+}
+
+// Plugin is a provider proxied by the gateway without changes to the proxy
+// core. Plugins are compiled in and registered with RegisterPlugin before the
+// proxy server is created. Requests are authenticated with provider settings
+// named after the plugin and are signed with their api key as a bearer token.
+type Plugin interface {
+	// Name is the provider of the provider settings used by the plugin.
+	Name() string
+	// RoutePrefix is the path prefix of the endpoints of the plugin, such as
+	// /api/providers/voyage.
+	RoutePrefix() string
+	Endpoints() []*Endpoint
+	ParseRequest(ep *Endpoint, body []byte) (*PluginRequest, error)
+	// ParseUsage returns nil if the response does not report usage.
+	ParseUsage(ep *Endpoint, body []byte) (*Usage, error)
+	// NewStreamDecoder returns nil if the endpoint does not stream.
+	NewStreamDecoder(ep *Endpoint) StreamDecoder
+	EstimateCost(ep *Endpoint, model string, usage *Usage) (float64, error)
+	// CountTokens approximates the tokens of texts of responses that do not
+	// report usage.
+	CountTokens(text string) int
+	// PricingTable returns the cost per million tokens of models by kind.
+	PricingTable() map[string]map[string]float64
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = map[string]Plugin{}
+)
+
+// RegisterPlugin makes a plugin available to the proxy. It panics if the
+// plugin is nil, if its route prefix is not under /api/providers/ or if a
+// plugin with the same name is already registered.
+func RegisterPlugin(p Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	if p == nil {
+		panic("provider: plugin is nil")
+	}
+
+	if !strings.HasPrefix(p.RoutePrefix(), "/api/providers/") {
+		panic(fmt.Sprintf("provider: route prefix of plugin %s must start with /api/providers/", p.Name()))
+	}
+
+	if _, dup := plugins[p.Name()]; dup {
+		panic(fmt.Sprintf("provider: plugin %s is registered twice", p.Name()))
+	}
+
+	plugins[p.Name()] = p
+}
+
+// GetPlugin returns nil if no plugin is registered with the name.
+func GetPlugin(name string) Plugin {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	return plugins[name]
+}
+
+// GetPlugins returns the registered plugins sorted by name.
+func GetPlugins() []Plugin {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	registered := []Plugin{}
+	for _, p := range plugins {
+		registered = append(registered, p)
+	}
+
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name() < registered[j].Name()
+	})
+
+	return registered
+}
+
+// GetPluginEndpoint returns the plugin and the endpoint serving a route. Nil
+// is returned if the route is not served by a plugin.
+func GetPluginEndpoint(route string) (Plugin, *Endpoint) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	for _, p := range plugins {
+		if !strings.HasPrefix(route, p.RoutePrefix()) {
+			continue
+		}
+
+		for _, ep := range p.Endpoints() {
+			if p.RoutePrefix()+ep.Path == route {
+				return p, ep
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	openAiCompletionsPath     = "/api/providers/openai/v1/completions"
+	openAiChatCompletionsPath = "/api/providers/openai/v1/chat/completions"
+	anthropicMessagesPath     = "/api/providers/anthropic/v1/messages"
+)
+
+// DefaultModelRemappings maps models retired by providers to their
+// successors on the endpoints the successors serve. Remappings managed via
+// the admin server take precedence.
+var DefaultModelRemappings = []*ModelRemapping{
+	{Model: "text-davinci-001", Successor: "gpt-3.5-turbo-instruct", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "text-davinci-002", Successor: "gpt-3.5-turbo-instruct", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "text-davinci-003", Successor: "gpt-3.5-turbo-instruct", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "code-davinci-002", Successor: "gpt-3.5-turbo-instruct", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "text-curie-001", Successor: "davinci-002", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "curie", Successor: "davinci-002", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "davinci", Successor: "davinci-002", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "text-babbage-001", Successor: "babbage-002", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "text-ada-001", Successor: "babbage-002", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "babbage", Successor: "babbage-002", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "ada", Successor: "babbage-002", Provider: "openai", Paths: []string{openAiCompletionsPath}},
+	{Model: "gpt-3.5-turbo-0301", Successor: "gpt-3.5-turbo", Provider: "openai", Paths: []string{openAiChatCompletionsPath}},
+	{Model: "gpt-3.5-turbo-0613", Successor: "gpt-3.5-turbo", Provider: "openai", Paths: []string{openAiChatCompletionsPath}},
+	{Model: "gpt-4-0314", Successor: "gpt-4", Provider: "openai", Paths: []string{openAiChatCompletionsPath}},
+	{Model: "gpt-4-32k-0314", Successor: "gpt-4-32k", Provider: "openai", Paths: []string{openAiChatCompletionsPath}},
+	// claude 3 models are not served by the text completions endpoint.
+	{Model: "claude-instant-1", Successor: "claude-3-haiku-20240307", Provider: "anthropic", Paths: []string{anthropicMessagesPath}},
+	{Model: "claude-instant-1.2", Successor: "claude-3-haiku-20240307", Provider: "anthropic", Paths: []string{anthropicMessagesPath}},
+}
+
+// DefaultModelRemapping returns the default remapping of a model.
+func DefaultModelRemapping(model string) (*ModelRemapping, bool) {
+	for _, mr := range DefaultModelRemappings {
+		if mr.Model == model {
+			return mr, true
+		}
+	}
+
+	return nil, false
+}
+
+// ModelRemapping transparently upgrades requests for a deprecated model to its
+// successor. Remappings can be scoped to the requests of a provider and to
+// proxy paths.
+type ModelRemapping struct {
+	Model     string   `json:"model"`
+	Successor string   `json:"successor"`
+	Provider  string   `json:"provider,omitempty"`
+	Paths     []string `json:"paths,omitempty"`
+	CreatedAt int64    `json:"createdAt"`
+	UpdatedAt int64    `json:"updatedAt"`
+	BuiltIn   bool     `json:"builtIn"`
+	Deleted   bool     `json:"-"`
+}
+
+// Applies reports whether the remapping applies to requests of a provider to
+// a proxy path. Unscoped remappings apply to every request.
+func (mr *ModelRemapping) Applies(provider, path string) bool {
+	if len(mr.Provider) != 0 && mr.Provider != provider {
+		return false
+	}
+
+	if len(mr.Paths) == 0 {
+		return true
+	}
+
+	for _, p := range mr.Paths {
+		if p == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate returns the names of invalid fields of a remapping.
+func (mr *ModelRemapping) Validate() []string {
+	invalid := []string{}
+	if len(mr.Model) == 0 {
+		invalid = append(invalid, "model")
+	}
+
+	if len(mr.Successor) == 0 || mr.Successor == mr.Model {
+		invalid = append(invalid, "successor")
+	}
+
+	for idx, path := range mr.Paths {
+		if !strings.HasPrefix(path, "/api/") {
+			invalid = append(invalid, fmt.Sprintf("paths.[%d]", idx))
+		}
+	}
+
+	return invalid
+}
+
+// ResolveSuccessor follows remappings of a model until reaching a model that
+// is not deprecated. False is returned if the model is not deprecated or the
+// remappings form a cycle.
+func ResolveSuccessor(model string, lookup func(string) (string, bool)) (string, bool) {
+	resolved := model
+	seen := map[string]bool{model: true}
+	for {
+		successor, ok := lookup(resolved)
+		if !ok {
+			break
+		}
+
+		if seen[successor] {
+			return "", false
+		}
+
+		seen[successor] = true
+		resolved = successor
+	}
+
+	return resolved, resolved != model
+}
@@ -3,14 +3,38 @@ package provider
 import "fmt"
 
 type Setting struct {
-	CreatedAt     int64             `json:"createdAt"`
-	UpdatedAt     int64             `json:"updatedAt"`
-	Provider      string            `json:"provider"`
-	Setting       map[string]string `json:"setting,omitempty"`
-	Id            string            `json:"id"`
-	Name          string            `json:"name"`
-	AllowedModels []string          `json:"allowedModels"`
-	CostMap       *CostMap          `json:"costMap"`
+	CreatedAt              int64             `json:"createdAt"`
+	UpdatedAt              int64             `json:"updatedAt"`
+	Provider               string            `json:"provider"`
+	Setting                map[string]string `json:"setting,omitempty"`
+	Id                     string            `json:"id"`
+	Name                   string            `json:"name"`
+	AllowedModels          []string          `json:"allowedModels"`
+	CostMap                *CostMap          `json:"costMap"`
+	Deployments            Deployments       `json:"deployments,omitempty"`
+	MaxConcurrentRequests  int               `json:"maxConcurrentRequests,omitempty"`
+	ModelConcurrencyLimits map[string]int    `json:"modelConcurrencyLimits,omitempty"`
+	ConcurrencyOverflow    string            `json:"concurrencyOverflow,omitempty"`
+}
+
+const (
+	// ConcurrencyOverflowQueue queues requests over concurrency limits until
+	// a slot frees up. It is the default.
+	ConcurrencyOverflowQueue = "queue"
+	// ConcurrencyOverflowReject rejects requests over concurrency limits.
+	ConcurrencyOverflowReject = "reject"
+)
+
+// ModelConcurrencyLimit returns the maximum number of requests in flight
+// with a model of the setting. Zero means the model is not capped.
+func (s *Setting) ModelConcurrencyLimit(model string) int {
+	return s.ModelConcurrencyLimits[model]
+}
+
+// ShouldRejectOverflow reports whether requests over the concurrency limits
+// of the setting are rejected instead of queued.
+func (s *Setting) ShouldRejectOverflow() bool {
+	return s.ConcurrencyOverflow == ConcurrencyOverflowReject
 }
 
 type CostMap struct {
@@ -19,16 +43,63 @@ type CostMap struct {
 	EmbeddingsCostPerModel map[string]float64 `json:"embeddingsCostPerModel"`
 }
 
+// AzureDeployment describes where a logical model name is served from
+// on Azure OpenAI. ResourceName and ApiVersion take precedence over the
+// values of the provider setting and the incoming request, which they fall
+// back to when left empty.
+type AzureDeployment struct {
+	Deployment   string `json:"deployment"`
+	ResourceName string `json:"resourceName,omitempty"`
+	ApiVersion   string `json:"apiVersion,omitempty"`
+	Model        string `json:"model,omitempty"`
+}
+
+// Deployments maps logical model names (e.g. gpt-4) to Azure deployments.
+type Deployments map[string]*AzureDeployment
+
+// Resolve looks up a deployment either by its logical model name or by
+// its deployment name and returns the model that should be used for
+// pricing along with the deployment.
+func (d Deployments) Resolve(name string) (string, *AzureDeployment) {
+	if len(name) == 0 {
+		return "", nil
+	}
+
+	if dep, ok := d[name]; ok && dep != nil {
+		return dep.PricingModel(name), dep
+	}
+
+	for logical, dep := range d {
+		if dep != nil && dep.Deployment == name {
+			return dep.PricingModel(logical), dep
+		}
+	}
+
+	return "", nil
+}
+
+func (ad *AzureDeployment) PricingModel(logical string) string {
+	if len(ad.Model) != 0 {
+		return ad.Model
+	}
+
+	return logical
+}
+
 func (s *Setting) GetParam(key string) string {
 	return s.Setting[key]
 }
 
 type UpdateSetting struct {
-	UpdatedAt     int64             `json:"updatedAt"`
-	Setting       map[string]string `json:"setting,omitempty"`
-	Name          *string           `json:"name"`
-	AllowedModels *[]string         `json:"allowedModels,omitempty"`
-	CostMap       *CostMap          `json:"costMap,omitempty"`
+	UpdatedAt              int64             `json:"updatedAt"`
+	Setting                map[string]string `json:"setting,omitempty"`
+	Name                   *string           `json:"name"`
+	AllowedModels          *[]string         `json:"allowedModels,omitempty"`
+	CostMap                *CostMap          `json:"costMap,omitempty"`
+	Deployments            *Deployments      `json:"deployments,omitempty"`
+	MaxConcurrentRequests  *int              `json:"maxConcurrentRequests,omitempty"`
+	ModelConcurrencyLimits map[string]int    `json:"modelConcurrencyLimits,omitempty"`
+	ConcurrencyOverflow    *string           `json:"concurrencyOverflow,omitempty"`
 }
 
 func EstimateCostWithCostMap(model string, tks int, div float64, costMap map[string]float64) (float64, error) {
@@ -41,6 +112,13 @@ func EstimateCostWithCostMap(model string, tks int, div float64, costMap map[str
 	return tksInFloat / div * cost, nil
 }
 
+// EstimateTotalCostWithOptionalCostMaps estimates the cost of a request with
+// cost maps in which models are free unless they are priced, such as the cost
+// maps of self hosted models.
+func EstimateTotalCostWithOptionalCostMaps(model string, ptks, ctks int, div float64, promptCostMap map[string]float64, completionCostMap map[string]float64) float64 {
+	return float64(ptks)/div*promptCostMap[model] + float64(ctks)/div*completionCostMap[model]
+}
+
 func EstimateTotalCostWithCostMaps(model string, ptks, ctks int, div float64, promptCostMap map[string]float64, completionCostMap map[string]float64) (float64, error) {
 	pcost, ok := promptCostMap[model]
 	if !ok {
@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateTotalCostWithOptionalCostMaps(t *testing.T) {
+	prompt := map[string]float64{"llama3": 0.002}
+	completion := map[string]float64{"llama3": 0.004}
+
+	tests := []struct {
+		name  string
+		model string
+		want  float64
+	}{
+		{name: "priced", model: "llama3", want: 0.008},
+		{name: "free", model: "mistral"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, EstimateTotalCostWithOptionalCostMaps(tt.model, 1000, 1500, 1000, prompt, completion), 1e-9)
+		})
+	}
+
+	assert.Zero(t, EstimateTotalCostWithOptionalCostMaps("llama3", 1000, 1000, 1000, nil, nil))
+}
@@ -0,0 +1,183 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	defaultTokenUri    = "https://oauth2.googleapis.com/token"
+	metadataTokenUrl   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyId string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenUri     string `json:"token_uri"`
+}
+
+// ParseServiceAccount parses and validates a service account key file.
+func ParseServiceAccount(data string) (*ServiceAccount, error) {
+	sa := &ServiceAccount{}
+	if err := json.Unmarshal([]byte(data), sa); err != nil {
+		return nil, err
+	}
+
+	if sa.Type != "service_account" || len(sa.ClientEmail) == 0 || len(sa.PrivateKey) == 0 {
+		return nil, errors.New("service account json must contain type, client_email and private_key")
+	}
+
+	if _, err := parsePrivateKey(sa.PrivateKey); err != nil {
+		return nil, err
+	}
+
+	return sa, nil
+}
+
+func parsePrivateKey(key string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("service account private key is not pem encoded")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		pkcs1, pkcs1Err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if pkcs1Err != nil {
+			return nil, err
+		}
+
+		return pkcs1, nil
+	}
+
+	rsaKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key is not an rsa key")
+	}
+
+	return rsaKey, nil
+}
+
+// gcpTokenSource issues OAuth2 access tokens for Google Cloud. Tokens are
+// minted from service account keys or, if no key is configured, fetched from
+// the metadata server of the workload identity the gateway runs as.
+type gcpTokenSource struct {
+	client http.Client
+	cache  *tokenCache
+}
+
+func newGcpTokenSource(client http.Client) *gcpTokenSource {
+	return &gcpTokenSource{
+		client: client,
+		cache:  newTokenCache(),
+	}
+}
+
+// getToken returns an access token for the service account json. The
+// workload identity of the gateway is used if the json is empty.
+func (ts *gcpTokenSource) getToken(ctx context.Context, serviceAccountJson string) (string, error) {
+	if len(serviceAccountJson) == 0 {
+		return ts.cache.get("gcp:workload_identity", func() (*accessToken, error) {
+			return ts.fetchMetadataToken(ctx)
+		})
+	}
+
+	return ts.cache.get("gcp:"+hashKey(serviceAccountJson), func() (*accessToken, error) {
+		return ts.fetchServiceAccountToken(ctx, serviceAccountJson)
+	})
+}
+
+func signJwt(sa *ServiceAccount, tokenUri string, now time.Time) (string, error) {
+	key, err := parsePrivateKey(sa.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": sa.PrivateKeyId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{
+		"iss":   sa.ClientEmail,
+		"scope": cloudPlatformScope,
+		"aud":   tokenUri,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hashed := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (ts *gcpTokenSource) fetchServiceAccountToken(ctx context.Context, serviceAccountJson string) (*accessToken, error) {
+	sa, err := ParseServiceAccount(serviceAccountJson)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenUri := sa.TokenUri
+	if len(tokenUri) == 0 {
+		tokenUri = defaultTokenUri
+	}
+
+	now := time.Now()
+	assertion, err := signJwt(sa, tokenUri, now)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", jwtBearerGrantType)
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return doTokenRequest(ts.client, req, now)
+}
+
+func (ts *gcpTokenSource) fetchMetadataToken(ctx context.Context) (*accessToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	return doTokenRequest(ts.client, req, time.Now())
+}
@@ -0,0 +1,45 @@
+package signer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// oauth2TokenSource issues access tokens with the OAuth2 client credentials
+// grant. Tokens are refreshed once they are about to expire.
+type oauth2TokenSource struct {
+	client http.Client
+	cache  *tokenCache
+}
+
+func newOAuth2TokenSource(client http.Client) *oauth2TokenSource {
+	return &oauth2TokenSource{
+		client: client,
+		cache:  newTokenCache(),
+	}
+}
+
+func (ts *oauth2TokenSource) getToken(ctx context.Context, tokenUrl, clientId, clientSecret string, scopes []string) (string, error) {
+	key := "oauth2:" + hashKey(tokenUrl, clientId, clientSecret, strings.Join(scopes, " "))
+
+	return ts.cache.get(key, func() (*accessToken, error) {
+		form := url.Values{}
+		form.Set("grant_type", "client_credentials")
+		if len(scopes) != 0 {
+			form.Set("scope", strings.Join(scopes, " "))
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenUrl, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+
+		req.SetBasicAuth(url.QueryEscape(clientId), url.QueryEscape(clientSecret))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return doTokenRequest(ts.client, req, time.Now())
+	})
+}
@@ -0,0 +1,198 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	SchemeApiKeyHeader            = "api_key_header"
+	SchemeBearer                  = "bearer"
+	SchemeOAuth2ClientCredentials = "oauth2_client_credentials"
+	SchemeAwsSigV4                = "aws_sigv4"
+	SchemeGcp                     = "gcp"
+)
+
+// Signer authenticates requests forwarded to an upstream provider.
+type Signer interface {
+	Sign(ctx context.Context, req *http.Request) error
+}
+
+// Config describes how requests to an upstream provider are authenticated.
+// Secrets are never part of the config. They are read from the params of the
+// provider setting used for the request.
+type Config struct {
+	Scheme string `json:"scheme"`
+
+	// Param is the provider setting param holding the api key of the
+	// api_key_header and bearer schemes. Defaults to apikey.
+	Param string `json:"param,omitempty"`
+
+	// HeaderName and Prefix configure the header of the api_key_header scheme.
+	HeaderName string `json:"headerName,omitempty"`
+	Prefix     string `json:"prefix,omitempty"`
+
+	// TokenUrl and Scopes configure the oauth2_client_credentials scheme.
+	TokenUrl string   `json:"tokenUrl,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+
+	// Service is the AWS service name requests are signed for with aws_sigv4.
+	Service string `json:"service,omitempty"`
+}
+
+func (c *Config) Validate() []string {
+	invalid := []string{}
+	switch c.Scheme {
+	case SchemeApiKeyHeader:
+		if len(c.HeaderName) == 0 {
+			invalid = append(invalid, "auth.headerName")
+		}
+	case SchemeBearer, SchemeGcp:
+	case SchemeOAuth2ClientCredentials:
+		if !strings.HasPrefix(c.TokenUrl, "https://") && !strings.HasPrefix(c.TokenUrl, "http://") {
+			invalid = append(invalid, "auth.tokenUrl")
+		}
+	case SchemeAwsSigV4:
+		if len(c.Service) == 0 {
+			invalid = append(invalid, "auth.service")
+		}
+	default:
+		invalid = append(invalid, "auth.scheme")
+	}
+
+	return invalid
+}
+
+func (c *Config) param() string {
+	if len(c.Param) != 0 {
+		return c.Param
+	}
+
+	return "apikey"
+}
+
+// RequiredParams returns the provider setting params a scheme reads secrets
+// from.
+func (c *Config) RequiredParams() []string {
+	switch c.Scheme {
+	case SchemeApiKeyHeader, SchemeBearer:
+		return []string{c.param()}
+	case SchemeOAuth2ClientCredentials:
+		return []string{"clientId", "clientSecret"}
+	case SchemeAwsSigV4:
+		return []string{"awsRegion"}
+	}
+
+	return []string{}
+}
+
+type headerSigner struct {
+	name  string
+	value string
+}
+
+func (s *headerSigner) Sign(ctx context.Context, req *http.Request) error {
+	req.Header.Set(s.name, s.value)
+	return nil
+}
+
+// NewStaticSigner returns a signer setting an api key header. Only the
+// api_key_header and bearer schemes are supported.
+func NewStaticSigner(cfg *Config, apiKey string) (Signer, error) {
+	if len(apiKey) == 0 {
+		return nil, fmt.Errorf("provider setting is missing %s", cfg.param())
+	}
+
+	switch cfg.Scheme {
+	case SchemeApiKeyHeader:
+		return &headerSigner{name: cfg.HeaderName, value: cfg.Prefix + apiKey}, nil
+	case SchemeBearer:
+		return &headerSigner{name: "Authorization", value: "Bearer " + apiKey}, nil
+	}
+
+	return nil, fmt.Errorf("auth scheme %s is not static", cfg.Scheme)
+}
+
+// Manager builds signers for provider settings. Access tokens issued for the
+// oauth2_client_credentials and gcp schemes and the IAM credentials of the
+// aws_sigv4 scheme are cached and shared between the signers it builds.
+type Manager struct {
+	oauth2 *oauth2TokenSource
+	gcp    *gcpTokenSource
+	aws    *awsCredentialChain
+}
+
+func NewManager(client http.Client) *Manager {
+	return &Manager{
+		oauth2: newOAuth2TokenSource(client),
+		gcp:    newGcpTokenSource(client),
+		aws:    &awsCredentialChain{},
+	}
+}
+
+// GetSigner returns the signer of an auth config using the secrets in the
+// params of a provider setting.
+func (m *Manager) GetSigner(cfg *Config, params map[string]string) (Signer, error) {
+	for _, param := range cfg.RequiredParams() {
+		if len(params[param]) == 0 {
+			return nil, fmt.Errorf("provider setting is missing %s", param)
+		}
+	}
+
+	switch cfg.Scheme {
+	case SchemeApiKeyHeader, SchemeBearer:
+		return NewStaticSigner(cfg, params[cfg.param()])
+	case SchemeOAuth2ClientCredentials:
+		return &tokenSigner{
+			get: func(ctx context.Context) (string, error) {
+				return m.oauth2.getToken(ctx, cfg.TokenUrl, params["clientId"], params["clientSecret"], cfg.Scopes)
+			},
+		}, nil
+	case SchemeAwsSigV4:
+		if (len(params["awsAccessKeyId"]) == 0) != (len(params["awsSecretAccessKey"]) == 0) {
+			return nil, errors.New("provider setting must contain both or neither of awsAccessKeyId and awsSecretAccessKey")
+		}
+
+		creds := staticAwsCredentials(params)
+		if creds == nil {
+			chain, err := m.aws.get(context.Background())
+			if err != nil {
+				return nil, err
+			}
+
+			creds = chain
+		}
+
+		return &sigV4Signer{
+			credentials: creds,
+			region:      params["awsRegion"],
+			service:     cfg.Service,
+		}, nil
+	case SchemeGcp:
+		return &tokenSigner{
+			get: func(ctx context.Context) (string, error) {
+				return m.gcp.getToken(ctx, params["serviceAccountJson"])
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("auth scheme %s is not supported", cfg.Scheme)
+}
+
+// tokenSigner sets an OAuth2 access token as the bearer token of requests.
+type tokenSigner struct {
+	get func(ctx context.Context) (string, error)
+}
+
+func (s *tokenSigner) Sign(ctx context.Context, req *http.Request) error {
+	token, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
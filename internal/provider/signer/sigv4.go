@@ -0,0 +1,93 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// sigV4Signer signs requests with AWS Signature Version 4.
+type sigV4Signer struct {
+	credentials aws.CredentialsProvider
+	region      string
+	service     string
+}
+
+// awsCredentialChain resolves the credentials of the IAM identity the gateway
+// runs as, such as an instance profile, an ECS task role or IRSA. Credentials
+// are loaded once and refreshed by the cache before they expire. Failed loads
+// are retried by the next request.
+type awsCredentialChain struct {
+	mu       sync.Mutex
+	provider aws.CredentialsProvider
+}
+
+func (c *awsCredentialChain) get(ctx context.Context) (aws.CredentialsProvider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider != nil {
+		return c.provider, nil
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.provider = cfg.Credentials
+
+	return c.provider, nil
+}
+
+// staticAwsCredentials returns the credentials of the params of a provider
+// setting. Nil is returned if the setting has no access key.
+func staticAwsCredentials(params map[string]string) aws.CredentialsProvider {
+	if len(params["awsAccessKeyId"]) == 0 {
+		return nil
+	}
+
+	return credentials.StaticCredentialsProvider{
+		Value: aws.Credentials{
+			AccessKeyID:     params["awsAccessKeyId"],
+			SecretAccessKey: params["awsSecretAccessKey"],
+			SessionToken:    params["awsSessionToken"],
+			Source:          "BricksLLM Credentials",
+		},
+	}
+}
+
+func (s *sigV4Signer) Sign(ctx context.Context, req *http.Request) error {
+	body := []byte{}
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+
+		body = data
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	return v4.NewSigner().SignHTTP(ctx, creds, req, payloadHash, s.service, s.region, time.Now())
+}
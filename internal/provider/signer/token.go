@@ -0,0 +1,139 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokens are refreshed slightly before they expire so that in flight requests
+// are not sent with an expired token.
+const tokenExpiryDelta = time.Minute
+
+// defaultTokenTtl is used for tokens whose response has no expiry.
+const defaultTokenTtl = 5 * time.Minute
+
+type accessToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+func hashKey(values ...string) string {
+	h := sha256.New()
+	for _, value := range values {
+		h.Write([]byte(value))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// tokenFetch is a token request in flight that concurrent misses of the same
+// key wait for.
+type tokenFetch struct {
+	done  chan struct{}
+	token *accessToken
+	err   error
+}
+
+// tokenCache caches access tokens until they are about to expire.
+type tokenCache struct {
+	mu       sync.Mutex
+	tokens   map[string]*accessToken
+	inflight map[string]*tokenFetch
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{
+		tokens:   map[string]*accessToken{},
+		inflight: map[string]*tokenFetch{},
+	}
+}
+
+func (tc *tokenCache) get(key string, fetch func() (*accessToken, error)) (string, error) {
+	tc.mu.Lock()
+	if cached, ok := tc.tokens[key]; ok && time.Now().Add(tokenExpiryDelta).Before(cached.expiresAt) {
+		tc.mu.Unlock()
+		return cached.value, nil
+	}
+
+	if f, ok := tc.inflight[key]; ok {
+		tc.mu.Unlock()
+		<-f.done
+
+		if f.err != nil {
+			return "", f.err
+		}
+
+		return f.token.value, nil
+	}
+
+	f := &tokenFetch{done: make(chan struct{})}
+	tc.inflight[key] = f
+	tc.mu.Unlock()
+
+	f.token, f.err = fetch()
+
+	tc.mu.Lock()
+	delete(tc.inflight, key)
+	if f.err == nil {
+		tc.tokens[key] = f.token
+	}
+	tc.mu.Unlock()
+	close(f.done)
+
+	if f.err != nil {
+		return "", f.err
+	}
+
+	return f.token.value, nil
+}
+
+func doTokenRequest(client http.Client, req *http.Request, now time.Time) (*accessToken, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with status code %d: %s", res.StatusCode, string(data))
+	}
+
+	tr := &tokenResponse{}
+	if err := json.Unmarshal(data, tr); err != nil {
+		return nil, err
+	}
+
+	if len(tr.AccessToken) == 0 {
+		return nil, errors.New("token response does not contain an access token")
+	}
+
+	ttl := time.Duration(tr.ExpiresIn) * time.Second
+	if ttl <= 0 {
+		ttl = defaultTokenTtl
+	}
+
+	return &accessToken{
+		value:     tr.AccessToken,
+		expiresAt: now.Add(ttl),
+	}, nil
+}
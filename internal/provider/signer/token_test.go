@@ -0,0 +1,96 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCacheFetchesOncePerMiss(t *testing.T) {
+	tc := newTokenCache()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func() (*accessToken, error) {
+		calls.Add(1)
+		<-release
+		return &accessToken{value: "token", expiresAt: time.Now().Add(time.Hour)}, nil
+	}
+
+	wg := sync.WaitGroup{}
+	values := make([]string, 10)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], _ = tc.get("key", fetch)
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, v := range values {
+		assert.Equal(t, "token", v)
+	}
+
+	value, err := tc.get("key", fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "token", value)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestTokenCacheDoesNotCacheErrors(t *testing.T) {
+	tc := newTokenCache()
+
+	_, err := tc.get("key", func() (*accessToken, error) {
+		return nil, errors.New("unavailable")
+	})
+	assert.Error(t, err)
+
+	value, err := tc.get("key", func() (*accessToken, error) {
+		return &accessToken{value: "token", expiresAt: time.Now().Add(time.Hour)}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "token", value)
+}
+
+func TestDoTokenRequestExpiry(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		body string
+		want time.Time
+	}{
+		{name: "expires in", body: `{"access_token":"token","expires_in":3600}`, want: now.Add(time.Hour)},
+		{name: "missing expires in", body: `{"access_token":"token"}`, want: now.Add(defaultTokenTtl)},
+		{name: "zero expires in", body: `{"access_token":"token","expires_in":0}`, want: now.Add(defaultTokenTtl)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+			require.NoError(t, err)
+
+			token, err := doTokenRequest(http.Client{}, req, now)
+			require.NoError(t, err)
+			assert.Equal(t, "token", token.value)
+			assert.Equal(t, tt.want, token.expiresAt)
+		})
+	}
+}
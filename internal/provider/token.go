@@ -0,0 +1,23 @@
+package provider
+
+import (
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// CountChatRequestTokens approximates the prompt tokens of a chat request
+// by counting the texts of its messages.
+func CountChatRequestTokens(r *goopenai.ChatCompletionRequest, count func(input string) int) int {
+	if r == nil {
+		return 0
+	}
+
+	tks := 0
+	for _, message := range r.Messages {
+		tks += count(message.Content)
+		for _, part := range message.MultiContent {
+			tks += count(part.Text)
+		}
+	}
+
+	return tks
+}
@@ -0,0 +1,134 @@
+package vertex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+)
+
+// VertexPerMillionTokenCost maps partner models served by Vertex AI to their
+// cost per million tokens. Gemini models are priced like on the Gemini API.
+var VertexPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"claude-3-opus":     15,
+		"claude-3-sonnet":   3,
+		"claude-3-5-sonnet": 3,
+		"claude-3-haiku":    0.25,
+	},
+	"completion": {
+		"claude-3-opus":     75,
+		"claude-3-sonnet":   15,
+		"claude-3-5-sonnet": 15,
+		"claude-3-haiku":    1.25,
+	},
+}
+
+// PricingTable returns the cost per million tokens of the Gemini and partner
+// models served by Vertex AI.
+func PricingTable() map[string]map[string]float64 {
+	table := map[string]map[string]float64{}
+	for _, costs := range []map[string]map[string]float64{gemini.GeminiPerMillionTokenCost, VertexPerMillionTokenCost} {
+		for kind, costPerModel := range costs {
+			if table[kind] == nil {
+				table[kind] = map[string]float64{}
+			}
+
+			for model, cost := range costPerModel {
+				table[kind][model] = cost
+			}
+		}
+	}
+
+	return table
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+	gemini       *gemini.CostEstimator
+}
+
+func NewCostEstimator() *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: VertexPerMillionTokenCost,
+		// token counts are not estimated when pricing Gemini models.
+		gemini: gemini.NewCostEstimator(nil),
+	}
+}
+
+func isGeminiModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(model), "gemini")
+}
+
+// selectModel maps versioned model names such as claude-3-5-sonnet@20240620
+// to the model family used for pricing.
+func selectModel(model string) string {
+	lowerCased := strings.ToLower(model)
+	if idx := strings.Index(lowerCased, "@"); idx != -1 {
+		lowerCased = lowerCased[:idx]
+	}
+
+	families := []string{"claude-3-5-sonnet", "claude-3-opus", "claude-3-sonnet", "claude-3-haiku"}
+	for _, family := range families {
+		if strings.HasPrefix(lowerCased, family) {
+			return family
+		}
+	}
+
+	return lowerCased
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	if isGeminiModel(model) {
+		return ce.gemini.EstimateTotalCost(model, promptTks, completionTks, 0)
+	}
+
+	promptCost, err := ce.estimateCost("prompt", model, promptTks)
+	if err != nil {
+		return 0, err
+	}
+
+	completionCost, err := ce.estimateCost("completion", model, completionTks)
+	if err != nil {
+		return 0, err
+	}
+
+	return promptCost + completionCost, nil
+}
+
+// EstimateCacheCost estimates the cost of prompt caching of Claude models
+// priced relative to their input token price like on the Anthropic API.
+func (ce *CostEstimator) EstimateCacheCost(model string, cacheWriteTks, cacheReadTks int) (float64, error) {
+	if cacheWriteTks == 0 && cacheReadTks == 0 {
+		return 0, nil
+	}
+
+	writeCost, err := ce.estimateCost("prompt", model, cacheWriteTks)
+	if err != nil {
+		return 0, err
+	}
+
+	readCost, err := ce.estimateCost("prompt", model, cacheReadTks)
+	if err != nil {
+		return 0, err
+	}
+
+	return writeCost*anthropic.CacheWriteCostMultiplier + readCost*anthropic.CacheReadCostMultiplier, nil
+}
+
+func (ce *CostEstimator) estimateCost(kind, model string, tks int) (float64, error) {
+	costMap, ok := ce.tokenCostMap[kind]
+	if !ok {
+		return 0, errors.New(kind + " token cost is not provided")
+	}
+
+	cost, ok := costMap[selectModel(model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	tksInFloat := float64(tks)
+	return tksInFloat / 1000000 * cost, nil
+}
@@ -0,0 +1,82 @@
+package vertex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+)
+
+// Gemini models served by Vertex AI share the methods, requests and responses
+// of the gemini package.
+const (
+	ActionGenerateContent       = gemini.ActionGenerateContent
+	ActionStreamGenerateContent = gemini.ActionStreamGenerateContent
+	ActionRawPredict            = "rawPredict"
+	ActionStreamRawPredict      = "streamRawPredict"
+	ActionCountTokens           = gemini.ActionCountTokens
+)
+
+var supportedActions = map[string]bool{
+	ActionGenerateContent:       true,
+	ActionStreamGenerateContent: true,
+	ActionRawPredict:            true,
+	ActionStreamRawPredict:      true,
+	ActionCountTokens:           true,
+}
+
+// ParseModelAction splits the last path segment of a Vertex AI publisher model
+// request, such as gemini-1.5-pro:generateContent, into the model and the
+// method invoked on it.
+func ParseModelAction(segment string) (string, string, error) {
+	model, action, ok := gemini.SplitModelAction(segment)
+	if !ok {
+		return "", "", fmt.Errorf("%s is not a valid vertex ai model method", segment)
+	}
+
+	if !supportedActions[action] {
+		return "", "", fmt.Errorf("vertex ai method %s is not supported", action)
+	}
+
+	return model, action, nil
+}
+
+func IsStreamingAction(action string) bool {
+	return action == ActionStreamGenerateContent || action == ActionStreamRawPredict
+}
+
+// GetEndpoint returns the regional Vertex AI endpoint. A custom endpoint such
+// as a private service connect address takes precedence over the region.
+func GetEndpoint(region, endpoint string) string {
+	if len(endpoint) != 0 {
+		return strings.TrimSuffix(endpoint, "/")
+	}
+
+	if region == "global" {
+		return "https://aiplatform.googleapis.com"
+	}
+
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com", region)
+}
+
+// GetModelUrl builds the url of a Vertex AI publisher model method.
+func GetModelUrl(endpoint, projectId, region, publisher, model, action string) string {
+	return fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/%s/models/%s:%s", endpoint, projectId, region, publisher, model, action)
+}
+
+type PartnerUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// PartnerResponse is the usage reported by partner models such as Anthropic
+// Claude served through the rawPredict and streamRawPredict methods.
+type PartnerResponse struct {
+	Type    string        `json:"type"`
+	Usage   *PartnerUsage `json:"usage"`
+	Message *struct {
+		Usage *PartnerUsage `json:"usage"`
+	} `json:"message"`
+}
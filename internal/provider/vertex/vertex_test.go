@@ -0,0 +1,66 @@
+package vertex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModelAction(t *testing.T) {
+	tests := []struct {
+		segment string
+		model   string
+		action  string
+		wantErr bool
+	}{
+		{segment: "gemini-1.5-pro:generateContent", model: "gemini-1.5-pro", action: ActionGenerateContent},
+		{segment: "claude-3-5-sonnet@20240620:streamRawPredict", model: "claude-3-5-sonnet@20240620", action: ActionStreamRawPredict},
+		{segment: "gemini-1.5-pro:embedContent", wantErr: true},
+		{segment: "gemini-1.5-pro", wantErr: true},
+		{segment: ":generateContent", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			model, action, err := ParseModelAction(tt.segment)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.model, model)
+			assert.Equal(t, tt.action, action)
+		})
+	}
+}
+
+func TestEstimateTotalCost(t *testing.T) {
+	ce := NewCostEstimator()
+
+	tests := []struct {
+		model   string
+		prompt  int
+		want    float64
+		wantErr bool
+	}{
+		{model: "gemini-1.5-pro-002", prompt: 1000, want: 0.00625},
+		{model: "gemini-1.5-pro-002", prompt: 200000, want: 0.51},
+		{model: "claude-3-haiku@20240307", prompt: 1000, want: 0.0015},
+		{model: "llama-3-70b", prompt: 1000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			cost, err := ce.EstimateTotalCost(tt.model, tt.prompt, 1000)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, cost, 1e-9)
+		})
+	}
+}
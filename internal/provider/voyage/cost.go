@@ -0,0 +1,73 @@
+package voyage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// VoyagePerMillionTokenCost maps embedding models to their cost per million
+// tokens.
+// updated according to this link:
+// https://docs.voyageai.com/docs/pricing
+var VoyagePerMillionTokenCost = map[string]map[string]float64{
+	"embeddings": {
+		"voyage-3-large":          0.18,
+		"voyage-3.5":              0.06,
+		"voyage-3.5-lite":         0.02,
+		"voyage-3":                0.06,
+		"voyage-3-lite":           0.02,
+		"voyage-code-3":           0.18,
+		"voyage-finance-2":        0.12,
+		"voyage-law-2":            0.12,
+		"voyage-code-2":           0.12,
+		"voyage-multilingual-2":   0.12,
+		"voyage-large-2-instruct": 0.12,
+		"voyage-large-2":          0.12,
+		"voyage-2":                0.1,
+		"voyage-multimodal-3":     0.12,
+	},
+}
+
+type tokenCounter interface {
+	Count(input string) int
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+	tc           tokenCounter
+}
+
+func NewCostEstimator(tc tokenCounter) *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: VoyagePerMillionTokenCost,
+		tc:           tc,
+	}
+}
+
+func (ce *CostEstimator) getCost(kind, model string) (float64, error) {
+	costMap, ok := ce.tokenCostMap[kind]
+	if !ok {
+		return 0, errors.New(kind + " token cost is not provided")
+	}
+
+	cost, ok := costMap[strings.ToLower(model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return cost, nil
+}
+
+func (ce *CostEstimator) EstimateEmbeddingsInputCost(model string, tks int) (float64, error) {
+	cost, err := ce.getCost("embeddings", model)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(tks) * cost / 1000000, nil
+}
+
+func (ce *CostEstimator) Count(input string) int {
+	return ce.tc.Count(input)
+}
@@ -0,0 +1,80 @@
+package voyage
+
+import (
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+// Plugin proxies the embeddings endpoint of Voyage AI.
+type Plugin struct {
+	ce *CostEstimator
+}
+
+func NewPlugin() (*Plugin, error) {
+	tc, err := NewTokenCounter()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Plugin{
+		ce: NewCostEstimator(tc),
+	}, nil
+}
+
+func (p *Plugin) Name() string {
+	return "voyage"
+}
+
+func (p *Plugin) RoutePrefix() string {
+	return "/api/providers/voyage"
+}
+
+func (p *Plugin) Endpoints() []*provider.Endpoint {
+	return []*provider.Endpoint{
+		{Path: "/v1/embeddings", Url: EmbeddingsUrl, Kind: provider.EmbeddingsEndpoint},
+	}
+}
+
+func (p *Plugin) ParseRequest(ep *provider.Endpoint, body []byte) (*provider.PluginRequest, error) {
+	er := &EmbeddingRequest{}
+	if err := json.Unmarshal(body, er); err != nil {
+		return nil, err
+	}
+
+	return &provider.PluginRequest{
+		Model: er.Model,
+		Body:  er,
+	}, nil
+}
+
+func (p *Plugin) ParseUsage(ep *provider.Endpoint, body []byte) (*provider.Usage, error) {
+	er := &EmbeddingResponse{}
+	if err := json.Unmarshal(body, er); err != nil {
+		return nil, err
+	}
+
+	if er.Usage == nil {
+		return nil, nil
+	}
+
+	return &provider.Usage{
+		PromptTokens: er.Usage.TotalTokens,
+	}, nil
+}
+
+func (p *Plugin) NewStreamDecoder(ep *provider.Endpoint) provider.StreamDecoder {
+	return nil
+}
+
+func (p *Plugin) EstimateCost(ep *provider.Endpoint, model string, usage *provider.Usage) (float64, error) {
+	return p.ce.EstimateEmbeddingsInputCost(model, usage.PromptTokens)
+}
+
+func (p *Plugin) CountTokens(text string) int {
+	return p.ce.Count(text)
+}
+
+func (p *Plugin) PricingTable() map[string]map[string]float64 {
+	return VoyagePerMillionTokenCost
+}
@@ -0,0 +1,99 @@
+package voyage
+
+import (
+	"encoding/json"
+)
+
+const EmbeddingsUrl = "https://api.voyageai.com/v1/embeddings"
+
+// EmbeddingRequest is a request of the embeddings endpoint. Fields other
+// than the model and the input, such as input_type, are kept as is so that a
+// request can be forwarded after its texts are redacted.
+type EmbeddingRequest struct {
+	Model string `json:"model"`
+	Input any    `json:"input"`
+
+	raw map[string]json.RawMessage
+}
+
+type embeddingRequest EmbeddingRequest
+
+func (r *EmbeddingRequest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.raw); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, (*embeddingRequest)(r))
+}
+
+func (r EmbeddingRequest) MarshalJSON() ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	for k, v := range r.raw {
+		fields[k] = v
+	}
+
+	model, err := json.Marshal(r.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := json.Marshal(r.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["model"] = model
+	fields["input"] = input
+
+	return json.Marshal(fields)
+}
+
+// Texts returns the texts of the input, which is either a string or a list
+// of strings.
+func (r *EmbeddingRequest) Texts() []string {
+	if text, ok := r.Input.(string); ok {
+		return []string{text}
+	}
+
+	texts := []string{}
+	if inputs, ok := r.Input.([]interface{}); ok {
+		for _, input := range inputs {
+			if text, ok := input.(string); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+
+	return texts
+}
+
+// SetTexts replaces the texts of the input in the order returned by Texts.
+func (r *EmbeddingRequest) SetTexts(texts []string) {
+	if _, ok := r.Input.(string); ok && len(texts) == 1 {
+		r.Input = texts[0]
+		return
+	}
+
+	inputs, ok := r.Input.([]interface{})
+	if !ok {
+		return
+	}
+
+	idx := 0
+	for i, input := range inputs {
+		if _, ok := input.(string); ok && idx < len(texts) {
+			inputs[i] = texts[idx]
+			idx++
+		}
+	}
+}
+
+type Usage struct {
+	TotalTokens int `json:"total_tokens"`
+}
+
+type EmbeddingResponse struct {
+	Object string `json:"object"`
+	Model  string `json:"model"`
+	Usage  *Usage `json:"usage"`
+}
@@ -0,0 +1,113 @@
+package xai
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider/compatible"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// XaiPerMillionTokenCost maps models to their cost per million tokens.
+// updated according to this link:
+// https://docs.x.ai/docs/models
+var XaiPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"grok-4":             3,
+		"grok-4-0709":        3,
+		"grok-3":             3,
+		"grok-3-latest":      3,
+		"grok-3-fast":        5,
+		"grok-3-mini":        0.3,
+		"grok-3-mini-fast":   0.6,
+		"grok-2":             2,
+		"grok-2-1212":        2,
+		"grok-2-vision":      2,
+		"grok-2-vision-1212": 2,
+		"grok-beta":          5,
+		"grok-vision-beta":   5,
+	},
+	"cached_prompt": {
+		"grok-4":           0.75,
+		"grok-4-0709":      0.75,
+		"grok-3":           0.75,
+		"grok-3-latest":    0.75,
+		"grok-3-fast":      1.25,
+		"grok-3-mini":      0.075,
+		"grok-3-mini-fast": 0.15,
+	},
+	"completion": {
+		"grok-4":             15,
+		"grok-4-0709":        15,
+		"grok-3":             15,
+		"grok-3-latest":      15,
+		"grok-3-fast":        25,
+		"grok-3-mini":        0.5,
+		"grok-3-mini-fast":   4,
+		"grok-2":             10,
+		"grok-2-1212":        10,
+		"grok-2-vision":      10,
+		"grok-2-vision-1212": 10,
+		"grok-beta":          15,
+		"grok-vision-beta":   15,
+	},
+}
+
+// CostEstimator prices xAI models with the cached prompt price on top of the
+// prices of OpenAI compatible providers.
+type CostEstimator struct {
+	*compatible.CostEstimator
+}
+
+func NewCostEstimator(tc compatible.Counter) *CostEstimator {
+	return &CostEstimator{
+		CostEstimator: compatible.NewCostEstimator(XaiPerMillionTokenCost, nil, tc),
+	}
+}
+
+// EstimateUsageCost estimates the cost of a request from its usage. Cached
+// prompt tokens are charged the cached prompt price when the model has one.
+// Reasoning tokens are charged the completion price and are added to the
+// completion tokens when xAI reports them outside of the completion count.
+func (ce *CostEstimator) EstimateUsageCost(model string, usage *goopenai.Usage) (float64, error) {
+	if usage == nil {
+		return 0, nil
+	}
+
+	promptCost, err := ce.GetCost("prompt", model)
+	if err != nil {
+		return 0, err
+	}
+
+	completionCost, err := ce.GetCost("completion", model)
+	if err != nil {
+		return 0, err
+	}
+
+	cachedPromptCost, err := ce.GetCostOr("cached_prompt", "prompt", model)
+	if err != nil {
+		return 0, err
+	}
+
+	cached := CachedTokens(usage)
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+
+	cost := float64(usage.PromptTokens-cached)*promptCost + float64(cached)*cachedPromptCost
+	cost += float64(CompletionTokens(usage)) * completionCost
+
+	return cost / 1000000, nil
+}
+
+// CompletionTokens returns the billed completion tokens of the usage
+// including reasoning tokens.
+func CompletionTokens(usage *goopenai.Usage) int {
+	if usage == nil {
+		return 0
+	}
+
+	reasoning := ReasoningTokens(usage)
+	if reasoning != 0 && usage.TotalTokens >= usage.PromptTokens+usage.CompletionTokens+reasoning {
+		return usage.CompletionTokens + reasoning
+	}
+
+	return usage.CompletionTokens
+}
@@ -0,0 +1,39 @@
+package xai
+
+import (
+	"errors"
+	"strings"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const ChatCompletionsUrl = "https://api.x.ai/v1/chat/completions"
+
+const apiKeyPrefix = "xai-"
+
+// ValidateApiKey checks that the key has the format of an xAI api key.
+func ValidateApiKey(key string) error {
+	if !strings.HasPrefix(key, apiKeyPrefix) || len(key) == len(apiKeyPrefix) {
+		return errors.New("xai api key must start with " + apiKeyPrefix)
+	}
+
+	return nil
+}
+
+// ReasoningTokens returns the reasoning tokens reported in the usage.
+func ReasoningTokens(usage *goopenai.Usage) int {
+	if usage == nil || usage.CompletionTokensDetails == nil {
+		return 0
+	}
+
+	return usage.CompletionTokensDetails.ReasoningTokens
+}
+
+// CachedTokens returns the prompt tokens read from the cache.
+func CachedTokens(usage *goopenai.Usage) int {
+	if usage == nil || usage.PromptTokensDetails == nil {
+		return 0
+	}
+
+	return usage.PromptTokensDetails.CachedTokens
+}
@@ -0,0 +1,240 @@
+package reconciliation
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+// minCorrectionInMicros is the smallest drift that gets corrected. Spend is
+// stored as floating point dollars in events, so tiny differences caused by
+// rounding are ignored.
+const minCorrectionInMicros = 100
+
+type keyStorage interface {
+	GetAllKeys() ([]*key.ResponseKey, error)
+}
+
+type eventStorage interface {
+	GetSpendByKeyIdsSince(keyIds []string, since int64) (map[string]float64, error)
+}
+
+type costStorage interface {
+	GetCounter(keyId string) (int64, error)
+	IncrementCounter(keyId string, incr int64) error
+}
+
+type costLimitCache interface {
+	GetCounter(keyId string, timeUnit key.TimeUnit) (int64, error)
+	IncrementCounter(keyId string, timeUnit key.TimeUnit, incr int64) error
+}
+
+// Job periodically recomputes spend of keys with cost limits from the events
+// table and corrects Redis counters that drifted below it. Counters are never
+// lowered since events removed by retention or erasure no longer add to the
+// recomputed spend while spend is counted before events are stored.
+type Job struct {
+	ks       keyStorage
+	es       eventStorage
+	cs       costStorage
+	clc      costLimitCache
+	log      *zap.Logger
+	interval time.Duration
+	done     chan bool
+}
+
+func NewJob(ks keyStorage, es eventStorage, cs costStorage, clc costLimitCache, log *zap.Logger, interval time.Duration) *Job {
+	return &Job{
+		ks:       ks,
+		es:       es,
+		cs:       cs,
+		clc:      clc,
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+}
+
+// windowStart returns the start of the cost limit window that is currently
+// being counted. Windows shorter than an hour are not reconciled since they
+// expire before events can be reliably recomputed.
+func windowStart(now time.Time, unit key.TimeUnit) (int64, bool) {
+	now = now.UTC()
+	switch unit {
+	case key.HourTimeUnit:
+		return now.Truncate(time.Hour).Unix(), true
+	case key.DayTimeUnit:
+		return now.Truncate(24 * time.Hour).Unix(), true
+	case key.MonthTimeUnit:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Unix(), true
+	}
+
+	return 0, false
+}
+
+func toMicros(usd float64) int64 {
+	return int64(usd * 1000000)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+func recordCorrection(counter string, correction int64) {
+	telemetry.Incr("bricksllm.reconciliation.job.correction", []string{
+		"counter:" + counter,
+	}, 1)
+
+	telemetry.Histogram("bricksllm.reconciliation.job.correction_size_in_micros", float64(abs(correction)), []string{
+		"counter:" + counter,
+	}, 1)
+}
+
+// reconcileTotal only corrects the total spend counter upwards.
+func (j *Job) reconcileTotal(k *key.ResponseKey, spend map[string]float64) {
+	counter, err := j.cs.GetCounter(k.KeyId)
+	if err != nil {
+		telemetry.Incr("bricksllm.reconciliation.job.reconcile_total.get_counter_error", nil, 1)
+		j.log.Sugar().Debugf("reconciliation job failed to get spend counter of key %s: %v", k.KeyId, err)
+		return
+	}
+
+	correction := toMicros(spend[k.KeyId]) - counter
+	if correction < minCorrectionInMicros {
+		return
+	}
+
+	err = j.cs.IncrementCounter(k.KeyId, correction)
+	if err != nil {
+		telemetry.Incr("bricksllm.reconciliation.job.reconcile_total.increment_counter_error", nil, 1)
+		j.log.Sugar().Debugf("reconciliation job failed to correct spend counter of key %s: %v", k.KeyId, err)
+		return
+	}
+
+	recordCorrection("total", correction)
+	j.log.Sugar().Infof("reconciliation job corrected total spend of key %s by %d micro dollars", k.KeyId, correction)
+}
+
+// reconcileWindow only corrects the spend counter of the current window
+// upwards.
+func (j *Job) reconcileWindow(k *key.ResponseKey, spend map[string]float64) {
+	counter, err := j.clc.GetCounter(k.KeyId, k.CostLimitInUsdUnit)
+	if err != nil {
+		telemetry.Incr("bricksllm.reconciliation.job.reconcile_window.get_counter_error", nil, 1)
+		j.log.Sugar().Debugf("reconciliation job failed to get windowed spend counter of key %s: %v", k.KeyId, err)
+		return
+	}
+
+	correction := toMicros(spend[k.KeyId]) - counter
+	if correction < minCorrectionInMicros {
+		return
+	}
+
+	err = j.clc.IncrementCounter(k.KeyId, k.CostLimitInUsdUnit, correction)
+	if err != nil {
+		telemetry.Incr("bricksllm.reconciliation.job.reconcile_window.increment_counter_error", nil, 1)
+		j.log.Sugar().Debugf("reconciliation job failed to correct windowed spend counter of key %s: %v", k.KeyId, err)
+		return
+	}
+
+	recordCorrection(string(k.CostLimitInUsdUnit), correction)
+	j.log.Sugar().Infof("reconciliation job corrected spend of key %s for the current %s window by %d micro dollars", k.KeyId, k.CostLimitInUsdUnit, correction)
+}
+
+// run reconciles keys with cost limits. Spend is only queried for the keys
+// being reconciled, once per window start.
+func (j *Job) run() {
+	keys, err := j.ks.GetAllKeys()
+	if err != nil {
+		telemetry.Incr("bricksllm.reconciliation.job.run.get_all_keys_error", nil, 1)
+		j.log.Sugar().Debugf("reconciliation job failed to get keys: %v", err)
+		return
+	}
+
+	now := time.Now()
+	limited := map[int64][]*key.ResponseKey{}
+	totals := []*key.ResponseKey{}
+	for _, k := range keys {
+		if k == nil {
+			continue
+		}
+
+		if k.CostLimitInUsd != 0 {
+			totals = append(totals, k)
+		}
+
+		if k.CostLimitInUsdOverTime == 0 {
+			continue
+		}
+
+		if since, ok := windowStart(now, k.CostLimitInUsdUnit); ok {
+			limited[since] = append(limited[since], k)
+		}
+	}
+
+	if len(totals) != 0 {
+		spend, err := j.es.GetSpendByKeyIdsSince(keyIds(totals), 0)
+		if err != nil {
+			telemetry.Incr("bricksllm.reconciliation.job.run.get_spend_error", nil, 1)
+			j.log.Sugar().Debugf("reconciliation job failed to get spend from events: %v", err)
+		}
+
+		if err == nil {
+			for _, k := range totals {
+				j.reconcileTotal(k, spend)
+			}
+		}
+	}
+
+	for since, ks := range limited {
+		spend, err := j.es.GetSpendByKeyIdsSince(keyIds(ks), since)
+		if err != nil {
+			telemetry.Incr("bricksllm.reconciliation.job.run.get_spend_error", nil, 1)
+			j.log.Sugar().Debugf("reconciliation job failed to get windowed spend from events: %v", err)
+			continue
+		}
+
+		for _, k := range ks {
+			j.reconcileWindow(k, spend)
+		}
+	}
+}
+
+func keyIds(keys []*key.ResponseKey) []string {
+	ids := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ids = append(ids, k.KeyId)
+	}
+
+	return ids
+}
+
+func (j *Job) Start() {
+	ticker := time.NewTicker(j.interval)
+	j.log.Info("reconciliation job started")
+
+	go func() {
+		for {
+			select {
+			case <-j.done:
+				ticker.Stop()
+				j.log.Info("reconciliation job stopped")
+				return
+			case <-ticker.C:
+				j.run()
+			}
+		}
+	}()
+}
+
+func (j *Job) Stop() {
+	j.log.Info("shutting down reconciliation job...")
+
+	j.done <- true
+}
@@ -0,0 +1,89 @@
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeKeyStorage struct {
+	keys []*key.ResponseKey
+}
+
+func (s *fakeKeyStorage) GetAllKeys() ([]*key.ResponseKey, error) {
+	return s.keys, nil
+}
+
+type fakeEventStorage struct {
+	spend   map[string]float64
+	queried [][]string
+}
+
+func (s *fakeEventStorage) GetSpendByKeyIdsSince(keyIds []string, since int64) (map[string]float64, error) {
+	s.queried = append(s.queried, keyIds)
+
+	spend := map[string]float64{}
+	for _, id := range keyIds {
+		if cost, ok := s.spend[id]; ok {
+			spend[id] = cost
+		}
+	}
+
+	return spend, nil
+}
+
+type fakeCounters struct {
+	counters map[string]int64
+}
+
+func (c *fakeCounters) GetCounter(keyId string) (int64, error) {
+	return c.counters[keyId], nil
+}
+
+func (c *fakeCounters) IncrementCounter(keyId string, incr int64) error {
+	c.counters[keyId] += incr
+	return nil
+}
+
+type fakeWindowCounters struct {
+	fakeCounters
+}
+
+func (c *fakeWindowCounters) GetCounter(keyId string, timeUnit key.TimeUnit) (int64, error) {
+	return c.counters[keyId], nil
+}
+
+func (c *fakeWindowCounters) IncrementCounter(keyId string, timeUnit key.TimeUnit, incr int64) error {
+	c.counters[keyId] += incr
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	keys := []*key.ResponseKey{
+		{KeyId: "drifted", CostLimitInUsd: 10, CostLimitInUsdOverTime: 5, CostLimitInUsdUnit: key.DayTimeUnit},
+		{KeyId: "erased", CostLimitInUsd: 10, CostLimitInUsdOverTime: 5, CostLimitInUsdUnit: key.DayTimeUnit},
+		{KeyId: "unlimited"},
+	}
+
+	es := &fakeEventStorage{spend: map[string]float64{"drifted": 2, "erased": 1, "unlimited": 3}}
+	cs := &fakeCounters{counters: map[string]int64{"drifted": 1000000, "erased": 2000000}}
+	clc := &fakeWindowCounters{fakeCounters{counters: map[string]int64{"drifted": 1000000, "erased": 2000000}}}
+
+	j := NewJob(&fakeKeyStorage{keys: keys}, es, cs, clc, zap.NewNop(), time.Minute)
+	j.run()
+
+	// drifted counters are corrected upwards while counters of keys whose
+	// events were erased are kept.
+	assert.Equal(t, int64(2000000), cs.counters["drifted"])
+	assert.Equal(t, int64(2000000), cs.counters["erased"])
+	assert.Equal(t, int64(2000000), clc.counters["drifted"])
+	assert.Equal(t, int64(2000000), clc.counters["erased"])
+
+	// spend is only queried for keys with cost limits.
+	for _, ids := range es.queried {
+		assert.ElementsMatch(t, []string{"drifted", "erased"}, ids)
+	}
+}
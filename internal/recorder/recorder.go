@@ -12,12 +12,17 @@ type Recorder struct {
 	uc Cache
 	ce CostEstimator
 	es EventsStore
+	ss SessionStore
 }
 
 type EventsStore interface {
 	InsertEvent(e *event.Event) error
 }
 
+type SessionStore interface {
+	IncrementUsage(keyId, sessionId string, micros, tks int64) error
+}
+
 type Store interface {
 	IncrementCounter(keyId string, incr int64) error
 }
@@ -31,7 +36,7 @@ type CostEstimator interface {
 	EstimateCompletionCost(model string, tks int) (float64, error)
 }
 
-func NewRecorder(s, us Store, c, uc Cache, ce CostEstimator, es EventsStore) *Recorder {
+func NewRecorder(s, us Store, c, uc Cache, ce CostEstimator, es EventsStore, ss SessionStore) *Recorder {
 	return &Recorder{
 		s:  s,
 		c:  c,
@@ -39,6 +44,7 @@ func NewRecorder(s, us Store, c, uc Cache, ce CostEstimator, es EventsStore) *Re
 		uc: uc,
 		ce: ce,
 		es: es,
+		ss: ss,
 	}
 }
 
@@ -74,6 +80,51 @@ func (r *Recorder) RecordKeySpend(keyId string, micros int64, costLimitUnit key.
 	return nil
 }
 
+func (r *Recorder) RecordSessionUsage(keyId, sessionId string, micros int64, tks int) error {
+	return r.ss.IncrementUsage(keyId, sessionId, micros, int64(tks))
+}
+
 func (r *Recorder) RecordEvent(e *event.Event) error {
 	return r.es.InsertEvent(e)
 }
+
+// AnonymizedEventsStore anonymizes events before they are written.
+type AnonymizedEventsStore struct {
+	es EventsStore
+	a  *event.Anonymizer
+}
+
+func NewAnonymizedEventsStore(es EventsStore, a *event.Anonymizer) *AnonymizedEventsStore {
+	return &AnonymizedEventsStore{
+		es: es,
+		a:  a,
+	}
+}
+
+func (s *AnonymizedEventsStore) InsertEvent(e *event.Event) error {
+	s.a.Anonymize(e)
+
+	return s.es.InsertEvent(e)
+}
+
+// RegionalEventsStore tags events with the region of the gateway that
+// served the request before they are written.
+type RegionalEventsStore struct {
+	es     EventsStore
+	region string
+}
+
+func NewRegionalEventsStore(es EventsStore, region string) *RegionalEventsStore {
+	return &RegionalEventsStore{
+		es:     es,
+		region: region,
+	}
+}
+
+func (s *RegionalEventsStore) InsertEvent(e *event.Event) error {
+	if e != nil {
+		e.Region = s.region
+	}
+
+	return s.es.InsertEvent(e)
+}
@@ -0,0 +1,115 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type keyStorage interface {
+	GetAllKeys() ([]*key.ResponseKey, error)
+}
+
+type eventStorage interface {
+	DeleteEventsByKeyIdBefore(keyId string, before int64) (int64, error)
+	StripEventPayloadsByKeyIdBefore(keyId string, before int64) (int64, error)
+}
+
+// Job periodically enforces data retention settings of keys by deleting
+// expired events and stripping logged payloads.
+type Job struct {
+	ks       keyStorage
+	es       eventStorage
+	log      *zap.Logger
+	interval time.Duration
+	done     chan bool
+}
+
+func NewJob(ks keyStorage, es eventStorage, log *zap.Logger, interval time.Duration) *Job {
+	return &Job{
+		ks:       ks,
+		es:       es,
+		log:      log,
+		interval: interval,
+		done:     make(chan bool),
+	}
+}
+
+func daysBefore(now time.Time, days int) int64 {
+	return now.Add(-time.Duration(days) * 24 * time.Hour).Unix()
+}
+
+func (j *Job) enforce(k *key.ResponseKey, now time.Time) {
+	if k.RetentionInDays != 0 {
+		deleted, err := j.es.DeleteEventsByKeyIdBefore(k.KeyId, daysBefore(now, k.RetentionInDays))
+		if err != nil {
+			telemetry.Incr("bricksllm.retention.job.enforce.delete_events_error", nil, 1)
+			j.log.Sugar().Debugf("retention job failed to delete events of key %s: %v", k.KeyId, err)
+		}
+
+		if deleted != 0 {
+			j.log.Sugar().Infof("retention job deleted %d events of key %s", deleted, k.KeyId)
+		}
+	}
+
+	if k.MetadataOnly || k.PayloadRetentionInDays != 0 {
+		before := now.Unix()
+		if !k.MetadataOnly {
+			before = daysBefore(now, k.PayloadRetentionInDays)
+		}
+
+		stripped, err := j.es.StripEventPayloadsByKeyIdBefore(k.KeyId, before)
+		if err != nil {
+			telemetry.Incr("bricksllm.retention.job.enforce.strip_payloads_error", nil, 1)
+			j.log.Sugar().Debugf("retention job failed to strip payloads of key %s: %v", k.KeyId, err)
+		}
+
+		if stripped != 0 {
+			j.log.Sugar().Infof("retention job stripped payloads of %d events of key %s", stripped, k.KeyId)
+		}
+	}
+}
+
+func (j *Job) run() {
+	keys, err := j.ks.GetAllKeys()
+	if err != nil {
+		telemetry.Incr("bricksllm.retention.job.run.get_all_keys_error", nil, 1)
+		j.log.Sugar().Debugf("retention job failed to get keys: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, k := range keys {
+		if k == nil || !k.HasRetentionPolicy() {
+			continue
+		}
+
+		j.enforce(k, now)
+	}
+}
+
+func (j *Job) Start() {
+	ticker := time.NewTicker(j.interval)
+	j.log.Info("retention job started")
+
+	go func() {
+		for {
+			select {
+			case <-j.done:
+				ticker.Stop()
+				j.log.Info("retention job stopped")
+				return
+			case <-ticker.C:
+				j.run()
+			}
+		}
+	}()
+}
+
+func (j *Job) Stop() {
+	j.log.Info("shutting down retention job...")
+
+	j.done <- true
+}
@@ -0,0 +1,140 @@
+package route
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// GuardrailConfig attaches a Bedrock guardrail to the bedrock steps of a
+// route. The guardrail trace is only requested if Trace is enabled, since
+// Bedrock adds it to response bodies.
+type GuardrailConfig struct {
+	Identifier string `json:"identifier"`
+	Version    string `json:"version"`
+	Trace      bool   `json:"trace"`
+}
+
+func (gc *GuardrailConfig) IsEnabled() bool {
+	return gc != nil && len(gc.Identifier) != 0
+}
+
+// GetVersion returns the version of the guardrail, which defaults to DRAFT.
+func (gc *GuardrailConfig) GetVersion() string {
+	if len(gc.Version) == 0 {
+		return "DRAFT"
+	}
+
+	return gc.Version
+}
+
+// newBedrockMessagesRequest converts a messages request into the body of a
+// Bedrock InvokeModel request, which names the model in its url.
+func newBedrockMessagesRequest(mr *anthropic.MessagesRequest) ([]byte, error) {
+	data, err := json.Marshal(mr)
+	if err != nil {
+		return nil, err
+	}
+
+	br := &anthropic.BedrockMessageRequest{}
+	if err := json.Unmarshal(data, br); err != nil {
+		return nil, err
+	}
+
+	br.AnthropicVersion = bedrockAnthropicVersion
+
+	return json.Marshal(br)
+}
+
+// createBedrockRequest creates an InvokeModel request of a bedrock step signed
+// with the credentials of the bedrock provider setting.
+func (r *Request) createBedrockRequest(ctx context.Context, model string, gc *GuardrailConfig, data []byte) (*http.Request, error) {
+	keyId, err := r.GetSettingValue("bedrock", "awsAccessKeyId")
+	if err != nil {
+		return nil, err
+	}
+
+	secretKey, err := r.GetSettingValue("bedrock", "awsSecretAccessKey")
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := r.GetSettingValue("bedrock", "awsRegion")
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/model/%s/invoke", region, url.PathEscape(model))
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("Accept", "application/json")
+
+	if gc.IsEnabled() {
+		hreq.Header.Set("X-Amzn-Bedrock-GuardrailIdentifier", gc.Identifier)
+		hreq.Header.Set("X-Amzn-Bedrock-GuardrailVersion", gc.GetVersion())
+
+		if gc.Trace {
+			hreq.Header.Set("X-Amzn-Bedrock-Trace", "ENABLED")
+		}
+	}
+
+	hash := sha256.Sum256(data)
+	credentials := aws.Credentials{AccessKeyID: keyId, SecretAccessKey: secretKey}
+	if err := v4.NewSigner().SignHTTP(ctx, credentials, hreq, hex.EncodeToString(hash[:]), "bedrock", region, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return hreq, nil
+}
+
+// recordBedrockGuardrailResult returns the result of the guardrail of a route
+// from a successful Bedrock response. Nil is returned if the route has no
+// guardrail or the response does not report its action.
+func recordBedrockGuardrailResult(res *http.Response, gc *GuardrailConfig) (*anthropic.GuardrailIntervention, error) {
+	if !gc.IsEnabled() {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(data))
+
+	output := &anthropic.BedrockGuardrailOutput{}
+	if err := json.Unmarshal(data, output); err != nil || len(output.Action) == 0 {
+		return nil, nil
+	}
+
+	if output.Action == anthropic.BedrockGuardrailIntervened {
+		telemetry.Incr("bricksllm.route.record_bedrock_guardrail_result.intervened", nil, 1)
+	}
+
+	return &anthropic.GuardrailIntervention{
+		GuardrailIdentifier: gc.Identifier,
+		GuardrailVersion:    gc.GetVersion(),
+		Action:              output.Action,
+		Trace:               output.Trace,
+	}, nil
+}
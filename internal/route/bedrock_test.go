@@ -0,0 +1,133 @@
+package route
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBedrockRequest(t *testing.T) {
+	req := &Request{
+		Settings: map[string]*provider.Setting{
+			"bedrock": {
+				Provider: "bedrock",
+				Setting: map[string]string{
+					"awsAccessKeyId":     "id",
+					"awsSecretAccessKey": "secret",
+					"awsRegion":          "us-east-1",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		gc         *GuardrailConfig
+		identifier string
+		version    string
+		trace      string
+	}{
+		{
+			name: "no guardrail",
+		},
+		{
+			name:       "guardrail without trace",
+			gc:         &GuardrailConfig{Identifier: "gr"},
+			identifier: "gr",
+			version:    "DRAFT",
+		},
+		{
+			name:       "guardrail with trace",
+			gc:         &GuardrailConfig{Identifier: "gr", Version: "2", Trace: true},
+			identifier: "gr",
+			version:    "2",
+			trace:      "ENABLED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hreq, err := req.createBedrockRequest(context.Background(), "anthropic.claude-3-haiku-20240307-v1:0", tt.gc, []byte(`{}`))
+			require.NoError(t, err)
+
+			assert.Equal(t, "https://bedrock-runtime.us-east-1.amazonaws.com/model/anthropic.claude-3-haiku-20240307-v1:0/invoke", hreq.URL.String())
+			assert.Equal(t, tt.identifier, hreq.Header.Get("X-Amzn-Bedrock-GuardrailIdentifier"))
+			assert.Equal(t, tt.version, hreq.Header.Get("X-Amzn-Bedrock-GuardrailVersion"))
+			assert.Equal(t, tt.trace, hreq.Header.Get("X-Amzn-Bedrock-Trace"))
+			assert.True(t, strings.HasPrefix(hreq.Header.Get("Authorization"), "AWS4-HMAC-SHA256"))
+		})
+	}
+}
+
+func TestNewBedrockMessagesRequest(t *testing.T) {
+	data, err := newBedrockMessagesRequest(&anthropic.MessagesRequest{
+		Model:     "claude-3-haiku",
+		MaxTokens: 10,
+		Messages:  []anthropic.Message{{Role: "user", Content: anthropic.MessageContent{Text: "hi"}}},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `"anthropic_version":"bedrock-2023-05-31"`)
+	assert.NotContains(t, string(data), `"model"`)
+}
+
+func TestRecordBedrockGuardrailResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		gc     *GuardrailConfig
+		body   string
+		action string
+		trace  bool
+	}{
+		{
+			name: "no guardrail",
+			body: `{"amazon-bedrock-guardrailAction":"INTERVENED"}`,
+		},
+		{
+			name: "no action",
+			gc:   &GuardrailConfig{Identifier: "gr"},
+			body: `{"id":"msg"}`,
+		},
+		{
+			name:   "intervened",
+			gc:     &GuardrailConfig{Identifier: "gr"},
+			body:   `{"amazon-bedrock-guardrailAction":"INTERVENED"}`,
+			action: "INTERVENED",
+		},
+		{
+			name:   "trace",
+			gc:     &GuardrailConfig{Identifier: "gr", Trace: true},
+			body:   `{"amazon-bedrock-guardrailAction":"NONE","amazon-bedrock-trace":{"guardrail":{}}}`,
+			action: "NONE",
+			trace:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{Body: io.NopCloser(strings.NewReader(tt.body))}
+
+			gi, err := recordBedrockGuardrailResult(res, tt.gc)
+			require.NoError(t, err)
+
+			if len(tt.action) == 0 {
+				assert.Nil(t, gi)
+			} else {
+				require.NotNil(t, gi)
+				assert.Equal(t, tt.action, gi.Action)
+				assert.Equal(t, tt.trace, len(gi.Trace) != 0)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
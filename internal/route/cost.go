@@ -0,0 +1,92 @@
+package route
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// RoutingStrategyCost orders the steps of a route satisfying its capability
+// tier from the cheapest provider and model.
+const RoutingStrategyCost = "cost"
+
+// priceSampleTokens is the number of prompt and completion tokens steps are
+// priced at when comparing their costs.
+const priceSampleTokens = 1000
+
+// CostEstimator estimates costs from the cost map of a provider.
+type CostEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+}
+
+// stepPrice returns the price of a thousand prompt and completion tokens of a
+// step. False is returned if the cost map of its provider does not price the
+// model.
+func (r *Request) stepPrice(step *Step) (float64, bool) {
+	ce, ok := r.Estimators[step.Provider]
+	if !ok || ce == nil {
+		return 0, false
+	}
+
+	price, err := ce.EstimateTotalCost(step.Model, priceSampleTokens, priceSampleTokens)
+	if err != nil {
+		return 0, false
+	}
+
+	return price, true
+}
+
+// orderByCost returns the steps satisfying a capability tier sorted from the
+// cheapest. Steps that cannot be priced are tried last in their configured
+// order. All steps are considered if the tier is empty.
+func (r *Request) orderByCost(steps []*Step, tier string) []*Step {
+	type priced struct {
+		step  *Step
+		price float64
+		ok    bool
+	}
+
+	candidates := []priced{}
+	for _, s := range steps {
+		if len(tier) != 0 && !provider.GetCapability(s.Model).SatisfiesTier(tier) {
+			continue
+		}
+
+		price, ok := r.stepPrice(s)
+		candidates = append(candidates, priced{step: s, price: price, ok: ok})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].ok != candidates[j].ok {
+			return candidates[i].ok
+		}
+
+		return candidates[i].price < candidates[j].price
+	})
+
+	ordered := make([]*Step, 0, len(candidates))
+	for _, c := range candidates {
+		ordered = append(ordered, c.step)
+	}
+
+	return ordered
+}
+
+// recordCostRoutingDecision emits the step that served a request of a cost
+// routed route along with its price.
+func recordCostRoutingDecision(req *Request, r *Route, step *Step) {
+	tags := []string{
+		fmt.Sprintf("route:%s", r.Path),
+		fmt.Sprintf("tier:%s", r.CapabilityTier),
+		fmt.Sprintf("provider:%s", step.Provider),
+		fmt.Sprintf("model:%s", step.Model),
+	}
+
+	telemetry.Incr("bricksllm.route.run_steps_v2.cost_routing.selected", tags, 1)
+
+	if price, ok := req.stepPrice(step); ok {
+		telemetry.Histogram("bricksllm.route.run_steps_v2.cost_routing.price_per_thousand_tokens", price, tags, 1)
+	}
+}
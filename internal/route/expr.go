@@ -0,0 +1,660 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Conditions of routing rules are written in a subset of CEL. They support
+// string, number, boolean and list literals, the ==, !=, <, <=, >, >=, in, &&,
+// || and ! operators, parentheses, map access with map.key or map["key"] and
+// the startsWith, endsWith and contains string methods.
+
+type exprTokenKind int
+
+const (
+	exprEOF exprTokenKind = iota
+	exprIdent
+	exprString
+	exprNumber
+	exprOp
+)
+
+type exprToken struct {
+	kind  exprTokenKind
+	value string
+	pos   int
+}
+
+var exprOps = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+func tokenizeExpr(input string) ([]exprToken, error) {
+	tokens := []exprToken{}
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+
+		if r == '"' || r == '\'' {
+			start := i
+			i++
+
+			sb := strings.Builder{}
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+
+				if runes[i] == r {
+					closed = true
+					i++
+					break
+				}
+
+				sb.WriteRune(runes[i])
+				i++
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+
+			tokens = append(tokens, exprToken{kind: exprString, value: sb.String(), pos: start})
+			continue
+		}
+
+		if unicode.IsDigit(r) {
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			tokens = append(tokens, exprToken{kind: exprNumber, value: string(runes[start:i]), pos: start})
+			continue
+		}
+
+		if unicode.IsLetter(r) || r == '_' {
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			tokens = append(tokens, exprToken{kind: exprIdent, value: string(runes[start:i]), pos: start})
+			continue
+		}
+
+		matched := false
+		for _, op := range exprOps {
+			if strings.HasPrefix(string(runes[i:]), op) {
+				tokens = append(tokens, exprToken{kind: exprOp, value: op, pos: i})
+				i += len([]rune(op))
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, exprToken{kind: exprEOF, pos: len(runes)}), nil
+}
+
+type exprNode interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type exprParser struct {
+	tokens   []exprToken
+	pos      int
+	declared map[string]bool
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.pos]
+	if t.kind != exprEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == exprOp && t.value == op {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %q at position %d", op, p.peek().pos)
+	}
+
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &exprLogical{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &exprLogical{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &exprNot{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == exprIdent && t.value == "in" {
+		p.next()
+		right, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+
+		return &exprCompare{op: "in", left: left, right: right}, nil
+	}
+
+	if t.kind == exprOp {
+		switch t.value {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+
+			return &exprCompare{op: t.value, left: left, right: right}, nil
+		}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.accept("[") {
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+
+			node = &exprIndex{target: node, index: index}
+			continue
+		}
+
+		if p.accept(".") {
+			name := p.next()
+			if name.kind != exprIdent {
+				return nil, fmt.Errorf("expected field or method name at position %d", name.pos)
+			}
+
+			if !p.accept("(") {
+				node = &exprIndex{target: node, index: &exprLiteral{value: name.value}}
+				continue
+			}
+
+			args := []exprNode{}
+			if !p.accept(")") {
+				for {
+					arg, err := p.parseOr()
+					if err != nil {
+						return nil, err
+					}
+
+					args = append(args, arg)
+					if p.accept(")") {
+						break
+					}
+
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			if _, ok := exprMethods[name.value]; !ok || len(args) != 1 {
+				return nil, fmt.Errorf("unsupported method %s at position %d", name.value, name.pos)
+			}
+
+			node = &exprMethod{name: name.value, target: node, arg: args[0]}
+			continue
+		}
+
+		return node, nil
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+
+	switch t.kind {
+	case exprString:
+		return &exprLiteral{value: t.value}, nil
+	case exprNumber:
+		parsed, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", t.value, t.pos)
+		}
+
+		return &exprLiteral{value: parsed}, nil
+	case exprIdent:
+		switch t.value {
+		case "true":
+			return &exprLiteral{value: true}, nil
+		case "false":
+			return &exprLiteral{value: false}, nil
+		case "null":
+			return &exprLiteral{value: nil}, nil
+		}
+
+		if !p.declared[t.value] {
+			return nil, fmt.Errorf("undeclared variable %s at position %d", t.value, t.pos)
+		}
+
+		return &exprVariable{name: t.value}, nil
+	case exprOp:
+		if t.value == "(" {
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+
+			return node, p.expect(")")
+		}
+
+		if t.value == "[" {
+			items := []exprNode{}
+			if p.accept("]") {
+				return &exprList{items: items}, nil
+			}
+
+			for {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+
+				items = append(items, item)
+				if p.accept("]") {
+					return &exprList{items: items}, nil
+				}
+
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if t.kind == exprEOF {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+}
+
+type exprLiteral struct {
+	value any
+}
+
+func (n *exprLiteral) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type exprVariable struct {
+	name string
+}
+
+func (n *exprVariable) eval(vars map[string]any) (any, error) {
+	val, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared variable %s", n.name)
+	}
+
+	return val, nil
+}
+
+type exprList struct {
+	items []exprNode
+}
+
+func (n *exprList) eval(vars map[string]any) (any, error) {
+	values := []any{}
+	for _, item := range n.items {
+		val, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, val)
+	}
+
+	return values, nil
+}
+
+type exprIndex struct {
+	target exprNode
+	index  exprNode
+}
+
+// eval returns null for keys missing from maps so that conditions over
+// optional headers and metadata do not fail.
+func (n *exprIndex) eval(vars map[string]any) (any, error) {
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := n.index.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case map[string]string:
+		key, ok := index.(string)
+		if !ok {
+			return nil, errors.New("map keys must be strings")
+		}
+
+		val, ok := t[key]
+		if !ok {
+			return nil, nil
+		}
+
+		return val, nil
+	case []any:
+		idx, ok := index.(float64)
+		if !ok || idx < 0 || idx != math.Trunc(idx) || int(idx) >= len(t) {
+			return nil, errors.New("list index out of range")
+		}
+
+		return t[int(idx)], nil
+	}
+
+	return nil, errors.New("only maps and lists can be indexed")
+}
+
+var exprMethods = map[string]func(string, string) bool{
+	"startsWith": strings.HasPrefix,
+	"endsWith":   strings.HasSuffix,
+	"contains":   strings.Contains,
+}
+
+type exprMethod struct {
+	name   string
+	target exprNode
+	arg    exprNode
+}
+
+func (n *exprMethod) eval(vars map[string]any) (any, error) {
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	arg, err := n.arg.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// missing map values do not satisfy string methods.
+	if target == nil {
+		return false, nil
+	}
+
+	s, ok := target.(string)
+	a, aok := arg.(string)
+	if !ok || !aok {
+		return nil, fmt.Errorf("%s is only supported on strings", n.name)
+	}
+
+	return exprMethods[n.name](s, a), nil
+}
+
+type exprNot struct {
+	operand exprNode
+}
+
+func (n *exprNot) eval(vars map[string]any) (any, error) {
+	val, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := val.(bool)
+	if !ok {
+		return nil, errors.New("! is only supported on booleans")
+	}
+
+	return !b, nil
+}
+
+type exprLogical struct {
+	op    string
+	left  exprNode
+	right exprNode
+}
+
+func (n *exprLogical) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	lb, ok := left.(bool)
+	if !ok {
+		return nil, fmt.Errorf("%s is only supported on booleans", n.op)
+	}
+
+	if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+		return lb, nil
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	rb, ok := right.(bool)
+	if !ok {
+		return nil, fmt.Errorf("%s is only supported on booleans", n.op)
+	}
+
+	return rb, nil
+}
+
+type exprCompare struct {
+	op    string
+	left  exprNode
+	right exprNode
+}
+
+func (n *exprCompare) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		if !isScalar(left) || !isScalar(right) {
+			return nil, fmt.Errorf("%s is only supported on strings, numbers, booleans and null", n.op)
+		}
+
+		return (left == right) == (n.op == "=="), nil
+	case "in":
+		list, ok := right.([]any)
+		if !ok {
+			return nil, errors.New("in is only supported on lists")
+		}
+
+		if !isScalar(left) {
+			return nil, errors.New("in is only supported on strings, numbers, booleans and null")
+		}
+
+		for _, item := range list {
+			if isScalar(item) && item == left {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	if l, ok := left.(float64); ok {
+		if r, ok := right.(float64); ok {
+			return compareOrdered(n.op, l, r), nil
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return compareOrdered(n.op, l, r), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s is only supported on numbers and strings of the same type", n.op)
+}
+
+func isScalar(val any) bool {
+	switch val.(type) {
+	case nil, string, float64, bool:
+		return true
+	}
+
+	return false
+}
+
+func compareOrdered[T float64 | string](op string, l, r T) bool {
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	}
+
+	return l >= r
+}
+
+// Condition is a compiled condition of a routing rule.
+type Condition struct {
+	root exprNode
+}
+
+// CompileCondition parses the condition of a routing rule over the request
+// attributes exposed to routing rules.
+func CompileCondition(input string) (*Condition, error) {
+	tokens, err := tokenizeExpr(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, declared: ruleVariables}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != exprEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+	}
+
+	return &Condition{root: root}, nil
+}
+
+// Eval reports whether variables satisfy the condition.
+func (c *Condition) Eval(vars map[string]any) (bool, error) {
+	val, err := c.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := val.(bool)
+	if !ok {
+		return false, errors.New("condition does not evaluate to a boolean")
+	}
+
+	return b, nil
+}
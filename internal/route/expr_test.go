@@ -0,0 +1,111 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		err       string
+	}{
+		{name: "comparison", condition: `model == "gpt-4o"`},
+		{name: "single quoted string", condition: `model == 'gpt-4o'`},
+		{name: "escaped quote", condition: `headers["x-team"] == "a\"b"`},
+		{name: "logical operators", condition: `tokens > 1000 && (model.startsWith("gpt") || !("vip" in tags))`},
+		{name: "list literal", condition: `model in ["gpt-4o", "gpt-4o-mini"]`},
+		{name: "empty list", condition: `model in []`},
+		{name: "map access", condition: `metadata.tier == "gold" && headers["x-region"] != null`},
+		{name: "empty", condition: ``, err: "unexpected end of expression"},
+		{name: "undeclared variable", condition: `user == "a"`, err: "undeclared variable user at position 0"},
+		{name: "unterminated string", condition: `model == "gpt`, err: "unterminated string at position 9"},
+		{name: "unexpected character", condition: `model = "gpt"`, err: `unexpected character '=' at position 6`},
+		{name: "unsupported method", condition: `model.matches("gpt")`, err: "unsupported method matches at position 6"},
+		{name: "method arity", condition: `model.startsWith("a", "b")`, err: "unsupported method startsWith at position 6"},
+		{name: "chained comparison", condition: `model == "a" == "b"`, err: `unexpected "==" at position 13`},
+		{name: "unclosed parenthesis", condition: `(model == "a"`, err: `expected ")" at position 13`},
+		{name: "unclosed list", condition: `model in ["a"`, err: `expected "," at position 13`},
+		{name: "invalid number", condition: `tokens > 1.2.3`, err: "invalid number 1.2.3 at position 9"},
+		{name: "missing field name", condition: `metadata."tier"`, err: "expected field or method name at position 9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := CompileCondition(tt.condition)
+			if len(tt.err) != 0 {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, cond)
+		})
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	vars := map[string]any{
+		"model":    "gpt-4o-mini",
+		"tokens":   float64(1500),
+		"tags":     []any{"prod", "vip"},
+		"headers":  map[string]string{"x-region": "eu"},
+		"metadata": map[string]string{"tier": "gold", "seats": "10"},
+	}
+
+	tests := []struct {
+		name      string
+		condition string
+		want      bool
+		err       string
+	}{
+		{name: "equal", condition: `model == "gpt-4o-mini"`, want: true},
+		{name: "not equal", condition: `model != "gpt-4o-mini"`},
+		{name: "number comparison", condition: `tokens >= 1500 && tokens < 2000`, want: true},
+		{name: "string comparison", condition: `model > "gpt-4"`, want: true},
+		{name: "in list literal", condition: `model in ["gpt-4o", "gpt-4o-mini"]`, want: true},
+		{name: "in tags", condition: `"vip" in tags`, want: true},
+		{name: "not in tags", condition: `!("beta" in tags)`, want: true},
+		{name: "list index", condition: `tags[1] == "vip"`, want: true},
+		{name: "starts with", condition: `model.startsWith("gpt-4o")`, want: true},
+		{name: "ends with", condition: `model.endsWith("mini")`, want: true},
+		{name: "contains", condition: `model.contains("4o")`, want: true},
+		{name: "metadata field", condition: `metadata.tier == "gold"`, want: true},
+		{name: "non string metadata field", condition: `metadata.seats == "10"`, want: true},
+		{name: "header index", condition: `headers["x-region"] == "eu"`, want: true},
+		{name: "missing header is null", condition: `headers["x-team"] == null`, want: true},
+		{name: "method on missing header", condition: `headers["x-team"].startsWith("a")`},
+		{name: "or short circuits", condition: `model == "gpt-4o-mini" || tags[5] == "a"`, want: true},
+		{name: "and short circuits", condition: `model == "claude" && tags[5] == "a"`},
+		{name: "precedence", condition: `model == "claude" && tokens > 0 || "prod" in tags`, want: true},
+		{name: "not a boolean", condition: `model`, err: "condition does not evaluate to a boolean"},
+		{name: "list index out of range", condition: `tags[2] == "a"`, err: "list index out of range"},
+		{name: "fractional list index", condition: `tags[0.5] == "prod"`, err: "list index out of range"},
+		{name: "mixed comparison", condition: `tokens > "1000"`, err: "> is only supported on numbers and strings of the same type"},
+		{name: "in on string", condition: `"gpt" in model`, err: "in is only supported on lists"},
+		{name: "equal on list", condition: `tags == ["prod"]`, err: "== is only supported on strings, numbers, booleans and null"},
+		{name: "not on string", condition: `!model`, err: "! is only supported on booleans"},
+		{name: "and on string", condition: `model && true`, err: "&& is only supported on booleans"},
+		{name: "index on string", condition: `model[0] == "g"`, err: "only maps and lists can be indexed"},
+		{name: "method on number", condition: `tokens.startsWith("1")`, err: "startsWith is only supported on strings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := CompileCondition(tt.condition)
+			require.NoError(t, err)
+
+			got, err := cond.Eval(vars)
+			if len(tt.err) != 0 {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
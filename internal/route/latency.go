@@ -0,0 +1,165 @@
+package route
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// RoutingStrategyLatency orders the steps of a route by the rolling p95
+	// latency of their provider and model instead of the configured order.
+	RoutingStrategyLatency = "latency"
+
+	latencyWindowSize      = 100
+	maxConsecutiveFailures = 3
+	unhealthyCooldown      = 30 * time.Second
+)
+
+func IsValidRoutingStrategy(strategy string) bool {
+	return len(strategy) == 0 || strategy == RoutingStrategyLatency || strategy == RoutingStrategyCost || strategy == RoutingStrategyWeighted
+}
+
+type latencyWindow struct {
+	samples             []time.Duration
+	next                int
+	consecutiveFailures int
+	lastFailure         time.Time
+}
+
+func (w *latencyWindow) p95() (time.Duration, bool) {
+	if len(w.samples) == 0 {
+		return 0, false
+	}
+
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	idx := int(math.Ceil(float64(len(sorted))*0.95)) - 1
+	return sorted[idx], true
+}
+
+// healthy reports whether an upstream can be preferred. Upstreams failing
+// several times in a row are demoted until the cooldown passes.
+func (w *latencyWindow) healthy(now time.Time) bool {
+	return w.consecutiveFailures < maxConsecutiveFailures || now.Sub(w.lastFailure) > unhealthyCooldown
+}
+
+// LatencyTracker keeps the latencies of the last requests sent to each
+// provider and model pair in memory of a gateway instance.
+type LatencyTracker struct {
+	mu      sync.Mutex
+	windows map[string]*latencyWindow
+}
+
+func NewLatencyTracker() *LatencyTracker {
+	return &LatencyTracker{
+		windows: map[string]*latencyWindow{},
+	}
+}
+
+func latencyKey(provider, model string) string {
+	return fmt.Sprintf("%s/%s", provider, model)
+}
+
+func (lt *LatencyTracker) window(provider, model string) *latencyWindow {
+	k := latencyKey(provider, model)
+	w, ok := lt.windows[k]
+	if !ok {
+		w = &latencyWindow{}
+		lt.windows[k] = w
+	}
+
+	return w
+}
+
+// RecordSuccess records the latency of a request answered by an upstream.
+func (lt *LatencyTracker) RecordSuccess(provider, model string, dur time.Duration) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	w := lt.window(provider, model)
+	w.consecutiveFailures = 0
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, dur)
+		return
+	}
+
+	w.samples[w.next] = dur
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+// RecordFailure records a request that timed out or failed upstream.
+func (lt *LatencyTracker) RecordFailure(provider, model string) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	w := lt.window(provider, model)
+	w.consecutiveFailures++
+	w.lastFailure = time.Now()
+}
+
+// P95 returns the rolling p95 latency of a provider and model pair. False is
+// returned if no request has succeeded yet.
+func (lt *LatencyTracker) P95(provider, model string) (time.Duration, bool) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	w, ok := lt.windows[latencyKey(provider, model)]
+	if !ok {
+		return 0, false
+	}
+
+	return w.p95()
+}
+
+// Order returns the steps sorted for latency aware routing. Steps without
+// samples come first so that their latencies get measured, followed by
+// healthy steps from the lowest p95 latency and unhealthy steps last.
+func (lt *LatencyTracker) Order(steps []*Step) []*Step {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	type ranked struct {
+		step *Step
+		tier int
+		p95  time.Duration
+	}
+
+	now := time.Now()
+	candidates := make([]ranked, 0, len(steps))
+	for _, s := range steps {
+		r := ranked{step: s}
+		if w, ok := lt.windows[latencyKey(s.Provider, s.Model)]; ok {
+			if p95, ok := w.p95(); ok {
+				r.tier, r.p95 = 1, p95
+			}
+
+			if !w.healthy(now) {
+				r.tier = 2
+			}
+		}
+
+		candidates = append(candidates, r)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].tier != candidates[j].tier {
+			return candidates[i].tier < candidates[j].tier
+		}
+
+		return candidates[i].p95 < candidates[j].p95
+	})
+
+	ordered := make([]*Step, 0, len(candidates))
+	for _, c := range candidates {
+		ordered = append(ordered, c.step)
+	}
+
+	return ordered
+}
@@ -0,0 +1,167 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	defaultRetryInitialInterval = 500 * time.Millisecond
+	defaultRetryMaxInterval     = 30 * time.Second
+	defaultRetryMultiplier      = 2
+)
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryConfig retries requests of every step of a route with exponential
+// backoff. It overrides the retries and retry intervals of the steps.
+type RetryConfig struct {
+	MaxAttempts          int     `json:"maxAttempts"`
+	InitialInterval      string  `json:"initialInterval"`
+	MaxInterval          string  `json:"maxInterval"`
+	Multiplier           float64 `json:"multiplier"`
+	RetryableStatusCodes []int   `json:"retryableStatusCodes"`
+	RespectRetryAfter    bool    `json:"respectRetryAfter"`
+}
+
+// IsEnabled reports whether requests of a route are retried by the config.
+func (rc *RetryConfig) IsEnabled() bool {
+	return rc != nil && rc.MaxAttempts > 0
+}
+
+// Validate returns the names of invalid fields of a retry config.
+func (rc *RetryConfig) Validate() []string {
+	invalid := []string{}
+	if rc.MaxAttempts < 0 || rc.MaxAttempts > 10 {
+		invalid = append(invalid, "retryConfig.maxAttempts")
+	}
+
+	if len(rc.InitialInterval) != 0 {
+		if parsed, err := time.ParseDuration(rc.InitialInterval); err != nil || parsed <= 0 {
+			invalid = append(invalid, "retryConfig.initialInterval")
+		}
+	}
+
+	if len(rc.MaxInterval) != 0 {
+		if parsed, err := time.ParseDuration(rc.MaxInterval); err != nil || parsed <= 0 {
+			invalid = append(invalid, "retryConfig.maxInterval")
+		}
+	}
+
+	if rc.Multiplier != 0 && rc.Multiplier < 1 {
+		invalid = append(invalid, "retryConfig.multiplier")
+	}
+
+	for idx, code := range rc.RetryableStatusCodes {
+		if code < 400 || code > 599 {
+			invalid = append(invalid, fmt.Sprintf("retryConfig.retryableStatusCodes.[%d]", idx))
+		}
+	}
+
+	return invalid
+}
+
+// IsRetryable reports whether requests failing with a status code are retried.
+func (rc *RetryConfig) IsRetryable(code int) bool {
+	codes := rc.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryableStatusCodes
+	}
+
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rc *RetryConfig) interval(value string, fallback time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return fallback
+	}
+
+	return parsed
+}
+
+// newBackOff returns the backoff of a step attempted with the config.
+func (rc *RetryConfig) newBackOff() *retryBackOff {
+	eb := backoff.NewExponentialBackOff()
+	eb.InitialInterval = rc.interval(rc.InitialInterval, defaultRetryInitialInterval)
+	eb.MaxInterval = rc.interval(rc.MaxInterval, defaultRetryMaxInterval)
+	eb.Multiplier = defaultRetryMultiplier
+	if rc.Multiplier >= 1 {
+		eb.Multiplier = rc.Multiplier
+	}
+
+	// attempts are bounded by the max attempts rather than elapsed time.
+	eb.MaxElapsedTime = 0
+	eb.Reset()
+
+	return &retryBackOff{
+		BackOff: backoff.WithMaxRetries(eb, uint64(rc.MaxAttempts-1)),
+		max:     eb.MaxInterval,
+	}
+}
+
+// retryBackOff waits for the duration of the Retry-After header of the last
+// response, capped at the max interval, instead of the exponential interval.
+type retryBackOff struct {
+	backoff.BackOff
+	max        time.Duration
+	retryAfter time.Duration
+}
+
+func (b *retryBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop || b.retryAfter <= 0 {
+		return next
+	}
+
+	next = b.retryAfter
+	b.retryAfter = 0
+	if next > b.max {
+		return b.max
+	}
+
+	return next
+}
+
+// parseRetryAfter returns the duration of a Retry-After header value in
+// seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		dur := time.Until(date)
+		if dur < 0 {
+			dur = 0
+		}
+
+		return dur, true
+	}
+
+	return 0, false
+}
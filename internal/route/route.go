@@ -15,12 +15,17 @@ import (
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 )
 
+const anthropicVersion = "2023-06-01"
+
 type recorder interface {
 	RecordEvent(e *event.Event) error
 }
@@ -38,6 +43,7 @@ type Step struct {
 	Params        map[string]string `json:"params"`
 	Model         string            `json:"model"`
 	Timeout       string            `json:"timeout"`
+	Weight        int               `json:"weight,omitempty"`
 }
 
 func ConvertToArrayOfStrings(input []any) []string {
@@ -100,6 +106,24 @@ func (s *Step) DecorateRequest(provider string, body []byte, isEmbedding bool) (
 
 		s.DecorateChatCompletionRequest(completionReq)
 
+		if provider == "anthropic" || provider == "bedrock" {
+			// route responses are buffered and translated back into the chat
+			// completions format, so anthropic and bedrock steps do not stream.
+			completionReq.Stream = false
+			completionReq.StreamOptions = nil
+
+			mr, err := anthropic.NewMessagesRequestFromOpenAi(completionReq)
+			if err != nil {
+				return nil, err
+			}
+
+			if provider == "bedrock" {
+				return newBedrockMessagesRequest(mr)
+			}
+
+			return json.Marshal(mr)
+		}
+
 		return json.Marshal(completionReq)
 	}
 
@@ -180,17 +204,58 @@ func (s *Step) DecorateChatCompletionRequest(req *goopenai.ChatCompletionRequest
 	}
 }
 
+// SupportsRequirements reports whether the step model has the capabilities
+// required by a request. Models without capability metadata are assumed to
+// support every request.
+func (s *Step) SupportsRequirements(r *provider.Requirements) bool {
+	return provider.GetCapability(s.Model).Satisfies(r)
+}
+
+func GetRequirements(req *goopenai.ChatCompletionRequest) *provider.Requirements {
+	r := &provider.Requirements{}
+	if req == nil {
+		return r
+	}
+
+	if len(req.Tools) != 0 || len(req.Functions) != 0 {
+		r.Tools = true
+	}
+
+	if req.ResponseFormat != nil && (req.ResponseFormat.Type == goopenai.ChatCompletionResponseFormatTypeJSONObject || req.ResponseFormat.Type == goopenai.ChatCompletionResponseFormatTypeJSONSchema) {
+		r.JsonMode = true
+	}
+
+	for _, message := range req.Messages {
+		for _, part := range message.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeImageURL {
+				r.Vision = true
+			}
+		}
+	}
+
+	return r
+}
+
 type Route struct {
-	Id            string       `json:"id"`
-	RetryStrategy string       `json:"retryStrategy"`
-	RequestFormat string       `json:"requestFormat"`
-	CreatedAt     int64        `json:"createdAt"`
-	UpdatedAt     int64        `json:"updatedAt"`
-	Name          string       `json:"name"`
-	Path          string       `json:"path"`
-	KeyIds        []string     `json:"keyIds"`
-	Steps         []*Step      `json:"steps"`
-	CacheConfig   *CacheConfig `json:"cacheConfig"`
+	Id               string                             `json:"id"`
+	RetryStrategy    string                             `json:"retryStrategy"`
+	RoutingStrategy  string                             `json:"routingStrategy"`
+	CapabilityTier   string                             `json:"capabilityTier"`
+	RequestFormat    string                             `json:"requestFormat"`
+	CreatedAt        int64                              `json:"createdAt"`
+	UpdatedAt        int64                              `json:"updatedAt"`
+	Name             string                             `json:"name"`
+	Path             string                             `json:"path"`
+	KeyIds           []string                           `json:"keyIds"`
+	Steps            []*Step                            `json:"steps"`
+	CacheConfig      *CacheConfig                       `json:"cacheConfig"`
+	TruncationConfig *TruncationConfig                  `json:"truncationConfig,omitempty"`
+	RetryConfig      *RetryConfig                       `json:"retryConfig,omitempty"`
+	ShadowConfig     *ShadowConfig                      `json:"shadowConfig,omitempty"`
+	Rules            []*RoutingRule                     `json:"rules,omitempty"`
+	ErrorTemplates   map[string]*errortemplate.Template `json:"errorTemplates,omitempty"`
+	PolicyId         string                             `json:"policyId,omitempty"`
+	GuardrailConfig  *GuardrailConfig                   `json:"guardrailConfig,omitempty"`
 }
 
 func (r *Route) ValidateSettings(settings []*provider.Setting) bool {
@@ -199,6 +264,14 @@ func (r *Route) ValidateSettings(settings []*provider.Setting) bool {
 		target[s.Provider] = true
 	}
 
+	if r.ShadowConfig.IsEnabled() {
+		target[r.ShadowConfig.Provider] = true
+	}
+
+	if r.TruncationConfig.ShouldSummarize() {
+		target[r.TruncationConfig.summaryStep().Provider] = true
+	}
+
 	source := map[string]bool{}
 	for _, s := range settings {
 		source[s.Provider] = true
@@ -253,10 +326,71 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 		return nil, err
 	}
 
-	events := []*event.Event{}
 	response := &Response{}
 
-	for _, step := range r.Steps {
+	truncated, summaryCost, ok, err := r.TruncateConversation(req, body)
+	if err != nil {
+		log.Debug("error when truncating conversation", zap.Error(err))
+	}
+
+	response.SummaryCostInUsd = summaryCost
+	if ok {
+		body = truncated
+	}
+
+	r.mirror(req, rec, log, kc, body)
+
+	requirements := &provider.Requirements{}
+	var completionReq *goopenai.ChatCompletionRequest
+	if !r.ShouldRunEmbeddings() {
+		parsed := &goopenai.ChatCompletionRequest{}
+		if err := json.Unmarshal(body, parsed); err == nil {
+			completionReq = parsed
+			requirements = GetRequirements(completionReq)
+		}
+	}
+
+	events := []*event.Event{}
+	eligible := 0
+
+	requestTags := []string{}
+	steps := r.Steps
+	if len(r.Rules) != 0 {
+		rule, selected := r.matchRule(req.ruleVars(kc, body, completionReq), log)
+		steps = selected
+		if rule != nil {
+			response.RuleTag = routingRuleTagPrefix + rule.Name
+			requestTags = append(requestTags, response.RuleTag)
+		}
+	}
+
+	if r.RoutingStrategy == RoutingStrategyLatency && req.Latencies != nil {
+		steps = req.Latencies.Order(steps)
+	}
+
+	if r.RoutingStrategy == RoutingStrategyCost {
+		steps = req.orderByCost(steps, r.CapabilityTier)
+		if len(steps) == 0 {
+			return nil, fmt.Errorf("no route steps satisfy the %s capability tier", r.CapabilityTier)
+		}
+	}
+
+	if r.RoutingStrategy == RoutingStrategyWeighted {
+		ordered, selected := orderByWeight(steps)
+		steps = ordered
+		if selected != nil {
+			response.SplitTag = SplitTag(selected)
+			requestTags = append(requestTags, response.SplitTag)
+		}
+	}
+
+	for _, step := range steps {
+		if !step.SupportsRequirements(requirements) {
+			log.Debug("skipping route step that does not support request capabilities", zap.String("model", step.Model))
+			continue
+		}
+
+		eligible++
 		dur := time.Second
 		if len(step.RetryInterval) != 0 {
 			parsed, err := time.ParseDuration(step.RetryInterval)
@@ -267,16 +401,25 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 			dur = parsed
 		}
 
-		b := InitializeBackoff(r.RetryStrategy, dur)
-		withRetries := backoff.WithMaxRetries(b, uint64(step.Retries))
+		var withRetries backoff.BackOff = backoff.WithMaxRetries(InitializeBackoff(r.RetryStrategy, dur), uint64(step.Retries))
 
+		var rb *retryBackOff
+		if r.RetryConfig.IsEnabled() {
+			rb = r.RetryConfig.newBackOff()
+			withRetries = rb
+		}
+
+		attempt := 0
 		do := func() error {
 			start := time.Now()
+			retried := attempt
+			attempt++
 
 			evt := &event.Event{
 				Id:            util.NewUuid(),
 				CreatedAt:     time.Now().Unix(),
 				Tags:          kc.Tags,
+				RequestTags:   requestTags,
 				KeyId:         kc.KeyId,
 				Provider:      step.Provider,
 				Method:        req.Forwarded.Method,
@@ -290,6 +433,7 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 				PolicyId:      req.PolicyId,
 				RouteId:       r.Id,
 				CorrelationId: req.CorrelationId,
+				RetryCount:    retried,
 			}
 
 			defer func() {
@@ -298,7 +442,7 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 
 			events = append(events, evt)
 
-			if kc.ShouldLogRequest {
+			if kc.ShouldStoreRequest() {
 				evt.Request = body
 			}
 
@@ -320,18 +464,34 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 				}
 			}()
 
-			hreq, err := req.createHttpRequest(ctx, step.Provider, r.ShouldRunEmbeddings(), step.Params, bs)
+			var hreq *http.Request
+			if step.Provider == "bedrock" {
+				hreq, err = req.createBedrockRequest(ctx, step.Model, r.GuardrailConfig, bs)
+			} else {
+				hreq, err = req.createHttpRequest(ctx, step.Provider, r.ShouldRunEmbeddings(), step.Params, bs)
+			}
+
 			if err != nil {
 				return err
 			}
 
 			res, err := req.Client.Do(hreq)
 			if err != nil {
+				req.recordFailure(step)
 				return err
 			}
 
+			if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+				req.recordFailure(step)
+			}
+
+			if res.StatusCode == http.StatusOK {
+				req.recordSuccess(step, time.Since(start))
+			}
+
 			response.Provider = step.Provider
 			response.Model = step.Model
+			response.Retries = retried
 			response.Response = res
 			response.Cancel = cancel
 			evt.Status = res.StatusCode
@@ -344,11 +504,52 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 					return err
 				}
 
+				if step.Provider == "anthropic" || step.Provider == "bedrock" {
+					bytes = translateAnthropicError(bytes)
+				}
+
 				response.Data = bytes
+
+				if rb != nil {
+					if !r.RetryConfig.IsRetryable(res.StatusCode) {
+						return backoff.Permanent(fmt.Errorf("response is not okay with non retryable status code %d", res.StatusCode))
+					}
+
+					if r.RetryConfig.RespectRetryAfter {
+						if dur, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+							rb.retryAfter = dur
+						}
+					}
+				}
+
 				return errors.New("response is not okay")
 			}
 
-			if kc.ShouldLogResponse {
+			if step.Provider == "bedrock" {
+				gi, err := recordBedrockGuardrailResult(res, r.GuardrailConfig)
+				if err != nil {
+					return err
+				}
+
+				if gi != nil {
+					response.GuardrailIntervention = gi
+					if data, err := json.Marshal(gi); err == nil {
+						evt.GuardrailIntervention = data
+					}
+
+					if gi.Action == anthropic.BedrockGuardrailIntervened {
+						evt.Action = "blocked"
+					}
+				}
+			}
+
+			if step.Provider == "anthropic" || step.Provider == "bedrock" {
+				if err := translateAnthropicResponse(res); err != nil {
+					return err
+				}
+			}
+
+			if kc.ShouldStoreResponse() {
 				evt.Response = body
 			}
 
@@ -365,6 +566,14 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 
 		err := backoff.RetryNotify(do, withRetries, notify)
 		if err == nil {
+			if r.RoutingStrategy == RoutingStrategyLatency {
+				recordLatencyRoutingDecision(req, r, step)
+			}
+
+			if r.RoutingStrategy == RoutingStrategyCost {
+				recordCostRoutingDecision(req, r, step)
+			}
+
 			break
 		}
 	}
@@ -386,6 +595,10 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 		return response, nil
 	}
 
+	if eligible == 0 {
+		return nil, errors.New("no route steps support the capabilities required by the request")
+	}
+
 	return nil, errors.New("no responses")
 }
 
@@ -479,7 +692,7 @@ func (r *Route) RunSteps(req *Request, rec recorder, log *zap.Logger) (*Response
 				evt.KeyId = req.Key.KeyId
 				evt.Tags = req.Key.Tags
 
-				if req.Key.ShouldLogRequest {
+				if req.Key.ShouldStoreRequest() {
 					evt.Request = req.Request
 				}
 			}
@@ -623,6 +836,41 @@ type Request struct {
 	PolicyId      string
 	Action        string
 	CorrelationId string
+	Counter       tokenCounter
+	Latencies     *LatencyTracker
+	Estimators    map[string]CostEstimator
+}
+
+func (r *Request) recordSuccess(step *Step, dur time.Duration) {
+	if r.Latencies != nil {
+		r.Latencies.RecordSuccess(step.Provider, step.Model, dur)
+	}
+}
+
+func (r *Request) recordFailure(step *Step) {
+	if r.Latencies != nil {
+		r.Latencies.RecordFailure(step.Provider, step.Model)
+	}
+}
+
+// recordLatencyRoutingDecision emits the step that served a request of a
+// latency routed route along with its rolling p95 latency.
+func recordLatencyRoutingDecision(req *Request, r *Route, step *Step) {
+	tags := []string{
+		fmt.Sprintf("route:%s", r.Path),
+		fmt.Sprintf("provider:%s", step.Provider),
+		fmt.Sprintf("model:%s", step.Model),
+	}
+
+	telemetry.Incr("bricksllm.route.run_steps_v2.latency_routing.selected", tags, 1)
+
+	if req.Latencies == nil {
+		return
+	}
+
+	if p95, ok := req.Latencies.P95(step.Provider, step.Model); ok {
+		telemetry.Histogram("bricksllm.route.run_steps_v2.latency_routing.p95_in_ms", float64(p95.Milliseconds()), tags, 1)
+	}
 }
 
 func (r *Request) GetSettingValue(provider string, param string) (string, error) {
@@ -640,12 +888,66 @@ func (r *Request) GetSettingValue(provider string, param string) (string, error)
 	return "", errors.New(fmt.Sprintf("%s setting is not found", provider))
 }
 
+// translateAnthropicResponse replaces the body of a successful messages
+// response with its chat completions translation so that callers of a route
+// receive the same format regardless of the step that served the request.
+func translateAnthropicResponse(res *http.Response) error {
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	mr := &anthropic.MessagesResponse{}
+	if err := json.Unmarshal(data, mr); err != nil {
+		return err
+	}
+
+	translated, err := json.Marshal(mr.ToOpenAi())
+	if err != nil {
+		return err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(translated))
+	res.ContentLength = int64(len(translated))
+	res.Header.Del("Content-Length")
+
+	return nil
+}
+
+// translateAnthropicError translates an error response of the messages API
+// into the chat completions error format. Bodies that cannot be parsed are
+// returned as they are.
+func translateAnthropicError(data []byte) []byte {
+	er := &anthropic.ErrorResponse{}
+	if err := json.Unmarshal(data, er); err != nil || er.Error == nil {
+		return data
+	}
+
+	translated, err := json.Marshal(er.ToOpenAi())
+	if err != nil {
+		return data
+	}
+
+	return translated
+}
+
 type Response struct {
 	Provider string
 	Model    string
-	Data     []byte
-	Cancel   context.CancelFunc
-	Response *http.Response
+	SplitTag string
+	RuleTag  string
+	Retries  int
+	// SummaryCostInUsd is the cost of summarizing messages removed by the
+	// truncation config of the route.
+	SummaryCostInUsd float64
+	Data             []byte
+	// GuardrailIntervention is the result of the guardrail of the route if a
+	// bedrock step served the request.
+	GuardrailIntervention *anthropic.GuardrailIntervention
+	Cancel                context.CancelFunc
+	Response              *http.Response
 }
 
 func buildRequestUrl(provider string, runEmbeddings bool, resourceName string, params map[string]string) string {
@@ -668,6 +970,10 @@ func buildRequestUrl(provider string, runEmbeddings bool, resourceName string, p
 		return fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/chat/completions?api-version=%s", resourceName, deploymentId, apiVersion)
 	}
 
+	if provider == "anthropic" && !runEmbeddings {
+		return "https://api.anthropic.com/v1/messages"
+	}
+
 	return ""
 }
 
@@ -677,6 +983,12 @@ func setHttpRequestAuthHeader(provider string, req *http.Request, key string) {
 		return
 	}
 
+	if provider == "anthropic" {
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", anthropicVersion)
+		return
+	}
+
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
 }
 
@@ -702,11 +1014,8 @@ func (r *Request) createHttpRequest(ctx context.Context, provider string, runEmb
 	}
 
 	hreq, err := http.NewRequestWithContext(ctx, r.Forwarded.Method, url, io.NopCloser(bytes.NewReader(data)))
-
-	if provider == "azure" {
-		hreq.Header.Set("api-key", key)
-	} else {
-		hreq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
+	if err != nil {
+		return nil, err
 	}
 
 	for k := range r.Forwarded.Header {
@@ -718,6 +1027,14 @@ func (r *Request) createHttpRequest(ctx context.Context, provider string, runEmb
 			continue
 		}
 
+		if strings.ToLower(k) == "x-api-key" || strings.ToLower(k) == "anthropic-version" {
+			continue
+		}
+
+		if strings.ToLower(k) == "content-length" {
+			continue
+		}
+
 		if strings.ToLower(k) == "accept-encoding" {
 			continue
 		}
@@ -725,5 +1042,17 @@ func (r *Request) createHttpRequest(ctx context.Context, provider string, runEmb
 		hreq.Header.Set(k, r.Forwarded.Header.Get(k))
 	}
 
-	return hreq, err
+	setHttpRequestAuthHeader(provider, hreq, key)
+
+	return hreq, nil
+}
+
+// GetErrorTemplate returns the error template configured for an error type.
+// Nil is returned if the route does not customize that error.
+func (r *Route) GetErrorTemplate(errType string) *errortemplate.Template {
+	if r == nil || r.ErrorTemplates == nil {
+		return nil
+	}
+
+	return r.ErrorTemplates[errType]
 }
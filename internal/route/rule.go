@@ -0,0 +1,180 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+const routingRuleTagPrefix = "routing_rule:"
+
+// ruleVariables are the request attributes conditions of routing rules are
+// evaluated over.
+var ruleVariables = map[string]bool{
+	"model":    true,
+	"tokens":   true,
+	"tags":     true,
+	"headers":  true,
+	"metadata": true,
+}
+
+// RoutingRule sends requests satisfying its condition to a subset of the steps
+// of a route. Rules are evaluated in order and the steps of the first matching
+// rule are tried in their listed order. Requests matching no rule are sent to
+// all steps.
+type RoutingRule struct {
+	Name      string `json:"name"`
+	Condition string `json:"condition"`
+	Steps     []int  `json:"steps"`
+
+	once     sync.Once
+	compiled *Condition
+	err      error
+}
+
+// Validate returns the names of invalid fields of the rule at an index of a
+// route with a number of steps.
+func (rr *RoutingRule) Validate(index, steps int) []string {
+	invalid := []string{}
+	if len(rr.Name) == 0 {
+		invalid = append(invalid, fmt.Sprintf("rules.[%d].name", index))
+	}
+
+	if _, err := CompileCondition(rr.Condition); err != nil {
+		invalid = append(invalid, fmt.Sprintf("rules.[%d].condition", index))
+	}
+
+	if len(rr.Steps) == 0 {
+		invalid = append(invalid, fmt.Sprintf("rules.[%d].steps", index))
+	}
+
+	for idx, step := range rr.Steps {
+		if step < 0 || step >= steps {
+			invalid = append(invalid, fmt.Sprintf("rules.[%d].steps.[%d]", index, idx))
+		}
+	}
+
+	return invalid
+}
+
+func (rr *RoutingRule) condition() (*Condition, error) {
+	rr.once.Do(func() {
+		rr.compiled, rr.err = CompileCondition(rr.Condition)
+	})
+
+	return rr.compiled, rr.err
+}
+
+// ruleVars returns the request attributes routing rules are evaluated over.
+// The token count is only estimated for chat completion requests.
+func (r *Request) ruleVars(kc *key.ResponseKey, body []byte, completionReq *goopenai.ChatCompletionRequest) map[string]any {
+	tags := []any{}
+	if kc != nil {
+		for _, tag := range kc.Tags {
+			tags = append(tags, tag)
+		}
+	}
+
+	tokens := 0
+	if completionReq != nil && r.Counter != nil {
+		if tks, err := r.Counter.EstimateChatCompletionPromptTokenCounts(completionReq.Model, completionReq); err == nil {
+			tokens = tks
+		}
+	}
+
+	return map[string]any{
+		"model":    gjson.GetBytes(body, "model").String(),
+		"tokens":   float64(tokens),
+		"tags":     tags,
+		"headers":  flattenHeaders(r.Forwarded.Header),
+		"metadata": parseMetadata(r.Forwarded.Header.Get("X-METADATA")),
+	}
+}
+
+// flattenHeaders returns the first values of headers keyed by their lower
+// cased names.
+func flattenHeaders(header http.Header) map[string]string {
+	flattened := map[string]string{}
+	for name, values := range header {
+		if len(values) != 0 {
+			flattened[strings.ToLower(name)] = values[0]
+		}
+	}
+
+	return flattened
+}
+
+// parseMetadata returns the fields of a JSON metadata object. Values that are
+// not strings are kept in their JSON encoding.
+func parseMetadata(raw string) map[string]string {
+	parsed := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return map[string]string{}
+	}
+
+	metadata := map[string]string{}
+	for k, v := range parsed {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			metadata[k] = s
+			continue
+		}
+
+		metadata[k] = string(v)
+	}
+
+	return metadata
+}
+
+// matchRule returns the first routing rule of a route matching request
+// attributes along with its steps. Rules failing to evaluate do not match.
+func (r *Route) matchRule(vars map[string]any, log *zap.Logger) (*RoutingRule, []*Step) {
+	for _, rule := range r.Rules {
+		if rule == nil {
+			continue
+		}
+
+		cond, err := rule.condition()
+		if err != nil {
+			log.Debug("error when compiling routing rule condition", zap.String("rule", rule.Name), zap.Error(err))
+			continue
+		}
+
+		matched, err := cond.Eval(vars)
+		if err != nil {
+			telemetry.Incr("bricksllm.route.run_steps_v2.routing_rule.eval_error", []string{fmt.Sprintf("rule:%s", rule.Name)}, 1)
+			log.Debug("error when evaluating routing rule condition", zap.String("rule", rule.Name), zap.Error(err))
+			continue
+		}
+
+		if !matched {
+			continue
+		}
+
+		steps := []*Step{}
+		for _, idx := range rule.Steps {
+			if idx >= 0 && idx < len(r.Steps) {
+				steps = append(steps, r.Steps[idx])
+			}
+		}
+
+		if len(steps) == 0 {
+			continue
+		}
+
+		telemetry.Incr("bricksllm.route.run_steps_v2.routing_rule.matched", []string{fmt.Sprintf("route:%s", r.Path), fmt.Sprintf("rule:%s", rule.Name)}, 1)
+
+		return rule, steps
+	}
+
+	return nil, r.Steps
+}
@@ -0,0 +1,154 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRoutingRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    *RoutingRule
+		invalid []string
+	}{
+		{
+			name:    "valid",
+			rule:    &RoutingRule{Name: "long", Condition: `tokens > 1000`, Steps: []int{1, 0}},
+			invalid: []string{},
+		},
+		{
+			name:    "missing name",
+			rule:    &RoutingRule{Condition: `tokens > 1000`, Steps: []int{0}},
+			invalid: []string{"rules.[2].name"},
+		},
+		{
+			name:    "invalid condition",
+			rule:    &RoutingRule{Name: "long", Condition: `tokens >`, Steps: []int{0}},
+			invalid: []string{"rules.[2].condition"},
+		},
+		{
+			name:    "missing steps",
+			rule:    &RoutingRule{Name: "long", Condition: `tokens > 1000`},
+			invalid: []string{"rules.[2].steps"},
+		},
+		{
+			name:    "steps out of range",
+			rule:    &RoutingRule{Name: "long", Condition: `tokens > 1000`, Steps: []int{0, 2, -1}},
+			invalid: []string{"rules.[2].steps.[1]", "rules.[2].steps.[2]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.invalid, tt.rule.Validate(2, 2))
+		})
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	steps := []*Step{{Provider: "openai", Model: "gpt-4o"}, {Provider: "anthropic", Model: "claude-3-haiku"}}
+
+	tests := []struct {
+		name  string
+		rules []*RoutingRule
+		model string
+		rule  string
+		steps []*Step
+	}{
+		{
+			name:  "no rules",
+			model: "gpt-4o",
+			steps: steps,
+		},
+		{
+			name: "first matching rule",
+			rules: []*RoutingRule{
+				{Name: "other", Condition: `model == "other"`, Steps: []int{0}},
+				{Name: "gpt", Condition: `model.startsWith("gpt")`, Steps: []int{1, 0}},
+				{Name: "any", Condition: `true`, Steps: []int{0}},
+			},
+			model: "gpt-4o",
+			rule:  "gpt",
+			steps: []*Step{steps[1], steps[0]},
+		},
+		{
+			name: "no matching rule",
+			rules: []*RoutingRule{
+				{Name: "other", Condition: `model == "other"`, Steps: []int{0}},
+			},
+			model: "gpt-4o",
+			steps: steps,
+		},
+		{
+			name: "rules failing to evaluate are skipped",
+			rules: []*RoutingRule{
+				{Name: "invalid", Condition: `tokens >`, Steps: []int{0}},
+				{Name: "error", Condition: `model > 1`, Steps: []int{0}},
+				{Name: "any", Condition: `true`, Steps: []int{1}},
+			},
+			model: "gpt-4o",
+			rule:  "any",
+			steps: []*Step{steps[1]},
+		},
+		{
+			name: "rules without valid steps are skipped",
+			rules: []*RoutingRule{
+				{Name: "removed", Condition: `true`, Steps: []int{5}},
+			},
+			model: "gpt-4o",
+			steps: steps,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Route{Steps: steps, Rules: tt.rules}
+
+			rule, selected := r.matchRule(map[string]any{"model": tt.model, "tokens": float64(0)}, zap.NewNop())
+			if len(tt.rule) == 0 {
+				assert.Nil(t, rule)
+			} else if assert.NotNil(t, rule) {
+				assert.Equal(t, tt.rule, rule.Name)
+			}
+
+			assert.Equal(t, tt.steps, selected)
+		})
+	}
+}
+
+func TestRuleVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		want     map[string]string
+	}{
+		{name: "no metadata", want: map[string]string{}},
+		{name: "invalid metadata", metadata: `{`, want: map[string]string{}},
+		{name: "string values", metadata: `{"tier":"gold"}`, want: map[string]string{"tier": "gold"}},
+		{name: "non string values", metadata: `{"seats":10,"beta":true}`, want: map[string]string{"seats": "10", "beta": "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded := httptest.NewRequest(http.MethodPost, "/route", nil)
+			forwarded.Header.Set("X-Region", "eu")
+			if len(tt.metadata) != 0 {
+				forwarded.Header.Set("X-METADATA", tt.metadata)
+			}
+
+			r := &Request{Forwarded: forwarded}
+			vars := r.ruleVars(&key.ResponseKey{Tags: []string{"prod"}}, []byte(`{"model":"gpt-4o"}`), nil)
+
+			assert.Equal(t, "gpt-4o", vars["model"])
+			assert.Equal(t, float64(0), vars["tokens"])
+			assert.Equal(t, []any{"prod"}, vars["tags"])
+			assert.Equal(t, "eu", vars["headers"].(map[string]string)["x-region"])
+			assert.Equal(t, tt.want, vars["metadata"])
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+// ShadowTag is the request tag of events of mirrored requests. Mirrored events
+// share the correlation id of the request they mirror. Their spend is not
+// charged to keys and is left out of spend reporting and reconciliation.
+const ShadowTag = "shadow"
+
+// ShadowConfig mirrors a percentage of the requests of a route to a secondary
+// provider and model. Responses of mirrored requests are recorded as events
+// and discarded.
+type ShadowConfig struct {
+	Provider   string            `json:"provider"`
+	Model      string            `json:"model"`
+	Params     map[string]string `json:"params"`
+	Percentage float64           `json:"percentage"`
+	Timeout    string            `json:"timeout"`
+}
+
+// IsEnabled reports whether requests of a route are mirrored by the config.
+func (sc *ShadowConfig) IsEnabled() bool {
+	return sc != nil && sc.Percentage > 0 && len(sc.Provider) != 0
+}
+
+// Validate returns the names of invalid fields of a shadow config.
+func (sc *ShadowConfig) Validate() []string {
+	invalid := []string{}
+	if sc.Percentage < 0 || sc.Percentage > 100 {
+		invalid = append(invalid, "shadowConfig.percentage")
+	}
+
+	if sc.Percentage == 0 {
+		return invalid
+	}
+
+	if len(sc.Provider) == 0 {
+		invalid = append(invalid, "shadowConfig.provider")
+	}
+
+	if len(sc.Model) == 0 {
+		invalid = append(invalid, "shadowConfig.model")
+	}
+
+	if sc.Provider == "azure" {
+		if len(sc.Params["apiVersion"]) == 0 {
+			invalid = append(invalid, "shadowConfig.params.apiVersion")
+		}
+
+		if len(sc.Params["deploymentId"]) == 0 {
+			invalid = append(invalid, "shadowConfig.params.deploymentId")
+		}
+	}
+
+	if len(sc.Timeout) != 0 {
+		if parsed, err := time.ParseDuration(sc.Timeout); err != nil || parsed <= 0 {
+			invalid = append(invalid, "shadowConfig.timeout")
+		}
+	}
+
+	return invalid
+}
+
+func (sc *ShadowConfig) sampled() bool {
+	return rand.Float64()*100 < sc.Percentage
+}
+
+func (sc *ShadowConfig) step() *Step {
+	timeout := sc.Timeout
+	if len(timeout) == 0 {
+		timeout = "5m"
+	}
+
+	return &Step{
+		Provider: sc.Provider,
+		Model:    sc.Model,
+		Params:   sc.Params,
+		Timeout:  timeout,
+	}
+}
+
+// mirror sends a sampled request of a route to its shadow provider and model
+// in the background and records the response as an event.
+func (r *Route) mirror(req *Request, rec recorder, log *zap.Logger, kc *key.ResponseKey, body []byte) {
+	if !r.ShadowConfig.IsEnabled() || !r.ShadowConfig.sampled() {
+		return
+	}
+
+	// the forwarded request is cloned since the handler might return before
+	// the mirrored request is sent.
+	mirrored := *req
+	mirrored.Forwarded = req.Forwarded.Clone(context.Background())
+
+	go func() {
+		step := r.ShadowConfig.step()
+		tags := []string{
+			fmt.Sprintf("route:%s", r.Path),
+			fmt.Sprintf("provider:%s", step.Provider),
+			fmt.Sprintf("model:%s", step.Model),
+		}
+
+		telemetry.Incr("bricksllm.route.shadow.requests", tags, 1)
+
+		evt, err := mirrored.sendShadow(r, step, kc, body)
+		if err != nil {
+			telemetry.Incr("bricksllm.route.shadow.error", tags, 1)
+			log.Debug("error when mirroring request to shadow model", zap.Error(err))
+		}
+
+		if evt == nil {
+			return
+		}
+
+		if err := rec.RecordEvent(evt); err != nil {
+			log.Debug("error when recording shadow event", zap.Error(err))
+		}
+	}()
+}
+
+func (r *Request) sendShadow(rt *Route, step *Step, kc *key.ResponseKey, body []byte) (*event.Event, error) {
+	start := time.Now()
+
+	evt := &event.Event{
+		Id:            util.NewUuid(),
+		CreatedAt:     time.Now().Unix(),
+		Tags:          kc.Tags,
+		RequestTags:   []string{ShadowTag},
+		KeyId:         kc.KeyId,
+		Provider:      step.Provider,
+		Method:        r.Forwarded.Method,
+		Path:          r.Forwarded.URL.Path,
+		Model:         step.Model,
+		Action:        r.Action,
+		Request:       []byte(`{}`),
+		Response:      []byte(`{}`),
+		CustomId:      r.Forwarded.Header.Get("X-CUSTOM-EVENT-ID"),
+		UserId:        r.UserId,
+		PolicyId:      r.PolicyId,
+		RouteId:       rt.Id,
+		CorrelationId: r.CorrelationId,
+	}
+
+	defer func() {
+		evt.LatencyInMs = int(time.Since(start).Milliseconds())
+	}()
+
+	if kc.ShouldStoreRequest() {
+		evt.Request = body
+	}
+
+	parsed, err := time.ParseDuration(step.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := step.DecorateRequest(step.Provider, body, rt.ShouldRunEmbeddings())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), parsed)
+	defer cancel()
+
+	hreq, err := r.createHttpRequest(ctx, step.Provider, rt.ShouldRunEmbeddings(), step.Params, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.Client.Do(hreq)
+	if err != nil {
+		return evt, err
+	}
+
+	defer res.Body.Close()
+
+	evt.Status = res.StatusCode
+
+	if res.StatusCode == http.StatusOK && step.Provider == "anthropic" {
+		if err := translateAnthropicResponse(res); err != nil {
+			return evt, err
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return evt, err
+	}
+
+	if res.StatusCode != http.StatusOK && step.Provider == "anthropic" {
+		data = translateAnthropicError(data)
+	}
+
+	if kc.ShouldStoreResponse() {
+		evt.Response = data
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return evt, fmt.Errorf("shadow response is not okay with status code %d", res.StatusCode)
+	}
+
+	usage := struct {
+		Usage goopenai.Usage `json:"usage"`
+	}{}
+
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return evt, err
+	}
+
+	evt.PromptTokenCount = usage.Usage.PromptTokens
+	evt.CompletionTokenCount = usage.Usage.CompletionTokens
+
+	if ce, ok := r.Estimators[step.Provider]; ok && ce != nil {
+		cost, err := ce.EstimateTotalCost(step.Model, evt.PromptTokenCount, evt.CompletionTokenCount)
+		if err != nil {
+			return evt, err
+		}
+
+		evt.CostInUsd = cost
+	}
+
+	return evt, nil
+}
@@ -0,0 +1,315 @@
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type tokenCounter interface {
+	EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error)
+}
+
+type TruncationConfig struct {
+	Enabled         bool              `json:"enabled"`
+	MaxPromptTokens int               `json:"maxPromptTokens"`
+	Strategy        string            `json:"strategy"`
+	SummaryModel    string            `json:"summaryModel"`
+	SummaryProvider string            `json:"summaryProvider,omitempty"`
+	SummaryParams   map[string]string `json:"summaryParams,omitempty"`
+}
+
+func (tc *TruncationConfig) ShouldTruncate() bool {
+	return tc != nil && tc.Enabled && tc.MaxPromptTokens > 0
+}
+
+// ShouldSummarize reports whether removed messages are replaced by a summary.
+func (tc *TruncationConfig) ShouldSummarize() bool {
+	return tc.ShouldTruncate() && tc.Strategy == "summarize" && len(tc.SummaryModel) != 0
+}
+
+// Validate returns the names of invalid fields of an enabled truncation
+// config.
+func (tc *TruncationConfig) Validate() []string {
+	invalid := []string{}
+	if tc.MaxPromptTokens <= 0 {
+		invalid = append(invalid, "truncationConfig.maxPromptTokens")
+	}
+
+	if len(tc.Strategy) != 0 && tc.Strategy != "trim" && tc.Strategy != "summarize" {
+		invalid = append(invalid, "truncationConfig.strategy")
+	}
+
+	if tc.Strategy != "summarize" {
+		return invalid
+	}
+
+	if len(tc.SummaryModel) == 0 {
+		invalid = append(invalid, "truncationConfig.summaryModel")
+	}
+
+	if tc.SummaryProvider == "azure" {
+		if len(tc.SummaryParams["apiVersion"]) == 0 {
+			invalid = append(invalid, "truncationConfig.summaryParams.apiVersion")
+		}
+
+		if len(tc.SummaryParams["deploymentId"]) == 0 {
+			invalid = append(invalid, "truncationConfig.summaryParams.deploymentId")
+		}
+	}
+
+	return invalid
+}
+
+// summaryStep returns the step summaries are generated with. Summaries are
+// generated by OpenAI unless another provider is set.
+func (tc *TruncationConfig) summaryStep() *Step {
+	provider := tc.SummaryProvider
+	if len(provider) == 0 {
+		provider = "openai"
+	}
+
+	return &Step{
+		Provider: provider,
+		Model:    tc.SummaryModel,
+		Params:   tc.SummaryParams,
+		Timeout:  "30s",
+	}
+}
+
+// removable returns the end of the group of messages starting at idx that is
+// removed together. Tool calls are removed along with the tool replies that
+// follow them so that no reply is left without its call.
+func removable(messages []goopenai.ChatCompletionMessage, idx int) int {
+	end := idx + 1
+
+	m := messages[idx]
+	if m.Role != goopenai.ChatMessageRoleAssistant || (len(m.ToolCalls) == 0 && m.FunctionCall == nil) {
+		return end
+	}
+
+	for end < len(messages) && (messages[end].Role == goopenai.ChatMessageRoleTool || messages[end].Role == goopenai.ChatMessageRoleFunction) {
+		end++
+	}
+
+	return end
+}
+
+// trim removes the oldest non system messages until the prompt fits within
+// the token budget. The last message is always kept. Removed messages are
+// returned in their original order. The raw messages of the request body are
+// removed alongside.
+func (tc *TruncationConfig) trim(req *goopenai.ChatCompletionRequest, raw []json.RawMessage, counter tokenCounter) ([]goopenai.ChatCompletionMessage, []json.RawMessage, error) {
+	removed := []goopenai.ChatCompletionMessage{}
+
+	for {
+		tks, err := counter.EstimateChatCompletionPromptTokenCounts(req.Model, req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if tks <= tc.MaxPromptTokens {
+			return removed, raw, nil
+		}
+
+		idx := -1
+		for i, m := range req.Messages[:len(req.Messages)-1] {
+			if m.Role != goopenai.ChatMessageRoleSystem {
+				idx = i
+				break
+			}
+		}
+
+		if idx == -1 {
+			return removed, raw, nil
+		}
+
+		end := removable(req.Messages, idx)
+		if end >= len(req.Messages) {
+			return removed, raw, nil
+		}
+
+		removed = append(removed, req.Messages[idx:end]...)
+		req.Messages = append(req.Messages[:idx:idx], req.Messages[end:]...)
+		raw = append(raw[:idx:idx], raw[end:]...)
+	}
+}
+
+// summarize generates a summary of removed messages with the summary step of
+// the config. The cost of the summary is returned so that it can be charged
+// to the key of the request.
+func (r *Request) summarize(tc *TruncationConfig, messages []goopenai.ChatCompletionMessage) (string, float64, error) {
+	step := tc.summaryStep()
+
+	sb := strings.Builder{}
+	for _, m := range messages {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", m.Role, m.Content))
+	}
+
+	data, err := json.Marshal(&goopenai.ChatCompletionRequest{
+		Model: step.Model,
+		Messages: []goopenai.ChatCompletionMessage{
+			{
+				Role:    goopenai.ChatMessageRoleSystem,
+				Content: "Summarize the following conversation concisely. Keep facts, decisions and open questions.",
+			},
+			{
+				Role:    goopenai.ChatMessageRoleUser,
+				Content: sb.String(),
+			},
+		},
+		MaxTokens: 512,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	bs, err := step.DecorateRequest(step.Provider, data, false)
+	if err != nil {
+		return "", 0, err
+	}
+
+	parsed, err := time.ParseDuration(step.Timeout)
+	if err != nil {
+		return "", 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), parsed)
+	defer cancel()
+
+	hreq, err := r.createHttpRequest(ctx, step.Provider, false, step.Params, bs)
+	if err != nil {
+		return "", 0, err
+	}
+
+	hreq.Method = http.MethodPost
+	hreq.Header.Set("Content-Type", "application/json")
+
+	res, err := r.Client.Do(hreq)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("summarization request failed with status code: %d", res.StatusCode)
+	}
+
+	if step.Provider == "anthropic" {
+		if err := translateAnthropicResponse(res); err != nil {
+			return "", 0, err
+		}
+	}
+
+	ccr := &goopenai.ChatCompletionResponse{}
+	err = json.NewDecoder(res.Body).Decode(ccr)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if len(ccr.Choices) == 0 {
+		return "", 0, errors.New("summarization response has no choices")
+	}
+
+	cost := 0.0
+	if ce, ok := r.Estimators[step.Provider]; ok && ce != nil {
+		cost, err = ce.EstimateTotalCost(step.Model, ccr.Usage.PromptTokens, ccr.Usage.CompletionTokens)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	return ccr.Choices[0].Message.Content, cost, nil
+}
+
+// TruncateConversation trims the oldest non system messages of a chat
+// completion request body when it exceeds the configured token budget. If the
+// summarize strategy is used, removed messages are replaced by a summary
+// whose cost is returned. Only the messages of the body are rewritten.
+func (r *Route) TruncateConversation(req *Request, body []byte) ([]byte, float64, bool, error) {
+	tc := r.TruncationConfig
+	if !tc.ShouldTruncate() || req.Counter == nil || r.ShouldRunEmbeddings() {
+		return body, 0, false, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(body, &fields)
+	if err != nil {
+		return body, 0, false, err
+	}
+
+	raw := []json.RawMessage{}
+	if len(fields["messages"]) != 0 {
+		if err := json.Unmarshal(fields["messages"], &raw); err != nil {
+			return body, 0, false, err
+		}
+	}
+
+	ccr := &goopenai.ChatCompletionRequest{}
+	err = json.Unmarshal(body, ccr)
+	if err != nil {
+		return body, 0, false, err
+	}
+
+	if len(ccr.Messages) == 0 || len(ccr.Messages) != len(raw) {
+		return body, 0, false, nil
+	}
+
+	if len(ccr.Model) == 0 && len(r.Steps) != 0 && r.Steps[0] != nil {
+		ccr.Model = r.Steps[0].Model
+	}
+
+	removed, raw, err := tc.trim(ccr, raw, req.Counter)
+	if err != nil {
+		return body, 0, false, err
+	}
+
+	if len(removed) == 0 {
+		return body, 0, false, nil
+	}
+
+	cost := 0.0
+	if tc.ShouldSummarize() {
+		summary, summaryCost, err := req.summarize(tc, removed)
+		if err != nil {
+			return body, 0, false, err
+		}
+
+		cost = summaryCost
+
+		message, err := json.Marshal(goopenai.ChatCompletionMessage{
+			Role:    goopenai.ChatMessageRoleSystem,
+			Content: "Summary of earlier conversation: " + summary,
+		})
+		if err != nil {
+			return body, cost, false, err
+		}
+
+		idx := 0
+		for idx < len(ccr.Messages) && ccr.Messages[idx].Role == goopenai.ChatMessageRoleSystem {
+			idx++
+		}
+
+		messages := append([]json.RawMessage{}, raw[:idx]...)
+		messages = append(messages, message)
+		raw = append(messages, raw[idx:]...)
+	}
+
+	fields["messages"], err = json.Marshal(raw)
+	if err != nil {
+		return body, cost, false, err
+	}
+
+	truncated, err := json.Marshal(fields)
+	if err != nil {
+		return body, cost, false, err
+	}
+
+	return truncated, cost, true, nil
+}
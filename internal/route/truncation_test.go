@@ -0,0 +1,102 @@
+package route
+
+import (
+	"strings"
+	"testing"
+
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wordCounter counts a token per word of message contents.
+type wordCounter struct{}
+
+func (wc *wordCounter) EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error) {
+	tks := 0
+	for _, m := range r.Messages {
+		tks += len(strings.Fields(m.Content))
+	}
+
+	return tks, nil
+}
+
+func TestTruncateConversation(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxTks    int
+		body      string
+		truncated bool
+		want      string
+	}{
+		{
+			name:   "fits",
+			maxTks: 10,
+			body:   `{"model":"gpt-4o","messages":[{"role":"user","content":"one two"}]}`,
+		},
+		{
+			name:      "oldest message removed",
+			maxTks:    3,
+			body:      `{"model":"gpt-4o","messages":[{"role":"system","content":"be nice"},{"role":"user","content":"one two"},{"role":"user","content":"three"}]}`,
+			truncated: true,
+			want:      `{"model":"gpt-4o","messages":[{"role":"system","content":"be nice"},{"role":"user","content":"three"}]}`,
+		},
+		{
+			name:      "tool call removed with its replies",
+			maxTks:    2,
+			body:      `{"model":"gpt-4o","messages":[{"role":"assistant","content":"","tool_calls":[{"id":"a","type":"function","function":{"name":"f","arguments":"{}"}},{"id":"b","type":"function","function":{"name":"g","arguments":"{}"}}]},{"role":"tool","tool_call_id":"a","content":"one"},{"role":"tool","tool_call_id":"b","content":"two"},{"role":"user","content":"three"}]}`,
+			truncated: true,
+			want:      `{"model":"gpt-4o","messages":[{"role":"user","content":"three"}]}`,
+		},
+		{
+			name:   "last message kept",
+			maxTks: 1,
+			body:   `{"model":"gpt-4o","messages":[{"role":"assistant","content":"","tool_calls":[{"id":"a","type":"function","function":{"name":"f","arguments":"{}"}}]},{"role":"tool","tool_call_id":"a","content":"one two"}]}`,
+		},
+		{
+			name:      "unknown fields kept",
+			maxTks:    1,
+			body:      `{"model":"gpt-4o","vendor_option":{"a":1},"messages":[{"role":"user","content":"one two","cache_control":{"type":"ephemeral"}},{"role":"user","content":"three","cache_control":{"type":"ephemeral"}}]}`,
+			truncated: true,
+			want:      `{"model":"gpt-4o","vendor_option":{"a":1},"messages":[{"role":"user","content":"three","cache_control":{"type":"ephemeral"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Route{TruncationConfig: &TruncationConfig{Enabled: true, MaxPromptTokens: tt.maxTks, Strategy: "trim"}}
+
+			body, cost, truncated, err := r.TruncateConversation(&Request{Counter: &wordCounter{}}, []byte(tt.body))
+			require.NoError(t, err)
+
+			assert.Zero(t, cost)
+			assert.Equal(t, tt.truncated, truncated)
+			if !tt.truncated {
+				assert.Equal(t, tt.body, string(body))
+				return
+			}
+
+			assert.JSONEq(t, tt.want, string(body))
+		})
+	}
+}
+
+func TestTruncationConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *TruncationConfig
+		invalid []string
+	}{
+		{name: "trim", config: &TruncationConfig{Enabled: true, MaxPromptTokens: 10, Strategy: "trim"}, invalid: []string{}},
+		{name: "summarize", config: &TruncationConfig{Enabled: true, MaxPromptTokens: 10, Strategy: "summarize", SummaryModel: "gpt-4o-mini"}, invalid: []string{}},
+		{name: "missing summary model", config: &TruncationConfig{Enabled: true, MaxPromptTokens: 10, Strategy: "summarize"}, invalid: []string{"truncationConfig.summaryModel"}},
+		{name: "azure without params", config: &TruncationConfig{Enabled: true, MaxPromptTokens: 10, Strategy: "summarize", SummaryModel: "gpt-4o-mini", SummaryProvider: "azure"}, invalid: []string{"truncationConfig.summaryParams.apiVersion", "truncationConfig.summaryParams.deploymentId"}},
+		{name: "invalid strategy", config: &TruncationConfig{Enabled: true, Strategy: "drop"}, invalid: []string{"truncationConfig.maxPromptTokens", "truncationConfig.strategy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.invalid, tt.config.Validate())
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package route
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	// RoutingStrategyWeighted splits the traffic of a route between its
+	// weighted steps by percentage. The remaining steps are tried in their
+	// configured order if the selected step fails.
+	RoutingStrategyWeighted = "weighted"
+
+	splitTagPrefix = "route_split:"
+)
+
+// SplitTag returns the request tag of events of requests assigned to a step
+// by a weighted route.
+func SplitTag(step *Step) string {
+	return fmt.Sprintf("%s%s/%s", splitTagPrefix, step.Provider, step.Model)
+}
+
+// TotalWeight returns the sum of the weights of the steps of a route.
+func (r *Route) TotalWeight() int {
+	total := 0
+	for _, s := range r.Steps {
+		total += s.Weight
+	}
+
+	return total
+}
+
+// orderByWeight returns the steps with a step selected by weight first and the
+// rest in their configured order.
+func orderByWeight(steps []*Step) ([]*Step, *Step) {
+	total := 0
+	for _, s := range steps {
+		if s.Weight > 0 {
+			total += s.Weight
+		}
+	}
+
+	if total == 0 {
+		return steps, nil
+	}
+
+	n := rand.Intn(total)
+	var selected *Step
+	for _, s := range steps {
+		if s.Weight <= 0 {
+			continue
+		}
+
+		if n < s.Weight {
+			selected = s
+			break
+		}
+
+		n -= s.Weight
+	}
+
+	ordered := []*Step{selected}
+	for _, s := range steps {
+		if s != selected {
+			ordered = append(ordered, s)
+		}
+	}
+
+	return ordered, selected
+}
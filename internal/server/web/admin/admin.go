@@ -24,6 +24,8 @@ type ProviderSettingsManager interface {
 	UpdateSetting(id string, setting *provider.UpdateSetting) (*provider.Setting, error)
 	GetSettingViaCache(id string) (*provider.Setting, error)
 	GetSettingsViaCache(ids []string) ([]*provider.Setting, error)
+	UpsertSetting(name string, setting *provider.Setting) (*provider.Setting, error)
+	GetSettingByName(name string) (*provider.Setting, error)
 }
 
 type KeyManager interface {
@@ -32,6 +34,10 @@ type KeyManager interface {
 	UpdateKey(id string, key *key.UpdateKey) (*key.ResponseKey, error)
 	CreateKey(key *key.RequestKey) (*key.ResponseKey, error)
 	DeleteKey(id string) error
+	SetLimitOverride(id string, r *key.LimitOverrideRequest) (*key.ResponseKey, error)
+	DeleteLimitOverride(id string) (*key.ResponseKey, error)
+	UpsertKey(name string, rk *key.RequestKey) (*key.ResponseKey, error)
+	GetKeyByName(name string) (*key.ResponseKey, error)
 }
 
 type KeyReportingManager interface {
@@ -43,12 +49,18 @@ type KeyReportingManager interface {
 	GetAggregatedEventByDayReporting(e *event.ReportingRequest) (*event.ReportingResponseV2, error)
 	GetCustomIds(keyId string) ([]string, error)
 	GetUserIds(keyId string) ([]string, error)
+	GetSessions(keyId string, start, end int64) ([]*event.SessionReporting, error)
+	GetPiiFindings(req *event.PiiFindingsRequest) (*event.PiiFindingsResponse, error)
+	GetPolicyAnalytics(req *event.PolicyAnalyticsRequest) (*event.PolicyAnalyticsResponse, error)
 }
 
 type PoliciesManager interface {
 	CreatePolicy(p *policy.Policy) (*policy.Policy, error)
 	UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy, error)
 	GetPoliciesByTags(tags []string) ([]*policy.Policy, error)
+	GetPolicyByIdFromMemdb(id string) *policy.Policy
+	UpsertPolicy(name string, p *policy.Policy) (*policy.Policy, error)
+	GetPolicyByName(name string) (*policy.Policy, error)
 }
 
 type ErrorResponse struct {
@@ -65,19 +77,22 @@ type AdminServer struct {
 	m      KeyManager
 }
 
-func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReportingManager, psm ProviderSettingsManager, cpm CustomProvidersManager, rm RouteManager, pm PoliciesManager, um UserManager, adminPass string) (*AdminServer, error) {
+func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReportingManager, psm ProviderSettingsManager, cpm CustomProvidersManager, mrm ModelRemappingsManager, mpm ModelPricingsManager, rm RouteManager, pm PoliciesManager, um UserManager, em ErasureManager, cm ComplianceManager, as auditStorage, ec effectiveConfig, scanner policy.Scanner, cd policy.CustomPolicyDetector, adminPass string) (*AdminServer, error) {
 	router := gin.New()
 
 	prod := mode == "production"
-	router.Use(getAdminLoggerMiddleware(log, "admin", prod, adminPass))
+	router.Use(getAdminLoggerMiddleware(log, "admin", prod, adminPass, as))
 
 	router.GET("/api/health", getGetHealthCheckHandler())
+	router.GET("/api/config", getGetEffectiveConfigHandler(ec))
 
 	router.POST("/api/v2/key-management/keys", getGetKeysV2Handler(m, prod))
 	router.GET("/api/key-management/keys", getGetKeysHandler(m, prod))
 	router.PUT("/api/key-management/keys", getCreateKeyHandler(m, prod))
 	router.PATCH("/api/key-management/keys/:id", getUpdateKeyHandler(m, prod))
 	router.DELETE("/api/key-management/keys/:id", getDeleteKeyHandler(m, prod))
+	router.PUT("/api/key-management/keys/:id/limit-override", getSetLimitOverrideHandler(m, prod))
+	router.DELETE("/api/key-management/keys/:id/limit-override", getDeleteLimitOverrideHandler(m, prod))
 
 	router.GET("/api/reporting/keys/:id", getGetKeyReportingHandler(krm, prod))
 	router.POST("/api/reporting/events", getGetEventMetricsHandler(krm, prod))
@@ -85,6 +100,12 @@ func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReporting
 	router.GET("/api/events", getGetEventsHandler(krm, prod))
 	router.POST("/api/v2/events", getGetEventsV2Handler(krm, prod))
 	router.GET("/api/reporting/user-ids", getGetUserIdsHandler(krm, prod))
+	router.GET("/api/reporting/sessions", getGetSessionsHandler(krm, prod))
+	router.POST("/api/reporting/pii-findings", getGetPiiFindingsHandler(krm, prod))
+	router.POST("/api/reporting/policies", getGetPolicyAnalyticsHandler(krm, prod))
+
+	router.POST("/api/erasure", getEraseHandler(em, prod))
+	router.GET("/api/compliance/export", getComplianceExportHandler(cm, prod))
 	router.POST("/api/reporting/top-keys", getGetTopKeysMetricsHandler(krm, prod))
 
 	router.GET("/api/reporting/custom-ids", getGetCustomIdsHandler(krm, prod))
@@ -97,6 +118,14 @@ func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReporting
 	router.GET("/api/custom/providers", getGetCustomProvidersHandler(cpm, prod))
 	router.PATCH("/api/custom/providers/:id", getUpdateCustomProvidersHandler(cpm, prod))
 
+	router.GET("/api/model-remappings", getGetModelRemappingsHandler(mrm, prod))
+	router.PUT("/api/model-remappings/:model", getUpsertModelRemappingHandler(mrm, prod))
+	router.DELETE("/api/model-remappings/:model", getDeleteModelRemappingHandler(mrm, prod))
+
+	router.GET("/api/model-pricings", getGetModelPricingsHandler(mpm, prod))
+	router.PUT("/api/model-pricings/:model", getUpsertModelPricingHandler(mpm, prod))
+	router.DELETE("/api/model-pricings/:model", getDeleteModelPricingHandler(mpm, prod))
+
 	router.POST("/api/routes", getCreateRouteHandler(rm, prod))
 	router.GET("/api/routes/:id", getGetRouteHandler(rm, prod))
 	router.GET("/api/routes", getGetRoutesHandler(rm, prod))
@@ -105,12 +134,30 @@ func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReporting
 	router.POST("/api/policies", getCreatePolicyHandler(pm, prod))
 	router.PATCH("/api/policies/:id", getUpdatePolicyHandler(pm, prod))
 	router.GET("/api/policies", getGetPoliciesByTagsHandler(pm, prod))
+	router.POST("/api/policies/:id/test", getTestPolicyHandler(pm, scanner, cd, prod))
+
+	dk := newDeclaredKeys(m)
+	dp := newDeclaredPolicies(pm)
+	dr := newDeclaredRoutes(rm)
+	dps := newDeclaredProviderSettings(psm)
+	router.PUT("/api/declarative/keys/:name", getUpsertDeclaredResourceHandler(dk, prod))
+	router.GET("/api/declarative/keys/:name", getGetDeclaredResourceHandler(dk, prod))
+	router.PUT("/api/declarative/policies/:name", getUpsertDeclaredResourceHandler(dp, prod))
+	router.GET("/api/declarative/policies/:name", getGetDeclaredResourceHandler(dp, prod))
+	router.PUT("/api/declarative/routes/:name", getUpsertDeclaredResourceHandler(dr, prod))
+	router.GET("/api/declarative/routes/:name", getGetDeclaredResourceHandler(dr, prod))
+	router.PUT("/api/declarative/provider-settings/:name", getUpsertDeclaredResourceHandler(dps, prod))
+	router.GET("/api/declarative/provider-settings/:name", getGetDeclaredResourceHandler(dps, prod))
 
 	router.POST("/api/users", getCreateUserHandler(um, prod))
 	router.PATCH("/api/users/:id", getUpdateUserHandler(um, prod))
 	router.PATCH("/api/users", getUpdateUserViaTagsAndUserIdHandler(um, prod))
 	router.GET("/api/users", getGetUsersHandler(um, prod))
 
+	router.GET("/api/capabilities", getGetCapabilitiesHandler())
+
+	router.GET("/api/openapi.json", getGetOpenApiSpecHandler())
+
 	srv := &http.Server{
 		Addr:    ":8001",
 		Handler: router,
@@ -127,19 +174,33 @@ func (as *AdminServer) Run() {
 	go func() {
 		as.log.Info("admin server listening at 8001")
 		as.log.Info("PORT 8001 | GET    | /api/health is set up for health checking the admin server")
+		as.log.Info("PORT 8001 | GET    | /api/config is set up for retrieving the effective configuration")
 		as.log.Info("PORT 8001 | GET    | /api/key-management/keys is set up for retrieving keys using a query param called tag")
 		as.log.Info("PORT 8001 | POST   | /api/v2/key-management/keys is set up for retrieving keys")
 		as.log.Info("PORT 8001 | PUT    | /api/key-management/keys is set up for creating a key")
 		as.log.Info("PORT 8001 | PATCH  | /api/key-management/keys/:id is set up for updating a key using an id")
+		as.log.Info("PORT 8001 | PUT    | /api/key-management/keys/:id/limit-override is set up for temporarily overriding limits of a key")
+		as.log.Info("PORT 8001 | DELETE | /api/key-management/keys/:id/limit-override is set up for removing a limit override of a key")
 		as.log.Info("PORT 8001 | GET    | /api/provider-settings is set up for getting provider settings")
 		as.log.Info("PORT 8001 | PUT    | /api/provider-settings is set up for creating a provider setting")
 		as.log.Info("PORT 8001 | PATCH  | /api/provider-settings:id is set up for updating provider setting")
 		as.log.Info("PORT 8001 | POST   | /api/reporting/events is set up for retrieving api metrics")
+		as.log.Info("PORT 8001 | GET    | /api/reporting/sessions is set up for retrieving session usage")
+		as.log.Info("PORT 8001 | POST   | /api/reporting/pii-findings is set up for retrieving pii findings")
+		as.log.Info("PORT 8001 | POST   | /api/reporting/policies is set up for retrieving policy analytics")
+		as.log.Info("PORT 8001 | POST   | /api/erasure is set up for erasing events associated with data subjects")
+		as.log.Info("PORT 8001 | GET    | /api/compliance/export is set up for exporting a signed compliance evidence bundle")
 		as.log.Info("PORT 8001 | GET    | /api/events is set up for retrieving events")
 		as.log.Info("PORT 8001 | POST   | /api/v2/events is set up for retrieving events")
 		as.log.Info("PORT 8001 | POST   | /api/custom/providers is set up for creating a custom provider")
 		as.log.Info("PORT 8001 | GET    | /api/custom/providers is set up for retrieving all custom providers")
 		as.log.Info("PORT 8001 | PATCH  | /api/custom/providers/:id is set up for updating a custom provider")
+		as.log.Info("PORT 8001 | GET    | /api/model-remappings is set up for retrieving deprecated model remappings")
+		as.log.Info("PORT 8001 | PUT    | /api/model-remappings/:model is set up for remapping a deprecated model to its successor")
+		as.log.Info("PORT 8001 | DELETE | /api/model-remappings/:model is set up for deleting a model remapping")
+		as.log.Info("PORT 8001 | GET    | /api/model-pricings is set up for retrieving openai model pricings")
+		as.log.Info("PORT 8001 | PUT    | /api/model-pricings/:model is set up for pricing an openai model")
+		as.log.Info("PORT 8001 | DELETE | /api/model-pricings/:model is set up for deleting a model pricing")
 		as.log.Info("PORT 8001 | POST   | /api/routes is set up for creating a custom route")
 		as.log.Info("PORT 8001 | GET    | /api/routes/:id is set up for retrieving a route")
 		as.log.Info("PORT 8001 | GET    | /api/routes is set up for retrieving routes")
@@ -147,9 +208,14 @@ func (as *AdminServer) Run() {
 		as.log.Info("PORT 8001 | POST   | /api/policies is set up for creating a policy")
 		as.log.Info("PORT 8001 | PATCH  | /api/policies/:id is set up for retrieving a policy")
 		as.log.Info("PORT 8001 | GET    | /api/policies is set up for retrieving policies")
+		as.log.Info("PORT 8001 | POST   | /api/policies/:id/test is set up for testing a policy against sample text")
+		as.log.Info("PORT 8001 | PUT    | /api/declarative/:kind/:name is set up for upserting keys, policies, routes and provider settings by name")
+		as.log.Info("PORT 8001 | GET    | /api/declarative/:kind/:name is set up for retrieving keys, policies, routes and provider settings by name")
 		as.log.Info("PORT 8001 | POST   | /api/users is set up for creating a user")
 		as.log.Info("PORT 8001 | GET    | /api/users is set up for retrieving users")
 		as.log.Info("PORT 8001 | PATCH  | /api/users is set up for updating a user")
+		as.log.Info("PORT 8001 | GET    | /api/capabilities is set up for retrieving model capabilities")
+		as.log.Info("PORT 8001 | GET    | /api/openapi.json is set up for retrieving the openapi specification of the admin and proxy servers")
 
 		if err := as.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			as.log.Sugar().Fatalf("error admin server listening: %v", err)
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+func getGetCapabilitiesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_get_capabilities_handler.requests", nil, 1)
+
+		model := c.Query("model")
+		if len(model) == 0 {
+			telemetry.Incr("bricksllm.admin.get_get_capabilities_handler.success", nil, 1)
+			c.JSON(http.StatusOK, provider.CapabilityPerModel)
+			return
+		}
+
+		capability := provider.GetCapability(model)
+		if capability == nil {
+			c.JSON(http.StatusNotFound, &ErrorResponse{
+				Type:     "/errors/not-found",
+				Title:    "capability not found",
+				Status:   http.StatusNotFound,
+				Detail:   "capability metadata is not found for model: " + model,
+				Instance: "/api/capabilities",
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_capabilities_handler.success", nil, 1)
+		c.JSON(http.StatusOK, capability)
+	}
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/compliance"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type ComplianceManager interface {
+	Export(req *compliance.ExportRequest) ([]byte, string, error)
+}
+
+func getComplianceExportHandler(m ComplianceManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_compliance_export_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_compliance_export_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/compliance/export"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		qstart, err := strconv.ParseInt(c.Query("start"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/bad-start-query-param",
+				Title:    "start query cannot be parsed",
+				Status:   http.StatusBadRequest,
+				Detail:   "start query param must be int64",
+				Instance: path,
+			})
+			return
+		}
+
+		qend, err := strconv.ParseInt(c.Query("end"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/bad-end-query-param",
+				Title:    "end query cannot be parsed",
+				Status:   http.StatusBadRequest,
+				Detail:   "end query param must be int64",
+				Instance: path,
+			})
+			return
+		}
+
+		archive, signature, err := m.Export(&compliance.ExportRequest{
+			Start: qstart,
+			End:   qend,
+		})
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_compliance_export_handler.export_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "compliance export request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when exporting compliance bundle", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/compliance-manager",
+				Title:    "exporting compliance bundle errored out",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_compliance_export_handler.success", nil, 1)
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"compliance-export-%d-%d.tar.gz\"", qstart, qend))
+		c.Header("X-BRICKSLLM-SIGNATURE", signature)
+		c.Data(http.StatusOK, "application/gzip", archive)
+	}
+}
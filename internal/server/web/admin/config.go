@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/config"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+type effectiveConfig interface {
+	Dump() []*config.EffectiveSetting
+}
+
+func getGetEffectiveConfigHandler(ec effectiveConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_get_effective_config_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_effective_config_handler.latency", dur, nil, 1)
+		}()
+
+		c.JSON(http.StatusOK, ec.Dump())
+	}
+}
@@ -0,0 +1,243 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// declaredResource is a kind of resource that can be upserted and retrieved
+// by name for declarative tools such as Terraform or GitOps controllers.
+type declaredResource struct {
+	kind   string
+	upsert func(name string, data []byte) (any, error)
+	get    func(name string) (any, error)
+}
+
+func newDeclaredKeys(m KeyManager) *declaredResource {
+	return &declaredResource{
+		kind: "keys",
+		upsert: func(name string, data []byte) (any, error) {
+			rk := &key.RequestKey{}
+			if err := json.Unmarshal(data, rk); err != nil {
+				return nil, err
+			}
+
+			return m.UpsertKey(name, rk)
+		},
+		get: func(name string) (any, error) {
+			return m.GetKeyByName(name)
+		},
+	}
+}
+
+func newDeclaredPolicies(pm PoliciesManager) *declaredResource {
+	return &declaredResource{
+		kind: "policies",
+		upsert: func(name string, data []byte) (any, error) {
+			p := &policy.Policy{}
+			if err := json.Unmarshal(data, p); err != nil {
+				return nil, err
+			}
+
+			return pm.UpsertPolicy(name, p)
+		},
+		get: func(name string) (any, error) {
+			return pm.GetPolicyByName(name)
+		},
+	}
+}
+
+func newDeclaredRoutes(rm RouteManager) *declaredResource {
+	return &declaredResource{
+		kind: "routes",
+		upsert: func(name string, data []byte) (any, error) {
+			r := &route.Route{}
+			if err := json.Unmarshal(data, r); err != nil {
+				return nil, err
+			}
+
+			return rm.UpsertRoute(name, r)
+		},
+		get: func(name string) (any, error) {
+			return rm.GetRouteByName(name)
+		},
+	}
+}
+
+func newDeclaredProviderSettings(psm ProviderSettingsManager) *declaredResource {
+	return &declaredResource{
+		kind: "provider-settings",
+		upsert: func(name string, data []byte) (any, error) {
+			setting := &provider.Setting{}
+			if err := json.Unmarshal(data, setting); err != nil {
+				return nil, err
+			}
+
+			return psm.UpsertSetting(name, setting)
+		},
+		get: func(name string) (any, error) {
+			return psm.GetSettingByName(name)
+		},
+	}
+}
+
+func getUpsertDeclaredResourceHandler(dr *declaredResource, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		tags := []string{"kind:" + dr.kind}
+		telemetry.Incr("bricksllm.admin.get_upsert_declared_resource_handler.requests", tags, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_upsert_declared_resource_handler.latency", dur, tags, 1)
+		}()
+
+		path := "/api/declarative/" + dr.kind + "/:name"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading declared resource request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		upserted, err := dr.upsert(c.Param("name"), data)
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_upsert_declared_resource_handler.upsert_error", append(tags, "error_type:"+errType), 1)
+			}()
+
+			if _, ok := err.(*json.SyntaxError); ok {
+				errType = "json"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/json-unmarshal",
+					Title:    "json unmarshaller error",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    dr.kind + " validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "referenced resource not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when upserting a declared resource", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/declarative",
+				Title:    "upserting " + dr.kind + " error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_upsert_declared_resource_handler.success", tags, 1)
+		c.JSON(http.StatusOK, upserted)
+	}
+}
+
+func getGetDeclaredResourceHandler(dr *declaredResource, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		tags := []string{"kind:" + dr.kind}
+		telemetry.Incr("bricksllm.admin.get_get_declared_resource_handler.requests", tags, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_declared_resource_handler.latency", dur, tags, 1)
+		}()
+
+		path := "/api/declarative/" + dr.kind + "/:name"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		found, err := dr.get(c.Param("name"))
+		if err != nil {
+			if _, ok := err.(notFoundError); ok {
+				telemetry.Incr("bricksllm.admin.get_get_declared_resource_handler.not_found", tags, 1)
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    dr.kind + " not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			telemetry.Incr("bricksllm.admin.get_get_declared_resource_handler.get_error", tags, 1)
+			logError(log, "error when getting a declared resource", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/declarative",
+				Title:    "getting " + dr.kind + " error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_declared_resource_handler.success", tags, 1)
+		c.JSON(http.StatusOK, found)
+	}
+}
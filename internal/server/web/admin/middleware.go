@@ -1,14 +1,21 @@
 package admin
 
 import (
+	"net/http"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/compliance"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPass string) gin.HandlerFunc {
+type auditStorage interface {
+	InsertAuditLog(l *compliance.AuditLog) error
+}
+
+func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPass string, as auditStorage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if len(adminPass) != 0 && c.Request.Header.Get("X-API-KEY") != adminPass {
 			c.Status(200)
@@ -36,5 +43,20 @@ func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPa
 				zap.Int64("lantecyInMs", latency),
 			)
 		}
+
+		if as != nil && c.Request.Method != http.MethodGet {
+			err := as.InsertAuditLog(&compliance.AuditLog{
+				Id:            util.NewUuid(),
+				CreatedAt:     start.Unix(),
+				Method:        c.Request.Method,
+				Path:          c.FullPath(),
+				StatusCode:    c.Writer.Status(),
+				CorrelationId: cid,
+				ClientIp:      c.ClientIP(),
+			})
+			if err != nil {
+				telemetry.Incr("bricksllm.admin.admin_logger_middleware.insert_audit_log_error", nil, 1)
+			}
+		}
 	}
 }
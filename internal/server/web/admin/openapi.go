@@ -0,0 +1,22 @@
+package admin
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate go run ../../../../cmd/openapi -admin ../../../../docs/admin.yaml -proxy ../../../../docs/proxy.yaml -admin-routes admin.go -proxy-routes ../proxy/proxy.go -out openapi.json
+
+//go:embed openapi.json
+var openApiSpec []byte
+
+func getGetOpenApiSpecHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_get_open_api_spec_handler.requests", nil, 1)
+
+		c.Data(http.StatusOK, "application/json", openApiSpec)
+	}
+}
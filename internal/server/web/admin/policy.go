@@ -229,3 +229,104 @@ func getGetPoliciesByTagsHandler(pm PoliciesManager, prod bool) gin.HandlerFunc
 		c.JSON(http.StatusOK, policies)
 	}
 }
+
+type PolicyTestRequest struct {
+	Text string `json:"text"`
+}
+
+func getTestPolicyHandler(pm PoliciesManager, scanner policy.Scanner, cd policy.CustomPolicyDetector, prod bool) gin.HandlerFunc {
+	client := http.Client{}
+
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_test_policy_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_test_policy_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/policies/:id/test"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		p := pm.GetPolicyByIdFromMemdb(id)
+		if p == nil {
+			c.JSON(http.StatusNotFound, &ErrorResponse{
+				Type:     "/errors/policies/not-found",
+				Title:    "policy not found",
+				Status:   http.StatusNotFound,
+				Detail:   "policy " + id + " is not found",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading policy test request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ptr := &PolicyTestRequest{}
+		err = json.Unmarshal(data, ptr)
+		if err != nil {
+			logError(log, "error when unmarshalling policy test request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if len(ptr.Text) == 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/text-empty",
+				Title:    "text is empty",
+				Status:   http.StatusBadRequest,
+				Detail:   "text is required for testing a policy.",
+				Instance: path,
+			})
+			return
+		}
+
+		result, err := p.Test(client, ptr.Text, scanner, cd, log)
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_test_policy_handler.test_policy_error", nil, 1)
+
+			logError(log, "error when testing a policy", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/policies/test",
+				Title:    "testing a policy failed",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_test_policy_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -17,6 +17,8 @@ type RouteManager interface {
 	GetRoute(id string) (*route.Route, error)
 	GetRoutes() ([]*route.Route, error)
 	CreateRoute(r *route.Route) (*route.Route, error)
+	UpsertRoute(name string, r *route.Route) (*route.Route, error)
+	GetRouteByName(name string) (*route.Route, error)
 }
 
 func getCreateRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
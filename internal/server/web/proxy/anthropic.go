@@ -24,6 +24,7 @@ type anthropicEstimator interface {
 	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
 	EstimateCompletionCost(model string, tks int) (float64, error)
 	EstimatePromptCost(model string, tks int) (float64, error)
+	EstimateCacheCost(model string, cacheWriteTks, cacheReadTks int) (float64, error)
 	Count(input string) int
 	CountMessagesTokens(messages []anthropic.Message) int
 }
@@ -380,13 +381,22 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 
 			if err == nil {
 				logCompletionResponse(log, bytes, prod, private)
+				c.Set("content", completionRes.Text())
 				completionTokens = completionRes.Usage.OutputTokens
-				promptTokens = completionRes.Usage.InputTokens
-				cost, err = e.EstimateTotalCost(model, promptTokens, completionTokens)
+				promptTokens = completionRes.Usage.TotalInputTokens()
+				cost, err = e.EstimateTotalCost(model, completionRes.Usage.InputTokens, completionTokens)
 				if err != nil {
 					telemetry.Incr("bricksllm.proxy.get_messages_handler.estimate_total_cost_error", nil, 1)
 					logError(log, "error when estimating anthropic cost", prod, err)
 				}
+
+				cacheCost, err := e.EstimateCacheCost(model, completionRes.Usage.CacheCreationInputTokens, completionRes.Usage.CacheReadInputTokens)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_messages_handler.estimate_cache_cost_error", nil, 1)
+					logError(log, "error when estimating anthropic prompt caching cost", prod, err)
+				}
+
+				cost += cacheCost
 			}
 
 			c.Set("costInUsd", cost)
@@ -422,7 +432,10 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 		}()
 
 		response := &anthropic.MessagesResponse{}
+		content := ""
 		defer func() {
+			c.Set("content", content)
+
 			tks := response.Usage.OutputTokens
 			model := c.GetString("model")
 			cost, err := e.EstimateCompletionCost(model, tks)
@@ -437,10 +450,16 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 				logError(log, "error when estimating anthropic prompt cost", prod, err)
 			}
 
-			totalCost = cost + estimatedPromptCost
+			cacheCost, err := e.EstimateCacheCost(model, response.Usage.CacheCreationInputTokens, response.Usage.CacheReadInputTokens)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_messages_handler.estimate_cache_cost_error", nil, 1)
+				logError(log, "error when estimating anthropic prompt caching cost", prod, err)
+			}
+
+			totalCost = cost + estimatedPromptCost + cacheCost
 
 			c.Set("costInUsd", totalCost)
-			c.Set("promptTokenCount", response.Usage.InputTokens)
+			c.Set("promptTokenCount", response.Usage.TotalInputTokens())
 			c.Set("completionTokenCount", tks)
 		}()
 
@@ -548,6 +567,8 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 				}
 
 				response.Usage.InputTokens = messageStart.Message.Usage.InputTokens
+				response.Usage.CacheCreationInputTokens = messageStart.Message.Usage.CacheCreationInputTokens
+				response.Usage.CacheReadInputTokens = messageStart.Message.Usage.CacheReadInputTokens
 			}
 
 			if eventName == " message_delta" {
@@ -581,6 +602,8 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 					logError(log, "error when unmarshalling anthropic message stream response content_block_delta", prod, err)
 					return true
 				}
+
+				content += contentBlockDelta.Delta.Text
 			}
 
 			return true
@@ -30,6 +30,55 @@ func buildAzureUrl(path, deploymentId, apiVersion, resourceName string) string {
 	return fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/embeddings?api-version=%s", resourceName, deploymentId, apiVersion)
 }
 
+// resolveAzureDeployment maps the deployment id of the request to a
+// deployment declared on the provider setting. The deployment id can
+// either be a logical model name or the name of the deployment itself.
+func resolveAzureDeployment(c *gin.Context, deployments provider.Deployments) {
+	model, deployment := deployments.Resolve(c.Param("deployment_id"))
+	if deployment == nil {
+		return
+	}
+
+	c.Set("azureDeploymentId", deployment.Deployment)
+	c.Set("azurePricingModel", model)
+
+	if len(deployment.ResourceName) != 0 {
+		c.Set("resourceName", deployment.ResourceName)
+	}
+
+	if len(deployment.ApiVersion) != 0 {
+		c.Set("azureApiVersion", deployment.ApiVersion)
+	}
+}
+
+func getAzureDeploymentId(c *gin.Context) string {
+	if id := c.GetString("azureDeploymentId"); len(id) != 0 {
+		return id
+	}
+
+	return c.Param("deployment_id")
+}
+
+// getAzureApiVersion returns the api version of the resolved deployment and
+// falls back to the api-version query of the request.
+func getAzureApiVersion(c *gin.Context) string {
+	if version := c.GetString("azureApiVersion"); len(version) != 0 {
+		return version
+	}
+
+	return c.Query("api-version")
+}
+
+// getAzurePricingModel returns the underlying model of a mapped deployment
+// so that costs are not estimated with deployment specific model names.
+func getAzurePricingModel(c *gin.Context, model string) string {
+	if mapped := c.GetString("azurePricingModel"); len(mapped) != 0 {
+		return mapped
+	}
+
+	return model
+}
+
 func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe azureEstimator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
@@ -43,7 +92,7 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildAzureUrl(c.FullPath(), c.Param("deployment_id"), c.Query("api-version"), c.GetString("resourceName")), c.Request.Body)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildAzureUrl(c.FullPath(), getAzureDeploymentId(c), getAzureApiVersion(c), c.GetString("resourceName")), c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating azure openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create azure openai http request")
@@ -98,10 +147,11 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 			}
 
 			if err == nil {
-				c.Set("model", chatRes.Model)
+				model := getAzurePricingModel(c, chatRes.Model)
+				c.Set("model", model)
 
 				logChatCompletionResponse(log, prod, private, chatRes)
-				cost, err = aoe.EstimateTotalCost(chatRes.Model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens)
+				cost, err = aoe.EstimateTotalCost(model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens)
 				if err != nil {
 					telemetry.Incr("bricksllm.proxy.get_azure_chat_completion_handler.estimate_total_cost_error", nil, 1)
 					logError(log, "error when estimating azure openai cost", prod, err)
@@ -111,7 +161,7 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 				if exists {
 					converted, ok := m.(*provider.CostMap)
 					if ok {
-						newCost, err := provider.EstimateTotalCostWithCostMaps(chatRes.Model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+						newCost, err := provider.EstimateTotalCostWithCostMaps(model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
 						if err != nil {
 							logError(log, "error when estimating azure chat completions total cost with cost maps", prod, err)
 							telemetry.Incr("bricksllm.proxy.get_azure_chat_completion_handler.estimate_total_cost_with_cost_maps_error", nil, 1)
@@ -128,6 +178,10 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 			c.Set("promptTokenCount", chatRes.Usage.PromptTokens)
 			c.Set("completionTokenCount", chatRes.Usage.CompletionTokens)
 
+			if handleAzureContentFilterResults(c, res.StatusCode, bytes) {
+				return
+			}
+
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
 		}
@@ -145,6 +199,7 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 			}
 
 			logAnthropicErrorResponse(log, bytes, prod)
+			handleAzureContentFilterResults(c, res.StatusCode, bytes)
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
 		}
@@ -152,15 +207,15 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 		buffer := bufio.NewReader(res.Body)
 		// var totalCost float64 = 0
 		// var totalTokens int = 0
-		content := ""
+		choices := &streamedChoices{}
 
 		model := ""
 		defer func() {
 			if len(model) != 0 {
-				c.Set("model", model)
+				c.Set("model", getAzurePricingModel(c, model))
 			}
 
-			c.Set("content", content)
+			c.Set("content", choices.String())
 
 			// tks, cost, err := aoe.EstimateChatCompletionStreamCostWithTokenCounts(model, content)
 			// if err != nil {
@@ -243,8 +298,8 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 			}
 
 			if err == nil {
-				if len(chatCompletionStreamResp.Choices) > 0 && len(chatCompletionStreamResp.Choices[0].Delta.Content) != 0 {
-					content += chatCompletionStreamResp.Choices[0].Delta.Content
+				for _, choice := range chatCompletionStreamResp.Choices {
+					choices.append(choice.Index, choice.Delta.Content)
 				}
 			}
 
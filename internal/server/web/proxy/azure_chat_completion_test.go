@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAzureApiVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		deployment string
+		want       string
+	}{
+		{name: "request version", query: "2024-02-01", want: "2024-02-01"},
+		{name: "deployment version", deployment: "2024-06-01", want: "2024-06-01"},
+		{name: "deployment version takes precedence", query: "2024-02-01", deployment: "2024-06-01", want: "2024-06-01"},
+		{name: "no version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/providers/azure/openai/deployments/gpt/chat/completions?api-version="+tt.query, nil)
+			if len(tt.deployment) != 0 {
+				c.Set("azureApiVersion", tt.deployment)
+			}
+
+			assert.Equal(t, tt.want, getAzureApiVersion(c))
+		})
+	}
+}
@@ -79,7 +79,7 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildAzureUrl(c.FullPath(), c.Param("deployment_id"), c.Query("api-version"), c.GetString("resourceName")), c.Request.Body)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildAzureUrl(c.FullPath(), getAzureDeploymentId(c), getAzureApiVersion(c), c.GetString("resourceName")), c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating azure openai completions http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create azure openai completions http request")
@@ -134,10 +134,11 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 			}
 
 			if err == nil {
-				c.Set("model", cr.Model)
+				model := getAzurePricingModel(c, cr.Model)
+				c.Set("model", model)
 
 				logAzureCompletionsResponse(log, prod, private, cr)
-				cost, err = aoe.EstimateTotalCost(cr.Model, cr.Usage.PromptTokens, cr.Usage.CompletionTokens)
+				cost, err = aoe.EstimateTotalCost(model, cr.Usage.PromptTokens, cr.Usage.CompletionTokens)
 				if err != nil {
 					telemetry.Incr("bricksllm.proxy.get_azure_completions_handler.estimate_total_cost_error", nil, 1)
 					logError(log, "error when estimating azure openai cost", prod, err)
@@ -147,7 +148,7 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 				if exists {
 					converted, ok := m.(*provider.CostMap)
 					if ok {
-						newCost, err := provider.EstimateTotalCostWithCostMaps(cr.Model, cr.Usage.PromptTokens, cr.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+						newCost, err := provider.EstimateTotalCostWithCostMaps(model, cr.Usage.PromptTokens, cr.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
 						if err != nil {
 							logError(log, "error when estimating azure completions total cost with cost maps", prod, err)
 							telemetry.Incr("bricksllm.proxy.get_azure_completions_handler.estimate_total_cost_with_cost_maps_error", nil, 1)
@@ -164,6 +165,10 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 			c.Set("promptTokenCount", cr.Usage.PromptTokens)
 			c.Set("completionTokenCount", cr.Usage.CompletionTokens)
 
+			if handleAzureContentFilterResults(c, res.StatusCode, bytes) {
+				return
+			}
+
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
 		}
@@ -181,6 +186,7 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 			}
 
 			logAnthropicErrorResponse(log, bytes, prod)
+			handleAzureContentFilterResults(c, res.StatusCode, bytes)
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
 		}
@@ -188,15 +194,15 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 		buffer := bufio.NewReader(res.Body)
 		// var totalCost float64 = 0
 		// var totalTokens int = 0
-		content := ""
+		choices := &streamedChoices{}
 
 		model := ""
 		defer func() {
 			if len(model) != 0 {
-				c.Set("model", model)
+				c.Set("model", getAzurePricingModel(c, model))
 			}
 
-			c.Set("content", content)
+			c.Set("content", choices.String())
 		}()
 
 		telemetry.Incr("bricksllm.proxy.get_azure_completions_handler.streaming_requests", nil, 1)
@@ -260,8 +266,8 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 			}
 
 			if err == nil {
-				if len(completionsStreamResp.Choices) > 0 && len(completionsStreamResp.Choices[0].Text) != 0 {
-					content += completionsStreamResp.Choices[0].Text
+				for _, choice := range completionsStreamResp.Choices {
+					choices.append(choice.Index, choice.Text)
 				}
 			}
 
@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// handleAzureContentFilterResults records the content filter results of an
+// Azure OpenAI response on the event and applies the Azure content filter
+// config of the key's policy. It returns true if the response was replaced by
+// a blocked error.
+func handleAzureContentFilterResults(c *gin.Context, status int, body []byte) bool {
+	findings := azure.ParseContentFilterFindings(body)
+	if len(findings) != 0 {
+		telemetry.Incr("bricksllm.proxy.handle_azure_content_filter_results.findings", nil, 1)
+		c.Set("content_filter_findings", findings)
+	}
+
+	if status != http.StatusOK {
+		if azure.IsContentFilterError(body) {
+			telemetry.Incr("bricksllm.proxy.handle_azure_content_filter_results.prompt_filtered", nil, 1)
+			c.Set("action", "blocked")
+		}
+
+		return false
+	}
+
+	raw, exists := c.Get("policy")
+	if !exists {
+		return false
+	}
+
+	p, ok := raw.(*policy.Policy)
+	if !ok {
+		return false
+	}
+
+	switch p.EvaluateAzureContentFilter(findings) {
+	case policy.Block:
+		telemetry.Incr("bricksllm.proxy.handle_azure_content_filter_results.blocked", nil, 1)
+		c.Set("action", "blocked")
+		c.Writer.Header().Del("Content-Length")
+
+		kc, _ := c.Get("key")
+		converted, _ := kc.(*key.ResponseKey)
+		templatedJSON(c, errortemplate.TypeBlocked, http.StatusForbidden, blockMessage(converted, c.GetString(util.STRING_CORRELATION_ID), "[BricksLLM] response blocked"))
+		return true
+	case policy.AllowButWarn:
+		telemetry.Incr("bricksllm.proxy.handle_azure_content_filter_results.warned", nil, 1)
+		c.Set("action", "warned")
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleAzureContentFilterResults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := []byte(`{"choices":[{"index":0,"content_filter_results":{"hate":{"filtered":true,"severity":"high"}}}]}`)
+
+	tests := []struct {
+		name    string
+		kc      *key.ResponseKey
+		action  policy.Action
+		blocked bool
+		message string
+	}{
+		{
+			name:   "allowed",
+			kc:     &key.ResponseKey{},
+			action: policy.Allow,
+		},
+		{
+			name:    "blocked",
+			kc:      &key.ResponseKey{},
+			action:  policy.Block,
+			blocked: true,
+			message: "[BricksLLM] response blocked",
+		},
+		{
+			name:    "blocked with block message",
+			kc:      &key.ResponseKey{BlockMessage: &key.BlockMessage{Template: "blocked: {{requestId}}"}},
+			action:  policy.Block,
+			blocked: true,
+			message: "blocked: cid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("key", tt.kc)
+			c.Set(util.STRING_CORRELATION_ID, "cid")
+			c.Set("policy", &policy.Policy{
+				AzureContentFilterConfig: &policy.AzureContentFilterConfig{Action: tt.action},
+			})
+
+			assert.Equal(t, tt.blocked, handleAzureContentFilterResults(c, http.StatusOK, body))
+			if tt.blocked {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.Contains(t, w.Body.String(), tt.message)
+			}
+		})
+	}
+}
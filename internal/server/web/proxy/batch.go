@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+type batchStorage interface {
+	SwapStatus(batchId, status string) (string, error)
+}
+
+func isBatchFinished(status string) bool {
+	return status == "completed" || status == "expired" || status == "cancelled"
+}
+
+// getBatchPassThroughHandler forwards batch requests to OpenAI and tracks the
+// status of the batches it sees. The usage of a batch is recorded with the
+// batch discount once the batch is seen finished for the first time.
+func getBatchPassThroughHandler(prod, private bool, client http.Client, e estimator, bs batchStorage) gin.HandlerFunc {
+	handler := getPassThroughHandler(prod, private, client)
+
+	return func(c *gin.Context) {
+		handler(c)
+
+		if c.Writer.Status() != http.StatusOK {
+			return
+		}
+
+		raw, ok := c.Get("passThroughResponse")
+		if !ok {
+			return
+		}
+
+		data, ok := raw.([]byte)
+		if !ok {
+			return
+		}
+
+		log := util.GetLogFromCtx(c)
+
+		batch := &goopenai.Batch{}
+		err := json.Unmarshal(data, batch)
+		if err != nil {
+			logError(log, "error when unmarshalling batch response for tracking", prod, err)
+			return
+		}
+
+		if len(batch.ID) == 0 {
+			return
+		}
+
+		previous, err := bs.SwapStatus(batch.ID, batch.Status)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_batch_pass_through_handler.swap_status_error", nil, 1)
+			logError(log, "error when recording batch status", prod, err)
+			return
+		}
+
+		if previous == batch.Status {
+			return
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_batch_pass_through_handler.status_changed", []string{"status:" + batch.Status}, 1)
+		if prod {
+			log.Info("openai batch status changed",
+				zap.String("batch_id", batch.ID),
+				zap.String("previous_status", previous),
+				zap.String("status", batch.Status),
+			)
+		}
+
+		if !isBatchFinished(batch.Status) || isBatchFinished(previous) || batch.OutputFileID == nil || len(*batch.OutputFileID) == 0 {
+			return
+		}
+
+		err = recordBatchUsage(c, client, e, *batch.OutputFileID, prod)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_batch_pass_through_handler.record_batch_usage_error", nil, 1)
+			logError(log, "error when recording batch usage", prod, err)
+
+			// the previous status is restored so that the usage is recorded the next time the batch is retrieved.
+			if _, err := bs.SwapStatus(batch.ID, previous); err != nil {
+				logError(log, "error when restoring batch status", prod, err)
+			}
+		}
+	}
+}
+
+// recordBatchUsage downloads the output file of a batch and records the
+// discounted cost and token counts of its successful requests.
+func recordBatchUsage(c *gin.Context, client http.Client, e estimator, fileId string, prod bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.openai.com/v1/files/"+fileId+"/content", nil)
+	if err != nil {
+		return err
+	}
+
+	copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		errorRes := &goopenai.ErrorResponse{}
+		if err := json.Unmarshal(data, errorRes); err == nil {
+			logOpenAiError(util.GetLogFromCtx(c), prod, errorRes)
+		}
+
+		return fmt.Errorf("batch output file %s cannot be retrieved", fileId)
+	}
+
+	usage := openai.ParseBatchOutput(data)
+
+	var cost float64 = 0
+	promptTks, completionTks := 0, 0
+	for model, u := range usage.Models {
+		// the model is only recorded for batches using a single model.
+		if len(usage.Models) == 1 {
+			c.Set("model", model)
+		}
+
+		promptTks += u.PromptTokens
+		completionTks += u.CompletionTokens
+
+		modelCost, err := e.EstimateBatchCost(model, u.PromptTokens, u.CompletionTokens)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.record_batch_usage.estimate_batch_cost_error", nil, 1)
+			logError(util.GetLogFromCtx(c), "error when estimating batch cost", prod, err)
+			continue
+		}
+
+		cost += modelCost
+	}
+
+	c.Set("costInUsd", cost)
+	c.Set("promptTokenCount", promptTks)
+	c.Set("completionTokenCount", completionTks)
+
+	return nil
+}
@@ -109,10 +109,14 @@ func getBedrockCompletionHandler(prod bool, e anthropicEstimator) gin.HandlerFun
 		start := time.Now()
 
 		if !stream {
+			guardrailId, guardrailVersion, trace := bedrockGuardrailConfig(c)
 			output, err := client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
-				ModelId:     &anthropicReq.Model,
-				ContentType: aws.String("application/json"),
-				Body:        bs,
+				ModelId:             &anthropicReq.Model,
+				ContentType:         aws.String("application/json"),
+				Body:                bs,
+				GuardrailIdentifier: guardrailId,
+				GuardrailVersion:    guardrailVersion,
+				Trace:               trace,
 			})
 
 			if err != nil {
@@ -136,16 +140,21 @@ func getBedrockCompletionHandler(prod bool, e anthropicEstimator) gin.HandlerFun
 
 			c.Set("content", completionRes.Completion)
 
+			recordBedrockGuardrailResult(c, output.Body)
 			c.Data(http.StatusOK, "application/json", output.Body)
 			return
 		}
 
 		telemetry.Incr("bricksllm.proxy.get_bedrock_completion_handler.streaming_requests", nil, 1)
 
+		guardrailId, guardrailVersion, trace := bedrockGuardrailConfig(c)
 		streamOutput, err := client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
-			ModelId:     &anthropicReq.Model,
-			ContentType: aws.String("application/json"),
-			Body:        bs,
+			ModelId:             &anthropicReq.Model,
+			ContentType:         aws.String("application/json"),
+			Body:                bs,
+			GuardrailIdentifier: guardrailId,
+			GuardrailVersion:    guardrailVersion,
+			Trace:               trace,
 		})
 
 		if err != nil {
@@ -186,6 +195,7 @@ func getBedrockCompletionHandler(prod bool, e anthropicEstimator) gin.HandlerFun
 				switch v := event.(type) {
 				case *types.ResponseStreamMemberChunk:
 					raw := v.Value.Bytes
+					recordBedrockGuardrailResult(c, raw)
 					noSpaceLine := bytes.TrimSpace(raw)
 					if len(noSpaceLine) == 0 {
 						return true
@@ -362,10 +372,14 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 		start := time.Now()
 
 		if !stream {
+			guardrailId, guardrailVersion, trace := bedrockGuardrailConfig(c)
 			output, err := client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
-				ModelId:     &anthropicReq.Model,
-				ContentType: aws.String("application/json"),
-				Body:        bs,
+				ModelId:             &anthropicReq.Model,
+				ContentType:         aws.String("application/json"),
+				Body:                bs,
+				GuardrailIdentifier: guardrailId,
+				GuardrailVersion:    guardrailVersion,
+				Trace:               trace,
 			})
 
 			if err != nil {
@@ -393,33 +407,46 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 
 			if err == nil {
 				completionTokens = messagesRes.Usage.OutputTokens
-				promptTokens = messagesRes.Usage.InputTokens
+				promptTokens = messagesRes.Usage.TotalInputTokens()
 
 				model := c.GetString("model")
 				translated := util.TranslateBedrockModelToAnthropicModel(model)
 
-				cost, err = e.EstimateTotalCost(translated, promptTokens, completionTokens)
+				cost, err = e.EstimateTotalCost(translated, messagesRes.Usage.InputTokens, completionTokens)
 				if err != nil {
 					telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.estimate_total_cost_error", nil, 1)
 					logError(log, "error when estimating anthropic cost", prod, err)
 				}
+
+				cacheCost, err := e.EstimateCacheCost(translated, messagesRes.Usage.CacheCreationInputTokens, messagesRes.Usage.CacheReadInputTokens)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.estimate_cache_cost_error", nil, 1)
+					logError(log, "error when estimating anthropic prompt caching cost", prod, err)
+				}
+
+				cost += cacheCost
 			}
 
 			c.Set("costInUsd", cost)
 			c.Set("promptTokenCount", promptTokens)
 			c.Set("completionTokenCount", completionTokens)
 
+			recordBedrockGuardrailResult(c, output.Body)
 			c.Data(http.StatusOK, "application/json", output.Body)
 			return
 		}
 
 		telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.streaming_requests", nil, 1)
 
+		guardrailId, guardrailVersion, trace := bedrockGuardrailConfig(c)
 		streamOutput, err := client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
-			ModelId:     &anthropicReq.Model,
-			ContentType: aws.String("application/json"),
-			Accept:      aws.String("application/json"),
-			Body:        bs,
+			ModelId:             &anthropicReq.Model,
+			ContentType:         aws.String("application/json"),
+			Accept:              aws.String("application/json"),
+			Body:                bs,
+			GuardrailIdentifier: guardrailId,
+			GuardrailVersion:    guardrailVersion,
+			Trace:               trace,
 		})
 
 		if err != nil {
@@ -433,6 +460,8 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 		streamingResponse := [][]byte{}
 		promptTokenCount := 0
 		completionTokenCount := 0
+		cacheWriteTokenCount := 0
+		cacheReadTokenCount := 0
 
 		defer func() {
 			model := c.GetString("model")
@@ -449,8 +478,14 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 				logError(log, "error when estimating bedrock prompt cost", prod, err)
 			}
 
-			c.Set("costInUsd", compeltionCost+promptCost)
-			c.Set("promptTokenCount", promptTokenCount)
+			cacheCost, err := e.EstimateCacheCost(translatedModel, cacheWriteTokenCount, cacheReadTokenCount)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.estimate_cache_cost_error", nil, 1)
+				logError(log, "error when estimating bedrock prompt caching cost", prod, err)
+			}
+
+			c.Set("costInUsd", compeltionCost+promptCost+cacheCost)
+			c.Set("promptTokenCount", promptTokenCount+cacheWriteTokenCount+cacheReadTokenCount)
 			c.Set("completionTokenCount", completionTokenCount)
 			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
 		}()
@@ -462,6 +497,7 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 				switch v := event.(type) {
 				case *types.ResponseStreamMemberChunk:
 					raw := v.Value.Bytes
+					recordBedrockGuardrailResult(c, raw)
 					streamingResponse = append(streamingResponse, raw)
 
 					noSpaceLine := bytes.TrimSpace(raw)
@@ -487,6 +523,8 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 						if stopResp.Metrics != nil {
 							promptTokenCount = stopResp.Metrics.InputTokenCount
 							completionTokenCount = stopResp.Metrics.OutputTokenCount
+							cacheWriteTokenCount = stopResp.Metrics.CacheWriteInputTokenCount
+							cacheReadTokenCount = stopResp.Metrics.CacheReadInputTokenCount
 						}
 					}
 
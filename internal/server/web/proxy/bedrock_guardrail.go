@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+var bedrockGuardrailActionField = []byte("amazon-bedrock-guardrailAction")
+
+// bedrockGuardrailConfig returns the guardrail identifier, version and trace
+// option of the Bedrock provider setting used by the request. Nil identifiers
+// are returned if no guardrail is attached. The trace is only requested if
+// the setting enables it, since Bedrock adds it to response bodies.
+func bedrockGuardrailConfig(c *gin.Context) (*string, *string, types.Trace) {
+	id := c.GetString("awsGuardrailIdentifier")
+	if len(id) == 0 {
+		return nil, nil, ""
+	}
+
+	version := c.GetString("awsGuardrailVersion")
+	if len(version) == 0 {
+		version = "DRAFT"
+	}
+
+	if c.GetBool("awsGuardrailTrace") {
+		return aws.String(id), aws.String(version), types.TraceEnabled
+	}
+
+	return aws.String(id), aws.String(version), ""
+}
+
+// recordBedrockGuardrailResult parses the guardrail action and trace from a
+// Bedrock response body or stream chunk. Requests that the guardrail
+// intervened on are recorded as blocked.
+func recordBedrockGuardrailResult(c *gin.Context, body []byte) {
+	if len(c.GetString("awsGuardrailIdentifier")) == 0 || !bytes.Contains(body, bedrockGuardrailActionField) {
+		return
+	}
+
+	output := &anthropic.BedrockGuardrailOutput{}
+	if err := json.Unmarshal(body, output); err != nil || len(output.Action) == 0 {
+		return
+	}
+
+	c.Set("guardrail_intervention", &anthropic.GuardrailIntervention{
+		GuardrailIdentifier: c.GetString("awsGuardrailIdentifier"),
+		GuardrailVersion:    c.GetString("awsGuardrailVersion"),
+		Action:              output.Action,
+		Trace:               output.Trace,
+	})
+
+	if output.Action == anthropic.BedrockGuardrailIntervened {
+		telemetry.Incr("bricksllm.proxy.record_bedrock_guardrail_result.intervened", nil, 1)
+		c.Set("action", "blocked")
+	}
+}
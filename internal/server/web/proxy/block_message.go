@@ -0,0 +1,14 @@
+package proxy
+
+import "github.com/bricks-cloud/bricksllm/internal/key"
+
+// blockMessage returns the message sent to clients when a request or response
+// is blocked. The block message of the key is rendered if it has one and the
+// fallback is returned otherwise.
+func blockMessage(kc *key.ResponseKey, cid, fallback string) string {
+	if kc == nil || kc.BlockMessage == nil {
+		return fallback
+	}
+
+	return kc.BlockMessage.Render(cid)
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+const downgradedTag = "downgraded"
+
+// applyBudgetDowngrade rewrites the model of a request to its fallback model
+// once the spend of a key reaches the threshold of its budget downgrade. Once
+// the cost limits are reached, only requests that are downgraded are served.
+// It returns the body to forward and true if the request was blocked.
+func applyBudgetDowngrade(c *gin.Context, log *zap.Logger, prod bool, v validator, ac accessCache, kc *key.ResponseKey, body []byte) ([]byte, bool) {
+	if kc.BudgetDowngrade == nil || !kc.HasCostLimits() {
+		return body, false
+	}
+
+	limited := ac.GetAccessStatus(key.BudgetAccessCacheKey(kc.KeyId))
+
+	ratio, err := v.SpendRatio(kc)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.apply_budget_downgrade.spend_ratio_error", nil, 1)
+		logError(log, "error when getting spend ratio of key", prod, err)
+	}
+
+	if limited {
+		ratio = math.Max(ratio, 1)
+	}
+
+	if ratio < kc.BudgetDowngrade.Threshold() {
+		return body, false
+	}
+
+	model := ""
+	if gjson.ValidBytes(body) {
+		if result := gjson.GetBytes(body, "model"); result.Type == gjson.String {
+			model = result.String()
+		}
+	}
+
+	fallback, ok := kc.BudgetDowngrade.Fallback(model)
+	if ok {
+		rewritten, err := rewriteModel(body, fallback)
+		if err == nil {
+			telemetry.Incr("bricksllm.proxy.apply_budget_downgrade.downgraded", []string{"model:" + model, "fallback:" + fallback}, 1)
+			c.Set("downgraded", true)
+
+			return rewritten, false
+		}
+
+		logError(log, "error when rewriting model of downgraded request", prod, err)
+	}
+
+	if ratio >= 1 {
+		telemetry.Incr("bricksllm.proxy.apply_budget_downgrade.blocked", nil, 1)
+		templatedJSON(c, errortemplate.TypeOverBudget, http.StatusTooManyRequests, "[BricksLLM] cost limit reached and request cannot be downgraded")
+		return body, true
+	}
+
+	return body, false
+}
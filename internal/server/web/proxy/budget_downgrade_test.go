@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type ratioValidator struct {
+	ratio float64
+}
+
+func (v *ratioValidator) Validate(k *key.ResponseKey, promptCost float64) error {
+	return nil
+}
+
+func (v *ratioValidator) SpendRatio(k *key.ResponseKey) (float64, error) {
+	return v.ratio, nil
+}
+
+type staticAccessCache struct {
+	limited map[string]bool
+}
+
+func (ac *staticAccessCache) GetAccessStatus(key string) bool {
+	return ac.limited[key]
+}
+
+func TestApplyBudgetDowngrade(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	kc := &key.ResponseKey{
+		KeyId:          "key",
+		CostLimitInUsd: 10,
+		BudgetDowngrade: &key.BudgetDowngrade{
+			ThresholdPercentage: 90,
+			FallbackModels:      map[string]string{"gpt-4o": "gpt-4o-mini"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		ratio       float64
+		limited     bool
+		body        string
+		blocked     bool
+		downgraded  bool
+		forwardBody string
+	}{
+		{name: "below threshold", ratio: 0.5, body: `{"model":"gpt-4o"}`, forwardBody: `{"model":"gpt-4o"}`},
+		{name: "downgraded past threshold", ratio: 0.95, body: `{"model":"gpt-4o"}`, downgraded: true, forwardBody: `{"model":"gpt-4o-mini"}`},
+		{name: "no fallback below limit", ratio: 0.95, body: `{"model":"gpt-3.5-turbo"}`, forwardBody: `{"model":"gpt-3.5-turbo"}`},
+		{name: "no fallback at limit", ratio: 1, body: `{"model":"gpt-3.5-turbo"}`, blocked: true},
+		{name: "no model at limit", ratio: 1, body: ``, blocked: true},
+		{name: "downgraded at limit", ratio: 1, body: `{"model":"gpt-4o"}`, downgraded: true, forwardBody: `{"model":"gpt-4o-mini"}`},
+		{name: "access cache at limit", ratio: 0, limited: true, body: `{"model":"gpt-3.5-turbo"}`, blocked: true},
+		{name: "access cache downgraded", ratio: 0, limited: true, body: `{"model":"gpt-4o"}`, downgraded: true, forwardBody: `{"model":"gpt-4o-mini"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			ac := &staticAccessCache{limited: map[string]bool{key.BudgetAccessCacheKey("key"): tt.limited}}
+			body, blocked := applyBudgetDowngrade(c, zap.NewNop(), true, &ratioValidator{ratio: tt.ratio}, ac, kc, []byte(tt.body))
+
+			assert.Equal(t, tt.blocked, blocked)
+			assert.Equal(t, tt.downgraded, c.GetBool("downgraded"))
+
+			if tt.blocked {
+				assert.Equal(t, http.StatusTooManyRequests, w.Code)
+				return
+			}
+
+			assert.JSONEq(t, tt.forwardBody, string(body))
+		})
+	}
+}
@@ -15,8 +15,35 @@ import (
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
 )
 
+func estimateChatCompletionCost(c *gin.Context, log *zap.Logger, prod bool, e estimator, model string, usage goopenai.Usage) float64 {
+	cost, err := e.EstimateTotalCost(model, usage.PromptTokens, usage.CompletionTokens)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.get_chat_completion_handler.estimate_total_cost_error", nil, 1)
+		logError(log, "error when estimating openai cost", prod, err)
+	}
+
+	m, exists := c.Get("cost_map")
+	if exists {
+		converted, ok := m.(*provider.CostMap)
+		if ok {
+			newCost, err := provider.EstimateTotalCostWithCostMaps(model, usage.PromptTokens, usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+			if err != nil {
+				logError(log, "error when estimating openai chat completions total cost with cost maps", prod, err)
+				telemetry.Incr("bricksllm.proxy.get_chat_completion_handler.estimate_total_cost_with_cost_maps_error", nil, 1)
+			}
+
+			if newCost != 0 {
+				cost = newCost
+			}
+		}
+	}
+
+	return cost
+}
+
 func getChatCompletionHandler(prod, private bool, client http.Client, e estimator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
@@ -30,7 +57,14 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions", c.Request.Body)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading openai chat completion request body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read openai chat completion request body")
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions", bytes.NewReader(body))
 		if err != nil {
 			logError(log, "error when creating openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create azure openai http request")
@@ -87,34 +121,34 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 				logError(log, "error when unmarshalling openai http chat completion response body", prod, err)
 			}
 
+			promptTokens := chatRes.Usage.PromptTokens
+			completionTokens := chatRes.Usage.CompletionTokens
+			blocked := false
+
 			if err == nil {
 				logChatCompletionResponse(log, prod, private, chatRes)
-				cost, err = e.EstimateTotalCost(model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens)
-				if err != nil {
-					telemetry.Incr("bricksllm.proxy.get_chat_completion_handler.estimate_total_cost_error", nil, 1)
-					logError(log, "error when estimating openai cost", prod, err)
-				}
-
-				m, exists := c.Get("cost_map")
-				if exists {
-					converted, ok := m.(*provider.CostMap)
-					if ok {
-						newCost, err := provider.EstimateTotalCostWithCostMaps(model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
-						if err != nil {
-							logError(log, "error when estimating openai chat completions total cost with cost maps", prod, err)
-							telemetry.Incr("bricksllm.proxy.get_chat_completion_handler.estimate_total_cost_with_cost_maps_error", nil, 1)
-						}
-
-						if newCost != 0 {
-							cost = newCost
-						}
-					}
+				cost = estimateChatCompletionCost(c, log, prod, e, model, chatRes.Usage)
+
+				var retries []goopenai.Usage
+				bytes, retries, blocked = enforceStructuredOutput(c, log, prod, body, bytes, chatRes, newRetrySender(ctx, client, req, body))
+				for _, usage := range retries {
+					cost += estimateChatCompletionCost(c, log, prod, e, model, usage)
+					promptTokens += usage.PromptTokens
+					completionTokens += usage.CompletionTokens
 				}
 			}
 
 			c.Set("costInUsd", cost)
-			c.Set("promptTokenCount", chatRes.Usage.PromptTokens)
-			c.Set("completionTokenCount", chatRes.Usage.CompletionTokens)
+			c.Set("promptTokenCount", promptTokens)
+			c.Set("completionTokenCount", completionTokens)
+
+			if chatRes.Usage.CompletionTokensDetails != nil {
+				c.Set("reasoningTokenCount", chatRes.Usage.CompletionTokensDetails.ReasoningTokens)
+			}
+
+			if blocked {
+				return
+			}
 
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
@@ -145,12 +179,20 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 		}
 
 		buffer := bufio.NewReader(res.Body)
-		content := ""
+		choices := &streamedChoices{}
 		streamingResponse := [][]byte{}
+		var usage *goopenai.Usage
 		defer func() {
-			c.Set("content", content)
+			c.Set("content", choices.String())
 			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
 
+			if usage != nil {
+				c.Set("stream_usage", usage)
+
+				if usage.CompletionTokensDetails != nil {
+					c.Set("reasoningTokenCount", usage.CompletionTokensDetails.ReasoningTokens)
+				}
+			}
 		}()
 
 		telemetry.Incr("bricksllm.proxy.get_chat_completion_handler.streaming_requests", nil, 1)
@@ -213,8 +255,12 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 			}
 
 			if err == nil {
-				if len(chatCompletionStreamResp.Choices) > 0 && len(chatCompletionStreamResp.Choices[0].Delta.Content) != 0 {
-					content += chatCompletionStreamResp.Choices[0].Delta.Content
+				for _, choice := range chatCompletionStreamResp.Choices {
+					choices.append(choice.Index, choice.Delta.Content)
+				}
+
+				if chatCompletionStreamResp.Usage != nil {
+					usage = chatCompletionStreamResp.Usage
 				}
 			}
 
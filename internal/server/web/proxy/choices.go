@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// maxStreamedChoices is the largest n accepted by OpenAI for a completion.
+const maxStreamedChoices = 128
+
+// streamedChoices accumulates streamed content per choice index so that
+// completions requested with n > 1 are accounted for all generated choices
+// rather than only the first one.
+type streamedChoices struct {
+	contents []string
+}
+
+func (sc *streamedChoices) append(index int, delta string) {
+	if index < 0 || len(delta) == 0 {
+		return
+	}
+
+	// chunks with an index no request can produce are dropped so that an
+	// upstream cannot grow the accumulated contents without bound.
+	if index >= maxStreamedChoices {
+		telemetry.Incr("bricksllm.proxy.streamed_choices.index_out_of_range", nil, 1)
+		return
+	}
+
+	for len(sc.contents) <= index {
+		sc.contents = append(sc.contents, "")
+	}
+
+	sc.contents[index] += delta
+}
+
+func (sc *streamedChoices) String() string {
+	return strings.Join(sc.contents, "")
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamedChoicesAppend(t *testing.T) {
+	tests := []struct {
+		name     string
+		indexes  []int
+		want     string
+		contents int
+	}{
+		{name: "single choice", indexes: []int{0, 0}, want: "aa", contents: 1},
+		{name: "multiple choices", indexes: []int{1, 0, 1}, want: "aaa", contents: 2},
+		{name: "negative index", indexes: []int{-1, 0}, want: "a", contents: 1},
+		{name: "index out of range", indexes: []int{0, maxStreamedChoices, 1 << 30}, want: "a", contents: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &streamedChoices{}
+			for _, index := range tt.indexes {
+				sc.append(index, "a")
+			}
+
+			assert.Equal(t, tt.want, sc.String())
+			assert.Len(t, sc.contents, tt.contents)
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type cohereEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
+	EstimateRerankCost(model string, searchUnits int) (float64, error)
+	EstimateSearchUnits(r *cohere.RerankRequest) int
+	CountTexts(texts []string) int
+	Count(input string) int
+}
+
+func estimateCohereChatCost(c *gin.Context, ce cohereEstimator, model, content string, usage *cohere.Usage) (int, int, float64, error) {
+	promptTks, completionTks := usage.InputTokens(), usage.OutputTokens()
+
+	// token counts are estimated when responses do not report usage.
+	if promptTks == 0 {
+		if cr, ok := c.Get("cohereRequest"); ok {
+			if converted, ok := cr.(*cohere.ChatRequest); ok {
+				promptTks = ce.CountTexts(converted.Texts())
+			}
+		}
+	}
+
+	if completionTks == 0 && len(content) != 0 {
+		completionTks = ce.Count(content)
+	}
+
+	m, exists := c.Get("cost_map")
+	if exists {
+		converted, ok := m.(*provider.CostMap)
+		if ok {
+			cost, err := provider.EstimateTotalCostWithCostMaps(model, promptTks, completionTks, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+			if err == nil && cost != 0 {
+				return promptTks, completionTks, cost, nil
+			}
+		}
+	}
+
+	cost, err := ce.EstimateTotalCost(model, promptTks, completionTks)
+	return promptTks, completionTks, cost, err
+}
+
+func sendCohereRequest(c *gin.Context, client http.Client, url string) (*http.Response, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, c.Request.Body)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+	if c.GetBool("stream") {
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Connection", "keep-alive")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	for name, values := range res.Header {
+		for _, value := range values {
+			c.Header(name, value)
+		}
+	}
+
+	return res, cancel, nil
+}
+
+func getCohereChatHandler(prod bool, client http.Client, ce cohereEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		start := time.Now()
+		res, cancel, err := sendCohereRequest(c, client, cohere.ChatUrl)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to cohere", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to cohere")
+			return
+		}
+
+		defer cancel()
+		defer res.Body.Close()
+
+		model := c.GetString("model")
+		isStreaming := c.GetBool("stream")
+
+		if res.StatusCode == http.StatusOK && !isStreaming {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.latency", dur, nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading cohere chat response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read cohere response body")
+				return
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.success_latency", dur, nil, 1)
+
+			cr := &cohere.ChatResponse{}
+			err = json.Unmarshal(data, cr)
+			if err != nil {
+				logError(log, "error when unmarshalling cohere chat response body", prod, err)
+			}
+
+			if err == nil {
+				content := cr.Text()
+				c.Set("content", content)
+
+				promptTks, completionTks, cost, err := estimateCohereChatCost(c, ce, model, content, cr.Usage)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.estimate_total_cost_error", nil, 1)
+					logError(log, "error when estimating cohere chat cost", prod, err)
+				}
+
+				c.Set("costInUsd", cost)
+				c.Set("promptTokenCount", promptTks)
+				c.Set("completionTokenCount", completionTks)
+			}
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.error_latency", dur, nil, 1)
+			telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.error_response", nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading cohere chat response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read cohere response body")
+				return
+			}
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		buffer := bufio.NewReader(res.Body)
+		content := ""
+		var usage *cohere.Usage
+		streamingResponse := [][]byte{}
+		defer func() {
+			c.Set("content", content)
+
+			promptTks, completionTks, cost, err := estimateCohereChatCost(c, ce, model, content, usage)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating cohere streaming chat cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", promptTks)
+			c.Set("completionTokenCount", completionTks)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.streaming_requests", nil, 1)
+
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				if errors.Is(err, context.DeadlineExceeded) {
+					telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.context_deadline_exceeded_error", nil, 1)
+					logError(log, "context deadline exceeded when reading bytes from cohere chat response", prod, err)
+
+					return false
+				}
+
+				telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from cohere chat response", prod, err)
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			if _, err := w.Write(raw); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.write_error", nil, 1)
+				logError(log, "error when writing cohere streaming response", prod, err)
+				return false
+			}
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			event := &cohere.StreamEvent{}
+			err = json.Unmarshal(bytes.TrimPrefix(noSpaceLine, headerData), event)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.stream_event_unmarshall_error", nil, 1)
+				logError(log, "error when unmarshalling cohere chat stream event", prod, err)
+				return true
+			}
+
+			content += event.Text()
+
+			// the usage of the request is sent in the message-end event.
+			if event.Delta != nil && event.Delta.Usage != nil {
+				usage = event.Delta.Usage
+			}
+
+			return true
+		})
+
+		telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
+
+func getCohereEmbedHandler(prod bool, client http.Client, ce cohereEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_cohere_embed_handler.requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		start := time.Now()
+		res, cancel, err := sendCohereRequest(c, client, cohere.EmbedUrl)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_cohere_embed_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to cohere", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to cohere")
+			return
+		}
+
+		defer cancel()
+		defer res.Body.Close()
+
+		dur := time.Since(start)
+		telemetry.Timing("bricksllm.proxy.get_cohere_embed_handler.latency", dur, nil, 1)
+
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading cohere embed response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read cohere embed response body")
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.get_cohere_embed_handler.error_response", nil, 1)
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_cohere_embed_handler.success", nil, 1)
+		telemetry.Timing("bricksllm.proxy.get_cohere_embed_handler.success_latency", dur, nil, 1)
+
+		er := &cohere.EmbedResponse{}
+		err = json.Unmarshal(data, er)
+		if err != nil {
+			logError(log, "error when unmarshalling cohere embed response body", prod, err)
+		}
+
+		tks := 0
+		if er.Meta != nil && er.Meta.BilledUnits != nil {
+			tks = er.Meta.BilledUnits.InputTokens
+		}
+
+		if tks == 0 {
+			if req, ok := c.Get("cohereRequest"); ok {
+				if converted, ok := req.(*cohere.EmbedRequest); ok {
+					tks = ce.CountTexts(converted.Texts)
+				}
+			}
+		}
+
+		model := c.GetString("model")
+		cost, err := ce.EstimateEmbeddingsInputCost(model, tks)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_cohere_embed_handler.estimate_total_cost_error", nil, 1)
+			logError(log, "error when estimating cohere embed cost", prod, err)
+		}
+
+		m, exists := c.Get("cost_map")
+		if exists {
+			converted, ok := m.(*provider.CostMap)
+			if ok {
+				newCost, err := provider.EstimateCostWithCostMap(model, tks, 1000, converted.EmbeddingsCostPerModel)
+				if err != nil {
+					logError(log, "error when estimating cohere embed cost with cost maps", prod, err)
+					telemetry.Incr("bricksllm.proxy.get_cohere_embed_handler.estimate_cost_with_cost_map_error", nil, 1)
+				}
+
+				if newCost != 0 {
+					cost = newCost
+				}
+			}
+		}
+
+		c.Set("costInUsd", cost)
+		c.Set("promptTokenCount", tks)
+
+		c.Data(res.StatusCode, "application/json", data)
+	}
+}
+
+// getCohereRerankHandler proxies rerank requests. Reranking is billed per
+// search unit rather than per token, so the cost is derived from the search
+// units reported by Cohere while the token count of the query and documents
+// is recorded for rate limiting.
+func getCohereRerankHandler(prod bool, client http.Client, ce cohereEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_cohere_rerank_handler.requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		start := time.Now()
+		res, cancel, err := sendCohereRequest(c, client, cohere.RerankUrl)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_cohere_rerank_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to cohere", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to cohere")
+			return
+		}
+
+		defer cancel()
+		defer res.Body.Close()
+
+		dur := time.Since(start)
+		telemetry.Timing("bricksllm.proxy.get_cohere_rerank_handler.latency", dur, nil, 1)
+
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading cohere rerank response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read cohere rerank response body")
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.get_cohere_rerank_handler.error_response", nil, 1)
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_cohere_rerank_handler.success", nil, 1)
+		telemetry.Timing("bricksllm.proxy.get_cohere_rerank_handler.success_latency", dur, nil, 1)
+
+		rr := &cohere.RerankResponse{}
+		err = json.Unmarshal(data, rr)
+		if err != nil {
+			logError(log, "error when unmarshalling cohere rerank response body", prod, err)
+		}
+
+		searchUnits := 0
+		if rr.Meta != nil && rr.Meta.BilledUnits != nil {
+			searchUnits = rr.Meta.BilledUnits.SearchUnits
+		}
+
+		tks := 0
+		if req, ok := c.Get("cohereRequest"); ok {
+			if converted, ok := req.(*cohere.RerankRequest); ok {
+				tks = ce.CountTexts(converted.Texts())
+
+				if searchUnits == 0 {
+					searchUnits = ce.EstimateSearchUnits(converted)
+				}
+			}
+		}
+
+		cost, err := ce.EstimateRerankCost(c.GetString("model"), searchUnits)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_cohere_rerank_handler.estimate_total_cost_error", nil, 1)
+			logError(log, "error when estimating cohere rerank cost", prod, err)
+		}
+
+		c.Set("costInUsd", cost)
+		c.Set("promptTokenCount", tks)
+
+		c.Data(res.StatusCode, "application/json", data)
+	}
+}
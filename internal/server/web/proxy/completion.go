@@ -22,7 +22,7 @@ func logCompletionRequest(log *zap.Logger, data []byte, prod, private bool) {
 			zap.Int("max_tokens_to_sample", cr.MaxTokensToSample),
 			zap.Any("stop_sequnces", cr.StopSequences),
 			zap.Float32("temperature", cr.Temperature),
-			zap.Int("top_p", cr.TopP),
+			zap.Float32("top_p", cr.TopP),
 			zap.Int("top_k", cr.TopK),
 			zap.Bool("stream", cr.Stream),
 		}
@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// fitContextWindow returns the model and the number of completion tokens that
+// fit within a context window. A larger context sibling model is preferred
+// over clamping if one is configured for the requested model. If the request
+// already fits or nothing can be done, the inputs are returned unchanged.
+func fitContextWindow(model string, promptTks, maxTks int, siblings map[string]string) (string, int) {
+	window := provider.GetContextWindow(model)
+	if window == 0 || maxTks == 0 || promptTks+maxTks <= window {
+		return model, maxTks
+	}
+
+	if sibling, ok := siblings[model]; ok && len(sibling) != 0 {
+		sw := provider.GetContextWindow(sibling)
+		if sw == 0 || promptTks+maxTks <= sw {
+			return sibling, maxTks
+		}
+	}
+
+	remaining := window - promptTks
+	if remaining <= 0 {
+		return model, maxTks
+	}
+
+	return model, remaining
+}
+
+func fitChatCompletionRequest(e estimator, ccr *goopenai.ChatCompletionRequest, siblings map[string]string) (bool, error) {
+	maxTks := ccr.MaxTokens
+	if ccr.MaxCompletionTokens != 0 {
+		maxTks = ccr.MaxCompletionTokens
+	}
+
+	if maxTks == 0 || provider.GetContextWindow(ccr.Model) == 0 {
+		return false, nil
+	}
+
+	tks, err := e.EstimateChatCompletionPromptTokenCounts(ccr.Model, ccr)
+	if err != nil {
+		return false, err
+	}
+
+	model, fitted := fitContextWindow(ccr.Model, tks, maxTks, siblings)
+	if model == ccr.Model && fitted == maxTks {
+		return false, nil
+	}
+
+	ccr.Model = model
+	if ccr.MaxCompletionTokens != 0 {
+		ccr.MaxCompletionTokens = fitted
+	} else {
+		ccr.MaxTokens = fitted
+	}
+
+	return true, nil
+}
+
+// countMessagesRequestTokens counts the tokens of the messages and of the
+// system prompt of a messages request.
+func countMessagesRequestTokens(ae anthropicEstimator, mr *anthropic.MessagesRequest) int {
+	tks := ae.CountMessagesTokens(mr.Messages)
+	if mr.System != nil {
+		tks += ae.Count(mr.System.String())
+	}
+
+	return tks
+}
+
+func fitMessagesRequest(ae anthropicEstimator, mr *anthropic.MessagesRequest, siblings map[string]string) bool {
+	if mr.MaxTokens == 0 || provider.GetContextWindow(mr.Model) == 0 {
+		return false
+	}
+
+	tks := countMessagesRequestTokens(ae, mr)
+
+	model, fitted := fitContextWindow(mr.Model, tks, mr.MaxTokens, siblings)
+	if model == mr.Model && fitted == mr.MaxTokens {
+		return false
+	}
+
+	mr.Model = model
+	mr.MaxTokens = fitted
+
+	return true
+}
+
+func patchJsonFields(body []byte, fields map[string]any) ([]byte, error) {
+	parsed := map[string]any{}
+	err := json.Unmarshal(body, &parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range fields {
+		parsed[k] = v
+	}
+
+	return json.Marshal(parsed)
+}
+
+// fitRequestToContextWindow rewrites the forwarded request body so that prompt
+// tokens and requested completion tokens fit within the model's context window.
+// Only fields related to the model and completion tokens are modified.
+func fitRequestToContextWindow(c *gin.Context, log *zap.Logger, prod bool, e estimator, ae anthropicEstimator, policyInput any, siblings map[string]string) {
+	fields := map[string]any{}
+	model := ""
+
+	switch input := policyInput.(type) {
+	case *goopenai.ChatCompletionRequest:
+		if c.FullPath() != "/api/providers/openai/v1/chat/completions" {
+			return
+		}
+
+		changed, err := fitChatCompletionRequest(e, input, siblings)
+		if err != nil {
+			logError(log, "error when fitting chat completion request to context window", prod, err)
+			return
+		}
+
+		if !changed {
+			return
+		}
+
+		model = input.Model
+		if input.MaxCompletionTokens != 0 {
+			fields["max_completion_tokens"] = input.MaxCompletionTokens
+		} else {
+			fields["max_tokens"] = input.MaxTokens
+		}
+	case *anthropic.MessagesRequest:
+		if c.FullPath() != "/api/providers/anthropic/v1/messages" {
+			return
+		}
+
+		if !fitMessagesRequest(ae, input, siblings) {
+			return
+		}
+
+		model = input.Model
+		fields["max_tokens"] = input.MaxTokens
+	default:
+		return
+	}
+
+	fields["model"] = model
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		logError(log, "error when reading request body for fitting context window", prod, err)
+		return
+	}
+
+	patched, err := patchJsonFields(body, fields)
+	if err != nil {
+		logError(log, "error when patching request body for fitting context window", prod, err)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		return
+	}
+
+	telemetry.Incr("bricksllm.proxy.fit_request_to_context_window.fitted", nil, 1)
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(patched))
+	c.Set("model", model)
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/stretchr/testify/assert"
+)
+
+// wordEstimator counts a token per word.
+type wordEstimator struct{}
+
+func (we *wordEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	return 0, nil
+}
+
+func (we *wordEstimator) EstimateCompletionCost(model string, tks int) (float64, error) {
+	return 0, nil
+}
+
+func (we *wordEstimator) EstimatePromptCost(model string, tks int) (float64, error) {
+	return 0, nil
+}
+
+func (we *wordEstimator) EstimateCacheCost(model string, cacheWriteTks, cacheReadTks int) (float64, error) {
+	return 0, nil
+}
+
+func (we *wordEstimator) Count(input string) int {
+	return len(strings.Fields(input))
+}
+
+func (we *wordEstimator) CountMessagesTokens(messages []anthropic.Message) int {
+	tks := 0
+	for _, m := range messages {
+		tks += we.Count(m.Content.String())
+	}
+
+	return tks
+}
+
+func TestFitContextWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string
+		promptTks int
+		maxTks    int
+		siblings  map[string]string
+		wantModel string
+		wantTks   int
+	}{
+		{name: "fits", model: "gpt-4", promptTks: 1000, maxTks: 1000, wantModel: "gpt-4", wantTks: 1000},
+		{name: "clamped", model: "gpt-4", promptTks: 8000, maxTks: 1000, wantModel: "gpt-4", wantTks: 192},
+		{name: "sibling", model: "gpt-4", promptTks: 8000, maxTks: 1000, siblings: map[string]string{"gpt-4": "gpt-4-32k"}, wantModel: "gpt-4-32k", wantTks: 1000},
+		{name: "prompt exceeds window", model: "gpt-4", promptTks: 9000, maxTks: 1000, wantModel: "gpt-4", wantTks: 1000},
+		{name: "unknown model", model: "unknown", promptTks: 9000, maxTks: 1000, wantModel: "unknown", wantTks: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, tks := fitContextWindow(tt.model, tt.promptTks, tt.maxTks, tt.siblings)
+			assert.Equal(t, tt.wantModel, model)
+			assert.Equal(t, tt.wantTks, tks)
+		})
+	}
+}
+
+func TestFitMessagesRequestCountsSystemPrompt(t *testing.T) {
+	mr := &anthropic.MessagesRequest{
+		Model:     "claude-2",
+		System:    &anthropic.MessageContent{Text: strings.Repeat("word ", 60000)},
+		Messages:  []anthropic.Message{{Role: "user", Content: anthropic.MessageContent{Text: strings.Repeat("word ", 30000)}}},
+		MaxTokens: 20000,
+	}
+
+	assert.True(t, fitMessagesRequest(&wordEstimator{}, mr, nil))
+	assert.Equal(t, 10000, mr.MaxTokens)
+}
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type CostEstimationRequest struct {
+	Provider             string `json:"provider"`
+	Model                string `json:"model"`
+	PromptTokenCount     int    `json:"promptTokenCount"`
+	CompletionTokenCount int    `json:"completionTokenCount"`
+	// CacheWriteTokenCount and CacheReadTokenCount are the prompt caching
+	// tokens of anthropic requests.
+	CacheWriteTokenCount int `json:"cacheWriteTokenCount"`
+	CacheReadTokenCount  int `json:"cacheReadTokenCount"`
+}
+
+type CostEstimationResponse struct {
+	Provider             string  `json:"provider"`
+	Model                string  `json:"model"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	CacheWriteTokenCount int     `json:"cacheWriteTokenCount,omitempty"`
+	CacheReadTokenCount  int     `json:"cacheReadTokenCount,omitempty"`
+	CostInUsd            float64 `json:"costInUsd"`
+	// CustomerPriceInUsd is the cost priced with the markup of the key.
+	CustomerPriceInUsd float64 `json:"customerPriceInUsd"`
+}
+
+func getCostMapForProvider(c *gin.Context, providerName string) *provider.CostMap {
+	settings, ok := c.Get("settings")
+	if !ok {
+		return nil
+	}
+
+	converted, ok := settings.([]*provider.Setting)
+	if !ok {
+		return nil
+	}
+
+	for _, setting := range converted {
+		if setting != nil && setting.Provider == providerName && setting.CostMap != nil {
+			return setting.CostMap
+		}
+	}
+
+	return nil
+}
+
+func getEstimateCostHandler(prod bool, e estimator, ae anthropicEstimator, aoe azureEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_estimate_cost_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading cost estimation request body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read cost estimation request body")
+			return
+		}
+
+		cer := &CostEstimationRequest{}
+		err = json.Unmarshal(data, cer)
+		if err != nil {
+			logError(log, "error when unmarshalling cost estimation request body", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] cost estimation request body is not valid")
+			return
+		}
+
+		if len(cer.Model) == 0 {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] model is required for estimating cost")
+			return
+		}
+
+		if cer.PromptTokenCount < 0 || cer.CompletionTokenCount < 0 || cer.CacheWriteTokenCount < 0 || cer.CacheReadTokenCount < 0 {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] token counts cannot be negative")
+			return
+		}
+
+		if len(cer.Provider) == 0 {
+			cer.Provider = "openai"
+		}
+
+		c.Set("model", cer.Model)
+
+		var cost float64
+		switch cer.Provider {
+		case "anthropic":
+			cost, err = estimateMessagesUsageCost(ae, cer.Model, anthropic.MessagesUsage{
+				InputTokens:              cer.PromptTokenCount,
+				OutputTokens:             cer.CompletionTokenCount,
+				CacheCreationInputTokens: cer.CacheWriteTokenCount,
+				CacheReadInputTokens:     cer.CacheReadTokenCount,
+			})
+		case "azure":
+			cost, err = aoe.EstimateTotalCost(cer.Model, cer.PromptTokenCount, cer.CompletionTokenCount)
+		default:
+			cost, err = e.EstimateTotalCost(cer.Model, cer.PromptTokenCount, cer.CompletionTokenCount)
+		}
+
+		if cm := getCostMapForProvider(c, cer.Provider); cm != nil {
+			newCost, cerr := provider.EstimateTotalCostWithCostMaps(cer.Model, cer.PromptTokenCount, cer.CompletionTokenCount, 1000, cm.PromptCostPerModel, cm.CompletionCostPerModel)
+			if cerr == nil {
+				if cer.Provider == "anthropic" {
+					writeCost, _ := provider.EstimateCostWithCostMap(cer.Model, cer.CacheWriteTokenCount, 1000, cm.PromptCostPerModel)
+					readCost, _ := provider.EstimateCostWithCostMap(cer.Model, cer.CacheReadTokenCount, 1000, cm.PromptCostPerModel)
+					newCost += writeCost*anthropic.CacheWriteCostMultiplier + readCost*anthropic.CacheReadCostMultiplier
+				}
+
+				cost = newCost
+				err = nil
+			}
+		}
+
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_estimate_cost_handler.estimate_total_cost_error", nil, 1)
+			logError(log, "error when estimating total cost", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] cost estimation is not supported for the given model")
+			return
+		}
+
+		price := cost
+		if raw, exists := c.Get("key"); exists {
+			if kc, ok := raw.(*key.ResponseKey); ok && kc != nil {
+				price = kc.Markup.Price(cer.Model, cost, cer.PromptTokenCount, cer.CompletionTokenCount)
+			}
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_estimate_cost_handler.success", nil, 1)
+		c.JSON(http.StatusOK, &CostEstimationResponse{
+			Provider:             cer.Provider,
+			Model:                cer.Model,
+			PromptTokenCount:     cer.PromptTokenCount,
+			CompletionTokenCount: cer.CompletionTokenCount,
+			CacheWriteTokenCount: cer.CacheWriteTokenCount,
+			CacheReadTokenCount:  cer.CacheReadTokenCount,
+			CostInUsd:            cost,
+			CustomerPriceInUsd:   price,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetEstimateCostHandlerMarkup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	settings := []*provider.Setting{{
+		Provider: "openai",
+		CostMap: &provider.CostMap{
+			PromptCostPerModel:     map[string]float64{"gpt-4o": 0.01},
+			CompletionCostPerModel: map[string]float64{"gpt-4o": 0.02},
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		key       *key.ResponseKey
+		costInUsd float64
+		price     float64
+	}{
+		{name: "no key", costInUsd: 0.03, price: 0.03},
+		{name: "no markup", key: &key.ResponseKey{}, costInUsd: 0.03, price: 0.03},
+		{name: "marked up", key: &key.ResponseKey{Markup: &key.Markup{Percentage: 50}}, costInUsd: 0.03, price: 0.045},
+		{name: "price override", key: &key.ResponseKey{Markup: &key.Markup{ModelPrices: map[string]*key.ModelPrice{"gpt-4o": {PromptCostPerMillionTokens: 100, CompletionCostPerMillionTokens: 100}}}}, costInUsd: 0.03, price: 0.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/costs/estimate", strings.NewReader(`{"model":"gpt-4o","promptTokenCount":1000,"completionTokenCount":1000}`))
+			util.SetLogToCtx(c, zap.NewNop())
+			c.Set("settings", settings)
+			if tt.key != nil {
+				c.Set("key", tt.key)
+			}
+
+			getEstimateCostHandler(true, openai.NewCostEstimator(openai.OpenAiPerThousandTokenCost, nil, nil), nil, nil)(c)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			cer := &CostEstimationResponse{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), cer))
+			assert.InDelta(t, tt.costInUsd, cer.CostInUsd, 1e-9)
+			assert.InDelta(t, tt.price, cer.CustomerPriceInUsd, 1e-9)
+		})
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
@@ -37,6 +39,39 @@ func getContentFromJson(bytes []byte, contentLoc string) string {
 	return content
 }
 
+type signerManager interface {
+	GetSigner(cfg *signer.Config, params map[string]string) (signer.Signer, error)
+}
+
+// signUpstreamRequest removes the headers carrying the BricksLLM key from a
+// request forwarded upstream and authenticates it with the signer.
+func signUpstreamRequest(ctx context.Context, s signer.Signer, req *http.Request) error {
+	req.Header.Del("Authorization")
+	req.Header.Del("x-api-key")
+	req.Header.Del("api-key")
+
+	return s.Sign(ctx, req)
+}
+
+// getCustomProviderSigner returns the signer of a custom provider using the
+// provider setting selected for the request. Custom providers without an
+// auth config send the apikey param as a bearer token.
+func getCustomProviderSigner(c *gin.Context, sm signerManager) (signer.Signer, error) {
+	cfg := &signer.Config{Scheme: signer.SchemeBearer}
+	raw, _ := c.Get("provider")
+	if cp, ok := raw.(*custom.Provider); ok && cp.Auth != nil {
+		cfg = cp.Auth
+	}
+
+	params := map[string]string{}
+	raw, _ = c.Get("settings")
+	if settings, ok := raw.([]*provider.Setting); ok && len(settings) != 0 {
+		params = settings[0].Setting
+	}
+
+	return sm.GetSigner(cfg, params)
+}
+
 type Error struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
@@ -46,7 +81,7 @@ type ErrorResponse struct {
 	Error *Error `json:"error"`
 }
 
-func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
+func getCustomProviderHandler(prod bool, client http.Client, sm signerManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tags := []string{
 			fmt.Sprintf("path:%s", c.FullPath()),
@@ -77,6 +112,14 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 			return
 		}
 
+		body, err = rc.RenderRequest(body)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.render_request_template_error", tags, 1)
+			logError(logWithCid, "error when rendering custom provider request template", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] failed to render custom provider request template")
+			return
+		}
+
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, rc.TargetUrl, io.NopCloser(bytes.NewReader(body)))
 		if err != nil {
 			logError(logWithCid, "error when creating custom provider http request", prod, err)
@@ -86,6 +129,22 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 
 		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
 
+		s, err := getCustomProviderSigner(c, sm)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.get_signer_error", tags, 1)
+			logError(logWithCid, "error when getting custom provider signer", prod, err)
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] auth credentials are missing")
+			return
+		}
+
+		err = signUpstreamRequest(ctx, s, req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.sign_request_error", tags, 1)
+			logError(logWithCid, "error when signing custom provider request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to sign custom provider request")
+			return
+		}
+
 		isStreaming := c.GetBool("stream")
 		if isStreaming {
 			req.Header.Set("Accept", "text/event-stream")
@@ -115,6 +174,14 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 				return
 			}
 
+			bytes, err = rc.RenderResponse(bytes)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.render_response_template_error", tags, 1)
+				logError(logWithCid, "error when rendering custom provider response template", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to render custom provider response template")
+				return
+			}
+
 			c.Set("response", bytes)
 
 			// tks, err := countTokensFromJson(bytes, rc.ResponseCompletionLocation)
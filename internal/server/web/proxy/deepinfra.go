@@ -141,10 +141,10 @@ func getDeepinfraCompletionsHandler(prod, private bool, client http.Client) gin.
 		}
 
 		buffer := bufio.NewReader(res.Body)
-		content := ""
+		choices := &streamedChoices{}
 		streamingResponse := [][]byte{}
 		defer func() {
-			c.Set("content", content)
+			c.Set("content", choices.String())
 			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
 		}()
 
@@ -208,8 +208,8 @@ func getDeepinfraCompletionsHandler(prod, private bool, client http.Client) gin.
 			}
 
 			if err == nil {
-				if len(completionsStreamResp.Choices) > 0 && len(completionsStreamResp.Choices[0].Text) != 0 {
-					content += completionsStreamResp.Choices[0].Text
+				for _, choice := range completionsStreamResp.Choices {
+					choices.append(choice.Index, choice.Text)
 				}
 			}
 
@@ -337,10 +337,10 @@ func getDeepinfraChatCompletionsHandler(prod, private bool, client http.Client)
 		}
 
 		buffer := bufio.NewReader(res.Body)
-		content := ""
+		choices := &streamedChoices{}
 		streamingResponse := [][]byte{}
 		defer func() {
-			c.Set("content", content)
+			c.Set("content", choices.String())
 			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
 		}()
 
@@ -404,8 +404,8 @@ func getDeepinfraChatCompletionsHandler(prod, private bool, client http.Client)
 			}
 
 			if err == nil {
-				if len(chatCompletionStreamResp.Choices) > 0 && len(chatCompletionStreamResp.Choices[0].Delta.Content) != 0 {
-					content += chatCompletionStreamResp.Choices[0].Delta.Content
+				for _, choice := range chatCompletionStreamResp.Choices {
+					choices.append(choice.Index, choice.Delta.Content)
 				}
 			}
 
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/deepseek"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type deepseekEstimator interface {
+	EstimateUsageCost(model string, usage *deepseek.Usage) (float64, error)
+	Count(input string) int
+}
+
+func newDeepseekUsage(usage *deepseek.Usage) *compatibleUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &compatibleUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		ReasoningTokens:  usage.ReasoningTokens(),
+		Details:          usage,
+	}
+}
+
+func newDeepseekProvider(de deepseekEstimator) *compatibleProvider {
+	return &compatibleProvider{
+		name: "deepseek",
+		url:  deepseek.ChatCompletionsUrl,
+		decodeResponse: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionResponse, *compatibleUsage, error) {
+			chatRes := &deepseek.ChatCompletionResponse{}
+			if err := json.Unmarshal(data, chatRes); err != nil {
+				return nil, nil, err
+			}
+
+			usage := &compatibleUsage{}
+			if chatRes.Usage != nil {
+				usage = newDeepseekUsage(chatRes.Usage)
+				chatRes.ChatCompletionResponse.Usage = goopenai.Usage{
+					PromptTokens:     usage.PromptTokens,
+					CompletionTokens: usage.CompletionTokens,
+					TotalTokens:      chatRes.Usage.TotalTokens,
+				}
+			}
+
+			return &chatRes.ChatCompletionResponse, usage, nil
+		},
+		decodeChunk: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionStreamResponse, *compatibleUsage, error) {
+			chunk := &deepseek.ChatCompletionStreamResponse{}
+			if err := json.Unmarshal(data, chunk); err != nil {
+				return nil, nil, err
+			}
+
+			// deepseek reports the usage of the request in the last chunk.
+			return &chunk.ChatCompletionStreamResponse, newDeepseekUsage(chunk.Usage), nil
+		},
+		estimateCost: func(c *gin.Context, model string, usage *compatibleUsage) (float64, error) {
+			if cost, ok := estimateCostWithCostMap(c, model, usage); ok {
+				return cost, nil
+			}
+
+			details, _ := usage.Details.(*deepseek.Usage)
+			if details == nil {
+				details = &deepseek.Usage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens}
+			}
+
+			return de.EstimateUsageCost(model, details)
+		},
+		count: de.Count,
+		countRequest: func(c *gin.Context) int {
+			r, _ := c.Value("deepseekRequest").(*goopenai.ChatCompletionRequest)
+			return provider.CountChatRequestTokens(r, de.Count)
+		},
+	}
+}
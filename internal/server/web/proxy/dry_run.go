@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type DryRunResponse struct {
+	DryRun                     bool    `json:"dryRun"`
+	Model                      string  `json:"model"`
+	PromptTokenCount           int     `json:"promptTokenCount"`
+	MaxCompletionTokenCount    int     `json:"maxCompletionTokenCount"`
+	EstimatedPromptCostInUsd   float64 `json:"estimatedPromptCostInUsd"`
+	EstimatedMaxTotalCostInUsd float64 `json:"estimatedMaxTotalCostInUsd"`
+	PolicyId                   string  `json:"policyId"`
+	PolicyAction               string  `json:"policyAction"`
+	CostEstimationNotSupported bool    `json:"costEstimationNotSupported"`
+	CostEstimationErrorMessage string  `json:"costEstimationErrorMessage,omitempty"`
+}
+
+func isDryRun(c *gin.Context) bool {
+	return strings.ToLower(c.GetHeader("X-DRY-RUN")) == "true"
+}
+
+func estimateDryRun(log *zap.Logger, prod bool, e estimator, ae anthropicEstimator, policyInput any) *DryRunResponse {
+	dr := &DryRunResponse{
+		DryRun: true,
+	}
+
+	switch policyInput.(type) {
+	case *goopenai.ChatCompletionRequest:
+		ccr := policyInput.(*goopenai.ChatCompletionRequest)
+		dr.Model = ccr.Model
+
+		tks, cost, err := e.EstimateChatCompletionPromptCostWithTokenCounts(ccr)
+		if err != nil {
+			logError(log, "error when estimating dry run chat completion prompt cost", prod, err)
+			dr.CostEstimationErrorMessage = err.Error()
+			return dr
+		}
+
+		dr.PromptTokenCount = tks
+		dr.EstimatedPromptCostInUsd = cost
+		dr.EstimatedMaxTotalCostInUsd = cost
+		dr.MaxCompletionTokenCount = ccr.MaxTokens
+
+		if ccr.MaxTokens != 0 {
+			completionCost, err := e.EstimateCompletionCost(ccr.Model, ccr.MaxTokens)
+			if err != nil {
+				logError(log, "error when estimating dry run chat completion completion cost", prod, err)
+				dr.CostEstimationErrorMessage = err.Error()
+				return dr
+			}
+
+			dr.EstimatedMaxTotalCostInUsd += completionCost
+		}
+	case *goopenai.EmbeddingRequest:
+		er := policyInput.(*goopenai.EmbeddingRequest)
+		dr.Model = string(er.Model)
+
+		cost, err := e.EstimateEmbeddingsCost(er)
+		if err != nil {
+			logError(log, "error when estimating dry run embeddings cost", prod, err)
+			dr.CostEstimationErrorMessage = err.Error()
+			return dr
+		}
+
+		dr.EstimatedPromptCostInUsd = cost
+		dr.EstimatedMaxTotalCostInUsd = cost
+	case *anthropic.MessagesRequest:
+		mr := policyInput.(*anthropic.MessagesRequest)
+		dr.Model = mr.Model
+
+		tks := countMessagesRequestTokens(ae, mr)
+		cost, err := ae.EstimatePromptCost(mr.Model, tks)
+		if err != nil {
+			logError(log, "error when estimating dry run anthropic messages prompt cost", prod, err)
+			dr.CostEstimationErrorMessage = err.Error()
+			return dr
+		}
+
+		dr.PromptTokenCount = tks
+		dr.EstimatedPromptCostInUsd = cost
+		dr.EstimatedMaxTotalCostInUsd = cost
+		dr.MaxCompletionTokenCount = mr.MaxTokens
+
+		if mr.MaxTokens != 0 {
+			completionCost, err := ae.EstimateCompletionCost(mr.Model, mr.MaxTokens)
+			if err != nil {
+				logError(log, "error when estimating dry run anthropic messages completion cost", prod, err)
+				dr.CostEstimationErrorMessage = err.Error()
+				return dr
+			}
+
+			dr.EstimatedMaxTotalCostInUsd += completionCost
+		}
+	case *anthropic.CompletionRequest:
+		cr := policyInput.(*anthropic.CompletionRequest)
+		dr.Model = cr.Model
+
+		tks := ae.Count(cr.Prompt)
+		cost, err := ae.EstimatePromptCost(cr.Model, tks)
+		if err != nil {
+			logError(log, "error when estimating dry run anthropic completion prompt cost", prod, err)
+			dr.CostEstimationErrorMessage = err.Error()
+			return dr
+		}
+
+		dr.PromptTokenCount = tks
+		dr.EstimatedPromptCostInUsd = cost
+		dr.EstimatedMaxTotalCostInUsd = cost
+		dr.MaxCompletionTokenCount = cr.MaxTokensToSample
+
+		if cr.MaxTokensToSample != 0 {
+			completionCost, err := ae.EstimateCompletionCost(cr.Model, cr.MaxTokensToSample)
+			if err != nil {
+				logError(log, "error when estimating dry run anthropic completion cost", prod, err)
+				dr.CostEstimationErrorMessage = err.Error()
+				return dr
+			}
+
+			dr.EstimatedMaxTotalCostInUsd += completionCost
+		}
+	default:
+		dr.CostEstimationNotSupported = true
+	}
+
+	return dr
+}
+
+func respondWithDryRun(c *gin.Context, log *zap.Logger, prod bool, e estimator, ae anthropicEstimator, policyInput any) {
+	telemetry.Incr("bricksllm.proxy.respond_with_dry_run.requests", nil, 1)
+
+	dr := estimateDryRun(log, prod, e, ae, policyInput)
+	dr.PolicyId = c.GetString("policyId")
+	dr.PolicyAction = c.GetString("action")
+
+	c.JSON(http.StatusOK, dr)
+}
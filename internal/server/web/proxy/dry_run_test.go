@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestEstimateDryRunAnthropic(t *testing.T) {
+	tests := []struct {
+		name        string
+		policyInput any
+		promptTks   int
+		maxTks      int
+	}{
+		{
+			name: "messages with system prompt",
+			policyInput: &anthropic.MessagesRequest{
+				Model:     "claude-3-haiku-20240307",
+				System:    &anthropic.MessageContent{Text: "you are a helpful assistant"},
+				Messages:  []anthropic.Message{{Role: "user", Content: anthropic.MessageContent{Text: "hello there"}}},
+				MaxTokens: 100,
+			},
+			promptTks: 7,
+			maxTks:    100,
+		},
+		{
+			name: "messages without system prompt",
+			policyInput: &anthropic.MessagesRequest{
+				Model:    "claude-3-haiku-20240307",
+				Messages: []anthropic.Message{{Role: "user", Content: anthropic.MessageContent{Text: "hello there"}}},
+			},
+			promptTks: 2,
+		},
+		{
+			name:        "completion",
+			policyInput: &anthropic.CompletionRequest{Model: "claude-2", Prompt: "Human: hello there Assistant:", MaxTokensToSample: 10},
+			promptTks:   4,
+			maxTks:      10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dr := estimateDryRun(zap.NewNop(), true, nil, &wordEstimator{}, tt.policyInput)
+
+			assert.True(t, dr.DryRun)
+			assert.Empty(t, dr.CostEstimationErrorMessage)
+			assert.Equal(t, tt.promptTks, dr.PromptTokenCount)
+			assert.Equal(t, tt.maxTks, dr.MaxCompletionTokenCount)
+		})
+	}
+}
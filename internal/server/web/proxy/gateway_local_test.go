@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type staticAuthenticator struct {
+	authenticator
+	kc *key.ResponseKey
+}
+
+func (a *staticAuthenticator) AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error) {
+	return a.kc, []*provider.Setting{}, nil
+}
+
+type noPolicies struct {
+	PoliciesManager
+}
+
+func (noPolicies) GetPolicyByKeyTagsFromMemdb(tags []string) *policy.Policy {
+	return nil
+}
+
+type unversionedEstimator struct {
+	estimator
+}
+
+func (unversionedEstimator) PricingVersion() string {
+	return ""
+}
+
+type recordingPublisher struct {
+	messages []message.Message
+}
+
+func (p *recordingPublisher) Publish(m message.Message) {
+	p.messages = append(p.messages, m)
+}
+
+func TestGatewayLocalRoutesAreNotPublished(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "token count", method: http.MethodPost, path: "/api/tokens/count"},
+		{name: "cost estimate", method: http.MethodPost, path: "/api/costs/estimate"},
+		{name: "models", method: http.MethodGet, path: "/v1/models"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &recordingPublisher{}
+			a := &staticAuthenticator{kc: &key.ResponseKey{KeyId: "key", RateLimitUnit: key.MinuteTimeUnit, RateLimitOverTime: 1}}
+
+			router := gin.New()
+			router.Use(getMiddleware(nil, nil, nil, noPolicies{}, a, true, false, zap.NewNop(), pub, "proxy", &staticAccessCache{}, nil, http.Client{}, nil, nil, nil, false, unversionedEstimator{}, nil, false, nil, nil, nil))
+
+			handled := false
+			router.Handle(tt.method, tt.path, func(c *gin.Context) {
+				handled = true
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"model":"gpt-4o"}`)))
+
+			assert.True(t, handled)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, pub.messages)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type geminiEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks, cachedTks int) (float64, error)
+	CountRequestTokens(r *gemini.GenerateContentRequest) int
+	Count(input string) int
+}
+
+// geminiUsage accumulates the token usage reported by Gemini in responses and
+// stream chunks.
+type geminiUsage struct {
+	promptTokens     int
+	completionTokens int
+	cachedTokens     int
+}
+
+func (u *geminiUsage) record(data []byte) string {
+	gcr := &gemini.GenerateContentResponse{}
+	if err := json.Unmarshal(data, gcr); err != nil {
+		return ""
+	}
+
+	// usage metadata of streamed chunks is cumulative.
+	if gcr.UsageMetadata != nil {
+		u.promptTokens = gcr.UsageMetadata.PromptTokenCount
+		u.completionTokens = gcr.UsageMetadata.CandidatesTokenCount
+		u.cachedTokens = gcr.UsageMetadata.CachedContentTokenCount
+	}
+
+	return gcr.Text()
+}
+
+func estimateGeminiCost(c *gin.Context, ge geminiEstimator, model, content string, u *geminiUsage) (float64, error) {
+	// token counts are estimated when responses do not report usage.
+	if u.promptTokens == 0 {
+		if gr, ok := c.Get("geminiRequest"); ok {
+			if converted, ok := gr.(*gemini.GenerateContentRequest); ok {
+				u.promptTokens = ge.CountRequestTokens(converted)
+			}
+		}
+	}
+
+	if u.completionTokens == 0 && len(content) != 0 {
+		u.completionTokens = ge.Count(content)
+	}
+
+	m, exists := c.Get("cost_map")
+	if exists {
+		converted, ok := m.(*provider.CostMap)
+		if ok {
+			cost, err := provider.EstimateTotalCostWithCostMaps(model, u.promptTokens, u.completionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+			if err == nil && cost != 0 {
+				return cost, nil
+			}
+		}
+	}
+
+	return ge.EstimateTotalCost(model, u.promptTokens, u.completionTokens, u.cachedTokens)
+}
+
+func getGeminiHandler(prod bool, client http.Client, ge geminiEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_gemini_handler.requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		model, action, err := gemini.ParseModelAction(c.Param("model"))
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_gemini_handler.parse_model_action_error", nil, 1)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		// the BricksLLM api key can be sent as the key query param like a
+		// Gemini api key. It must not be forwarded.
+		query := c.Request.URL.Query()
+		query.Del("key")
+		if action == gemini.ActionStreamGenerateContent {
+			query.Set("alt", "sse")
+		}
+
+		url := gemini.GetModelUrl(c.GetString("geminiEndpoint"), c.Param("version"), model, action)
+		if len(query) != 0 {
+			url = fmt.Sprintf("%s?%s", url, query.Encode())
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating gemini http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create gemini http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		// Gemini treats bearer tokens as OAuth access tokens.
+		req.Header.Del("Authorization")
+
+		isStreaming := gemini.IsStreamingAction(action)
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_gemini_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to gemini", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to gemini")
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		usage := &geminiUsage{}
+
+		if res.StatusCode == http.StatusOK && !isStreaming {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_gemini_handler.latency", dur, nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading gemini http response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read gemini response body")
+				return
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_gemini_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_gemini_handler.success_latency", dur, nil, 1)
+
+			if action != gemini.ActionCountTokens {
+				content := usage.record(data)
+				c.Set("content", content)
+
+				cost, err := estimateGeminiCost(c, ge, model, content, usage)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_gemini_handler.estimate_total_cost_error", nil, 1)
+					logError(log, "error when estimating gemini cost", prod, err)
+				}
+
+				c.Set("costInUsd", cost)
+				c.Set("promptTokenCount", usage.promptTokens)
+				c.Set("completionTokenCount", usage.completionTokens)
+			}
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_gemini_handler.error_latency", dur, nil, 1)
+			telemetry.Incr("bricksllm.proxy.get_gemini_handler.error_response", nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading gemini http response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read gemini response body")
+				return
+			}
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		buffer := bufio.NewReader(res.Body)
+		content := ""
+		streamingResponse := [][]byte{}
+		defer func() {
+			c.Set("content", content)
+
+			cost, err := estimateGeminiCost(c, ge, model, content, usage)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_gemini_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating gemini streaming cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", usage.promptTokens)
+			c.Set("completionTokenCount", usage.completionTokens)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_gemini_handler.streaming_requests", nil, 1)
+
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				if errors.Is(err, context.DeadlineExceeded) {
+					telemetry.Incr("bricksllm.proxy.get_gemini_handler.context_deadline_exceeded_error", nil, 1)
+					logError(log, "context deadline exceeded when reading bytes from gemini response", prod, err)
+
+					return false
+				}
+
+				telemetry.Incr("bricksllm.proxy.get_gemini_handler.read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from gemini response", prod, err)
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			if _, err := w.Write(raw); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_gemini_handler.write_error", nil, 1)
+				logError(log, "error when writing gemini streaming response", prod, err)
+				return false
+			}
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			content += usage.record(bytes.TrimSpace(bytes.TrimPrefix(noSpaceLine, headerData)))
+			return true
+		})
+
+		telemetry.Timing("bricksllm.proxy.get_gemini_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/groq"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type groqEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	Count(input string) int
+}
+
+func newGroqProvider(ge groqEstimator) *compatibleProvider {
+	return &compatibleProvider{
+		name:           "groq",
+		url:            groq.ChatCompletionsUrl,
+		decodeResponse: decodeOpenAiChatCompletion,
+		decodeChunk: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionStreamResponse, *compatibleUsage, error) {
+			chunk := &groq.ChatCompletionStreamResponse{}
+			if err := json.Unmarshal(data, chunk); err != nil {
+				return nil, nil, err
+			}
+
+			// groq reports the usage of the request in the x_groq field of the last chunk.
+			return &chunk.ChatCompletionStreamResponse, newOpenAiUsage(chunk.GetUsage()), nil
+		},
+		estimateCost: func(c *gin.Context, model string, usage *compatibleUsage) (float64, error) {
+			if cost, ok := estimateCostWithCostMap(c, model, usage); ok {
+				return cost, nil
+			}
+
+			return ge.EstimateTotalCost(model, usage.PromptTokens, usage.CompletionTokens)
+		},
+		count: ge.Count,
+		countRequest: func(c *gin.Context) int {
+			r, _ := c.Value("groqRequest").(*goopenai.ChatCompletionRequest)
+			return provider.CountChatRequestTokens(r, ge.Count)
+		},
+	}
+}
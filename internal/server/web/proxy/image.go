@@ -1,13 +1,119 @@
 package proxy
 
 import (
+	"context"
 	"encoding/json"
+	"io"
+	"net/http"
+	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+func getCreateImageHandler(prod, private bool, client http.Client, e estimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_create_image_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, c.Request.Method, "https://api.openai.com/v1/images/generations", c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating openai http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		start := time.Now()
+
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_create_image_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending create image request to openai", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send create image request to openai")
+			return
+		}
+		defer res.Body.Close()
+
+		dur := time.Since(start)
+		telemetry.Timing("bricksllm.proxy.get_create_image_handler.latency", dur, nil, 1)
+
+		bytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading openai create image response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read openai create image response body")
+			return
+		}
+
+		if res.StatusCode == http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.get_create_image_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_create_image_handler.success_latency", dur, nil, 1)
+
+			ir := &goopenai.ImageResponse{}
+			err = json.Unmarshal(bytes, ir)
+			if err != nil {
+				logError(log, "error when unmarshalling openai create image response body", prod, err)
+			}
+
+			if err == nil {
+				logImageResponse(log, bytes, prod, private)
+
+				quality, size := "", ""
+				if raw, ok := c.Get("imageRequest"); ok {
+					if converted, ok := raw.(*goopenai.ImageRequest); ok {
+						quality = converted.Quality
+						size = converted.Size
+					}
+				}
+
+				// images are priced by the number returned rather than the number requested.
+				cost, err := e.EstimateImageCost(c.GetString("model"), quality, size, len(ir.Data))
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_create_image_handler.estimate_image_cost_error", nil, 1)
+					logError(log, "error when estimating openai create image cost", prod, err)
+				}
+
+				c.Set("costInUsd", cost)
+			}
+		}
+
+		if res.StatusCode != http.StatusOK {
+			telemetry.Timing("bricksllm.proxy.get_create_image_handler.error_latency", dur, nil, 1)
+			telemetry.Incr("bricksllm.proxy.get_create_image_handler.error_response", nil, 1)
+
+			errorRes := &goopenai.ErrorResponse{}
+			err = json.Unmarshal(bytes, errorRes)
+			if err != nil {
+				logError(log, "error when unmarshalling openai create image error response body", prod, err)
+			}
+
+			logOpenAiError(log, prod, errorRes)
+		}
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		c.Data(res.StatusCode, "application/json", bytes)
+	}
+}
+
 func logCreateImageRequest(log *zap.Logger, ir *goopenai.ImageRequest, prod, private bool) {
 	if prod {
 		fields := []zapcore.Field{
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,12 +11,19 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
 	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/provider/perplexity"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vertex"
 	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
@@ -43,6 +51,8 @@ type keyStorage interface {
 type estimator interface {
 	EstimateTranscriptionCost(secs float64, model string) (float64, error)
 	EstimateSpeechCost(input string, model string) (float64, error)
+	EstimateImageCost(model, quality, size string, num int) (float64, error)
+	EstimateBatchCost(model string, promptTks, completionTks int) (float64, error)
 	EstimateChatCompletionPromptCostWithTokenCounts(r *goopenai.ChatCompletionRequest) (int, float64, error)
 	EstimateEmbeddingsCost(r *goopenai.EmbeddingRequest) (float64, error)
 	EstimateChatCompletionStreamCostWithTokenCounts(model, content string) (int, float64, error)
@@ -50,6 +60,8 @@ type estimator interface {
 	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
 	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
 	EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error)
+	PricingTable() map[string]map[string]float64
+	PricingVersion() string
 }
 
 type azureEstimator interface {
@@ -67,10 +79,16 @@ type deepinfraEstimator interface {
 
 type authenticator interface {
 	AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error)
+	Acquire(settingId string) func()
+	Queue(ctx context.Context, kc *key.ResponseKey, setting *provider.Setting) (func(), error)
+	QueueModel(ctx context.Context, kc *key.ResponseKey, setting *provider.Setting, model string) (func(), error)
+	WaitForQuota(ctx context.Context, setting *provider.Setting) error
+	RecordQuota(setting *provider.Setting, header http.Header)
 }
 
 type validator interface {
 	Validate(k *key.ResponseKey, promptCost float64) error
+	SpendRatio(k *key.ResponseKey) (float64, error)
 }
 
 type rateLimitManager interface {
@@ -85,6 +103,10 @@ type userAccessCache interface {
 	GetAccessStatus(userId string) bool
 }
 
+type sessionStorage interface {
+	GetUsage(keyId, sessionId string) (*key.SessionUsage, error)
+}
+
 func JSON(c *gin.Context, code int, message string) {
 	c.JSON(code, &goopenai.ErrorResponse{
 		Error: &goopenai.APIError{
@@ -94,6 +116,41 @@ func JSON(c *gin.Context, code int, message string) {
 	})
 }
 
+// errorTemplater is implemented by the routes and custom provider route
+// configs that customize gateway generated errors.
+type errorTemplater interface {
+	GetErrorTemplate(errType string) *errortemplate.Template
+}
+
+// templatedJSON writes a gateway generated error using the error template of
+// the requested route or custom provider route config if one is configured
+// for the error type.
+func templatedJSON(c *gin.Context, errType string, code int, message string) {
+	templatedJSONWithReason(c, errType, code, strconv.Itoa(code), message)
+}
+
+// templatedJSONWithReason behaves like templatedJSON but reports reason as
+// the error code of untemplated errors instead of the status code.
+func templatedJSONWithReason(c *gin.Context, errType string, code int, reason string, message string) {
+	if raw, exists := c.Get("route_config"); exists {
+		if rc, ok := raw.(errorTemplater); ok {
+			if et := rc.GetErrorTemplate(errType); et != nil {
+				telemetry.Incr("bricksllm.proxy.templated_json.templated", []string{"type:" + errType}, 1)
+				status, body := et.Render(errType, code, message)
+				c.Data(status, "application/json", body)
+				return
+			}
+		}
+	}
+
+	c.JSON(code, &goopenai.ErrorResponse{
+		Error: &goopenai.APIError{
+			Message: message,
+			Code:    reason,
+		},
+	})
+}
+
 type notAuthorizedError interface {
 	Authenticated()
 }
@@ -121,6 +178,17 @@ type publisher interface {
 	Publish(message.Message)
 }
 
+// gatewayLocalPaths are answered by the gateway without calling a provider.
+var gatewayLocalPaths = map[string]bool{
+	"/api/tokens/count":   true,
+	"/api/costs/estimate": true,
+	"/v1/models":          true,
+}
+
+func isGatewayLocal(c *gin.Context) bool {
+	return gatewayLocalPaths[c.FullPath()]
+}
+
 func getProvider(c *gin.Context) string {
 	existing := c.GetString("provider")
 	if len(existing) != 0 {
@@ -168,7 +236,7 @@ type CustomPolicyDetector interface {
 	Detect(input []string, requirements []string) (bool, error)
 }
 
-func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManager, a authenticator, prod, private bool, log *zap.Logger, pub publisher, prefix string, ac accessCache, uac userAccessCache, client http.Client, scanner Scanner, cd CustomPolicyDetector, um userManager, removeUserAgent bool) gin.HandlerFunc {
+func getMiddleware(cpm CustomProvidersManager, mr modelRemapper, rm routeManager, pm PoliciesManager, a authenticator, prod, private bool, log *zap.Logger, pub publisher, prefix string, ac accessCache, uac userAccessCache, client http.Client, scanner Scanner, cd CustomPolicyDetector, um userManager, removeUserAgent bool, e estimator, ae anthropicEstimator, clampMaxTokens bool, contextWindowSiblings map[string]string, ss sessionStorage, v validator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c == nil || c.Request == nil {
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] request is empty")
@@ -196,16 +264,25 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 		start := time.Now()
 		c.Set("startTime", start)
 
+		// costs of requests handled while the pricing table is reloaded may
+		// come from either table, so they are not labelled with a version.
+		pricingVersion := e.PricingVersion()
+
 		enrichedEvent := &event.EventWithRequestAndContent{}
 		requestBytes := []byte(`{}`)
 		responseBytes := []byte(`{}`)
 		userId := ""
 
 		var policyInput any = nil
+		var redactionTokens *policy.Tokens
 
 		customId := c.Request.Header.Get("X-CUSTOM-EVENT-ID")
+		sessionId := c.Request.Header.Get("X-SESSION-ID")
+		requestTags := parseRequestTags(c.Request.Header.Get("X-BRICKSLLM-TAGS"))
 
 		metadataBytes := []byte(`{}`)
+		var piiFindingsBytes []byte
+		var policyExemptionBytes []byte
 		metadata := c.Request.Header.Get("X-METADATA")
 
 		defer func() {
@@ -254,6 +331,42 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				"status:" + strconv.Itoa(c.Writer.Status()),
 			}, 1)
 
+			if len(c.GetStringSlice("structured_output_violations")) != 0 {
+				requestTags = append(requestTags, structuredOutputInvalidTag)
+			}
+
+			if tag := c.GetString("route_split_tag"); len(tag) != 0 {
+				requestTags = append(requestTags, tag)
+			}
+
+			if tag := c.GetString("routing_rule_tag"); len(tag) != 0 {
+				requestTags = append(requestTags, tag)
+			}
+
+			if alias := c.GetString("model_alias"); len(alias) != 0 {
+				requestTags = append(requestTags, modelAliasTagPrefix+alias)
+			}
+
+			if deprecated := c.GetString("remapped_model"); len(deprecated) != 0 {
+				requestTags = append(requestTags, remappedModelTagPrefix+deprecated)
+			}
+
+			if c.GetBool("downgraded") {
+				requestTags = append(requestTags, downgradedTag)
+			}
+
+			if c.GetBool("prompt_truncated") {
+				requestTags = append(requestTags, promptTruncatedTag)
+			}
+
+			if mode := c.GetString("pii_scanner_failure"); len(mode) != 0 {
+				requestTags = append(requestTags, piiScannerFailedTagPrefix+mode)
+			}
+
+			for _, rule := range c.GetStringSlice("prompt_injection_rules") {
+				requestTags = append(requestTags, promptInjectionTagPrefix+rule)
+			}
+
 			evt := &event.Event{
 				Id:                   util.NewUuid(),
 				CreatedAt:            time.Now().Unix(),
@@ -265,10 +378,16 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				Status:               c.Writer.Status(),
 				PromptTokenCount:     c.GetInt("promptTokenCount"),
 				CompletionTokenCount: c.GetInt("completionTokenCount"),
+				ReasoningTokenCount:  c.GetInt("reasoningTokenCount"),
+				RetryCount:           c.GetInt("retryCount"),
 				LatencyInMs:          latency,
 				Path:                 c.Request.URL.Path,
 				Method:               c.Request.Method,
 				CustomId:             customId,
+				SessionId:            sessionId,
+				RequestTags:          requestTags,
+				PiiFindings:          piiFindingsBytes,
+				PolicyExemption:      policyExemptionBytes,
 				Request:              requestBytes,
 				Response:             responseBytes,
 				UserId:               userId,
@@ -279,6 +398,20 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				Metadata:             metadataBytes,
 			}
 
+			if selectedProvider == "openai" && e.PricingVersion() == pricingVersion {
+				evt.PricingVersion = pricingVersion
+			}
+
+			// usage of assistants runs is attributed to the key and custom id that created the thread.
+			if raw, ok := c.Get("threadOwner"); ok {
+				if owner, ok := raw.(*key.ThreadOwner); ok {
+					evt.KeyId = owner.KeyId
+					if len(owner.CustomId) != 0 {
+						evt.CustomId = owner.CustomId
+					}
+				}
+			}
+
 			enrichedEvent.Event = evt
 			content := c.GetString("content")
 			if len(content) != 0 {
@@ -290,6 +423,62 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				enrichedEvent.Response = resp
 			}
 
+			if raw, ok := c.Get("content_filter_findings"); ok {
+				data, err := json.Marshal(raw)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_middleware.json_marshal_content_filter_findings_error", nil, 1)
+				}
+
+				if err == nil {
+					evt.ContentFilterResults = data
+				}
+			}
+
+			if raw, ok := c.Get("guardrail_intervention"); ok {
+				data, err := json.Marshal(raw)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_middleware.json_marshal_guardrail_intervention_error", nil, 1)
+				}
+
+				if err == nil {
+					evt.GuardrailIntervention = data
+				}
+			}
+
+			if raw, ok := c.Get("policy_rules"); ok {
+				data, err := json.Marshal(raw)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_middleware.json_marshal_policy_rules_error", nil, 1)
+				}
+
+				if err == nil {
+					evt.PolicyRules = data
+				}
+			}
+
+			if raw, ok := c.Get("citations"); ok {
+				data, err := json.Marshal(raw)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_middleware.json_marshal_citations_error", nil, 1)
+				}
+
+				if err == nil {
+					evt.Citations = data
+				}
+			}
+
+			if raw, ok := c.Get("stream_usage"); ok {
+				if usage, ok := raw.(*goopenai.Usage); ok {
+					enrichedEvent.StreamUsage = usage
+				}
+			}
+
+			// dry runs and gateway local routes are not forwarded, so they are
+			// neither recorded nor counted against the limits of keys.
+			if c.GetBool("dryRun") || c.GetBool("gatewayLocal") {
+				return
+			}
+
 			pub.Publish(message.Message{
 				Type: "event",
 				Data: enrichedEvent,
@@ -303,6 +492,10 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
+		if isGatewayLocal(c) {
+			c.Set("gatewayLocal", true)
+		}
+
 		kc, settings, err := a.AuthenticateHttpRequest(c.Request)
 		enrichedEvent.Key = kc
 		_, ok := err.(notAuthorizedError)
@@ -334,7 +527,40 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 		c.Set("key", kc)
 		c.Set("settings", settings)
 
-		if len(settings) >= 1 {
+		// requests are counted until they complete so that keys balancing
+		// the least loaded settings spread concurrent requests.
+		if kc.RotationStrategy == key.RotationStrategyLeastLoaded && len(settings) != 0 && c.FullPath() != unifiedChatCompletionsPath {
+			release := a.Acquire(settings[0].Id)
+			defer release()
+		}
+
+		// requests over the concurrency limit of the selected setting wait
+		// for a slot with the priority of the key.
+		if len(settings) != 0 && settings[0].MaxConcurrentRequests > 0 && c.FullPath() != unifiedChatCompletionsPath && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			release, err := a.Queue(c.Request.Context(), kc, settings[0])
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.queue_timeout", nil, 1)
+				templatedJSON(c, errortemplate.TypeRateLimited, http.StatusTooManyRequests, "[BricksLLM] too many concurrent requests for provider setting")
+				c.Abort()
+				return
+			}
+
+			defer release()
+		}
+
+		// requests are throttled until the upstream quotas of the selected
+		// setting reset if they are nearly exhausted.
+		if len(settings) != 0 && c.FullPath() != unifiedChatCompletionsPath && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			if err := a.WaitForQuota(c.Request.Context(), settings[0]); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.upstream_quota_exhausted", nil, 1)
+				templatedJSON(c, errortemplate.TypeRateLimited, http.StatusTooManyRequests, "[BricksLLM] upstream rate limit of provider setting is exhausted")
+				c.Abort()
+				return
+			}
+		}
+
+		// settings of unified requests are selected once the model is known.
+		if len(settings) >= 1 && c.FullPath() != unifiedChatCompletionsPath {
 			selected := settings[0]
 
 			if selected.CostMap != nil {
@@ -346,6 +572,10 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				if selected != nil && len(selected.Setting["resourceName"]) != 0 {
 					c.Set("resourceName", selected.Setting["resourceName"])
 				}
+
+				if selected != nil {
+					resolveAzureDeployment(c, selected.Deployments)
+				}
 			}
 
 			if strings.HasPrefix(c.FullPath(), "/api/providers/bedrock/anthropic") {
@@ -360,6 +590,27 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				if selected != nil && len(selected.Setting["awsRegion"]) != 0 {
 					c.Set("awsRegion", selected.Setting["awsRegion"])
 				}
+
+				if selected != nil && len(selected.Setting["guardrailIdentifier"]) != 0 {
+					c.Set("awsGuardrailIdentifier", selected.Setting["guardrailIdentifier"])
+					c.Set("awsGuardrailVersion", selected.Setting["guardrailVersion"])
+					c.Set("awsGuardrailTrace", selected.Setting["guardrailTrace"] == "true")
+				}
+			}
+
+			if strings.HasPrefix(c.FullPath(), "/api/providers/vertexai") {
+				if selected != nil {
+					c.Set("vertexProjectId", selected.Setting["projectId"])
+					c.Set("vertexRegion", selected.Setting["region"])
+					c.Set("vertexEndpoint", selected.Setting["endpoint"])
+					c.Set("vertexServiceAccountJson", selected.Setting["serviceAccountJson"])
+				}
+			}
+
+			if strings.HasPrefix(c.FullPath(), "/api/providers/gemini") {
+				if selected != nil {
+					c.Set("geminiEndpoint", selected.Setting["endpoint"])
+				}
 			}
 
 			if strings.HasPrefix(c.FullPath(), "/api/providers/vllm") {
@@ -369,9 +620,10 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			}
 		}
 
-		p := pm.GetPolicyByIdFromMemdb(kc.PolicyId)
-
-		c.Set("policyId", kc.PolicyId)
+		p := resolvePolicy(c, pm, rm, kc)
+		if p != nil {
+			c.Set("policyId", p.Id)
+		}
 
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
@@ -379,7 +631,27 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
-		if kc.ShouldLogRequest {
+		if aliased, alias, ok := resolveModelAlias(kc, body); ok {
+			telemetry.Incr("bricksllm.proxy.get_middleware.model_alias_resolved", nil, 1)
+			body = aliased
+			c.Set("model_alias", alias)
+		}
+
+		if remapped, deprecated, ok := remapDeprecatedModel(mr, getProvider(c), c.FullPath(), body); ok {
+			telemetry.Incr("bricksllm.proxy.get_middleware.deprecated_model_remapped", []string{"model:" + deprecated}, 1)
+			body = remapped
+			c.Set("remapped_model", deprecated)
+		}
+
+		downgraded, blocked := applyBudgetDowngrade(c, logWithCid, prod, v, ac, kc, body)
+		if blocked {
+			c.Abort()
+			return
+		}
+
+		body = downgraded
+
+		if kc.ShouldStoreRequest() {
 			if len(body) != 0 {
 				requestBytes = body
 			}
@@ -416,6 +688,319 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			policyInput = cr
 		}
 
+		if strings.HasPrefix(c.FullPath(), "/api/providers/vertexai") {
+			model, action, err := vertex.ParseModelAction(c.Param("model"))
+			if err == nil {
+				c.Set("model", model)
+
+				if vertex.IsStreamingAction(action) {
+					c.Set("stream", true)
+				}
+			}
+
+			if err == nil && (action == vertex.ActionGenerateContent || action == vertex.ActionStreamGenerateContent) {
+				gr := &gemini.GenerateContentRequest{}
+				err = json.Unmarshal(body, gr)
+				if err != nil {
+					// requests that cannot be parsed would bypass policies.
+					telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_vertex_request_error", nil, 1)
+					logError(logWithCid, "error when unmarshalling vertex ai generate content request", prod, err)
+					JSON(c, http.StatusBadRequest, "[BricksLLM] invalid vertex ai generate content request")
+					c.Abort()
+					return
+				}
+
+				enrichedEvent.Request = gr
+
+				policyInput = gr
+			}
+
+			if err == nil && c.Param("publisher") == "anthropic" && (action == vertex.ActionRawPredict || action == vertex.ActionStreamRawPredict) {
+				mr := &anthropic.MessagesRequest{}
+				err = json.Unmarshal(body, mr)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_vertex_request_error", nil, 1)
+					logError(logWithCid, "error when unmarshalling vertex ai anthropic messages request", prod, err)
+					JSON(c, http.StatusBadRequest, "[BricksLLM] invalid vertex ai anthropic messages request")
+					c.Abort()
+					return
+				}
+
+				enrichedEvent.Request = mr
+
+				policyInput = mr
+			}
+		}
+
+		if c.FullPath() == "/api/providers/gemini/:version/models/:model" {
+			model, action, err := gemini.ParseModelAction(c.Param("model"))
+			if err == nil {
+				c.Set("model", model)
+
+				if gemini.IsStreamingAction(action) {
+					c.Set("stream", true)
+				}
+			}
+
+			if err == nil && action != gemini.ActionCountTokens {
+				gr := &gemini.GenerateContentRequest{}
+				err = json.Unmarshal(body, gr)
+				if err != nil {
+					// requests that cannot be parsed would bypass policies.
+					telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_gemini_request_error", nil, 1)
+					logError(logWithCid, "error when unmarshalling gemini generate content request", prod, err)
+					JSON(c, http.StatusBadRequest, "[BricksLLM] invalid gemini generate content request")
+					c.Abort()
+					return
+				}
+
+				enrichedEvent.Request = gr
+				c.Set("geminiRequest", gr)
+
+				policyInput = gr
+			}
+		}
+
+		if c.FullPath() == "/api/providers/mistral/v1/chat/completions" {
+			ccr := &mistral.ChatRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_mistral_chat_completions_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling mistral chat completions request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid mistral chat completions request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", ccr.Model)
+			c.Set("mistralRequest", ccr)
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			logRequest(logWithCid, prod, private, &ccr.ChatCompletionRequest)
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/mistral/v1/embeddings" {
+			er := &goopenai.EmbeddingRequest{}
+			err = json.Unmarshal(body, er)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_mistral_embeddings_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling mistral embeddings request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid mistral embeddings request")
+				c.Abort()
+				return
+			}
+
+			userId = er.User
+			enrichedEvent.Request = er
+
+			c.Set("model", string(er.Model))
+
+			logEmbeddingRequest(logWithCid, prod, private, er)
+			policyInput = er
+		}
+
+		if c.FullPath() == "/api/providers/groq/v1/chat/completions" {
+			ccr := &goopenai.ChatCompletionRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_groq_chat_completions_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling groq chat completions request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid groq chat completions request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", ccr.Model)
+			c.Set("groqRequest", ccr)
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			logRequest(logWithCid, prod, private, ccr)
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/perplexity/chat/completions" {
+			ccr := &perplexity.ChatRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_perplexity_chat_completions_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling perplexity chat completions request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid perplexity chat completions request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", ccr.Model)
+			c.Set("perplexityRequest", ccr)
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			logRequest(logWithCid, prod, private, &ccr.ChatCompletionRequest)
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/deepseek/chat/completions" {
+			ccr := &goopenai.ChatCompletionRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_deepseek_chat_completions_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling deepseek chat completions request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid deepseek chat completions request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", ccr.Model)
+			c.Set("deepseekRequest", ccr)
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			logRequest(logWithCid, prod, private, ccr)
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/xai/v1/chat/completions" {
+			ccr := &goopenai.ChatCompletionRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_xai_chat_completions_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling xai chat completions request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid xai chat completions request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", ccr.Model)
+			c.Set("xaiRequest", ccr)
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			logRequest(logWithCid, prod, private, ccr)
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/openrouter/v1/chat/completions" {
+			ccr := &goopenai.ChatCompletionRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_openrouter_chat_completions_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling openrouter chat completions request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid openrouter chat completions request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", ccr.Model)
+			c.Set("openrouterRequest", ccr)
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			logRequest(logWithCid, prod, private, ccr)
+			policyInput = ccr
+		}
+
+		if p, ep := provider.GetPluginEndpoint(c.FullPath()); p != nil {
+			pr, err := p.ParseRequest(ep, body)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_plugin_request_error", []string{"provider:" + p.Name()}, 1)
+				logError(logWithCid, fmt.Sprintf("error when unmarshalling %s request", p.Name()), prod, err)
+				JSON(c, http.StatusBadRequest, fmt.Sprintf("[BricksLLM] invalid %s request", p.Name()))
+				c.Abort()
+				return
+			}
+
+			c.Set("model", pr.Model)
+			c.Set("stream", pr.Stream)
+			c.Set("pluginRequest", pr)
+			enrichedEvent.Request = pr.Body
+
+			policyInput = pr.Body
+		}
+
+		if c.FullPath() == "/api/providers/cohere/v2/chat" {
+			ccr := &cohere.ChatRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_cohere_chat_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling cohere chat request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid cohere chat request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", ccr.Model)
+			c.Set("cohereRequest", ccr)
+			enrichedEvent.Request = ccr
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/cohere/v2/embed" {
+			er := &cohere.EmbedRequest{}
+			err = json.Unmarshal(body, er)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_cohere_embed_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling cohere embed request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid cohere embed request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", er.Model)
+			c.Set("cohereRequest", er)
+			enrichedEvent.Request = er
+
+			policyInput = er
+		}
+
+		if c.FullPath() == "/api/providers/cohere/v2/rerank" {
+			rr := &cohere.RerankRequest{}
+			err = json.Unmarshal(body, rr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_cohere_rerank_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling cohere rerank request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid cohere rerank request")
+				c.Abort()
+				return
+			}
+
+			c.Set("model", rr.Model)
+			c.Set("cohereRequest", rr)
+			enrichedEvent.Request = rr
+
+			policyInput = rr
+		}
+
 		if c.FullPath() == "/api/providers/bedrock/anthropic/v1/complete" {
 			logCompletionRequest(logWithCid, body, prod, private)
 
@@ -447,7 +1032,11 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			mr := &anthropic.MessagesRequest{}
 			err = json.Unmarshal(body, mr)
 			if err != nil {
+				// requests that cannot be parsed would bypass policies.
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_anthropic_messages_request_error", nil, 1)
 				logError(logWithCid, "error when unmarshalling anthropic messages request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid anthropic messages request")
+				c.Abort()
 				return
 			}
 
@@ -470,7 +1059,11 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			mr := &anthropic.MessagesRequest{}
 			err = json.Unmarshal(body, mr)
 			if err != nil {
+				// requests that cannot be parsed would bypass policies.
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_anthropic_messages_request_error", nil, 1)
 				logError(logWithCid, "error when unmarshalling anthropic messages request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid anthropic messages request")
+				c.Abort()
 				return
 			}
 
@@ -711,7 +1304,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 			userId = ccr.User
 			enrichedEvent.Request = ccr
-			c.Set("model", ccr.Model)
+			c.Set("model", getAzurePricingModel(c, ccr.Model))
 
 			logRequest(logWithCid, prod, private, ccr)
 
@@ -732,7 +1325,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 			userId = cr.User
 			enrichedEvent.Request = cr
-			c.Set("model", cr.Model)
+			c.Set("model", getAzurePricingModel(c, cr.Model))
 
 			logAzureCompletionsRequest(logWithCid, prod, private, cr)
 
@@ -753,7 +1346,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 			userId = er.User
 
-			c.Set("model", "ada")
+			c.Set("model", getAzurePricingModel(c, "ada"))
 			c.Set("encoding_format", string(er.EncodingFormat))
 
 			logEmbeddingRequest(logWithCid, prod, private, er)
@@ -784,6 +1377,58 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			policyInput = ccr
 		}
 
+		if c.FullPath() == unifiedChatCompletionsPath {
+			ccr := &goopenai.ChatCompletionRequest{}
+			err = json.Unmarshal(body, ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unmarshal_unified_chat_completions_request_error", nil, 1)
+				logError(logWithCid, "error when unmarshalling unified chat completions request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] invalid chat completions request")
+				c.Abort()
+				return
+			}
+
+			target := getUnifiedProvider(ccr.Model)
+			selected := selectUnifiedSettings(settings, target)
+			if len(selected) == 0 {
+				telemetry.Incr("bricksllm.proxy.get_middleware.unified_provider_setting_not_found", nil, 1)
+				JSON(c, http.StatusBadRequest, fmt.Sprintf("[BricksLLM] no %s provider setting is associated with the key for model %s", target, ccr.Model))
+				c.Abort()
+				return
+			}
+
+			err = signUnifiedRequest(c.Request, target, selected[0])
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.sign_unified_request_error", nil, 1)
+				logError(logWithCid, "error when signing unified chat completions request", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to authenticate request with provider")
+				c.Abort()
+				return
+			}
+
+			settings = selected
+			c.Set("settings", selected)
+			c.Set("provider", target)
+
+			if selected[0].CostMap != nil {
+				enrichedEvent.CostMap = selected[0].CostMap
+				c.Set("cost_map", selected[0].CostMap)
+			}
+
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			c.Set("model", ccr.Model)
+
+			logRequest(logWithCid, prod, private, ccr)
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			policyInput = ccr
+		}
+
 		if c.FullPath() == "/api/providers/openai/v1/embeddings" {
 			er := &goopenai.EmbeddingRequest{}
 			err = json.Unmarshal(body, er)
@@ -816,7 +1461,10 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				c.Set("model", "dall-e-2")
 			}
 
-			c.Set("model", ir.Model)
+			c.Set("imageRequest", ir)
+			userId = ir.User
+			enrichedEvent.Request = ir
+
 			logCreateImageRequest(logWithCid, ir, prod, private)
 		}
 
@@ -914,6 +1562,21 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
+		// requests over the concurrency limit of the model with the selected
+		// setting are queued or rejected so a single heavy model cannot starve
+		// the others.
+		if len(settings) != 0 && len(model) != 0 && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			release, err := a.QueueModel(c.Request.Context(), kc, settings[0], model)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.model_concurrency_limit_reached", []string{fmt.Sprintf("model:%s", model)}, 1)
+				templatedJSON(c, errortemplate.TypeRateLimited, http.StatusTooManyRequests, "[BricksLLM] too many concurrent requests for model")
+				c.Abort()
+				return
+			}
+
+			defer release()
+		}
+
 		aid := c.Param("assistant_id")
 		fid := c.Param("file_id")
 		tid := c.Param("thread_id")
@@ -1051,6 +1714,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 		if c.FullPath() == "/api/providers/openai/v1/threads/:thread_id/runs" && c.Request.Method == http.MethodPost {
 			logCreateRunRequest(logWithCid, body, prod, private)
+			c.Set("stream", gjson.GetBytes(body, "stream").Bool())
 
 			rr := &goopenai.RunRequest{}
 			err := json.Unmarshal(body, rr)
@@ -1078,6 +1742,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 		if c.FullPath() == "/api/providers/openai/v1/threads/:thread_id/runs/:run_id/submit_tool_outputs" && c.Request.Method == http.MethodPost {
 			logSubmitToolOutputsRequest(logWithCid, body, prod, tid, rid)
+			c.Set("stream", gjson.GetBytes(body, "stream").Bool())
 		}
 
 		if c.FullPath() == "/api/providers/openai/v1/threads/:thread_id/runs/:run_id/cancel" && c.Request.Method == http.MethodPost {
@@ -1086,6 +1751,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 		if c.FullPath() == "/api/providers/openai/v1/threads/runs" && c.Request.Method == http.MethodPost {
 			logCreateThreadAndRunRequest(logWithCid, body, prod, private)
+			c.Set("stream", gjson.GetBytes(body, "stream").Bool())
 
 			r := &openai.CreateThreadAndRunRequest{}
 			err := json.Unmarshal(body, r)
@@ -1108,6 +1774,16 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 		}
 
 		if c.FullPath() == "/api/providers/openai/v1/moderations" && c.Request.Method == http.MethodPost {
+			mr := &ModerationRequest{}
+			if err := json.Unmarshal(body, mr); err == nil {
+				model := mr.Model
+				if len(model) == 0 {
+					model = openai.DefaultModerationModel
+				}
+
+				c.Set("model", model)
+			}
+
 			logCreateModerationRequest(logWithCid, body, prod, private)
 		}
 
@@ -1126,6 +1802,20 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 		if c.FullPath() == "/api/providers/openai/v1/files" && c.Request.Method == http.MethodPost {
 			purpose := c.PostForm("purpose")
 			logUploadFileRequest(logWithCid, prod, purpose)
+
+			if !kc.FilePolicy.IsPurposeAllowed(purpose) {
+				telemetry.Incr("bricksllm.proxy.get_middleware.file_purpose_not_allowed", nil, 1)
+				JSON(c, http.StatusForbidden, fmt.Sprintf("[BricksLLM] file purpose %s is not allowed", purpose))
+				c.Abort()
+				return
+			}
+
+			if fh, err := c.FormFile("file"); err == nil && kc.FilePolicy.ExceedsMaxFileSize(fh.Size) {
+				telemetry.Incr("bricksllm.proxy.get_middleware.file_size_exceeded", nil, 1)
+				JSON(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("[BricksLLM] file exceeds the maximum size of %d bytes", kc.FilePolicy.MaxFileSizeInBytes))
+				c.Abort()
+				return
+			}
 		}
 
 		if c.FullPath() == "/api/providers/openai/v1/files/:file_id" && c.Request.Method == http.MethodDelete {
@@ -1142,11 +1832,38 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 		if ac.GetAccessStatus(kc.KeyId) {
 			telemetry.Incr("bricksllm.proxy.get_middleware.rate_limited", nil, 1)
-			JSON(c, http.StatusTooManyRequests, "[BricksLLM] too many requests")
+			templatedJSON(c, errortemplate.TypeRateLimited, http.StatusTooManyRequests, "[BricksLLM] too many requests")
+			c.Abort()
+			return
+		}
+
+		// keys with a budget downgrade are blocked by applyBudgetDowngrade.
+		if kc.BudgetDowngrade == nil && kc.HasCostLimits() && ac.GetAccessStatus(key.BudgetAccessCacheKey(kc.KeyId)) {
+			telemetry.Incr("bricksllm.proxy.get_middleware.cost_limited", nil, 1)
+			templatedJSON(c, errortemplate.TypeOverBudget, http.StatusTooManyRequests, "[BricksLLM] cost limit reached")
 			c.Abort()
 			return
 		}
 
+		if len(sessionId) != 0 {
+			c.Set("sessionId", sessionId)
+		}
+
+		if len(sessionId) != 0 && kc.HasSessionLimits() {
+			su, err := ss.GetUsage(kc.KeyId, sessionId)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.get_session_usage_error", nil, 1)
+				logError(logWithCid, "error when getting session usage", prod, err)
+			}
+
+			if su != nil && kc.ExceedsSessionLimits(su) {
+				telemetry.Incr("bricksllm.proxy.get_middleware.session_budget_exceeded", nil, 1)
+				templatedJSON(c, errortemplate.TypeOverBudget, http.StatusTooManyRequests, fmt.Sprintf("[BricksLLM] session budget exceeded: %s", sessionId))
+				c.Abort()
+				return
+			}
+		}
+
 		if len(userId) != 0 {
 			c.Set("userId", userId)
 			us, err := um.GetUsers(kc.Tags, nil, []string{userId}, 0, 0)
@@ -1180,7 +1897,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 				if uac.GetAccessStatus(us[0].Id) {
 					telemetry.Incr("bricksllm.proxy.get_middleware.user_rate_limited", nil, 1)
-					JSON(c, http.StatusTooManyRequests, fmt.Sprintf("[BricksLLM] too many requests for user: %s", userId))
+					templatedJSON(c, errortemplate.TypeRateLimited, http.StatusTooManyRequests, fmt.Sprintf("[BricksLLM] too many requests for user: %s", userId))
 					c.Abort()
 					return
 				}
@@ -1193,10 +1910,73 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 		if p != nil {
 			c.Set("policyId", p.Id)
+
+			if !kc.PolicyExempt {
+				c.Set("policy", p)
+			}
+		}
+
+		if p != nil && policyInput != nil && kc.PolicyExempt {
+			ex, err := p.Evaluate(client, policyInput, scanner, cd, logWithCid)
+			if err != nil {
+				logError(logWithCid, "error when evaluating policy for an exempt key", prod, err)
+				ex = &policy.Exemption{
+					PolicyId: p.Id,
+					Action:   "unknown",
+					Reason:   err.Error(),
+					Rules:    []string{},
+				}
+			}
+
+			data, err := json.Marshal(ex)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.json_marshal_policy_exemption_error", nil, 1)
+			}
+
+			if err == nil {
+				policyExemptionBytes = data
+			}
+
+			c.Set("action", "exempted")
+			telemetry.Incr("bricksllm.proxy.get_middleware.request_exempted", []string{
+				"action:" + ex.Action,
+			}, 1)
+
+			logWithCid.Info("policy exempted request",
+				zap.String("keyId", kc.KeyId),
+				zap.String("policyId", p.Id),
+				zap.String("action", ex.Action),
+				zap.Strings("rules", ex.Rules),
+			)
 		}
 
-		if p != nil && policyInput != nil {
-			err := p.Filter(client, policyInput, scanner, cd, logWithCid)
+		if p != nil && policyInput != nil && !kc.PolicyExempt {
+			fr, err := p.FilterWithResult(client, policyInput, scanner, cd, logWithCid)
+			if fr.Tokens.Len() != 0 {
+				redactionTokens = fr.Tokens
+			}
+
+			if len(fr.ScannerFailure) != 0 {
+				c.Set("pii_scanner_failure", fr.ScannerFailure)
+			}
+
+			if fr.Truncated {
+				c.Set("prompt_truncated", true)
+			}
+
+			recordPolicyRules(c, p, "request", fr.Rules)
+
+			if p.ShouldStoreFindings() && len(fr.Findings) != 0 {
+				data, merr := json.Marshal(fr.Findings)
+				if merr != nil {
+					telemetry.Incr("bricksllm.proxy.get_middleware.json_marshal_pii_findings_error", nil, 1)
+				}
+
+				if merr == nil {
+					piiFindingsBytes = data
+				}
+			}
+
 			if err == nil {
 				c.Set("action", "allowed")
 			}
@@ -1206,14 +1986,22 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				if ok {
 					c.Set("action", "blocked")
 					telemetry.Incr("bricksllm.proxy.get_middleware.request_blocked", nil, 1)
-					JSON(c, http.StatusForbidden, "[BricksLLM] request blocked")
+
+					logWithCid.Info("request blocked",
+						zap.String("keyId", kc.KeyId),
+						zap.String("policyId", p.Id),
+						zap.String("reason", err.Error()),
+					)
+
+					templatedJSON(c, errortemplate.TypeBlocked, http.StatusForbidden, blockMessage(kc, cid, "[BricksLLM] request blocked"))
 					c.Abort()
 					return
 				}
 
 				_, ok = err.(warnedError)
-				if ok {
-					c.Set("action", "warned")
+				if ok && !warnRequest(c, logWithCid, p, kc, cid, warningKindRules, err.Error()) {
+					c.Abort()
+					return
 				}
 
 				_, ok = err.(redactedError)
@@ -1224,19 +2012,67 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				logError(logWithCid, "error when filtering a request", prod, err)
 			}
 
+			// tokens cannot be restored in streamed responses, which reach
+			// clients as they are generated.
+			if redactionTokens != nil && c.GetBool("stream") {
+				telemetry.Incr("bricksllm.proxy.get_middleware.reversible_redaction_streamed", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] reversible redaction is not supported for streamed requests")
+				c.Abort()
+				return
+			}
+
+			if p.ShouldDetectPromptInjection() && !detectPromptInjection(c, logWithCid, p, kc, policyInput, cd, cid) {
+				c.Abort()
+				return
+			}
+
+			if p.ShouldModerate() && !moderateRequest(c, logWithCid, prod, client, p, kc, settings, policyInput, cid) {
+				c.Abort()
+				return
+			}
+
 			data, err := json.Marshal(policyInput)
 			if err == nil {
 				c.Request.Body = io.NopCloser(bytes.NewReader(data))
 
-				if kc.ShouldLogRequest {
+				if kc.ShouldStoreRequest() {
 					requestBytes = data
 				}
 			}
 		}
 
+		if clampMaxTokens {
+			fitRequestToContextWindow(c, logWithCid, prod, e, ae, policyInput, contextWindowSiblings)
+		}
+
+		if isDryRun(c) {
+			c.Set("dryRun", true)
+			respondWithDryRun(c, logWithCid, prod, e, ae, policyInput)
+			c.Abort()
+			return
+		}
+
+		// streamed responses reach clients as they are generated and are not
+		// scanned. Streamed requests with reversibly redacted values are
+		// rejected above.
+		var held *heldResponseWriter
+		if p != nil && !kc.PolicyExempt && (p.ShouldScanResponses() || redactionTokens != nil) && !c.GetBool("stream") {
+			held = holdResponse(c)
+		}
+
 		c.Next()
 
-		if kc.ShouldLogResponse {
+		if held != nil {
+			filterResponse(c, logWithCid, prod, held, p, kc, scanner, cd, cid, redactionTokens)
+		}
+
+		// handlers copy the headers of upstream responses, which report the
+		// remaining upstream quotas of the setting used.
+		if len(settings) != 0 && c.FullPath() != unifiedChatCompletionsPath && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			a.RecordQuota(settings[0], c.Writer.Header())
+		}
+
+		if kc.ShouldStoreResponse() {
 			if c.GetBool("stream") {
 				streamingResponse, ok := c.Get("streaming_response")
 
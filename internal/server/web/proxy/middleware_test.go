@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplatedJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	templates := map[string]*errortemplate.Template{
+		errortemplate.TypeOverBudget: {StatusCode: 402, Body: `{"detail":"{{message}}","type":"{{type}}"}`},
+	}
+
+	tests := []struct {
+		name        string
+		routeConfig any
+		errType     string
+		status      int
+		body        string
+	}{
+		{name: "no route config", errType: errortemplate.TypeOverBudget, status: http.StatusTooManyRequests, body: `{"error":{"message":"[BricksLLM] cost limit reached","type":"","code":"429"}}`},
+		{name: "route template", routeConfig: &route.Route{ErrorTemplates: templates}, errType: errortemplate.TypeOverBudget, status: 402, body: `{"detail":"[BricksLLM] cost limit reached","type":"over_budget"}`},
+		{name: "custom provider template", routeConfig: &custom.RouteConfig{ErrorTemplates: templates}, errType: errortemplate.TypeOverBudget, status: 402, body: `{"detail":"[BricksLLM] cost limit reached","type":"over_budget"}`},
+		{name: "untemplated type", routeConfig: &custom.RouteConfig{ErrorTemplates: templates}, errType: errortemplate.TypeRateLimited, status: http.StatusTooManyRequests, body: `{"error":{"message":"[BricksLLM] cost limit reached","type":"","code":"429"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			if tt.routeConfig != nil {
+				c.Set("route_config", tt.routeConfig)
+			}
+
+			templatedJSON(c, tt.errType, http.StatusTooManyRequests, "[BricksLLM] cost limit reached")
+			assert.Equal(t, tt.status, w.Code)
+			assert.JSONEq(t, tt.body, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type mistralEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
+	Count(input string) int
+}
+
+func newMistralProvider(me mistralEstimator) *compatibleProvider {
+	return &compatibleProvider{
+		name:           "mistral",
+		url:            mistral.ChatCompletionsUrl,
+		decodeResponse: decodeOpenAiChatCompletion,
+		decodeChunk:    decodeOpenAiChatCompletionChunk,
+		estimateCost: func(c *gin.Context, model string, usage *compatibleUsage) (float64, error) {
+			if cost, ok := estimateCostWithCostMap(c, model, usage); ok {
+				return cost, nil
+			}
+
+			return me.EstimateTotalCost(model, usage.PromptTokens, usage.CompletionTokens)
+		},
+		count: me.Count,
+		countRequest: func(c *gin.Context) int {
+			r, ok := c.Value("mistralRequest").(*mistral.ChatRequest)
+			if !ok || r == nil {
+				return 0
+			}
+
+			return provider.CountChatRequestTokens(&r.ChatCompletionRequest, me.Count)
+		},
+	}
+}
+
+func getMistralEmbeddingsHandler(prod, private bool, client http.Client, me mistralEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_mistral_embeddings_handler.requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, mistral.EmbeddingsUrl, c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating mistral embeddings http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create mistral http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		start := time.Now()
+
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_mistral_embeddings_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to mistral", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to mistral")
+			return
+		}
+
+		defer res.Body.Close()
+
+		dur := time.Since(start)
+		telemetry.Timing("bricksllm.proxy.get_mistral_embeddings_handler.latency", dur, nil, 1)
+
+		bytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading mistral embeddings response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read mistral embeddings response body")
+			return
+		}
+
+		var cost float64 = 0
+		chatRes := &EmbeddingResponse{}
+		promptTokenCounts := 0
+
+		if res.StatusCode == http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.get_mistral_embeddings_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_mistral_embeddings_handler.success_latency", dur, nil, 1)
+
+			err = json.Unmarshal(bytes, chatRes)
+			if err != nil {
+				logError(log, "error when unmarshalling mistral embeddings response body", prod, err)
+			}
+
+			model := c.GetString("model")
+
+			if err == nil {
+				logEmbeddingResponse(log, prod, private, chatRes)
+				totalTokens := chatRes.Usage.TotalTokens
+				promptTokenCounts = chatRes.Usage.PromptTokens
+
+				cost, err = me.EstimateEmbeddingsInputCost(model, totalTokens)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_mistral_embeddings_handler.estimate_total_cost_error", nil, 1)
+					logError(log, "error when estimating mistral embeddings cost", prod, err)
+				}
+
+				m, exists := c.Get("cost_map")
+				if exists {
+					converted, ok := m.(*provider.CostMap)
+					if ok {
+						newCost, err := provider.EstimateCostWithCostMap(model, totalTokens, 1000, converted.EmbeddingsCostPerModel)
+						if err != nil {
+							logError(log, "error when estimating mistral embeddings total cost with cost maps", prod, err)
+							telemetry.Incr("bricksllm.proxy.get_mistral_embeddings_handler.estimate_cost_with_cost_map_error", nil, 1)
+						}
+
+						if newCost != 0 {
+							cost = newCost
+						}
+					}
+				}
+			}
+		}
+
+		c.Set("costInUsd", cost)
+		c.Set("promptTokenCount", promptTokenCounts)
+
+		if res.StatusCode != http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.get_mistral_embeddings_handler.error_response", nil, 1)
+
+			errorRes := &goopenai.ErrorResponse{}
+			err = json.Unmarshal(bytes, errorRes)
+			if err != nil {
+				logError(log, "error when unmarshalling mistral embeddings error response body", prod, err)
+			}
+
+			logOpenAiError(log, prod, errorRes)
+		}
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		c.Data(res.StatusCode, "application/json", bytes)
+	}
+}
@@ -0,0 +1,210 @@
+package proxy
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
+	"github.com/bricks-cloud/bricksllm/internal/provider/deepinfra"
+	"github.com/bricks-cloud/bricksllm/internal/provider/deepseek"
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+	"github.com/bricks-cloud/bricksllm/internal/provider/groq"
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
+	"github.com/bricks-cloud/bricksllm/internal/provider/perplexity"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vertex"
+	"github.com/bricks-cloud/bricksllm/internal/provider/xai"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+type ModelInfo struct {
+	Id                             string               `json:"id"`
+	Object                         string               `json:"object"`
+	OwnedBy                        string               `json:"owned_by"`
+	Provider                       string               `json:"provider"`
+	ContextWindow                  int                  `json:"contextWindow,omitempty"`
+	PromptCostPerMillionTokens     *float64             `json:"promptCostPerMillionTokens,omitempty"`
+	CompletionCostPerMillionTokens *float64             `json:"completionCostPerMillionTokens,omitempty"`
+	Capability                     *provider.Capability `json:"capability,omitempty"`
+}
+
+type ModelList struct {
+	Object string       `json:"object"`
+	Data   []*ModelInfo `json:"data"`
+}
+
+// getPricingTable returns the pricing table of a provider with costs
+// normalized to USD per million tokens. OpenAI pricings managed via the admin
+// server are applied to the built in OpenAI pricing table.
+func getPricingTable(providerName string, e estimator) map[string]map[string]float64 {
+	switch providerName {
+	case "openai":
+		return scalePricingTable(e.PricingTable(), 1000)
+	case "azure":
+		return scalePricingTable(azure.AzureOpenAiPerThousandTokenCost, 1000)
+	case "anthropic":
+		return anthropic.AnthropicPerMillionTokenCost
+	case "deepinfra":
+		return deepinfra.DeepinfraPerMillionTokenCost
+	case "vertexai":
+		return vertex.PricingTable()
+	case "gemini":
+		return gemini.GeminiPerMillionTokenCost
+	case "mistral":
+		return mistral.MistralPerMillionTokenCost
+	case "cohere":
+		return cohere.CoherePerMillionTokenCost
+	case "groq":
+		return groq.GroqPerMillionTokenCost
+	case "perplexity":
+		return perplexity.PerplexityPerMillionTokenCost
+	case "deepseek":
+		return deepseek.DeepseekPerMillionTokenCost
+	case "xai":
+		return xai.XaiPerMillionTokenCost
+	}
+
+	if p := provider.GetPlugin(providerName); p != nil {
+		return p.PricingTable()
+	}
+
+	return nil
+}
+
+func scalePricingTable(table map[string]map[string]float64, multiplier float64) map[string]map[string]float64 {
+	scaled := map[string]map[string]float64{}
+	for kind, costs := range table {
+		scaled[kind] = map[string]float64{}
+		for model, cost := range costs {
+			scaled[kind][model] = cost * multiplier
+		}
+	}
+
+	return scaled
+}
+
+func getPrice(costs map[string]float64, model string) *float64 {
+	if costs == nil {
+		return nil
+	}
+
+	cost, ok := costs[model]
+	if !ok {
+		return nil
+	}
+
+	return &cost
+}
+
+func buildModelInfo(setting *provider.Setting, model string, table map[string]map[string]float64) *ModelInfo {
+	mi := &ModelInfo{
+		Id:            model,
+		Object:        "model",
+		OwnedBy:       setting.Provider,
+		Provider:      setting.Provider,
+		ContextWindow: provider.GetContextWindow(model),
+		Capability:    provider.GetCapability(model),
+	}
+
+	if table != nil {
+		mi.PromptCostPerMillionTokens = getPrice(table["prompt"], model)
+		if mi.PromptCostPerMillionTokens == nil {
+			mi.PromptCostPerMillionTokens = getPrice(table["embeddings"], model)
+		}
+
+		mi.CompletionCostPerMillionTokens = getPrice(table["completion"], model)
+	}
+
+	if setting.CostMap != nil {
+		if price := getPrice(setting.CostMap.PromptCostPerModel, model); price != nil {
+			scaled := *price * 1000
+			mi.PromptCostPerMillionTokens = &scaled
+		}
+
+		if price := getPrice(setting.CostMap.CompletionCostPerModel, model); price != nil {
+			scaled := *price * 1000
+			mi.CompletionCostPerMillionTokens = &scaled
+		}
+	}
+
+	return mi
+}
+
+func getModelsFromPricingTable(table map[string]map[string]float64) []string {
+	models := []string{}
+	seen := map[string]bool{}
+	for _, kind := range []string{"prompt", "embeddings"} {
+		for model := range table[kind] {
+			if !seen[model] {
+				seen[model] = true
+				models = append(models, model)
+			}
+		}
+	}
+
+	return models
+}
+
+func buildModelList(settings []*provider.Setting, e estimator) *ModelList {
+	ml := &ModelList{
+		Object: "list",
+		Data:   []*ModelInfo{},
+	}
+
+	seen := map[string]bool{}
+	for _, setting := range settings {
+		if setting == nil {
+			continue
+		}
+
+		table := getPricingTable(setting.Provider, e)
+		models := setting.AllowedModels
+		if len(models) == 0 {
+			models = getModelsFromPricingTable(table)
+		}
+
+		for _, model := range models {
+			id := setting.Provider + "/" + model
+			if seen[id] {
+				continue
+			}
+
+			seen[id] = true
+			ml.Data = append(ml.Data, buildModelInfo(setting, model, table))
+		}
+	}
+
+	sort.Slice(ml.Data, func(i, j int) bool {
+		if ml.Data[i].Provider != ml.Data[j].Provider {
+			return ml.Data[i].Provider < ml.Data[j].Provider
+		}
+
+		return ml.Data[i].Id < ml.Data[j].Id
+	})
+
+	return ml
+}
+
+func getListModelsHandler(prod bool, e estimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.proxy.get_list_models_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		settings := []*provider.Setting{}
+		if raw, ok := c.Get("settings"); ok {
+			if converted, ok := raw.([]*provider.Setting); ok {
+				settings = converted
+			}
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_list_models_handler.success", nil, 1)
+		c.JSON(http.StatusOK, buildModelList(settings, e))
+	}
+}
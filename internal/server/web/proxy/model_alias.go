@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/tidwall/gjson"
+)
+
+const modelAliasTagPrefix = "model_alias:"
+
+// resolveModelAlias replaces the model of a JSON request body with the model
+// an alias of the key points to, so that providers, policies and cost
+// estimators see the real model. It returns the rewritten body and the alias.
+func resolveModelAlias(kc *key.ResponseKey, body []byte) ([]byte, string, bool) {
+	if kc == nil || len(kc.ModelAliases) == 0 || !gjson.ValidBytes(body) {
+		return body, "", false
+	}
+
+	result := gjson.GetBytes(body, "model")
+	if result.Type != gjson.String {
+		return body, "", false
+	}
+
+	model, ok := kc.ResolveModel(result.String())
+	if !ok {
+		return body, "", false
+	}
+
+	rewritten, err := rewriteModel(body, model)
+	if err != nil {
+		return body, "", false
+	}
+
+	return rewritten, result.String(), true
+}
+
+// rewriteModel replaces the model field of a JSON request body.
+func rewriteModel(body []byte, model string) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["model"] = encoded
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+const remappedModelTagPrefix = "remapped_from:"
+
+type modelRemapper interface {
+	ResolveModel(provider, path, model string) (string, bool)
+}
+
+// remapDeprecatedModel upgrades the model of a JSON request body of a
+// provider to a proxy path to the successor of a deprecated model, so that
+// requests for retired models do not fail upstream. It returns the rewritten
+// body and the deprecated model.
+func remapDeprecatedModel(mr modelRemapper, provider, path string, body []byte) ([]byte, string, bool) {
+	if mr == nil || !gjson.ValidBytes(body) {
+		return body, "", false
+	}
+
+	result := gjson.GetBytes(body, "model")
+	if result.Type != gjson.String {
+		return body, "", false
+	}
+
+	successor, ok := mr.ResolveModel(provider, path, result.String())
+	if !ok {
+		return body, "", false
+	}
+
+	rewritten, err := rewriteModel(body, successor)
+	if err != nil {
+		return body, "", false
+	}
+
+	return rewritten, result.String(), true
+}
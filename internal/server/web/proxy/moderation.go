@@ -1,8 +1,21 @@
 package proxy
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -50,3 +63,135 @@ func logCreateModerationResponse(log *zap.Logger, data []byte, prod bool) {
 		log.Info("openai create moderation response", fields...)
 	}
 }
+
+type moderationInput struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// moderate sends texts to an OpenAI compatible moderations endpoint and
+// returns the scored categories. Moderation requests are free of charge and
+// are not recorded as events.
+func moderate(client http.Client, timeout time.Duration, url, apiKey, model string, texts []string) ([]*openai.ModerationFinding, error) {
+	data, err := json.Marshal(&moderationInput{
+		Model: model,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(apiKey) != 0 {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation request failed with status code %d", res.StatusCode)
+	}
+
+	return openai.ParseModerationFindings(body), nil
+}
+
+// moderateRequest applies the moderation config of the policy to a request
+// using the OpenAI provider setting of the key. Moderation endpoints
+// configured by the policy are called without the API key of the setting so
+// that it is never sent to third parties. Moderation findings are recorded on
+// the event. Requests of keys exempt from policies are not moderated.
+// Requests are let through if the moderations endpoint fails unless the
+// failure mode of the config is closed. It returns false if the request was
+// blocked.
+func moderateRequest(c *gin.Context, log *zap.Logger, prod bool, client http.Client, p *policy.Policy, kc *key.ResponseKey, settings []*provider.Setting, input any, cid string) bool {
+	if kc.PolicyExempt {
+		return true
+	}
+
+	texts := policy.ModerationInput(input)
+	if len(texts) == 0 {
+		return true
+	}
+
+	apiKey := ""
+	for _, setting := range settings {
+		if setting != nil && setting.Provider == "openai" {
+			apiKey = setting.Setting["apikey"]
+			break
+		}
+	}
+
+	url := p.ModerationUrl()
+	if url != openai.ModerationsUrl {
+		apiKey = ""
+	} else if len(apiKey) == 0 {
+		telemetry.Incr("bricksllm.proxy.moderate_request.openai_setting_not_found", nil, 1)
+		return true
+	}
+
+	findings, err := moderate(client, c.GetDuration("requestTimeout"), url, apiKey, p.ModerationModel(), texts)
+	if err != nil {
+		mode := p.ModerationFailureMode()
+		telemetry.Incr("bricksllm.proxy.moderate_request.moderate_error", []string{
+			"failure_mode:" + mode,
+		}, 1)
+		logError(log, "error when moderating a request", prod, err)
+
+		if mode != policy.FailureModeClosed {
+			return true
+		}
+
+		c.Set("action", "blocked")
+
+		log.Info("request blocked due to moderation failure",
+			zap.String("keyId", kc.KeyId),
+			zap.String("policyId", p.Id),
+		)
+
+		templatedJSON(c, errortemplate.TypeBlocked, http.StatusForbidden, blockMessage(kc, cid, "[BricksLLM] request blocked"))
+		return false
+	}
+
+	action := p.EvaluateModeration(findings)
+	if action != policy.Allow {
+		c.Set("content_filter_findings", findings)
+	} else if flagged := openai.FlaggedModerationFindings(findings); len(flagged) != 0 {
+		c.Set("content_filter_findings", flagged)
+	}
+
+	switch action {
+	case policy.Block:
+		telemetry.Incr("bricksllm.proxy.moderate_request.blocked", nil, 1)
+		c.Set("action", "blocked")
+
+		log.Info("request blocked by moderation",
+			zap.String("keyId", kc.KeyId),
+			zap.String("policyId", p.Id),
+		)
+
+		templatedJSON(c, errortemplate.TypeBlocked, http.StatusForbidden, blockMessage(kc, cid, "[BricksLLM] request blocked"))
+		return false
+	case policy.AllowButWarn:
+		telemetry.Incr("bricksllm.proxy.moderate_request.warned", nil, 1)
+		return warnRequest(c, log, p, kc, cid, warningKindModeration, "request warned due to moderation")
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestModerateRequestFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	moderated := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		moderated = true
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	input := &goopenai.ChatCompletionRequest{
+		Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: "hello there"}},
+	}
+
+	tests := []struct {
+		name        string
+		failureMode string
+		exempt      bool
+		allowed     bool
+		moderated   bool
+		status      int
+	}{
+		{name: "fail open by default", allowed: true, moderated: true, status: http.StatusOK},
+		{name: "fail open", failureMode: policy.FailureModeOpen, allowed: true, moderated: true, status: http.StatusOK},
+		{name: "fail closed", failureMode: policy.FailureModeClosed, moderated: true, status: http.StatusForbidden},
+		{name: "exempt key", failureMode: policy.FailureModeClosed, exempt: true, allowed: true, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moderated = false
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("requestTimeout", time.Second)
+
+			p := &policy.Policy{
+				Id: "policy",
+				ModerationConfig: &policy.ModerationConfig{
+					Action:      policy.Block,
+					Url:         server.URL,
+					FailureMode: tt.failureMode,
+				},
+			}
+			kc := &key.ResponseKey{KeyId: "key", PolicyExempt: tt.exempt}
+
+			allowed := moderateRequest(c, zap.NewNop(), true, http.Client{}, p, kc, nil, input, "cid")
+
+			assert.Equal(t, tt.allowed, allowed)
+			assert.Equal(t, tt.moderated, moderated)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
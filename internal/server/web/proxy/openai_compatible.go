@@ -0,0 +1,336 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// compatibleUsage is the usage of a chat completion of an OpenAI compatible
+// provider. Details holds the usage in the format of the provider and is nil
+// if the usage was estimated by the gateway.
+type compatibleUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	ReasoningTokens  int
+	Details          any
+}
+
+func newOpenAiUsage(usage *goopenai.Usage) *compatibleUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &compatibleUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Details:          usage,
+	}
+}
+
+// compatibleProvider adapts a provider serving the chat completions API of
+// OpenAI to getOpenAiCompatibleChatCompletionsHandler.
+type compatibleProvider struct {
+	// name is the provider in logs, metrics and error messages.
+	name string
+	url  string
+	// decodeResponse returns a nil usage if the response does not report it.
+	decodeResponse func(c *gin.Context, data []byte) (*goopenai.ChatCompletionResponse, *compatibleUsage, error)
+	// decodeChunk decodes a chunk of a streamed response. It returns a nil
+	// usage for chunks that do not report it.
+	decodeChunk func(c *gin.Context, data []byte) (*goopenai.ChatCompletionStreamResponse, *compatibleUsage, error)
+	// finishStream is called once a streamed response ended if it is set.
+	finishStream func(c *gin.Context)
+	estimateCost func(c *gin.Context, model string, usage *compatibleUsage) (float64, error)
+	count        func(input string) int
+	// countRequest approximates the prompt tokens of the request.
+	countRequest func(c *gin.Context) int
+}
+
+// decodeOpenAiChatCompletion decodes a chat completion of a provider that
+// reports usage in the format of OpenAI.
+func decodeOpenAiChatCompletion(c *gin.Context, data []byte) (*goopenai.ChatCompletionResponse, *compatibleUsage, error) {
+	chatRes := &goopenai.ChatCompletionResponse{}
+	if err := json.Unmarshal(data, chatRes); err != nil {
+		return nil, nil, err
+	}
+
+	return chatRes, newOpenAiUsage(&chatRes.Usage), nil
+}
+
+// decodeOpenAiChatCompletionChunk decodes a chunk of a streamed chat
+// completion of a provider that reports usage in the format of OpenAI.
+func decodeOpenAiChatCompletionChunk(c *gin.Context, data []byte) (*goopenai.ChatCompletionStreamResponse, *compatibleUsage, error) {
+	chunk := &goopenai.ChatCompletionStreamResponse{}
+	if err := json.Unmarshal(data, chunk); err != nil {
+		return nil, nil, err
+	}
+
+	return chunk, newOpenAiUsage(chunk.Usage), nil
+}
+
+// estimateCostWithCostMap estimates the cost of a request with the cost map
+// of its provider setting. False is returned if the cost map does not price
+// the model.
+func estimateCostWithCostMap(c *gin.Context, model string, usage *compatibleUsage) (float64, bool) {
+	m, exists := c.Get("cost_map")
+	if !exists {
+		return 0, false
+	}
+
+	converted, ok := m.(*provider.CostMap)
+	if !ok {
+		return 0, false
+	}
+
+	cost, err := provider.EstimateTotalCostWithCostMaps(model, usage.PromptTokens, usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+	if err != nil || cost == 0 {
+		return 0, false
+	}
+
+	return cost, true
+}
+
+// getOpenAiCompatibleChatCompletionsHandler forwards chat completions
+// requests to a provider serving the chat completions API of OpenAI. Token
+// counts are estimated when a streamed response does not report usage.
+func getOpenAiCompatibleChatCompletionsHandler(prod, private bool, client http.Client, cp *compatibleProvider) gin.HandlerFunc {
+	metric := "bricksllm.proxy.get_" + cp.name + "_chat_completions_handler"
+
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr(metric+".requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cp.url, c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating "+cp.name+" chat completions http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create "+cp.name+" http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		isStreaming := c.GetBool("stream")
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr(metric+".http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to "+cp.name, prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to "+cp.name)
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		model := c.GetString("model")
+
+		if res.StatusCode == http.StatusOK && !isStreaming {
+			dur := time.Since(start)
+			telemetry.Timing(metric+".latency", dur, nil, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading "+cp.name+" chat completions response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read "+cp.name+" response body")
+				return
+			}
+
+			telemetry.Incr(metric+".success", nil, 1)
+			telemetry.Timing(metric+".success_latency", dur, nil, 1)
+
+			chatRes, usage, err := cp.decodeResponse(c, bytes)
+			if err != nil {
+				logError(log, "error when unmarshalling "+cp.name+" chat completions response body", prod, err)
+			}
+
+			var cost float64 = 0
+			if err == nil {
+				logChatCompletionResponse(log, prod, private, chatRes)
+
+				if len(chatRes.Choices) != 0 {
+					c.Set("content", chatRes.Choices[0].Message.Content)
+				}
+
+				if usage != nil {
+					cost, err = cp.estimateCost(c, model, usage)
+					if err != nil {
+						telemetry.Incr(metric+".estimate_total_cost_error", nil, 1)
+						logError(log, "error when estimating "+cp.name+" chat completions cost", prod, err)
+					}
+				}
+			}
+
+			c.Set("costInUsd", cost)
+
+			if usage != nil {
+				c.Set("promptTokenCount", usage.PromptTokens)
+				c.Set("completionTokenCount", usage.CompletionTokens)
+				c.Set("reasoningTokenCount", usage.ReasoningTokens)
+			}
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing(metric+".error_latency", dur, nil, 1)
+			telemetry.Incr(metric+".error_response", nil, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading "+cp.name+" chat completions response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read "+cp.name+" response body")
+				return
+			}
+
+			errorRes := &goopenai.ErrorResponse{}
+			err = json.Unmarshal(bytes, errorRes)
+			if err != nil {
+				logError(log, "error when unmarshalling "+cp.name+" chat completions error response body", prod, err)
+			}
+
+			logOpenAiError(log, prod, errorRes)
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		buffer := bufio.NewReader(res.Body)
+		choices := &streamedChoices{}
+		streamingResponse := [][]byte{}
+		var usage *compatibleUsage
+		defer func() {
+			content := choices.String()
+			c.Set("content", content)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+
+			if cp.finishStream != nil {
+				cp.finishStream(c)
+			}
+
+			if usage == nil {
+				usage = &compatibleUsage{
+					PromptTokens:     cp.countRequest(c),
+					CompletionTokens: cp.count(content),
+				}
+			}
+
+			cost, err := cp.estimateCost(c, model, usage)
+			if err != nil {
+				telemetry.Incr(metric+".estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating "+cp.name+" streaming chat completions cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", usage.PromptTokens)
+			c.Set("completionTokenCount", usage.CompletionTokens)
+			c.Set("reasoningTokenCount", usage.ReasoningTokens)
+		}()
+
+		telemetry.Incr(metric+".streaming_requests", nil, 1)
+
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				if errors.Is(err, context.DeadlineExceeded) {
+					telemetry.Incr(metric+".context_deadline_exceeded_error", nil, 1)
+					logError(log, "context deadline exceeded when reading bytes from "+cp.name+" chat completions response", prod, err)
+
+					return false
+				}
+
+				telemetry.Incr(metric+".read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from "+cp.name+" chat completions response", prod, err)
+
+				apiErr := &goopenai.ErrorResponse{
+					Error: &goopenai.APIError{
+						Type:    "bricksllm_error",
+						Message: err.Error(),
+					},
+				}
+
+				bytes, err := json.Marshal(apiErr)
+				if err != nil {
+					telemetry.Incr(metric+".json_marshal_error", nil, 1)
+					logError(log, "error when marshalling bytes for streaming "+cp.name+" chat completions error response", prod, err)
+					return false
+				}
+
+				c.SSEvent("", string(bytes))
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
+			c.SSEvent("", " "+string(noPrefixLine))
+
+			if string(noPrefixLine) == "[DONE]" {
+				return false
+			}
+
+			chunk, chunkUsage, err := cp.decodeChunk(c, noPrefixLine)
+			if err != nil {
+				telemetry.Incr(metric+".completion_response_unmarshall_error", nil, 1)
+				logError(log, "error when unmarshalling "+cp.name+" chat completions stream response", prod, err)
+			}
+
+			if err == nil {
+				for _, choice := range chunk.Choices {
+					choices.append(choice.Index, choice.Delta.Content)
+				}
+
+				// the usage of the request is kept from the last chunk reporting it.
+				if chunkUsage != nil {
+					usage = chunkUsage
+				}
+			}
+
+			return true
+		})
+
+		telemetry.Timing(metric+".streaming_latency", time.Since(start), nil, 1)
+	}
+}
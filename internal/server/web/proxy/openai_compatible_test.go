@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/openrouter"
+	"github.com/bricks-cloud/bricksllm/internal/provider/perplexity"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type compatibleEstimatorStub struct{}
+
+func (compatibleEstimatorStub) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	return float64(promptTks+completionTks) / 100, nil
+}
+
+func (compatibleEstimatorStub) EstimateSearchCost(model string, usage *perplexity.Usage) float64 {
+	return float64(usage.NumSearchQueries)
+}
+
+func (compatibleEstimatorStub) EstimateUsageCost(usage *openrouter.Usage) (float64, error) {
+	return (&openrouter.CostEstimator{}).EstimateUsageCost(usage)
+}
+
+func (compatibleEstimatorStub) Count(input string) int {
+	return len(strings.Fields(input))
+}
+
+// closeNotifyingRecorder lets handlers stream responses to a recorder.
+type closeNotifyingRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (closeNotifyingRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func TestOpenAiCompatibleChatCompletionsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := &goopenai.ChatCompletionRequest{
+		Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: "say hello to me"}},
+	}
+
+	tests := []struct {
+		name       string
+		provider   *compatibleProvider
+		stream     bool
+		response   string
+		cost       float64
+		prompt     int
+		completion int
+		citations  int
+	}{
+		{
+			name:       "groq response",
+			provider:   newGroqProvider(compatibleEstimatorStub{}),
+			response:   `{"choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`,
+			cost:       0.15,
+			prompt:     10,
+			completion: 5,
+		},
+		{
+			name:       "groq stream reporting usage",
+			provider:   newGroqProvider(compatibleEstimatorStub{}),
+			stream:     true,
+			response:   "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hello there\"}}]}\n\ndata: {\"choices\":[],\"x_groq\":{\"usage\":{\"prompt_tokens\":20,\"completion_tokens\":10}}}\n\ndata: [DONE]\n\n",
+			cost:       0.3,
+			prompt:     20,
+			completion: 10,
+		},
+		{
+			name:       "groq stream without usage",
+			provider:   newGroqProvider(compatibleEstimatorStub{}),
+			stream:     true,
+			response:   "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hello there\"}}]}\n\ndata: [DONE]\n\n",
+			cost:       0.06,
+			prompt:     4,
+			completion: 2,
+		},
+		{
+			name:       "openrouter billed cost",
+			provider:   newOpenrouterProvider(compatibleEstimatorStub{}),
+			response:   `{"choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"cost":0.5}}`,
+			cost:       0.5,
+			prompt:     10,
+			completion: 5,
+		},
+		{
+			name:       "perplexity searches and citations",
+			provider:   newPerplexityProvider(false, false, compatibleEstimatorStub{}),
+			response:   `{"choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"num_search_queries":2},"citations":["https://a.example","https://b.example"]}`,
+			cost:       2.15,
+			prompt:     10,
+			completion: 5,
+			citations:  2,
+		},
+		{
+			name:       "perplexity stream citations",
+			provider:   newPerplexityProvider(false, false, compatibleEstimatorStub{}),
+			stream:     true,
+			response:   "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hello\"}}],\"citations\":[\"https://a.example\"]}\n\ndata: {\"choices\":[],\"citations\":[\"https://a.example\"],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5}}\n\ndata: [DONE]\n\n",
+			cost:       0.15,
+			prompt:     10,
+			completion: 5,
+			citations:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			tt.provider.url = server.URL
+
+			w := closeNotifyingRecorder{httptest.NewRecorder()}
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+			util.SetLogToCtx(c, zap.NewNop())
+			c.Set("requestTimeout", time.Second)
+			c.Set("stream", tt.stream)
+			c.Set("groqRequest", request)
+
+			getOpenAiCompatibleChatCompletionsHandler(false, false, http.Client{}, tt.provider)(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.InDelta(t, tt.cost, c.GetFloat64("costInUsd"), 1e-9)
+			assert.Equal(t, tt.prompt, c.GetInt("promptTokenCount"))
+			assert.Equal(t, tt.completion, c.GetInt("completionTokenCount"))
+
+			if tt.citations == 0 {
+				return
+			}
+
+			citations, ok := c.Value("citations").([]*perplexity.Citation)
+			require.True(t, ok)
+			assert.Len(t, citations, tt.citations)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openrouter"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type openrouterEstimator interface {
+	EstimateUsageCost(usage *openrouter.Usage) (float64, error)
+	Count(input string) int
+}
+
+func newOpenrouterUsage(usage *openrouter.Usage) *compatibleUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &compatibleUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		ReasoningTokens:  usage.ReasoningTokens(),
+		Details:          usage,
+	}
+}
+
+func newOpenrouterProvider(oe openrouterEstimator) *compatibleProvider {
+	return &compatibleProvider{
+		name: "openrouter",
+		url:  openrouter.ChatCompletionsUrl,
+		decodeResponse: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionResponse, *compatibleUsage, error) {
+			chatRes := &openrouter.ChatCompletionResponse{}
+			if err := json.Unmarshal(data, chatRes); err != nil {
+				return nil, nil, err
+			}
+
+			return &chatRes.ChatCompletionResponse, newOpenrouterUsage(chatRes.Usage), nil
+		},
+		decodeChunk: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionStreamResponse, *compatibleUsage, error) {
+			chunk := &openrouter.ChatCompletionStreamResponse{}
+			if err := json.Unmarshal(data, chunk); err != nil {
+				return nil, nil, err
+			}
+
+			// openrouter reports the usage and the cost of the request in the last chunk.
+			return &chunk.ChatCompletionStreamResponse, newOpenrouterUsage(chunk.Usage), nil
+		},
+		// the cost billed by OpenRouter is preferred over the cost map of the
+		// provider setting since OpenRouter prices requests according to the
+		// upstream provider serving them.
+		estimateCost: func(c *gin.Context, model string, usage *compatibleUsage) (float64, error) {
+			details, _ := usage.Details.(*openrouter.Usage)
+			cost, err := oe.EstimateUsageCost(details)
+			if err == nil {
+				return cost, nil
+			}
+
+			if cost, ok := estimateCostWithCostMap(c, model, usage); ok {
+				return cost, nil
+			}
+
+			return 0, err
+		},
+		count: oe.Count,
+		countRequest: func(c *gin.Context) int {
+			r, _ := c.Value("openrouterRequest").(*goopenai.ChatCompletionRequest)
+			return provider.CountChatRequestTokens(r, oe.Count)
+		},
+	}
+}
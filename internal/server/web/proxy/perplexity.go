@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/perplexity"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type perplexityEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	EstimateSearchCost(model string, usage *perplexity.Usage) float64
+	Count(input string) int
+}
+
+// setPerplexityCitations records the citations of a response on the event.
+func setPerplexityCitations(c *gin.Context, log *zap.Logger, prod, private bool, urls []string, results []*perplexity.SearchResult) {
+	citations := perplexity.NewCitations(urls, results)
+	if len(citations) == 0 {
+		return
+	}
+
+	c.Set("citations", citations)
+	logPerplexityCitations(log, prod, private, citations)
+}
+
+func logPerplexityCitations(log *zap.Logger, prod, private bool, citations []*perplexity.Citation) {
+	if prod {
+		fields := []zapcore.Field{
+			zap.Time("createdAt", time.Now()),
+			zap.Int("count", len(citations)),
+		}
+
+		if !private {
+			urls := []string{}
+			for _, citation := range citations {
+				urls = append(urls, citation.Url)
+			}
+
+			fields = append(fields, zap.Strings("urls", urls))
+		}
+
+		log.Info("perplexity citations", fields...)
+	}
+}
+
+func newPerplexityUsage(usage *perplexity.Usage) *compatibleUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &compatibleUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Details:          usage,
+	}
+}
+
+func newPerplexityProvider(prod, private bool, pe perplexityEstimator) *compatibleProvider {
+	return &compatibleProvider{
+		name: "perplexity",
+		url:  perplexity.ChatCompletionsUrl,
+		decodeResponse: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionResponse, *compatibleUsage, error) {
+			chatRes := &perplexity.ChatCompletionResponse{}
+			if err := json.Unmarshal(data, chatRes); err != nil {
+				return nil, nil, err
+			}
+
+			usage := &compatibleUsage{Details: &perplexity.Usage{}}
+			if chatRes.Usage != nil {
+				usage = newPerplexityUsage(chatRes.Usage)
+				chatRes.ChatCompletionResponse.Usage = goopenai.Usage{
+					PromptTokens:     usage.PromptTokens,
+					CompletionTokens: usage.CompletionTokens,
+					TotalTokens:      chatRes.Usage.TotalTokens,
+				}
+			}
+
+			setPerplexityCitations(c, util.GetLogFromCtx(c), prod, private, chatRes.Citations, chatRes.SearchResults)
+
+			return &chatRes.ChatCompletionResponse, usage, nil
+		},
+		// the citations of a stream are recorded once it ended.
+		decodeChunk: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionStreamResponse, *compatibleUsage, error) {
+			chunk := &perplexity.ChatCompletionStreamResponse{}
+			if err := json.Unmarshal(data, chunk); err != nil {
+				return nil, nil, err
+			}
+
+			if len(chunk.Citations) != 0 {
+				c.Set("perplexityCitations", chunk.Citations)
+			}
+
+			if len(chunk.SearchResults) != 0 {
+				c.Set("perplexitySearchResults", chunk.SearchResults)
+			}
+
+			return &chunk.ChatCompletionStreamResponse, newPerplexityUsage(chunk.Usage), nil
+		},
+		finishStream: func(c *gin.Context) {
+			urls, _ := c.Value("perplexityCitations").([]string)
+			results, _ := c.Value("perplexitySearchResults").([]*perplexity.SearchResult)
+			setPerplexityCitations(c, util.GetLogFromCtx(c), prod, private, urls, results)
+		},
+		// the cost of the searches performed by Perplexity is added to the
+		// token cost of the request.
+		estimateCost: func(c *gin.Context, model string, usage *compatibleUsage) (float64, error) {
+			details, _ := usage.Details.(*perplexity.Usage)
+			if details == nil {
+				details = &perplexity.Usage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens}
+			}
+
+			searchCost := pe.EstimateSearchCost(model, details)
+			if cost, ok := estimateCostWithCostMap(c, model, usage); ok {
+				return cost + searchCost, nil
+			}
+
+			cost, err := pe.EstimateTotalCost(model, usage.PromptTokens, usage.CompletionTokens)
+			if err != nil {
+				return 0, err
+			}
+
+			return cost + searchCost, nil
+		},
+		count: pe.Count,
+		countRequest: func(c *gin.Context) int {
+			r, ok := c.Value("perplexityRequest").(*perplexity.ChatRequest)
+			if !ok || r == nil {
+				return 0
+			}
+
+			return provider.CountChatRequestTokens(&r.ChatCompletionRequest, pe.Count)
+		},
+	}
+}
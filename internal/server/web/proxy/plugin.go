@@ -0,0 +1,248 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+type texter interface {
+	Texts() []string
+}
+
+// estimatePluginUsage approximates the usage of responses that do not report
+// it from the texts of the request and the content of the response.
+func estimatePluginUsage(c *gin.Context, p provider.Plugin, usage *provider.Usage, content string) *provider.Usage {
+	if usage == nil {
+		usage = &provider.Usage{}
+	}
+
+	if usage.PromptTokens == 0 {
+		if raw, ok := c.Get("pluginRequest"); ok {
+			if pr, ok := raw.(*provider.PluginRequest); ok {
+				if t, ok := pr.Body.(texter); ok {
+					for _, text := range t.Texts() {
+						usage.PromptTokens += p.CountTokens(text)
+					}
+				}
+			}
+		}
+	}
+
+	if usage.CompletionTokens == 0 && len(content) != 0 {
+		usage.CompletionTokens = p.CountTokens(content)
+	}
+
+	return usage
+}
+
+// estimatePluginCost prefers the cost reported by the provider, then the cost
+// map of the provider setting and then the pricing of the plugin.
+func estimatePluginCost(c *gin.Context, p provider.Plugin, ep *provider.Endpoint, model string, usage *provider.Usage) (float64, error) {
+	if usage.CostInUsd != 0 {
+		return usage.CostInUsd, nil
+	}
+
+	m, exists := c.Get("cost_map")
+	if exists {
+		converted, ok := m.(*provider.CostMap)
+		if ok {
+			var cost float64
+			var err error
+			if ep.Kind == provider.EmbeddingsEndpoint {
+				cost, err = provider.EstimateCostWithCostMap(model, usage.PromptTokens, 1000, converted.EmbeddingsCostPerModel)
+			} else {
+				cost, err = provider.EstimateTotalCostWithCostMaps(model, usage.PromptTokens, usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+			}
+
+			if err == nil && cost != 0 {
+				return cost, nil
+			}
+		}
+	}
+
+	return p.EstimateCost(ep, model, usage)
+}
+
+func setPluginUsage(c *gin.Context, log *zap.Logger, prod bool, p provider.Plugin, ep *provider.Endpoint, tags []string, usage *provider.Usage) {
+	cost, err := estimatePluginCost(c, p, ep, c.GetString("model"), usage)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.get_plugin_handler.estimate_total_cost_error", tags, 1)
+		logError(log, fmt.Sprintf("error when estimating %s cost", p.Name()), prod, err)
+	}
+
+	c.Set("costInUsd", cost)
+	c.Set("promptTokenCount", usage.PromptTokens)
+	c.Set("completionTokenCount", usage.CompletionTokens)
+}
+
+// getPluginHandler proxies requests to an endpoint of a provider plugin.
+func getPluginHandler(prod bool, client http.Client, p provider.Plugin, ep *provider.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		tags := []string{
+			fmt.Sprintf("provider:%s", p.Name()),
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_plugin_handler.requests", tags, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.Url, c.Request.Body)
+		if err != nil {
+			logError(log, fmt.Sprintf("error when creating %s http request", p.Name()), prod, err)
+			JSON(c, http.StatusInternalServerError, fmt.Sprintf("[BricksLLM] failed to create %s http request", p.Name()))
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		decoder := p.NewStreamDecoder(ep)
+		isStreaming := c.GetBool("stream") && decoder != nil
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_plugin_handler.http_client_error", tags, 1)
+
+			logError(log, fmt.Sprintf("error when sending http request to %s", p.Name()), prod, err)
+			JSON(c, http.StatusInternalServerError, fmt.Sprintf("[BricksLLM] failed to send http request to %s", p.Name()))
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		if res.StatusCode != http.StatusOK || !isStreaming {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_plugin_handler.latency", dur, tags, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, fmt.Sprintf("error when reading %s response body", p.Name()), prod, err)
+				JSON(c, http.StatusInternalServerError, fmt.Sprintf("[BricksLLM] failed to read %s response body", p.Name()))
+				return
+			}
+
+			if res.StatusCode != http.StatusOK {
+				telemetry.Incr("bricksllm.proxy.get_plugin_handler.error_response", tags, 1)
+				c.Data(res.StatusCode, "application/json", data)
+				return
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_plugin_handler.success", tags, 1)
+			telemetry.Timing("bricksllm.proxy.get_plugin_handler.success_latency", dur, tags, 1)
+
+			usage, err := p.ParseUsage(ep, data)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_plugin_handler.parse_usage_error", tags, 1)
+				logError(log, fmt.Sprintf("error when parsing %s response usage", p.Name()), prod, err)
+			}
+
+			setPluginUsage(c, log, prod, p, ep, tags, estimatePluginUsage(c, p, usage, ""))
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		buffer := bufio.NewReader(res.Body)
+		streamingResponse := [][]byte{}
+		defer func() {
+			content := decoder.Content()
+			c.Set("content", content)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+
+			setPluginUsage(c, log, prod, p, ep, tags, estimatePluginUsage(c, p, decoder.Usage(), content))
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_plugin_handler.streaming_requests", tags, 1)
+
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				if errors.Is(err, context.DeadlineExceeded) {
+					telemetry.Incr("bricksllm.proxy.get_plugin_handler.context_deadline_exceeded_error", tags, 1)
+					logError(log, fmt.Sprintf("context deadline exceeded when reading bytes from %s response", p.Name()), prod, err)
+
+					return false
+				}
+
+				telemetry.Incr("bricksllm.proxy.get_plugin_handler.read_bytes_error", tags, 1)
+				logError(log, fmt.Sprintf("error when reading bytes from %s response", p.Name()), prod, err)
+
+				apiErr := &goopenai.ErrorResponse{
+					Error: &goopenai.APIError{
+						Type:    "bricksllm_error",
+						Message: err.Error(),
+					},
+				}
+
+				bytes, err := json.Marshal(apiErr)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_plugin_handler.json_marshal_error", tags, 1)
+					logError(log, fmt.Sprintf("error when marshalling bytes for streaming %s error response", p.Name()), prod, err)
+					return false
+				}
+
+				c.SSEvent("", string(bytes))
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
+			c.SSEvent("", " "+string(noPrefixLine))
+
+			if string(noPrefixLine) == "[DONE]" {
+				return false
+			}
+
+			if err := decoder.Decode(noPrefixLine); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_plugin_handler.decode_error", tags, 1)
+				logError(log, fmt.Sprintf("error when decoding %s stream response", p.Name()), prod, err)
+			}
+
+			return true
+		})
+
+		telemetry.Timing("bricksllm.proxy.get_plugin_handler.streaming_latency", time.Since(start), tags, 1)
+	}
+}
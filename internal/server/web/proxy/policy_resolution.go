@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// resolvePolicy returns the policy enforced on a request. Policies attached
+// to keys take precedence over policies attached to routes, which take
+// precedence over policies attached to the tags of keys.
+func resolvePolicy(c *gin.Context, pm PoliciesManager, rm routeManager, kc *key.ResponseKey) *policy.Policy {
+	if len(kc.PolicyId) != 0 {
+		if p := pm.GetPolicyByIdFromMemdb(kc.PolicyId); p != nil {
+			telemetry.Incr("bricksllm.proxy.resolve_policy.resolved", []string{"source:key"}, 1)
+			return p
+		}
+	}
+
+	if strings.HasPrefix(c.FullPath(), "/api/routes") && rm != nil {
+		if r := rm.GetRouteFromMemDb(c.Param("route")); r != nil && len(r.PolicyId) != 0 {
+			if p := pm.GetPolicyByIdFromMemdb(r.PolicyId); p != nil {
+				telemetry.Incr("bricksllm.proxy.resolve_policy.resolved", []string{"source:route"}, 1)
+				return p
+			}
+		}
+	}
+
+	if p := pm.GetPolicyByKeyTagsFromMemdb(kc.Tags); p != nil {
+		telemetry.Incr("bricksllm.proxy.resolve_policy.resolved", []string{"source:key_tags"}, 1)
+		return p
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// recordPolicyRules counts the rules that made a policy act on a request or
+// its response and keeps them for the event of the request.
+func recordPolicyRules(c *gin.Context, p *policy.Policy, target string, rules []*policy.TriggeredRule) {
+	if len(rules) == 0 {
+		return
+	}
+
+	for _, r := range rules {
+		telemetry.Incr("bricksllm.proxy.record_policy_rules.rule_triggered", []string{
+			"policy_id:" + p.Id,
+			"rule:" + r.Rule,
+			"action:" + string(r.Action),
+			"target:" + target,
+		}, 1)
+	}
+
+	recorded := []*policy.TriggeredRule{}
+	if raw, ok := c.Get("policy_rules"); ok {
+		if existing, ok := raw.([]*policy.TriggeredRule); ok {
+			recorded = existing
+		}
+	}
+
+	c.Set("policy_rules", append(recorded, rules...))
+}
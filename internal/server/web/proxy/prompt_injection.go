@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// promptInjectionTagPrefix is prepended to the names of the heuristics
+	// that detected a prompt injection in the request tags of events.
+	promptInjectionTagPrefix = "prompt_injection:"
+
+	// promptInjectionBlockedReason is the error code of requests blocked for
+	// prompt injection so that clients can tell them apart from other
+	// blocked requests.
+	promptInjectionBlockedReason = "prompt_injection_detected"
+)
+
+// detectPromptInjection checks the texts of a request for prompt injections
+// and applies the prompt injection config of the key's policy. It returns
+// false if the request was blocked.
+func detectPromptInjection(c *gin.Context, log *zap.Logger, p *policy.Policy, kc *key.ResponseKey, input any, cd CustomPolicyDetector, cid string) bool {
+	action, rules := p.DetectPromptInjection(policy.PromptInjectionInput(input), cd, log)
+	if len(rules) != 0 {
+		c.Set("prompt_injection_rules", rules)
+	}
+
+	switch action {
+	case policy.Block:
+		telemetry.Incr("bricksllm.proxy.detect_prompt_injection.blocked", nil, 1)
+		c.Set("action", "blocked")
+
+		log.Info("request blocked due to prompt injection",
+			zap.String("keyId", kc.KeyId),
+			zap.String("policyId", p.Id),
+			zap.Strings("rules", rules),
+		)
+
+		templatedJSONWithReason(c, errortemplate.TypeBlocked, http.StatusForbidden, promptInjectionBlockedReason, blockMessage(kc, cid, "[BricksLLM] request blocked due to prompt injection"))
+		return false
+	case policy.AllowButWarn:
+		telemetry.Incr("bricksllm.proxy.detect_prompt_injection.warned", nil, 1)
+		return warnRequest(c, log, p, kc, cid, warningKindPromptInjection, "request warned due to prompt injection")
+	}
+
+	return true
+}
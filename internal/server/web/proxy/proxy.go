@@ -9,6 +9,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/bricks-cloud/bricksllm/internal/event"
@@ -17,6 +18,8 @@ import (
 	"github.com/bricks-cloud/bricksllm/internal/policy"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
@@ -33,6 +36,7 @@ type ProviderSettingsManager interface {
 
 type PoliciesManager interface {
 	GetPolicyByIdFromMemdb(id string) *policy.Policy
+	GetPolicyByKeyTagsFromMemdb(tags []string) *policy.Policy
 }
 
 type ProxyServer struct {
@@ -79,16 +83,17 @@ func CorsMiddleware() gin.HandlerFunc {
 	}
 }
 
-func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyManager, rm routeManager, a authenticator, psm ProviderSettingsManager, cpm CustomProvidersManager, ks keyStorage, e estimator, ae anthropicEstimator, aoe azureEstimator, v validator, r recorder, pub publisher, rlm rateLimitManager, timeout time.Duration, ac accessCache, uac userAccessCache, pm PoliciesManager, scanner Scanner, cd CustomPolicyDetector, die deepinfraEstimator, um userManager, removeAgentHeaders bool) (*ProxyServer, error) {
+func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyManager, rm routeManager, a authenticator, psm ProviderSettingsManager, cpm CustomProvidersManager, mr modelRemapper, ks keyStorage, e estimator, ae anthropicEstimator, aoe azureEstimator, v validator, r recorder, pub publisher, rlm rateLimitManager, timeout time.Duration, ac accessCache, uac userAccessCache, pm PoliciesManager, scanner Scanner, cd CustomPolicyDetector, die deepinfraEstimator, ve vertexEstimator, ge geminiEstimator, me mistralEstimator, coe cohereEstimator, gre groqEstimator, pe perplexityEstimator, dse deepseekEstimator, xe xaiEstimator, ore openrouterEstimator, um userManager, removeAgentHeaders bool, clampMaxTokens bool, contextWindowSiblings map[string]string, ss sessionStorage, ts threadStorage, bs batchStorage) (*ProxyServer, error) {
 	router := gin.New()
 	prod := mode == "production"
 	private := privacyMode == "strict"
 
 	router.Use(CorsMiddleware())
 	router.Use(getTimeoutMiddleware(timeout))
-	router.Use(getMiddleware(cpm, rm, pm, a, prod, private, log, pub, "proxy", ac, uac, http.Client{}, scanner, cd, um, removeAgentHeaders))
+	router.Use(getMiddleware(cpm, mr, rm, pm, a, prod, private, log, pub, "proxy", ac, uac, http.Client{}, scanner, cd, um, removeAgentHeaders, e, ae, clampMaxTokens, contextWindowSiblings, ss, v))
 
 	client := http.Client{}
+	sm := signer.NewManager(client)
 
 	// health check
 	router.POST("/api/health", getGetHealthCheckHandler())
@@ -96,6 +101,15 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	// health check
 	router.GET("/api/health", getGetHealthCheckHandler())
 
+	// tokens
+	router.POST("/api/tokens/count", getCountTokensHandler(prod, e, ae))
+
+	// costs
+	router.POST("/api/costs/estimate", getEstimateCostHandler(prod, e, ae, aoe))
+
+	// models
+	router.GET("/v1/models", getListModelsHandler(prod, e))
+
 	// audios
 	router.POST("/api/providers/openai/v1/audio/speech", getSpeechHandler(prod, client))
 	router.POST("/api/providers/openai/v1/audio/transcriptions", getTranscriptionsHandler(prod, client, e))
@@ -129,7 +143,7 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.GET("/api/providers/openai/v1/assistants/:assistant_id/files", getPassThroughHandler(prod, private, client))
 
 	// threads
-	router.POST("/api/providers/openai/v1/threads", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/threads", getThreadPassThroughHandler(prod, private, client, e, ts))
 	router.GET("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client))
 	router.POST("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client))
 	router.DELETE("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client))
@@ -145,13 +159,13 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.GET("/api/providers/openai/v1/threads/:thread_id/messages/:message_id/files", getPassThroughHandler(prod, private, client))
 
 	// runs
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs", getThreadPassThroughHandler(prod, private, client, e, ts))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getThreadPassThroughHandler(prod, private, client, e, ts))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getThreadPassThroughHandler(prod, private, client, e, ts))
 	router.GET("/api/providers/openai/v1/threads/:thread_id/runs", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/submit_tool_outputs", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/cancel", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/runs", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/submit_tool_outputs", getThreadPassThroughHandler(prod, private, client, e, ts))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/cancel", getThreadPassThroughHandler(prod, private, client, e, ts))
+	router.POST("/api/providers/openai/v1/threads/runs", getThreadPassThroughHandler(prod, private, client, e, ts))
 	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/steps/:step_id", getPassThroughHandler(prod, private, client))
 	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/steps", getPassThroughHandler(prod, private, client))
 
@@ -163,13 +177,13 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.GET("/api/providers/openai/v1/files/:file_id/content", getPassThroughHandler(prod, private, client))
 
 	// batch
-	router.POST("/api/providers/openai/v1/batches", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/batches/:batch_id", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/batches/:batch_id/cancel", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/batches", getBatchPassThroughHandler(prod, private, client, e, bs))
+	router.GET("/api/providers/openai/v1/batches/:batch_id", getBatchPassThroughHandler(prod, private, client, e, bs))
+	router.POST("/api/providers/openai/v1/batches/:batch_id/cancel", getBatchPassThroughHandler(prod, private, client, e, bs))
 	router.GET("/api/providers/openai/v1/batches", getPassThroughHandler(prod, private, client))
 
 	// images
-	router.POST("/api/providers/openai/v1/images/generations", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/images/generations", getCreateImageHandler(prod, private, client, e))
 	router.POST("/api/providers/openai/v1/images/edits", getPassThroughHandler(prod, private, client))
 	router.POST("/api/providers/openai/v1/images/variations", getPassThroughHandler(prod, private, client))
 
@@ -195,11 +209,51 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.POST("/api/providers/deepinfra/v1/completions", getDeepinfraCompletionsHandler(prod, private, client))
 	router.POST("/api/providers/deepinfra/v1/embeddings", getDeepinfraEmbeddingsHandler(prod, private, client, die))
 
+	// vertex ai
+	router.POST("/api/providers/vertexai/v1/publishers/:publisher/models/:model", getVertexHandler(prod, client, ve, sm))
+
+	// gemini
+	router.POST("/api/providers/gemini/:version/models/:model", getGeminiHandler(prod, client, ge))
+
+	// mistral
+	router.POST("/api/providers/mistral/v1/chat/completions", getOpenAiCompatibleChatCompletionsHandler(prod, private, client, newMistralProvider(me)))
+	router.POST("/api/providers/mistral/v1/embeddings", getMistralEmbeddingsHandler(prod, private, client, me))
+
+	// cohere
+	router.POST("/api/providers/cohere/v2/chat", getCohereChatHandler(prod, client, coe))
+	router.POST("/api/providers/cohere/v2/embed", getCohereEmbedHandler(prod, client, coe))
+	router.POST("/api/providers/cohere/v2/rerank", getCohereRerankHandler(prod, client, coe))
+
+	// groq
+	router.POST("/api/providers/groq/v1/chat/completions", getOpenAiCompatibleChatCompletionsHandler(prod, private, client, newGroqProvider(gre)))
+
+	// perplexity
+	router.POST("/api/providers/perplexity/chat/completions", getOpenAiCompatibleChatCompletionsHandler(prod, private, client, newPerplexityProvider(prod, private, pe)))
+
+	// deepseek
+	router.POST("/api/providers/deepseek/chat/completions", getOpenAiCompatibleChatCompletionsHandler(prod, private, client, newDeepseekProvider(dse)))
+
+	// xai
+	router.POST("/api/providers/xai/v1/chat/completions", getOpenAiCompatibleChatCompletionsHandler(prod, private, client, newXaiProvider(xe)))
+
+	// openrouter
+	router.POST("/api/providers/openrouter/v1/chat/completions", getOpenAiCompatibleChatCompletionsHandler(prod, private, client, newOpenrouterProvider(ore)))
+
+	// provider plugins
+	for _, p := range provider.GetPlugins() {
+		for _, ep := range p.Endpoints() {
+			router.POST(p.RoutePrefix()+ep.Path, getPluginHandler(prod, client, p, ep))
+		}
+	}
+
+	// unified
+	router.POST(unifiedChatCompletionsPath, getUnifiedChatCompletionsHandler(prod, private, client, e, ae))
+
 	// custom provider
-	router.POST("/api/custom/providers/:provider/*wildcard", getCustomProviderHandler(prod, client))
+	router.POST("/api/custom/providers/:provider/*wildcard", getCustomProviderHandler(prod, client, sm))
 
 	// custom route
-	router.POST("/api/routes/*route", getRouteHandler(prod, c, aoe, e, client, r))
+	router.POST("/api/routes/*route", getRouteHandler(prod, c, aoe, e, ae, client, r))
 
 	// vector store
 	router.POST("/api/providers/openai/v1/vector_stores", getCreateVectorStoreHandler(prod, client))
@@ -330,6 +384,26 @@ func getPassThroughHandler(prod, private bool, client http.Client) gin.HandlerFu
 				return
 			}
 
+			// files expire after the duration of the file policy of the key unless the upload sets its own expiration.
+			overWrites := map[string]string{}
+			if raw, ok := c.Get("key"); ok {
+				if kc, ok := raw.(*key.ResponseKey); ok && kc.FilePolicy != nil && kc.FilePolicy.ExpiresAfterInSeconds != 0 && len(c.PostForm("expires_after[seconds]")) == 0 {
+					overWrites["expires_after[anchor]"] = "created_at"
+					overWrites["expires_after[seconds]"] = strconv.Itoa(kc.FilePolicy.ExpiresAfterInSeconds)
+				}
+			}
+
+			err = writeFieldToBuffer([]string{
+				"expires_after[anchor]",
+				"expires_after[seconds]",
+			}, c, writer, overWrites)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_pass_through_handler.write_field_to_buffer_error", tags, 1)
+				logError(log, "error when writing field to buffer", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] cannot write field to buffer")
+				return
+			}
+
 			var form Form
 			c.ShouldBind(&form)
 
@@ -525,6 +599,8 @@ func getPassThroughHandler(prod, private bool, client http.Client) gin.HandlerFu
 			telemetry.Incr("bricksllm.proxy.get_pass_through_handler.success", tags, 1)
 			telemetry.Timing("bricksllm.proxy.get_pass_through_handler.success_latency", dur, tags, 1)
 
+			c.Set("passThroughResponse", bytes)
+
 			if c.FullPath() == "/api/providers/openai/v1/assistants" && c.Request.Method == http.MethodPost {
 				logAssistantResponse(log, bytes, prod, private)
 			}
@@ -639,6 +715,10 @@ func getPassThroughHandler(prod, private bool, client http.Client) gin.HandlerFu
 
 			if c.FullPath() == "/api/providers/openai/v1/moderations" && c.Request.Method == http.MethodPost {
 				logCreateModerationResponse(log, bytes, prod)
+
+				if flagged := openai.FlaggedModerationFindings(openai.ParseModerationFindings(bytes)); len(flagged) != 0 {
+					c.Set("content_filter_findings", flagged)
+				}
 			}
 
 			if c.FullPath() == "/api/providers/openai/v1/models" && c.Request.Method == http.MethodGet {
@@ -920,6 +1000,15 @@ func (ps *ProxyServer) Run() {
 		// health check
 		ps.log.Info("PORT 8002 | GET    | /api/health is ready")
 
+		// tokens
+		ps.log.Info("PORT 8002 | POST   | /api/tokens/count is ready for counting prompt tokens")
+
+		// costs
+		ps.log.Info("PORT 8002 | POST   | /api/costs/estimate is ready for estimating request costs")
+
+		// models
+		ps.log.Info("PORT 8002 | GET    | /v1/models is ready for listing models available to a key")
+
 		// audio
 		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/audio/speech is ready for creating openai speeches")
 		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/audio/transcriptions is ready for creating openai transcriptions")
@@ -1010,6 +1099,46 @@ func (ps *ProxyServer) Run() {
 		ps.log.Info("PORT 8002 | POST   | /api/providers/deepinfra/v1/completions is ready for forwarding deepinfra completions requests")
 		ps.log.Info("PORT 8002 | POST   | /api/providers/deepinfra/v1/embeddings is ready for forwarding deepinfra embeddings requests")
 
+		// vertex ai
+		ps.log.Info("PORT 8002 | POST   | /api/providers/vertexai/v1/publishers/:publisher/models/:model is ready for forwarding vertex ai generate content and partner model requests")
+
+		// gemini
+		ps.log.Info("PORT 8002 | POST   | /api/providers/gemini/:version/models/:model is ready for forwarding gemini generate content requests")
+
+		// mistral
+		ps.log.Info("PORT 8002 | POST   | /api/providers/mistral/v1/chat/completions is ready for forwarding mistral chat completions requests")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/mistral/v1/embeddings is ready for forwarding mistral embeddings requests")
+
+		// cohere
+		ps.log.Info("PORT 8002 | POST   | /api/providers/cohere/v2/chat is ready for forwarding cohere chat requests")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/cohere/v2/embed is ready for forwarding cohere embed requests")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/cohere/v2/rerank is ready for forwarding cohere rerank requests")
+
+		// groq
+		ps.log.Info("PORT 8002 | POST   | /api/providers/groq/v1/chat/completions is ready for forwarding groq chat completions requests")
+
+		// perplexity
+		ps.log.Info("PORT 8002 | POST   | /api/providers/perplexity/chat/completions is ready for forwarding perplexity chat completions requests")
+
+		// deepseek
+		ps.log.Info("PORT 8002 | POST   | /api/providers/deepseek/chat/completions is ready for forwarding deepseek chat completions requests")
+
+		// xai
+		ps.log.Info("PORT 8002 | POST   | /api/providers/xai/v1/chat/completions is ready for forwarding xai chat completions requests")
+
+		// openrouter
+		ps.log.Info("PORT 8002 | POST   | /api/providers/openrouter/v1/chat/completions is ready for forwarding openrouter chat completions requests")
+
+		// provider plugins
+		for _, p := range provider.GetPlugins() {
+			for _, ep := range p.Endpoints() {
+				ps.log.Info(fmt.Sprintf("PORT 8002 | POST   | %s%s is ready for forwarding %s requests", p.RoutePrefix(), ep.Path, p.Name()))
+			}
+		}
+
+		// unified
+		ps.log.Info("PORT 8002 | POST   | /api/v1/chat/completions is ready for forwarding openai chat completions requests to the provider of the requested model")
+
 		// custom provider
 		ps.log.Info("PORT 8002 | POST   | /api/custom/providers/:provider/*wildcard is ready for forwarding requests to custom providers")
 
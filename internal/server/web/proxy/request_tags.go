@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const maxRequestTagLength = 255
+
+// parseRequestTags parses tags passed via the X-BRICKSLLM-TAGS header. Tags can
+// either be comma separated or a JSON array of strings. Empty, duplicated and
+// overly long tags are dropped.
+func parseRequestTags(header string) []string {
+	header = strings.TrimSpace(header)
+	if len(header) == 0 {
+		return []string{}
+	}
+
+	raw := []string{}
+	if strings.HasPrefix(header, "[") {
+		if err := json.Unmarshal([]byte(header), &raw); err != nil {
+			return []string{}
+		}
+	} else {
+		raw = strings.Split(header, ",")
+	}
+
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range raw {
+		tag = strings.TrimSpace(tag)
+		if len(tag) == 0 || len(tag) > maxRequestTagLength || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// piiScannerFailedTagPrefix tags events of requests whose PII scanner failed
+// with the failure mode applied by their policy.
+const piiScannerFailedTagPrefix = "pii_scanner_failed:"
+
+// promptTruncatedTag tags events of requests whose oldest messages were
+// dropped to fit the size limits of their policy.
+const promptTruncatedTag = "prompt_truncated"
+
+// heldResponseWriter holds the status and body written by handlers so that
+// responses can be scanned by policies before they reach the client. Headers
+// are written to the underlying writer directly.
+type heldResponseWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    *bytes.Buffer
+}
+
+func holdResponse(c *gin.Context) *heldResponseWriter {
+	held := &heldResponseWriter{
+		ResponseWriter: c.Writer,
+		status:         http.StatusOK,
+		body:           bytes.NewBufferString(""),
+	}
+
+	c.Writer = held
+	return held
+}
+
+func (w *heldResponseWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *heldResponseWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *heldResponseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *heldResponseWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *heldResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *heldResponseWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+
+	return w.body.Len()
+}
+
+func (w *heldResponseWriter) Written() bool {
+	return w.written
+}
+
+func (w *heldResponseWriter) Flush() {}
+
+// filterResponse scans the held response of a request with the policy of its
+// key and writes the response, redacted if needed, to the client. Blocked
+// responses are replaced by a blocked error. Values redacted reversibly from
+// the request are restored in the response after it is scanned.
+func filterResponse(c *gin.Context, log *zap.Logger, prod bool, held *heldResponseWriter, p *policy.Policy, kc *key.ResponseKey, scanner Scanner, cd CustomPolicyDetector, cid string, tokens *policy.Tokens) {
+	c.Writer = held.ResponseWriter
+
+	data := held.body.Bytes()
+	if held.status == http.StatusOK && p.ShouldScanResponses() {
+		filtered, rules, err := p.FilterResponseWithRules(data, scanner, cd, log)
+		recordPolicyRules(c, p, "response", rules)
+
+		if err != nil {
+			if _, ok := err.(blockedError); ok {
+				c.Set("action", "blocked")
+				telemetry.Incr("bricksllm.proxy.filter_response.response_blocked", nil, 1)
+
+				log.Info("response blocked",
+					zap.String("keyId", kc.KeyId),
+					zap.String("policyId", p.Id),
+					zap.String("reason", err.Error()),
+				)
+
+				c.Writer.Header().Del("Content-Length")
+				templatedJSON(c, errortemplate.TypeBlocked, http.StatusForbidden, blockMessage(kc, cid, "[BricksLLM] response blocked"))
+				return
+			}
+
+			if _, ok := err.(warnedError); ok {
+				c.Set("action", "warned")
+				telemetry.Incr("bricksllm.proxy.filter_response.response_warned", nil, 1)
+			}
+
+			if _, ok := err.(redactedError); ok {
+				c.Set("action", "redacted")
+				telemetry.Incr("bricksllm.proxy.filter_response.response_redacted", nil, 1)
+			}
+
+			logError(log, "error when filtering a response", prod, err)
+		}
+
+		data = filtered
+	}
+
+	if held.status == http.StatusOK && tokens.Len() != 0 {
+		telemetry.Incr("bricksllm.proxy.filter_response.response_detokenized", nil, 1)
+		data = tokens.DetokenizeJson(data)
+	}
+
+	// redacted bodies differ in length from upstream responses.
+	c.Writer.Header().Del("Content-Length")
+	c.Writer.WriteHeader(held.status)
+	if held.written {
+		c.Writer.Write(data)
+	}
+}
@@ -9,11 +9,13 @@ import (
 
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
+	"strings"
 )
 
 type routeManager interface {
@@ -25,7 +27,15 @@ type cache interface {
 	GetBytes(key string) ([]byte, error)
 }
 
-func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, client http.Client, rec recorder) gin.HandlerFunc {
+func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, ae anthropicEstimator, client http.Client, rec recorder) gin.HandlerFunc {
+	latencies := route.NewLatencyTracker()
+	estimators := map[string]route.CostEstimator{
+		"openai":    e,
+		"azure":     aoe,
+		"anthropic": ae,
+		"bedrock":   &bedrockRouteEstimator{ae: ae},
+	}
+
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		trueStart := time.Now()
@@ -99,6 +109,9 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 			PolicyId:      c.GetString("policyId"),
 			Action:        c.GetString("action"),
 			CorrelationId: cid,
+			Counter:       e,
+			Latencies:     latencies,
+			Estimators:    estimators,
 		}
 
 		val, exists := c.Get("requestBytes")
@@ -119,6 +132,22 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 
 		c.Set("model", runRes.Model)
 		c.Set("provider", runRes.Provider)
+		if len(runRes.SplitTag) != 0 {
+			c.Set("route_split_tag", runRes.SplitTag)
+		}
+
+		if len(runRes.RuleTag) != 0 {
+			c.Set("routing_rule_tag", runRes.RuleTag)
+		}
+
+		c.Set("retryCount", runRes.Retries)
+
+		if runRes.GuardrailIntervention != nil {
+			c.Set("guardrail_intervention", runRes.GuardrailIntervention)
+			if runRes.GuardrailIntervention.Action == anthropic.BedrockGuardrailIntervened {
+				c.Set("action", "blocked")
+			}
+		}
 
 		res := runRes.Response
 
@@ -155,12 +184,17 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 
 			}
 
-			err = parseResult(c, rc.ShouldRunEmbeddings(), bytes, e, aoe, runRes.Model, runRes.Provider)
+			err = parseResult(c, rc.ShouldRunEmbeddings(), bytes, e, aoe, ae, runRes.Model, runRes.Provider)
 			if err != nil {
 				logError(log, "error when parsing run steps result", prod, err)
 			}
 		}
 
+		// summaries of truncated conversations are charged to the key.
+		if runRes.SummaryCostInUsd != 0 {
+			c.Set("costInUsd", c.GetFloat64("costInUsd")+runRes.SummaryCostInUsd)
+		}
+
 		if res.StatusCode != http.StatusOK {
 			telemetry.Timing("bricksllm.proxy.get_route_handeler.error_latency", dur, nil, 1)
 			telemetry.Incr("bricksllm.proxy.get_route_handeler.error_response", nil, 1)
@@ -184,7 +218,7 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 	}
 }
 
-func parseResult(c *gin.Context, runEmbeddings bool, bytes []byte, e estimator, aoe azureEstimator, model, provider string) error {
+func parseResult(c *gin.Context, runEmbeddings bool, bytes []byte, e estimator, aoe azureEstimator, ae anthropicEstimator, model, provider string) error {
 	base64ChatRes := &EmbeddingResponseBase64{}
 	chatRes := &EmbeddingResponse{}
 
@@ -271,6 +305,16 @@ func parseResult(c *gin.Context, runEmbeddings bool, bytes []byte, e estimator,
 			if err != nil {
 				return err
 			}
+		} else if provider == "anthropic" {
+			cost, err = ae.EstimateTotalCost(chatRes.Model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens)
+			if err != nil {
+				return err
+			}
+		} else if provider == "bedrock" {
+			cost, err = (&bedrockRouteEstimator{ae: ae}).EstimateTotalCost(model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens)
+			if err != nil {
+				return err
+			}
 		}
 
 		// micros := int64(cost * 1000000)
@@ -282,3 +326,18 @@ func parseResult(c *gin.Context, runEmbeddings bool, bytes []byte, e estimator,
 
 	return nil
 }
+
+// bedrockRouteEstimator prices bedrock route steps with the Anthropic prices
+// of their models.
+type bedrockRouteEstimator struct {
+	ae anthropicEstimator
+}
+
+func (be *bedrockRouteEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	// model ids of inference profiles are prefixed with their region.
+	if idx := strings.Index(model, "anthropic."); idx > 0 {
+		model = model[idx:]
+	}
+
+	return be.ae.EstimateTotalCost(util.TranslateBedrockModelToAnthropicModel(model), promptTks, completionTks)
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+// structuredOutputInvalidTag is added to the request tags of events whose
+// responses do not conform to the declared response format.
+const structuredOutputInvalidTag = "structured_output_invalid"
+
+// newRetrySender returns a function sending a copy of a request with the same
+// body and headers. Responses with a status other than 200 are errors.
+func newRetrySender(ctx context.Context, client http.Client, req *http.Request, body []byte) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		retryReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		retryReq.Header = req.Header.Clone()
+
+		res, err := client.Do(retryReq)
+		if err != nil {
+			return nil, err
+		}
+
+		defer res.Body.Close()
+
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("retried request failed with status %d", res.StatusCode)
+		}
+
+		return data, nil
+	}
+}
+
+func structuredOutputViolations(rf *policy.ResponseFormat, res *goopenai.ChatCompletionResponse) []string {
+	violations := []string{}
+	for _, choice := range res.Choices {
+		// refusals of structured output requests carry no content.
+		if len(choice.Message.Refusal) != 0 {
+			continue
+		}
+
+		for _, v := range rf.Violations(choice.Message.Content) {
+			violations = append(violations, fmt.Sprintf("choices[%d]: %s", choice.Index, v))
+		}
+	}
+
+	return violations
+}
+
+// enforceStructuredOutput validates the response of a JSON mode or structured
+// output chat completion request and applies the structured output config of
+// the key's policy. Requests are sent again with resend for the retry action.
+// It returns the response to forward, the usage of the retries and true if
+// the response was replaced by a blocked error.
+func enforceStructuredOutput(c *gin.Context, log *zap.Logger, prod bool, body []byte, data []byte, res *goopenai.ChatCompletionResponse, resend func() ([]byte, error)) ([]byte, []goopenai.Usage, bool) {
+	raw, exists := c.Get("policy")
+	if !exists {
+		return data, nil, false
+	}
+
+	p, ok := raw.(*policy.Policy)
+	if !ok || !p.ShouldValidateStructuredOutput() {
+		return data, nil, false
+	}
+
+	rf := policy.ParseResponseFormat(body)
+	if rf == nil {
+		return data, nil, false
+	}
+
+	usages := []goopenai.Usage{}
+	violations := structuredOutputViolations(rf, res)
+	for attempt := 0; len(violations) != 0 && attempt < p.StructuredOutputRetries(); attempt++ {
+		telemetry.Incr("bricksllm.proxy.enforce_structured_output.retries", nil, 1)
+
+		retried, err := resend()
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.enforce_structured_output.retry_error", nil, 1)
+			logError(log, "error when retrying structured output request", prod, err)
+			break
+		}
+
+		retriedRes := &goopenai.ChatCompletionResponse{}
+		if err := json.Unmarshal(retried, retriedRes); err != nil {
+			logError(log, "error when unmarshalling retried structured output response", prod, err)
+			break
+		}
+
+		// the upstream content length belongs to the original response.
+		c.Writer.Header().Del("Content-Length")
+
+		usages = append(usages, retriedRes.Usage)
+		data = retried
+		violations = structuredOutputViolations(rf, retriedRes)
+	}
+
+	if len(violations) == 0 {
+		return data, usages, false
+	}
+
+	c.Set("structured_output_violations", violations)
+	if !prod {
+		log.Sugar().Infof("structured output violations: %s", strings.Join(violations, "; "))
+	}
+
+	if p.StructuredOutputConfig.Action == policy.Block {
+		telemetry.Incr("bricksllm.proxy.enforce_structured_output.blocked", nil, 1)
+		c.Set("action", "blocked")
+		c.Writer.Header().Del("Content-Length")
+		templatedJSON(c, errortemplate.TypeBlocked, http.StatusForbidden, "[BricksLLM] response does not conform to the requested response format")
+		return data, usages, true
+	}
+
+	telemetry.Incr("bricksllm.proxy.enforce_structured_output.warned", nil, 1)
+	c.Set("action", "warned")
+
+	return data, usages, false
+}
@@ -1,15 +1,187 @@
 package proxy
 
 import (
+	"bytes"
 	"encoding/json"
+	"net/http"
+	"strings"
 
+	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+type threadStorage interface {
+	SetOwner(threadId string, owner *key.ThreadOwner) error
+	GetOwner(threadId string) (*key.ThreadOwner, error)
+	MarkRunAttributed(runId string) (bool, error)
+}
+
+// getThreadPassThroughHandler forwards assistants threads and runs requests
+// to OpenAI. Threads created through the proxy are recorded with the key and
+// custom id that created them, and the token usage of finished runs is
+// attributed back to them.
+func getThreadPassThroughHandler(prod, private bool, client http.Client, e estimator, ts threadStorage) gin.HandlerFunc {
+	handler := getPassThroughHandler(prod, private, client)
+
+	return func(c *gin.Context) {
+		handler(c)
+
+		if c.Writer.Status() != http.StatusOK {
+			return
+		}
+
+		raw, ok := c.Get("passThroughResponse")
+		if !ok {
+			return
+		}
+
+		data, ok := raw.([]byte)
+		if !ok {
+			return
+		}
+
+		log := util.GetLogFromCtx(c)
+
+		if c.FullPath() == "/api/providers/openai/v1/threads" && c.Request.Method == http.MethodPost {
+			t := &goopenai.Thread{}
+			err := json.Unmarshal(data, t)
+			if err != nil {
+				logError(log, "error when unmarshalling create thread response for attribution", prod, err)
+				return
+			}
+
+			recordThreadOwner(c, ts, t.ID, prod)
+			return
+		}
+
+		if c.GetBool("stream") {
+			c.Set("streaming_response", data)
+		}
+
+		// streamed runs are billed from their final run event.
+		if events, ok := lastRunEvent(data); ok {
+			data = events
+		}
+
+		run := &goopenai.Run{}
+		err := json.Unmarshal(data, run)
+		if err != nil {
+			logError(log, "error when unmarshalling run response for attribution", prod, err)
+			return
+		}
+
+		if c.FullPath() == "/api/providers/openai/v1/threads/runs" && c.Request.Method == http.MethodPost {
+			recordThreadOwner(c, ts, run.ThreadID, prod)
+		}
+
+		attributeRunUsage(c, e, ts, run, prod)
+	}
+}
+
+// lastRunEvent returns the data of the last run event of a streamed run. Run
+// step and message events are skipped. False is returned for responses that
+// are not streamed.
+func lastRunEvent(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("event:")) {
+		return nil, false
+	}
+
+	name := ""
+	var last []byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if value, ok := bytes.CutPrefix(line, []byte("event:")); ok {
+			name = string(bytes.TrimSpace(value))
+			continue
+		}
+
+		value, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok || !strings.HasPrefix(name, "thread.run.") || strings.HasPrefix(name, "thread.run.step.") {
+			continue
+		}
+
+		last = bytes.TrimSpace(value)
+	}
+
+	return last, len(last) != 0
+}
+
+func recordThreadOwner(c *gin.Context, ts threadStorage, threadId string, prod bool) {
+	if len(threadId) == 0 {
+		return
+	}
+
+	raw, exists := c.Get("key")
+	kc, ok := raw.(*key.ResponseKey)
+	if !exists || !ok || kc == nil {
+		return
+	}
+
+	err := ts.SetOwner(threadId, &key.ThreadOwner{
+		KeyId:    kc.KeyId,
+		CustomId: c.Request.Header.Get("X-CUSTOM-EVENT-ID"),
+	})
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.record_thread_owner.set_owner_error", nil, 1)
+		logError(util.GetLogFromCtx(c), "error when recording thread owner", prod, err)
+	}
+}
+
+func isRunFinished(status goopenai.RunStatus) bool {
+	return status == goopenai.RunStatusCompleted || status == goopenai.RunStatusFailed || status == goopenai.RunStatusCancelled || status == goopenai.RunStatusExpired || status == "incomplete"
+}
+
+// attributeRunUsage records the token usage of a finished run once. The run
+// is attributed to the owner of its thread when the thread was created
+// through the proxy and to the key making the request otherwise.
+func attributeRunUsage(c *gin.Context, e estimator, ts threadStorage, run *goopenai.Run, prod bool) {
+	if !isRunFinished(run.Status) || run.Usage.TotalTokens == 0 {
+		return
+	}
+
+	log := util.GetLogFromCtx(c)
+
+	first, err := ts.MarkRunAttributed(run.ID)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.attribute_run_usage.mark_run_attributed_error", nil, 1)
+		logError(log, "error when marking run as attributed", prod, err)
+		return
+	}
+
+	if !first {
+		return
+	}
+
+	cost, err := e.EstimateTotalCost(run.Model, run.Usage.PromptTokens, run.Usage.CompletionTokens)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.attribute_run_usage.estimate_total_cost_error", nil, 1)
+		logError(log, "error when estimating run cost", prod, err)
+	}
+
+	c.Set("model", run.Model)
+	c.Set("costInUsd", cost)
+	c.Set("promptTokenCount", run.Usage.PromptTokens)
+	c.Set("completionTokenCount", run.Usage.CompletionTokens)
+
+	owner, err := ts.GetOwner(run.ThreadID)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.attribute_run_usage.get_owner_error", nil, 1)
+		logError(log, "error when getting thread owner", prod, err)
+		return
+	}
+
+	if owner != nil {
+		c.Set("threadOwner", owner)
+	}
+}
+
 func logCreateThreadRequest(log *zap.Logger, data []byte, prod, private bool) {
 	tr := &openai.ThreadRequest{}
 	err := json.Unmarshal(data, tr)
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastRunEvent(t *testing.T) {
+	stream := "event: thread.created\ndata: {\"id\":\"thread_1\"}\n\n" +
+		"event: thread.run.created\ndata: {\"id\":\"run_1\",\"status\":\"queued\"}\n\n" +
+		"event: thread.run.step.completed\ndata: {\"id\":\"step_1\"}\n\n" +
+		"event: thread.message.delta\ndata: {\"id\":\"msg_1\"}\n\n" +
+		"event: thread.run.completed\ndata: {\"id\":\"run_1\",\"status\":\"completed\"}\n\n" +
+		"event: done\ndata: [DONE]\n\n"
+
+	tests := []struct {
+		name  string
+		data  string
+		want  string
+		found bool
+	}{
+		{name: "streamed run", data: stream, want: `{"id":"run_1","status":"completed"}`, found: true},
+		{name: "not streamed", data: `{"id":"run_1","status":"completed"}`},
+		{name: "no run events", data: "event: thread.created\ndata: {\"id\":\"thread_1\"}\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, found := lastRunEvent([]byte(tt.data))
+			assert.Equal(t, tt.found, found)
+			if tt.found {
+				assert.Equal(t, tt.want, string(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type TokenCountRequest struct {
+	Provider  string                           `json:"provider"`
+	Model     string                           `json:"model"`
+	Messages  []goopenai.ChatCompletionMessage `json:"messages"`
+	Functions []goopenai.FunctionDefinition    `json:"functions"`
+	Tools     []goopenai.Tool                  `json:"tools"`
+}
+
+type TokenCountResponse struct {
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	PromptTokenCount int    `json:"promptTokenCount"`
+}
+
+func (r *TokenCountRequest) toChatCompletionRequest() *goopenai.ChatCompletionRequest {
+	functions := []goopenai.FunctionDefinition{}
+	functions = append(functions, r.Functions...)
+
+	for _, tool := range r.Tools {
+		if tool.Type == goopenai.ToolTypeFunction && tool.Function != nil {
+			functions = append(functions, *tool.Function)
+		}
+	}
+
+	return &goopenai.ChatCompletionRequest{
+		Model:     r.Model,
+		Messages:  r.Messages,
+		Functions: functions,
+	}
+}
+
+func getCountTokensHandler(prod bool, e estimator, ae anthropicEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_count_tokens_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading token count request body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read token count request body")
+			return
+		}
+
+		tcr := &TokenCountRequest{}
+		err = json.Unmarshal(data, tcr)
+		if err != nil {
+			logError(log, "error when unmarshalling token count request body", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] token count request body is not valid")
+			return
+		}
+
+		if len(tcr.Model) == 0 {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] model is required for counting tokens")
+			return
+		}
+
+		c.Set("model", tcr.Model)
+
+		if tcr.Provider == "anthropic" {
+			messages := []anthropic.Message{}
+			for _, m := range tcr.Messages {
+				messages = append(messages, anthropic.Message{
+					Role:    m.Role,
+					Content: anthropic.NewTextContent(m.Content),
+				})
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_count_tokens_handler.success", nil, 1)
+			c.JSON(http.StatusOK, &TokenCountResponse{
+				Provider:         tcr.Provider,
+				Model:            tcr.Model,
+				PromptTokenCount: ae.CountMessagesTokens(messages),
+			})
+			return
+		}
+
+		tks, err := e.EstimateChatCompletionPromptTokenCounts(tcr.Model, tcr.toChatCompletionRequest())
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_count_tokens_handler.estimate_chat_completion_prompt_token_counts_error", nil, 1)
+			logError(log, "error when counting chat completion prompt tokens", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] failed to count tokens for the given model")
+			return
+		}
+
+		provider := tcr.Provider
+		if len(provider) == 0 {
+			provider = "openai"
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_count_tokens_handler.success", nil, 1)
+		c.JSON(http.StatusOK, &TokenCountResponse{
+			Provider:         provider,
+			Model:            tcr.Model,
+			PromptTokenCount: tks,
+		})
+	}
+}
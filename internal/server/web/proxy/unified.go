@@ -0,0 +1,378 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	unifiedChatCompletionsPath = "/api/v1/chat/completions"
+	defaultAnthropicVersion    = "2023-06-01"
+)
+
+// getUnifiedProvider returns the provider serving a model requested through
+// the unified chat completions endpoint.
+func getUnifiedProvider(model string) string {
+	if strings.HasPrefix(model, "claude") {
+		return "anthropic"
+	}
+
+	return "openai"
+}
+
+// selectUnifiedSettings returns the provider settings of the provider serving
+// the requested model.
+func selectUnifiedSettings(settings []*provider.Setting, providerName string) []*provider.Setting {
+	selected := []*provider.Setting{}
+	for _, setting := range settings {
+		if setting.Provider == providerName {
+			selected = append(selected, setting)
+		}
+	}
+
+	return selected
+}
+
+// signUnifiedRequest replaces the BricksLLM key of a unified request with the
+// api key of the provider setting in the header expected by the provider.
+func signUnifiedRequest(req *http.Request, providerName string, setting *provider.Setting) error {
+	cfg := &signer.Config{Scheme: signer.SchemeBearer}
+	if providerName == "anthropic" {
+		cfg = &signer.Config{Scheme: signer.SchemeApiKeyHeader, HeaderName: "x-api-key"}
+	}
+
+	s, err := signer.NewStaticSigner(cfg, setting.GetParam("apikey"))
+	if err != nil {
+		return err
+	}
+
+	return signUpstreamRequest(req.Context(), s, req)
+}
+
+// getUnifiedChatCompletionsHandler serves OpenAI chat completions requests
+// with the provider of the requested model. Requests to Anthropic models are
+// translated to the messages API and their responses are translated back.
+func getUnifiedChatCompletionsHandler(prod, private bool, client http.Client, e estimator, ae anthropicEstimator) gin.HandlerFunc {
+	openAiHandler := getChatCompletionHandler(prod, private, client, e)
+	anthropicHandler := getTranslatedMessagesHandler(prod, private, client, ae)
+
+	return func(c *gin.Context) {
+		if c.GetString("provider") == "anthropic" {
+			anthropicHandler(c)
+			return
+		}
+
+		openAiHandler(c)
+	}
+}
+
+func estimateMessagesUsageCost(ae anthropicEstimator, model string, usage anthropic.MessagesUsage) (float64, error) {
+	cost, err := ae.EstimateTotalCost(model, usage.InputTokens, usage.OutputTokens)
+	if err != nil {
+		return 0, err
+	}
+
+	cacheCost, err := ae.EstimateCacheCost(model, usage.CacheCreationInputTokens, usage.CacheReadInputTokens)
+	if err != nil {
+		return 0, err
+	}
+
+	return cost + cacheCost, nil
+}
+
+func writeTranslatedError(c *gin.Context, status int, data []byte) {
+	er := &anthropic.ErrorResponse{}
+	if err := json.Unmarshal(data, er); err != nil || er.Error == nil {
+		c.Data(status, "application/json", data)
+		return
+	}
+
+	c.JSON(status, er.ToOpenAi())
+}
+
+func getTranslatedMessagesHandler(prod, private bool, client http.Client, ae anthropicEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logError(log, "error when reading unified chat completions request body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+			return
+		}
+
+		ccr := &goopenai.ChatCompletionRequest{}
+		err = json.Unmarshal(body, ccr)
+		if err != nil {
+			logError(log, "error when unmarshalling unified chat completions request", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] invalid chat completions request")
+			return
+		}
+
+		mr, err := anthropic.NewMessagesRequestFromOpenAi(ccr)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.translate_request_error", nil, 1)
+			logError(log, "error when translating chat completions request to anthropic messages request", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] chat completions request cannot be translated to anthropic: "+err.Error())
+			return
+		}
+
+		data, err := json.Marshal(mr)
+		if err != nil {
+			logError(log, "error when marshalling translated anthropic messages request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to translate request to anthropic")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader(data))
+		if err != nil {
+			logError(log, "error when creating anthropic http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create anthropic http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+		req.Header.Del("Content-Length")
+		req.Header.Set("Content-Type", "application/json")
+		if len(req.Header.Get("anthropic-version")) == 0 {
+			req.Header.Set("anthropic-version", defaultAnthropicVersion)
+		}
+
+		isStreaming := c.GetBool("stream")
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to anthropic", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to anthropic")
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			if strings.ToLower(name) == "content-length" || strings.ToLower(name) == "content-type" {
+				continue
+			}
+
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		model := c.GetString("model")
+
+		if !isStreaming && res.StatusCode == http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_translated_messages_handler.latency", dur, nil, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading anthropic http messages response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read anthropic response body")
+				return
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_translated_messages_handler.success_latency", dur, nil, 1)
+
+			mres := &anthropic.MessagesResponse{}
+			err = json.Unmarshal(bytes, mres)
+			if err != nil {
+				logError(log, "error when unmarshalling anthropic http messages response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to translate anthropic response")
+				return
+			}
+
+			chatRes := mres.ToOpenAi()
+			logChatCompletionResponse(log, prod, private, chatRes)
+
+			cost, err := estimateMessagesUsageCost(ae, model, mres.Usage)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating anthropic cost", prod, err)
+			}
+
+			c.Set("content", mres.Text())
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", mres.Usage.TotalInputTokens())
+			c.Set("completionTokenCount", mres.Usage.OutputTokens)
+
+			c.JSON(res.StatusCode, chatRes)
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_translated_messages_handler.error_latency", dur, nil, 1)
+			telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.error_response", nil, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading anthropic http messages response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read anthropic response body")
+				return
+			}
+
+			logAnthropicErrorResponse(log, bytes, prod)
+			writeTranslatedError(c, res.StatusCode, bytes)
+			return
+		}
+
+		includeUsage := ccr.StreamOptions != nil && ccr.StreamOptions.IncludeUsage
+		translator := anthropic.NewStreamTranslator(includeUsage)
+		buffer := bufio.NewReader(res.Body)
+		streamingResponse := [][]byte{}
+		content := ""
+
+		defer func() {
+			usage := translator.Usage()
+			cost, err := estimateMessagesUsageCost(ae, model, usage)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating anthropic messages stream cost", prod, err)
+			}
+
+			c.Set("content", content)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", usage.TotalInputTokens())
+			c.Set("completionTokenCount", usage.OutputTokens)
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.streaming_requests", nil, 1)
+
+		eventName := ""
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				if errors.Is(err, context.DeadlineExceeded) {
+					telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.context_deadline_exceeded_error", nil, 1)
+					logError(log, "context deadline exceeded when reading bytes from anthropic streaming response", prod, err)
+
+					return false
+				}
+
+				telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from anthropic streaming response", prod, err)
+
+				apiErr := &goopenai.ErrorResponse{
+					Error: &goopenai.APIError{
+						Type:    "bricksllm_error",
+						Message: err.Error(),
+					},
+				}
+
+				bytes, err := json.Marshal(apiErr)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.json_marshal_error", nil, 1)
+					logError(log, "error when marshalling bytes for translated streaming error response", prod, err)
+					return false
+				}
+
+				c.SSEvent("", string(bytes))
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if len(noSpaceLine) == 0 {
+				return true
+			}
+
+			if bytes.HasPrefix(noSpaceLine, []byte("event:")) {
+				eventName = string(bytes.TrimSpace(bytes.TrimPrefix(noSpaceLine, []byte("event:"))))
+				return true
+			}
+
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
+
+			if eventName == "error" {
+				telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.stream_error_event", nil, 1)
+
+				er := &anthropic.ErrorResponse{}
+				if err := json.Unmarshal(noPrefixLine, er); err == nil && er.Error != nil {
+					if data, err := json.Marshal(er.ToOpenAi()); err == nil {
+						noPrefixLine = data
+					}
+				}
+
+				c.SSEvent("", " "+string(noPrefixLine))
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			if eventName == "message_stop" {
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			chunks, err := translator.Translate(eventName, noPrefixLine)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.translate_event_error", nil, 1)
+				logError(log, "error when translating anthropic stream event "+eventName, prod, err)
+				return true
+			}
+
+			for _, chunk := range chunks {
+				for _, choice := range chunk.Choices {
+					content += choice.Delta.Content
+				}
+
+				data, err := json.Marshal(chunk)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_translated_messages_handler.json_marshal_error", nil, 1)
+					logError(log, "error when marshalling translated chat completion chunk", prod, err)
+					continue
+				}
+
+				c.SSEvent("", " "+string(data))
+			}
+
+			return true
+		})
+
+		telemetry.Timing("bricksllm.proxy.get_translated_messages_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
@@ -0,0 +1,319 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/gemini"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vertex"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type vertexEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	EstimateCacheCost(model string, cacheWriteTks, cacheReadTks int) (float64, error)
+}
+
+// vertexUsage accumulates the token usage reported by Gemini and partner
+// models in responses and stream chunks.
+type vertexUsage struct {
+	promptTokens     int
+	completionTokens int
+	cacheWriteTokens int
+	cacheReadTokens  int
+}
+
+// totalPromptTokens includes the prompt caching tokens of partner models.
+func (u *vertexUsage) totalPromptTokens() int {
+	return u.promptTokens + u.cacheWriteTokens + u.cacheReadTokens
+}
+
+func (u *vertexUsage) recordPartnerCache(pu *vertex.PartnerUsage) {
+	if pu.CacheCreationInputTokens != 0 {
+		u.cacheWriteTokens = pu.CacheCreationInputTokens
+	}
+
+	if pu.CacheReadInputTokens != 0 {
+		u.cacheReadTokens = pu.CacheReadInputTokens
+	}
+}
+
+func (u *vertexUsage) record(action string, data []byte) string {
+	if action == vertex.ActionGenerateContent || action == vertex.ActionStreamGenerateContent {
+		gcr := &gemini.GenerateContentResponse{}
+		if err := json.Unmarshal(data, gcr); err != nil {
+			return ""
+		}
+
+		// usage metadata of streamed chunks is cumulative.
+		if gcr.UsageMetadata != nil {
+			u.promptTokens = gcr.UsageMetadata.PromptTokenCount
+			u.completionTokens = gcr.UsageMetadata.CandidatesTokenCount
+		}
+
+		return gcr.Text()
+	}
+
+	pr := &vertex.PartnerResponse{}
+	if err := json.Unmarshal(data, pr); err != nil {
+		return ""
+	}
+
+	if pr.Message != nil && pr.Message.Usage != nil {
+		u.promptTokens = pr.Message.Usage.InputTokens
+		u.completionTokens = pr.Message.Usage.OutputTokens
+		u.recordPartnerCache(pr.Message.Usage)
+	}
+
+	if pr.Usage != nil {
+		if pr.Usage.InputTokens != 0 {
+			u.promptTokens = pr.Usage.InputTokens
+		}
+
+		u.completionTokens = pr.Usage.OutputTokens
+		u.recordPartnerCache(pr.Usage)
+	}
+
+	return ""
+}
+
+func estimateVertexCost(c *gin.Context, ve vertexEstimator, model string, u *vertexUsage) (float64, error) {
+	m, exists := c.Get("cost_map")
+	if exists {
+		converted, ok := m.(*provider.CostMap)
+		if ok {
+			cost, err := provider.EstimateTotalCostWithCostMaps(model, u.promptTokens, u.completionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+			if err == nil && cost != 0 {
+				writeCost, _ := provider.EstimateCostWithCostMap(model, u.cacheWriteTokens, 1000, converted.PromptCostPerModel)
+				readCost, _ := provider.EstimateCostWithCostMap(model, u.cacheReadTokens, 1000, converted.PromptCostPerModel)
+
+				return cost + writeCost*anthropic.CacheWriteCostMultiplier + readCost*anthropic.CacheReadCostMultiplier, nil
+			}
+		}
+	}
+
+	cost, err := ve.EstimateTotalCost(model, u.promptTokens, u.completionTokens)
+	if err != nil {
+		return 0, err
+	}
+
+	cacheCost, err := ve.EstimateCacheCost(model, u.cacheWriteTokens, u.cacheReadTokens)
+	if err != nil {
+		return 0, err
+	}
+
+	return cost + cacheCost, nil
+}
+
+func getVertexHandler(prod bool, client http.Client, ve vertexEstimator, sm signerManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_vertex_handler.requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		model, action, err := vertex.ParseModelAction(c.Param("model"))
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_vertex_handler.parse_model_action_error", nil, 1)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+			return
+		}
+
+		projectId := c.GetString("vertexProjectId")
+		region := c.GetString("vertexRegion")
+		if len(projectId) == 0 || len(region) == 0 {
+			telemetry.Incr("bricksllm.proxy.get_vertex_handler.auth_error", nil, 1)
+			log.Error("vertex ai project id or region is missing")
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] auth credentials are missing")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		endpoint := vertex.GetEndpoint(region, c.GetString("vertexEndpoint"))
+		query := c.Request.URL.Query()
+		if action == vertex.ActionStreamGenerateContent {
+			query.Set("alt", "sse")
+		}
+
+		url := vertex.GetModelUrl(endpoint, projectId, region, c.Param("publisher"), model, action)
+		if len(query) != 0 {
+			url = fmt.Sprintf("%s?%s", url, query.Encode())
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating vertex ai http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create vertex ai http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		s, err := sm.GetSigner(&signer.Config{Scheme: signer.SchemeGcp}, map[string]string{
+			"serviceAccountJson": c.GetString("vertexServiceAccountJson"),
+		})
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_vertex_handler.get_signer_error", nil, 1)
+			logError(log, "error when getting vertex ai signer", prod, err)
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] auth credentials are missing")
+			return
+		}
+
+		err = signUpstreamRequest(ctx, s, req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_vertex_handler.sign_request_error", nil, 1)
+			logError(log, "error when signing vertex ai request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to get vertex ai access token")
+			return
+		}
+
+		isStreaming := vertex.IsStreamingAction(action)
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_vertex_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to vertex ai", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to vertex ai")
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		usage := &vertexUsage{}
+
+		if res.StatusCode == http.StatusOK && !isStreaming {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_vertex_handler.latency", dur, nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading vertex ai http response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read vertex ai response body")
+				return
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_vertex_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_vertex_handler.success_latency", dur, nil, 1)
+
+			if action != vertex.ActionCountTokens {
+				c.Set("content", usage.record(action, data))
+
+				cost, err := estimateVertexCost(c, ve, model, usage)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_vertex_handler.estimate_total_cost_error", nil, 1)
+					logError(log, "error when estimating vertex ai cost", prod, err)
+				}
+
+				c.Set("costInUsd", cost)
+				c.Set("promptTokenCount", usage.totalPromptTokens())
+				c.Set("completionTokenCount", usage.completionTokens)
+			}
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_vertex_handler.error_latency", dur, nil, 1)
+			telemetry.Incr("bricksllm.proxy.get_vertex_handler.error_response", nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading vertex ai http response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read vertex ai response body")
+				return
+			}
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		buffer := bufio.NewReader(res.Body)
+		content := ""
+		streamingResponse := [][]byte{}
+		defer func() {
+			c.Set("content", content)
+
+			cost, err := estimateVertexCost(c, ve, model, usage)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_vertex_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating vertex ai streaming cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", usage.totalPromptTokens())
+			c.Set("completionTokenCount", usage.completionTokens)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_vertex_handler.streaming_requests", nil, 1)
+
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadBytes('\n')
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				if errors.Is(err, context.DeadlineExceeded) {
+					telemetry.Incr("bricksllm.proxy.get_vertex_handler.context_deadline_exceeded_error", nil, 1)
+					logError(log, "context deadline exceeded when reading bytes from vertex ai response", prod, err)
+
+					return false
+				}
+
+				telemetry.Incr("bricksllm.proxy.get_vertex_handler.read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from vertex ai response", prod, err)
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			if _, err := w.Write(raw); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_vertex_handler.write_error", nil, 1)
+				logError(log, "error when writing vertex ai streaming response", prod, err)
+				return false
+			}
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			content += usage.record(action, bytes.TrimSpace(bytes.TrimPrefix(noSpaceLine, headerData)))
+			return true
+		})
+
+		telemetry.Timing("bricksllm.proxy.get_vertex_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
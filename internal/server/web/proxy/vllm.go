@@ -105,15 +105,7 @@ func getVllmCompletionsHandler(prod, private bool, client http.Client) gin.Handl
 			if exists {
 				converted, ok := m.(*provider.CostMap)
 				if ok {
-					newCost, err := provider.EstimateTotalCostWithCostMaps(model, cr.Usage.PromptTokens, cr.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
-					if err != nil {
-						logError(log, "error when estimating vllm completions total cost with cost maps", prod, err)
-						telemetry.Incr("bricksllm.proxy.get_vllm_completions_handler.estimate_total_cost_with_cost_maps_error", nil, 1)
-					}
-
-					if newCost != 0 {
-						cost = newCost
-					}
+					cost = provider.EstimateTotalCostWithOptionalCostMaps(model, cr.Usage.PromptTokens, cr.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
 				}
 			}
 
@@ -150,10 +142,10 @@ func getVllmCompletionsHandler(prod, private bool, client http.Client) gin.Handl
 		}
 
 		buffer := bufio.NewReader(res.Body)
-		content := ""
+		choices := &streamedChoices{}
 		streamingResponse := [][]byte{}
 		defer func() {
-			c.Set("content", content)
+			c.Set("content", choices.String())
 			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
 		}()
 
@@ -217,8 +209,8 @@ func getVllmCompletionsHandler(prod, private bool, client http.Client) gin.Handl
 			}
 
 			if err == nil {
-				if len(completionsStreamResp.Choices) > 0 && len(completionsStreamResp.Choices[0].Text) != 0 {
-					content += completionsStreamResp.Choices[0].Text
+				for _, choice := range completionsStreamResp.Choices {
+					choices.append(choice.Index, choice.Text)
 				}
 			}
 
@@ -453,15 +445,7 @@ func getVllmChatCompletionsHandler(prod, private bool, client http.Client) gin.H
 			if exists {
 				converted, ok := m.(*provider.CostMap)
 				if ok {
-					newCost, err := provider.EstimateTotalCostWithCostMaps(model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
-					if err != nil {
-						logError(log, "error when estimating vllm chat completions total cost with cost maps", prod, err)
-						telemetry.Incr("bricksllm.proxy.get_vllm_chat_completions_handler.estimate_total_cost_with_cost_maps_error", nil, 1)
-					}
-
-					if newCost != 0 {
-						cost = newCost
-					}
+					cost = provider.EstimateTotalCostWithOptionalCostMaps(model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
 				}
 			}
 
@@ -497,10 +481,10 @@ func getVllmChatCompletionsHandler(prod, private bool, client http.Client) gin.H
 		}
 
 		buffer := bufio.NewReader(res.Body)
-		content := ""
+		choices := &streamedChoices{}
 		streamingResponse := [][]byte{}
 		defer func() {
-			c.Set("content", content)
+			c.Set("content", choices.String())
 			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
 		}()
 
@@ -564,8 +548,8 @@ func getVllmChatCompletionsHandler(prod, private bool, client http.Client) gin.H
 			}
 
 			if err == nil {
-				if len(chatCompletionStreamResp.Choices) > 0 && len(chatCompletionStreamResp.Choices[0].Delta.Content) != 0 {
-					content += chatCompletionStreamResp.Choices[0].Delta.Content
+				for _, choice := range chatCompletionStreamResp.Choices {
+					choices.append(choice.Index, choice.Delta.Content)
 				}
 			}
 
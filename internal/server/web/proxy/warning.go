@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/errortemplate"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// policyWarningHeader is set on responses of requests forwarded despite a
+// policy warning. Its value is the kind of check that warned the request, so
+// that detected entities and rule definitions are not disclosed to clients.
+const policyWarningHeader = "X-BRICKSLLM-POLICY-WARNING"
+
+const (
+	warningKindRules           = "rules"
+	warningKindModeration      = "moderation"
+	warningKindPromptInjection = "prompt_injection"
+)
+
+// warnRequest applies the warning config of the key's policy to a request
+// warned by a check of kind for reason. Warned requests are recorded as
+// warned and forwarded with the warning header set on their responses unless
+// the policy blocks warned requests. The reason is only logged. It returns
+// false if the request was blocked.
+func warnRequest(c *gin.Context, log *zap.Logger, p *policy.Policy, kc *key.ResponseKey, cid, kind, reason string) bool {
+	if p.ShouldBlockWarnings() {
+		c.Set("action", "blocked")
+		telemetry.Incr("bricksllm.proxy.warn_request.blocked", nil, 1)
+
+		log.Info("warned request blocked",
+			zap.String("keyId", kc.KeyId),
+			zap.String("policyId", p.Id),
+			zap.String("reason", reason),
+		)
+
+		templatedJSON(c, errortemplate.TypeBlocked, http.StatusForbidden, blockMessage(kc, cid, "[BricksLLM] request blocked"))
+		return false
+	}
+
+	c.Set("action", "warned")
+	telemetry.Incr("bricksllm.proxy.warn_request.forwarded", nil, 1)
+
+	log.Info("warned request forwarded",
+		zap.String("keyId", kc.KeyId),
+		zap.String("policyId", p.Id),
+		zap.String("reason", reason),
+	)
+
+	c.Header(policyWarningHeader, kind)
+	return true
+}
@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWarnRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		mode      string
+		forwarded bool
+	}{
+		{name: "forwarded", mode: policy.WarningModeForward, forwarded: true},
+		{name: "blocked", mode: policy.WarningModeBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			p := &policy.Policy{WarningConfig: &policy.WarningConfig{Mode: tt.mode}}
+			forwarded := warnRequest(c, zap.NewNop(), p, &key.ResponseKey{}, "cid", warningKindRules, "request warned due to detected entities: (?i)secret-[0-9]+")
+
+			assert.Equal(t, tt.forwarded, forwarded)
+			if !tt.forwarded {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.Empty(t, w.Header().Get(policyWarningHeader))
+				return
+			}
+
+			assert.Equal(t, warningKindRules, w.Header().Get(policyWarningHeader))
+		})
+	}
+}
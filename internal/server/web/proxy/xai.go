@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/xai"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+type xaiEstimator interface {
+	EstimateUsageCost(model string, usage *goopenai.Usage) (float64, error)
+	Count(input string) int
+}
+
+// newXaiUsage counts the reasoning tokens reported by xAI as completion
+// tokens.
+func newXaiUsage(usage *goopenai.Usage) *compatibleUsage {
+	if usage == nil {
+		return nil
+	}
+
+	return &compatibleUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: xai.CompletionTokens(usage),
+		ReasoningTokens:  xai.ReasoningTokens(usage),
+		Details:          usage,
+	}
+}
+
+func newXaiProvider(xe xaiEstimator) *compatibleProvider {
+	return &compatibleProvider{
+		name: "xai",
+		url:  xai.ChatCompletionsUrl,
+		decodeResponse: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionResponse, *compatibleUsage, error) {
+			chatRes, _, err := decodeOpenAiChatCompletion(c, data)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return chatRes, newXaiUsage(&chatRes.Usage), nil
+		},
+		decodeChunk: func(c *gin.Context, data []byte) (*goopenai.ChatCompletionStreamResponse, *compatibleUsage, error) {
+			chunk, _, err := decodeOpenAiChatCompletionChunk(c, data)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return chunk, newXaiUsage(chunk.Usage), nil
+		},
+		estimateCost: func(c *gin.Context, model string, usage *compatibleUsage) (float64, error) {
+			if cost, ok := estimateCostWithCostMap(c, model, usage); ok {
+				return cost, nil
+			}
+
+			details, _ := usage.Details.(*goopenai.Usage)
+			if details == nil {
+				details = &goopenai.Usage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens}
+			}
+
+			return xe.EstimateUsageCost(model, details)
+		},
+		count: xe.Count,
+		countRequest: func(c *gin.Context) int {
+			r, _ := c.Value("xaiRequest").(*goopenai.ChatCompletionRequest)
+			return provider.CountChatRequestTokens(r, xe.Count)
+		},
+	}
+}
@@ -0,0 +1,151 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type ModelPricingsStorage interface {
+	GetModelPricings() ([]*openai.ModelPricing, error)
+	GetUpdatedModelPricings(updatedAt int64) ([]*openai.ModelPricing, error)
+}
+
+type ModelPricingsMemDb struct {
+	external        ModelPricingsStorage
+	lastUpdated     int64
+	modelToPricings map[string]*openai.ModelPricing
+	lock            sync.RWMutex
+	done            chan bool
+	interval        time.Duration
+	log             *zap.Logger
+}
+
+func NewModelPricingsMemDb(ex ModelPricingsStorage, log *zap.Logger, interval time.Duration) (*ModelPricingsMemDb, error) {
+	modelToPricings := map[string]*openai.ModelPricing{}
+
+	pricings, err := ex.GetModelPricings()
+	if err != nil {
+		return nil, err
+	}
+
+	var latetest int64 = -1
+	for _, mp := range pricings {
+		modelToPricings[mp.Model] = mp
+		if mp.UpdatedAt > latetest {
+			latetest = mp.UpdatedAt
+		}
+	}
+
+	if len(pricings) != 0 {
+		log.Sugar().Infof("model pricings memdb updated at %d with %d pricings", latetest, len(pricings))
+	}
+
+	return &ModelPricingsMemDb{
+		external:        ex,
+		modelToPricings: modelToPricings,
+		log:             log,
+		lastUpdated:     latetest,
+		interval:        interval,
+		done:            make(chan bool),
+	}, nil
+}
+
+// GetCosts returns the costs of a model managed via the admin server.
+func (mdb *ModelPricingsMemDb) GetCosts(model string) (map[string]float64, bool) {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	mp, ok := mdb.modelToPricings[model]
+	if !ok {
+		return nil, false
+	}
+
+	return mp.Costs, true
+}
+
+// GetAllCosts returns the costs of all models managed via the admin server.
+func (mdb *ModelPricingsMemDb) GetAllCosts() map[string]map[string]float64 {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	costs := map[string]map[string]float64{}
+	for model, mp := range mdb.modelToPricings {
+		costs[model] = mp.Costs
+	}
+
+	return costs
+}
+
+func (mdb *ModelPricingsMemDb) SetPricing(mp *openai.ModelPricing) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	if mp.Deleted {
+		delete(mdb.modelToPricings, mp.Model)
+		return
+	}
+
+	mdb.modelToPricings[mp.Model] = mp
+}
+
+func (mdb *ModelPricingsMemDb) getPricing(model string) *openai.ModelPricing {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.modelToPricings[model]
+}
+
+func (mdb *ModelPricingsMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("model pricings memdb started listening for pricing updates")
+
+	go func() {
+		lastUpdated := mdb.lastUpdated
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("memdb stopped")
+				return
+			case <-ticker.C:
+				pricings, err := mdb.external.GetUpdatedModelPricings(lastUpdated)
+				if err != nil {
+					telemetry.Incr("bricksllm.memdb.model_pricings_memdb.listen.get_updated_model_pricings_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to update model pricings: %v", err)
+					continue
+				}
+
+				numberOfUpdated := 0
+				for _, mp := range pricings {
+					if mp.UpdatedAt > lastUpdated {
+						lastUpdated = mp.UpdatedAt
+					}
+
+					existing := mdb.getPricing(mp.Model)
+					if existing == nil && mp.Deleted {
+						continue
+					}
+
+					if existing == nil || mp.UpdatedAt > existing.UpdatedAt || mp.Deleted {
+						numberOfUpdated++
+						mdb.SetPricing(mp)
+					}
+				}
+
+				if numberOfUpdated != 0 {
+					mdb.log.Sugar().Infof("model pricings memdb updated at %d with %d pricings", lastUpdated, numberOfUpdated)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *ModelPricingsMemDb) Stop() {
+	mdb.log.Info("shutting down model pricings memdb...")
+
+	mdb.done <- true
+}
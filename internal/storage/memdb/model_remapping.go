@@ -0,0 +1,135 @@
+package memdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type ModelRemappingsStorage interface {
+	GetModelRemappings() ([]*provider.ModelRemapping, error)
+	GetUpdatedModelRemappings(updatedAt int64) ([]*provider.ModelRemapping, error)
+}
+
+type ModelRemappingsMemDb struct {
+	external          ModelRemappingsStorage
+	lastUpdated       int64
+	modelToRemappings map[string]*provider.ModelRemapping
+	lock              sync.RWMutex
+	done              chan bool
+	interval          time.Duration
+	log               *zap.Logger
+}
+
+func NewModelRemappingsMemDb(ex ModelRemappingsStorage, log *zap.Logger, interval time.Duration) (*ModelRemappingsMemDb, error) {
+	modelToRemappings := map[string]*provider.ModelRemapping{}
+
+	remappings, err := ex.GetModelRemappings()
+	if err != nil {
+		return nil, err
+	}
+
+	var latetest int64 = -1
+	for _, mr := range remappings {
+		modelToRemappings[mr.Model] = mr
+		if mr.UpdatedAt > latetest {
+			latetest = mr.UpdatedAt
+		}
+	}
+
+	if len(remappings) != 0 {
+		log.Sugar().Infof("model remappings memdb updated at %d with %d remappings", latetest, len(remappings))
+	}
+
+	return &ModelRemappingsMemDb{
+		external:          ex,
+		modelToRemappings: modelToRemappings,
+		log:               log,
+		lastUpdated:       latetest,
+		interval:          interval,
+		done:              make(chan bool),
+	}, nil
+}
+
+// GetRemapping returns the remapping of a model managed via the admin
+// server.
+func (mdb *ModelRemappingsMemDb) GetRemapping(model string) (*provider.ModelRemapping, bool) {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	mr, ok := mdb.modelToRemappings[model]
+	return mr, ok
+}
+
+func (mdb *ModelRemappingsMemDb) SetRemapping(mr *provider.ModelRemapping) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	if mr.Deleted {
+		delete(mdb.modelToRemappings, mr.Model)
+		return
+	}
+
+	mdb.modelToRemappings[mr.Model] = mr
+}
+
+func (mdb *ModelRemappingsMemDb) getRemapping(model string) *provider.ModelRemapping {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.modelToRemappings[model]
+}
+
+func (mdb *ModelRemappingsMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("model remappings memdb started listening for remapping updates")
+
+	go func() {
+		lastUpdated := mdb.lastUpdated
+		for {
+			select {
+			case <-mdb.done:
+				mdb.log.Info("memdb stopped")
+				return
+			case <-ticker.C:
+				remappings, err := mdb.external.GetUpdatedModelRemappings(lastUpdated)
+				if err != nil {
+					telemetry.Incr("bricksllm.memdb.model_remappings_memdb.listen.get_updated_model_remappings_error", nil, 1)
+
+					mdb.log.Sugar().Debugf("memdb failed to update model remappings: %v", err)
+					continue
+				}
+
+				numberOfUpdated := 0
+				for _, mr := range remappings {
+					if mr.UpdatedAt > lastUpdated {
+						lastUpdated = mr.UpdatedAt
+					}
+
+					existing := mdb.getRemapping(mr.Model)
+					if existing == nil && mr.Deleted {
+						continue
+					}
+
+					if existing == nil || mr.UpdatedAt > existing.UpdatedAt || mr.Deleted {
+						numberOfUpdated++
+						mdb.SetRemapping(mr)
+					}
+				}
+
+				if numberOfUpdated != 0 {
+					mdb.log.Sugar().Infof("model remappings memdb updated at %d with %d remappings", lastUpdated, numberOfUpdated)
+				}
+			}
+		}
+	}()
+}
+
+func (mdb *ModelRemappingsMemDb) Stop() {
+	mdb.log.Info("shutting down model remappings memdb...")
+
+	mdb.done <- true
+}
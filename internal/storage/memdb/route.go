@@ -1,6 +1,8 @@
 package memdb
 
 import (
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +28,7 @@ type RoutesMemDb struct {
 	lastUpdatedPolicies int64
 	lastUpdated         int64
 	idToPolicy          map[string]*policy.Policy
+	keyTagsToPolicy     map[string]*policy.Policy
 	pathToRoute         map[string]*route.Route
 	lock                sync.RWMutex
 	done                chan bool
@@ -64,6 +67,7 @@ func NewRoutesMemDb(ex RoutesStorage, ps PoliciesStorage, log *zap.Logger, inter
 	numberOfPolicies := 0
 	var platetest int64 = -1
 	for _, p := range policies {
+		compilePolicy(p, log)
 		idToPolicy[p.Id] = p
 		numberOfPolicies++
 		if p.UpdatedAt > platetest {
@@ -79,6 +83,7 @@ func NewRoutesMemDb(ex RoutesStorage, ps PoliciesStorage, log *zap.Logger, inter
 		external:            ex,
 		ps:                  ps,
 		idToPolicy:          idToPolicy,
+		keyTagsToPolicy:     map[string]*policy.Policy{},
 		pathToRoute:         pathToRoute,
 		log:                 log,
 		lastUpdated:         latetest,
@@ -89,6 +94,9 @@ func NewRoutesMemDb(ex RoutesStorage, ps PoliciesStorage, log *zap.Logger, inter
 }
 
 func (mdb *RoutesMemDb) GetRoute(path string) *route.Route {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
 	r, ok := mdb.pathToRoute[path]
 	if ok {
 		return r
@@ -106,18 +114,82 @@ func (mdb *RoutesMemDb) GetPolicy(id string) *policy.Policy {
 	return nil
 }
 
+// SetRoute caches a route by its path. A previous version of the route
+// cached under another path is evicted.
 func (mdb *RoutesMemDb) SetRoute(r *route.Route) {
-	mdb.lock.RLock()
-	defer mdb.lock.RUnlock()
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	for path, existing := range mdb.pathToRoute {
+		if existing.Id == r.Id && path != r.Path {
+			delete(mdb.pathToRoute, path)
+		}
+	}
 
 	mdb.pathToRoute[r.Path] = r
 }
 
 func (mdb *RoutesMemDb) SetPolicy(p *policy.Policy) {
-	mdb.lock.RLock()
-	defer mdb.lock.RUnlock()
+	compilePolicy(p, mdb.log)
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
 
 	mdb.idToPolicy[p.Id] = p
+
+	// resolved key tags may now resolve to another policy.
+	mdb.keyTagsToPolicy = map[string]*policy.Policy{}
+}
+
+// GetPolicyByKeyTags returns the policy attached to any of the tags of a key.
+// If several policies are, the one created first is returned. Resolved tags
+// are cached until policies are updated.
+func (mdb *RoutesMemDb) GetPolicyByKeyTags(tags []string) *policy.Policy {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	cacheKey := strings.Join(sorted, ",")
+
+	mdb.lock.RLock()
+	p, ok := mdb.keyTagsToPolicy[cacheKey]
+	mdb.lock.RUnlock()
+
+	if ok {
+		return p
+	}
+
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	var resolved *policy.Policy
+	for _, candidate := range mdb.idToPolicy {
+		if !attachedToAny(candidate, tags) {
+			continue
+		}
+
+		if resolved == nil || candidate.CreatedAt < resolved.CreatedAt || (candidate.CreatedAt == resolved.CreatedAt && candidate.Id < resolved.Id) {
+			resolved = candidate
+		}
+	}
+
+	mdb.keyTagsToPolicy[cacheKey] = resolved
+
+	return resolved
+}
+
+func attachedToAny(p *policy.Policy, tags []string) bool {
+	for _, attached := range p.KeyTags {
+		for _, tag := range tags {
+			if attached == tag {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 func (mdb *RoutesMemDb) Listen() {
@@ -199,3 +271,13 @@ func (mdb *RoutesMemDb) Stop() {
 
 	mdb.done <- true
 }
+
+// compilePolicy compiles the regular expressions of a policy before it is
+// cached. Invalid rules are skipped when requests are scanned.
+func compilePolicy(p *policy.Policy, log *zap.Logger) {
+	if err := p.Compile(); err != nil {
+		telemetry.Incr("bricksllm.memdb.routes_memdb.compile_policy_error", nil, 1)
+
+		log.Sugar().Debugf("memdb failed to compile policy: %v", err)
+	}
+}
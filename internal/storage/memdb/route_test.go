@@ -0,0 +1,59 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSetRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		cached  []*route.Route
+		set     *route.Route
+		present []string
+		absent  []string
+	}{
+		{
+			name:    "new route",
+			set:     &route.Route{Id: "a", Path: "/a"},
+			present: []string{"/a"},
+		},
+		{
+			name:    "path changed",
+			cached:  []*route.Route{{Id: "a", Path: "/a"}, {Id: "b", Path: "/b"}},
+			set:     &route.Route{Id: "a", Path: "/c"},
+			present: []string{"/b", "/c"},
+			absent:  []string{"/a"},
+		},
+		{
+			name:    "path kept",
+			cached:  []*route.Route{{Id: "a", Path: "/a"}},
+			set:     &route.Route{Id: "a", Path: "/a", UpdatedAt: 1},
+			present: []string{"/a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb := &RoutesMemDb{pathToRoute: map[string]*route.Route{}, log: zap.NewNop()}
+			for _, r := range tt.cached {
+				mdb.pathToRoute[r.Path] = r
+			}
+
+			mdb.SetRoute(tt.set)
+
+			for _, path := range tt.present {
+				assert.NotNil(t, mdb.GetRoute(path), path)
+			}
+
+			for _, path := range tt.absent {
+				assert.Nil(t, mdb.GetRoute(path), path)
+			}
+
+			assert.Equal(t, tt.set, mdb.GetRoute(tt.set.Path))
+		})
+	}
+}
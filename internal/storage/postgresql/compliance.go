@@ -0,0 +1,261 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bricks-cloud/bricksllm/internal/compliance"
+)
+
+func (s *Store) CreateAuditLogsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS audit_logs (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		method VARCHAR(255) NOT NULL,
+		path VARCHAR(255) NOT NULL,
+		status_code INT NOT NULL,
+		correlation_id VARCHAR(255) NOT NULL DEFAULT '',
+		client_ip VARCHAR(255) NOT NULL DEFAULT ''
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateKeyHistoryTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS key_history (
+		key_id VARCHAR(255) NOT NULL,
+		action VARCHAR(255) NOT NULL,
+		created_at BIGINT NOT NULL,
+		snapshot JSONB NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) InsertAuditLog(l *compliance.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (id, created_at, method, path, status_code, correlation_id, client_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, query, l.Id, l.CreatedAt, l.Method, l.Path, l.StatusCode, l.CorrelationId, l.ClientIp); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) InsertKeyHistory(h *compliance.KeyHistory) error {
+	query := `
+		INSERT INTO key_history (key_id, action, created_at, snapshot)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, query, h.KeyId, h.Action, h.CreatedAt, []byte(h.Snapshot)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetAuditLogs(start, end int64) ([]*compliance.AuditLog, error) {
+	query := `
+		SELECT id, created_at, method, path, status_code, correlation_id, client_ip FROM audit_logs WHERE created_at >= $1 AND created_at < $2 ORDER BY created_at
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	logs := []*compliance.AuditLog{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, start, end)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return logs, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l := &compliance.AuditLog{}
+		if err := rows.Scan(
+			&l.Id,
+			&l.CreatedAt,
+			&l.Method,
+			&l.Path,
+			&l.StatusCode,
+			&l.CorrelationId,
+			&l.ClientIp,
+		); err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, l)
+	}
+
+	return logs, nil
+}
+
+func (s *Store) GetKeyHistory(start, end int64) ([]*compliance.KeyHistory, error) {
+	query := `
+		SELECT key_id, action, created_at, snapshot FROM key_history WHERE created_at >= $1 AND created_at < $2 ORDER BY created_at
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	history := []*compliance.KeyHistory{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, start, end)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return history, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h := &compliance.KeyHistory{}
+		var snapshot []byte
+		if err := rows.Scan(
+			&h.KeyId,
+			&h.Action,
+			&h.CreatedAt,
+			&snapshot,
+		); err != nil {
+			return nil, err
+		}
+
+		h.Snapshot = snapshot
+		history = append(history, h)
+	}
+
+	return history, nil
+}
+
+func (s *Store) GetPolicyDecisions(start, end int64) ([]*compliance.PolicyDecision, error) {
+	query := `
+		SELECT event_id, created_at, key_id, policy_id, action, pii_findings, policy_exemption, content_filter_results, guardrail_intervention FROM events WHERE created_at >= $1 AND created_at < $2 AND (NOT policy_id = '' OR guardrail_intervention IS NOT NULL) ORDER BY created_at
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	decisions := []*compliance.PolicyDecision{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, start, end)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return decisions, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d := &compliance.PolicyDecision{}
+		var findings []byte
+		var exemption []byte
+		var contentFilterResults []byte
+		var guardrailIntervention []byte
+		if err := rows.Scan(
+			&d.EventId,
+			&d.CreatedAt,
+			&d.KeyId,
+			&d.PolicyId,
+			&d.Action,
+			&findings,
+			&exemption,
+			&contentFilterResults,
+			&guardrailIntervention,
+		); err != nil {
+			return nil, err
+		}
+
+		if len(findings) != 0 {
+			d.PiiFindings = findings
+		}
+
+		if len(exemption) != 0 {
+			d.Exemption = exemption
+		}
+
+		if len(contentFilterResults) != 0 {
+			d.ContentFilterResults = contentFilterResults
+		}
+
+		if len(guardrailIntervention) != 0 {
+			d.GuardrailIntervention = guardrailIntervention
+		}
+
+		decisions = append(decisions, d)
+	}
+
+	return decisions, nil
+}
+
+func (s *Store) GetAccessRecords(start, end int64) ([]*compliance.AccessRecord, error) {
+	query := `
+		SELECT event_id, created_at, key_id, user_id, path, method, status_code, correlation_id FROM events WHERE created_at >= $1 AND created_at < $2 ORDER BY created_at
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	records := []*compliance.AccessRecord{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, start, end)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return records, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := &compliance.AccessRecord{}
+		var path sql.NullString
+		var method sql.NullString
+		if err := rows.Scan(
+			&r.EventId,
+			&r.CreatedAt,
+			&r.KeyId,
+			&r.UserId,
+			&path,
+			&method,
+			&r.Status,
+			&r.CorrelationId,
+		); err != nil {
+			return nil, err
+		}
+
+		r.Path = path.String
+		r.Method = method.String
+		records = append(records, r)
+	}
+
+	return records, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/provider/signer"
 )
 
 func (s *Store) CreateCustomProvidersTable() error {
@@ -32,11 +33,39 @@ func (s *Store) CreateCustomProvidersTable() error {
 	return nil
 }
 
+func (s *Store) AlterCustomProvidersTable() error {
+	alterTableQuery := `
+		ALTER TABLE custom_providers ADD COLUMN IF NOT EXISTS auth_config JSONB
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func parseAuthConfig(data []byte) (*signer.Config, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	cfg := &signer.Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
 func (s *Store) CreateCustomProvider(provider *custom.Provider) (*custom.Provider, error) {
 	query := `
-		INSERT INTO custom_providers (id, created_at, updated_at, provider, route_configs, authentication_param)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at, provider, route_configs, authentication_param
+		INSERT INTO custom_providers (id, created_at, updated_at, provider, route_configs, authentication_param, auth_config)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at, provider, route_configs, authentication_param, auth_config
 	`
 
 	bytes, err := json.Marshal(provider.RouteConfigs)
@@ -44,6 +73,14 @@ func (s *Store) CreateCustomProvider(provider *custom.Provider) (*custom.Provide
 		return nil, err
 	}
 
+	var authData []byte
+	if provider.Auth != nil {
+		authData, err = json.Marshal(provider.Auth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	values := []any{
 		provider.Id,
 		provider.CreatedAt,
@@ -51,6 +88,7 @@ func (s *Store) CreateCustomProvider(provider *custom.Provider) (*custom.Provide
 		provider.Provider,
 		bytes,
 		provider.AuthenticationParam,
+		authData,
 	}
 
 	created := &custom.Provider{}
@@ -66,6 +104,7 @@ func (s *Store) CreateCustomProvider(provider *custom.Provider) (*custom.Provide
 		&created.Provider,
 		&data,
 		&created.AuthenticationParam,
+		&authData,
 	); err != nil {
 		return nil, err
 	}
@@ -74,6 +113,13 @@ func (s *Store) CreateCustomProvider(provider *custom.Provider) (*custom.Provide
 		return nil, err
 	}
 
+	auth, err := parseAuthConfig(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	created.Auth = auth
+
 	return created, nil
 }
 
@@ -83,6 +129,7 @@ func (s *Store) GetCustomProviderByName(name string) (*custom.Provider, error) {
 
 	retrieved := &custom.Provider{}
 	var data []byte
+	var authData []byte
 	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM custom_providers WHERE $1 = provider", name).Scan(
 		&retrieved.Id,
 		&retrieved.CreatedAt,
@@ -90,6 +137,7 @@ func (s *Store) GetCustomProviderByName(name string) (*custom.Provider, error) {
 		&retrieved.Provider,
 		&data,
 		&retrieved.AuthenticationParam,
+		&authData,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("custom provider is not found")
@@ -97,6 +145,13 @@ func (s *Store) GetCustomProviderByName(name string) (*custom.Provider, error) {
 		return nil, err
 	}
 
+	auth, err := parseAuthConfig(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	retrieved.Auth = auth
+
 	return retrieved, nil
 }
 
@@ -106,6 +161,7 @@ func (s *Store) GetCustomProvider(id string) (*custom.Provider, error) {
 
 	retrieved := &custom.Provider{}
 	var data []byte
+	var authData []byte
 	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM custom_providers WHERE $1 = id", id).Scan(
 		&retrieved.Id,
 		&retrieved.CreatedAt,
@@ -113,6 +169,7 @@ func (s *Store) GetCustomProvider(id string) (*custom.Provider, error) {
 		&retrieved.Provider,
 		&data,
 		&retrieved.AuthenticationParam,
+		&authData,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("custom provider is not found")
@@ -124,6 +181,13 @@ func (s *Store) GetCustomProvider(id string) (*custom.Provider, error) {
 		return nil, err
 	}
 
+	auth, err := parseAuthConfig(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	retrieved.Auth = auth
+
 	return retrieved, nil
 }
 
@@ -141,6 +205,7 @@ func (s *Store) GetCustomProviders() ([]*custom.Provider, error) {
 	for rows.Next() {
 		provider := &custom.Provider{}
 		var data []byte
+		var authData []byte
 		if err := rows.Scan(
 			&provider.Id,
 			&provider.CreatedAt,
@@ -148,6 +213,7 @@ func (s *Store) GetCustomProviders() ([]*custom.Provider, error) {
 			&provider.Provider,
 			&data,
 			&provider.AuthenticationParam,
+			&authData,
 		); err != nil {
 			return nil, err
 		}
@@ -156,6 +222,13 @@ func (s *Store) GetCustomProviders() ([]*custom.Provider, error) {
 			return nil, err
 		}
 
+		auth, err := parseAuthConfig(authData)
+		if err != nil {
+			return nil, err
+		}
+
+		provider.Auth = auth
+
 		providers = append(providers, provider)
 	}
 
@@ -183,6 +256,17 @@ func (s *Store) UpdateCustomProvider(id string, provider *custom.UpdateProvider)
 		counter++
 	}
 
+	if provider.Auth != nil {
+		authData, err := json.Marshal(provider.Auth)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, authData)
+		fields = append(fields, fmt.Sprintf("auth_config = $%d", counter))
+		counter++
+	}
+
 	if provider.UpdatedAt != 0 {
 		values = append(values, provider.UpdatedAt)
 		fields = append(fields, fmt.Sprintf("updated_at = $%d", counter))
@@ -208,6 +292,7 @@ func (s *Store) UpdateCustomProvider(id string, provider *custom.UpdateProvider)
 
 	updated := &custom.Provider{}
 	var updatedData []byte
+	var authData []byte
 
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&updated.Id,
@@ -216,6 +301,7 @@ func (s *Store) UpdateCustomProvider(id string, provider *custom.UpdateProvider)
 		&updated.Provider,
 		&updatedData,
 		&updated.AuthenticationParam,
+		&authData,
 	); err != nil {
 		return nil, err
 	}
@@ -224,6 +310,13 @@ func (s *Store) UpdateCustomProvider(id string, provider *custom.UpdateProvider)
 		return nil, err
 	}
 
+	auth, err := parseAuthConfig(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.Auth = auth
+
 	return updated, nil
 }
 
@@ -241,6 +334,7 @@ func (s *Store) GetUpdatedCustomProviders(updatedAt int64) ([]*custom.Provider,
 	for rows.Next() {
 		provider := &custom.Provider{}
 		var data []byte
+		var authData []byte
 
 		if err := rows.Scan(
 			&provider.Id,
@@ -249,6 +343,7 @@ func (s *Store) GetUpdatedCustomProviders(updatedAt int64) ([]*custom.Provider,
 			&provider.Provider,
 			&data,
 			&provider.AuthenticationParam,
+			&authData,
 		); err != nil {
 			return nil, err
 		}
@@ -257,6 +352,13 @@ func (s *Store) GetUpdatedCustomProviders(updatedAt int64) ([]*custom.Provider,
 			return nil, err
 		}
 
+		auth, err := parseAuthConfig(authData)
+		if err != nil {
+			return nil, err
+		}
+
+		provider.Auth = auth
+
 		providers = append(providers, provider)
 	}
 
@@ -3,11 +3,15 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/lib/pq"
 )
 
@@ -37,7 +41,7 @@ func (s *Store) CreateEventsByDayTable() error {
 
 func (s *Store) AlterEventsTable() error {
 	alterTableQuery := `
-		ALTER TABLE events ADD COLUMN IF NOT EXISTS path VARCHAR(255), ADD COLUMN IF NOT EXISTS method VARCHAR(255), ADD COLUMN IF NOT EXISTS custom_id VARCHAR(255), ADD COLUMN IF NOT EXISTS request JSONB, ADD COLUMN IF NOT EXISTS response JSONB, ADD COLUMN IF NOT EXISTS user_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS action VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS route_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS metadata JSONB;
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS path VARCHAR(255), ADD COLUMN IF NOT EXISTS method VARCHAR(255), ADD COLUMN IF NOT EXISTS custom_id VARCHAR(255), ADD COLUMN IF NOT EXISTS request JSONB, ADD COLUMN IF NOT EXISTS response JSONB, ADD COLUMN IF NOT EXISTS user_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS action VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS route_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS metadata JSONB, ADD COLUMN IF NOT EXISTS session_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS pii_findings JSONB, ADD COLUMN IF NOT EXISTS policy_exemption JSONB, ADD COLUMN IF NOT EXISTS region VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS request_tags VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR(255)[], ADD COLUMN IF NOT EXISTS content_filter_results JSONB, ADD COLUMN IF NOT EXISTS guardrail_intervention JSONB, ADD COLUMN IF NOT EXISTS citations JSONB, ADD COLUMN IF NOT EXISTS reasoning_token_count INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS retry_count INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS policy_rules JSONB, ADD COLUMN IF NOT EXISTS pricing_version VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS customer_price_in_usd FLOAT8 NOT NULL DEFAULT 0;
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -92,6 +96,20 @@ func (s *Store) CreateKeyIdIndexForEventsTable() error {
 	return nil
 }
 
+func (s *Store) CreateKeyIdAndCreatedAtIndexForEventsTable() error {
+	createIndexQuery := `
+	CREATE index IF NOT EXISTS idx_events_key_id_and_created_at on events (key_id, created_at);`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createIndexQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Store) CreateEventsTable() error {
 	createTableQuery := `
 	CREATE TABLE IF NOT EXISTS events (
@@ -193,6 +211,19 @@ func (s *Store) GetEvents(userId string, customId string, keyIds []string, start
 			&e.RouteId,
 			&e.CorrelationId,
 			&e.Metadata,
+			&e.SessionId,
+			&e.PiiFindings,
+			&e.PolicyExemption,
+			&e.Region,
+			pq.Array(&e.RequestTags),
+			&e.ContentFilterResults,
+			&e.GuardrailIntervention,
+			&e.Citations,
+			&e.ReasoningTokenCount,
+			&e.RetryCount,
+			&e.PolicyRules,
+			&e.PricingVersion,
+			&e.CustomerPriceInUsd,
 		); err != nil {
 			return nil, err
 		}
@@ -301,6 +332,52 @@ func (s *Store) GetCustomIds(keyId string) ([]string, error) {
 	return result, nil
 }
 
+// notShadowCondition excludes events of requests mirrored to shadow models
+// from spend aggregations. Spend of mirrored requests is not charged to keys.
+var notShadowCondition = fmt.Sprintf("NOT ('%s' = ANY(request_tags))", route.ShadowTag)
+
+func (s *Store) GetSessions(keyId string, start, end int64) ([]*event.SessionReporting, error) {
+	query := `
+	SELECT session_id, key_id, COUNT(*), COALESCE(SUM(cost_in_usd), 0), COALESCE(SUM(prompt_token_count), 0), COALESCE(SUM(completion_token_count), 0), MIN(created_at), MAX(created_at)
+	FROM events
+	WHERE key_id = $1 AND NOT session_id = '' AND created_at >= $2 AND created_at <= $3
+	GROUP BY session_id, key_id
+	ORDER BY MAX(created_at) DESC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, keyId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*event.SessionReporting{}
+
+	for rows.Next() {
+		sr := &event.SessionReporting{}
+
+		if err := rows.Scan(
+			&sr.SessionId,
+			&sr.KeyId,
+			&sr.NumberOfRequests,
+			&sr.CostInUsd,
+			&sr.PromptTokenCount,
+			&sr.CompletionTokenCount,
+			&sr.FirstRequestAt,
+			&sr.LastRequestAt,
+		); err != nil {
+			return nil, err
+		}
+
+		result = append(result, sr)
+	}
+
+	return result, nil
+}
+
 func (s *Store) GetUserIds(keyId string) ([]string, error) {
 	query := fmt.Sprintf(`
 	SELECT DISTINCT user_id
@@ -405,7 +482,7 @@ func (s *Store) GetTopKeyDataPoints(start, end int64, tags, keyIds []string, ord
 		FROM events
 		LEFT JOIN keys
 		ON keys.key_id = events.key_id
-		WHERE (events.key_id = '') IS FALSE AND events.created_at >= %d AND events.created_at < %d %s
+		WHERE (events.key_id = '') IS FALSE AND events.created_at >= %d AND events.created_at < %d AND %s %s
 		GROUP BY events.key_id
 	)
 	SELECT CASE
@@ -418,7 +495,7 @@ func (s *Store) GetTopKeyDataPoints(start, end int64, tags, keyIds []string, ord
 		FULL JOIN top_keys_table
 		ON top_keys_table.key_id = keys_table.key_id 
 
-`, start, end, condition, start, end, condition2)
+`, start, end, condition, start, end, notShadowCondition, condition2)
 
 	qorder := "DESC"
 	if len(order) != 0 && strings.ToUpper(order) == "ASC" {
@@ -526,7 +603,7 @@ func (s *Store) GetAggregatedEventByDayDataPoints(start, end int64, keyIds []str
 	return data, nil
 }
 
-func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, customIds, userIds []string, filters []string) ([]*event.DataPoint, error) {
+func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, customIds, userIds, requestTags []string, filters []string) ([]*event.DataPoint, error) {
 	groupByQuery := "GROUP BY time_series_table.series"
 	selectQuery := "SELECT series AS time_stamp, COALESCE(COUNT(events_table.event_id),0) AS num_of_requests, COALESCE(SUM(events_table.cost_in_usd),0) AS cost_in_usd, COALESCE(SUM(events_table.latency_in_ms),0) AS latency_in_ms, COALESCE(SUM(events_table.prompt_token_count),0) AS prompt_token_count, COALESCE(SUM(events_table.completion_token_count),0) AS completion_token_count, COALESCE(SUM(CASE WHEN status_code = 200 THEN 1 END),0) AS success_count"
 
@@ -551,6 +628,11 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 				groupByQuery += ",events_table.user_id"
 				selectQuery += ",events_table.user_id as userId"
 			}
+
+			if filter == "requestTag" {
+				groupByQuery += ",events_table.request_tag"
+				selectQuery += ",events_table.request_tag as requestTag"
+			}
 		}
 	}
 
@@ -577,6 +659,16 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 			SELECT * FROM events 
 	`
 
+	// events are expanded into one row per request tag for grouping by tags
+	if containsFilter(filters, "requestTag") {
+		eventSelectionBlock = `
+		WITH events_table AS
+			(
+				SELECT *, unnest(request_tags) AS request_tag FROM events 
+		`
+	}
+
+	args := []any{}
 	conditionBlock := fmt.Sprintf("WHERE created_at >= %d AND created_at < %d ", start, end)
 	if len(tags) != 0 {
 		conditionBlock += fmt.Sprintf("AND tags @> '%s' ", sliceToSqlStringArray(tags))
@@ -594,6 +686,16 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 		conditionBlock += fmt.Sprintf("AND user_id = ANY('%s')", sliceToSqlStringArray(userIds))
 	}
 
+	if len(requestTags) != 0 {
+		args = append(args, pq.Array(requestTags))
+		conditionBlock += fmt.Sprintf("AND request_tags @> $%d ", len(args))
+	}
+
+	// mirrored requests are only reported when their tag is requested.
+	if !slices.Contains(requestTags, route.ShadowTag) {
+		conditionBlock += "AND " + notShadowCondition + " "
+	}
+
 	eventSelectionBlock += conditionBlock
 	eventSelectionBlock += ")"
 
@@ -602,7 +704,7 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
 	defer cancel()
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -615,6 +717,7 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 		var keyId sql.NullString
 		var customId sql.NullString
 		var userId sql.NullString
+		var requestTag sql.NullString
 
 		additional := []any{
 			&e.TimeStamp,
@@ -643,6 +746,10 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 				if filter == "userId" {
 					additional = append(additional, &userId)
 				}
+
+				if filter == "requestTag" {
+					additional = append(additional, &requestTag)
+				}
 			}
 		}
 
@@ -657,6 +764,7 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 		pe.KeyId = keyId.String
 		pe.CustomId = customId.String
 		pe.UserId = userId.String
+		pe.RequestTag = requestTag.String
 
 		data = append(data, pe)
 	}
@@ -673,6 +781,8 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 	SELECT COUNT(*) FROM events WHERE created_at >= %d AND created_at < %d
 `, req.Start, req.End)
 
+	args := []any{}
+
 	if len(req.UserIds) != 0 {
 		query += fmt.Sprintf(" AND user_id = ANY('%s')", sliceToSqlStringArray(req.UserIds))
 		cquery += fmt.Sprintf(" AND user_id = ANY('%s')", sliceToSqlStringArray(req.UserIds))
@@ -688,6 +798,24 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 		cquery += fmt.Sprintf(" AND custom_id = ANY('%s')", sliceToSqlStringArray(req.CustomIds))
 	}
 
+	if len(req.SessionIds) != 0 {
+		args = append(args, pq.Array(req.SessionIds))
+		query += fmt.Sprintf(" AND session_id = ANY($%d)", len(args))
+		cquery += fmt.Sprintf(" AND session_id = ANY($%d)", len(args))
+	}
+
+	if len(req.Regions) != 0 {
+		args = append(args, pq.Array(req.Regions))
+		query += fmt.Sprintf(" AND region = ANY($%d)", len(args))
+		cquery += fmt.Sprintf(" AND region = ANY($%d)", len(args))
+	}
+
+	if len(req.RequestTags) != 0 {
+		args = append(args, pq.Array(req.RequestTags))
+		query += fmt.Sprintf(" AND request_tags @> $%d", len(args))
+		cquery += fmt.Sprintf(" AND request_tags @> $%d", len(args))
+	}
+
 	if len(req.KeyIds) != 0 {
 		query += fmt.Sprintf(" AND key_id = ANY('%s')", sliceToSqlStringArray(req.KeyIds))
 		cquery += fmt.Sprintf(" AND key_id = ANY('%s')", sliceToSqlStringArray(req.KeyIds))
@@ -727,7 +855,7 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 
 	if req.ReturnCount {
 		count := 0
-		err := s.db.QueryRowContext(qrContext, cquery).Scan(&count)
+		err := s.db.QueryRowContext(qrContext, cquery, args...).Scan(&count)
 		if err != nil {
 			if err != sql.ErrNoRows {
 				return nil, err
@@ -741,7 +869,7 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 	defer cancel()
 
 	events := []*event.Event{}
-	rows, err := s.db.QueryContext(ctxTimeout, query)
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -777,6 +905,19 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 			&e.RouteId,
 			&e.CorrelationId,
 			&e.Metadata,
+			&e.SessionId,
+			&e.PiiFindings,
+			&e.PolicyExemption,
+			&e.Region,
+			pq.Array(&e.RequestTags),
+			&e.ContentFilterResults,
+			&e.GuardrailIntervention,
+			&e.Citations,
+			&e.ReasoningTokenCount,
+			&e.RetryCount,
+			&e.PolicyRules,
+			&e.PricingVersion,
+			&e.CustomerPriceInUsd,
 		); err != nil {
 			return nil, err
 		}
@@ -794,10 +935,191 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 	return resp, nil
 }
 
+func (s *Store) GetPiiFindings(req *event.PiiFindingsRequest) ([]*event.PiiFindingsRecord, error) {
+	query := fmt.Sprintf(`
+		SELECT event_id, created_at, key_id, policy_id, action, pii_findings FROM events WHERE created_at >= %d AND created_at < %d AND pii_findings IS NOT NULL
+	`, req.Start, req.End)
+
+	args := []any{}
+	if len(req.KeyIds) != 0 {
+		args = append(args, pq.Array(req.KeyIds))
+		query += fmt.Sprintf(" AND key_id = ANY($%d)", len(args))
+	}
+
+	if len(req.Tags) != 0 {
+		args = append(args, pq.Array(req.Tags))
+		query += fmt.Sprintf(" AND tags @> $%d", len(args))
+	}
+
+	if len(req.PolicyIds) != 0 {
+		args = append(args, pq.Array(req.PolicyIds))
+		query += fmt.Sprintf(" AND policy_id = ANY($%d)", len(args))
+	}
+
+	if len(req.Types) != 0 {
+		args = append(args, pq.Array(req.Types))
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM jsonb_array_elements(pii_findings) AS f WHERE f->>'type' = ANY($%d))", len(args))
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	if req.Limit != 0 {
+		query += fmt.Sprintf(` LIMIT %d OFFSET %d;`, req.Limit, req.Offset)
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	records := []*event.PiiFindingsRecord{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return records, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := &event.PiiFindingsRecord{}
+		var data []byte
+
+		if err := rows.Scan(
+			&r.EventId,
+			&r.CreatedAt,
+			&r.KeyId,
+			&r.PolicyId,
+			&r.Action,
+			&data,
+		); err != nil {
+			return nil, err
+		}
+
+		findings := []*pii.Finding{}
+		if err := json.Unmarshal(data, &findings); err != nil {
+			return nil, err
+		}
+
+		r.Findings = findings
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// GetEventsForErasure returns events associated with any of the given user ids,
+// custom ids or correlation ids. Only fields needed for erasure are populated.
+func (s *Store) GetEventsForErasure(userIds, customIds, correlationIds []string) ([]*event.Event, error) {
+	query := `
+		SELECT event_id, key_id, path, request, route_id FROM events WHERE user_id = ANY($1) OR custom_id = ANY($2) OR correlation_id = ANY($3)
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	events := []*event.Event{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, pq.Array(userIds), pq.Array(customIds), pq.Array(correlationIds))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return events, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := &event.Event{}
+		var path sql.NullString
+
+		if err := rows.Scan(
+			&e.Id,
+			&e.KeyId,
+			&path,
+			&e.Request,
+			&e.RouteId,
+		); err != nil {
+			return nil, err
+		}
+
+		e.Path = path.String
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+func (s *Store) DeleteEventsByIds(ids []string) (int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM events WHERE event_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// GetSpendByKeyIdsSince sums the cost of the events of keys created since a
+// unix timestamp by key id. Events of mirrored requests are excluded.
+func (s *Store) GetSpendByKeyIdsSince(keyIds []string, since int64) (map[string]float64, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	spend := map[string]float64{}
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT key_id, COALESCE(SUM(cost_in_usd), 0) FROM events WHERE key_id = ANY($1) AND created_at >= $2 AND "+notShadowCondition+" GROUP BY key_id", pq.Array(keyIds), since)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return spend, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keyId string
+		var cost float64
+		if err := rows.Scan(&keyId, &cost); err != nil {
+			return nil, err
+		}
+
+		spend[keyId] = cost
+	}
+
+	return spend, nil
+}
+
+func (s *Store) DeleteEventsByKeyIdBefore(keyId string, before int64) (int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "DELETE FROM events WHERE key_id = $1 AND created_at < $2", keyId, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *Store) StripEventPayloadsByKeyIdBefore(keyId string, before int64) (int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "UPDATE events SET request = NULL, response = NULL WHERE key_id = $1 AND created_at < $2 AND (request IS NOT NULL OR response IS NOT NULL)", keyId, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
 func (s *Store) InsertEvent(e *event.Event) error {
 	query := `
-		INSERT INTO events (event_id, created_at, tags, key_id, cost_in_usd, provider, model, status_code, prompt_token_count, completion_token_count, latency_in_ms, path, method, custom_id, request, response, user_id, action, policy_id, route_id, correlation_id, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		INSERT INTO events (event_id, created_at, tags, key_id, cost_in_usd, provider, model, status_code, prompt_token_count, completion_token_count, latency_in_ms, path, method, custom_id, request, response, user_id, action, policy_id, route_id, correlation_id, metadata, session_id, pii_findings, policy_exemption, region, request_tags, content_filter_results, guardrail_intervention, citations, reasoning_token_count, retry_count, policy_rules, pricing_version, customer_price_in_usd)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35)
 	`
 
 	values := []any{
@@ -823,6 +1145,19 @@ func (s *Store) InsertEvent(e *event.Event) error {
 		e.RouteId,
 		e.CorrelationId,
 		e.Metadata,
+		e.SessionId,
+		e.PiiFindings,
+		e.PolicyExemption,
+		e.Region,
+		pq.Array(e.RequestTags),
+		e.ContentFilterResults,
+		e.GuardrailIntervention,
+		e.Citations,
+		e.ReasoningTokenCount,
+		e.RetryCount,
+		e.PolicyRules,
+		e.PricingVersion,
+		e.CustomerPriceInUsd,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -833,3 +1168,13 @@ func (s *Store) InsertEvent(e *event.Event) error {
 
 	return nil
 }
+
+func containsFilter(filters []string, target string) bool {
+	for _, filter := range filters {
+		if filter == target {
+			return true
+		}
+	}
+
+	return false
+}
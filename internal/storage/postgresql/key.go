@@ -55,7 +55,7 @@ func (s *Store) AlterKeysTable() error {
 			END IF;
 		END
 		$$;
-		ALTER TABLE keys ADD COLUMN IF NOT EXISTS setting_id VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_paths JSONB, ADD COLUMN IF NOT EXISTS setting_ids VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR(255)[], ADD COLUMN IF NOT EXISTS should_log_request BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS should_log_response BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS rotation_enabled BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS is_key_not_hashed BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS setting_id VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_paths JSONB, ADD COLUMN IF NOT EXISTS setting_ids VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR(255)[], ADD COLUMN IF NOT EXISTS should_log_request BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS should_log_response BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS rotation_enabled BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS is_key_not_hashed BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS session_cost_limit_in_usd FLOAT8 NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS session_token_limit INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS retention_in_days INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS payload_retention_in_days INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS metadata_only BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS policy_exempt BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS limit_override JSONB, ADD COLUMN IF NOT EXISTS block_message JSONB, ADD COLUMN IF NOT EXISTS file_policy JSONB, ADD COLUMN IF NOT EXISTS rotation_strategy VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS model_aliases JSONB, ADD COLUMN IF NOT EXISTS budget_downgrade JSONB, ADD COLUMN IF NOT EXISTS priority VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS markup JSONB;
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -164,6 +164,12 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var overrideData []byte
+		var blockMessageData []byte
+		var filePolicyData []byte
+		var modelAliasesData []byte
+		var budgetDowngradeData []byte
+		var markupData []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -187,6 +193,20 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.SessionCostLimitInUsd,
+			&k.SessionTokenLimit,
+			&k.RetentionInDays,
+			&k.PayloadRetentionInDays,
+			&k.MetadataOnly,
+			&k.PolicyExempt,
+			&overrideData,
+			&blockMessageData,
+			&filePolicyData,
+			&k.RotationStrategy,
+			&modelAliasesData,
+			&budgetDowngradeData,
+			&k.Priority,
+			&markupData,
 		); err != nil {
 			return nil, err
 		}
@@ -194,6 +214,48 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 		pk := &k
 		pk.SettingId = settingId.String
 
+		lo, err := parseLimitOverride(overrideData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.LimitOverride = lo
+
+		bm, err := parseBlockMessage(blockMessageData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BlockMessage = bm
+
+		fp, err := parseFilePolicy(filePolicyData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.FilePolicy = fp
+
+		ma, err := parseModelAliases(modelAliasesData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.ModelAliases = ma
+
+		bd, err := parseBudgetDowngrade(budgetDowngradeData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BudgetDowngrade = bd
+
+		mk, err := parseMarkup(markupData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.Markup = mk
+
 		if len(data) != 0 {
 			pathConfigs := []key.PathConfig{}
 			if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -288,6 +350,12 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var overrideData []byte
+		var blockMessageData []byte
+		var filePolicyData []byte
+		var modelAliasesData []byte
+		var budgetDowngradeData []byte
+		var markupData []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -311,6 +379,20 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.SessionCostLimitInUsd,
+			&k.SessionTokenLimit,
+			&k.RetentionInDays,
+			&k.PayloadRetentionInDays,
+			&k.MetadataOnly,
+			&k.PolicyExempt,
+			&overrideData,
+			&blockMessageData,
+			&filePolicyData,
+			&k.RotationStrategy,
+			&modelAliasesData,
+			&budgetDowngradeData,
+			&k.Priority,
+			&markupData,
 		); err != nil {
 			return nil, err
 		}
@@ -318,6 +400,48 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 		pk := &k
 		pk.SettingId = settingId.String
 
+		lo, err := parseLimitOverride(overrideData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.LimitOverride = lo
+
+		bm, err := parseBlockMessage(blockMessageData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BlockMessage = bm
+
+		fp, err := parseFilePolicy(filePolicyData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.FilePolicy = fp
+
+		ma, err := parseModelAliases(modelAliasesData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.ModelAliases = ma
+
+		bd, err := parseBudgetDowngrade(budgetDowngradeData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BudgetDowngrade = bd
+
+		mk, err := parseMarkup(markupData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.Markup = mk
+
 		if len(data) != 0 {
 			pathConfigs := []key.PathConfig{}
 			if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -365,6 +489,12 @@ func (s *Store) GetKeyByHash(hash string) (*key.ResponseKey, error) {
 	var k key.ResponseKey
 	var settingId sql.NullString
 	var data []byte
+	var overrideData []byte
+	var blockMessageData []byte
+	var filePolicyData []byte
+	var modelAliasesData []byte
+	var budgetDowngradeData []byte
+	var markupData []byte
 
 	err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM keys WHERE key = $1", hash).Scan(
 		&k.Name,
@@ -389,6 +519,20 @@ func (s *Store) GetKeyByHash(hash string) (*key.ResponseKey, error) {
 		&k.RotationEnabled,
 		&k.PolicyId,
 		&k.IsKeyNotHashed,
+		&k.SessionCostLimitInUsd,
+		&k.SessionTokenLimit,
+		&k.RetentionInDays,
+		&k.PayloadRetentionInDays,
+		&k.MetadataOnly,
+		&k.PolicyExempt,
+		&overrideData,
+		&blockMessageData,
+		&filePolicyData,
+		&k.RotationStrategy,
+		&modelAliasesData,
+		&budgetDowngradeData,
+		&k.Priority,
+		&markupData,
 	)
 
 	if err != nil {
@@ -401,6 +545,48 @@ func (s *Store) GetKeyByHash(hash string) (*key.ResponseKey, error) {
 
 	k.SettingId = settingId.String
 
+	lo, err := parseLimitOverride(overrideData)
+	if err != nil {
+		return nil, err
+	}
+
+	k.LimitOverride = lo
+
+	bm, err := parseBlockMessage(blockMessageData)
+	if err != nil {
+		return nil, err
+	}
+
+	k.BlockMessage = bm
+
+	fp, err := parseFilePolicy(filePolicyData)
+	if err != nil {
+		return nil, err
+	}
+
+	k.FilePolicy = fp
+
+	ma, err := parseModelAliases(modelAliasesData)
+	if err != nil {
+		return nil, err
+	}
+
+	k.ModelAliases = ma
+
+	bd, err := parseBudgetDowngrade(budgetDowngradeData)
+	if err != nil {
+		return nil, err
+	}
+
+	k.BudgetDowngrade = bd
+
+	mk, err := parseMarkup(markupData)
+	if err != nil {
+		return nil, err
+	}
+
+	k.Markup = mk
+
 	if len(data) != 0 {
 		pathConfigs := []key.PathConfig{}
 		if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -428,6 +614,12 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var overrideData []byte
+		var blockMessageData []byte
+		var filePolicyData []byte
+		var modelAliasesData []byte
+		var budgetDowngradeData []byte
+		var markupData []byte
 
 		if err := rows.Scan(
 			&k.Name,
@@ -452,6 +644,20 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.SessionCostLimitInUsd,
+			&k.SessionTokenLimit,
+			&k.RetentionInDays,
+			&k.PayloadRetentionInDays,
+			&k.MetadataOnly,
+			&k.PolicyExempt,
+			&overrideData,
+			&blockMessageData,
+			&filePolicyData,
+			&k.RotationStrategy,
+			&modelAliasesData,
+			&budgetDowngradeData,
+			&k.Priority,
+			&markupData,
 		); err != nil {
 			return nil, err
 		}
@@ -459,6 +665,48 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 		pk := &k
 		pk.SettingId = settingId.String
 
+		lo, err := parseLimitOverride(overrideData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.LimitOverride = lo
+
+		bm, err := parseBlockMessage(blockMessageData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BlockMessage = bm
+
+		fp, err := parseFilePolicy(filePolicyData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.FilePolicy = fp
+
+		ma, err := parseModelAliases(modelAliasesData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.ModelAliases = ma
+
+		bd, err := parseBudgetDowngrade(budgetDowngradeData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BudgetDowngrade = bd
+
+		mk, err := parseMarkup(markupData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.Markup = mk
+
 		if len(data) != 0 {
 			pathConfigs := []key.PathConfig{}
 			if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -493,6 +741,12 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var overrideData []byte
+		var blockMessageData []byte
+		var filePolicyData []byte
+		var modelAliasesData []byte
+		var budgetDowngradeData []byte
+		var markupData []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -516,12 +770,68 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.SessionCostLimitInUsd,
+			&k.SessionTokenLimit,
+			&k.RetentionInDays,
+			&k.PayloadRetentionInDays,
+			&k.MetadataOnly,
+			&k.PolicyExempt,
+			&overrideData,
+			&blockMessageData,
+			&filePolicyData,
+			&k.RotationStrategy,
+			&modelAliasesData,
+			&budgetDowngradeData,
+			&k.Priority,
+			&markupData,
 		); err != nil {
 			return nil, err
 		}
 		pk := &k
 		pk.SettingId = settingId.String
 
+		lo, err := parseLimitOverride(overrideData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.LimitOverride = lo
+
+		bm, err := parseBlockMessage(blockMessageData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BlockMessage = bm
+
+		fp, err := parseFilePolicy(filePolicyData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.FilePolicy = fp
+
+		ma, err := parseModelAliases(modelAliasesData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.ModelAliases = ma
+
+		bd, err := parseBudgetDowngrade(budgetDowngradeData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BudgetDowngrade = bd
+
+		mk, err := parseMarkup(markupData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.Markup = mk
+
 		if len(data) != 0 {
 			pathConfigs := []key.PathConfig{}
 			if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -552,6 +862,12 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var overrideData []byte
+		var blockMessageData []byte
+		var filePolicyData []byte
+		var modelAliasesData []byte
+		var budgetDowngradeData []byte
+		var markupData []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -575,12 +891,68 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.SessionCostLimitInUsd,
+			&k.SessionTokenLimit,
+			&k.RetentionInDays,
+			&k.PayloadRetentionInDays,
+			&k.MetadataOnly,
+			&k.PolicyExempt,
+			&overrideData,
+			&blockMessageData,
+			&filePolicyData,
+			&k.RotationStrategy,
+			&modelAliasesData,
+			&budgetDowngradeData,
+			&k.Priority,
+			&markupData,
 		); err != nil {
 			return nil, err
 		}
 
 		pk := &k
 		pk.SettingId = settingId.String
+
+		lo, err := parseLimitOverride(overrideData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.LimitOverride = lo
+
+		bm, err := parseBlockMessage(blockMessageData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BlockMessage = bm
+
+		fp, err := parseFilePolicy(filePolicyData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.FilePolicy = fp
+
+		ma, err := parseModelAliases(modelAliasesData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.ModelAliases = ma
+
+		bd, err := parseBudgetDowngrade(budgetDowngradeData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.BudgetDowngrade = bd
+
+		mk, err := parseMarkup(markupData)
+		if err != nil {
+			return nil, err
+		}
+
+		pk.Markup = mk
 		if len(data) != 0 {
 			pathConfigs := []key.PathConfig{}
 			if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -707,6 +1079,7 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 
 		values = append(values, data)
 		fields = append(fields, fmt.Sprintf("allowed_paths = $%d", counter))
+		counter++
 	}
 
 	if uk.PolicyId != nil {
@@ -721,6 +1094,119 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		counter++
 	}
 
+	if uk.SessionCostLimitInUsd != nil {
+		values = append(values, *uk.SessionCostLimitInUsd)
+		fields = append(fields, fmt.Sprintf("session_cost_limit_in_usd = $%d", counter))
+		counter++
+	}
+
+	if uk.SessionTokenLimit != nil {
+		values = append(values, *uk.SessionTokenLimit)
+		fields = append(fields, fmt.Sprintf("session_token_limit = $%d", counter))
+		counter++
+	}
+
+	if uk.RetentionInDays != nil {
+		values = append(values, *uk.RetentionInDays)
+		fields = append(fields, fmt.Sprintf("retention_in_days = $%d", counter))
+		counter++
+	}
+
+	if uk.PayloadRetentionInDays != nil {
+		values = append(values, *uk.PayloadRetentionInDays)
+		fields = append(fields, fmt.Sprintf("payload_retention_in_days = $%d", counter))
+		counter++
+	}
+
+	if uk.MetadataOnly != nil {
+		values = append(values, *uk.MetadataOnly)
+		fields = append(fields, fmt.Sprintf("metadata_only = $%d", counter))
+		counter++
+	}
+
+	if uk.PolicyExempt != nil {
+		values = append(values, *uk.PolicyExempt)
+		fields = append(fields, fmt.Sprintf("policy_exempt = $%d", counter))
+		counter++
+	}
+
+	if uk.BlockMessage != nil {
+		data, err := json.Marshal(uk.BlockMessage)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("block_message = $%d", counter))
+		counter++
+	}
+
+	if uk.FilePolicy != nil {
+		data, err := json.Marshal(uk.FilePolicy)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("file_policy = $%d", counter))
+		counter++
+	}
+
+	if uk.RotationStrategy != nil {
+		values = append(values, *uk.RotationStrategy)
+		fields = append(fields, fmt.Sprintf("rotation_strategy = $%d", counter))
+		counter++
+	}
+
+	if uk.ModelAliases != nil {
+		data, err := json.Marshal(uk.ModelAliases)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("model_aliases = $%d", counter))
+		counter++
+	}
+
+	if uk.BudgetDowngrade != nil {
+		var data []byte
+		if len(uk.BudgetDowngrade.FallbackModels) != 0 {
+			bs, err := json.Marshal(uk.BudgetDowngrade)
+			if err != nil {
+				return nil, err
+			}
+
+			data = bs
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("budget_downgrade = $%d", counter))
+		counter++
+	}
+
+	if uk.Priority != nil {
+		values = append(values, *uk.Priority)
+		fields = append(fields, fmt.Sprintf("priority = $%d", counter))
+		counter++
+	}
+
+	if uk.Markup != nil {
+		var data []byte
+		if !uk.Markup.IsEmpty() {
+			bs, err := json.Marshal(uk.Markup)
+			if err != nil {
+				return nil, err
+			}
+
+			data = bs
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("markup = $%d", counter))
+		counter++
+	}
+
 	query := fmt.Sprintf("UPDATE keys SET %s WHERE key_id = $1 RETURNING *;", strings.Join(fields, ","))
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -729,6 +1215,12 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 	var k key.ResponseKey
 	var settingId sql.NullString
 	var data []byte
+	var overrideData []byte
+	var blockMessageData []byte
+	var filePolicyData []byte
+	var modelAliasesData []byte
+	var budgetDowngradeData []byte
+	var markupData []byte
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&k.Name,
 		&k.CreatedAt,
@@ -752,6 +1244,20 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		&k.RotationEnabled,
 		&k.PolicyId,
 		&k.IsKeyNotHashed,
+		&k.SessionCostLimitInUsd,
+		&k.SessionTokenLimit,
+		&k.RetentionInDays,
+		&k.PayloadRetentionInDays,
+		&k.MetadataOnly,
+		&k.PolicyExempt,
+		&overrideData,
+		&blockMessageData,
+		&filePolicyData,
+		&k.RotationStrategy,
+		&modelAliasesData,
+		&budgetDowngradeData,
+		&k.Priority,
+		&markupData,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("key not found for id: %s", id))
@@ -762,6 +1268,48 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 	pk := &k
 	pk.SettingId = settingId.String
 
+	lo, err := parseLimitOverride(overrideData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.LimitOverride = lo
+
+	bm, err := parseBlockMessage(blockMessageData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.BlockMessage = bm
+
+	fp, err := parseFilePolicy(filePolicyData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.FilePolicy = fp
+
+	ma, err := parseModelAliases(modelAliasesData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.ModelAliases = ma
+
+	bd, err := parseBudgetDowngrade(budgetDowngradeData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.BudgetDowngrade = bd
+
+	mk, err := parseMarkup(markupData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.Markup = mk
+
 	if len(data) != 0 {
 		pathConfigs := []key.PathConfig{}
 		if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -776,8 +1324,8 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 
 func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 	query := `
-		INSERT INTO keys (name, created_at, updated_at, tags, revoked, key_id, key, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, setting_id, allowed_paths, setting_ids, should_log_request, should_log_response, rotation_enabled, policy_id, is_key_not_hashed)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		INSERT INTO keys (name, created_at, updated_at, tags, revoked, key_id, key, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, setting_id, allowed_paths, setting_ids, should_log_request, should_log_response, rotation_enabled, policy_id, is_key_not_hashed, session_cost_limit_in_usd, session_token_limit, retention_in_days, payload_retention_in_days, metadata_only, policy_exempt, block_message, file_policy, rotation_strategy, model_aliases, budget_downgrade, priority, markup)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35)
 		RETURNING *;
 	`
 
@@ -786,6 +1334,46 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		return nil, err
 	}
 
+	var bmdata []byte
+	if rk.BlockMessage != nil {
+		bmdata, err = json.Marshal(rk.BlockMessage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fpdata []byte
+	if rk.FilePolicy != nil {
+		fpdata, err = json.Marshal(rk.FilePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var madata []byte
+	if len(rk.ModelAliases) != 0 {
+		madata, err = json.Marshal(rk.ModelAliases)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var bddata []byte
+	if rk.BudgetDowngrade != nil {
+		bddata, err = json.Marshal(rk.BudgetDowngrade)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mkdata []byte
+	if rk.Markup != nil {
+		mkdata, err = json.Marshal(rk.Markup)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	values := []any{
 		rk.Name,
 		rk.CreatedAt,
@@ -809,6 +1397,19 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		rk.RotationEnabled,
 		rk.PolicyId,
 		rk.IsKeyNotHashed,
+		rk.SessionCostLimitInUsd,
+		rk.SessionTokenLimit,
+		rk.RetentionInDays,
+		rk.PayloadRetentionInDays,
+		rk.MetadataOnly,
+		rk.PolicyExempt,
+		bmdata,
+		fpdata,
+		rk.RotationStrategy,
+		madata,
+		bddata,
+		rk.Priority,
+		mkdata,
 	}
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -818,6 +1419,12 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 
 	var settingId sql.NullString
 	var data []byte
+	var overrideData []byte
+	var blockMessageData []byte
+	var filePolicyData []byte
+	var modelAliasesData []byte
+	var budgetDowngradeData []byte
+	var markupData []byte
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&k.Name,
 		&k.CreatedAt,
@@ -841,6 +1448,20 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		&k.RotationEnabled,
 		&k.PolicyId,
 		&k.IsKeyNotHashed,
+		&k.SessionCostLimitInUsd,
+		&k.SessionTokenLimit,
+		&k.RetentionInDays,
+		&k.PayloadRetentionInDays,
+		&k.MetadataOnly,
+		&k.PolicyExempt,
+		&overrideData,
+		&blockMessageData,
+		&filePolicyData,
+		&k.RotationStrategy,
+		&modelAliasesData,
+		&budgetDowngradeData,
+		&k.Priority,
+		&markupData,
 	); err != nil {
 		return nil, err
 	}
@@ -848,6 +1469,48 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 	pk := &k
 	pk.SettingId = settingId.String
 
+	lo, err := parseLimitOverride(overrideData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.LimitOverride = lo
+
+	bm, err := parseBlockMessage(blockMessageData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.BlockMessage = bm
+
+	fp, err := parseFilePolicy(filePolicyData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.FilePolicy = fp
+
+	ma, err := parseModelAliases(modelAliasesData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.ModelAliases = ma
+
+	bd, err := parseBudgetDowngrade(budgetDowngradeData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.BudgetDowngrade = bd
+
+	mk, err := parseMarkup(markupData)
+	if err != nil {
+		return nil, err
+	}
+
+	pk.Markup = mk
+
 	if len(data) != 0 {
 		pathConfigs := []key.PathConfig{}
 		if err := json.Unmarshal(data, &pathConfigs); err != nil {
@@ -868,6 +1531,115 @@ func (s *Store) DeleteKey(id string) error {
 	return err
 }
 
+func parseLimitOverride(data []byte) (*key.LimitOverride, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	lo := &key.LimitOverride{}
+	if err := json.Unmarshal(data, lo); err != nil {
+		return nil, err
+	}
+
+	return lo, nil
+}
+
+func parseBlockMessage(data []byte) (*key.BlockMessage, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	bm := &key.BlockMessage{}
+	if err := json.Unmarshal(data, bm); err != nil {
+		return nil, err
+	}
+
+	return bm, nil
+}
+
+func parseFilePolicy(data []byte) (*key.FilePolicy, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	fp := &key.FilePolicy{}
+	if err := json.Unmarshal(data, fp); err != nil {
+		return nil, err
+	}
+
+	return fp, nil
+}
+
+func parseModelAliases(data []byte) (map[string]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	aliases := map[string]string{}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+func parseBudgetDowngrade(data []byte) (*key.BudgetDowngrade, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	bd := &key.BudgetDowngrade{}
+	if err := json.Unmarshal(data, bd); err != nil {
+		return nil, err
+	}
+
+	return bd, nil
+}
+
+func parseMarkup(data []byte) (*key.Markup, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	mk := &key.Markup{}
+	if err := json.Unmarshal(data, mk); err != nil {
+		return nil, err
+	}
+
+	return mk, nil
+}
+
+func (s *Store) UpdateKeyLimitOverride(id string, lo *key.LimitOverride, updatedAt int64) (*key.ResponseKey, error) {
+	var data []byte
+	if lo != nil {
+		bs, err := json.Marshal(lo)
+		if err != nil {
+			return nil, err
+		}
+
+		data = bs
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "UPDATE keys SET limit_override = $2, updated_at = $3 WHERE key_id = $1", id, data, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, internal_errors.NewNotFoundError(fmt.Sprintf("key not found for id: %s", id))
+	}
+
+	return s.GetKey(id)
+}
+
 func sliceToSqlStringArray(slice []string) string {
 	return "{" + strings.Join(slice, ",") + "}"
 }
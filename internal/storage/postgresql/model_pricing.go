@@ -0,0 +1,138 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+)
+
+func (s *Store) CreateModelPricingsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS model_pricings (
+		model VARCHAR(255) PRIMARY KEY,
+		costs JSONB NOT NULL,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		deleted BOOLEAN NOT NULL DEFAULT FALSE
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpsertModelPricing creates a pricing or replaces the costs of an existing
+// one, restoring it if it was deleted.
+func (s *Store) UpsertModelPricing(mp *openai.ModelPricing) (*openai.ModelPricing, error) {
+	query := `
+		INSERT INTO model_pricings (model, costs, created_at, updated_at, deleted)
+		VALUES ($1, $2, $3, $4, FALSE)
+		ON CONFLICT (model) DO UPDATE SET costs = EXCLUDED.costs, updated_at = EXCLUDED.updated_at, deleted = FALSE
+		RETURNING model, costs, created_at, updated_at, deleted
+	`
+
+	costs, err := json.Marshal(mp.Costs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	upserted := &openai.ModelPricing{}
+	var data []byte
+	if err := s.db.QueryRowContext(ctxTimeout, query, mp.Model, costs, mp.CreatedAt, mp.UpdatedAt).Scan(
+		&upserted.Model,
+		&data,
+		&upserted.CreatedAt,
+		&upserted.UpdatedAt,
+		&upserted.Deleted,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &upserted.Costs); err != nil {
+		return nil, err
+	}
+
+	return upserted, nil
+}
+
+// DeleteModelPricing soft deletes a pricing so that gateway instances pick up
+// the deletion with the rest of the updated pricings.
+func (s *Store) DeleteModelPricing(model string, updatedAt int64) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "UPDATE model_pricings SET deleted = TRUE, updated_at = $2 WHERE model = $1 AND deleted = FALSE", model, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("model pricing is not found for: " + model)
+	}
+
+	return nil
+}
+
+func (s *Store) GetModelPricings() ([]*openai.ModelPricing, error) {
+	return s.queryModelPricings("SELECT model, costs, created_at, updated_at, deleted FROM model_pricings WHERE deleted = FALSE")
+}
+
+// GetUpdatedModelPricings returns pricings updated since a timestamp
+// including deleted ones.
+func (s *Store) GetUpdatedModelPricings(updatedAt int64) ([]*openai.ModelPricing, error) {
+	return s.queryModelPricings("SELECT model, costs, created_at, updated_at, deleted FROM model_pricings WHERE updated_at >= $1", updatedAt)
+}
+
+func (s *Store) queryModelPricings(query string, args ...any) ([]*openai.ModelPricing, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return []*openai.ModelPricing{}, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	pricings := []*openai.ModelPricing{}
+	for rows.Next() {
+		mp := &openai.ModelPricing{}
+		var data []byte
+		if err := rows.Scan(
+			&mp.Model,
+			&data,
+			&mp.CreatedAt,
+			&mp.UpdatedAt,
+			&mp.Deleted,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, &mp.Costs); err != nil {
+			return nil, err
+		}
+
+		pricings = append(pricings, mp)
+	}
+
+	return pricings, nil
+}
@@ -0,0 +1,129 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateModelRemappingsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS model_remappings (
+		model VARCHAR(255) PRIMARY KEY,
+		successor VARCHAR(255) NOT NULL,
+		provider VARCHAR(255) NOT NULL DEFAULT '',
+		paths VARCHAR(255)[] NOT NULL DEFAULT '{}',
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		deleted BOOLEAN NOT NULL DEFAULT FALSE
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpsertModelRemapping creates a remapping or replaces the successor of an
+// existing one, restoring it if it was deleted.
+func (s *Store) UpsertModelRemapping(mr *provider.ModelRemapping) (*provider.ModelRemapping, error) {
+	query := `
+		INSERT INTO model_remappings (model, successor, provider, paths, created_at, updated_at, deleted)
+		VALUES ($1, $2, $3, $4, $5, $6, FALSE)
+		ON CONFLICT (model) DO UPDATE SET successor = EXCLUDED.successor, provider = EXCLUDED.provider, paths = EXCLUDED.paths, updated_at = EXCLUDED.updated_at, deleted = FALSE
+		RETURNING model, successor, provider, paths, created_at, updated_at, deleted
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	upserted := &provider.ModelRemapping{}
+	if err := s.db.QueryRowContext(ctxTimeout, query, mr.Model, mr.Successor, mr.Provider, pq.Array(mr.Paths), mr.CreatedAt, mr.UpdatedAt).Scan(
+		&upserted.Model,
+		&upserted.Successor,
+		&upserted.Provider,
+		pq.Array(&upserted.Paths),
+		&upserted.CreatedAt,
+		&upserted.UpdatedAt,
+		&upserted.Deleted,
+	); err != nil {
+		return nil, err
+	}
+
+	return upserted, nil
+}
+
+// DeleteModelRemapping soft deletes a remapping so that gateway instances pick
+// up the deletion with the rest of the updated remappings.
+func (s *Store) DeleteModelRemapping(model string, updatedAt int64) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "UPDATE model_remappings SET deleted = TRUE, updated_at = $2 WHERE model = $1 AND deleted = FALSE", model, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("model remapping is not found for: " + model)
+	}
+
+	return nil
+}
+
+func (s *Store) GetModelRemappings() ([]*provider.ModelRemapping, error) {
+	return s.queryModelRemappings("SELECT model, successor, provider, paths, created_at, updated_at, deleted FROM model_remappings WHERE deleted = FALSE")
+}
+
+// GetUpdatedModelRemappings returns remappings updated since a timestamp
+// including deleted ones.
+func (s *Store) GetUpdatedModelRemappings(updatedAt int64) ([]*provider.ModelRemapping, error) {
+	return s.queryModelRemappings("SELECT model, successor, provider, paths, created_at, updated_at, deleted FROM model_remappings WHERE updated_at >= $1", updatedAt)
+}
+
+func (s *Store) queryModelRemappings(query string, args ...any) ([]*provider.ModelRemapping, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return []*provider.ModelRemapping{}, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	remappings := []*provider.ModelRemapping{}
+	for rows.Next() {
+		mr := &provider.ModelRemapping{}
+		if err := rows.Scan(
+			&mr.Model,
+			&mr.Successor,
+			&mr.Provider,
+			pq.Array(&mr.Paths),
+			&mr.CreatedAt,
+			&mr.UpdatedAt,
+			&mr.Deleted,
+		); err != nil {
+			return nil, err
+		}
+
+		remappings = append(remappings, mr)
+	}
+
+	return remappings, nil
+}
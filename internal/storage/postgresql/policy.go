@@ -35,6 +35,33 @@ func (s *Store) CreatePolicyTable() error {
 	return nil
 }
 
+func (s *Store) AlterPolicyTable() error {
+	alterTableQuery := `
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS azure_content_filter_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS moderation_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS structured_output_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS prompt_injection_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS external_inspection_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS image_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS warning_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS topic_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS size_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS language_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS key_tags JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS brand_config JSONB;
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS profanity_config JSONB;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 	fields := []string{
 		"id",
@@ -90,6 +117,162 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		fields = append(fields, "custom_config")
 		values = append(values, cd)
 		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.AzureContentFilterConfig != nil {
+		cd, err := json.Marshal(p.AzureContentFilterConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "azure_content_filter_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.ModerationConfig != nil {
+		cd, err := json.Marshal(p.ModerationConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "moderation_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.StructuredOutputConfig != nil {
+		cd, err := json.Marshal(p.StructuredOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "structured_output_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.PromptInjectionConfig != nil {
+		cd, err := json.Marshal(p.PromptInjectionConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "prompt_injection_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.ExternalInspectionConfig != nil {
+		cd, err := json.Marshal(p.ExternalInspectionConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "external_inspection_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.ImageConfig != nil {
+		cd, err := json.Marshal(p.ImageConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "image_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.WarningConfig != nil {
+		cd, err := json.Marshal(p.WarningConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "warning_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.TopicConfig != nil {
+		cd, err := json.Marshal(p.TopicConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "topic_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.SizeConfig != nil {
+		cd, err := json.Marshal(p.SizeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "size_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.LanguageConfig != nil {
+		cd, err := json.Marshal(p.LanguageConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "language_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.KeyTags != nil {
+		cd, err := json.Marshal(p.KeyTags)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "key_tags")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.BrandConfig != nil {
+		cd, err := json.Marshal(p.BrandConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "brand_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.ProfanityConfig != nil {
+		cd, err := json.Marshal(p.ProfanityConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "profanity_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -105,6 +288,19 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 
 	var createdcd []byte
 	var createdcusd []byte
+	var createdazurecfd []byte
+	var createdmodd []byte
+	var createdsod []byte
+	var createdpid []byte
+	var createdeid []byte
+	var createdimd []byte
+	var createdwad []byte
+	var createdtod []byte
+	var createdszc []byte
+	var createdlgc []byte
+	var createdkyt []byte
+	var createdbc []byte
+	var createdpfc []byte
 	var createdregexd []byte
 	row := s.db.QueryRowContext(ctx, query, values...)
 	if err := row.Scan(
@@ -116,6 +312,19 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		&createdcd,
 		&createdregexd,
 		&createdcusd,
+		&createdazurecfd,
+		&createdmodd,
+		&createdsod,
+		&createdpid,
+		&createdeid,
+		&createdimd,
+		&createdwad,
+		&createdtod,
+		&createdszc,
+		&createdlgc,
+		&createdkyt,
+		&createdbc,
+		&createdpfc,
 	); err != nil {
 
 		return nil, err
@@ -139,6 +348,84 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		}
 	}
 
+	if len(createdazurecfd) != 0 {
+		if err := json.Unmarshal(createdazurecfd, &created.AzureContentFilterConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdmodd) != 0 {
+		if err := json.Unmarshal(createdmodd, &created.ModerationConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdsod) != 0 {
+		if err := json.Unmarshal(createdsod, &created.StructuredOutputConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdpid) != 0 {
+		if err := json.Unmarshal(createdpid, &created.PromptInjectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdeid) != 0 {
+		if err := json.Unmarshal(createdeid, &created.ExternalInspectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdimd) != 0 {
+		if err := json.Unmarshal(createdimd, &created.ImageConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdwad) != 0 {
+		if err := json.Unmarshal(createdwad, &created.WarningConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdtod) != 0 {
+		if err := json.Unmarshal(createdtod, &created.TopicConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdszc) != 0 {
+		if err := json.Unmarshal(createdszc, &created.SizeConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdlgc) != 0 {
+		if err := json.Unmarshal(createdlgc, &created.LanguageConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdkyt) != 0 {
+		if err := json.Unmarshal(createdkyt, &created.KeyTags); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdbc) != 0 {
+		if err := json.Unmarshal(createdbc, &created.BrandConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdpfc) != 0 {
+		if err := json.Unmarshal(createdpfc, &created.ProfanityConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	return created, nil
 }
 
@@ -181,61 +468,308 @@ func (s *Store) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy,
 			return nil, err
 		}
 
-		values = append(values, data)
-		fields = append(fields, fmt.Sprintf("regex_config = $%d", d))
-		d++
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("regex_config = $%d", d))
+		d++
+	}
+
+	if p.CustomConfig != nil {
+		data, err := json.Marshal(p.CustomConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("custom_config = $%d", d))
+		d++
+	}
+
+	if p.AzureContentFilterConfig != nil {
+		data, err := json.Marshal(p.AzureContentFilterConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("azure_content_filter_config = $%d", d))
+		d++
+	}
+
+	if p.ModerationConfig != nil {
+		data, err := json.Marshal(p.ModerationConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("moderation_config = $%d", d))
+		d++
+	}
+
+	if p.StructuredOutputConfig != nil {
+		data, err := json.Marshal(p.StructuredOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("structured_output_config = $%d", d))
+		d++
+	}
+
+	if p.PromptInjectionConfig != nil {
+		data, err := json.Marshal(p.PromptInjectionConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("prompt_injection_config = $%d", d))
+		d++
+	}
+
+	if p.ExternalInspectionConfig != nil {
+		data, err := json.Marshal(p.ExternalInspectionConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("external_inspection_config = $%d", d))
+		d++
+	}
+
+	if p.ImageConfig != nil {
+		data, err := json.Marshal(p.ImageConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("image_config = $%d", d))
+		d++
+	}
+
+	if p.WarningConfig != nil {
+		data, err := json.Marshal(p.WarningConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("warning_config = $%d", d))
+		d++
+	}
+
+	if p.TopicConfig != nil {
+		data, err := json.Marshal(p.TopicConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("topic_config = $%d", d))
+		d++
+	}
+
+	if p.SizeConfig != nil {
+		data, err := json.Marshal(p.SizeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("size_config = $%d", d))
+		d++
+	}
+
+	if p.LanguageConfig != nil {
+		data, err := json.Marshal(p.LanguageConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("language_config = $%d", d))
+		d++
+	}
+
+	if p.KeyTags != nil {
+		data, err := json.Marshal(p.KeyTags)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("key_tags = $%d", d))
+		d++
+	}
+
+	if p.BrandConfig != nil {
+		data, err := json.Marshal(p.BrandConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("brand_config = $%d", d))
+		d++
+	}
+
+	if p.ProfanityConfig != nil {
+		data, err := json.Marshal(p.ProfanityConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("profanity_config = $%d", d))
+	}
+
+	query := fmt.Sprintf("UPDATE policies SET %s WHERE id = $1 RETURNING *", strings.Join(fields, ","))
+	updated := &policy.Policy{}
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	var cd []byte
+	var cusd []byte
+	var azurecfd []byte
+	var modd []byte
+	var sod []byte
+	var pid []byte
+	var eid []byte
+	var imd []byte
+	var wad []byte
+	var tod []byte
+	var szc []byte
+	var lgc []byte
+	var kyt []byte
+	var bc []byte
+	var pfc []byte
+	var regexd []byte
+	row := s.db.QueryRowContext(ctxTimeout, query, values...)
+	if err := row.Scan(
+		&updated.Id,
+		&updated.CreatedAt,
+		&updated.UpdatedAt,
+		&updated.Name,
+		pq.Array(&updated.Tags),
+		&cd,
+		&regexd,
+		&cusd,
+		&azurecfd,
+		&modd,
+		&sod,
+		&pid,
+		&eid,
+		&imd,
+		&wad,
+		&tod,
+		&szc,
+		&lgc,
+		&kyt,
+		&bc,
+		&pfc,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("policy is not found for id: " + id)
+		}
+
+		return nil, err
+	}
+
+	if len(cd) != 0 {
+		if err := json.Unmarshal(cd, &updated.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(regexd) != 0 {
+		if err := json.Unmarshal(regexd, &updated.RegexConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cusd) != 0 {
+		if err := json.Unmarshal(cusd, &updated.CustomConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(azurecfd) != 0 {
+		if err := json.Unmarshal(azurecfd, &updated.AzureContentFilterConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(modd) != 0 {
+		if err := json.Unmarshal(modd, &updated.ModerationConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sod) != 0 {
+		if err := json.Unmarshal(sod, &updated.StructuredOutputConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pid) != 0 {
+		if err := json.Unmarshal(pid, &updated.PromptInjectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(eid) != 0 {
+		if err := json.Unmarshal(eid, &updated.ExternalInspectionConfig); err != nil {
+			return nil, err
+		}
 	}
 
-	if p.CustomConfig != nil {
-		data, err := json.Marshal(p.CustomConfig)
-		if err != nil {
+	if len(imd) != 0 {
+		if err := json.Unmarshal(imd, &updated.ImageConfig); err != nil {
 			return nil, err
 		}
+	}
 
-		values = append(values, data)
-		fields = append(fields, fmt.Sprintf("custom_config = $%d", d))
+	if len(wad) != 0 {
+		if err := json.Unmarshal(wad, &updated.WarningConfig); err != nil {
+			return nil, err
+		}
 	}
 
-	query := fmt.Sprintf("UPDATE policies SET %s WHERE id = $1 RETURNING *", strings.Join(fields, ","))
-	updated := &policy.Policy{}
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
-	defer cancel()
+	if len(tod) != 0 {
+		if err := json.Unmarshal(tod, &updated.TopicConfig); err != nil {
+			return nil, err
+		}
+	}
 
-	var cd []byte
-	var cusd []byte
-	var regexd []byte
-	row := s.db.QueryRowContext(ctxTimeout, query, values...)
-	if err := row.Scan(
-		&updated.Id,
-		&updated.CreatedAt,
-		&updated.UpdatedAt,
-		&updated.Name,
-		pq.Array(&updated.Tags),
-		&cd,
-		&regexd,
-		&cusd,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, internal_errors.NewNotFoundError("policy is not found for id: " + id)
+	if len(szc) != 0 {
+		if err := json.Unmarshal(szc, &updated.SizeConfig); err != nil {
+			return nil, err
 		}
+	}
 
-		return nil, err
+	if len(lgc) != 0 {
+		if err := json.Unmarshal(lgc, &updated.LanguageConfig); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(cd) != 0 {
-		if err := json.Unmarshal(cd, &updated.Config); err != nil {
+	if len(kyt) != 0 {
+		if err := json.Unmarshal(kyt, &updated.KeyTags); err != nil {
 			return nil, err
 		}
 	}
 
-	if len(regexd) != 0 {
-		if err := json.Unmarshal(regexd, &updated.RegexConfig); err != nil {
+	if len(bc) != 0 {
+		if err := json.Unmarshal(bc, &updated.BrandConfig); err != nil {
 			return nil, err
 		}
 	}
 
-	if len(cusd) != 0 {
-		if err := json.Unmarshal(cusd, &updated.CustomConfig); err != nil {
+	if len(pfc) != 0 {
+		if err := json.Unmarshal(pfc, &updated.ProfanityConfig); err != nil {
 			return nil, err
 		}
 	}
@@ -257,6 +791,19 @@ func (s *Store) GetAllPolicies() ([]*policy.Policy, error) {
 	for rows.Next() {
 		var cd []byte
 		var cusd []byte
+		var azurecfd []byte
+		var modd []byte
+		var sod []byte
+		var pid []byte
+		var eid []byte
+		var imd []byte
+		var wad []byte
+		var tod []byte
+		var szc []byte
+		var lgc []byte
+		var kyt []byte
+		var bc []byte
+		var pfc []byte
 		var regexd []byte
 
 		p := &policy.Policy{}
@@ -269,6 +816,19 @@ func (s *Store) GetAllPolicies() ([]*policy.Policy, error) {
 			&cd,
 			&regexd,
 			&cusd,
+			&azurecfd,
+			&modd,
+			&sod,
+			&pid,
+			&eid,
+			&imd,
+			&wad,
+			&tod,
+			&szc,
+			&lgc,
+			&kyt,
+			&bc,
+			&pfc,
 		); err != nil {
 			return nil, err
 		}
@@ -291,6 +851,84 @@ func (s *Store) GetAllPolicies() ([]*policy.Policy, error) {
 			}
 		}
 
+		if len(azurecfd) != 0 {
+			if err := json.Unmarshal(azurecfd, &p.AzureContentFilterConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(modd) != 0 {
+			if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(sod) != 0 {
+			if err := json.Unmarshal(sod, &p.StructuredOutputConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pid) != 0 {
+			if err := json.Unmarshal(pid, &p.PromptInjectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(eid) != 0 {
+			if err := json.Unmarshal(eid, &p.ExternalInspectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(imd) != 0 {
+			if err := json.Unmarshal(imd, &p.ImageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(wad) != 0 {
+			if err := json.Unmarshal(wad, &p.WarningConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(tod) != 0 {
+			if err := json.Unmarshal(tod, &p.TopicConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(szc) != 0 {
+			if err := json.Unmarshal(szc, &p.SizeConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(lgc) != 0 {
+			if err := json.Unmarshal(lgc, &p.LanguageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(kyt) != 0 {
+			if err := json.Unmarshal(kyt, &p.KeyTags); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(bc) != 0 {
+			if err := json.Unmarshal(bc, &p.BrandConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pfc) != 0 {
+			if err := json.Unmarshal(pfc, &p.ProfanityConfig); err != nil {
+				return nil, err
+			}
+		}
+
 		ps = append(ps, p)
 	}
 
@@ -306,6 +944,19 @@ func (s *Store) GetPolicyById(id string) (*policy.Policy, error) {
 
 	var cd []byte
 	var cusd []byte
+	var azurecfd []byte
+	var modd []byte
+	var sod []byte
+	var pid []byte
+	var eid []byte
+	var imd []byte
+	var wad []byte
+	var tod []byte
+	var szc []byte
+	var lgc []byte
+	var kyt []byte
+	var bc []byte
+	var pfc []byte
 	var regexd []byte
 
 	if err := row.Scan(
@@ -317,6 +968,19 @@ func (s *Store) GetPolicyById(id string) (*policy.Policy, error) {
 		&cd,
 		&regexd,
 		&cusd,
+		&azurecfd,
+		&modd,
+		&sod,
+		&pid,
+		&eid,
+		&imd,
+		&wad,
+		&tod,
+		&szc,
+		&lgc,
+		&kyt,
+		&bc,
+		&pfc,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("policy is not found for id: " + id)
@@ -343,6 +1007,84 @@ func (s *Store) GetPolicyById(id string) (*policy.Policy, error) {
 		}
 	}
 
+	if len(azurecfd) != 0 {
+		if err := json.Unmarshal(azurecfd, &p.AzureContentFilterConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(modd) != 0 {
+		if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sod) != 0 {
+		if err := json.Unmarshal(sod, &p.StructuredOutputConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pid) != 0 {
+		if err := json.Unmarshal(pid, &p.PromptInjectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(eid) != 0 {
+		if err := json.Unmarshal(eid, &p.ExternalInspectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(imd) != 0 {
+		if err := json.Unmarshal(imd, &p.ImageConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(wad) != 0 {
+		if err := json.Unmarshal(wad, &p.WarningConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tod) != 0 {
+		if err := json.Unmarshal(tod, &p.TopicConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(szc) != 0 {
+		if err := json.Unmarshal(szc, &p.SizeConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(lgc) != 0 {
+		if err := json.Unmarshal(lgc, &p.LanguageConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(kyt) != 0 {
+		if err := json.Unmarshal(kyt, &p.KeyTags); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(bc) != 0 {
+		if err := json.Unmarshal(bc, &p.BrandConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pfc) != 0 {
+		if err := json.Unmarshal(pfc, &p.ProfanityConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	return p, nil
 }
 
@@ -361,6 +1103,19 @@ func (s *Store) GetPoliciesByTags(tags []string) ([]*policy.Policy, error) {
 	for rows.Next() {
 		var cd []byte
 		var cusd []byte
+		var azurecfd []byte
+		var modd []byte
+		var sod []byte
+		var pid []byte
+		var eid []byte
+		var imd []byte
+		var wad []byte
+		var tod []byte
+		var szc []byte
+		var lgc []byte
+		var kyt []byte
+		var bc []byte
+		var pfc []byte
 		var regexd []byte
 
 		p := &policy.Policy{}
@@ -374,6 +1129,19 @@ func (s *Store) GetPoliciesByTags(tags []string) ([]*policy.Policy, error) {
 			&cd,
 			&regexd,
 			&cusd,
+			&azurecfd,
+			&modd,
+			&sod,
+			&pid,
+			&eid,
+			&imd,
+			&wad,
+			&tod,
+			&szc,
+			&lgc,
+			&kyt,
+			&bc,
+			&pfc,
 		); err != nil {
 			return nil, err
 		}
@@ -396,6 +1164,84 @@ func (s *Store) GetPoliciesByTags(tags []string) ([]*policy.Policy, error) {
 			}
 		}
 
+		if len(azurecfd) != 0 {
+			if err := json.Unmarshal(azurecfd, &p.AzureContentFilterConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(modd) != 0 {
+			if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(sod) != 0 {
+			if err := json.Unmarshal(sod, &p.StructuredOutputConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pid) != 0 {
+			if err := json.Unmarshal(pid, &p.PromptInjectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(eid) != 0 {
+			if err := json.Unmarshal(eid, &p.ExternalInspectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(imd) != 0 {
+			if err := json.Unmarshal(imd, &p.ImageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(wad) != 0 {
+			if err := json.Unmarshal(wad, &p.WarningConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(tod) != 0 {
+			if err := json.Unmarshal(tod, &p.TopicConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(szc) != 0 {
+			if err := json.Unmarshal(szc, &p.SizeConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(lgc) != 0 {
+			if err := json.Unmarshal(lgc, &p.LanguageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(kyt) != 0 {
+			if err := json.Unmarshal(kyt, &p.KeyTags); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(bc) != 0 {
+			if err := json.Unmarshal(bc, &p.BrandConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pfc) != 0 {
+			if err := json.Unmarshal(pfc, &p.ProfanityConfig); err != nil {
+				return nil, err
+			}
+		}
+
 		ps = append(ps, p)
 
 	}
@@ -417,6 +1263,19 @@ func (s *Store) GetUpdatedPolicies(updatedAt int64) ([]*policy.Policy, error) {
 	for rows.Next() {
 		var cd []byte
 		var cusd []byte
+		var azurecfd []byte
+		var modd []byte
+		var sod []byte
+		var pid []byte
+		var eid []byte
+		var imd []byte
+		var wad []byte
+		var tod []byte
+		var szc []byte
+		var lgc []byte
+		var kyt []byte
+		var bc []byte
+		var pfc []byte
 		var regexd []byte
 
 		p := &policy.Policy{}
@@ -429,6 +1288,19 @@ func (s *Store) GetUpdatedPolicies(updatedAt int64) ([]*policy.Policy, error) {
 			&cd,
 			&regexd,
 			&cusd,
+			&azurecfd,
+			&modd,
+			&sod,
+			&pid,
+			&eid,
+			&imd,
+			&wad,
+			&tod,
+			&szc,
+			&lgc,
+			&kyt,
+			&bc,
+			&pfc,
 		); err != nil {
 			return nil, err
 		}
@@ -451,6 +1323,84 @@ func (s *Store) GetUpdatedPolicies(updatedAt int64) ([]*policy.Policy, error) {
 			}
 		}
 
+		if len(azurecfd) != 0 {
+			if err := json.Unmarshal(azurecfd, &p.AzureContentFilterConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(modd) != 0 {
+			if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(sod) != 0 {
+			if err := json.Unmarshal(sod, &p.StructuredOutputConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pid) != 0 {
+			if err := json.Unmarshal(pid, &p.PromptInjectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(eid) != 0 {
+			if err := json.Unmarshal(eid, &p.ExternalInspectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(imd) != 0 {
+			if err := json.Unmarshal(imd, &p.ImageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(wad) != 0 {
+			if err := json.Unmarshal(wad, &p.WarningConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(tod) != 0 {
+			if err := json.Unmarshal(tod, &p.TopicConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(szc) != 0 {
+			if err := json.Unmarshal(szc, &p.SizeConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(lgc) != 0 {
+			if err := json.Unmarshal(lgc, &p.LanguageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(kyt) != 0 {
+			if err := json.Unmarshal(kyt, &p.KeyTags); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(bc) != 0 {
+			if err := json.Unmarshal(bc, &p.BrandConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pfc) != 0 {
+			if err := json.Unmarshal(pfc, &p.ProfanityConfig); err != nil {
+				return nil, err
+			}
+		}
+
 		ps = append(ps, p)
 	}
 
@@ -0,0 +1,182 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/lib/pq"
+)
+
+// policyEventsCondition returns the condition selecting events filtered by
+// policies along with its arguments.
+func policyEventsCondition(req *event.PolicyAnalyticsRequest) (string, []any) {
+	condition := fmt.Sprintf("policy_id <> '' AND created_at >= %d AND created_at < %d", req.Start, req.End)
+	args := []any{}
+
+	if len(req.PolicyIds) != 0 {
+		args = append(args, pq.Array(req.PolicyIds))
+		condition += fmt.Sprintf(" AND policy_id = ANY($%d)", len(args))
+	}
+
+	if len(req.KeyIds) != 0 {
+		args = append(args, pq.Array(req.KeyIds))
+		condition += fmt.Sprintf(" AND key_id = ANY($%d)", len(args))
+	}
+
+	return condition, args
+}
+
+// GetPolicyDataPoints counts the events filtered by policies in each time
+// bucket of a request by the action taken on them.
+func (s *Store) GetPolicyDataPoints(req *event.PolicyAnalyticsRequest) ([]*event.PolicyDataPoint, error) {
+	condition, args := policyEventsCondition(req)
+
+	query := fmt.Sprintf(`
+		WITH events_table AS
+		(
+			SELECT created_at, action FROM events WHERE %s
+		),time_series_table AS
+		(
+			SELECT generate_series(%d, %d, %d) series
+		)
+		SELECT
+			series AS time_stamp,
+			COUNT(events_table.action) AS num_of_requests,
+			COUNT(CASE WHEN events_table.action = 'blocked' THEN 1 END) AS blocked_count,
+			COUNT(CASE WHEN events_table.action = 'warned' THEN 1 END) AS warned_count,
+			COUNT(CASE WHEN events_table.action = 'redacted' THEN 1 END) AS redacted_count
+		FROM       time_series_table
+		LEFT JOIN  events_table
+		ON         events_table.created_at >= time_series_table.series
+		AND        events_table.created_at < time_series_table.series + %d
+		GROUP BY   time_series_table.series
+		ORDER BY   time_series_table.series;
+	`, condition, req.Start, req.End-1, req.Increment, req.Increment)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	data := []*event.PolicyDataPoint{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return data, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dp := &event.PolicyDataPoint{}
+		if err := rows.Scan(
+			&dp.TimeStamp,
+			&dp.NumberOfRequests,
+			&dp.BlockedCount,
+			&dp.WarnedCount,
+			&dp.RedactedCount,
+		); err != nil {
+			return nil, err
+		}
+
+		data = append(data, dp)
+	}
+
+	return data, nil
+}
+
+// GetTopPolicyRules returns the rules that triggered policy actions the most.
+func (s *Store) GetTopPolicyRules(req *event.PolicyAnalyticsRequest, limit int) ([]*event.PolicyRuleCount, error) {
+	condition, args := policyEventsCondition(req)
+
+	query := fmt.Sprintf(`
+		SELECT r->>'rule' AS rule, r->>'action' AS action, COUNT(*) AS count
+		FROM events, jsonb_array_elements(policy_rules) AS r
+		WHERE policy_rules IS NOT NULL AND %s
+		GROUP BY 1, 2
+		ORDER BY count DESC, rule
+		LIMIT %d;
+	`, condition, limit)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	counts := []*event.PolicyRuleCount{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return counts, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rc := &event.PolicyRuleCount{}
+		if err := rows.Scan(
+			&rc.Rule,
+			&rc.Action,
+			&rc.Count,
+		); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, rc)
+	}
+
+	return counts, nil
+}
+
+// GetTopPolicyKeys returns the keys with the most requests blocked, warned or
+// redacted by policies.
+func (s *Store) GetTopPolicyKeys(req *event.PolicyAnalyticsRequest, limit int) ([]*event.PolicyKeyCount, error) {
+	condition, args := policyEventsCondition(req)
+
+	query := fmt.Sprintf(`
+		SELECT
+			key_id,
+			COUNT(CASE WHEN action = 'blocked' THEN 1 END) AS blocked_count,
+			COUNT(CASE WHEN action = 'warned' THEN 1 END) AS warned_count,
+			COUNT(CASE WHEN action = 'redacted' THEN 1 END) AS redacted_count,
+			COUNT(*) AS count
+		FROM events
+		WHERE key_id <> '' AND action IN ('blocked', 'warned', 'redacted') AND %s
+		GROUP BY key_id
+		ORDER BY count DESC, blocked_count DESC, key_id
+		LIMIT %d;
+	`, condition, limit)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	counts := []*event.PolicyKeyCount{}
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return counts, nil
+		}
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		kc := &event.PolicyKeyCount{}
+		if err := rows.Scan(
+			&kc.KeyId,
+			&kc.BlockedCount,
+			&kc.WarnedCount,
+			&kc.RedactedCount,
+			&kc.Count,
+		); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, kc)
+	}
+
+	return counts, nil
+}
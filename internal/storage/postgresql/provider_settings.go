@@ -36,7 +36,7 @@ func (s *Store) CreateProviderSettingsTable() error {
 
 func (s *Store) AlterProviderSettingsTable() error {
 	alterTableQuery := `
-		ALTER TABLE provider_settings ADD COLUMN IF NOT EXISTS name VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_models VARCHAR(255)[], ADD COLUMN IF NOT EXISTS cost_map JSONB NOT NULL DEFAULT '{}'::JSONB
+		ALTER TABLE provider_settings ADD COLUMN IF NOT EXISTS name VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_models VARCHAR(255)[], ADD COLUMN IF NOT EXISTS cost_map JSONB NOT NULL DEFAULT '{}'::JSONB, ADD COLUMN IF NOT EXISTS deployments JSONB NOT NULL DEFAULT '{}'::JSONB, ADD COLUMN IF NOT EXISTS max_concurrent_requests INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS model_concurrency_limits JSONB NOT NULL DEFAULT '{}'::JSONB, ADD COLUMN IF NOT EXISTS concurrency_overflow VARCHAR(255) NOT NULL DEFAULT ''
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -56,6 +56,8 @@ func (s *Store) GetProviderSetting(id string, withSecret bool) (*provider.Settin
 	setting := &provider.Setting{}
 	var data []byte
 	var cmdata []byte
+	var dpdata []byte
+	var mcdata []byte
 	var name sql.NullString
 	err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM provider_settings WHERE $1 = id", id).Scan(
 		&setting.Id,
@@ -66,6 +68,10 @@ func (s *Store) GetProviderSetting(id string, withSecret bool) (*provider.Settin
 		&name,
 		pq.Array(&setting.AllowedModels),
 		&cmdata,
+		&dpdata,
+		&setting.MaxConcurrentRequests,
+		&mcdata,
+		&setting.ConcurrencyOverflow,
 	)
 
 	if err != nil {
@@ -86,12 +92,25 @@ func (s *Store) GetProviderSetting(id string, withSecret bool) (*provider.Settin
 		return nil, err
 	}
 
+	dp := provider.Deployments{}
+	if err := json.Unmarshal(dpdata, &dp); err != nil {
+		return nil, err
+	}
+
+	mc := map[string]int{}
+	if err := json.Unmarshal(mcdata, &mc); err != nil {
+		return nil, err
+	}
+
 	if !withSecret {
 		delete(m, "apikey")
+		delete(m, "serviceAccountJson")
 	}
 
 	setting.Setting = m
 	setting.CostMap = cm
+	setting.Deployments = dp
+	setting.ModelConcurrencyLimits = mc
 
 	setting.Name = name.String
 
@@ -113,6 +132,8 @@ func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting
 		setting := &provider.Setting{}
 		var data []byte
 		var cmdata []byte
+		var dpdata []byte
+		var mcdata []byte
 		var name sql.NullString
 		if err := rows.Scan(
 			&setting.Id,
@@ -123,6 +144,10 @@ func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting
 			&name,
 			pq.Array(&setting.AllowedModels),
 			&cmdata,
+			&dpdata,
+			&setting.MaxConcurrentRequests,
+			&mcdata,
+			&setting.ConcurrencyOverflow,
 		); err != nil {
 			return nil, err
 		}
@@ -137,8 +162,21 @@ func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting
 			return nil, err
 		}
 
+		dp := provider.Deployments{}
+		if err := json.Unmarshal(dpdata, &dp); err != nil {
+			return nil, err
+		}
+
+		mc := map[string]int{}
+		if err := json.Unmarshal(mcdata, &mc); err != nil {
+			return nil, err
+		}
+
 		setting.Setting = m
 		setting.CostMap = cm
+		setting.Deployments = dp
+		setting.Deployments = dp
+		setting.ModelConcurrencyLimits = mc
 		setting.Name = name.String
 		settings = append(settings, setting)
 	}
@@ -186,15 +224,51 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 
 		values = append(values, data)
 		fields = append(fields, fmt.Sprintf("cost_map = $%d", d))
+		d++
+	}
+
+	if setting.Deployments != nil {
+		data, err := json.Marshal(setting.Deployments)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("deployments = $%d", d))
+		d++
+	}
+
+	if setting.MaxConcurrentRequests != nil {
+		values = append(values, *setting.MaxConcurrentRequests)
+		fields = append(fields, fmt.Sprintf("max_concurrent_requests = $%d", d))
+		d++
+	}
+
+	if setting.ModelConcurrencyLimits != nil {
+		data, err := json.Marshal(setting.ModelConcurrencyLimits)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("model_concurrency_limits = $%d", d))
+		d++
 	}
 
-	query := fmt.Sprintf("UPDATE provider_settings SET %s WHERE id = $1 RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map;", strings.Join(fields, ","))
+	if setting.ConcurrencyOverflow != nil {
+		values = append(values, *setting.ConcurrencyOverflow)
+		fields = append(fields, fmt.Sprintf("concurrency_overflow = $%d", d))
+	}
+
+	query := fmt.Sprintf("UPDATE provider_settings SET %s WHERE id = $1 RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map, deployments, max_concurrent_requests, model_concurrency_limits, concurrency_overflow;", strings.Join(fields, ","))
 	updated := &provider.Setting{}
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
 	var rawd []byte
 	var cmdata []byte
+	var dpdata []byte
+	var mcdata []byte
 
 	row := s.db.QueryRowContext(ctxTimeout, query, values...)
 	if err := row.Scan(
@@ -206,6 +280,10 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 		pq.Array(&updated.AllowedModels),
 		&rawd,
 		&cmdata,
+		&dpdata,
+		&updated.MaxConcurrentRequests,
+		&mcdata,
+		&updated.ConcurrencyOverflow,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("provider setting is not found for: " + id)
@@ -224,10 +302,22 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 		return nil, err
 	}
 
+	dp := provider.Deployments{}
+	if err := json.Unmarshal(dpdata, &dp); err != nil {
+		return nil, err
+	}
+
+	mc := map[string]int{}
+	if err := json.Unmarshal(mcdata, &mc); err != nil {
+		return nil, err
+	}
+
 	delete(m, "apikey")
 
 	updated.Setting = m
 	updated.CostMap = cm
+	updated.Deployments = dp
+	updated.ModelConcurrencyLimits = mc
 
 	return updated, nil
 }
@@ -255,9 +345,9 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 	}
 
 	query := `
-		INSERT INTO provider_settings (id, created_at, updated_at, provider, setting, name, allowed_models, cost_map)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map
+		INSERT INTO provider_settings (id, created_at, updated_at, provider, setting, name, allowed_models, cost_map, deployments, max_concurrent_requests, model_concurrency_limits, concurrency_overflow)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map, deployments, max_concurrent_requests, model_concurrency_limits, concurrency_overflow
 	`
 
 	data, err := json.Marshal(setting.Setting)
@@ -270,6 +360,26 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		return nil, err
 	}
 
+	deployments := setting.Deployments
+	if deployments == nil {
+		deployments = provider.Deployments{}
+	}
+
+	dpd, err := json.Marshal(deployments)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := setting.ModelConcurrencyLimits
+	if limits == nil {
+		limits = map[string]int{}
+	}
+
+	mcd, err := json.Marshal(limits)
+	if err != nil {
+		return nil, err
+	}
+
 	values := []any{
 		setting.Id,
 		setting.CreatedAt,
@@ -279,10 +389,16 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		setting.Name,
 		sliceToSqlStringArray(setting.AllowedModels),
 		cmd,
+		dpd,
+		setting.MaxConcurrentRequests,
+		mcd,
+		setting.ConcurrencyOverflow,
 	}
 
 	var rawd []byte
 	var rawcmd []byte
+	var rawdpd []byte
+	var mcdata []byte
 
 	created := &provider.Setting{}
 	var name sql.NullString
@@ -295,6 +411,10 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		pq.Array(&created.AllowedModels),
 		&rawd,
 		&rawcmd,
+		&rawdpd,
+		&created.MaxConcurrentRequests,
+		&mcdata,
+		&created.ConcurrencyOverflow,
 	); err != nil {
 		return nil, err
 	}
@@ -309,10 +429,22 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		return nil, err
 	}
 
+	dp := provider.Deployments{}
+	if err := json.Unmarshal(rawdpd, &dp); err != nil {
+		return nil, err
+	}
+
+	mc := map[string]int{}
+	if err := json.Unmarshal(mcdata, &mc); err != nil {
+		return nil, err
+	}
+
 	delete(m, "apikey")
 
 	created.Setting = m
 	created.CostMap = cm
+	created.Deployments = dp
+	created.ModelConcurrencyLimits = mc
 
 	created.Name = name.String
 	return created, nil
@@ -342,6 +474,8 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 		setting := &provider.Setting{}
 		var data []byte
 		var cmdata []byte
+		var dpdata []byte
+		var mcdata []byte
 
 		var name sql.NullString
 		if err := rows.Scan(
@@ -353,6 +487,10 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 			&name,
 			pq.Array(&setting.AllowedModels),
 			&cmdata,
+			&dpdata,
+			&setting.MaxConcurrentRequests,
+			&mcdata,
+			&setting.ConcurrencyOverflow,
 		); err != nil {
 			return nil, err
 		}
@@ -367,12 +505,26 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 			return nil, err
 		}
 
+		dp := provider.Deployments{}
+		if err := json.Unmarshal(dpdata, &dp); err != nil {
+			return nil, err
+		}
+
+		mc := map[string]int{}
+		if err := json.Unmarshal(mcdata, &mc); err != nil {
+			return nil, err
+		}
+
 		if !withSecret {
 			delete(m, "apikey")
+			delete(m, "serviceAccountJson")
 		}
 
 		setting.Setting = m
 		setting.CostMap = cm
+		setting.Deployments = dp
+		setting.Deployments = dp
+		setting.ModelConcurrencyLimits = mc
 
 		setting.Name = name.String
 		settings = append(settings, setting)
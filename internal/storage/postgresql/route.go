@@ -35,7 +35,7 @@ func (s *Store) CreateRoutesTable() error {
 
 func (s *Store) AlterRoutesTable() error {
 	alterTableQuery := `
-		ALTER TABLE routes ADD COLUMN IF NOT EXISTS request_format VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS retry_strategy VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS request_format VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS retry_strategy VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS truncation_config JSONB NOT NULL DEFAULT '{}', ADD COLUMN IF NOT EXISTS error_templates JSONB NOT NULL DEFAULT '{}', ADD COLUMN IF NOT EXISTS routing_strategy VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS capability_tier VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS retry_config JSONB NOT NULL DEFAULT '{}', ADD COLUMN IF NOT EXISTS shadow_config JSONB NOT NULL DEFAULT '{}', ADD COLUMN IF NOT EXISTS rules JSONB NOT NULL DEFAULT '[]', ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '';
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -73,6 +73,46 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		return nil, err
 	}
 
+	tbytes := []byte(`{}`)
+	if r.TruncationConfig != nil {
+		tbytes, err = json.Marshal(r.TruncationConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ebytes := []byte(`{}`)
+	if len(r.ErrorTemplates) != 0 {
+		ebytes, err = json.Marshal(r.ErrorTemplates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rbytes := []byte(`{}`)
+	if r.RetryConfig != nil {
+		rbytes, err = json.Marshal(r.RetryConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shbytes := []byte(`{}`)
+	if r.ShadowConfig != nil {
+		shbytes, err = json.Marshal(r.ShadowConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rubytes := []byte(`[]`)
+	if r.Rules != nil {
+		rubytes, err = json.Marshal(r.Rules)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	values := []any{
 		r.Id,
 		r.CreatedAt,
@@ -84,12 +124,20 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		cbytes,
 		r.RequestFormat,
 		r.RetryStrategy,
+		tbytes,
+		ebytes,
+		r.RoutingStrategy,
+		r.CapabilityTier,
+		rbytes,
+		shbytes,
+		rubytes,
+		r.PolicyId,
 	}
 
 	query := `
-	INSERT INTO routes (id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	RETURNING id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy
+	INSERT INTO routes (id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy, truncation_config, error_templates, routing_strategy, capability_tier, retry_config, shadow_config, rules, policy_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	RETURNING id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy, truncation_config, error_templates, routing_strategy, capability_tier, retry_config, shadow_config, rules, policy_id
 `
 
 	created := &route.Route{}
@@ -99,6 +147,11 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 
 	var cdata []byte
 	var sdata []byte
+	var tdata []byte
+	var edata []byte
+	var rdata []byte
+	var shdata []byte
+	var rudata []byte
 
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&created.Id,
@@ -111,6 +164,14 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		&cdata,
 		&created.RequestFormat,
 		&created.RetryStrategy,
+		&tdata,
+		&edata,
+		&created.RoutingStrategy,
+		&created.CapabilityTier,
+		&rdata,
+		&shdata,
+		&rudata,
+		&created.PolicyId,
 	); err != nil {
 		return nil, err
 	}
@@ -123,15 +184,188 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		return nil, err
 	}
 
+	if err := json.Unmarshal(tdata, &created.TruncationConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(edata, &created.ErrorTemplates); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rdata, &created.RetryConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(shdata, &created.ShadowConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rudata, &created.Rules); err != nil {
+		return nil, err
+	}
+
 	return created, nil
 }
 
+func (s *Store) UpdateRoute(r *route.Route) (*route.Route, error) {
+	sbytes, err := json.Marshal(r.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	cbytes, err := json.Marshal(r.CacheConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	tbytes := []byte(`{}`)
+	if r.TruncationConfig != nil {
+		tbytes, err = json.Marshal(r.TruncationConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ebytes := []byte(`{}`)
+	if len(r.ErrorTemplates) != 0 {
+		ebytes, err = json.Marshal(r.ErrorTemplates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rbytes := []byte(`{}`)
+	if r.RetryConfig != nil {
+		rbytes, err = json.Marshal(r.RetryConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shbytes := []byte(`{}`)
+	if r.ShadowConfig != nil {
+		shbytes, err = json.Marshal(r.ShadowConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rubytes := []byte(`[]`)
+	if r.Rules != nil {
+		rubytes, err = json.Marshal(r.Rules)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	values := []any{
+		r.Id,
+		r.UpdatedAt,
+		r.Name,
+		r.Path,
+		sliceToSqlStringArray(r.KeyIds),
+		sbytes,
+		cbytes,
+		r.RequestFormat,
+		r.RetryStrategy,
+		tbytes,
+		ebytes,
+		r.RoutingStrategy,
+		r.CapabilityTier,
+		rbytes,
+		shbytes,
+		rubytes,
+		r.PolicyId,
+	}
+
+	query := `
+	UPDATE routes SET updated_at = $2, name = $3, path = $4, key_ids = $5, steps = $6, cache_config = $7, request_format = $8, retry_strategy = $9, truncation_config = $10, error_templates = $11, routing_strategy = $12, capability_tier = $13, retry_config = $14, shadow_config = $15, rules = $16, policy_id = $17
+	WHERE id = $1
+	RETURNING id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy, truncation_config, error_templates, routing_strategy, capability_tier, retry_config, shadow_config, rules, policy_id
+`
+
+	updated := &route.Route{}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	var cdata []byte
+	var sdata []byte
+	var tdata []byte
+	var edata []byte
+	var rdata []byte
+	var shdata []byte
+	var rudata []byte
+
+	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
+		&updated.Id,
+		&updated.CreatedAt,
+		&updated.UpdatedAt,
+		&updated.Name,
+		&updated.Path,
+		pq.Array(&updated.KeyIds),
+		&sdata,
+		&cdata,
+		&updated.RequestFormat,
+		&updated.RetryStrategy,
+		&tdata,
+		&edata,
+		&updated.RoutingStrategy,
+		&updated.CapabilityTier,
+		&rdata,
+		&shdata,
+		&rudata,
+		&updated.PolicyId,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("route is not found")
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(sdata, &updated.Steps); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(cdata, &updated.CacheConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(tdata, &updated.TruncationConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(edata, &updated.ErrorTemplates); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rdata, &updated.RetryConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(shdata, &updated.ShadowConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rudata, &updated.Rules); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
 func (s *Store) GetRoute(id string) (*route.Route, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
 	var cdata []byte
 	var sdata []byte
+	var tdata []byte
+	var edata []byte
+	var rdata []byte
+	var shdata []byte
+	var rudata []byte
 
 	created := &route.Route{}
 	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM routes WHERE $1 = id", id).Scan(
@@ -145,6 +379,14 @@ func (s *Store) GetRoute(id string) (*route.Route, error) {
 		&cdata,
 		&created.RequestFormat,
 		&created.RetryStrategy,
+		&tdata,
+		&edata,
+		&created.RoutingStrategy,
+		&created.CapabilityTier,
+		&rdata,
+		&shdata,
+		&rudata,
+		&created.PolicyId,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("custom provider is not found")
@@ -161,6 +403,26 @@ func (s *Store) GetRoute(id string) (*route.Route, error) {
 		return nil, err
 	}
 
+	if err := json.Unmarshal(tdata, &created.TruncationConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(edata, &created.ErrorTemplates); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rdata, &created.RetryConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(shdata, &created.ShadowConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rudata, &created.Rules); err != nil {
+		return nil, err
+	}
+
 	return created, nil
 }
 
@@ -170,6 +432,11 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 
 	var cdata []byte
 	var sdata []byte
+	var tdata []byte
+	var edata []byte
+	var rdata []byte
+	var shdata []byte
+	var rudata []byte
 
 	created := &route.Route{}
 	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM routes WHERE $1 = path", path).Scan(
@@ -183,6 +450,14 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 		&cdata,
 		&created.RequestFormat,
 		&created.RetryStrategy,
+		&tdata,
+		&edata,
+		&created.RoutingStrategy,
+		&created.CapabilityTier,
+		&rdata,
+		&shdata,
+		&rudata,
+		&created.PolicyId,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("route is not found")
@@ -199,6 +474,26 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 		return nil, err
 	}
 
+	if err := json.Unmarshal(tdata, &created.TruncationConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(edata, &created.ErrorTemplates); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rdata, &created.RetryConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(shdata, &created.ShadowConfig); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rudata, &created.Rules); err != nil {
+		return nil, err
+	}
+
 	return created, nil
 }
 
@@ -217,6 +512,11 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 		r := &route.Route{}
 		var cdata []byte
 		var sdata []byte
+		var tdata []byte
+		var edata []byte
+		var rdata []byte
+		var shdata []byte
+		var rudata []byte
 
 		if err := rows.Scan(
 			&r.Id,
@@ -229,6 +529,14 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 			&cdata,
 			&r.RequestFormat,
 			&r.RetryStrategy,
+			&tdata,
+			&edata,
+			&r.RoutingStrategy,
+			&r.CapabilityTier,
+			&rdata,
+			&shdata,
+			&rudata,
+			&r.PolicyId,
 		); err != nil {
 			return nil, err
 		}
@@ -241,6 +549,26 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 			return nil, err
 		}
 
+		if err := json.Unmarshal(tdata, &r.TruncationConfig); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(edata, &r.ErrorTemplates); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(rdata, &r.RetryConfig); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(shdata, &r.ShadowConfig); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(rudata, &r.Rules); err != nil {
+			return nil, err
+		}
+
 		routes = append(routes, r)
 	}
 
@@ -262,6 +590,11 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 		r := &route.Route{}
 		var cdata []byte
 		var sdata []byte
+		var tdata []byte
+		var edata []byte
+		var rdata []byte
+		var shdata []byte
+		var rudata []byte
 
 		if err := rows.Scan(
 			&r.Id,
@@ -274,6 +607,14 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 			&cdata,
 			&r.RequestFormat,
 			&r.RetryStrategy,
+			&tdata,
+			&edata,
+			&r.RoutingStrategy,
+			&r.CapabilityTier,
+			&rdata,
+			&shdata,
+			&rudata,
+			&r.PolicyId,
 		); err != nil {
 			return nil, err
 		}
@@ -286,6 +627,26 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 			return nil, err
 		}
 
+		if err := json.Unmarshal(tdata, &r.TruncationConfig); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(edata, &r.ErrorTemplates); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(rdata, &r.RetryConfig); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(shdata, &r.ShadowConfig); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(rudata, &r.Rules); err != nil {
+			return nil, err
+		}
+
 		routes = append(routes, r)
 	}
 
@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type BatchStorage struct {
+	client *redis.Client
+	wt     time.Duration
+	ttl    time.Duration
+}
+
+func NewBatchStorage(c *redis.Client, wt time.Duration, ttl time.Duration) *BatchStorage {
+	return &BatchStorage{
+		client: c,
+		wt:     wt,
+		ttl:    ttl,
+	}
+}
+
+func getBatchKey(batchId string) string {
+	return "batch:" + batchId
+}
+
+// SwapStatus records the status of the batch and returns the status recorded
+// before. An empty status is returned for batches seen for the first time.
+func (bs *BatchStorage) SwapStatus(batchId, status string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), bs.wt)
+	defer cancel()
+
+	previous, err := bs.client.SetArgs(ctx, getBatchKey(batchId), status, redis.SetArgs{
+		Get: true,
+		TTL: bs.ttl,
+	}).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	return previous, err
+}
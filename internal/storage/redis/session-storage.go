@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/redis/go-redis/v9"
+)
+
+type SessionStorage struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+	ttl    time.Duration
+}
+
+func NewSessionStorage(c *redis.Client, wt time.Duration, rt time.Duration, ttl time.Duration) *SessionStorage {
+	return &SessionStorage{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+		ttl:    ttl,
+	}
+}
+
+func getSessionKey(keyId, sessionId string) string {
+	return fmt.Sprintf("%s:%s", keyId, sessionId)
+}
+
+func (ss *SessionStorage) IncrementUsage(keyId, sessionId string, micros, tks int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.wt)
+	defer cancel()
+
+	sk := getSessionKey(keyId, sessionId)
+
+	pipe := ss.client.TxPipeline()
+	pipe.HIncrBy(ctx, sk, "cost", micros)
+	pipe.HIncrBy(ctx, sk, "tokens", tks)
+	pipe.Expire(ctx, sk, ss.ttl)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (ss *SessionStorage) GetUsage(keyId, sessionId string) (*key.SessionUsage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.rt)
+	defer cancel()
+
+	vals, err := ss.client.HMGet(ctx, getSessionKey(keyId, sessionId), "cost", "tokens").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &key.SessionUsage{}
+	if len(vals) == 2 {
+		result.CostInMicros = parseSessionCounter(vals[0])
+		result.TokenCount = parseSessionCounter(vals[1])
+	}
+
+	return result, nil
+}
+
+func parseSessionCounter(val any) int64 {
+	str, ok := val.(string)
+	if !ok {
+		return 0
+	}
+
+	parsed, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return parsed
+}
+
+func getSessionPinKey(keyId, sessionId string) string {
+	return fmt.Sprintf("%s:%s:pin", keyId, sessionId)
+}
+
+// GetPinnedSetting returns the id of the provider setting that served earlier
+// requests of a session. An empty id is returned if the session is not pinned.
+func (ss *SessionStorage) GetPinnedSetting(keyId, sessionId string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.rt)
+	defer cancel()
+
+	settingId, err := ss.client.Get(ctx, getSessionPinKey(keyId, sessionId)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	return settingId, err
+}
+
+// PinSetting pins a session to a provider setting for the session ttl.
+func (ss *SessionStorage) PinSetting(keyId, sessionId, settingId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ss.wt)
+	defer cancel()
+
+	return ss.client.Set(ctx, getSessionPinKey(keyId, sessionId), settingId, ss.ttl).Err()
+}
@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/redis/go-redis/v9"
+)
+
+type ThreadStorage struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+	ttl    time.Duration
+}
+
+func NewThreadStorage(c *redis.Client, wt time.Duration, rt time.Duration, ttl time.Duration) *ThreadStorage {
+	return &ThreadStorage{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+		ttl:    ttl,
+	}
+}
+
+func getThreadKey(threadId string) string {
+	return "thread:" + threadId
+}
+
+func getRunKey(runId string) string {
+	return "run:" + runId
+}
+
+func (ts *ThreadStorage) SetOwner(threadId string, owner *key.ThreadOwner) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.wt)
+	defer cancel()
+
+	tk := getThreadKey(threadId)
+
+	pipe := ts.client.TxPipeline()
+	pipe.HSet(ctx, tk, "keyId", owner.KeyId, "customId", owner.CustomId)
+	pipe.Expire(ctx, tk, ts.ttl)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetOwner returns the owner of the thread or nil if the thread was not
+// created through the proxy.
+func (ts *ThreadStorage) GetOwner(threadId string) (*key.ThreadOwner, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.rt)
+	defer cancel()
+
+	vals, err := ts.client.HGetAll(ctx, getThreadKey(threadId)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vals["keyId"]) == 0 {
+		return nil, nil
+	}
+
+	return &key.ThreadOwner{
+		KeyId:    vals["keyId"],
+		CustomId: vals["customId"],
+	}, nil
+}
+
+// MarkRunAttributed reports whether the usage of the run has not been
+// attributed yet and marks it as attributed.
+func (ts *ThreadStorage) MarkRunAttributed(runId string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ts.wt)
+	defer cancel()
+
+	return ts.client.SetNX(ctx, getRunKey(runId), true, ts.ttl).Result()
+}
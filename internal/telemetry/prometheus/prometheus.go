@@ -62,3 +62,16 @@ func (c *Client) Timing(name string, value time.Duration, tags []string, rate fl
 
 	histogramMetric.WithLabelValues(tags...).Observe(float64(value))
 }
+
+func (c *Client) Histogram(name string, value float64, tags []string, rate float64) {
+	if c == nil {
+		return
+	}
+
+	histogramMetric, exists := c.HistogramMetrics[name]
+	if !exists {
+		return
+	}
+
+	histogramMetric.WithLabelValues(tags...).Observe(value)
+}
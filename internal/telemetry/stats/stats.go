@@ -39,3 +39,9 @@ func (c *Client) Timing(name string, value time.Duration, tags []string, rate fl
 		c.statsdc.Timing(name, value, tags, rate)
 	}
 }
+
+func (c *Client) Histogram(name string, value float64, tags []string, rate float64) {
+	if c != nil && c.config.Enabled {
+		c.statsdc.Histogram(name, value, tags, rate)
+	}
+}
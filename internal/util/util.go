@@ -21,6 +21,12 @@ func NewUuid() string {
 	return uuid.New().String()
 }
 
+// NewUuidFromName returns a stable id for a resource of a kind declared by
+// name. The same kind and name always result in the same id.
+func NewUuidFromName(kind, name string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte("bricksllm/"+kind+"/"+name)).String()
+}
+
 func SetLogToCtx(c *gin.Context, logger *zap.Logger) {
 	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), STRING_LOG, logger))
 }
@@ -3,6 +3,7 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
@@ -22,20 +23,30 @@ type costLimitStorage interface {
 }
 
 type Validator struct {
-	clc costLimitCache
-	rlc rateLimitCache
-	cls costLimitStorage
+	clc          costLimitCache
+	rlc          rateLimitCache
+	cls          costLimitStorage
+	lagTolerance float64
 }
 
+// NewValidator creates a validator. lagTolerance is the fraction of cost limits
+// reserved for spend recorded by gateways in other regions that has not been
+// replicated yet. It is ignored unless it is within [0, 1).
 func NewValidator(
 	clc costLimitCache,
 	rlc rateLimitCache,
 	cls costLimitStorage,
+	lagTolerance float64,
 ) *Validator {
+	if lagTolerance < 0 || lagTolerance >= 1 {
+		lagTolerance = 0
+	}
+
 	return &Validator{
-		clc: clc,
-		rlc: rlc,
-		cls: cls,
+		clc:          clc,
+		rlc:          rlc,
+		cls:          cls,
+		lagTolerance: lagTolerance,
 	}
 }
 
@@ -44,6 +55,8 @@ func (v *Validator) Validate(k *key.ResponseKey, promptCost float64) error {
 		return internal_errors.NewValidationError("empty api key")
 	}
 
+	k = k.WithActiveLimitOverride(time.Now())
+
 	if k.Revoked {
 		return internal_errors.NewValidationError("api key revoked")
 	}
@@ -109,7 +122,7 @@ func (v *Validator) validateCostLimitOverTime(keyId string, costLimitOverTime fl
 		return errors.New("failed to get cached token cost")
 	}
 
-	if cachedCost >= convertDollarToMicroDollars(costLimitOverTime) {
+	if cachedCost >= v.effectiveLimit(costLimitOverTime) {
 		return internal_errors.NewCostLimitError(fmt.Sprintf("cost limit: %f has been reached for the current time period: %s", costLimitOverTime, costLimitUnit))
 	}
 
@@ -120,6 +133,10 @@ func convertDollarToMicroDollars(dollar float64) int64 {
 	return int64(dollar * 1000000)
 }
 
+func (v *Validator) effectiveLimit(limit float64) int64 {
+	return convertDollarToMicroDollars(limit * (1 - v.lagTolerance))
+}
+
 func (v *Validator) validateCostLimit(keyId string, costLimit float64) error {
 	if costLimit == 0 {
 		return nil
@@ -130,9 +147,40 @@ func (v *Validator) validateCostLimit(keyId string, costLimit float64) error {
 		return errors.New("failed to get total token cost")
 	}
 
-	if existingTotalCost >= convertDollarToMicroDollars(costLimit) {
+	if existingTotalCost >= v.effectiveLimit(costLimit) {
 		return internal_errors.NewExpirationError(fmt.Sprintf("total cost limit: %f has been reached", costLimit), internal_errors.CostLimitExpiration)
 	}
 
 	return nil
 }
+
+// SpendRatio returns the spend of a key relative to the closest of its cost
+// limits. Zero is returned for keys without cost limits.
+func (v *Validator) SpendRatio(k *key.ResponseKey) (float64, error) {
+	if k == nil {
+		return 0, nil
+	}
+
+	k = k.WithActiveLimitOverride(time.Now())
+
+	ratio := 0.0
+	if k.CostLimitInUsdOverTime != 0 {
+		cachedCost, err := v.clc.GetCounter(k.KeyId, k.CostLimitInUsdUnit)
+		if err != nil {
+			return 0, errors.New("failed to get cached token cost")
+		}
+
+		ratio = math.Max(ratio, float64(cachedCost)/float64(v.effectiveLimit(k.CostLimitInUsdOverTime)))
+	}
+
+	if k.CostLimitInUsd != 0 {
+		existingTotalCost, err := v.cls.GetCounter(k.KeyId)
+		if err != nil {
+			return 0, errors.New("failed to get total token cost")
+		}
+
+		ratio = math.Max(ratio, float64(existingTotalCost)/float64(v.effectiveLimit(k.CostLimitInUsd)))
+	}
+
+	return ratio, nil
+}
@@ -0,0 +1,130 @@
+package bricksllm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+// Counters tracks the requests and spend of keys that rate and cost limits are
+// enforced with. Services running several replicas can implement it on top of
+// shared storage or use MemoryCounters.
+type Counters interface {
+	// GetRequestCount returns the number of requests made with a key in the
+	// current period of a unit.
+	GetRequestCount(keyId string, unit TimeUnit) (int64, error)
+	// GetSpend returns the spend of a key in micro dollars in the current
+	// period of a unit, or in total if the unit is empty.
+	GetSpend(keyId string, unit TimeUnit) (int64, error)
+	// Record adds a request and its cost in micro dollars to the counters of
+	// a key.
+	Record(keyId string, microDollars int64) error
+}
+
+var timeUnits = []TimeUnit{SecondTimeUnit, MinuteTimeUnit, HourTimeUnit, DayTimeUnit, MonthTimeUnit}
+
+// periodStart returns the start of the period of a unit containing a time.
+func periodStart(t time.Time, unit TimeUnit) int64 {
+	t = t.UTC()
+	switch unit {
+	case SecondTimeUnit:
+		return t.Truncate(time.Second).Unix()
+	case MinuteTimeUnit:
+		return t.Truncate(time.Minute).Unix()
+	case HourTimeUnit:
+		return t.Truncate(time.Hour).Unix()
+	case DayTimeUnit:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix()
+	case MonthTimeUnit:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).Unix()
+	}
+
+	return 0
+}
+
+type counter struct {
+	start    int64
+	requests int64
+	spend    int64
+}
+
+// MemoryCounters is a Counters keeping the counters of the current periods in
+// memory.
+type MemoryCounters struct {
+	lock     sync.Mutex
+	counters map[string]map[TimeUnit]*counter
+	now      func() time.Time
+}
+
+func NewMemoryCounters() *MemoryCounters {
+	return &MemoryCounters{
+		counters: map[string]map[TimeUnit]*counter{},
+		now:      time.Now,
+	}
+}
+
+// get returns the counter of the current period of a unit. Callers must hold
+// the lock.
+func (mc *MemoryCounters) get(keyId string, unit TimeUnit) *counter {
+	units, ok := mc.counters[keyId]
+	if !ok {
+		units = map[TimeUnit]*counter{}
+		mc.counters[keyId] = units
+	}
+
+	start := periodStart(mc.now(), unit)
+	c, ok := units[unit]
+	if !ok || c.start != start {
+		c = &counter{start: start}
+		units[unit] = c
+	}
+
+	return c
+}
+
+func (mc *MemoryCounters) GetRequestCount(keyId string, unit TimeUnit) (int64, error) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	return mc.get(keyId, unit).requests, nil
+}
+
+func (mc *MemoryCounters) GetSpend(keyId string, unit TimeUnit) (int64, error) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	return mc.get(keyId, unit).spend, nil
+}
+
+func (mc *MemoryCounters) Record(keyId string, microDollars int64) error {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	for _, unit := range append(timeUnits, "") {
+		c := mc.get(keyId, unit)
+		c.requests++
+		c.spend += microDollars
+	}
+
+	return nil
+}
+
+// validator adapters of the counters.
+type requestCounter struct{ c Counters }
+
+func (rc *requestCounter) GetCounter(keyId string, unit key.TimeUnit) (int64, error) {
+	return rc.c.GetRequestCount(keyId, TimeUnit(unit))
+}
+
+type spendCounter struct{ c Counters }
+
+func (sc *spendCounter) GetCounter(keyId string, unit key.TimeUnit) (int64, error) {
+	return sc.c.GetSpend(keyId, TimeUnit(unit))
+}
+
+type totalSpendCounter struct{ c Counters }
+
+func (tc *totalSpendCounter) GetCounter(keyId string) (int64, error) {
+	return tc.c.GetSpend(keyId, "")
+}
@@ -0,0 +1,49 @@
+package bricksllm
+
+import (
+	"errors"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// IsAuthError returns true if an api key is missing or not registered.
+func IsAuthError(err error) bool {
+	var target *internal_errors.AuthError
+	return errors.As(err, &target)
+}
+
+// IsRateLimited returns true if a key exceeded its rate limit.
+func IsRateLimited(err error) bool {
+	var target *internal_errors.RateLimitError
+	return errors.As(err, &target)
+}
+
+// IsCostLimited returns true if a key reached its cost limit, either over time
+// or in total.
+func IsCostLimited(err error) bool {
+	var target *internal_errors.CostLimitError
+	if errors.As(err, &target) {
+		return true
+	}
+
+	var expiration *internal_errors.ExpirationError
+	return errors.As(err, &expiration) && expiration.Reason() == internal_errors.CostLimitExpiration
+}
+
+// IsExpired returns true if the ttl of a key expired.
+func IsExpired(err error) bool {
+	var expiration *internal_errors.ExpirationError
+	return errors.As(err, &expiration) && expiration.Reason() == internal_errors.TtlExpiration
+}
+
+// IsRevokedOrInvalid returns true if a key is revoked or a request is invalid.
+func IsRevokedOrInvalid(err error) bool {
+	var target *internal_errors.ValidationError
+	return errors.As(err, &target)
+}
+
+// IsBlocked returns true if a request was blocked by the policy of its key.
+func IsBlocked(err error) bool {
+	var target *internal_errors.BlockedError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,402 @@
+// Package bricksllm exposes the governance pipeline of the BricksLLM proxy as
+// a library so that Go services can authenticate keys, enforce policies, rate
+// limits and cost limits, and dispatch requests to providers without running
+// the HTTP proxy.
+//
+// Keys, provider settings and policies are looked up from a Store and usage is
+// tracked with Counters. Both have in memory implementations for services that
+// do not share state between replicas.
+package bricksllm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+	"github.com/bricks-cloud/bricksllm/internal/pii/local"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/validator"
+	"go.uber.org/zap"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// EntityDetector detects the PII entities that policy rules act on. It
+// returns the entities of every input in the order of the input. It is only
+// required by policies with entity rules.
+type EntityDetector interface {
+	Detect(input []string) ([][]*Entity, error)
+}
+
+// Entity is a PII entity detected in a text. Types follow the entity types of
+// Amazon Comprehend, such as EMAIL and PHONE.
+type Entity struct {
+	Type        string
+	BeginOffset int
+	EndOffset   int
+}
+
+// CustomDetector detects the custom requirements of custom policy rules. It is
+// only required by policies with custom rules.
+type CustomDetector interface {
+	Detect(input []string, requirements []string) (bool, error)
+}
+
+// entityDetector adapts an EntityDetector to the detectors of policies.
+// Inputs without entities returned by the detector are treated as failed
+// detections.
+type entityDetector struct {
+	d EntityDetector
+}
+
+func (ed *entityDetector) Detect(input []string) (*pii.Result, error) {
+	detected, err := ed.d.Detect(input)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &pii.Result{
+		Detections: make([]*pii.Detection, 0, len(input)),
+	}
+
+	for idx, entities := range detected {
+		if idx >= len(input) {
+			break
+		}
+
+		detection := &pii.Detection{
+			Input:    input[idx],
+			Entities: []*pii.Entity{},
+		}
+
+		for _, entity := range entities {
+			if entity != nil {
+				detection.Entities = append(detection.Entities, &pii.Entity{
+					Type:        entity.Type,
+					BeginOffset: entity.BeginOffset,
+					EndOffset:   entity.EndOffset,
+				})
+			}
+		}
+
+		result.Detections = append(result.Detections, detection)
+	}
+
+	return result, nil
+}
+
+type Options struct {
+	Store    Store
+	Counters Counters
+
+	// EntityDetector and CustomDetector are optional. Policies with rules
+	// requiring a missing detector fail with an error.
+	EntityDetector EntityDetector
+	CustomDetector CustomDetector
+
+	// OpenAiBaseUrl overrides the url chat completions are sent to.
+	OpenAiBaseUrl string
+	// HttpClient is used to call providers. Defaults to a client with a
+	// timeout of 3 minutes.
+	HttpClient *http.Client
+	// SpendLagTolerance is the fraction of cost limits reserved for spend
+	// that has not been recorded by Counters yet. It must be within [0, 1).
+	SpendLagTolerance float64
+	Log               *zap.Logger
+}
+
+// Gateway enforces the governance of BricksLLM keys on requests made by the
+// embedding service.
+type Gateway struct {
+	store      Store
+	counters   Counters
+	validator  *validator.Validator
+	scanner    policy.Scanner
+	cd         policy.CustomPolicyDetector
+	estimator  *openai.CostEstimator
+	client     *http.Client
+	openAiBase string
+	log        *zap.Logger
+
+	lock     sync.Mutex
+	policies map[string]*compiledPolicy
+}
+
+// compiledPolicy is a policy of the store compiled from its config.
+type compiledPolicy struct {
+	config []byte
+	policy *policy.Policy
+}
+
+type missingDetector struct{}
+
+func (d *missingDetector) Detect(input []string) (*pii.Result, error) {
+	return nil, errors.New("entity detector is not configured")
+}
+
+type missingCustomDetector struct{}
+
+func (d *missingCustomDetector) Detect(input []string, requirements []string) (bool, error) {
+	return false, errors.New("custom detector is not configured")
+}
+
+func New(opts Options) (*Gateway, error) {
+	if opts.Store == nil {
+		return nil, errors.New("store is required")
+	}
+
+	if opts.Counters == nil {
+		return nil, errors.New("counters are required")
+	}
+
+	if opts.SpendLagTolerance < 0 || opts.SpendLagTolerance >= 1 {
+		return nil, errors.New("spend lag tolerance must be within [0, 1)")
+	}
+
+	var detector pii.Detector = &missingDetector{}
+	if opts.EntityDetector != nil {
+		detector = &entityDetector{d: opts.EntityDetector}
+	}
+
+	var cd policy.CustomPolicyDetector = &missingCustomDetector{}
+	if opts.CustomDetector != nil {
+		cd = opts.CustomDetector
+	}
+
+	client := opts.HttpClient
+	if client == nil {
+		client = &http.Client{Timeout: 3 * time.Minute}
+	}
+
+	log := opts.Log
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	scanner := pii.NewScanner(detector, map[string]pii.Detector{
+		pii.BackendLocal: local.NewDetector(),
+	})
+
+	return &Gateway{
+		store:      opts.Store,
+		counters:   opts.Counters,
+		validator:  validator.NewValidator(&spendCounter{opts.Counters}, &requestCounter{opts.Counters}, &totalSpendCounter{opts.Counters}, opts.SpendLagTolerance),
+		scanner:    scanner,
+		cd:         cd,
+		estimator:  openai.NewCostEstimator(openai.OpenAiPerThousandTokenCost, openai.NewTokenCounter(), nil),
+		client:     client,
+		openAiBase: opts.OpenAiBaseUrl,
+		log:        log,
+		policies:   map[string]*compiledPolicy{},
+	}, nil
+}
+
+// Authenticate returns the key of an api key.
+func (g *Gateway) Authenticate(apiKey string) (*Key, error) {
+	if len(apiKey) == 0 {
+		return nil, internal_errors.NewAuthError("api key is not provided")
+	}
+
+	k, err := g.store.GetKeyByHash(HashKey(apiKey))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, internal_errors.NewAuthError("api key is not registered")
+		}
+
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// CheckLimits returns an error if a key is revoked, expired, or exceeded its
+// rate or cost limits.
+func (g *Gateway) CheckLimits(k *Key) error {
+	return g.validator.Validate(k.responseKey(), 0)
+}
+
+// compile returns the policy enforced for a policy of the store. Policies are
+// compiled again once their config changes.
+func (g *Gateway) compile(p *Policy) (*policy.Policy, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if cached, ok := g.policies[p.Id]; ok && bytes.Equal(cached.config, p.Config) {
+		return cached.policy, nil
+	}
+
+	compiled, err := p.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	g.policies[p.Id] = &compiledPolicy{
+		config: append([]byte{}, p.Config...),
+		policy: compiled,
+	}
+
+	return compiled, nil
+}
+
+// ApplyPolicy enforces the policy of a key on a chat completion request.
+// Redacted content is replaced in the request. The returned exemption
+// describes the action that was taken, or that would have been taken if the
+// key is exempt from its policy. It is nil if the key has no policy.
+func (g *Gateway) ApplyPolicy(k *Key, req *goopenai.ChatCompletionRequest) (*Exemption, error) {
+	if len(k.PolicyId) == 0 {
+		return nil, nil
+	}
+
+	sp, err := g.store.GetPolicy(k.PolicyId)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := g.compile(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	if k.PolicyExempt {
+		evaluated, err := p.Evaluate(*g.client, req, g.scanner, g.cd, g.log)
+		if err != nil {
+			return nil, err
+		}
+
+		ex := newExemption(evaluated)
+		ex.Action = "exempted"
+		return ex, nil
+	}
+
+	ex := &Exemption{
+		PolicyId: p.Id,
+		Action:   "allowed",
+		Rules:    []string{},
+	}
+
+	err = p.Filter(*g.client, req, g.scanner, g.cd, g.log)
+	if err == nil {
+		return ex, nil
+	}
+
+	switch err.(type) {
+	case *internal_errors.BlockedError:
+		return nil, err
+	case *internal_errors.WarningError:
+		ex.Action = "warned"
+	case *internal_errors.RedactError:
+		ex.Action = "redacted"
+	default:
+		return nil, err
+	}
+
+	ex.Reason = err.Error()
+	return ex, nil
+}
+
+// EstimateChatCompletionCost returns the cost in USD of a chat completion.
+func (g *Gateway) EstimateChatCompletionCost(model string, promptTks, completionTks int) (float64, error) {
+	return g.estimator.EstimateTotalCost(model, promptTks, completionTks)
+}
+
+// Record adds a request and its cost in USD to the usage of a key.
+func (g *Gateway) Record(k *Key, costInUsd float64) error {
+	return g.counters.Record(k.KeyId, int64(costInUsd*1000000))
+}
+
+// ChatCompletionResult is the outcome of a chat completion dispatched by the
+// gateway.
+type ChatCompletionResult struct {
+	Response  *goopenai.ChatCompletionResponse
+	CostInUsd float64
+	// Exemption is the outcome of the policy of the key. It is nil if the
+	// key has no policy.
+	Exemption *Exemption
+}
+
+func (g *Gateway) getOpenAiSetting(k *Key, model string) (*ProviderSetting, error) {
+	settings, err := g.store.GetProviderSettings(k.GetSettingIds())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, setting := range settings {
+		if setting.Provider != "openai" {
+			continue
+		}
+
+		if len(setting.AllowedModels) != 0 && !slices.Contains(setting.AllowedModels, model) {
+			continue
+		}
+
+		if len(setting.Setting["apikey"]) == 0 {
+			continue
+		}
+
+		return setting, nil
+	}
+
+	return nil, internal_errors.NewValidationError(fmt.Sprintf("no openai provider setting of the key allows model %s", model))
+}
+
+// ChatCompletion authenticates an api key, enforces its limits and policy,
+// sends a chat completion request to OpenAI and records its cost. Streaming
+// requests are not supported.
+func (g *Gateway) ChatCompletion(ctx context.Context, apiKey string, req goopenai.ChatCompletionRequest) (*ChatCompletionResult, error) {
+	if req.Stream {
+		return nil, internal_errors.NewValidationError("streaming is not supported")
+	}
+
+	k, err := g.Authenticate(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.CheckLimits(k); err != nil {
+		return nil, err
+	}
+
+	ex, err := g.ApplyPolicy(k, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	setting, err := g.getOpenAiSetting(k, req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	config := goopenai.DefaultConfig(setting.Setting["apikey"])
+	config.HTTPClient = g.client
+	if len(g.openAiBase) != 0 {
+		config.BaseURL = g.openAiBase
+	}
+
+	res, err := goopenai.NewClientWithConfig(config).CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	cost, err := g.EstimateChatCompletionCost(req.Model, res.Usage.PromptTokens, res.Usage.CompletionTokens)
+	if err != nil {
+		g.log.Debug("error when estimating chat completion cost", zap.Error(err))
+	}
+
+	if err := g.Record(k, cost); err != nil {
+		return nil, err
+	}
+
+	return &ChatCompletionResult{
+		Response:  &res,
+		CostInUsd: cost,
+		Exemption: ex,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package bricksllm
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// externalStore is a Store implemented outside of the package, which can only
+// report missing keys with ErrNotFound.
+type externalStore struct {
+	keys     map[string]*Key
+	policies map[string]*Policy
+}
+
+func (s *externalStore) GetKeyByHash(hash string) (*Key, error) {
+	if k, ok := s.keys[hash]; ok {
+		return k, nil
+	}
+
+	return nil, fmt.Errorf("key lookup: %w", ErrNotFound)
+}
+
+func (s *externalStore) GetProviderSettings(ids []string) ([]*ProviderSetting, error) {
+	return []*ProviderSetting{}, nil
+}
+
+func (s *externalStore) GetPolicy(id string) (*Policy, error) {
+	if p, ok := s.policies[id]; ok {
+		return p, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+type emailDetector struct{}
+
+func (d *emailDetector) Detect(input []string) ([][]*Entity, error) {
+	detected := [][]*Entity{}
+	for _, text := range input {
+		entities := []*Entity{}
+		if text == "mail jane@example.com" {
+			entities = append(entities, &Entity{Type: "EMAIL", BeginOffset: 5, EndOffset: 21})
+		}
+
+		detected = append(detected, entities)
+	}
+
+	return detected, nil
+}
+
+func newTestGateway(t *testing.T, s Store) *Gateway {
+	g, err := New(Options{
+		Store:          s,
+		Counters:       NewMemoryCounters(),
+		EntityDetector: &emailDetector{},
+		Log:            zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	return g
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := &externalStore{keys: map[string]*Key{HashKey("known"): {KeyId: "key"}}}
+	g := newTestGateway(t, s)
+
+	tests := []struct {
+		name    string
+		apiKey  string
+		keyId   string
+		authErr bool
+	}{
+		{name: "known key", apiKey: "known", keyId: "key"},
+		{name: "unknown key", apiKey: "unknown", authErr: true},
+		{name: "missing key", authErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := g.Authenticate(tt.apiKey)
+			if tt.authErr {
+				assert.True(t, IsAuthError(err))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.keyId, k.KeyId)
+		})
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	g := newTestGateway(t, NewMemoryStore())
+
+	tests := []struct {
+		name        string
+		key         *Key
+		spend       float64
+		costLimited bool
+		revoked     bool
+	}{
+		{name: "within limit", key: &Key{KeyId: "within", CostLimitInUsd: 10}, spend: 1},
+		{name: "over limit", key: &Key{KeyId: "over", CostLimitInUsd: 10}, spend: 10, costLimited: true},
+		{name: "over limit over time", key: &Key{KeyId: "daily", CostLimitInUsdOverTime: 1, CostLimitInUsdUnit: DayTimeUnit}, spend: 2, costLimited: true},
+		{name: "revoked", key: &Key{KeyId: "revoked", Revoked: true}, revoked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, g.Record(tt.key, tt.spend))
+
+			err := g.CheckLimits(tt.key)
+			assert.Equal(t, tt.costLimited, IsCostLimited(err))
+			assert.Equal(t, tt.revoked, IsRevokedOrInvalid(err))
+			if !tt.costLimited && !tt.revoked {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApplyPolicy(t *testing.T) {
+	s := &externalStore{policies: map[string]*Policy{
+		"redact": {
+			Id:     "redact",
+			Config: json.RawMessage(`{"config":{"rules":{"email":"allow_but_redact"}},"regexConfig":{"rules":[{"definition":"secret","action":"allow_but_redact"}]}}`),
+		},
+		"block": {
+			Id:     "block",
+			Config: json.RawMessage(`{"regexConfig":{"rules":[{"definition":"secret","action":"block"}]}}`),
+		},
+	}}
+	g := newTestGateway(t, s)
+
+	tests := []struct {
+		name     string
+		key      *Key
+		content  string
+		action   string
+		blocked  bool
+		redacted string
+	}{
+		{name: "no policy", key: &Key{}, content: "a secret", redacted: "a secret"},
+		{name: "redacted regex", key: &Key{PolicyId: "redact"}, content: "a secret", action: "redacted", redacted: "a ***"},
+		{name: "redacted entity", key: &Key{PolicyId: "redact"}, content: "mail jane@example.com", action: "redacted", redacted: "mail ***"},
+		{name: "blocked", key: &Key{PolicyId: "block"}, content: "a secret", blocked: true},
+		{name: "exempted", key: &Key{PolicyId: "block", PolicyExempt: true}, content: "a secret", action: "exempted", redacted: "a secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &goopenai.ChatCompletionRequest{
+				Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: tt.content}},
+			}
+
+			ex, err := g.ApplyPolicy(tt.key, req)
+			if tt.blocked {
+				assert.True(t, IsBlocked(err))
+				return
+			}
+
+			require.NoError(t, err)
+			if len(tt.action) == 0 {
+				assert.Nil(t, ex)
+			} else {
+				require.NotNil(t, ex)
+				assert.Equal(t, tt.action, ex.Action)
+			}
+
+			assert.Equal(t, tt.redacted, req.Messages[0].Content)
+		})
+	}
+}
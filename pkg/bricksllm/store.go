@@ -0,0 +1,104 @@
+package bricksllm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bricks-cloud/bricksllm/internal/hasher"
+)
+
+// Store looks up the keys, provider settings and policies governed by the
+// gateway. Services can implement it on top of their own storage or use
+// MemoryStore.
+type Store interface {
+	// GetKeyByHash returns the key with a hashed secret. See HashKey.
+	// ErrNotFound is returned if there is none.
+	GetKeyByHash(hash string) (*Key, error)
+	GetProviderSettings(ids []string) ([]*ProviderSetting, error)
+	// GetPolicy returns the policy with an id. ErrNotFound is returned if
+	// there is none.
+	GetPolicy(id string) (*Policy, error)
+}
+
+// HashKey returns the hash api keys are looked up by.
+func HashKey(apiKey string) string {
+	return hasher.Hash(apiKey)
+}
+
+// MemoryStore is a Store keeping keys, provider settings and policies in
+// memory.
+type MemoryStore struct {
+	lock     sync.RWMutex
+	keys     map[string]*Key
+	settings map[string]*ProviderSetting
+	policies map[string]*Policy
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys:     map[string]*Key{},
+		settings: map[string]*ProviderSetting{},
+		policies: map[string]*Policy{},
+	}
+}
+
+// PutKey stores a key authenticated with an api key.
+func (s *MemoryStore) PutKey(apiKey string, k *Key) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	k.Key = HashKey(apiKey)
+	s.keys[k.Key] = k
+}
+
+func (s *MemoryStore) PutProviderSetting(setting *ProviderSetting) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.settings[setting.Id] = setting
+}
+
+func (s *MemoryStore) PutPolicy(p *Policy) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.policies[p.Id] = p
+}
+
+func (s *MemoryStore) GetKeyByHash(hash string) (*Key, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	k, ok := s.keys[hash]
+	if !ok {
+		return nil, fmt.Errorf("key is %w", ErrNotFound)
+	}
+
+	return k, nil
+}
+
+func (s *MemoryStore) GetProviderSettings(ids []string) ([]*ProviderSetting, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	settings := []*ProviderSetting{}
+	for _, id := range ids {
+		if setting, ok := s.settings[id]; ok {
+			settings = append(settings, setting)
+		}
+	}
+
+	return settings, nil
+}
+
+func (s *MemoryStore) GetPolicy(id string) (*Policy, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	p, ok := s.policies[id]
+	if !ok {
+		return nil, fmt.Errorf("policy %s is %w", id, ErrNotFound)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,125 @@
+package bricksllm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+)
+
+// ErrNotFound is returned by stores if a key or policy does not exist.
+// Implementations of Store may wrap it.
+var ErrNotFound = errors.New("not found")
+
+// Key is an API key governed by the gateway. Its fields follow the keys
+// managed through the admin API.
+type Key struct {
+	KeyId string `json:"keyId"`
+	Name  string `json:"name"`
+	// Key is the hash of the api key. See HashKey.
+	Key       string `json:"key"`
+	Revoked   bool   `json:"revoked"`
+	CreatedAt int64  `json:"createdAt"`
+	// Ttl is the duration the key is valid for after it was created, such
+	// as 24h. Keys never expire if it is empty.
+	Ttl                    string   `json:"ttl"`
+	RateLimitOverTime      int      `json:"rateLimitOverTime"`
+	RateLimitUnit          TimeUnit `json:"rateLimitUnit"`
+	CostLimitInUsd         float64  `json:"costLimitInUsd"`
+	CostLimitInUsdOverTime float64  `json:"costLimitInUsdOverTime"`
+	CostLimitInUsdUnit     TimeUnit `json:"costLimitInUsdUnit"`
+	SettingId              string   `json:"settingId"`
+	SettingIds             []string `json:"settingIds"`
+	PolicyId               string   `json:"policyId"`
+	PolicyExempt           bool     `json:"policyExempt"`
+}
+
+// GetSettingIds returns the ids of the provider settings of the key.
+func (k *Key) GetSettingIds() []string {
+	return k.responseKey().GetSettingIds()
+}
+
+func (k *Key) responseKey() *key.ResponseKey {
+	return &key.ResponseKey{
+		KeyId:                  k.KeyId,
+		Name:                   k.Name,
+		Key:                    k.Key,
+		Revoked:                k.Revoked,
+		CreatedAt:              k.CreatedAt,
+		Ttl:                    k.Ttl,
+		RateLimitOverTime:      k.RateLimitOverTime,
+		RateLimitUnit:          key.TimeUnit(k.RateLimitUnit),
+		CostLimitInUsd:         k.CostLimitInUsd,
+		CostLimitInUsdOverTime: k.CostLimitInUsdOverTime,
+		CostLimitInUsdUnit:     key.TimeUnit(k.CostLimitInUsdUnit),
+		SettingId:              k.SettingId,
+		SettingIds:             k.SettingIds,
+		PolicyId:               k.PolicyId,
+		PolicyExempt:           k.PolicyExempt,
+	}
+}
+
+// TimeUnit is the period of rate and cost limits over time.
+type TimeUnit string
+
+const (
+	SecondTimeUnit TimeUnit = TimeUnit(key.SecondTimeUnit)
+	MinuteTimeUnit TimeUnit = TimeUnit(key.MinuteTimeUnit)
+	HourTimeUnit   TimeUnit = TimeUnit(key.HourTimeUnit)
+	DayTimeUnit    TimeUnit = TimeUnit(key.DayTimeUnit)
+	MonthTimeUnit  TimeUnit = TimeUnit(key.MonthTimeUnit)
+)
+
+// ProviderSetting holds the provider credentials requests of a key are
+// dispatched with.
+type ProviderSetting struct {
+	Id       string `json:"id"`
+	Provider string `json:"provider"`
+	// Setting holds the credentials of the provider, such as its apikey.
+	Setting       map[string]string `json:"setting"`
+	AllowedModels []string          `json:"allowedModels"`
+}
+
+// Policy configures the rules applied to requests of a key. Config is a
+// policy in the JSON format of the admin API, such as
+// {"config":{"rules":{"email":"allow_but_redact"}}}.
+type Policy struct {
+	Id     string          `json:"id"`
+	Config json.RawMessage `json:"config"`
+}
+
+// compile returns the policy enforced by the gateway.
+func (p *Policy) compile() (*policy.Policy, error) {
+	compiled := &policy.Policy{}
+	if len(p.Config) != 0 {
+		if err := json.Unmarshal(p.Config, compiled); err != nil {
+			return nil, fmt.Errorf("policy %s is not valid: %w", p.Id, err)
+		}
+	}
+
+	compiled.Id = p.Id
+	if err := compiled.Compile(); err != nil {
+		return nil, err
+	}
+
+	return compiled, nil
+}
+
+// Exemption is the outcome a policy would have enforced on a request.
+type Exemption struct {
+	PolicyId string   `json:"policyId"`
+	Action   string   `json:"action"`
+	Reason   string   `json:"reason,omitempty"`
+	Rules    []string `json:"rules"`
+}
+
+func newExemption(ex *policy.Exemption) *Exemption {
+	return &Exemption{
+		PolicyId: ex.PolicyId,
+		Action:   ex.Action,
+		Reason:   ex.Reason,
+		Rules:    ex.Rules,
+	}
+}